@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// AuditConfig berisi pengaturan antrean penulisan transaction_logs.
+type AuditConfig struct {
+	QueueSize      int           // Kapasitas antrean log dari AUDIT_QUEUE_SIZE
+	BatchSize      int           // Jumlah log maksimum per COPY dari AUDIT_BATCH_SIZE
+	FlushInterval  time.Duration // Jarak maksimum antar penulisan batch dari AUDIT_FLUSH_INTERVAL
+	EnqueueTimeout time.Duration // Lama request menunggu saat antrean penuh sebelum log dibuang dari AUDIT_ENQUEUE_TIMEOUT
+}
+
+// LoadAuditConfig membaca pengaturan antrean log transaksi dari environment variable.
+// Jika variabel kosong atau formatnya salah, nilai bawaan yang digunakan.
+func LoadAuditConfig() AuditConfig {
+	return AuditConfig{
+		QueueSize:      intFromEnv("AUDIT_QUEUE_SIZE", 10000),
+		BatchSize:      intFromEnv("AUDIT_BATCH_SIZE", 500),
+		FlushInterval:  durationFromEnv("AUDIT_FLUSH_INTERVAL", time.Second),
+		EnqueueTimeout: durationFromEnv("AUDIT_ENQUEUE_TIMEOUT", 50*time.Millisecond),
+	}
+}
+
+// intFromEnv membaca bilangan bulat positif dari environment variable key atau mengembalikan fallback.
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		slog.Warn("Environment variable tidak valid, menggunakan nilai bawaan", "key", key, "value", value, "default", fallback)
+		return fallback
+	}
+	return number
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// minJWTSecretLength adalah panjang secret JWT yang dianjurkan untuk HS256.
+const minJWTSecretLength = 32
+
+// AuthConfig berisi pengaturan penandatanganan token JWT.
+type AuthConfig struct {
+	JWTSecret      string        // Secret penandatangan token dari JWT_SECRET
+	AccessTokenTTL time.Duration // Masa berlaku token akses dari JWT_TIME_DURATION (dalam menit)
+}
+
+// LoadAuthConfig membaca pengaturan token dari environment variable.
+// JWT_TIME_DURATION berisi jumlah menit, bawaan 1440 (24 jam).
+func LoadAuthConfig() AuthConfig {
+	return AuthConfig{
+		JWTSecret:      os.Getenv("JWT_SECRET"),
+		AccessTokenTTL: time.Duration(intFromEnv("JWT_TIME_DURATION", 24*60)) * time.Minute,
+	}
+}
+
+// validate memastikan secret JWT terisi. Secret yang terlalu pendek hanya diberi peringatan.
+func (a AuthConfig) validate() []error {
+	if a.JWTSecret == "" {
+		return []error{errors.New("JWT_SECRET wajib diisi")}
+	}
+	if len(a.JWTSecret) < minJWTSecretLength {
+		slog.Warn("JWT_SECRET lebih pendek dari panjang yang dianjurkan", "min_length", minJWTSecretLength)
+	}
+	return nil
+}
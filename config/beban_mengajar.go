@@ -0,0 +1,27 @@
+package config
+
+import "fmt"
+
+// BebanMengajarConfig berisi batas jam mengajar per minggu untuk menandai guru
+// yang bebannya kurang atau berlebih pada laporan beban mengajar.
+type BebanMengajarConfig struct {
+	MinJam int // Batas bawah jam mengajar per minggu dari BEBAN_MENGAJAR_MIN_JAM
+	MaxJam int // Batas atas jam mengajar per minggu dari BEBAN_MENGAJAR_MAX_JAM
+}
+
+// LoadBebanMengajarConfig membaca batas beban mengajar dari environment variable.
+// Jika variabel kosong atau formatnya salah, nilai bawaan 24 dan 40 jam yang digunakan.
+func LoadBebanMengajarConfig() BebanMengajarConfig {
+	return BebanMengajarConfig{
+		MinJam: intFromEnv("BEBAN_MENGAJAR_MIN_JAM", 24),
+		MaxJam: intFromEnv("BEBAN_MENGAJAR_MAX_JAM", 40),
+	}
+}
+
+// validate memastikan batas bawah tidak melebihi batas atas.
+func (b BebanMengajarConfig) validate() []error {
+	if b.MinJam > b.MaxJam {
+		return []error{fmt.Errorf("BEBAN_MENGAJAR_MIN_JAM (%d) tidak boleh lebih besar dari BEBAN_MENGAJAR_MAX_JAM (%d)", b.MinJam, b.MaxJam)}
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -32,16 +34,156 @@ func LoadEnv() {
 	// Coba load file sesuai APP_ENV
 	if err := godotenv.Load(envFile); err != nil {
 		// Jika tidak menemukan file .env yang spesifik, maka mencoba .env default
-		log.Printf("[WARN] ❌ Tidak menemukan %s, mencoba .env default", envFile)
+		slog.Warn("Tidak menemukan file env, mencoba .env default", "file", envFile)
 
 		// fallback ke .env (global/default)
 		if err := godotenv.Load(".env"); err != nil {
 			// Jika tidak menemukan .env default, maka menggunakan environment bawaan OS
-			log.Printf("[WARN] ❌ Tidak menemukan .env default, menggunakan environment bawaan OS")
+			slog.Warn("Tidak menemukan .env default, menggunakan environment bawaan OS")
 		} else {
-			log.Printf("[INFO] ✅ Berhasil load .env default")
+			slog.Info("Berhasil load .env default")
 		}
 	} else {
-		log.Printf("[INFO] ✅ Berhasil load %s", envFile)
+		slog.Info("Berhasil load", "file", envFile)
 	}
 }
+
+// Config berisi seluruh konfigurasi aplikasi yang dibaca satu kali saat startup.
+// Gunakan Load untuk membuatnya, lalu teruskan bagian yang dibutuhkan ke router dan helper
+// alih-alih membaca environment variable di tempat lain.
+type Config struct {
+	Env       string          // Nama environment dari APP_ENV
+	Server    ServerConfig    // Pengaturan http.Server
+	Database  DatabaseConfig  // Pengaturan koneksi dan pool PostgreSQL
+	Auth      AuthConfig      // Pengaturan token JWT
+	Log       LogConfig       // Pengaturan logger
+	Audit     AuditConfig     // Pengaturan antrean transaction_logs
+	RateLimit RateLimitConfig // Batas request per client
+	// DeletePolicy berisi policy hapus bawaan untuk data yang masih dirujuk data lain
+	DeletePolicy DeletePolicyConfig
+	// Idempotency berisi lama penyimpanan response dan batas body request untuk header Idempotency-Key
+	Idempotency IdempotencyConfig
+	// BebanMengajar berisi batas jam mengajar per minggu untuk laporan beban mengajar guru
+	BebanMengajar BebanMengajarConfig
+	// Storage berisi driver dan batas ukuran penyimpanan file lampiran
+	Storage StorageConfig
+	// Kalender berisi hari sekolah untuk menghitung hari efektif kalender akademik
+	Kalender KalenderConfig
+}
+
+// LogConfig berisi pengaturan logger aplikasi.
+type LogConfig struct {
+	Level  string // Level log dari LOG_LEVEL (debug, info, warn, error)
+	Format string // Format log dari LOG_FORMAT (text atau json)
+}
+
+// RateLimitConfig berisi batas request dengan format "<jumlah>/<durasi>", misalnya "120/1m".
+// Nilai kosong berarti batas bawaan router yang digunakan.
+type RateLimitConfig struct {
+	Default string // Batas bawaan semua endpoint dari RATE_LIMIT_DEFAULT
+	Login   string // Batas endpoint /login dari RATE_LIMIT_LOGIN
+	Siswa   string // Batas endpoint /siswa dari RATE_LIMIT_SISWA
+}
+
+// DeletePolicyConfig berisi policy hapus bawaan (block atau cascade) saat client tidak mengirim ?policy=.
+// Policy reassign tidak bisa dijadikan bawaan karena membutuhkan target per request.
+type DeletePolicyConfig struct {
+	Kelas string // Policy hapus kelas dari DELETE_POLICY_KELAS
+	Guru  string // Policy hapus guru dari DELETE_POLICY_GURU
+}
+
+// Load memuat file env sesuai APP_ENV, membaca seluruh konfigurasi, lalu memvalidasinya.
+// Jika konfigurasi tidak valid, error berisi semua kesalahan yang ditemukan sekaligus.
+func Load() (Config, error) {
+	LoadEnv()
+
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "development"
+	}
+
+	cfg := Config{
+		Env:      env,
+		Server:   LoadServerConfig(),
+		Database: LoadDatabaseConfig(),
+		Auth:     LoadAuthConfig(),
+		Log: LogConfig{
+			Level:  stringFromEnv("LOG_LEVEL", "info"),
+			Format: stringFromEnv("LOG_FORMAT", "text"),
+		},
+		Audit: LoadAuditConfig(),
+		RateLimit: RateLimitConfig{
+			Default: os.Getenv("RATE_LIMIT_DEFAULT"),
+			Login:   os.Getenv("RATE_LIMIT_LOGIN"),
+			Siswa:   os.Getenv("RATE_LIMIT_SISWA"),
+		},
+		DeletePolicy: DeletePolicyConfig{
+			Kelas: strings.ToLower(stringFromEnv("DELETE_POLICY_KELAS", "block")),
+			Guru:  strings.ToLower(stringFromEnv("DELETE_POLICY_GURU", "block")),
+		},
+		Idempotency:   LoadIdempotencyConfig(),
+		BebanMengajar: LoadBebanMengajarConfig(),
+		Storage:       LoadStorageConfig(),
+		Kalender:      LoadKalenderConfig(),
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate memastikan konfigurasi wajib terisi dan nilainya masuk akal.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Server.Port == "" {
+		errs = append(errs, errors.New("PORT wajib diisi"))
+	}
+	errs = append(errs, c.Server.validate()...)
+	errs = append(errs, c.Database.validate()...)
+	errs = append(errs, c.Auth.validate()...)
+	errs = append(errs, c.Log.validate()...)
+	errs = append(errs, c.DeletePolicy.validate()...)
+	errs = append(errs, c.BebanMengajar.validate()...)
+	errs = append(errs, c.Storage.validate()...)
+	errs = append(errs, c.Kalender.validate()...)
+	return errors.Join(errs...)
+}
+
+// validate memastikan level dan format log dikenal.
+func (l LogConfig) validate() []error {
+	var errs []error
+	switch strings.ToLower(l.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL tidak dikenal: %q", l.Level))
+	}
+	switch strings.ToLower(l.Format) {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_FORMAT tidak dikenal: %q", l.Format))
+	}
+	return errs
+}
+
+// validate memastikan policy hapus bawaan hanya block atau cascade.
+func (d DeletePolicyConfig) validate() []error {
+	var errs []error
+	policies := []struct{ key, value string }{
+		{"DELETE_POLICY_KELAS", d.Kelas},
+		{"DELETE_POLICY_GURU", d.Guru},
+	}
+	for _, p := range policies {
+		if p.value != "block" && p.value != "cascade" {
+			errs = append(errs, fmt.Errorf("%s harus block atau cascade: %q", p.key, p.value))
+		}
+	}
+	return errs
+}
+
+// stringFromEnv membaca string dari environment variable key atau mengembalikan fallback jika kosong.
+func stringFromEnv(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -3,8 +3,11 @@ package config
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -12,45 +15,104 @@ import (
 
 var DBPool *pgxpool.Pool
 
+// sslModes adalah nilai sslmode yang didukung PostgreSQL.
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// DatabaseConfig berisi pengaturan koneksi dan pool PostgreSQL.
+type DatabaseConfig struct {
+	Host              string        // Host database dari DBHOST
+	Port              string        // Port database dari DBPORT
+	User              string        // User database dari DBUSER
+	Password          string        // Password database dari DBPASS
+	Name              string        // Nama database dari DBNAME
+	SSLMode           string        // Mode SSL dari DB_SSLMODE
+	TimeZone          string        // Zona waktu sesi database dari DB_TIMEZONE
+	MaxConns          int           // Jumlah koneksi maksimum dari DB_MAX_CONNS
+	MinConns          int           // Jumlah koneksi minimum dari DB_MIN_CONNS
+	MaxConnLifetime   time.Duration // Masa hidup koneksi maksimum dari DB_MAX_CONN_LIFETIME
+	HealthCheckPeriod time.Duration // Periode pengecekan kesehatan koneksi dari DB_HEALTH_CHECK_PERIOD
+	ConnectTimeout    time.Duration // Batas waktu membuat pool dan ping awal dari DB_CONNECT_TIMEOUT
+}
+
+// LoadDatabaseConfig membaca pengaturan database dari environment variable.
+// Jika variabel opsional kosong atau formatnya salah, nilai bawaan yang digunakan.
+func LoadDatabaseConfig() DatabaseConfig {
+	return DatabaseConfig{
+		Host:              os.Getenv("DBHOST"),
+		Port:              os.Getenv("DBPORT"),
+		User:              os.Getenv("DBUSER"),
+		Password:          os.Getenv("DBPASS"),
+		Name:              os.Getenv("DBNAME"),
+		SSLMode:           stringFromEnv("DB_SSLMODE", "disable"),
+		TimeZone:          stringFromEnv("DB_TIMEZONE", "Asia/Shanghai"),
+		MaxConns:          intFromEnv("DB_MAX_CONNS", 50),
+		MinConns:          intFromEnv("DB_MIN_CONNS", 10),
+		MaxConnLifetime:   durationFromEnv("DB_MAX_CONN_LIFETIME", 30*time.Minute),
+		HealthCheckPeriod: durationFromEnv("DB_HEALTH_CHECK_PERIOD", 2*time.Second),
+		ConnectTimeout:    durationFromEnv("DB_CONNECT_TIMEOUT", 5*time.Second),
+	}
+}
+
+// DSN menyusun connection string PostgreSQL. User dan password di-escape
+// sehingga karakter khusus pada password tidak merusak URL.
+func (d DatabaseConfig) DSN() string {
+	query := url.Values{}
+	query.Set("sslmode", d.SSLMode)
+	query.Set("timezone", d.TimeZone)
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.User, d.Password),
+		Host:     net.JoinHostPort(d.Host, d.Port),
+		Path:     "/" + d.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// validate memastikan variabel koneksi wajib terisi dan pengaturan pool konsisten.
+func (d DatabaseConfig) validate() []error {
+	var errs []error
+	required := []struct{ key, value string }{
+		{"DBHOST", d.Host}, {"DBPORT", d.Port}, {"DBUSER", d.User}, {"DBNAME", d.Name},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			errs = append(errs, fmt.Errorf("%s wajib diisi", r.key))
+		}
+	}
+	if !slices.Contains(sslModes, d.SSLMode) {
+		errs = append(errs, fmt.Errorf("DB_SSLMODE tidak dikenal: %q", d.SSLMode))
+	}
+	if _, err := time.LoadLocation(d.TimeZone); err != nil {
+		errs = append(errs, fmt.Errorf("DB_TIMEZONE tidak valid: %q", d.TimeZone))
+	}
+	if d.MinConns > d.MaxConns {
+		errs = append(errs, fmt.Errorf("DB_MIN_CONNS (%d) tidak boleh lebih besar dari DB_MAX_CONNS (%d)", d.MinConns, d.MaxConns))
+	}
+	return errs
+}
+
 // InitPostgreSQLPool digunakan untuk menginisialisasi pool koneksi ke database PostgreSQL.
 // Fungsi ini akan mengembalikan pointer ke objek pgxpool.Pool dan error.
 // Jika terjadi error maka akan mengembalikan error dengan pesan "Gagal koneksi ke database".
 // Fungsi ini juga akan mengisi variabel DBPool dengan pointer ke objek pgxpool.Pool yang diinisialisasi.
 // DBPool dapat digunakan untuk mengakses database tanpa harus membuat objek pgxpool.Pool lagi.
-func InitPostgreSQLPool() (*pgxpool.Pool, error) {
-	// // Memuat file .env
-	// err := godotenv.Load(".env")
-	// if err != nil {
-	// 	log.Fatalf("Gagal memuat file .env: %v", err)
-	// }
-	
-	// Load env sesuai APP_ENV (.env.development / .env.production / .env.testing)
-	LoadEnv()
-
-	// Mengambil environment variable
-	host := os.Getenv("DBHOST")
-	port := os.Getenv("DBPORT")
-	user := os.Getenv("DBUSER")
-	password := os.Getenv("DBPASS")
-	dbname := os.Getenv("DBNAME")
-
-	// Membuat string DSN untuk koneksi ke database PostgreSQL
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&timezone=Asia/Shanghai", user, password, host, port, dbname)
-
-	// Konfigurasi pool
-	config, err := pgxpool.ParseConfig(dsn)
+// Parameter cfg berasal dari Config yang sudah divalidasi saat startup.
+func InitPostgreSQLPool(cfg DatabaseConfig) (*pgxpool.Pool, error) {
+	// Parsing connection string
+	config, err := pgxpool.ParseConfig(cfg.DSN())
 	if err != nil {
-		log.Fatalf("Gagal parsing konfigurasi pool: %v", err)
+		return nil, fmt.Errorf("gagal parsing konfigurasi pool: %w", err)
 	}
 
 	// Konfigurasi pool
-	config.MaxConns = 500                      // Jumlah koneksi maksimum
-	config.MinConns = 10                       // Jumlah koneksi minimum
-	config.MaxConnLifetime = 5 * time.Second   // Masa hidup koneksi maksimum
-	config.HealthCheckPeriod = 2 * time.Second // Periode pengecekan kesehatan
+	config.MaxConns = int32(cfg.MaxConns)            // Jumlah koneksi maksimum
+	config.MinConns = int32(cfg.MinConns)            // Jumlah koneksi minimum
+	config.MaxConnLifetime = cfg.MaxConnLifetime     // Masa hidup koneksi maksimum
+	config.HealthCheckPeriod = cfg.HealthCheckPeriod // Periode pengecekan kesehatan
 
-	// Membuat context dengan timeout 5 detik
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Membuat context dengan timeout koneksi awal
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
 
 	// Membuat pool
@@ -68,7 +130,7 @@ func InitPostgreSQLPool() (*pgxpool.Pool, error) {
 	DBPool = pool
 
 	// Mencetak pesan informasi bahwa berhasil koneksi ke database PostgreSQL
-	log.Println("[INFO] Berhasil koneksi ke database PostgreSQL")
+	slog.Info("Berhasil koneksi ke database PostgreSQL")
 
 	// Mengembalikan pointer ke objek pgxpool.Pool yang diinisialisasi dan nilai error yang nil
 	return pool, nil
@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// IdempotencyConfig berisi pengaturan penyimpanan Idempotency-Key untuk endpoint create.
+type IdempotencyConfig struct {
+	TTL       time.Duration // Lama response disimpan dan diputar ulang untuk key yang sama dari IDEMPOTENCY_TTL
+	MaxBodyMB int           // Ukuran maksimum body request yang dibaca untuk dihitung hash-nya dalam MB dari IDEMPOTENCY_MAX_BODY_MB
+}
+
+// LoadIdempotencyConfig membaca pengaturan Idempotency-Key dari environment variable.
+// Jika variabel kosong atau formatnya salah, nilai bawaan 24 jam dan body paling besar 1 MB yang digunakan.
+func LoadIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		TTL:       durationFromEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+		MaxBodyMB: intFromEnv("IDEMPOTENCY_MAX_BODY_MB", 1),
+	}
+}
+
+// MaxBodyBytes mengembalikan batas ukuran body request dalam byte.
+func (i IdempotencyConfig) MaxBodyBytes() int64 {
+	return int64(i.MaxBodyMB) << 20
+}
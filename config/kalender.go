@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// namaHari memetakan nama hari dalam bahasa Indonesia ke time.Weekday.
+var namaHari = map[string]time.Weekday{
+	"minggu": time.Sunday,
+	"senin":  time.Monday,
+	"selasa": time.Tuesday,
+	"rabu":   time.Wednesday,
+	"kamis":  time.Thursday,
+	"jumat":  time.Friday,
+	"sabtu":  time.Saturday,
+}
+
+// KalenderConfig berisi pengaturan kalender akademik untuk menghitung hari efektif sekolah.
+type KalenderConfig struct {
+	HariSekolah string // Daftar hari sekolah dipisah koma dari HARI_SEKOLAH, misalnya "senin,selasa,rabu,kamis,jumat"
+}
+
+// LoadKalenderConfig membaca pengaturan kalender akademik dari environment variable.
+// Jika variabel kosong, hari sekolah bawaan Senin sampai Jumat yang digunakan.
+func LoadKalenderConfig() KalenderConfig {
+	return KalenderConfig{
+		HariSekolah: strings.ToLower(stringFromEnv("HARI_SEKOLAH", "senin,selasa,rabu,kamis,jumat")),
+	}
+}
+
+// Hari mengubah HariSekolah menjadi daftar time.Weekday tanpa duplikat.
+// Mengembalikan error jika ada nama hari yang tidak dikenal atau daftarnya kosong.
+func (k KalenderConfig) Hari() ([]time.Weekday, error) {
+	var hari []time.Weekday
+	sudah := map[time.Weekday]bool{}
+	for _, nama := range strings.Split(k.HariSekolah, ",") {
+		nama = strings.TrimSpace(nama)
+		if nama == "" {
+			continue
+		}
+		h, ok := namaHari[nama]
+		if !ok {
+			return nil, fmt.Errorf("HARI_SEKOLAH berisi hari yang tidak dikenal: %q", nama)
+		}
+		if !sudah[h] {
+			sudah[h] = true
+			hari = append(hari, h)
+		}
+	}
+	if len(hari) == 0 {
+		return nil, fmt.Errorf("HARI_SEKOLAH minimal berisi satu hari")
+	}
+	return hari, nil
+}
+
+// validate memastikan HARI_SEKOLAH hanya berisi nama hari yang dikenal.
+func (k KalenderConfig) validate() []error {
+	if _, err := k.Hari(); err != nil {
+		return []error{err}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+)
+
+// ServerConfig berisi pengaturan http.Server dan proses shutdown.
+type ServerConfig struct {
+	Port            string        // Port server dari PORT
+	ReadTimeout     time.Duration // Batas waktu membaca seluruh request dari SERVER_READ_TIMEOUT
+	WriteTimeout    time.Duration // Batas waktu menulis response dari SERVER_WRITE_TIMEOUT
+	IdleTimeout     time.Duration // Batas waktu koneksi keep-alive menganggur dari SERVER_IDLE_TIMEOUT
+	ShutdownTimeout time.Duration // Batas waktu menunggu request dan log selesai saat shutdown dari SERVER_SHUTDOWN_TIMEOUT
+	QueryTimeout    time.Duration // Batas waktu query database per request dari DB_QUERY_TIMEOUT
+	TrustedProxies  string        // Daftar IP atau CIDR reverse proxy tepercaya dipisah koma dari TRUSTED_PROXIES
+}
+
+// LoadServerConfig membaca pengaturan server dari environment variable.
+// Durasi memakai format time.ParseDuration (misalnya "15s" atau "1m").
+// Jika variabel kosong atau formatnya salah, nilai bawaan yang digunakan.
+func LoadServerConfig() ServerConfig {
+	return ServerConfig{
+		Port:            os.Getenv("PORT"),
+		ReadTimeout:     durationFromEnv("SERVER_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:    durationFromEnv("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:     durationFromEnv("SERVER_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout: durationFromEnv("SERVER_SHUTDOWN_TIMEOUT", 20*time.Second),
+		QueryTimeout:    durationFromEnv("DB_QUERY_TIMEOUT", 10*time.Second),
+		TrustedProxies:  os.Getenv("TRUSTED_PROXIES"),
+	}
+}
+
+// Proxies mengubah TrustedProxies menjadi daftar prefix. IP tunggal dianggap prefix /32 atau /128.
+// Daftar kosong berarti tidak ada proxy yang dipercaya sehingga header X-Forwarded-For selalu diabaikan.
+// Mengembalikan error jika ada alamat atau CIDR yang tidak valid.
+func (s ServerConfig) Proxies() ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, item := range strings.Split(s.TrustedProxies, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES berisi CIDR yang tidak valid: %q", item)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES berisi IP yang tidak valid: %q", item)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+// validate memastikan TRUSTED_PROXIES hanya berisi IP atau CIDR yang valid.
+func (s ServerConfig) validate() []error {
+	if _, err := s.Proxies(); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// durationFromEnv membaca durasi dari environment variable key atau mengembalikan fallback.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		slog.Warn("Environment variable tidak valid, menggunakan nilai bawaan", "key", key, "value", value, "default", fallback)
+		return fallback
+	}
+	return duration
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StorageConfig berisi pengaturan penyimpanan file lampiran (foto dan dokumen).
+type StorageConfig struct {
+	Driver        string // Jenis penyimpanan dari STORAGE_DRIVER: local atau s3
+	LocalDir      string // Folder penyimpanan driver local dari STORAGE_LOCAL_DIR
+	S3Endpoint    string // URL endpoint S3 atau layanan kompatibel (misalnya MinIO) dari S3_ENDPOINT
+	S3Region      string // Region bucket dari S3_REGION
+	S3Bucket      string // Nama bucket dari S3_BUCKET
+	S3AccessKey   string // Access key dari S3_ACCESS_KEY
+	S3SecretKey   string // Secret key dari S3_SECRET_KEY
+	MaxUploadMB   int    // Ukuran maksimum satu file dalam MB dari UPLOAD_MAX_SIZE_MB
+	ThumbnailSize int    // Sisi terpanjang thumbnail gambar dalam piksel dari THUMBNAIL_SIZE
+	MaxImagePixel int    // Batas lebar x tinggi gambar yang diunggah dari UPLOAD_MAX_IMAGE_PIXELS
+}
+
+// LoadStorageConfig membaca pengaturan penyimpanan file dari environment variable.
+// Jika variabel kosong, driver local di folder "uploads" dengan batas 5 MB, gambar paling besar 25 megapiksel,
+// dan thumbnail 256 piksel yang digunakan.
+func LoadStorageConfig() StorageConfig {
+	region := stringFromEnv("S3_REGION", "us-east-1")
+	return StorageConfig{
+		Driver:        strings.ToLower(stringFromEnv("STORAGE_DRIVER", "local")),
+		LocalDir:      stringFromEnv("STORAGE_LOCAL_DIR", "uploads"),
+		S3Endpoint:    stringFromEnv("S3_ENDPOINT", "https://s3."+region+".amazonaws.com"),
+		S3Region:      region,
+		S3Bucket:      os.Getenv("S3_BUCKET"),
+		S3AccessKey:   os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:   os.Getenv("S3_SECRET_KEY"),
+		MaxUploadMB:   intFromEnv("UPLOAD_MAX_SIZE_MB", 5),
+		ThumbnailSize: intFromEnv("THUMBNAIL_SIZE", 256),
+		MaxImagePixel: intFromEnv("UPLOAD_MAX_IMAGE_PIXELS", 25_000_000),
+	}
+}
+
+// MaxUploadBytes mengembalikan batas ukuran satu file dalam byte.
+func (s StorageConfig) MaxUploadBytes() int64 {
+	return int64(s.MaxUploadMB) << 20
+}
+
+// validate memastikan driver dikenal dan pengaturan S3 lengkap jika driver s3 dipakai.
+func (s StorageConfig) validate() []error {
+	var errs []error
+	switch s.Driver {
+	case "local":
+	case "s3":
+		required := []struct{ key, value string }{
+			{"S3_BUCKET", s.S3Bucket},
+			{"S3_ACCESS_KEY", s.S3AccessKey},
+			{"S3_SECRET_KEY", s.S3SecretKey},
+		}
+		for _, r := range required {
+			if r.value == "" {
+				errs = append(errs, fmt.Errorf("%s wajib diisi jika STORAGE_DRIVER=s3", r.key))
+			}
+		}
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER harus local atau s3: %q", s.Driver))
+	}
+	return errs
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"go_rest_native_sekolah/features/auth"
 	"go_rest_native_sekolah/helper"
-	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // AuthController merepresentasikan controller untuk autentikasi.
@@ -29,6 +31,7 @@ func NewAutController(authService auth.ServiceAuthInterface) *AuthController {
 
 // Auth melakukan login user berdasarkan input yang diterima.
 // Fungsi ini akan mengembalikan token dan data user jika login berhasil.
+// Jika user mengaktifkan 2FA, yang dikembalikan adalah token challenge berumur pendek.
 // Jika login gagal maka akan terjadi error.
 func (lc *AuthController) Auth(w http.ResponseWriter, r *http.Request) error {
 	if lc.authService == nil {
@@ -37,27 +40,190 @@ func (lc *AuthController) Auth(w http.ResponseWriter, r *http.Request) error {
 
 	var inputLogin LoginRequest // Input yang diterima dari request body
 	if err := json.NewDecoder(r.Body).Decode(&inputLogin); err != nil {
-		log.Printf("Error decoding JSON: %v", err)
+		helper.LoggerFromContext(r.Context()).Error("Error decoding JSON", "error", err)
 		http.Error(w, "Data tidak valid", http.StatusBadRequest)
 		return fmt.Errorf("auth controller: error decoding request: %v", err)
 	}
 
-	log.Printf("Attempting to log in user with email: %s", inputLogin.Email)
-	login, err := lc.authService.Login(inputLogin.Email, inputLogin.Password) // Melakukan login
+	login, err := lc.authService.Login(r.Context(), inputLogin.Email, inputLogin.Password, helper.GetClientIP(r)) // Melakukan login
 	if err != nil {
-		log.Printf("Login error for %s: %v", inputLogin.Email, err)
-		if strings.Contains(err.Error(), "invalid Password") {
-			http.Error(w, "Email atau password salah", http.StatusUnauthorized)
-		} else {
-			http.Error(w, "Terjadi kesalahan saat login", http.StatusInternalServerError)
+		return writeLoginError(w, r, err)
+	}
+	helper.SetAuditUserID(r.Context(), login.ID)
+
+	// Jika 2FA aktif, token akses belum diberikan. Client harus mengirim kode 2FA
+	// bersama token challenge ke endpoint /login/2fa.
+	if login.TOTP_Enabled {
+		challenge, expTime, err := helper.SignChallengeToken(login.ID)
+		if err != nil {
+			helper.LoggerFromContext(r.Context()).Error("Challenge token generation failed", "error", err)
+			http.Error(w, "Gagal membuat token", http.StatusInternalServerError)
+			return err
+		}
+
+		response := ResponseTwoFactorChallenge{
+			Two_Factor_Required: true,
+			Challenge_Token:     challenge,
+			Expiration:          expTime,
 		}
+		helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "verifikasi 2FA diperlukan", response))
+		return nil
+	}
+
+	return writeAccessToken(w, r, login)
+}
+
+// VerifyTwoFactor adalah langkah kedua login untuk user yang mengaktifkan 2FA.
+// Request body berisi challenge_token dari /login dan code berupa kode TOTP atau kode pemulihan.
+// Jika kode benar maka token akses diberikan seperti login biasa.
+func (lc *AuthController) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	if lc.authService == nil {
+		return errors.New("auth controller: Nil service")
+	}
+
+	var input TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Data tidak valid", http.StatusBadRequest)
+		return fmt.Errorf("auth controller: error decoding request: %v", err)
+	}
+
+	userID, err := helper.VerifyChallengeToken(input.Challenge_Token)
+	if err != nil {
+		helper.JSONResponse(w, http.StatusUnauthorized, helper.APIResponse(http.StatusUnauthorized, "Token challenge tidak valid atau kedaluwarsa", nil))
+		return nil
+	}
+	helper.SetAuditUserID(r.Context(), userID)
+
+	login, err := lc.authService.VerifyTwoFactor(r.Context(), userID, input.Code, helper.GetClientIP(r))
+	if err != nil {
+		return writeLoginError(w, r, err)
+	}
+
+	return writeAccessToken(w, r, login)
+}
+
+// EnrollTOTP memulai pendaftaran 2FA untuk user yang sedang login.
+// Response berisi secret dan URI otpauth yang bisa ditampilkan sebagai QR code.
+func (lc *AuthController) EnrollTOTP(w http.ResponseWriter, r *http.Request) error {
+	if lc.authService == nil {
+		return errors.New("auth controller: Nil service")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	enrollment, err := lc.authService.EnrollTOTP(r.Context(), meta.ID)
+	if err != nil {
+		if strings.Contains(err.Error(), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		return fmt.Errorf("auth controller: gagal mendaftarkan 2FA: %v", err)
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Pindai QR code lalu konfirmasi dengan kode dari aplikasi authenticator", FormatTOTPEnrollment(enrollment))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("auth controller: error encoding response: %v", err)
+	}
+	return nil
+}
+
+// ConfirmTOTP mengaktifkan 2FA dengan kode pertama dari aplikasi authenticator.
+// Response berisi kode pemulihan yang hanya ditampilkan sekali.
+func (lc *AuthController) ConfirmTOTP(w http.ResponseWriter, r *http.Request) error {
+	if lc.authService == nil {
+		return errors.New("auth controller: Nil service")
+	}
+
+	var input TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Data tidak valid", http.StatusBadRequest)
+		return fmt.Errorf("auth controller: error decoding request: %v", err)
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	codes, err := lc.authService.ConfirmTOTP(r.Context(), meta.ID, input.Code)
+	if err != nil {
+		return writeTOTPError(w, err, "mengaktifkan")
+	}
+
+	response := helper.APIResponse(http.StatusOK, "2FA berhasil diaktifkan, simpan kode pemulihan di tempat aman", RecoveryCodesResponse{Recovery_Codes: codes})
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("auth controller: error encoding response: %v", err)
+	}
+	return nil
+}
+
+// DisableTOTP menonaktifkan 2FA untuk user yang sedang login.
+// Request body berisi kode TOTP atau kode pemulihan sebagai konfirmasi.
+func (lc *AuthController) DisableTOTP(w http.ResponseWriter, r *http.Request) error {
+	if lc.authService == nil {
+		return errors.New("auth controller: Nil service")
+	}
+
+	var input TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Data tidak valid", http.StatusBadRequest)
+		return fmt.Errorf("auth controller: error decoding request: %v", err)
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := lc.authService.DisableTOTP(r.Context(), meta.ID, input.Code); err != nil {
+		return writeTOTPError(w, err, "menonaktifkan")
+	}
+
+	response := helper.APIResponse(http.StatusOK, "2FA berhasil dinonaktifkan", nil)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("auth controller: error encoding response: %v", err)
+	}
+	return nil
+}
+
+// writeLoginError menulis response untuk error login dan verifikasi 2FA.
+// Semua kegagalan kredensial dijawab dengan pesan yang sama agar tidak
+// membocorkan apakah email terdaftar atau bagian mana yang salah.
+func writeLoginError(w http.ResponseWriter, r *http.Request, err error) error {
+	var lockedErr *auth.LockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		helper.JSONResponse(w, http.StatusTooManyRequests, helper.APIResponse(http.StatusTooManyRequests, "Terlalu banyak percobaan login, coba lagi nanti", nil))
+		return nil
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		helper.JSONResponse(w, http.StatusUnauthorized, helper.APIResponse(http.StatusUnauthorized, "Email atau password salah", nil))
+		return nil
+	case errors.Is(err, auth.ErrInvalidTOTPCode):
+		helper.JSONResponse(w, http.StatusUnauthorized, helper.APIResponse(http.StatusUnauthorized, "Kode verifikasi salah", nil))
+		return nil
+	default:
+		helper.LoggerFromContext(r.Context()).Error("Login error", "error", err)
+		http.Error(w, "Terjadi kesalahan saat login", http.StatusInternalServerError)
 		return err
 	}
+}
+
+// writeTOTPError menulis response untuk error saat mengaktifkan atau menonaktifkan 2FA.
+func writeTOTPError(w http.ResponseWriter, err error, action string) error {
+	if errors.Is(err, auth.ErrInvalidTOTPCode) {
+		helper.JSONResponse(w, http.StatusBadRequest, helper.APIResponse(http.StatusBadRequest, "Kode verifikasi salah", nil))
+		return nil
+	}
+	if strings.Contains(err.Error(), "validation") {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	return fmt.Errorf("auth controller: gagal %s 2FA: %v", action, err)
+}
 
-	data := map[string]interface{}{"id": login.ID} // Data untuk membuat token
-	token, expTime, err := helper.SignToken(data)  // Membuat token berdasarkan data
+// writeAccessToken membuat token akses untuk user yang sudah terautentikasi
+// lalu mengirimkannya sebagai response login.
+func writeAccessToken(w http.ResponseWriter, r *http.Request, login auth.UserCore) error {
+	data := map[string]interface{}{"id": login.ID, "role": login.Role} // Data untuk membuat token
+	token, expTime, err := helper.SignToken(data)                      // Membuat token berdasarkan data
 	if err != nil {
-		log.Printf("Token generation failed: %v", err)
+		helper.LoggerFromContext(r.Context()).Error("Token generation failed", "error", err)
 		http.Error(w, "Gagal membuat token", http.StatusInternalServerError)
 		return err
 	}
@@ -80,11 +246,61 @@ func (lc *AuthController) Auth(w http.ResponseWriter, r *http.Request) error {
 	// Membuat response yang berisi kode status, message, dan data
 	apiResponse := helper.APIResponse(http.StatusOK, "success login", response) // Membuat response
 	if err := json.NewEncoder(w).Encode(apiResponse); err != nil {              // Mengencode response
-		log.Printf("Error encoding response: %v", err)
+		helper.LoggerFromContext(r.Context()).Error("Error encoding response", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError) // Mengirimkan response error
 		return err
 	}
 
-	log.Printf("Login successful for user with email: %s", inputLogin.Email)
+	helper.LoggerFromContext(r.Context()).Info("Login successful", "user_id", login.ID)
 	return nil // Mengembalikan nil karena login berhasil
 }
+
+// Lockouts menampilkan daftar email dan IP yang memiliki kegagalan login atau sedang dikunci.
+// Endpoint ini hanya untuk admin.
+func (lc *AuthController) Lockouts(w http.ResponseWriter, r *http.Request) error {
+	if lc.authService == nil {
+		return errors.New("auth controller: Nil service")
+	}
+
+	attempts, err := lc.authService.SelectLockouts(r.Context())
+	if err != nil {
+		return fmt.Errorf("auth controller: gagal mengambil data penguncian: %v", err)
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data penguncian login", FormatLockoutList(attempts, time.Now()))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("auth controller: error encoding response: %v", err)
+	}
+	return nil
+}
+
+// ClearLockout melepas penguncian login untuk satu email atau IP.
+// Parameter query: scope (email atau ip) dan identifier. Endpoint ini hanya untuk admin.
+func (lc *AuthController) ClearLockout(w http.ResponseWriter, r *http.Request) error {
+	if lc.authService == nil {
+		return errors.New("auth controller: Nil service")
+	}
+
+	scope := r.URL.Query().Get("scope")
+	identifier := r.URL.Query().Get("identifier")
+	if scope == "" || identifier == "" {
+		http.Error(w, "parameter 'scope' dan 'identifier' wajib diisi", http.StatusBadRequest)
+		return errors.New("missing 'scope' or 'identifier' query parameter")
+	}
+
+	if err := lc.authService.ClearLockout(r.Context(), scope, identifier); err != nil {
+		if strings.Contains(err.Error(), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		return fmt.Errorf("auth controller: gagal melepas penguncian: %v", err)
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil melepas penguncian login", nil)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("auth controller: error encoding response: %v", err)
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package controllers
 
-import "time"
+import (
+	"go_rest_native_sekolah/features/auth"
+	"time"
+)
 
 type (
 	// ResponseAuth digunakan untuk merepresentasikan respons setelah login berhasil.
@@ -19,4 +22,70 @@ type (
 		Email    string `json:"email"`    // Email pengguna untuk proses login
 		Password string `json:"password"` // Password pengguna untuk proses login
 	}
+
+	// ResponseTwoFactorChallenge digunakan sebagai respons login untuk user yang mengaktifkan 2FA.
+	// Token challenge hanya bisa ditukar dengan token akses di endpoint /login/2fa.
+	ResponseTwoFactorChallenge struct {
+		Two_Factor_Required bool      `json:"two_factor_required"` // Selalu true, penanda langkah kedua diperlukan
+		Challenge_Token     string    `json:"challenge_token"`     // Token sementara untuk verifikasi 2FA
+		Expiration          time.Time `json:"expiration"`          // Waktu kedaluwarsa token challenge
+	}
+
+	// TwoFactorLoginRequest digunakan untuk merepresentasikan permintaan verifikasi 2FA saat login.
+	TwoFactorLoginRequest struct {
+		Challenge_Token string `json:"challenge_token"` // Token challenge dari respons /login
+		Code            string `json:"code"`            // Kode TOTP atau kode pemulihan
+	}
+
+	// TOTPCodeRequest digunakan untuk merepresentasikan permintaan yang hanya berisi kode 2FA.
+	TOTPCodeRequest struct {
+		Code string `json:"code"` // Kode TOTP atau kode pemulihan
+	}
+
+	// TOTPEnrollResponse digunakan sebagai respons saat memulai pendaftaran 2FA.
+	TOTPEnrollResponse struct {
+		Secret string `json:"secret"`      // Secret base32 untuk dimasukkan manual
+		URI    string `json:"otpauth_uri"` // URI otpauth:// untuk dibuat QR code
+	}
+
+	// RecoveryCodesResponse digunakan sebagai respons saat 2FA diaktifkan.
+	RecoveryCodesResponse struct {
+		Recovery_Codes []string `json:"recovery_codes"` // Kode pemulihan, hanya ditampilkan sekali
+	}
+
+	// LockoutResponse digunakan untuk merepresentasikan status penguncian login satu identitas.
+	// Struktur ini berisi scope (email atau ip), identifier, jumlah kegagalan, dan waktu penguncian.
+	LockoutResponse struct {
+		Scope          string     `json:"scope"`          // Scope penguncian (email atau ip)
+		Identifier     string     `json:"identifier"`     // Email atau alamat IP
+		Failed_Count   int        `json:"failed_count"`   // Jumlah kegagalan dalam window
+		Last_Failed_At *time.Time `json:"last_failed_at"` // Waktu kegagalan terakhir
+		Locked_Until   *time.Time `json:"locked_until"`   // Waktu sampai boleh mencoba lagi
+		Locked         bool       `json:"locked"`         // Apakah identitas masih dikunci saat ini
+	}
 )
+
+// FormatLockoutList digunakan untuk mengubah slice LoginAttemptCore menjadi slice LockoutResponse.
+// Parameter now digunakan untuk menentukan apakah identitas masih dikunci.
+func FormatLockoutList(cores []auth.LoginAttemptCore, now time.Time) []LockoutResponse {
+	formatted := make([]LockoutResponse, 0)
+	for _, core := range cores {
+		formatted = append(formatted, LockoutResponse{
+			Scope:          core.Scope,
+			Identifier:     core.Identifier,
+			Failed_Count:   core.Failed_Count,
+			Last_Failed_At: core.Last_Failed_At,
+			Locked_Until:   core.Locked_Until,
+			Locked:         core.Locked_Until != nil && core.Locked_Until.After(now),
+		})
+	}
+	return formatted
+}
+
+// FormatTOTPEnrollment digunakan untuk mengubah TOTPEnrollment menjadi TOTPEnrollResponse.
+func FormatTOTPEnrollment(core auth.TOTPEnrollment) TOTPEnrollResponse {
+	return TOTPEnrollResponse{
+		Secret: core.Secret,
+		URI:    core.URI,
+	}
+}
@@ -1,6 +1,46 @@
 package auth
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Scope percobaan login yang dilacak.
+// ScopeEmail melacak kegagalan per akun (email), ScopeIP melacak kegagalan per alamat IP client.
+const (
+	ScopeEmail = "email"
+	ScopeIP    = "ip"
+)
+
+// ErrInvalidCredentials dikembalikan untuk semua kegagalan login akibat kredensial,
+// baik email tidak terdaftar maupun password salah, agar client tidak bisa membedakannya.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrInvalidTOTPCode dikembalikan ketika kode 2FA atau kode pemulihan salah, kedaluwarsa, atau sudah dipakai.
+var ErrInvalidTOTPCode = errors.New("invalid two-factor code")
+
+// TOTPIssuer adalah nama penerbit yang tampil di aplikasi authenticator.
+const TOTPIssuer = "Sistem Informasi Sekolah"
+
+// RecoveryCodeCount adalah jumlah kode pemulihan yang dibuat saat 2FA diaktifkan.
+const RecoveryCodeCount = 10
+
+// TwoFactorRoles adalah role yang boleh mengaktifkan 2FA.
+var TwoFactorRoles = []string{"admin", "guru"}
+
+// LockedError dikembalikan ketika akun atau IP sedang dikunci sementara
+// karena terlalu banyak percobaan login yang gagal.
+type LockedError struct {
+	Scope      string        // Scope yang terkunci (email atau ip)
+	RetryAfter time.Duration // Sisa waktu sampai percobaan berikutnya diizinkan
+}
+
+// Error implements error.
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("login locked (%s), retry after %s", e.Scope, e.RetryAfter.Round(time.Second))
+}
 
 type (
 	// UserCore merepresentasikan data user di database.
@@ -12,14 +52,55 @@ type (
 	// 5. Role (string) sebagai peran user
 	// 6. Update_At (time.Time) sebagai waktu update data user
 	// 7. Delete_At (*time.Time) sebagai waktu delete data user
+	// 8. TOTP_Enabled (bool) sebagai penanda user wajib verifikasi 2FA saat login
 	UserCore struct {
-		ID        string     `json:"id"`        // ID data user
-		Username  string     `json:"username"`  // Nama pengguna
-		Email     string     `json:"email"`     // Email user
-		Password  string     `json:"password"`  // Password user
-		Role      string     `json:"role"`      // Peran user
-		Update_At time.Time  `json:"update_at"` // Waktu update data user
-		Delete_At *time.Time `json:"delete_at"` // Waktu delete data user
+		ID           string     `json:"id"`           // ID data user
+		Username     string     `json:"username"`     // Nama pengguna
+		Email        string     `json:"email"`        // Email user
+		Password     string     `json:"password"`     // Password user
+		Role         string     `json:"role"`         // Peran user
+		Update_At    time.Time  `json:"update_at"`    // Waktu update data user
+		Delete_At    *time.Time `json:"delete_at"`    // Waktu delete data user
+		TOTP_Enabled bool       `json:"totp_enabled"` // Apakah 2FA aktif untuk user
+	}
+
+	// TOTPCore merepresentasikan konfigurasi 2FA (TOTP) milik satu user di tabel user_totp.
+	// Secret tersimpan sejak enrolment, tetapi 2FA baru berlaku setelah Enabled bernilai true.
+	TOTPCore struct {
+		User_ID        string     `json:"user_id"`        // ID user pemilik 2FA
+		Secret         string     `json:"secret"`         // Secret TOTP dalam format base32
+		Enabled        bool       `json:"enabled"`        // Apakah 2FA sudah dikonfirmasi dan aktif
+		Last_Used_Step int64      `json:"last_used_step"` // Langkah waktu kode terakhir yang dipakai, untuk mencegah replay
+		Confirmed_At   *time.Time `json:"confirmed_at"`   // Waktu 2FA dikonfirmasi
+	}
+
+	// TOTPEnrollment berisi data yang dikirim ke user saat memulai enrolment 2FA.
+	TOTPEnrollment struct {
+		Secret string `json:"secret"` // Secret base32 untuk dimasukkan manual
+		URI    string `json:"uri"`    // URI otpauth:// untuk dibuat QR code
+	}
+
+	// LoginAttemptCore merepresentasikan catatan percobaan login yang gagal
+	// untuk satu identitas (email atau IP) di tabel login_attempts.
+	LoginAttemptCore struct {
+		Scope          string     `json:"scope"`          // Scope percobaan (email atau ip)
+		Identifier     string     `json:"identifier"`     // Nilai email atau alamat IP
+		Failed_Count   int        `json:"failed_count"`   // Jumlah kegagalan berturut-turut dalam window
+		Last_Failed_At *time.Time `json:"last_failed_at"` // Waktu kegagalan terakhir
+		Locked_Until   *time.Time `json:"locked_until"`   // Waktu sampai identitas ini boleh mencoba lagi
+	}
+
+	// LockoutPolicy mengatur perlambatan progresif dan penguncian sementara untuk login.
+	// Setelah DelayAfter kegagalan, percobaan berikutnya ditunda BaseDelay yang berlipat dua
+	// setiap kegagalan. Setelah batas maksimum per akun atau per IP tercapai, identitas
+	// dikunci selama LockoutDuration. Hitungan kegagalan di-reset setelah Window berlalu.
+	LockoutPolicy struct {
+		DelayAfter         int           // Jumlah kegagalan sebelum penundaan mulai berlaku
+		BaseDelay          time.Duration // Penundaan awal yang berlipat dua setiap kegagalan
+		MaxAccountAttempts int           // Batas kegagalan per akun sebelum dikunci
+		MaxIPAttempts      int           // Batas kegagalan per IP sebelum dikunci
+		LockoutDuration    time.Duration // Lama penguncian sementara
+		Window             time.Duration // Rentang waktu penghitungan kegagalan
 	}
 
 	// DataAuthInterface merepresentasikan interface untuk data auth.
@@ -27,16 +108,92 @@ type (
 	DataAuthInterface interface {
 		// Login melakukan login user berdasarkan input email dan password.
 		// Fungsi ini akan mengembalikan nilai UserCore yang berisi data user jika login berhasil,
-		// atau error jika login gagal.
-		Login(email, password string) (dataLogin UserCore, err error)
+		// atau ErrInvalidCredentials jika email atau password salah.
+		Login(ctx context.Context, email, password string) (dataLogin UserCore, err error)
+		// SelectLoginAttempt mengambil catatan percobaan login untuk scope dan identifier.
+		// Jika belum ada catatan, dikembalikan LoginAttemptCore kosong tanpa error.
+		SelectLoginAttempt(ctx context.Context, scope, identifier string) (LoginAttemptCore, error)
+		// IncrementLoginFailure menambah hitungan kegagalan dan mengembalikan hitungan terbaru.
+		// Hitungan dimulai dari awal jika kegagalan terakhir lebih lama dari window.
+		IncrementLoginFailure(ctx context.Context, scope, identifier string, window time.Duration) (int, error)
+		// SetLockedUntil menyimpan waktu sampai identitas boleh mencoba login lagi.
+		SetLockedUntil(ctx context.Context, scope, identifier string, until time.Time) error
+		// SelectAllLoginAttempts mengambil semua identitas yang memiliki kegagalan login.
+		SelectAllLoginAttempts(ctx context.Context) ([]LoginAttemptCore, error)
+		// DeleteLoginAttempt menghapus catatan percobaan login sehingga kunci dilepas.
+		DeleteLoginAttempt(ctx context.Context, scope, identifier string) error
+		// SelectUserById mengambil data user aktif beserta status 2FA berdasarkan ID.
+		SelectUserById(ctx context.Context, id string) (UserCore, error)
+		// SelectTOTP mengambil konfigurasi 2FA user. Jika belum ada, dikembalikan TOTPCore kosong tanpa error.
+		SelectTOTP(ctx context.Context, userID string) (TOTPCore, error)
+		// SaveTOTPSecret menyimpan secret baru yang belum aktif dan menghapus kode pemulihan lama.
+		SaveTOTPSecret(ctx context.Context, userID, secret string) error
+		// EnableTOTP mengaktifkan 2FA dan menyimpan hash kode pemulihan.
+		EnableTOTP(ctx context.Context, userID string, recoveryHashes []string, step int64) error
+		// DisableTOTP menghapus konfigurasi 2FA user.
+		DisableTOTP(ctx context.Context, userID string) error
+		// UpdateTOTPStep menyimpan langkah waktu kode yang baru dipakai.
+		// Mengembalikan false jika langkah tersebut tidak lebih baru dari yang tersimpan (replay).
+		UpdateTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+		// ConsumeRecoveryCode menghapus satu hash kode pemulihan. Mengembalikan false jika tidak ditemukan.
+		ConsumeRecoveryCode(ctx context.Context, userID, hash string) (bool, error)
 	}
 
 	// ServiceAuthInterface merepresentasikan interface untuk service auth.
 	// Interface ini digunakan untuk menghandle logika bisnis yang berhubungan dengan autentikasi.
 	ServiceAuthInterface interface {
-		// Login melakukan login user berdasarkan input email dan password.
+		// Login melakukan login user berdasarkan input email, password, dan IP client.
 		// Fungsi ini akan mengembalikan nilai UserCore yang berisi data user jika login berhasil,
-		// atau error jika login gagal.
-		Login(email, password string) (dataLogin UserCore, err error)
+		// ErrInvalidCredentials jika kredensial salah, atau *LockedError jika sedang dikunci.
+		Login(ctx context.Context, email, password, ip string) (dataLogin UserCore, err error)
+		// SelectLockouts mengambil daftar identitas yang memiliki kegagalan login atau sedang dikunci.
+		SelectLockouts(ctx context.Context) ([]LoginAttemptCore, error)
+		// ClearLockout melepas kunci dan me-reset hitungan kegagalan untuk satu identitas.
+		ClearLockout(ctx context.Context, scope, identifier string) error
+		// VerifyTwoFactor memverifikasi kode TOTP atau kode pemulihan pada langkah kedua login.
+		VerifyTwoFactor(ctx context.Context, userID, code, ip string) (UserCore, error)
+		// EnrollTOTP membuat secret 2FA baru untuk user dan mengembalikan URI otpauth.
+		EnrollTOTP(ctx context.Context, userID string) (TOTPEnrollment, error)
+		// ConfirmTOTP mengaktifkan 2FA setelah kode pertama benar dan mengembalikan kode pemulihan.
+		ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error)
+		// DisableTOTP menonaktifkan 2FA setelah kode TOTP atau kode pemulihan diverifikasi.
+		DisableTOTP(ctx context.Context, userID, code string) error
 	}
 )
+
+// DefaultLockoutPolicy mengembalikan kebijakan penguncian bawaan.
+// Batas per IP dibuat lebih longgar karena banyak client sekolah berbagi satu IP publik.
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		DelayAfter:         3,
+		BaseDelay:          2 * time.Second,
+		MaxAccountAttempts: 5,
+		MaxIPAttempts:      20,
+		LockoutDuration:    15 * time.Minute,
+		Window:             15 * time.Minute,
+	}
+}
+
+// LockDuration menghitung lama penundaan setelah kegagalan ke-count untuk scope tertentu.
+// Nilai nol berarti percobaan berikutnya boleh langsung dilakukan.
+func (p LockoutPolicy) LockDuration(scope string, count int) time.Duration {
+	limit := p.MaxAccountAttempts
+	if scope == ScopeIP {
+		limit = p.MaxIPAttempts
+	}
+	if limit > 0 && count >= limit {
+		return p.LockoutDuration
+	}
+	if p.DelayAfter <= 0 || count < p.DelayAfter {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.DelayAfter; i < count; i++ {
+		delay *= 2
+		if delay >= p.LockoutDuration {
+			return p.LockoutDuration
+		}
+	}
+	return delay
+}
@@ -1,7 +1,7 @@
 package model
 
 import (
-	"go_rest_native_sekolah/features/users"
+	"go_rest_native_sekolah/features/auth"
 )
 
 // User merepresentasikan data user di database
@@ -11,12 +11,14 @@ import (
 // 3. Email (string) sebagai alamat email user
 // 4. Password (string) sebagai password user
 // 5. Role (string) sebagai peran user
+// 6. TOTP_Enabled (bool) sebagai penanda 2FA aktif
 type User struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	Password     string `json:"password"`
+	Role         string `json:"role"`
+	TOTP_Enabled bool   `json:"totp_enabled"`
 }
 
 // TableName digunakan untuk mengembalikan nama tabel yang digunakan
@@ -28,7 +30,7 @@ func (u *User) TableName() string {
 
 // FormatterRequest digunakan untuk mengubah objek UserCore menjadi objek User
 // Fungsi ini digunakan untuk memformat data user agar sesuai dengan kebutuhan database
-func FormatterRequest(req users.UserCore) User {
+func FormatterRequest(req auth.UserCore) User {
 	return User{
 		Email:    req.Email,
 		Password: req.Password,
@@ -38,12 +40,13 @@ func FormatterRequest(req users.UserCore) User {
 
 // FormatterResponse digunakan untuk mengubah objek User menjadi objek UserCore
 // Fungsi ini digunakan untuk memformat data user agar sesuai dengan kebutuhan response API
-func FormatterResponse(res User) users.UserCore {
-	return users.UserCore{
-		ID:       res.ID,
-		Username: res.Username,
-		Email:    res.Email,
-		Password: res.Password,
-		Role:     res.Role,
+func FormatterResponse(res User) auth.UserCore {
+	return auth.UserCore{
+		ID:           res.ID,
+		Username:     res.Username,
+		Email:        res.Email,
+		Password:     res.Password,
+		Role:         res.Role,
+		TOTP_Enabled: res.TOTP_Enabled,
 	}
 }
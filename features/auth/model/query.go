@@ -3,24 +3,29 @@ package model
 import (
 	"context"
 	"errors"
+	"fmt"
 	"go_rest_native_sekolah/features/auth"
 	"go_rest_native_sekolah/helper"
-	"log"
+	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// dummyPasswordHash adalah hash bcrypt yang dibandingkan ketika email tidak ditemukan,
+// agar waktu proses login tidak membocorkan apakah email terdaftar.
+const dummyPasswordHash = "$2a$10$hAL5DmxcBWDCjQyD08keGebCkL9xkQeRH/DzLMvhBAHNTdJ/zavQ."
+
 // AuthQuery merepresentasikan query yang berhubungan dengan autentikasi.
 // Struct ini menggunakan database PostgreSQL untuk menghandle query ke database.
 type AuthQuery struct {
-	DB *pgxpool.Pool
+	DB helper.DBTX
 }
 
 // NewAuthData membuat objek AuthQuery dengan parameter db.
 // Fungsi ini akan mengembalikan nilai AuthQuery yang siap digunakan.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
 // Jika parameter db nil maka akan terjadi panic.
-func NewAuthData(db *pgxpool.Pool) auth.DataAuthInterface {
+func NewAuthData(db helper.DBTX) auth.DataAuthInterface {
 	if db == nil {
 		// Jika db nil maka akan terjadi panic
 		panic("NewAuthData: db is nil")
@@ -32,47 +37,286 @@ func NewAuthData(db *pgxpool.Pool) auth.DataAuthInterface {
 // Login implements auth.DataAuthInterface.
 // Fungsi ini digunakan untuk melakukan login dengan input email dan password.
 // Fungsi ini akan mengembalikan nilai UserCore yang berisi data user jika login berhasil,
-// atau error jika login gagal.
-func (a *AuthQuery) Login(email string, password string) (dataLogin auth.UserCore, err error) {
+// atau auth.ErrInvalidCredentials jika email tidak ditemukan maupun password salah.
+func (a *AuthQuery) Login(ctx context.Context, email string, password string) (dataLogin auth.UserCore, err error) {
 	var userLogin User
 
 	// Ambil user berdasarkan email saja
 	// Query ini digunakan untuk mengambil data user dari database berdasarkan email.
 	// Jika user tidak ditemukan maka akan terjadi error.
-	query := "SELECT id, username, email, password, role FROM users WHERE email = $1"
-	err = a.DB.QueryRow(context.Background(), query, email).Scan(
+	// Status 2FA ikut diambil agar controller tahu apakah perlu langkah verifikasi kedua.
+	// Email dibandingkan tanpa membedakan huruf besar/kecil; parameter email sudah huruf kecil.
+	query := `SELECT u.id, u.username, u.email, u.password, u.role, COALESCE(t.enabled, FALSE)
+		FROM users u
+		LEFT JOIN user_totp t ON t.user_id = u.id
+		WHERE LOWER(u.email) = $1`
+	err = a.DB.QueryRow(ctx, query, email).Scan(
 		&userLogin.ID,
 		&userLogin.Username,
 		&userLogin.Email,
 		&userLogin.Password,
 		&userLogin.Role,
+		&userLogin.TOTP_Enabled,
 	)
 
 	if err != nil {
 		// Jika error maka cek apakah error tersebut adalah error karena user tidak ditemukan
 		if errors.Is(err, pgx.ErrNoRows) {
-			log.Printf("User with email %s not found", email)
-			return auth.UserCore{}, errors.New("user not found")
+			// Tetap jalankan bcrypt agar waktu respons sama dengan kasus password salah,
+			// sehingga keberadaan email tidak bisa ditebak dari lamanya respons.
+			helper.CheckPassword(password, dummyPasswordHash)
+			helper.LoggerFromContext(ctx).Warn("Login gagal: kredensial tidak valid")
+			return auth.UserCore{}, auth.ErrInvalidCredentials
 		}
 
 		// Jika bukan error karena user tidak ditemukan maka log error-nya
-		log.Printf("Error while querying user with email %s: %v", email, err)
+		helper.LoggerFromContext(ctx).Error("Error while querying user for login", "error", err)
 		return auth.UserCore{}, err
 	}
 
-	log.Printf("User found with email %s, checking password", email)
-
 	// Bandingkan password input dengan hash password dari DB
 	// Fungsi helper.CheckPassword digunakan untuk membandingkan password input dengan hash password dari DB
 	// Jika password tidak sama maka akan terjadi error
 	if !helper.CheckPassword(password, userLogin.Password) {
-		log.Printf("Login failed for user with email %s: wrong password", email)
-		return auth.UserCore{}, errors.New("login failed, wrong password")
+		helper.LoggerFromContext(ctx).Warn("Login gagal: kredensial tidak valid")
+		return auth.UserCore{}, auth.ErrInvalidCredentials
 	}
 
-	log.Printf("Login successful for user with email %s", email)
+	helper.LoggerFromContext(ctx).Info("Login successful", "user_id", userLogin.ID)
 
 	// Buatkan objek UserCore berdasarkan data user yang diambil dari database
-	dataLogin = auth.UserCore(FormatterResponse(userLogin))
+	dataLogin = FormatterResponse(userLogin)
 	return dataLogin, nil
 }
+
+// SelectLoginAttempt implements auth.DataAuthInterface.
+// Fungsi ini mengambil catatan percobaan login untuk scope dan identifier tertentu.
+// Jika belum ada catatan maka dikembalikan LoginAttemptCore kosong tanpa error.
+func (a *AuthQuery) SelectLoginAttempt(ctx context.Context, scope, identifier string) (auth.LoginAttemptCore, error) {
+	attempt := auth.LoginAttemptCore{Scope: scope, Identifier: identifier}
+
+	query := `SELECT failed_count, last_failed_at, locked_until
+		FROM login_attempts WHERE scope = $1 AND identifier = $2`
+	err := a.DB.QueryRow(ctx, query, scope, identifier).Scan(
+		&attempt.Failed_Count,
+		&attempt.Last_Failed_At,
+		&attempt.Locked_Until,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Belum pernah gagal, bukan error
+			return attempt, nil
+		}
+		helper.LoggerFromContext(ctx).Error("SelectLoginAttempt error scan", "error", err)
+		return attempt, fmt.Errorf("select login attempt failed: %w", err)
+	}
+
+	return attempt, nil
+}
+
+// IncrementLoginFailure implements auth.DataAuthInterface.
+// Fungsi ini menambah hitungan kegagalan secara atomik dengan upsert.
+// Jika kegagalan terakhir sudah lebih lama dari window maka hitungan dimulai dari 1 lagi.
+func (a *AuthQuery) IncrementLoginFailure(ctx context.Context, scope, identifier string, window time.Duration) (int, error) {
+	query := `INSERT INTO login_attempts (scope, identifier, failed_count, last_failed_at)
+		VALUES ($1, $2, 1, NOW())
+		ON CONFLICT (scope, identifier) DO UPDATE SET
+			failed_count = CASE
+				WHEN login_attempts.last_failed_at < NOW() - make_interval(secs => $3) THEN 1
+				ELSE login_attempts.failed_count + 1
+			END,
+			last_failed_at = NOW()
+		RETURNING failed_count`
+
+	var count int
+	err := a.DB.QueryRow(ctx, query, scope, identifier, window.Seconds()).Scan(&count)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("IncrementLoginFailure error exec", "error", err)
+		return 0, fmt.Errorf("increment login failure failed: %w", err)
+	}
+
+	return count, nil
+}
+
+// SetLockedUntil implements auth.DataAuthInterface.
+// Fungsi ini menyimpan waktu sampai identitas boleh mencoba login lagi.
+// pgx membuang zona waktu saat menulis TIMESTAMP dan membacanya kembali sebagai UTC,
+// jadi until selalu disimpan dalam UTC.
+func (a *AuthQuery) SetLockedUntil(ctx context.Context, scope, identifier string, until time.Time) error {
+	query := "UPDATE login_attempts SET locked_until = $3 WHERE scope = $1 AND identifier = $2"
+	if _, err := a.DB.Exec(ctx, query, scope, identifier, until.UTC()); err != nil {
+		helper.LoggerFromContext(ctx).Error("SetLockedUntil error exec", "error", err)
+		return fmt.Errorf("set locked until failed: %w", err)
+	}
+
+	return nil
+}
+
+// SelectAllLoginAttempts implements auth.DataAuthInterface.
+// Fungsi ini mengambil semua catatan percobaan login, yang sedang dikunci ditampilkan lebih dulu.
+func (a *AuthQuery) SelectAllLoginAttempts(ctx context.Context) ([]auth.LoginAttemptCore, error) {
+	query := `SELECT scope, identifier, failed_count, last_failed_at, locked_until
+		FROM login_attempts
+		ORDER BY locked_until DESC NULLS LAST, last_failed_at DESC`
+
+	rows, err := a.DB.Query(ctx, query)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectAllLoginAttempts error query", "error", err)
+		return nil, fmt.Errorf("select login attempts failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]auth.LoginAttemptCore, 0)
+	for rows.Next() {
+		var attempt auth.LoginAttemptCore
+		err := rows.Scan(&attempt.Scope, &attempt.Identifier, &attempt.Failed_Count, &attempt.Last_Failed_At, &attempt.Locked_Until)
+		if err != nil {
+			helper.LoggerFromContext(ctx).Error("SelectAllLoginAttempts error scan", "error", err)
+			return nil, fmt.Errorf("select login attempts failed: %w", err)
+		}
+		result = append(result, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectAllLoginAttempts error rows", "error", err)
+		return nil, fmt.Errorf("select login attempts failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteLoginAttempt implements auth.DataAuthInterface.
+// Fungsi ini menghapus catatan percobaan login sehingga kunci dan hitungan kegagalan di-reset.
+func (a *AuthQuery) DeleteLoginAttempt(ctx context.Context, scope, identifier string) error {
+	query := "DELETE FROM login_attempts WHERE scope = $1 AND identifier = $2"
+	if _, err := a.DB.Exec(ctx, query, scope, identifier); err != nil {
+		helper.LoggerFromContext(ctx).Error("DeleteLoginAttempt error exec", "error", err)
+		return fmt.Errorf("delete login attempt failed: %w", err)
+	}
+
+	return nil
+}
+
+// SelectUserById implements auth.DataAuthInterface.
+// Fungsi ini mengambil data user aktif beserta status 2FA berdasarkan ID.
+func (a *AuthQuery) SelectUserById(ctx context.Context, id string) (auth.UserCore, error) {
+	var user User
+
+	query := `SELECT u.id, u.username, u.email, u.password, u.role, COALESCE(t.enabled, FALSE)
+		FROM users u
+		LEFT JOIN user_totp t ON t.user_id = u.id
+		WHERE u.id = $1 AND u.delete_at IS NULL`
+	err := a.DB.QueryRow(ctx, query, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.TOTP_Enabled,
+	)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectUserById error scan", "error", err)
+		return auth.UserCore{}, fmt.Errorf("select user failed: %w", err)
+	}
+
+	return FormatterResponse(user), nil
+}
+
+// SelectTOTP implements auth.DataAuthInterface.
+// Fungsi ini mengambil konfigurasi 2FA user. Jika belum ada maka dikembalikan TOTPCore kosong.
+func (a *AuthQuery) SelectTOTP(ctx context.Context, userID string) (auth.TOTPCore, error) {
+	totp := auth.TOTPCore{User_ID: userID}
+
+	query := "SELECT secret, enabled, last_used_step, confirmed_at FROM user_totp WHERE user_id = $1"
+	err := a.DB.QueryRow(ctx, query, userID).Scan(
+		&totp.Secret,
+		&totp.Enabled,
+		&totp.Last_Used_Step,
+		&totp.Confirmed_At,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return totp, nil
+		}
+		helper.LoggerFromContext(ctx).Error("SelectTOTP error scan", "error", err)
+		return totp, fmt.Errorf("select totp failed: %w", err)
+	}
+
+	return totp, nil
+}
+
+// SaveTOTPSecret implements auth.DataAuthInterface.
+// Fungsi ini menyimpan secret baru dalam keadaan belum aktif dan menghapus kode pemulihan lama.
+func (a *AuthQuery) SaveTOTPSecret(ctx context.Context, userID, secret string) error {
+	query := `INSERT INTO user_totp (user_id, secret, enabled, recovery_codes, last_used_step)
+		VALUES ($1, $2, FALSE, '{}', 0)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			enabled = FALSE,
+			recovery_codes = '{}',
+			last_used_step = 0,
+			confirmed_at = NULL`
+	if _, err := a.DB.Exec(ctx, query, userID, secret); err != nil {
+		helper.LoggerFromContext(ctx).Error("SaveTOTPSecret error exec", "error", err)
+		return fmt.Errorf("save totp secret failed: %w", err)
+	}
+
+	return nil
+}
+
+// EnableTOTP implements auth.DataAuthInterface.
+// Fungsi ini mengaktifkan 2FA dan menyimpan hash kode pemulihan.
+func (a *AuthQuery) EnableTOTP(ctx context.Context, userID string, recoveryHashes []string, step int64) error {
+	query := `UPDATE user_totp
+		SET enabled = TRUE, recovery_codes = $2, last_used_step = $3, confirmed_at = NOW()
+		WHERE user_id = $1`
+	res, err := a.DB.Exec(ctx, query, userID, recoveryHashes, step)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("EnableTOTP error exec", "error", err)
+		return fmt.Errorf("enable totp failed: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return errors.New("enable totp failed: no rows affected")
+	}
+
+	return nil
+}
+
+// DisableTOTP implements auth.DataAuthInterface.
+// Fungsi ini menghapus konfigurasi 2FA user.
+func (a *AuthQuery) DisableTOTP(ctx context.Context, userID string) error {
+	if _, err := a.DB.Exec(ctx, "DELETE FROM user_totp WHERE user_id = $1", userID); err != nil {
+		helper.LoggerFromContext(ctx).Error("DisableTOTP error exec", "error", err)
+		return fmt.Errorf("disable totp failed: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTOTPStep implements auth.DataAuthInterface.
+// Fungsi ini hanya menyimpan langkah waktu yang lebih baru dari yang tersimpan,
+// sehingga kode yang sama tidak bisa dipakai dua kali walaupun ada request bersamaan.
+func (a *AuthQuery) UpdateTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	query := "UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2"
+	res, err := a.DB.Exec(ctx, query, userID, step)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("UpdateTOTPStep error exec", "error", err)
+		return false, fmt.Errorf("update totp step failed: %w", err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// ConsumeRecoveryCode implements auth.DataAuthInterface.
+// Fungsi ini menghapus satu hash kode pemulihan secara atomik agar setiap kode hanya bisa dipakai sekali.
+func (a *AuthQuery) ConsumeRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	query := `UPDATE user_totp SET recovery_codes = array_remove(recovery_codes, $2)
+		WHERE user_id = $1 AND enabled AND $2 = ANY(recovery_codes)`
+	res, err := a.DB.Exec(ctx, query, userID, hash)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("ConsumeRecoveryCode error exec", "error", err)
+		return false, fmt.Errorf("consume recovery code failed: %w", err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
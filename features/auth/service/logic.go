@@ -1,8 +1,14 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"go_rest_native_sekolah/features/auth"
-	"log"
+	"go_rest_native_sekolah/helper"
+	"slices"
+	"strings"
+	"time"
 )
 
 // authService merepresentasikan service untuk autentikasi.
@@ -10,36 +16,295 @@ import (
 // authService berisi field authData yang digunakan untuk mengakses data autentikasi.
 type authService struct {
 	authData auth.DataAuthInterface // authData digunakan untuk mengakses data autentikasi.
+	policy   auth.LockoutPolicy     // policy mengatur penundaan dan penguncian login.
+	now      func() time.Time       // now digunakan untuk mengambil waktu sekarang.
 }
 
 // NewServiceAuth membuat objek authService yang siap digunakan.
 // authService digunakan untuk menghandle logika bisnis yang berhubungan dengan autentikasi.
+// Parameter policy mengatur perlindungan brute-force pada login.
 // Jika parameter authData nil maka akan terjadi panic.
-func NewServiceAuth(authData auth.DataAuthInterface) auth.ServiceAuthInterface {
+func NewServiceAuth(authData auth.DataAuthInterface, policy auth.LockoutPolicy) auth.ServiceAuthInterface {
 	if authData == nil {
 		panic("NewServiceAuth: authData is nil")
 	}
 	// Membuat objek authService yang siap digunakan
 	return &authService{
 		authData: authData, // Menyimpan data auth ke dalam field authData
+		policy:   policy,   // Menyimpan kebijakan penguncian login
+		now:      time.Now,
 	}
 }
 
 // Login implements auth.ServiceAuthInterface.
-// Fungsi ini digunakan untuk melakukan login dengan input email dan password.
-// Fungsi ini akan mengembalikan nilai UserCore yang berisi data user jika login berhasil,
-// atau error jika login gagal.
-// Fungsi ini juga akan mengembalikan error jika terjadi kesalahan saat login.
-func (a *authService) Login(email string, password string) (dataLogin auth.UserCore, err error) {
-	log.Printf("Memulai proses login untuk email: %s", email)
+// Fungsi ini digunakan untuk melakukan login dengan input email, password, dan IP client.
+// Sebelum mengecek kredensial, fungsi ini menolak login jika email atau IP sedang dikunci.
+// Setiap kegagalan kredensial dicatat per email dan per IP, lalu dihitung penundaannya
+// sesuai LockoutPolicy. Login yang berhasil me-reset hitungan kegagalan email tersebut.
+func (a *authService) Login(ctx context.Context, email string, password string, ip string) (dataLogin auth.UserCore, err error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	identities := a.identities(email, ip)
+
+	// Tolak lebih awal jika salah satu identitas masih dalam masa penundaan atau terkunci
+	if err := a.checkLocked(ctx, identities); err != nil {
+		return auth.UserCore{}, err
+	}
+
 	// Menggunakan data auth untuk menghandle login
 	// Jika terjadi error maka akan mengembalikan error
-	dataLogin, err = a.authData.Login(email, password)
+	dataLogin, err = a.authData.Login(ctx, email, password)
 	if err != nil {
-		log.Printf("Terjadi kesalahan saat login user dengan email %s: %v", email, err)
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			// Catat kegagalan untuk email dan IP, lalu kembalikan error yang seragam
+			if recordErr := a.recordFailure(ctx, identities); recordErr != nil {
+				return auth.UserCore{}, recordErr
+			}
+			return auth.UserCore{}, auth.ErrInvalidCredentials
+		}
+		helper.LoggerFromContext(ctx).Error("Terjadi kesalahan saat login", "error", err)
 		return auth.UserCore{}, err // Mengembalikan error jika terjadi kesalahan
 	}
-	log.Printf("Login berhasil untuk email: %s", email)
+
+	// Login berhasil, hitungan kegagalan untuk akun ini di-reset
+	if err := a.authData.DeleteLoginAttempt(ctx, auth.ScopeEmail, email); err != nil {
+		helper.LoggerFromContext(ctx).Error("Gagal me-reset percobaan login", "error", err)
+	}
+
 	// Mengembalikan data user yang berhasil login
 	return dataLogin, nil
 }
+
+// SelectLockouts implements auth.ServiceAuthInterface.
+// Fungsi ini mengambil semua identitas yang memiliki kegagalan login, termasuk yang sedang dikunci.
+func (a *authService) SelectLockouts(ctx context.Context) ([]auth.LoginAttemptCore, error) {
+	attempts, err := a.authData.SelectAllLoginAttempts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("auth service: gagal mengambil data penguncian: %w", err)
+	}
+	return attempts, nil
+}
+
+// ClearLockout implements auth.ServiceAuthInterface.
+// Fungsi ini melepas kunci untuk satu identitas setelah memvalidasi scope dan identifier.
+func (a *authService) ClearLockout(ctx context.Context, scope string, identifier string) error {
+	if scope != auth.ScopeEmail && scope != auth.ScopeIP {
+		return errors.New("validation error: scope harus 'email' atau 'ip'")
+	}
+	if identifier == "" {
+		return errors.New("validation error: identifier harus diisi")
+	}
+	if scope == auth.ScopeEmail {
+		identifier = strings.ToLower(strings.TrimSpace(identifier))
+	}
+
+	if err := a.authData.DeleteLoginAttempt(ctx, scope, identifier); err != nil {
+		return fmt.Errorf("auth service: gagal melepas penguncian: %w", err)
+	}
+	return nil
+}
+
+// VerifyTwoFactor implements auth.ServiceAuthInterface.
+// Fungsi ini adalah langkah kedua login untuk user yang mengaktifkan 2FA.
+// Kode yang diterima bisa berupa kode TOTP atau salah satu kode pemulihan.
+// Kegagalan dicatat dengan mekanisme penguncian yang sama dengan login password.
+func (a *authService) VerifyTwoFactor(ctx context.Context, userID string, code string, ip string) (auth.UserCore, error) {
+	user, err := a.authData.SelectUserById(ctx, userID)
+	if err != nil {
+		// User sudah dihapus atau tidak ada, challenge dianggap tidak valid
+		return auth.UserCore{}, auth.ErrInvalidCredentials
+	}
+
+	identities := a.identities(user.Email, ip)
+	if err := a.checkLocked(ctx, identities); err != nil {
+		return auth.UserCore{}, err
+	}
+
+	totp, err := a.authData.SelectTOTP(ctx, user.ID)
+	if err != nil {
+		return auth.UserCore{}, err
+	}
+	if !totp.Enabled {
+		return auth.UserCore{}, auth.ErrInvalidTOTPCode
+	}
+
+	valid, err := a.checkSecondFactor(ctx, totp, code)
+	if err != nil {
+		return auth.UserCore{}, err
+	}
+	if !valid {
+		if recordErr := a.recordFailure(ctx, identities); recordErr != nil {
+			return auth.UserCore{}, recordErr
+		}
+		return auth.UserCore{}, auth.ErrInvalidTOTPCode
+	}
+
+	// Verifikasi berhasil, hitungan kegagalan untuk akun ini di-reset
+	if err := a.authData.DeleteLoginAttempt(ctx, auth.ScopeEmail, user.Email); err != nil {
+		helper.LoggerFromContext(ctx).Error("Gagal me-reset percobaan login", "error", err)
+	}
+	return user, nil
+}
+
+// EnrollTOTP implements auth.ServiceAuthInterface.
+// Fungsi ini membuat secret 2FA baru untuk user admin atau guru.
+// 2FA belum berlaku sampai user mengonfirmasi dengan kode pertama lewat ConfirmTOTP.
+func (a *authService) EnrollTOTP(ctx context.Context, userID string) (auth.TOTPEnrollment, error) {
+	user, err := a.authData.SelectUserById(ctx, userID)
+	if err != nil {
+		return auth.TOTPEnrollment{}, fmt.Errorf("auth service: gagal mengambil data user: %w", err)
+	}
+	if !slices.Contains(auth.TwoFactorRoles, user.Role) {
+		return auth.TOTPEnrollment{}, errors.New("validation error: 2FA hanya tersedia untuk admin dan guru")
+	}
+
+	totp, err := a.authData.SelectTOTP(ctx, user.ID)
+	if err != nil {
+		return auth.TOTPEnrollment{}, err
+	}
+	if totp.Enabled {
+		return auth.TOTPEnrollment{}, errors.New("validation error: 2FA sudah aktif, nonaktifkan terlebih dahulu")
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return auth.TOTPEnrollment{}, err
+	}
+	if err := a.authData.SaveTOTPSecret(ctx, user.ID, secret); err != nil {
+		return auth.TOTPEnrollment{}, fmt.Errorf("auth service: gagal menyimpan secret 2FA: %w", err)
+	}
+
+	return auth.TOTPEnrollment{
+		Secret: secret,
+		URI:    helper.TOTPURI(auth.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP implements auth.ServiceAuthInterface.
+// Fungsi ini mengaktifkan 2FA jika kode dari aplikasi authenticator benar,
+// lalu membuat kode pemulihan yang hanya ditampilkan sekali.
+func (a *authService) ConfirmTOTP(ctx context.Context, userID string, code string) ([]string, error) {
+	totp, err := a.authData.SelectTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if totp.Secret == "" {
+		return nil, errors.New("validation error: 2FA belum didaftarkan")
+	}
+	if totp.Enabled {
+		return nil, errors.New("validation error: 2FA sudah aktif")
+	}
+
+	step, ok := helper.ValidateTOTP(totp.Secret, code, a.now())
+	if !ok {
+		return nil, auth.ErrInvalidTOTPCode
+	}
+
+	codes, err := helper.GenerateRecoveryCodes(auth.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, c := range codes {
+		hashes = append(hashes, helper.HashRecoveryCode(c))
+	}
+
+	if err := a.authData.EnableTOTP(ctx, userID, hashes, step); err != nil {
+		return nil, fmt.Errorf("auth service: gagal mengaktifkan 2FA: %w", err)
+	}
+	return codes, nil
+}
+
+// DisableTOTP implements auth.ServiceAuthInterface.
+// Fungsi ini menonaktifkan 2FA setelah kode TOTP atau kode pemulihan diverifikasi.
+func (a *authService) DisableTOTP(ctx context.Context, userID string, code string) error {
+	totp, err := a.authData.SelectTOTP(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !totp.Enabled {
+		return errors.New("validation error: 2FA belum aktif")
+	}
+
+	valid, err := a.checkSecondFactor(ctx, totp, code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return auth.ErrInvalidTOTPCode
+	}
+
+	if err := a.authData.DisableTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("auth service: gagal menonaktifkan 2FA: %w", err)
+	}
+	return nil
+}
+
+// checkSecondFactor memeriksa kode sebagai kode TOTP lalu sebagai kode pemulihan.
+// Kode TOTP yang sudah pernah dipakai ditolak, dan kode pemulihan langsung dihapus setelah dipakai.
+func (a *authService) checkSecondFactor(ctx context.Context, totp auth.TOTPCore, code string) (bool, error) {
+	if step, ok := helper.ValidateTOTP(totp.Secret, code, a.now()); ok {
+		fresh, err := a.authData.UpdateTOTPStep(ctx, totp.User_ID, step)
+		if err != nil {
+			return false, err
+		}
+		return fresh, nil
+	}
+
+	return a.authData.ConsumeRecoveryCode(ctx, totp.User_ID, helper.HashRecoveryCode(code))
+}
+
+// loginIdentity adalah pasangan scope dan identifier yang dilacak saat login.
+type loginIdentity struct {
+	scope      string
+	identifier string
+}
+
+// identities mengembalikan identitas yang dilacak untuk satu percobaan login.
+// IP kosong (misalnya pada pengujian) tidak dilacak.
+func (a *authService) identities(email, ip string) []loginIdentity {
+	ids := []loginIdentity{{scope: auth.ScopeEmail, identifier: email}}
+	if ip != "" {
+		ids = append(ids, loginIdentity{scope: auth.ScopeIP, identifier: ip})
+	}
+	return ids
+}
+
+// checkLocked mengembalikan *auth.LockedError jika salah satu identitas masih dalam masa penundaan.
+func (a *authService) checkLocked(ctx context.Context, identities []loginIdentity) error {
+	for _, id := range identities {
+		attempt, err := a.authData.SelectLoginAttempt(ctx, id.scope, id.identifier)
+		if err != nil {
+			return err
+		}
+		if attempt.Locked_Until != nil {
+			if remaining := attempt.Locked_Until.Sub(a.now()); remaining > 0 {
+				helper.LoggerFromContext(ctx).Warn("Login ditolak, identitas sedang dikunci", "scope", id.scope, "retry_after", remaining.Round(time.Second))
+				return &auth.LockedError{Scope: id.scope, RetryAfter: remaining}
+			}
+		}
+	}
+	return nil
+}
+
+// recordFailure mencatat kegagalan login untuk setiap identitas dan
+// menyimpan waktu penundaan jika kebijakan mengharuskan.
+func (a *authService) recordFailure(ctx context.Context, identities []loginIdentity) error {
+	for _, id := range identities {
+		count, err := a.authData.IncrementLoginFailure(ctx, id.scope, id.identifier, a.policy.Window)
+		if err != nil {
+			return err
+		}
+
+		delay := a.policy.LockDuration(id.scope, count)
+		if delay <= 0 {
+			continue
+		}
+		// Kolom locked_until bertipe TIMESTAMP tanpa zona, sehingga waktu disimpan dalam UTC
+		// agar terbaca kembali sebagai waktu yang sama di server dengan zona waktu apa pun
+		if err := a.authData.SetLockedUntil(ctx, id.scope, id.identifier, a.now().UTC().Add(delay)); err != nil {
+			return err
+		}
+		helper.LoggerFromContext(ctx).Warn("Login ditunda setelah kegagalan", "scope", id.scope, "delay", delay, "failed_count", count)
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package service_test
 
 import (
+	"context"
 	"errors"
 	"go_rest_native_sekolah/features/auth"
 	"go_rest_native_sekolah/features/auth/service"
+	"go_rest_native_sekolah/helper"
 	"testing"
 	"time"
 
@@ -16,16 +18,85 @@ type mockDataAuth struct {
 	mock.Mock
 }
 
-func (m *mockDataAuth) Login(email, password string) (auth.UserCore, error) {
+func (m *mockDataAuth) Login(ctx context.Context, email, password string) (auth.UserCore, error) {
 	args := m.Called(email, password)
 	return args.Get(0).(auth.UserCore), args.Error(1)
 }
 
+func (m *mockDataAuth) SelectLoginAttempt(ctx context.Context, scope, identifier string) (auth.LoginAttemptCore, error) {
+	// Meniru pgx: query dengan context yang sudah dibatalkan langsung gagal
+	if err := ctx.Err(); err != nil {
+		return auth.LoginAttemptCore{}, err
+	}
+	args := m.Called(scope, identifier)
+	return args.Get(0).(auth.LoginAttemptCore), args.Error(1)
+}
+
+func (m *mockDataAuth) IncrementLoginFailure(ctx context.Context, scope, identifier string, window time.Duration) (int, error) {
+	args := m.Called(scope, identifier, window)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockDataAuth) SetLockedUntil(ctx context.Context, scope, identifier string, until time.Time) error {
+	args := m.Called(scope, identifier, until)
+	return args.Error(0)
+}
+
+func (m *mockDataAuth) SelectAllLoginAttempts(ctx context.Context) ([]auth.LoginAttemptCore, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]auth.LoginAttemptCore), args.Error(1)
+}
+
+func (m *mockDataAuth) DeleteLoginAttempt(ctx context.Context, scope, identifier string) error {
+	args := m.Called(scope, identifier)
+	return args.Error(0)
+}
+
+func (m *mockDataAuth) SelectUserById(ctx context.Context, id string) (auth.UserCore, error) {
+	args := m.Called(id)
+	return args.Get(0).(auth.UserCore), args.Error(1)
+}
+
+func (m *mockDataAuth) SelectTOTP(ctx context.Context, userID string) (auth.TOTPCore, error) {
+	args := m.Called(userID)
+	return args.Get(0).(auth.TOTPCore), args.Error(1)
+}
+
+func (m *mockDataAuth) SaveTOTPSecret(ctx context.Context, userID, secret string) error {
+	args := m.Called(userID, secret)
+	return args.Error(0)
+}
+
+func (m *mockDataAuth) EnableTOTP(ctx context.Context, userID string, recoveryHashes []string, step int64) error {
+	args := m.Called(userID, recoveryHashes, step)
+	return args.Error(0)
+}
+
+func (m *mockDataAuth) DisableTOTP(ctx context.Context, userID string) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *mockDataAuth) UpdateTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockDataAuth) ConsumeRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	args := m.Called(userID, hash)
+	return args.Bool(0), args.Error(1)
+}
+
 func TestLogin(t *testing.T) {
-	mockRepo := new(mockDataAuth)
-	svc := service.NewServiceAuth(mockRepo)
+	policy := auth.DefaultLockoutPolicy()
 
 	t.Run("success login", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, policy)
+
 		expectedUser := auth.UserCore{
 			ID:        "123",
 			Username:  "john",
@@ -35,10 +106,13 @@ func TestLogin(t *testing.T) {
 			Update_At: time.Now(),
 		}
 
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "john@example.com").Return(auth.LoginAttemptCore{}, nil).Once()
+		mockRepo.On("SelectLoginAttempt", auth.ScopeIP, "10.0.0.1").Return(auth.LoginAttemptCore{}, nil).Once()
 		mockRepo.On("Login", "john@example.com", "password123").
 			Return(expectedUser, nil).Once()
+		mockRepo.On("DeleteLoginAttempt", auth.ScopeEmail, "john@example.com").Return(nil).Once()
 
-		result, err := svc.Login("john@example.com", "password123")
+		result, err := svc.Login(context.Background(), "john@example.com", "password123", "10.0.0.1")
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedUser, result)
@@ -46,13 +120,326 @@ func TestLogin(t *testing.T) {
 	})
 
 	t.Run("failed login", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, policy)
+
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "wrong@example.com").Return(auth.LoginAttemptCore{}, nil).Once()
 		mockRepo.On("Login", "wrong@example.com", "wrongpass").
-			Return(auth.UserCore{}, errors.New("invalid credentials")).Once()
+			Return(auth.UserCore{}, auth.ErrInvalidCredentials).Once()
+		mockRepo.On("IncrementLoginFailure", auth.ScopeEmail, "wrong@example.com", policy.Window).Return(1, nil).Once()
+
+		result, err := svc.Login(context.Background(), "wrong@example.com", "wrongpass", "")
+
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		assert.Equal(t, auth.UserCore{}, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed login - delay applied after threshold", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, policy)
+
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "john@example.com").Return(auth.LoginAttemptCore{}, nil).Once()
+		mockRepo.On("SelectLoginAttempt", auth.ScopeIP, "10.0.0.1").Return(auth.LoginAttemptCore{}, nil).Once()
+		mockRepo.On("Login", "john@example.com", "wrongpass").
+			Return(auth.UserCore{}, auth.ErrInvalidCredentials).Once()
+		mockRepo.On("IncrementLoginFailure", auth.ScopeEmail, "john@example.com", policy.Window).Return(policy.DelayAfter, nil).Once()
+		mockRepo.On("IncrementLoginFailure", auth.ScopeIP, "10.0.0.1", policy.Window).Return(1, nil).Once()
+		mockRepo.On("SetLockedUntil", auth.ScopeEmail, "john@example.com", mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		_, err := svc.Login(context.Background(), "john@example.com", "wrongpass", "10.0.0.1")
+
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+		mockRepo.AssertExpectations(t)
+	})
 
-		result, err := svc.Login("wrong@example.com", "wrongpass")
+	t.Run("failed login - penguncian berlaku di server dengan zona waktu non-UTC", func(t *testing.T) {
+		lokal := time.Local
+		defer func() { time.Local = lokal }()
+
+		for _, zona := range []*time.Location{time.FixedZone("WIB", 7*3600), time.FixedZone("EST", -5*3600)} {
+			time.Local = zona
+			mockRepo := new(mockDataAuth)
+			svc := service.NewServiceAuth(mockRepo, policy)
+
+			var disimpan time.Time
+			mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "john@example.com").Return(auth.LoginAttemptCore{}, nil).Once()
+			mockRepo.On("Login", "john@example.com", "wrongpass").Return(auth.UserCore{}, auth.ErrInvalidCredentials).Once()
+			mockRepo.On("IncrementLoginFailure", auth.ScopeEmail, "john@example.com", policy.Window).Return(policy.DelayAfter, nil).Once()
+			mockRepo.On("SetLockedUntil", auth.ScopeEmail, "john@example.com", mock.AnythingOfType("time.Time")).
+				Run(func(args mock.Arguments) { disimpan = args.Get(2).(time.Time) }).Return(nil).Once()
+
+			_, err := svc.Login(context.Background(), "john@example.com", "wrongpass", "")
+			assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
+
+			// Tiru kolom TIMESTAMP: pgx membuang zona waktu lalu membacanya kembali sebagai UTC
+			dariDB := time.Date(disimpan.Year(), disimpan.Month(), disimpan.Day(),
+				disimpan.Hour(), disimpan.Minute(), disimpan.Second(), disimpan.Nanosecond(), time.UTC)
+			mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "john@example.com").
+				Return(auth.LoginAttemptCore{Failed_Count: policy.DelayAfter, Locked_Until: &dariDB}, nil).Once()
+
+			_, err = svc.Login(context.Background(), "john@example.com", "wrongpass", "")
+
+			var lockedErr *auth.LockedError
+			if assert.ErrorAs(t, err, &lockedErr, zona.String()) {
+				assert.LessOrEqual(t, lockedErr.RetryAfter, policy.BaseDelay, zona.String())
+				assert.Greater(t, lockedErr.RetryAfter, policy.BaseDelay-time.Second, zona.String())
+			}
+		}
+	})
+
+	t.Run("failed login - account locked", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, policy)
+
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "john@example.com").
+			Return(auth.LoginAttemptCore{Failed_Count: 5, Locked_Until: &lockedUntil}, nil).Once()
+
+		_, err := svc.Login(context.Background(), "john@example.com", "password123", "10.0.0.1")
+
+		var lockedErr *auth.LockedError
+		assert.ErrorAs(t, err, &lockedErr)
+		assert.Equal(t, auth.ScopeEmail, lockedErr.Scope)
+		assert.Greater(t, lockedErr.RetryAfter, 9*time.Minute)
+		mockRepo.AssertNotCalled(t, "Login", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed login - ip locked", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, policy)
+
+		expired := time.Now().Add(-time.Minute)
+		lockedUntil := time.Now().Add(time.Minute)
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "john@example.com").
+			Return(auth.LoginAttemptCore{Failed_Count: 3, Locked_Until: &expired}, nil).Once()
+		mockRepo.On("SelectLoginAttempt", auth.ScopeIP, "10.0.0.1").
+			Return(auth.LoginAttemptCore{Failed_Count: 20, Locked_Until: &lockedUntil}, nil).Once()
+
+		_, err := svc.Login(context.Background(), "john@example.com", "password123", "10.0.0.1")
+
+		var lockedErr *auth.LockedError
+		assert.ErrorAs(t, err, &lockedErr)
+		assert.Equal(t, auth.ScopeIP, lockedErr.Scope)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed login - repository error", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, policy)
+
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "john@example.com").Return(auth.LoginAttemptCore{}, nil).Once()
+		mockRepo.On("Login", "john@example.com", "password123").
+			Return(auth.UserCore{}, errors.New("connection refused")).Once()
+
+		_, err := svc.Login(context.Background(), "john@example.com", "password123", "")
 
 		assert.Error(t, err)
+		assert.NotErrorIs(t, err, auth.ErrInvalidCredentials)
+		mockRepo.AssertNotCalled(t, "IncrementLoginFailure", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed login - context dibatalkan diteruskan ke repository", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, policy)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := svc.Login(ctx, "john@example.com", "password123", "10.0.0.1")
+
+		assert.ErrorIs(t, err, context.Canceled)
 		assert.Equal(t, auth.UserCore{}, result)
+		mockRepo.AssertNotCalled(t, "Login", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLockDuration(t *testing.T) {
+	policy := auth.DefaultLockoutPolicy()
+
+	assert.Equal(t, time.Duration(0), policy.LockDuration(auth.ScopeEmail, policy.DelayAfter-1))
+	assert.Equal(t, policy.BaseDelay, policy.LockDuration(auth.ScopeEmail, policy.DelayAfter))
+	assert.Equal(t, 2*policy.BaseDelay, policy.LockDuration(auth.ScopeEmail, policy.DelayAfter+1))
+	assert.Equal(t, policy.LockoutDuration, policy.LockDuration(auth.ScopeEmail, policy.MaxAccountAttempts))
+	// Batas IP lebih longgar dari batas akun
+	assert.Less(t, policy.LockDuration(auth.ScopeIP, policy.MaxAccountAttempts), policy.LockoutDuration)
+	assert.Equal(t, policy.LockoutDuration, policy.LockDuration(auth.ScopeIP, policy.MaxIPAttempts))
+}
+
+func TestClearLockout(t *testing.T) {
+	t.Run("success clear lockout", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		mockRepo.On("DeleteLoginAttempt", auth.ScopeEmail, "john@example.com").Return(nil).Once()
+
+		err := svc.ClearLockout(context.Background(), auth.ScopeEmail, " John@Example.com ")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed clear lockout - invalid scope", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		err := svc.ClearLockout(context.Background(), "user", "john@example.com")
+
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "DeleteLoginAttempt", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	t.Run("success enroll totp", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		mockRepo.On("SelectUserById", "admin-1").Return(auth.UserCore{ID: "admin-1", Email: "admin@example.com", Role: "admin"}, nil).Once()
+		mockRepo.On("SelectTOTP", "admin-1").Return(auth.TOTPCore{User_ID: "admin-1"}, nil).Once()
+		mockRepo.On("SaveTOTPSecret", "admin-1", mock.AnythingOfType("string")).Return(nil).Once()
+
+		enrollment, err := svc.EnrollTOTP(context.Background(), "admin-1")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, enrollment.Secret)
+		assert.Contains(t, enrollment.URI, "otpauth://totp/")
+		assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed enroll totp - role not allowed", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		mockRepo.On("SelectUserById", "user-1").Return(auth.UserCore{ID: "user-1", Role: "user"}, nil).Once()
+
+		_, err := svc.EnrollTOTP(context.Background(), "user-1")
+
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "SaveTOTPSecret", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed enroll totp - already enabled", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		mockRepo.On("SelectUserById", "guru-1").Return(auth.UserCore{ID: "guru-1", Role: "guru"}, nil).Once()
+		mockRepo.On("SelectTOTP", "guru-1").Return(auth.TOTPCore{User_ID: "guru-1", Secret: "SECRET", Enabled: true}, nil).Once()
+
+		_, err := svc.EnrollTOTP(context.Background(), "guru-1")
+
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "SaveTOTPSecret", mock.Anything, mock.Anything)
+	})
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	secret, err := helper.GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	t.Run("success confirm totp", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		code, err := helper.TOTPCode(secret, helper.TOTPStep(time.Now()))
+		assert.NoError(t, err)
+
+		mockRepo.On("SelectTOTP", "admin-1").Return(auth.TOTPCore{User_ID: "admin-1", Secret: secret}, nil).Once()
+		mockRepo.On("EnableTOTP", "admin-1", mock.AnythingOfType("[]string"), mock.AnythingOfType("int64")).Return(nil).Once()
+
+		codes, err := svc.ConfirmTOTP(context.Background(), "admin-1", code)
+
+		assert.NoError(t, err)
+		assert.Len(t, codes, auth.RecoveryCodeCount)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed confirm totp - wrong code", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		mockRepo.On("SelectTOTP", "admin-1").Return(auth.TOTPCore{User_ID: "admin-1", Secret: secret}, nil).Once()
+
+		_, err := svc.ConfirmTOTP(context.Background(), "admin-1", "000000x")
+
+		assert.ErrorIs(t, err, auth.ErrInvalidTOTPCode)
+		mockRepo.AssertNotCalled(t, "EnableTOTP", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestVerifyTwoFactor(t *testing.T) {
+	secret, err := helper.GenerateTOTPSecret()
+	assert.NoError(t, err)
+	user := auth.UserCore{ID: "admin-1", Email: "admin@example.com", Role: "admin", TOTP_Enabled: true}
+	totp := auth.TOTPCore{User_ID: "admin-1", Secret: secret, Enabled: true}
+
+	t.Run("success verify with totp code", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		code, err := helper.TOTPCode(secret, helper.TOTPStep(time.Now()))
+		assert.NoError(t, err)
+
+		mockRepo.On("SelectUserById", "admin-1").Return(user, nil).Once()
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "admin@example.com").Return(auth.LoginAttemptCore{}, nil).Once()
+		mockRepo.On("SelectTOTP", "admin-1").Return(totp, nil).Once()
+		mockRepo.On("UpdateTOTPStep", "admin-1", mock.AnythingOfType("int64")).Return(true, nil).Once()
+		mockRepo.On("DeleteLoginAttempt", auth.ScopeEmail, "admin@example.com").Return(nil).Once()
+
+		result, err := svc.VerifyTwoFactor(context.Background(), "admin-1", code, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, user, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed verify - totp code replayed", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		code, err := helper.TOTPCode(secret, helper.TOTPStep(time.Now()))
+		assert.NoError(t, err)
+
+		mockRepo.On("SelectUserById", "admin-1").Return(user, nil).Once()
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "admin@example.com").Return(auth.LoginAttemptCore{}, nil).Once()
+		mockRepo.On("SelectTOTP", "admin-1").Return(totp, nil).Once()
+		mockRepo.On("UpdateTOTPStep", "admin-1", mock.AnythingOfType("int64")).Return(false, nil).Once()
+		mockRepo.On("IncrementLoginFailure", auth.ScopeEmail, "admin@example.com", mock.Anything).Return(1, nil).Once()
+
+		_, err = svc.VerifyTwoFactor(context.Background(), "admin-1", code, "")
+
+		assert.ErrorIs(t, err, auth.ErrInvalidTOTPCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success verify with recovery code", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		mockRepo.On("SelectUserById", "admin-1").Return(user, nil).Once()
+		mockRepo.On("SelectLoginAttempt", auth.ScopeEmail, "admin@example.com").Return(auth.LoginAttemptCore{}, nil).Once()
+		mockRepo.On("SelectTOTP", "admin-1").Return(totp, nil).Once()
+		mockRepo.On("ConsumeRecoveryCode", "admin-1", helper.HashRecoveryCode("abcde-12345")).Return(true, nil).Once()
+		mockRepo.On("DeleteLoginAttempt", auth.ScopeEmail, "admin@example.com").Return(nil).Once()
+
+		_, err := svc.VerifyTwoFactor(context.Background(), "admin-1", "ABCDE-12345", "")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed verify - unknown user", func(t *testing.T) {
+		mockRepo := new(mockDataAuth)
+		svc := service.NewServiceAuth(mockRepo, auth.DefaultLockoutPolicy())
+
+		mockRepo.On("SelectUserById", "ghost").Return(auth.UserCore{}, errors.New("no rows")).Once()
+
+		_, err := svc.VerifyTwoFactor(context.Background(), "ghost", "123456", "")
+
+		assert.ErrorIs(t, err, auth.ErrInvalidCredentials)
 		mockRepo.AssertExpectations(t)
 	})
 }
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"go_rest_native_sekolah/features/guru"
 	"go_rest_native_sekolah/helper"
-	"log"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -30,9 +30,21 @@ func NewGuruController(service guru.ServiceGuruInterface) *Gurucontroller {
 // Guru digunakan untuk menghandle HTTP request untuk mengambil semua data guru.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
 func (gc *Gurucontroller) Guru(w http.ResponseWriter, r *http.Request) error {
-	// Mengambil semua data guru dari database melalui service guru.
-	gurus, err := gc.guruService.GetAllGuru()
+	// Baca filter dari query parameter.
+	filter, err := filterDariQuery(r)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	// Mengambil data guru sesuai filter dari database melalui service guru.
+	gurus, err := gc.guruService.GetAllGuru(r.Context(), filter)
+	if err != nil {
+		// Filter yang tidak valid dijawab 400 Bad Request.
+		if strings.Contains(err.Error(), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil
+		}
 		// Jika terjadi error maka akan mengembalikan error dengan pesan "Error retrieving data".
 		return fmt.Errorf("guru controller: Error retrieving data: %v", err)
 	}
@@ -87,6 +99,17 @@ func (gc *Gurucontroller) InsertGuru(w http.ResponseWriter, r *http.Request) err
 			Nama:    r.FormValue("nama"),
 			Email:   r.FormValue("email"),
 			Alamat:  r.FormValue("alamat"),
+
+			NIP:                 r.FormValue("nip"),
+			NUPTK:               r.FormValue("nuptk"),
+			Status_Kepegawaian:  r.FormValue("status_kepegawaian"),
+			Pendidikan_Terakhir: r.FormValue("pendidikan_terakhir"),
+			Sertifikasi:         r.Form["sertifikasi"], // sertifikasi boleh dikirim berulang
+			Telepon:             r.FormValue("telepon"),
+			Tanggal_Masuk:       r.FormValue("tanggal_masuk"),
+
+			Username: r.FormValue("username"),
+			Password: r.FormValue("password"),
 		}
 	}
 
@@ -100,8 +123,13 @@ func (gc *Gurucontroller) InsertGuru(w http.ResponseWriter, r *http.Request) err
 	guruCore = FormatGuruRequestToCore(guruReq)
 
 	// Simpan data.
-	err := gc.guruService.InsertGuru(&guruCore)
+	err := gc.guruService.InsertGuru(r.Context(), &guruCore)
 	if err != nil {
+		// Data yang tidak lolos validasi service dijawab 400 Bad Request.
+		if strings.Contains(err.Error(), "validasi") || strings.Contains(err.Error(), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return fmt.Errorf("gagal insert guru: %v", err)
+		}
 		http.Error(w, "gagal menyimpan data guru", http.StatusInternalServerError)
 		return fmt.Errorf("gagal insert guru: %v", err)
 	}
@@ -131,25 +159,38 @@ func (gc *Gurucontroller) UpdateGuru(w http.ResponseWriter, r *http.Request) err
 		http.Error(w, "parameter 'id' wajib diisi", http.StatusBadRequest)
 		return errors.New("missing 'id' query parameter")
 	}
-	log.Printf("Request update guru dengan ID: %s", idStr)
+	helper.LoggerFromContext(r.Context()).Debug("Request update guru", "id", idStr)
+
+	// Ambil versi data dari header If-Match agar update tidak menimpa perubahan orang lain.
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
 
 	// Dekode request body menjadi objek GuruFormatter.
 	var guruReq GuruFormatter
-	err := json.NewDecoder(r.Body).Decode(&guruReq)
+	err = json.NewDecoder(r.Body).Decode(&guruReq)
 	if err != nil {
 		// Jika terjadi error saat decoding maka kembalikan error dengan status 400.
-		log.Printf("Error decoding request body: %v", err)
+		helper.LoggerFromContext(r.Context()).Error("Error decoding request body", "error", err)
 		http.Error(w, "Gagal memproses data input", http.StatusBadRequest)
 		return err
 	}
 
 	// Format data GuruFormatter menjadi objek GuruCore.
 	updateGuru := FormatGuruRequestToCore(guruReq)
+	updateGuru.Version = version
 
 	// Panggil service untuk memperbarui data guru berdasarkan ID.
-	err = gc.guruService.UpdateGuru(&updateGuru, idStr)
+	err = gc.guruService.UpdateGuru(r.Context(), &updateGuru, idStr)
 	if err != nil {
 		// Jika terjadi error saat memperbarui data guru maka kembalikan error sesuai dengan status error.
+		// Jika data sudah diubah request lain, kirimkan response dengan status 412.
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		}
 		if strings.Contains(err.Error(), "validation") {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return err
@@ -163,7 +204,7 @@ func (gc *Gurucontroller) UpdateGuru(w http.ResponseWriter, r *http.Request) err
 	}
 
 	// Ambil data guru yang telah diupdate dari database.
-	updatedGuru, err := gc.guruService.SelectById(idStr)
+	updatedGuru, err := gc.guruService.SelectById(r.Context(), idStr)
 	if err != nil {
 		// Jika terjadi error saat mengambil data guru maka kembalikan error dengan status 500.
 		http.Error(w, "Gagal mengambil data setelah update", http.StatusInternalServerError)
@@ -176,8 +217,9 @@ func (gc *Gurucontroller) UpdateGuru(w http.ResponseWriter, r *http.Request) err
 	// Buat response API dengan status OK dan pesan "Berhasil mengupdate data guru ke database".
 	response := helper.APIResponse(http.StatusOK, "Berhasil mengupdate data guru ke database", formattedGurus)
 
-	// Set header Content-Type menjadi application/json.
+	// Set header Content-Type menjadi application/json dan ETag versi terbaru.
 	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updatedGuru.Version)
 
 	// Encode response API menjadi JSON dan tulis ke response writer.
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -199,7 +241,7 @@ func (gc *Gurucontroller) GetGuruById(w http.ResponseWriter, r *http.Request) er
 		return fmt.Errorf("guru controller: ID guru tidak ditemukan dalam query parameter")
 	}
 
-	guruData, err := gc.guruService.SelectById(id)
+	guruData, err := gc.guruService.SelectById(r.Context(), id)
 	if err != nil {
 		if strings.Contains(err.Error(), "tidak ditemukan") {
 			http.Error(w, "Data guru tidak ditemukan", http.StatusNotFound)
@@ -217,6 +259,8 @@ func (gc *Gurucontroller) GetGuruById(w http.ResponseWriter, r *http.Request) er
 	// Jika terjadi error saat encoding maka kembalikan error dengan status 500.
 	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data guru berdasarkan ID", formattedGurus)
 	w.Header().Set("Content-Type", "application/json")
+	// ETag dipakai client sebagai If-Match saat update atau delete.
+	helper.SetETag(w, guruData.Version)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		return fmt.Errorf("guru controller: error saat encoding response: %v", err)
 	}
@@ -236,10 +280,43 @@ func (gc *Gurucontroller) DeleteGuru(w http.ResponseWriter, r *http.Request) err
 		return fmt.Errorf("guru controller: ID guru tidak ditemukan dalam query parameter")
 	}
 
+	// Ambil versi data dari header If-Match agar tidak menghapus data yang sudah diubah orang lain.
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
+
+	// Baca policy hapus dari query parameter (?policy=block|reassign|cascade&target=)
+	opts, err := helper.DeleteOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
 	// Panggil service untuk menghapus data guru berdasarkan ID
 	// Jika terjadi error saat menghapus data guru, kembalikan error dengan pesan yang sesuai.
-	err := gc.guruService.DeleteById(id)
+	err = gc.guruService.DeleteById(r.Context(), id, version, opts)
 	if err != nil {
+		// Jika data sudah diubah request lain, kirimkan response dengan status 412.
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		}
+		// Jika guru masih dipakai dan policy block, kirimkan daftar data yang merujuk dengan status 409.
+		var blocked *helper.DeleteBlockedError
+		if errors.As(err, &blocked) {
+			helper.JSONResponse(w, http.StatusConflict, helper.APIResponse(http.StatusConflict, "guru masih dipakai: "+blocked.Error(), blocked.Impact))
+			return nil
+		}
+		if strings.Contains(err.Error(), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		if strings.Contains(err.Error(), "no rows affected") {
+			http.Error(w, "Data guru tidak ditemukan", http.StatusNotFound)
+			return err
+		}
 		return fmt.Errorf("guru controller: gagal menghapus data guru berdasarkan ID: %v", err)
 	}
 
@@ -255,3 +332,139 @@ func (gc *Gurucontroller) DeleteGuru(w http.ResponseWriter, r *http.Request) err
 	// Jika tidak ada error maka kembalikan nil.
 	return nil
 }
+
+// PreviewDeleteGuru digunakan untuk menghandle HTTP request dry-run penghapusan guru.
+// Response berisi kelas, mata pelajaran, dan siswa yang akan terdampak dengan policy yang dipilih,
+// tanpa menghapus data apa pun.
+func (gc *Gurucontroller) PreviewDeleteGuru(w http.ResponseWriter, r *http.Request) error {
+	// Ambil ID guru dari parameter query (?id=)
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "ID guru tidak ditemukan dalam query parameter", http.StatusBadRequest)
+		return fmt.Errorf("guru controller: ID guru tidak ditemukan dalam query parameter")
+	}
+
+	// Baca policy hapus dari query parameter (?policy=block|reassign|cascade&target=)
+	opts, err := helper.DeleteOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	impact, err := gc.guruService.PreviewDelete(r.Context(), id, opts)
+	if err != nil {
+		if strings.Contains(err.Error(), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			http.Error(w, "Data guru tidak ditemukan", http.StatusNotFound)
+			return err
+		}
+		return fmt.Errorf("guru controller: gagal mengambil data terdampak: %v", err)
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Dampak penghapusan data guru", impact)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("guru controller: error saat encoding response: %v", err)
+	}
+
+	return nil
+}
+
+// BulkGuru digunakan untuk menghandle HTTP request POST yang menjalankan banyak operasi
+// create, update, dan delete guru sekaligus. Operasi delete dapat memilih policy dan target.
+// Response berisi hasil per operasi. Fungsi ini akan mengembalikan error jika terjadi kesalahan.
+func (gc *Gurucontroller) BulkGuru(w http.ResponseWriter, r *http.Request) error {
+	// Cek service tidak nil.
+	if gc == nil || gc.guruService == nil {
+		return fmt.Errorf("guru controller: service is nil")
+	}
+
+	// Baca dan validasi daftar operasi dari body JSON.
+	req, err := helper.DecodeBulkRequest[GuruFormatter](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	// Ubah data setiap operasi ke core lalu jalankan di service.
+	result, err := gc.guruService.Bulk(r.Context(), req.Mode, helper.MapBulkOperations(req.Operations, FormatGuruRequestToCore))
+	if err != nil {
+		return fmt.Errorf("guru controller: gagal menjalankan bulk: %v", err)
+	}
+
+	// Kirim hasil per operasi dengan status sesuai hasil bulk.
+	helper.JSONResponse(w, result.StatusCode(), helper.APIResponse(result.StatusCode(), result.Message(), result))
+	return nil
+}
+
+// GetBebanMengajarGuru digunakan untuk menghandle HTTP request GET /guru/{id}/beban-mengajar.
+// Response berisi mata pelajaran dan kelas yang diajar guru, kelas yang diwalikan,
+// total jam per minggu, dan status beban mengajar.
+func (gc *Gurucontroller) GetBebanMengajarGuru(w http.ResponseWriter, r *http.Request) error {
+	// Ambil ID guru dari path (/guru/{id}/beban-mengajar)
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "ID guru tidak ditemukan dalam path", http.StatusBadRequest)
+		return fmt.Errorf("guru controller: ID guru tidak ditemukan dalam path")
+	}
+
+	beban, err := gc.guruService.GetBebanMengajar(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			http.Error(w, "Data guru tidak ditemukan", http.StatusNotFound)
+			return nil
+		}
+		return fmt.Errorf("guru controller: gagal mengambil beban mengajar: %v", err)
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil beban mengajar guru", beban)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("guru controller: error saat encoding response: %v", err)
+	}
+
+	return nil
+}
+
+// RekapBebanMengajar digunakan untuk menghandle HTTP request GET /guru/beban-mengajar.
+// Response berisi beban mengajar seluruh guru aktif, batas jam yang dipakai,
+// dan jumlah guru dengan beban kurang, normal, dan berlebih.
+func (gc *Gurucontroller) RekapBebanMengajar(w http.ResponseWriter, r *http.Request) error {
+	rekap, err := gc.guruService.GetRekapBebanMengajar(r.Context())
+	if err != nil {
+		return fmt.Errorf("guru controller: gagal mengambil rekap beban mengajar: %v", err)
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil rekap beban mengajar guru", rekap)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("guru controller: error saat encoding response: %v", err)
+	}
+
+	return nil
+}
+
+// filterDariQuery membaca filter daftar guru dari query parameter, misalnya
+// /guru?status_kepegawaian=PNS&pendidikan_terakhir=S1&sertifikasi=Matematika&tahun_masuk=2015.
+func filterDariQuery(r *http.Request) (guru.FilterGuru, error) {
+	q := r.URL.Query()
+	filter := guru.FilterGuru{
+		Nama:                q.Get("nama"),
+		NIP:                 q.Get("nip"),
+		NUPTK:               q.Get("nuptk"),
+		Status_Kepegawaian:  q.Get("status_kepegawaian"),
+		Pendidikan_Terakhir: q.Get("pendidikan_terakhir"),
+		Sertifikasi:         q.Get("sertifikasi"),
+	}
+	if v := q.Get("tahun_masuk"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return filter, fmt.Errorf("validation error: tahun_masuk harus berupa tahun")
+		}
+		filter.Tahun_Masuk = n
+	}
+	return filter, nil
+}
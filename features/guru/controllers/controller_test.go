@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"go_rest_native_sekolah/features/guru"
+	"go_rest_native_sekolah/helper"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,25 +21,29 @@ type mockServiceGuru struct {
 	mock.Mock
 }
 
-func (m *mockServiceGuru) GetAllGuru() ([]guru.GuruCore, error) {
-	args := m.Called()
+func (m *mockServiceGuru) GetAllGuru(ctx context.Context, filter guru.FilterGuru) ([]guru.GuruCore, error) {
+	// Meniru pgx: query dengan context yang sudah dibatalkan langsung gagal
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	args := m.Called(filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]guru.GuruCore), args.Error(1)
 }
 
-func (m *mockServiceGuru) InsertGuru(insert *guru.GuruCore) error {
+func (m *mockServiceGuru) InsertGuru(ctx context.Context, insert *guru.GuruCore) error {
 	args := m.Called(insert)
 	return args.Error(0)
 }
 
-func (m *mockServiceGuru) UpdateGuru(insert *guru.GuruCore, id string) error {
+func (m *mockServiceGuru) UpdateGuru(ctx context.Context, insert *guru.GuruCore, id string) error {
 	args := m.Called(insert, id)
 	return args.Error(0)
 }
 
-func (m *mockServiceGuru) SelectById(id string) (*guru.GuruCore, error) {
+func (m *mockServiceGuru) SelectById(ctx context.Context, id string) (*guru.GuruCore, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -45,11 +51,43 @@ func (m *mockServiceGuru) SelectById(id string) (*guru.GuruCore, error) {
 	return args.Get(0).(*guru.GuruCore), args.Error(1)
 }
 
-func (m *mockServiceGuru) DeleteById(id string) error {
-	args := m.Called(id)
+func (m *mockServiceGuru) DeleteById(ctx context.Context, id string, version int, opts helper.DeleteOptions) error {
+	args := m.Called(id, version, opts)
 	return args.Error(0)
 }
 
+func (m *mockServiceGuru) PreviewDelete(ctx context.Context, id string, opts helper.DeleteOptions) (*helper.DeleteImpact, error) {
+	args := m.Called(id, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*helper.DeleteImpact), args.Error(1)
+}
+
+func (m *mockServiceGuru) Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[guru.GuruCore]) (*helper.BulkResult, error) {
+	args := m.Called(mode, ops)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*helper.BulkResult), args.Error(1)
+}
+
+func (m *mockServiceGuru) GetBebanMengajar(ctx context.Context, id string) (*guru.BebanMengajar, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*guru.BebanMengajar), args.Error(1)
+}
+
+func (m *mockServiceGuru) GetRekapBebanMengajar(ctx context.Context) (*guru.RekapBebanMengajar, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*guru.RekapBebanMengajar), args.Error(1)
+}
+
 // Test GetAllGuru Controller
 func TestGetAllGuruController(t *testing.T) {
 	mockService := new(mockServiceGuru)
@@ -66,7 +104,7 @@ func TestGetAllGuruController(t *testing.T) {
 			},
 		}
 
-		mockService.On("GetAllGuru").Return(expectedGurus, nil).Once()
+		mockService.On("GetAllGuru", guru.FilterGuru{}).Return(expectedGurus, nil).Once()
 
 		controller := NewGuruController(mockService)
 		w := httptest.NewRecorder()
@@ -80,7 +118,7 @@ func TestGetAllGuruController(t *testing.T) {
 	})
 
 	t.Run("failed get all guru - service error", func(t *testing.T) {
-		mockService.On("GetAllGuru").Return(nil, errors.New("service error")).Once()
+		mockService.On("GetAllGuru", guru.FilterGuru{}).Return(nil, errors.New("service error")).Once()
 
 		controller := NewGuruController(mockService)
 		w := httptest.NewRecorder()
@@ -91,6 +129,51 @@ func TestGetAllGuruController(t *testing.T) {
 		assert.Error(t, err)
 		mockService.AssertExpectations(t)
 	})
+
+	t.Run("failed get all guru - request dibatalkan client", func(t *testing.T) {
+		mockService := new(mockServiceGuru)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/guru", nil).WithContext(ctx)
+
+		err := controller.Guru(w, r)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), context.Canceled.Error())
+		mockService.AssertNotCalled(t, "GetAllGuru", mock.Anything)
+	})
+
+	t.Run("success get all guru - filter dari query", func(t *testing.T) {
+		mockService := new(mockServiceGuru)
+		mockService.On("GetAllGuru", guru.FilterGuru{Status_Kepegawaian: "PNS", Sertifikasi: "Matematika", Tahun_Masuk: 2015}).Return([]guru.GuruCore{}, nil).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/guru?status_kepegawaian=PNS&sertifikasi=Matematika&tahun_masuk=2015", nil)
+
+		err := controller.Guru(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("failed get all guru - tahun masuk tidak valid", func(t *testing.T) {
+		mockService := new(mockServiceGuru)
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/guru?tahun_masuk=abc", nil)
+
+		err := controller.Guru(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "GetAllGuru", mock.Anything)
+	})
 }
 
 // Test InsertGuru Controller
@@ -153,6 +236,7 @@ func TestGetGuruByIdController(t *testing.T) {
 			Email:     "john@example.com",
 			Alamat:    "Jl. Merdeka No. 1",
 			Update_At: time.Now(),
+			Version:   2,
 		}
 
 		mockService.On("SelectById", "guru-001").Return(expectedGuru, nil).Once()
@@ -165,6 +249,7 @@ func TestGetGuruByIdController(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
 	})
 
 	t.Run("failed get guru by id - missing id parameter", func(t *testing.T) {
@@ -209,6 +294,7 @@ func TestUpdateGuruController(t *testing.T) {
 			Nama:   "John Updated",
 			Email:  "john.updated@example.com",
 			Alamat: "Jl. Merdeka No. 2",
+			Version: 1,
 		}
 
 		mockService.On("SelectById", "guru-001").Return(existingGuru, nil).Once()
@@ -225,12 +311,44 @@ func TestUpdateGuruController(t *testing.T) {
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPut, "/guru?id=guru-001", bytes.NewReader(requestBody))
 		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("If-Match", `"1"`)
 
 		err := controller.UpdateGuru(w, r)
 
 		assert.NoError(t, err)
 	})
 
+	t.Run("failed update guru - missing If-Match", func(t *testing.T) {
+		mockService := new(mockServiceGuru)
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/guru?id=guru-001", bytes.NewReader([]byte(`{"nama":"John"}`)))
+		r.Header.Set("Content-Type", "application/json")
+
+		err := controller.UpdateGuru(w, r)
+
+		assert.ErrorIs(t, err, helper.ErrPreconditionRequired)
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+		mockService.AssertNotCalled(t, "UpdateGuru", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed update guru - version conflict", func(t *testing.T) {
+		mockService := new(mockServiceGuru)
+		mockService.On("UpdateGuru", mock.Anything, "guru-001").Return(helper.ErrVersionConflict).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/guru?id=guru-001", bytes.NewReader([]byte(`{"nama":"John"}`)))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("If-Match", `"1"`)
+
+		err := controller.UpdateGuru(w, r)
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
 	t.Run("failed update guru - missing id parameter", func(t *testing.T) {
 		controller := NewGuruController(mockService)
 		w := httptest.NewRecorder()
@@ -248,11 +366,12 @@ func TestDeleteGuruController(t *testing.T) {
 	mockService := new(mockServiceGuru)
 
 	t.Run("success delete guru", func(t *testing.T) {
-		mockService.On("DeleteById", "guru-001").Return(nil).Once()
+		mockService.On("DeleteById", "guru-001", 1, helper.DeleteOptions{}).Return(nil).Once()
 
 		controller := NewGuruController(mockService)
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodDelete, "/guru?id=guru-001", nil)
+		r.Header.Set("If-Match", `"1"`)
 
 		err := controller.DeleteGuru(w, r)
 
@@ -270,4 +389,216 @@ func TestDeleteGuruController(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+	t.Run("success delete guru - reassign to another guru", func(t *testing.T) {
+		opts := helper.DeleteOptions{Policy: helper.DeletePolicyReassign, TargetID: "guru-002"}
+		mockService.On("DeleteById", "guru-001", 2, opts).Return(nil).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/guru/deleted?id=guru-001&policy=reassign&target=guru-002", nil)
+		r.Header.Set("If-Match", `"2"`)
+
+		err := controller.DeleteGuru(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("failed delete guru - blocked by dependents", func(t *testing.T) {
+		impact := helper.NewDeleteImpact("guru-003", helper.DeleteOptions{Policy: helper.DeletePolicyBlock}, []helper.Dependent{
+			{Tabel: "kelas", ID: "kelas-001", Nama: "10A", Langsung: true},
+		})
+		mockService.On("DeleteById", "guru-003", 1, helper.DeleteOptions{}).Return(&helper.DeleteBlockedError{Impact: impact}).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/guru/deleted?id=guru-003", nil)
+		r.Header.Set("If-Match", `"1"`)
+
+		err := controller.DeleteGuru(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "kelas-001")
+	})
+
+	t.Run("failed delete guru - unknown policy", func(t *testing.T) {
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/guru/deleted?id=guru-001&policy=hapus-semua", nil)
+		r.Header.Set("If-Match", `"1"`)
+
+		err := controller.DeleteGuru(w, r)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("failed delete guru - version conflict", func(t *testing.T) {
+		mockService.On("DeleteById", "guru-001", 1, helper.DeleteOptions{}).Return(helper.ErrVersionConflict).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/guru/deleted?id=guru-001", nil)
+		r.Header.Set("If-Match", `"1"`)
+
+		err := controller.DeleteGuru(w, r)
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("failed delete guru - missing If-Match", func(t *testing.T) {
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/guru/deleted?id=guru-001", nil)
+
+		err := controller.DeleteGuru(w, r)
+
+		assert.ErrorIs(t, err, helper.ErrPreconditionRequired)
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	})
+}
+
+// Test PreviewDeleteGuru Controller
+func TestPreviewDeleteGuruController(t *testing.T) {
+	mockService := new(mockServiceGuru)
+
+	t.Run("success preview delete guru", func(t *testing.T) {
+		opts := helper.DeleteOptions{Policy: helper.DeletePolicyCascade}
+		impact := helper.NewDeleteImpact("guru-001", opts, []helper.Dependent{
+			{Tabel: "kelas", ID: "kelas-001", Nama: "10A", Langsung: true},
+			{Tabel: "siswa", ID: "siswa-001", Nama: "Ahmad Rauf"},
+		})
+		mockService.On("PreviewDelete", "guru-001", opts).Return(impact, nil).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/guru/deleted/preview?id=guru-001&policy=cascade", nil)
+
+		err := controller.PreviewDeleteGuru(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "siswa-001")
+		mockService.AssertNotCalled(t, "DeleteById", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed preview delete guru - not found", func(t *testing.T) {
+		mockService.On("PreviewDelete", "999", helper.DeleteOptions{}).Return(nil, errors.New("guru service: Data tidak ditemukan")).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/guru/deleted/preview?id=999", nil)
+
+		err := controller.PreviewDeleteGuru(w, r)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+// Test BulkGuru Controller
+func TestBulkGuruController(t *testing.T) {
+	mockService := new(mockServiceGuru)
+
+	t.Run("success bulk guru - partial result", func(t *testing.T) {
+		result := &helper.BulkResult{
+			Mode:     helper.BulkModePartial,
+			Berhasil: 1,
+			Gagal:    1,
+			Items: []helper.BulkItemResult{
+				{Index: 0, Action: helper.BulkActionDelete, ID: "guru-001", Status: http.StatusOK},
+				{Index: 1, Action: helper.BulkActionDelete, ID: "guru-002", Status: http.StatusPreconditionFailed, Error: helper.ErrVersionConflict.Error()},
+			},
+		}
+		mockService.On("Bulk", helper.BulkModePartial, mock.Anything).Return(result, nil).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		body := `{"mode":"partial","operations":[{"action":"delete","id":"guru-001","version":1},{"action":"delete","id":"guru-002","version":1,"policy":"cascade"}]}`
+		r := httptest.NewRequest(http.MethodPost, "/guru/bulk", bytes.NewBufferString(body))
+
+		err := controller.BulkGuru(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		assert.Contains(t, w.Body.String(), "guru-002")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("failed bulk guru - delete without version", func(t *testing.T) {
+		mockService := new(mockServiceGuru)
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		body := `{"operations":[{"action":"delete","id":"guru-001"}]}`
+		r := httptest.NewRequest(http.MethodPost, "/guru/bulk", bytes.NewBufferString(body))
+
+		err := controller.BulkGuru(w, r)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Bulk", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed bulk guru - unknown action", func(t *testing.T) {
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		body := `{"operations":[{"action":"archive","id":"guru-001","version":1}]}`
+		r := httptest.NewRequest(http.MethodPost, "/guru/bulk", bytes.NewBufferString(body))
+
+		err := controller.BulkGuru(w, r)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// Test BebanMengajar Controller
+func TestBebanMengajarGuruController(t *testing.T) {
+	mockService := new(mockServiceGuru)
+
+	t.Run("success beban mengajar guru", func(t *testing.T) {
+		mockService.On("GetBebanMengajar", "guru-001").Return(&guru.BebanMengajar{ID_Guru: "guru-001", Nama: "Budi", TotalJam: 24, Status: guru.StatusBebanNormal}, nil).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/guru/guru-001/beban-mengajar", nil)
+		r.SetPathValue("id", "guru-001")
+
+		err := controller.GetBebanMengajarGuru(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"total_jam":24`)
+	})
+
+	t.Run("failed beban mengajar guru - not found", func(t *testing.T) {
+		mockService.On("GetBebanMengajar", "999").Return(nil, errors.New("guru service: Data tidak ditemukan")).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/guru/999/beban-mengajar", nil)
+		r.SetPathValue("id", "999")
+
+		err := controller.GetBebanMengajarGuru(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("success rekap beban mengajar", func(t *testing.T) {
+		mockService.On("GetRekapBebanMengajar").Return(&guru.RekapBebanMengajar{JumlahGuru: 1, JumlahBerlebih: 1}, nil).Once()
+
+		controller := NewGuruController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/guru/beban-mengajar", nil)
+
+		err := controller.RekapBebanMengajar(w, r)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"jumlah_berlebih":1`)
+	})
 }
@@ -10,6 +10,21 @@ type GuruFormatter struct {
 	Nama    string `json:"nama"`    // Nama adalah nama lengkap dari guru
 	Email   string `json:"email"`   // Email adalah alamat email dari guru
 	Alamat  string `json:"alamat"`  // Alamat adalah alamat tempat tinggal dari guru
+	Version int    `json:"version"` // Version adalah versi data yang juga dikirim sebagai ETag
+
+	// Data kepegawaian guru. Sertifikasi yang tidak dikirim saat update berarti tidak diubah.
+	NIP                 string   `json:"nip"`
+	NUPTK               string   `json:"nuptk"`
+	Status_Kepegawaian  string   `json:"status_kepegawaian"`
+	Pendidikan_Terakhir string   `json:"pendidikan_terakhir"`
+	Sertifikasi         []string `json:"sertifikasi"`
+	Telepon             string   `json:"telepon"`
+	Tanggal_Masuk       string   `json:"tanggal_masuk"`
+
+	// Username dan Password hanya dipakai saat request insert untuk membuat akun users
+	// jika email guru belum terdaftar. Keduanya tidak pernah diisi di response.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
 }
 
 // FormatGuruList digunakan untuk mengubah slice GuruCore menjadi slice GuruFormatter.
@@ -26,6 +41,15 @@ func FormatGuruList(cores []guru.GuruCore) []GuruFormatter {
 			Nama:    core.Nama,    // Nama adalah nama lengkap dari guru
 			Email:   core.Email,   // Email adalah alamat email dari guru
 			Alamat:  core.Alamat,  // Alamat adalah alamat tempat tinggal dari guru
+			Version: core.Version, // Version adalah versi data yang juga dikirim sebagai ETag
+			// Data kepegawaian guru.
+			NIP:                 core.NIP,
+			NUPTK:               core.NUPTK,
+			Status_Kepegawaian:  core.Status_Kepegawaian,
+			Pendidikan_Terakhir: core.Pendidikan_Terakhir,
+			Sertifikasi:         core.Sertifikasi,
+			Telepon:             core.Telepon,
+			Tanggal_Masuk:       core.Tanggal_Masuk,
 		})
 	}
 	// Mengembalikan slice GuruFormatter yang telah di format.
@@ -48,6 +72,17 @@ func FormatGuruRequestToCore(req GuruFormatter) guru.GuruCore {
 	core.Email = req.Email
 	// Alamat adalah alamat tempat tinggal dari guru.
 	core.Alamat = req.Alamat
+	// Data kepegawaian guru.
+	core.NIP = req.NIP
+	core.NUPTK = req.NUPTK
+	core.Status_Kepegawaian = req.Status_Kepegawaian
+	core.Pendidikan_Terakhir = req.Pendidikan_Terakhir
+	core.Sertifikasi = req.Sertifikasi
+	core.Telepon = req.Telepon
+	core.Tanggal_Masuk = req.Tanggal_Masuk
+	// Username dan Password untuk akun users guru baru.
+	core.Username = req.Username
+	core.Password = req.Password
 	// Mengembalikan objek GuruCore yang telah di format.
 	return core
 }
@@ -1,37 +1,176 @@
 package guru
 
-import "time"
+import (
+	"context"
+	"go_rest_native_sekolah/features/users"
+	"go_rest_native_sekolah/helper"
+	"time"
+)
 
 type ( // GuruCore struct untuk merepresentasikan tabel guru
 	GuruCore struct { // Guru struct untuk merepresentasikan tabel guru
-		ID        string     `json:"id"`
-		ID_User   string     `json:"id_user"`
-		Nama      string     `json:"nama"`
-		Email     string     `json:"email"`
-		Alamat    string     `json:"alamat"`
-		Update_At time.Time  `json:"update_at"`
-		Delete_At *time.Time `json:"delete_at"`
+		ID      string `json:"id"`
+		ID_User string `json:"id_user"`
+		Nama    string `json:"nama"`
+		Email   string `json:"email"`
+		Alamat  string `json:"alamat"`
+		// Data kepegawaian guru. NIP (18 digit) dan NUPTK (16 digit) unik di antara guru aktif,
+		// NIP wajib untuk guru berstatus PNS.
+		NIP                 string     `json:"nip"`
+		NUPTK               string     `json:"nuptk"`
+		Status_Kepegawaian  string     `json:"status_kepegawaian"`  // Salah satu dari DaftarStatusKepegawaian
+		Pendidikan_Terakhir string     `json:"pendidikan_terakhir"` // Salah satu dari DaftarPendidikan
+		Sertifikasi         []string   `json:"sertifikasi"`         // Bidang studi sertifikasi guru; nil pada update berarti tidak diubah
+		Telepon             string     `json:"telepon"`
+		Tanggal_Masuk       string     `json:"tanggal_masuk"` // Tanggal mulai bekerja dengan format YYYY-MM-DD
+		Update_At           time.Time  `json:"update_at"`
+		Delete_At           *time.Time `json:"delete_at"`
+		// Version bertambah setiap kali data diubah atau dihapus dan dikirim sebagai ETag.
+		// Pada update, Version berisi versi dari header If-Match.
+		Version int `json:"version"`
+
+		// Username dan Password dipakai untuk membuat akun users dengan role guru
+		// ketika email guru belum terdaftar. Keduanya tidak disimpan di tabel guru.
+		Username string `json:"-"`
+		Password string `json:"-"`
+	}
+
+	// FilterGuru berisi filter opsional untuk daftar guru. Field kosong berarti tidak difilter.
+	FilterGuru struct {
+		Nama                string // Nama mengandung teks ini (tidak membedakan huruf besar/kecil)
+		NIP                 string
+		NUPTK               string
+		Status_Kepegawaian  string
+		Pendidikan_Terakhir string
+		Sertifikasi         string // Guru yang memiliki sertifikasi bidang studi ini
+		Tahun_Masuk         int    // Guru yang mulai bekerja pada tahun ini
+	}
+
+	// Repositories berisi repository yang dipakai bersama dalam satu transaksi
+	// saat data guru dan akun users-nya dibuat sekaligus.
+	Repositories struct {
+		Guru  DataGuruInterface
+		Users users.DataUserInterface
+	}
+
+	// BatasBebanMengajar berisi batas jam mengajar per minggu untuk menentukan status beban mengajar.
+	BatasBebanMengajar struct {
+		MinJam int `json:"min_jam"`
+		MaxJam int `json:"max_jam"`
+	}
+
+	// BebanMengajar berisi mata pelajaran, kelas, dan jam mengajar per minggu seorang guru.
+	BebanMengajar struct {
+		ID_Guru       string      `json:"id_guru"`
+		Nama          string      `json:"nama"`
+		Email         string      `json:"email"`
+		WaliKelas     []KelasAjar `json:"wali_kelas"` // Kelas yang kelas.id_guru-nya guru ini
+		MataPelajaran []MapelAjar `json:"mata_pelajaran"`
+		Kelas         []KelasAjar `json:"kelas"` // Kelas berbeda yang diajar lewat mata pelajaran
+		JumlahMapel   int         `json:"jumlah_mapel"`
+		JumlahKelas   int         `json:"jumlah_kelas"`
+		TotalJam      int         `json:"total_jam"` // Jumlah jam_per_minggu semua mata pelajaran
+		Status        string      `json:"status"`    // StatusBebanKurang, StatusBebanNormal, atau StatusBebanBerlebih
+	}
+
+	// MapelAjar adalah mata pelajaran yang diajar guru beserta kelasnya.
+	MapelAjar struct {
+		ID             string `json:"id"`
+		Nama_Pelajaran string `json:"mata_pelajaran"`
+		Kelas_ID       string `json:"kelas_id"`
+		Nama_Kelas     string `json:"nama_kelas"`
+		Jam_Per_Minggu int    `json:"jam_per_minggu"`
+	}
+
+	// KelasAjar adalah kelas yang diajar atau diwalikan guru.
+	KelasAjar struct {
+		ID    string `json:"id"`
+		Kelas string `json:"kelas"`
+	}
+
+	// RekapBebanMengajar adalah laporan beban mengajar seluruh guru aktif beserta totalnya.
+	RekapBebanMengajar struct {
+		Batas          BatasBebanMengajar `json:"batas"`
+		JumlahGuru     int                `json:"jumlah_guru"`
+		JumlahKurang   int                `json:"jumlah_kurang"`
+		JumlahNormal   int                `json:"jumlah_normal"`
+		JumlahBerlebih int                `json:"jumlah_berlebih"`
+		TotalJam       int                `json:"total_jam"`
+		Guru           []BebanMengajar    `json:"guru"`
 	}
 
 	DataGuruInterface interface { // Interface untuk mengakses data guru
 		// SelectAllGuru digunakan untuk mengambil semua data guru dari database.
 		// Fungsi ini mengembalikan slice dari GuruCore yang berisi data guru.
 		// Jika terjadi kesalahan selama pengambilan data, fungsi ini akan mengembalikan error.
-		SelectAllGuru() ([]GuruCore, error)
-		InsertGuru(insert *GuruCore) error
-		Update(insert *GuruCore, id string) error
-		SelectById(id string) (*GuruCore, error)
-		DeleteById(id string) error
+		// Parameter filter membatasi guru yang diambil; FilterGuru kosong berarti semua guru aktif.
+		SelectAllGuru(ctx context.Context, filter FilterGuru) ([]GuruCore, error)
+		InsertGuru(ctx context.Context, insert *GuruCore) error
+		Update(ctx context.Context, insert *GuruCore, id string) error
+		SelectById(ctx context.Context, id string) (*GuruCore, error)
+		// DeleteById menghapus guru jika versinya masih sama dengan version.
+		// Fungsi ini mengembalikan helper.ErrVersionConflict jika versi sudah berubah.
+		DeleteById(ctx context.Context, id string, version int) error
+		// LockById memastikan guru aktif ada dan menguncinya sampai transaksi selesai.
+		// Fungsi ini mengembalikan pgx.ErrNoRows jika guru tidak ditemukan.
+		LockById(ctx context.Context, id string) error
+		// ListDependents mengambil kelas (wali kelas) dan mata pelajaran yang diajar guru,
+		// serta siswa dan mata pelajaran di kelas tersebut, dan tugas, jadwal mengajar, serta jadwal ujian
+		// mata pelajaran yang ikut terhapus sebagai dependents tidak langsung.
+		ListDependents(ctx context.Context, id string) ([]helper.Dependent, error)
+		// ReassignDependents memindahkan wali kelas dan pengajar mata pelajaran ke guru targetID.
+		ReassignDependents(ctx context.Context, id, targetID string) error
+		// CascadeDelete ikut menghapus (soft delete) kelas dan mata pelajaran milik guru,
+		// beserta siswa dan mata pelajaran di kelas tersebut dan tugas, jadwal mengajar, serta jadwal ujian
+		// mata pelajaran yang terhapus.
+		CascadeDelete(ctx context.Context, id string) error
+		// SelectBebanMengajar mengambil guru aktif beserta mata pelajaran yang diajar dan kelas yang diwalikan.
+		// Jika id kosong, semua guru aktif diambil. Total dan status dihitung oleh service.
+		SelectBebanMengajar(ctx context.Context, id string) ([]BebanMengajar, error)
 	}
 
 	ServiceGuruInterface interface { // Interface untuk mengakses logika bisnis guru
 		// GetAllGuru digunakan untuk mengambil semua data guru dari database.
 		// Fungsi ini mengembalikan slice dari GuruCore yang berisi data guru.
 		// Jika terjadi kesalahan selama pengambilan data, fungsi ini akan mengembalikan error.
-		GetAllGuru() ([]GuruCore, error)
-		InsertGuru(insert *GuruCore) error
-		UpdateGuru(insert *GuruCore, id string) error
-		SelectById(id string) (*GuruCore, error)
-		DeleteById(id string) error
+		// Parameter filter membatasi guru yang diambil; FilterGuru kosong berarti semua guru aktif.
+		GetAllGuru(ctx context.Context, filter FilterGuru) ([]GuruCore, error)
+		InsertGuru(ctx context.Context, insert *GuruCore) error
+		UpdateGuru(ctx context.Context, insert *GuruCore, id string) error
+		SelectById(ctx context.Context, id string) (*GuruCore, error)
+		// DeleteById menghapus data guru. Kelas dan mata pelajaran milik guru ditangani sesuai opts.Policy.
+		// Fungsi ini mengembalikan *helper.DeleteBlockedError jika penghapusan ditolak.
+		// Version harus sama dengan versi guru saat ini, jika tidak dikembalikan helper.ErrVersionConflict.
+		DeleteById(ctx context.Context, id string, version int, opts helper.DeleteOptions) error
+		// PreviewDelete mengembalikan data yang terdampak jika guru dihapus, tanpa menghapusnya.
+		PreviewDelete(ctx context.Context, id string, opts helper.DeleteOptions) (*helper.DeleteImpact, error)
+		// Bulk menjalankan banyak operasi create, update, dan delete guru sekaligus sesuai mode
+		// dan mengembalikan hasil per operasi.
+		Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[GuruCore]) (*helper.BulkResult, error)
+		// GetBebanMengajar mengembalikan mata pelajaran, kelas, status wali kelas, dan total jam per minggu seorang guru.
+		GetBebanMengajar(ctx context.Context, id string) (*BebanMengajar, error)
+		// GetRekapBebanMengajar mengembalikan beban mengajar seluruh guru aktif beserta jumlah guru
+		// yang bebannya kurang, normal, dan berlebih menurut BatasBebanMengajar.
+		GetRekapBebanMengajar(ctx context.Context) (*RekapBebanMengajar, error)
 	}
 )
+
+// Status beban mengajar guru dibandingkan dengan BatasBebanMengajar.
+const (
+	StatusBebanKurang   = "kurang"   // Total jam di bawah MinJam
+	StatusBebanNormal   = "normal"   // Total jam di antara MinJam dan MaxJam
+	StatusBebanBerlebih = "berlebih" // Total jam di atas MaxJam
+)
+
+// Status kepegawaian guru.
+const (
+	StatusPNS     = "PNS"     // Pegawai negeri sipil
+	StatusHonorer = "Honorer" // Guru honorer
+	StatusGTT     = "GTT"     // Guru tidak tetap
+)
+
+// DaftarStatusKepegawaian adalah nilai status_kepegawaian yang diterima.
+var DaftarStatusKepegawaian = []string{StatusPNS, StatusHonorer, StatusGTT}
+
+// DaftarPendidikan adalah nilai pendidikan_terakhir yang diterima, dari yang terendah.
+var DaftarPendidikan = []string{"SMA", "D1", "D2", "D3", "D4", "S1", "S2", "S3"}
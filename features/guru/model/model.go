@@ -13,14 +13,24 @@ import (
 // 4. Alamat (string) sebagai alamat guru
 // 5. Update_At (time.Time) sebagai waktu update data guru
 // 6. Delete_At (*time.Time) sebagai waktu delete data guru
+// 7. Version (int) sebagai versi data untuk optimistic concurrency
+// 8. Data kepegawaian: NIP, NUPTK, status kepegawaian, pendidikan terakhir, sertifikasi, telepon, dan tanggal masuk
 type Guru struct {
-	ID        string     `json:"id"`
-	ID_User   string     `json:"id_user"`
-	Nama      string     `json:"nama"`
-	Email     string     `json:"email"`
-	Alamat    string     `json:"alamat"`
-	Update_At time.Time  `json:"update_at"`
-	Delete_At *time.Time `json:"delete_at"`
+	ID                  string     `json:"id"`
+	ID_User             string     `json:"id_user"`
+	Nama                string     `json:"nama"`
+	Email               string     `json:"email"`
+	Alamat              string     `json:"alamat"`
+	NIP                 string     `json:"nip"`
+	NUPTK               string     `json:"nuptk"`
+	Status_Kepegawaian  string     `json:"status_kepegawaian"`
+	Pendidikan_Terakhir string     `json:"pendidikan_terakhir"`
+	Sertifikasi         []string   `json:"sertifikasi"`
+	Telepon             string     `json:"telepon"`
+	Tanggal_Masuk       string     `json:"tanggal_masuk"`
+	Update_At           time.Time  `json:"update_at"`
+	Delete_At           *time.Time `json:"delete_at"`
+	Version             int        `json:"version"`
 }
 
 // TableName digunakan untuk mengembalikan nama tabel yang digunakan dalam database
@@ -35,12 +45,20 @@ func (u *Guru) TableName() string {
 func FormatterRequest(req guru.GuruCore) Guru {
 	// Membuat objek Guru dan mengisi dengan data dari objek GuruCore.
 	return Guru{
-		ID:        req.ID,      // Mengisi field ID dengan ID dari objek GuruCore.
-		ID_User:   req.ID_User, // Mengisi field ID_User dengan ID_User dari objek GuruCore.
-		Nama:      req.Nama,    // Mengisi field Nama dengan Nama dari objek GuruCore.
-		Email:     req.Email,   // Mengisi field Email dengan Email dari objek GuruCore.
-		Alamat:    req.Alamat,  // Mengisi field Alamat dengan Alamat dari objek GuruCore.
-		Update_At: time.Now(),  // Mengisi field Update_At dengan waktu saat ini.
+		ID:      req.ID,      // Mengisi field ID dengan ID dari objek GuruCore.
+		ID_User: req.ID_User, // Mengisi field ID_User dengan ID_User dari objek GuruCore.
+		Nama:    req.Nama,    // Mengisi field Nama dengan Nama dari objek GuruCore.
+		Email:   req.Email,   // Mengisi field Email dengan Email dari objek GuruCore.
+		Alamat:  req.Alamat,  // Mengisi field Alamat dengan Alamat dari objek GuruCore.
+		// Data kepegawaian guru.
+		NIP:                 req.NIP,
+		NUPTK:               req.NUPTK,
+		Status_Kepegawaian:  req.Status_Kepegawaian,
+		Pendidikan_Terakhir: req.Pendidikan_Terakhir,
+		Sertifikasi:         req.Sertifikasi,
+		Telepon:             req.Telepon,
+		Tanggal_Masuk:       req.Tanggal_Masuk,
+		Update_At:           time.Now(), // Mengisi field Update_At dengan waktu saat ini.
 	}
 }
 
@@ -55,11 +73,19 @@ func FormatterResponse(res Guru) guru.GuruCore {
 	// Email adalah alamat email dari guru.
 	// Alamat adalah alamat tempat tinggal dari guru.
 	return guru.GuruCore{
-		ID:      res.ID,
-		ID_User: res.ID_User,
-		Nama:    res.Nama,
-		Email:   res.Email,
-		Alamat:  res.Alamat,
+		ID:                  res.ID,
+		ID_User:             res.ID_User,
+		Nama:                res.Nama,
+		Email:               res.Email,
+		Alamat:              res.Alamat,
+		NIP:                 res.NIP,
+		NUPTK:               res.NUPTK,
+		Status_Kepegawaian:  res.Status_Kepegawaian,
+		Pendidikan_Terakhir: res.Pendidikan_Terakhir,
+		Sertifikasi:         res.Sertifikasi,
+		Telepon:             res.Telepon,
+		Tanggal_Masuk:       res.Tanggal_Masuk,
+		Version:             res.Version,
 	}
 }
 
@@ -6,43 +6,126 @@ import (
 	"errors"
 	"fmt"
 	"go_rest_native_sekolah/features/guru"
-	"log"
+	"go_rest_native_sekolah/helper"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // guruQuery merepresentasikan query yang berhubungan dengan tabel guru.
 // guruQuery menggunakan database PostgreSQL untuk menghandle query ke database.
 type guruQuery struct {
-	db *pgxpool.Pool
+	db helper.DBTX
 }
 
 // NewDataGuru membuat objek guruQuery dengan parameter db.
 // guruQuery digunakan untuk menghandle query ke database yang berhubungan dengan tabel guru.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
 // Jika parameter db nil maka akan terjadi panic.
-func NewDataGuru(db *pgxpool.Pool) guru.DataGuruInterface {
+func NewDataGuru(db helper.DBTX) guru.DataGuruInterface {
 	if db == nil {
 		panic("guru model: Nil database")
 	}
 	return &guruQuery{db: db}
 }
 
-// SelectAllGuru digunakan untuk mengambil semua data guru dari database.
+// kolomGuru adalah daftar kolom yang diambil untuk setiap guru, sesuai urutan scanGuru.
+// Data kepegawaian yang NULL dikembalikan sebagai nilai kosong dan tanggal diformat YYYY-MM-DD.
+const kolomGuru = `id, id_user, nama, email, alamat,
+	COALESCE(nip, ''), COALESCE(nuptk, ''), COALESCE(status_kepegawaian, ''), COALESCE(pendidikan_terakhir, ''),
+	COALESCE(sertifikasi, '{}'), COALESCE(telepon, ''), COALESCE(TO_CHAR(tanggal_masuk, 'YYYY-MM-DD'), ''), version`
+
+// scanGuru membaca satu baris hasil query dengan kolom kolomGuru ke dalam dst.
+// id_user bisa NULL sehingga dibaca lewat sql.NullString.
+func scanGuru(row pgx.Row, dst *Guru) error {
+	var idUser sql.NullString
+	err := row.Scan(&dst.ID, &idUser, &dst.Nama, &dst.Email, &dst.Alamat,
+		&dst.NIP, &dst.NUPTK, &dst.Status_Kepegawaian, &dst.Pendidikan_Terakhir,
+		&dst.Sertifikasi, &dst.Telepon, &dst.Tanggal_Masuk, &dst.Version)
+	dst.ID_User = idUser.String
+	return err
+}
+
+// kondisiFilter menyusun kondisi WHERE tambahan dan argumennya dari filter daftar guru.
+func kondisiFilter(filter guru.FilterGuru) (string, []any) {
+	var kondisi []string
+	var args []any
+	tambah := func(format string, nilai any) {
+		args = append(args, nilai)
+		kondisi = append(kondisi, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Nama != "" {
+		tambah("nama ILIKE '%%' || $%d || '%%'", filter.Nama)
+	}
+	if filter.NIP != "" {
+		tambah("nip = $%d", filter.NIP)
+	}
+	if filter.NUPTK != "" {
+		tambah("nuptk = $%d", filter.NUPTK)
+	}
+	if filter.Status_Kepegawaian != "" {
+		tambah("status_kepegawaian = $%d", filter.Status_Kepegawaian)
+	}
+	if filter.Pendidikan_Terakhir != "" {
+		tambah("pendidikan_terakhir = $%d", filter.Pendidikan_Terakhir)
+	}
+	if filter.Sertifikasi != "" {
+		tambah("EXISTS (SELECT 1 FROM unnest(sertifikasi) AS s WHERE LOWER(s) = LOWER($%d))", filter.Sertifikasi)
+	}
+	if filter.Tahun_Masuk > 0 {
+		tambah("EXTRACT(YEAR FROM tanggal_masuk) = $%d", filter.Tahun_Masuk)
+	}
+
+	if len(kondisi) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(kondisi, " AND "), args
+}
+
+// cekNomorInduk memastikan NIP dan NUPTK data tidak dipakai guru aktif lain selain guru id.
+// Fungsi ini mengembalikan validation error jika nomor sudah dipakai.
+func (r *guruQuery) cekNomorInduk(ctx context.Context, data *guru.GuruCore, id string) error {
+	nomor := []struct{ kolom, nilai string }{
+		{"nip", data.NIP},
+		{"nuptk", data.NUPTK},
+	}
+	for _, n := range nomor {
+		if n.nilai == "" {
+			continue
+		}
+		// Nama kolom berasal dari daftar tetap di atas, bukan dari input client.
+		var dipakai bool
+		err := r.db.QueryRow(ctx,
+			"SELECT EXISTS (SELECT 1 FROM guru WHERE "+n.kolom+" = $1 AND id <> $2 AND delete_at IS NULL)",
+			n.nilai, id).Scan(&dipakai)
+		if err != nil {
+			helper.LoggerFromContext(ctx).Error("cekNomorInduk error query", "error", err)
+			return fmt.Errorf("cek %s failed: %w", n.kolom, err)
+		}
+		if dipakai {
+			return fmt.Errorf("validation error: %s '%s' sudah dipakai guru lain", strings.ToUpper(n.kolom), n.nilai)
+		}
+	}
+	return nil
+}
+
+// SelectAllGuru digunakan untuk mengambil data guru dari database yang sesuai dengan filter.
 // Fungsi ini akan mengembalikan slice guru.GuruCore yang berisi data guru.
 // Jika terjadi error maka fungsi ini akan mengembalikan error.
-func (r *guruQuery) SelectAllGuru() ([]guru.GuruCore, error) {
+func (r *guruQuery) SelectAllGuru(ctx context.Context, filter guru.FilterGuru) ([]guru.GuruCore, error) {
 	// Validasi apakah database nil
 	if r.db == nil {
 		return nil, errors.New("guru model: Nil database")
 	}
 
-	// Query untuk mengambil semua data guru
-	query := "SELECT id, id_user, nama, email, alamat FROM guru WHERE delete_at IS NULL"
+	// Query untuk mengambil data guru; nilai filter dikirim sebagai parameter query
+	where, args := kondisiFilter(filter)
+	query := "SELECT " + kolomGuru + " FROM guru WHERE delete_at IS NULL" + where + " ORDER BY nama"
 
 	// Jalankan query
-	rows, err := r.db.Query(context.Background(), query)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -54,22 +137,12 @@ func (r *guruQuery) SelectAllGuru() ([]guru.GuruCore, error) {
 	for rows.Next() {
 		var guru Guru
 
-		// id_user bisa NULL, jadi gunakan sql.NullString
-		var idUser sql.NullString
-
-		err := rows.Scan(&guru.ID, &idUser, &guru.Nama, &guru.Email, &guru.Alamat)
+		err := scanGuru(rows, &guru)
 		if err != nil {
-			log.Printf("SelectAll error scan: %v", err)
+			helper.LoggerFromContext(ctx).Error("SelectAll error scan", "error", err)
 			return nil, fmt.Errorf("select failed: %w", err)
 		}
 
-		// Konversi idUser ke string biasa
-		if idUser.Valid {
-			guru.ID_User = idUser.String
-		} else {
-			guru.ID_User = "" // atau bisa nil jika pakai pointer
-		}
-
 		// Format ke Core
 		core := FormatterResponse(guru)
 		result = append(result, core)
@@ -77,18 +150,18 @@ func (r *guruQuery) SelectAllGuru() ([]guru.GuruCore, error) {
 
 	// Cek error setelah loop
 	if err := rows.Err(); err != nil {
-		log.Printf("SelectAll error rows: %v", err)
+		helper.LoggerFromContext(ctx).Error("SelectAll error rows", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
 
-	log.Printf("Successfully fetched %d guru from database", len(result))
+	helper.LoggerFromContext(ctx).Info("Successfully fetched guru from database", "count", len(result))
 	return result, nil
 }
 
 // InsertGuru implements guru.DataGuruInterface.
 // Fungsi ini digunakan untuk menginsert data guru ke dalam database.
 // Fungsi ini mengembalikan error jika terjadi kesalahan.
-func (r *guruQuery) InsertGuru(insert *guru.GuruCore) error {
+func (r *guruQuery) InsertGuru(ctx context.Context, insert *guru.GuruCore) error {
 	if r.db == nil {
 		return errors.New("guru model: nil database connection")
 	}
@@ -98,6 +171,11 @@ func (r *guruQuery) InsertGuru(insert *guru.GuruCore) error {
 		insert.ID = uuid.New().String()
 	}
 
+	// Pastikan NIP dan NUPTK belum dipakai guru aktif lain
+	if err := r.cekNomorInduk(ctx, insert, insert.ID); err != nil {
+		return err
+	}
+
 	// Cek apakah ID_User kosong, untuk disisipkan sebagai NULL jika iya
 	var idUserParam interface{}
 	if insert.ID_User == "" {
@@ -106,19 +184,29 @@ func (r *guruQuery) InsertGuru(insert *guru.GuruCore) error {
 		idUserParam = insert.ID_User
 	}
 
-	// Query untuk menyimpan data guru
-	query := `INSERT INTO guru (id, id_user, nama, email, alamat) VALUES ($1, $2, $3, $4, $5)`
+	// Query untuk menyimpan data guru; data kepegawaian yang kosong disimpan sebagai NULL
+	query := `INSERT INTO guru (id, id_user, nama, email, alamat, nip, nuptk, status_kepegawaian,
+			pendidikan_terakhir, sertifikasi, telepon, tanggal_masuk)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''),
+			NULLIF($9, ''), $10, NULLIF($11, ''), NULLIF($12, '')::date)`
 
 	// Jalankan query
-	_, err := r.db.Exec(context.Background(), query,
+	_, err := r.db.Exec(ctx, query,
 		insert.ID,
 		idUserParam,
 		insert.Nama,
 		insert.Email,
 		insert.Alamat,
+		insert.NIP,
+		insert.NUPTK,
+		insert.Status_Kepegawaian,
+		insert.Pendidikan_Terakhir,
+		sertifikasiParam(insert.Sertifikasi),
+		insert.Telepon,
+		insert.Tanggal_Masuk,
 	)
 	if err != nil {
-		log.Printf("InsertGuru error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("InsertGuru error exec", "error", err)
 		return fmt.Errorf("insert failed: %w", err)
 	}
 
@@ -128,7 +216,7 @@ func (r *guruQuery) InsertGuru(insert *guru.GuruCore) error {
 // Update implements guru.DataGuruInterface.
 // Fungsi ini digunakan untuk mengupdate data guru berdasarkan ID.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (r *guruQuery) Update(insert *guru.GuruCore, id string) error {
+func (r *guruQuery) Update(ctx context.Context, insert *guru.GuruCore, id string) error {
 	// Cek apakah koneksi database nil
 	if r.db == nil {
 		return errors.New("koneksi database nil")
@@ -144,30 +232,50 @@ func (r *guruQuery) Update(insert *guru.GuruCore, id string) error {
 		return errors.New("validation error: id harus diisi")
 	}
 
+	// Pastikan NIP dan NUPTK belum dipakai guru aktif lain
+	if err := r.cekNomorInduk(ctx, insert, id); err != nil {
+		return err
+	}
+
 	// Query untuk mengupdate data guru berdasarkan ID
-	// query ini akan mengupdate kolom nama, email, dan alamat
-	// berdasarkan ID yang dikirimkan
-	query := `UPDATE guru SET nama = $2, email = $3, alamat = $4 WHERE id = $1`
+	// query ini akan mengupdate kolom nama, email, alamat, dan data kepegawaian
+	// berdasarkan ID yang dikirimkan, hanya jika versinya masih sama (optimistic concurrency)
+	query := `UPDATE guru SET nama = $2, email = $3, alamat = $4,
+			nip = NULLIF($6, ''), nuptk = NULLIF($7, ''), status_kepegawaian = NULLIF($8, ''),
+			pendidikan_terakhir = NULLIF($9, ''), sertifikasi = $10, telepon = NULLIF($11, ''),
+			tanggal_masuk = NULLIF($12, '')::date, update_at = NOW(), version = version + 1
+		WHERE id = $1 AND delete_at IS NULL AND version = $5`
 
 	// Eksekusi query update
 	// fungsi Exec akan mengembalikan hasil query dan error
 	// jika terjadi error maka akan dikembalikan error
-	res, err := r.db.Exec(context.Background(), query,
+	res, err := r.db.Exec(ctx, query,
 		id,
 		insert.Nama,
 		insert.Email,
 		insert.Alamat,
+		insert.Version,
+		insert.NIP,
+		insert.NUPTK,
+		insert.Status_Kepegawaian,
+		insert.Pendidikan_Terakhir,
+		sertifikasiParam(insert.Sertifikasi),
+		insert.Telepon,
+		insert.Tanggal_Masuk,
 	)
 	if err != nil {
 		// Log error jika terjadi kesalahan
-		log.Printf("UpdateGuru error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("UpdateGuru error exec", "error", err)
 		return fmt.Errorf("update failed: %w", err)
 	}
 
 	// Cek apakah ada baris yang terpengaruh
-	// jika tidak ada baris yang terpengaruh maka akan dikembalikan error
+	// jika tidak ada baris yang terpengaruh maka versi sudah berubah atau data tidak ada
 	if res.RowsAffected() == 0 {
-		log.Printf("UpdateGuru: no rows updated for id %s", id)
+		if err := helper.CheckVersionConflict(ctx, r.db, "guru", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		helper.LoggerFromContext(ctx).Warn("UpdateGuru: no rows updated", "id", id)
 		return errors.New("update failed: no rows affected")
 	}
 
@@ -177,7 +285,7 @@ func (r *guruQuery) Update(insert *guru.GuruCore, id string) error {
 // SelectById digunakan untuk mengambil data guru berdasarkan ID
 // Fungsi ini akan mengembalikan data guru yang sesuai dengan ID yang dikirimkan
 // dan error jika terjadi kesalahan
-func (r *guruQuery) SelectById(id string) (*guru.GuruCore, error) {
+func (r *guruQuery) SelectById(ctx context.Context, id string) (*guru.GuruCore, error) {
 	// Cek koneksi database
 	// Jika koneksi database nil maka kembalikan error
 	if r.db == nil {
@@ -185,11 +293,11 @@ func (r *guruQuery) SelectById(id string) (*guru.GuruCore, error) {
 	}
 
 	// Query untuk mengambil data guru berdasarkan ID
-	// Query ini akan mengambil kolom id, id_user, nama, email, dan alamat
+	// Query ini akan mengambil kolom kolomGuru
 	// berdasarkan ID yang dikirimkan dan delete_at IS NULL
 	// yang artinya data guru yang diambil belum dihapus
 	query := `
-		SELECT id, id_user, nama, email, alamat 
+		SELECT ` + kolomGuru + `
 		FROM guru 
 		WHERE id = $1 AND delete_at IS NULL
 	`
@@ -197,14 +305,14 @@ func (r *guruQuery) SelectById(id string) (*guru.GuruCore, error) {
 	// Jalankan query
 	// Fungsi QueryRow akan mengembalikan row yang sesuai dengan query
 	// dan error jika terjadi kesalahan
-	row := r.db.QueryRow(context.Background(), query, id)
+	row := r.db.QueryRow(ctx, query, id)
 
 	// Deklarasikan variabel untuk menyimpan hasil query
-	var result guru.GuruCore
+	var data Guru
 
-	// Scan hasil query ke variabel result
+	// Scan hasil query ke variabel data
 	// Fungsi Scan akan mengembalikan error jika terjadi kesalahan
-	err := row.Scan(&result.ID, &result.ID_User, &result.Nama, &result.Email, &result.Alamat)
+	err := scanGuru(row, &data)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, pgx.ErrNoRows
@@ -213,31 +321,320 @@ func (r *guruQuery) SelectById(id string) (*guru.GuruCore, error) {
 	}
 
 	// Jika tidak ada error maka kembalikan data guru
+	result := FormatterResponse(data)
 	return &result, nil
 }
 
+// sertifikasiParam mengubah daftar sertifikasi menjadi parameter TEXT[]; daftar kosong disimpan sebagai NULL.
+func sertifikasiParam(sertifikasi []string) interface{} {
+	if len(sertifikasi) == 0 {
+		return nil
+	}
+	return sertifikasi
+}
+
 // DeleteById implements guru.DataGuruInterface.
-func (r *guruQuery) DeleteById(id string) error {
+// Guru hanya dihapus jika versinya masih sama dengan version.
+func (r *guruQuery) DeleteById(ctx context.Context, id string, version int) error {
 	// Cek koneksi database
 	if r.db == nil {
 		return errors.New("guru query: koneksi database nil")
 	}
 
-	// Query untuk menghapus data guru berdasarkan ID
-	query := "UPDATE guru SET delete_at = NOW() WHERE id = $1 AND delete_at IS NULL"
+	// Query untuk menghapus data guru berdasarkan ID dan versi
+	query := "UPDATE guru SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2"
 
 	// Eksekusi query
-	res, err := r.db.Exec(context.Background(), query, id)
+	res, err := r.db.Exec(ctx, query, id, version)
 	if err != nil {
-		log.Printf("DeleteById error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("DeleteById error exec", "error", err)
 		return fmt.Errorf("delete failed: %w", err)
 	}
 
 	// Cek apakah ada baris yang terpengaruh
 	if res.RowsAffected() == 0 {
-		log.Printf("DeleteById: no rows deleted for id %s", id)
+		if err := helper.CheckVersionConflict(ctx, r.db, "guru", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		helper.LoggerFromContext(ctx).Warn("DeleteById: no rows deleted", "id", id)
 		return errors.New("delete failed: no rows affected")
 	}
 
 	return nil // Jika tidak ada error maka kembalikan nil
 }
+
+// LockById implements guru.DataGuruInterface.
+// Fungsi ini memastikan guru aktif ada dan menguncinya (FOR SHARE) sampai transaksi selesai.
+func (r *guruQuery) LockById(ctx context.Context, id string) error {
+	// Cek koneksi database
+	if r.db == nil {
+		return errors.New("guru query: koneksi database nil")
+	}
+
+	var found string
+	err := r.db.QueryRow(ctx, "SELECT id FROM guru WHERE id = $1 AND delete_at IS NULL FOR SHARE", id).Scan(&found)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgx.ErrNoRows
+		}
+		helper.LoggerFromContext(ctx).Error("LockById error query", "error", err)
+		return fmt.Errorf("gagal mengunci data guru: %w", err)
+	}
+
+	return nil
+}
+
+// mataPelajaranTerhapus adalah kondisi mata pelajaran aktif (alias m) yang ikut terhapus pada cascade guru $1:
+// mata pelajaran di kelas yang diwalikan guru dan mata pelajaran yang tidak punya pengajar aktif lain.
+const mataPelajaranTerhapus = `m.delete_at IS NULL AND (
+	m.kelas_id IN (SELECT id FROM kelas WHERE id_guru = $1 AND delete_at IS NULL)
+	OR (EXISTS (SELECT 1 FROM mata_pelajaran_guru mpg WHERE mpg.mata_pelajaran_id = m.id AND mpg.id_guru = $1)
+		AND NOT EXISTS (
+			SELECT 1 FROM mata_pelajaran_guru mpg JOIN guru g ON g.id = mpg.id_guru AND g.delete_at IS NULL
+			WHERE mpg.mata_pelajaran_id = m.id AND mpg.id_guru <> $1)))`
+
+// ListDependents implements guru.DataGuruInterface.
+// Dependents langsung adalah kelas dengan guru sebagai wali kelas dan mata pelajaran yang diajar guru,
+// baik sebagai guru utama maupun guru pendamping.
+// Siswa dan mata pelajaran lain di kelas tersebut, serta tugas, jadwal mengajar, dan jadwal ujian
+// yang belum lewat dari mata pelajaran yang ikut terhapus, dikembalikan sebagai dependents tidak langsung
+// karena hanya ikut terhapus pada policy cascade.
+func (r *guruQuery) ListDependents(ctx context.Context, id string) ([]helper.Dependent, error) {
+	// Cek koneksi database
+	if r.db == nil {
+		return nil, errors.New("guru query: koneksi database nil")
+	}
+
+	query := `
+		WITH terhapus AS (SELECT m.id FROM mata_pelajaran m WHERE ` + mataPelajaranTerhapus + `)
+		SELECT 'kelas', id, kelas, TRUE FROM kelas WHERE id_guru = $1 AND delete_at IS NULL
+		UNION ALL
+		SELECT 'mata_pelajaran', m.id, mp.nama, TRUE
+		FROM mata_pelajaran m JOIN mapel mp ON mp.id = m.mapel_id
+		JOIN mata_pelajaran_guru mpg ON mpg.mata_pelajaran_id = m.id AND mpg.id_guru = $1
+		WHERE m.delete_at IS NULL
+		UNION ALL
+		SELECT 'siswa', s.id, s.nama, FALSE
+		FROM siswa s JOIN kelas k ON k.id = s.kelas_id
+		WHERE k.id_guru = $1 AND k.delete_at IS NULL AND s.delete_at IS NULL
+		UNION ALL
+		SELECT 'mata_pelajaran', m.id, mp.nama, FALSE
+		FROM mata_pelajaran m JOIN kelas k ON k.id = m.kelas_id JOIN mapel mp ON mp.id = m.mapel_id
+		WHERE k.id_guru = $1 AND k.delete_at IS NULL AND m.delete_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM mata_pelajaran_guru mpg WHERE mpg.mata_pelajaran_id = m.id AND mpg.id_guru = $1)
+		UNION ALL
+		SELECT 'tugas', t.id, t.judul, FALSE
+		FROM tugas t WHERE t.delete_at IS NULL AND t.mata_pelajaran_id IN (SELECT id FROM terhapus)
+		UNION ALL
+		SELECT 'jadwal_mengajar', j.id,
+			CONCAT_WS(' ', mp.nama, k.kelas, (ARRAY['minggu','senin','selasa','rabu','kamis','jumat','sabtu'])[j.hari + 1],
+				LEFT(j.jam_mulai::text, 5)), FALSE
+		FROM jadwal_mengajar j JOIN mata_pelajaran m ON m.id = j.mata_pelajaran_id
+		JOIN mapel mp ON mp.id = m.mapel_id LEFT JOIN kelas k ON k.id = m.kelas_id
+		WHERE j.delete_at IS NULL AND j.mata_pelajaran_id IN (SELECT id FROM terhapus)
+		UNION ALL
+		SELECT 'jadwal_ujian', j.sesi_id, CONCAT_WS(' ', u.nama, mp.nama, TO_CHAR(s.tanggal, 'YYYY-MM-DD')), FALSE
+		FROM jadwal_ujian j JOIN sesi_ujian s ON s.id = j.sesi_id JOIN ujian u ON u.id = s.ujian_id
+		JOIN mata_pelajaran m ON m.id = j.mata_pelajaran_id JOIN mapel mp ON mp.id = m.mapel_id
+		WHERE s.tanggal >= CURRENT_DATE AND u.delete_at IS NULL AND j.mata_pelajaran_id IN (SELECT id FROM terhapus)
+		ORDER BY 4 DESC, 1, 3`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("ListDependents error query", "error", err)
+		return nil, fmt.Errorf("gagal mengambil data terdampak: %w", err)
+	}
+	defer rows.Close()
+
+	var dependents []helper.Dependent
+	for rows.Next() {
+		var d helper.Dependent
+		if err := rows.Scan(&d.Tabel, &d.ID, &d.Nama, &d.Langsung); err != nil {
+			return nil, fmt.Errorf("gagal membaca data terdampak: %w", err)
+		}
+		dependents = append(dependents, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("gagal membaca data terdampak: %w", err)
+	}
+
+	return dependents, nil
+}
+
+// ReassignDependents implements guru.DataGuruInterface.
+// Fungsi ini memindahkan wali kelas dan pengajar mata pelajaran dari guru id ke guru targetID.
+// Jika targetID sudah ada di tim pengajar mata pelajaran yang sama, baris guru id dihapus
+// dan status guru utama (jika ada) berpindah ke targetID.
+func (r *guruQuery) ReassignDependents(ctx context.Context, id, targetID string) error {
+	// Cek koneksi database
+	if r.db == nil {
+		return errors.New("guru query: koneksi database nil")
+	}
+
+	queries := []string{
+		"UPDATE kelas SET id_guru = $2, update_at = NOW(), version = version + 1 WHERE id_guru = $1 AND delete_at IS NULL",
+		`UPDATE mata_pelajaran SET update_at = NOW(), version = version + 1
+		WHERE delete_at IS NULL AND id IN (SELECT mata_pelajaran_id FROM mata_pelajaran_guru WHERE id_guru = $1)`,
+		// Guru pendamping yang targetnya sudah ada di tim cukup dihapus
+		`DELETE FROM mata_pelajaran_guru s
+		WHERE s.id_guru = $1 AND NOT s.utama
+			AND s.mata_pelajaran_id IN (SELECT id FROM mata_pelajaran WHERE delete_at IS NULL)
+			AND EXISTS (SELECT 1 FROM mata_pelajaran_guru t WHERE t.mata_pelajaran_id = s.mata_pelajaran_id AND t.id_guru = $2)`,
+		// Jika guru id adalah guru utama, baris target dihapus agar baris guru id bisa dialihkan sebagai guru utama
+		`DELETE FROM mata_pelajaran_guru t
+		WHERE t.id_guru = $2
+			AND EXISTS (SELECT 1 FROM mata_pelajaran_guru s WHERE s.mata_pelajaran_id = t.mata_pelajaran_id AND s.id_guru = $1 AND s.utama)
+			AND t.mata_pelajaran_id IN (SELECT id FROM mata_pelajaran WHERE delete_at IS NULL)`,
+		`UPDATE mata_pelajaran_guru SET id_guru = $2
+		WHERE id_guru = $1 AND mata_pelajaran_id IN (SELECT id FROM mata_pelajaran WHERE delete_at IS NULL)`,
+	}
+	for _, query := range queries {
+		if _, err := r.db.Exec(ctx, query, id, targetID); err != nil {
+			helper.LoggerFromContext(ctx).Error("ReassignDependents error exec", "error", err)
+			return fmt.Errorf("reassign dependents failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CascadeDelete implements guru.DataGuruInterface.
+// Fungsi ini ikut menghapus (soft delete) kelas milik guru beserta siswa dan mata pelajaran di kelas tersebut,
+// serta mata pelajaran yang hanya diajar guru itu. Tugas dan jadwal mengajar mata pelajaran yang terhapus
+// ikut di-soft delete, dan jadwal ujiannya dikeluarkan dari sesi yang belum lewat; sesi yang sudah lewat
+// tetap disimpan sebagai riwayat. Pada mata pelajaran team teaching yang tetap aktif,
+// guru dikeluarkan dari tim pengajar. Kelas dihapus paling akhir karena query sebelumnya
+// mencari data melalui kelas yang masih aktif.
+func (r *guruQuery) CascadeDelete(ctx context.Context, id string) error {
+	// Cek koneksi database
+	if r.db == nil {
+		return errors.New("guru query: koneksi database nil")
+	}
+
+	queries := []string{
+		`UPDATE siswa SET delete_at = NOW(), version = version + 1
+		WHERE delete_at IS NULL AND kelas_id IN (SELECT id FROM kelas WHERE id_guru = $1 AND delete_at IS NULL)`,
+		`WITH terhapus AS (
+			UPDATE mata_pelajaran m SET delete_at = NOW(), version = version + 1
+			WHERE ` + mataPelajaranTerhapus + `
+			RETURNING m.id
+		), tugas_terhapus AS (
+			UPDATE tugas SET delete_at = NOW(), version = version + 1
+			WHERE delete_at IS NULL AND mata_pelajaran_id IN (SELECT id FROM terhapus)
+		), jadwal_terhapus AS (
+			UPDATE jadwal_mengajar SET delete_at = NOW(), update_at = NOW()
+			WHERE delete_at IS NULL AND mata_pelajaran_id IN (SELECT id FROM terhapus)
+		)
+		DELETE FROM jadwal_ujian j USING sesi_ujian s
+		WHERE s.id = j.sesi_id AND s.tanggal >= CURRENT_DATE AND j.mata_pelajaran_id IN (SELECT id FROM terhapus)`,
+		`UPDATE mata_pelajaran SET update_at = NOW(), version = version + 1
+		WHERE delete_at IS NULL AND id IN (SELECT mata_pelajaran_id FROM mata_pelajaran_guru WHERE id_guru = $1)`,
+		`DELETE FROM mata_pelajaran_guru
+		WHERE id_guru = $1 AND mata_pelajaran_id IN (SELECT id FROM mata_pelajaran WHERE delete_at IS NULL)`,
+		"UPDATE kelas SET delete_at = NOW(), version = version + 1 WHERE id_guru = $1 AND delete_at IS NULL",
+	}
+	for _, query := range queries {
+		if _, err := r.db.Exec(ctx, query, id); err != nil {
+			helper.LoggerFromContext(ctx).Error("CascadeDelete error exec", "error", err)
+			return fmt.Errorf("cascade delete failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SelectBebanMengajar implements guru.DataGuruInterface.
+// Fungsi ini mengambil guru aktif (atau satu guru jika id diisi), lalu melengkapinya dengan
+// mata pelajaran yang diajar beserta kelasnya dan kelas yang diwalikan (kelas.id_guru).
+// Pada team teaching, jam mata pelajaran dihitung penuh untuk setiap guru di tim pengajar.
+// Guru yang tidak mengajar tetap dikembalikan dengan daftar kosong.
+func (r *guruQuery) SelectBebanMengajar(ctx context.Context, id string) ([]guru.BebanMengajar, error) {
+	// Cek koneksi database
+	if r.db == nil {
+		return nil, errors.New("guru query: koneksi database nil")
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT id, nama, email FROM guru
+		WHERE delete_at IS NULL AND ($1 = '' OR id = $1)
+		ORDER BY nama`, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectBebanMengajar error query guru", "error", err)
+		return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+	}
+	beban := []guru.BebanMengajar{}
+	index := map[string]int{}
+	for rows.Next() {
+		var b guru.BebanMengajar
+		if err := rows.Scan(&b.ID_Guru, &b.Nama, &b.Email); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+		}
+		b.WaliKelas = []guru.KelasAjar{}
+		b.MataPelajaran = []guru.MapelAjar{}
+		index[b.ID_Guru] = len(beban)
+		beban = append(beban, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+	}
+	if len(beban) == 0 {
+		return beban, nil
+	}
+
+	// Mata pelajaran aktif yang diajar guru; kelas yang sudah dihapus dianggap tanpa kelas
+	rows, err = r.db.Query(ctx, `SELECT mpg.id_guru, m.id, mp.nama, k.id, k.kelas, m.jam_per_minggu
+		FROM mata_pelajaran_guru mpg
+		JOIN mata_pelajaran m ON m.id = mpg.mata_pelajaran_id
+		JOIN mapel mp ON mp.id = m.mapel_id
+		LEFT JOIN kelas k ON k.id = m.kelas_id AND k.delete_at IS NULL
+		WHERE m.delete_at IS NULL AND ($1 = '' OR mpg.id_guru = $1)
+		ORDER BY mp.nama, k.kelas`, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectBebanMengajar error query mata pelajaran", "error", err)
+		return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+	}
+	for rows.Next() {
+		var idGuru string
+		var m guru.MapelAjar
+		var kelasID, namaKelas sql.NullString
+		if err := rows.Scan(&idGuru, &m.ID, &m.Nama_Pelajaran, &kelasID, &namaKelas, &m.Jam_Per_Minggu); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+		}
+		if i, ok := index[idGuru]; ok {
+			m.Kelas_ID = kelasID.String
+			m.Nama_Kelas = namaKelas.String
+			beban[i].MataPelajaran = append(beban[i].MataPelajaran, m)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+	}
+
+	// Kelas aktif yang wali kelasnya guru tersebut
+	rows, err = r.db.Query(ctx, `SELECT id_guru, id, kelas FROM kelas
+		WHERE delete_at IS NULL AND id_guru IS NOT NULL AND ($1 = '' OR id_guru = $1)
+		ORDER BY kelas`, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectBebanMengajar error query kelas", "error", err)
+		return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var idGuru string
+		var k guru.KelasAjar
+		if err := rows.Scan(&idGuru, &k.ID, &k.Kelas); err != nil {
+			return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+		}
+		if i, ok := index[idGuru]; ok {
+			beban[i].WaliKelas = append(beban[i].WaliKelas, k)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select beban mengajar failed: %w", err)
+	}
+
+	return beban, nil
+}
@@ -5,18 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"go_rest_native_sekolah/features/guru"
+	"go_rest_native_sekolah/features/users"
+	"go_rest_native_sekolah/helper"
 	"regexp"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var errGuruNotFound = errors.New("guru service: Data tidak ditemukan")
 
 // guruService  merepresentasikan service untuk tabel guru
 type guruService struct {
-	guruData guru.DataGuruInterface // guruData  berisi kumpulan function-pointers yang dibutuhkan untuk mengakses data guru
-	db       *pgxpool.Pool          // db berisi kumpulan function-pointers yang dibutuhkan untuk mengakses database
+	guruData     guru.DataGuruInterface               // guruData  berisi kumpulan function-pointers yang dibutuhkan untuk mengakses data guru
+	uow          helper.UnitOfWork[guru.Repositories] // uow menjalankan repository guru dan users dalam satu transaksi
+	deletePolicy helper.DeletePolicy                  // deletePolicy adalah policy hapus bawaan jika client tidak memilih policy
+	batas        guru.BatasBebanMengajar              // batas adalah batas jam mengajar per minggu untuk status beban mengajar
 }
 
 // SelectById implements guru.ServiceGuruInterface.
@@ -25,31 +29,49 @@ type guruService struct {
 
 // NewServiceGuru digunakan untuk membuat objek guruService dengan parameter guruData.
 // guruService digunakan untuk menghandle logika bisnis yang berhubungan dengan tabel guru.
+// Parameter uow dipakai untuk operasi yang menyentuh tabel guru dan users sekaligus.
+// Parameter deletePolicy adalah policy hapus bawaan; nilai kosong berarti helper.DeletePolicyBlock.
+// Parameter batas dipakai untuk menandai guru dengan beban mengajar kurang atau berlebih.
 // Jika parameter guruData nil maka akan terjadi panic.
-func NewServiceGuru(repo guru.DataGuruInterface, db *pgxpool.Pool) guru.ServiceGuruInterface {
+func NewServiceGuru(repo guru.DataGuruInterface, uow helper.UnitOfWork[guru.Repositories], deletePolicy helper.DeletePolicy, batas guru.BatasBebanMengajar) guru.ServiceGuruInterface {
 	if repo == nil {
 		panic("guru service: Nil repository")
 	}
 	return &guruService{guruData: repo,
-		db: db}
+		uow:          uow,
+		deletePolicy: deletePolicy,
+		batas:        batas}
 
 }
 
-// GetAllGuru digunakan untuk mengambil semua data guru dari database.
+// GetAllGuru digunakan untuk mengambil data guru dari database yang sesuai dengan filter.
 // Fungsi ini akan mengembalikan slice guru.GuruCore yang berisi data guru
 // dan error jika terjadi kesalahan.
-func (s *guruService) GetAllGuru() ([]guru.GuruCore, error) {
+func (s *guruService) GetAllGuru(ctx context.Context, filter guru.FilterGuru) ([]guru.GuruCore, error) {
 	// Periksa apakah guruData adalah nil
 	if s.guruData == nil {
 		// Kembalikan error jika guruData nil
 		return nil, errors.New("guru service: Nil repository")
 	}
 
-	// Panggil fungsi SelectAllGuru dari guruData untuk mengambil semua data guru
-	gurus, err := s.guruData.SelectAllGuru()
+	// Rapikan filter agar cocok dengan nilai yang tersimpan di database
+	var err error
+	if filter.Status_Kepegawaian, err = normalisasiDaftar("status_kepegawaian", filter.Status_Kepegawaian, guru.DaftarStatusKepegawaian); err != nil {
+		return nil, err
+	}
+	if filter.Pendidikan_Terakhir, err = normalisasiDaftar("pendidikan_terakhir", filter.Pendidikan_Terakhir, guru.DaftarPendidikan); err != nil {
+		return nil, err
+	}
+	filter.Nama = strings.TrimSpace(filter.Nama)
+	filter.NIP = strings.TrimSpace(filter.NIP)
+	filter.NUPTK = strings.TrimSpace(filter.NUPTK)
+	filter.Sertifikasi = strings.TrimSpace(filter.Sertifikasi)
+
+	// Panggil fungsi SelectAllGuru dari guruData untuk mengambil data guru
+	gurus, err := s.guruData.SelectAllGuru(ctx, filter)
 	if err != nil {
 		// Kembalikan error jika terjadi kesalahan saat mengambil data
-		return nil, errors.New("guru service: gagal mengambil data")
+		return nil, fmt.Errorf("guru service: gagal mengambil data: %w", err)
 	}
 
 	// Kembalikan slice dari guru.GuruCore dan error nil
@@ -58,7 +80,7 @@ func (s *guruService) GetAllGuru() ([]guru.GuruCore, error) {
 
 // InsertGuru digunakan untuk memasukkan data guru ke dalam database.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *guruService) InsertGuru(insert *guru.GuruCore) error {
+func (s *guruService) InsertGuru(ctx context.Context, insert *guru.GuruCore) error {
 	// Periksa apakah data repository guruData kosong (nil).
 	if s.guruData == nil {
 		return errors.New("guru service: Repository kosong")
@@ -75,30 +97,59 @@ func (s *guruService) InsertGuru(insert *guru.GuruCore) error {
 		return errors.New("validasi error: email tidak valid")
 	}
 
-	// Deklarasi variabel untuk menyimpan ID user.
-	var idUser string
+	// Validasi dan rapikan data kepegawaian guru.
+	if err := validasiKepegawaian(insert); err != nil {
+		return err
+	}
+	if err := cekStatusPNS(insert); err != nil {
+		return err
+	}
 
-	// Cek apakah email sudah ada di tabel users untuk mendapatkan id_user.
-	err := s.db.QueryRow(context.Background(),
-		"SELECT id FROM users WHERE email = $1", insert.Email).Scan(&idUser)
+	if s.uow == nil {
+		return errors.New("guru service: Unit of work kosong")
+	}
 
-	if err != nil {
-		// Jika email tidak ditemukan, biarkan id_user kosong (NULL di database).
-		if err == pgx.ErrNoRows {
-			idUser = ""
-		} else {
+	// Akun users dan data guru dibuat dalam satu transaksi,
+	// sehingga tidak ada akun guru tanpa data guru atau sebaliknya.
+	return s.uow.Do(ctx, func(repos guru.Repositories) error {
+		// Cek apakah email sudah ada di tabel users untuk mendapatkan id_user.
+		user, err := repos.Users.SelectUserByEmail(ctx, insert.Email)
+		switch {
+		case err == nil:
+			// Email sudah terdaftar, hubungkan guru dengan akun tersebut.
+			insert.ID_User = user.ID
+		case errors.Is(err, pgx.ErrNoRows):
+			// Email belum terdaftar, buat akun users baru dengan role guru.
+			if insert.Password == "" {
+				return errors.New("validasi error: password harus diisi untuk membuat akun guru baru")
+			}
+			username := insert.Username
+			if username == "" {
+				username = insert.Email
+			}
+			newUser := &users.UserCore{
+				Username: username,
+				Email:    insert.Email,
+				Password: insert.Password,
+				Role:     "guru",
+			}
+			if err := repos.Users.InsertUser(ctx, newUser); err != nil {
+				return fmt.Errorf("gagal membuat akun guru: %w", err)
+			}
+			insert.ID_User = newUser.ID
+		default:
 			// Jika ada kesalahan lain saat pengecekan, kembalikan error.
 			return fmt.Errorf("gagal cek users: %w", err)
 		}
-	}
 
-	// Panggil fungsi InsertGuru pada data repository untuk memasukkan data guru.
-	return s.guruData.InsertGuru(insert)
+		// Panggil fungsi InsertGuru pada repository transaksi untuk memasukkan data guru.
+		return repos.Guru.InsertGuru(ctx, insert)
+	})
 }
 
 // UpdateGuru memperbarui data guru berdasarkan ID yang diberikan.
 // Fungsi ini mengimplementasikan guru.ServiceGuruInterface.
-func (s *guruService) UpdateGuru(insert *guru.GuruCore, id string) error {
+func (s *guruService) UpdateGuru(ctx context.Context, insert *guru.GuruCore, id string) error {
 	// Periksa apakah service atau data repository nil
 	if s == nil || s.guruData == nil {
 		return errors.New("guru service: Nil repository")
@@ -114,8 +165,13 @@ func (s *guruService) UpdateGuru(insert *guru.GuruCore, id string) error {
 		return errors.New("validation error: id harus diisi")
 	}
 
+	// Validasi data kepegawaian yang dikirim sebelum mengambil data lama
+	if err := validasiKepegawaian(insert); err != nil {
+		return err
+	}
+
 	// Ambil data lama dari database berdasarkan ID
-	existingData, err := s.guruData.SelectById(id)
+	existingData, err := s.guruData.SelectById(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return errGuruNotFound
@@ -123,6 +179,11 @@ func (s *guruService) UpdateGuru(insert *guru.GuruCore, id string) error {
 		return fmt.Errorf("Id salah atau gagal mengambil data lama: %w", err)
 	}
 
+	// Tolak update jika data sudah diubah sejak client mengambilnya (If-Match)
+	if insert.Version != existingData.Version {
+		return helper.ErrVersionConflict
+	}
+
 	// Gabungkan data baru dengan data lama
 	// Jika field baru kosong, gunakan field dari data lama
 	if insert.Nama == "" {
@@ -134,9 +195,15 @@ func (s *guruService) UpdateGuru(insert *guru.GuruCore, id string) error {
 	if insert.Alamat == "" {
 		insert.Alamat = existingData.Alamat
 	}
+	gabungKepegawaian(insert, existingData)
+
+	// Status PNS diperiksa setelah digabung karena NIP bisa berasal dari data lama
+	if err := cekStatusPNS(insert); err != nil {
+		return err
+	}
 
 	// Lakukan update data ke database
-	if err := s.guruData.Update(insert, id); err != nil {
+	if err := s.guruData.Update(ctx, insert, id); err != nil {
 		return err
 	}
 
@@ -147,9 +214,9 @@ func (s *guruService) UpdateGuru(insert *guru.GuruCore, id string) error {
 // SelectById digunakan untuk mengambil data guru berdasarkan ID yang diberikan.
 // Fungsi ini akan mengembalikan objek guru.GuruCore yang sesuai dengan ID tersebut
 // dan error jika terjadi kesalahan dalam pengambilan data.
-func (s *guruService) SelectById(id string) (*guru.GuruCore, error) {
+func (s *guruService) SelectById(ctx context.Context, id string) (*guru.GuruCore, error) {
 	// Panggil method SelectById dari data layer untuk mengambil data guru berdasarkan ID.
-	guru, err := s.guruData.SelectById(id)
+	guru, err := s.guruData.SelectById(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errGuruNotFound
@@ -160,12 +227,14 @@ func (s *guruService) SelectById(id string) (*guru.GuruCore, error) {
 	return guru, nil
 }
 
-// DeleteById mengimplementasikan interface guru.ServiceGuruInterface.
-// Fungsi ini digunakan untuk menghapus data guru berdasarkan ID yang diberikan.
-// Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *guruService) DeleteById(id string) error {
-	// Periksa apakah service atau data repository nil.
-	if s == nil || s.guruData == nil {
+// DeleteById menghapus data guru berdasarkan ID yang diberikan.
+// Kelas (wali kelas) dan mata pelajaran milik guru ditangani sesuai policy hapus:
+// block menolak penghapusan, reassign memindahkan ke guru target, dan cascade ikut menghapusnya.
+// Semua langkah dijalankan dalam satu transaksi.
+// Fungsi ini mengimplementasikan guru.ServiceGuruInterface.
+func (s *guruService) DeleteById(ctx context.Context, id string, version int, opts helper.DeleteOptions) error {
+	// Periksa apakah service, data repository, atau unit of work nil.
+	if s == nil || s.guruData == nil || s.uow == nil {
 		return errors.New("guru service: Nil repository")
 	}
 
@@ -174,12 +243,307 @@ func (s *guruService) DeleteById(id string) error {
 		return errors.New("validation error: id harus diisi")
 	}
 
-	// Panggil fungsi DeleteById pada data repository untuk menghapus data guru.
-	// Jika terjadi error saat menghapus data guru, kembalikan error yang terbungkus dengan pesan.
-	if err := s.guruData.DeleteById(id); err != nil {
-		return fmt.Errorf("gagal menghapus data guru: %w", err)
+	// Isi policy bawaan dan periksa guru target untuk reassign.
+	opts, err := helper.ValidateDeleteOptions(id, opts, s.deletePolicy)
+	if err != nil {
+		return err
+	}
+
+	return s.uow.Do(ctx, func(repos guru.Repositories) error {
+		// Soft delete lebih dulu agar baris guru terkunci sampai transaksi selesai.
+		// Penghapusan ditolak jika versi guru sudah berubah sejak diambil client.
+		if err := repos.Guru.DeleteById(ctx, id, version); err != nil {
+			return fmt.Errorf("gagal menghapus data guru: %w", err)
+		}
+
+		// Guru target harus masih aktif dan dikunci agar tidak ikut terhapus.
+		if opts.Policy == helper.DeletePolicyReassign {
+			if err := repos.Guru.LockById(ctx, opts.TargetID); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errors.New("validation error: guru target tidak ditemukan")
+				}
+				return fmt.Errorf("gagal memeriksa guru target: %w", err)
+			}
+		}
+
+		// Ambil kelas dan mata pelajaran yang masih merujuk ke guru ini.
+		dependents, err := repos.Guru.ListDependents(ctx, id)
+		if err != nil {
+			return fmt.Errorf("gagal menghapus data guru: %w", err)
+		}
+		if len(dependents) == 0 {
+			return nil
+		}
+
+		switch opts.Policy {
+		case helper.DeletePolicyReassign:
+			err = repos.Guru.ReassignDependents(ctx, id, opts.TargetID)
+		case helper.DeletePolicyCascade:
+			err = repos.Guru.CascadeDelete(ctx, id)
+		default:
+			// Policy block: batalkan penghapusan dan kembalikan daftar data yang masih merujuk.
+			return &helper.DeleteBlockedError{Impact: helper.NewDeleteImpact(id, opts, dependents)}
+		}
+		if err != nil {
+			return fmt.Errorf("gagal menghapus data guru: %w", err)
+		}
+
+		// Jika tidak ada error maka kembalikan nil agar transaksi di-commit.
+		return nil
+	})
+}
+
+// PreviewDelete mengembalikan kelas, mata pelajaran, dan siswa yang terdampak
+// jika guru dihapus dengan policy tertentu, tanpa mengubah data apa pun.
+// Fungsi ini mengimplementasikan guru.ServiceGuruInterface.
+func (s *guruService) PreviewDelete(ctx context.Context, id string, opts helper.DeleteOptions) (*helper.DeleteImpact, error) {
+	// Periksa apakah service atau data repository nil.
+	if s == nil || s.guruData == nil {
+		return nil, errors.New("guru service: Nil repository")
+	}
+
+	// Periksa apakah ID harus diisi.
+	if id == "" {
+		return nil, errors.New("validation error: id harus diisi")
+	}
+
+	// Isi policy bawaan dan periksa guru target untuk reassign.
+	opts, err := helper.ValidateDeleteOptions(id, opts, s.deletePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pastikan guru yang akan dihapus (dan guru target) masih ada.
+	if _, err := s.guruData.SelectById(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errGuruNotFound
+		}
+		return nil, fmt.Errorf("gagal mengambil data guru: %w", err)
+	}
+	if opts.Policy == helper.DeletePolicyReassign {
+		if _, err := s.guruData.SelectById(ctx, opts.TargetID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, errors.New("validation error: guru target tidak ditemukan")
+			}
+			return nil, fmt.Errorf("gagal mengambil data guru target: %w", err)
+		}
+	}
+
+	dependents, err := s.guruData.ListDependents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil data terdampak: %w", err)
+	}
+
+	return helper.NewDeleteImpact(id, opts, dependents), nil
+}
+
+// Bulk menjalankan banyak operasi create, update, dan delete guru sekaligus.
+// Setiap operasi memakai validasi yang sama dengan endpoint satuan, dengan repository dari transaksi bulk.
+// Operasi delete memakai policy hapus dari operasi tersebut atau policy bawaan service.
+// Fungsi ini mengimplementasikan guru.ServiceGuruInterface.
+func (s *guruService) Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[guru.GuruCore]) (*helper.BulkResult, error) {
+	// Periksa apakah service, data repository, atau unit of work nil.
+	if s == nil || s.guruData == nil || s.uow == nil {
+		return nil, errors.New("guru service: Nil repository")
+	}
+
+	return helper.RunBulk(ctx, s.uow, mode, ops, func(ctx context.Context, repos guru.Repositories, op helper.BulkOperation[guru.GuruCore]) (string, error) {
+		// Service yang memakai repository dan transaksi milik operasi bulk.
+		tx := &guruService{guruData: repos.Guru, uow: helper.JoinUnitOfWork(repos), deletePolicy: s.deletePolicy, batas: s.batas}
+		data := op.Data
+		switch op.Action {
+		case helper.BulkActionCreate:
+			err := tx.InsertGuru(ctx, &data)
+			return data.ID, err
+		case helper.BulkActionUpdate:
+			data.Version = op.Version
+			return op.ID, tx.UpdateGuru(ctx, &data, op.ID)
+		default:
+			return op.ID, tx.DeleteById(ctx, op.ID, op.Version, op.DeleteOptions())
+		}
+	})
+}
+
+// GetBebanMengajar mengembalikan beban mengajar satu guru beserta total dan statusnya.
+// Fungsi ini mengimplementasikan guru.ServiceGuruInterface.
+func (s *guruService) GetBebanMengajar(ctx context.Context, id string) (*guru.BebanMengajar, error) {
+	// Periksa apakah service atau data repository nil
+	if s == nil || s.guruData == nil {
+		return nil, errors.New("guru service: Nil repository")
+	}
+	if id == "" {
+		return nil, errors.New("validasi error: id guru harus diisi")
+	}
+
+	beban, err := s.guruData.SelectBebanMengajar(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("guru service: gagal mengambil beban mengajar: %w", err)
+	}
+	if len(beban) == 0 {
+		return nil, errGuruNotFound
+	}
+
+	s.hitungBebanMengajar(&beban[0])
+	return &beban[0], nil
+}
+
+// GetRekapBebanMengajar mengembalikan beban mengajar seluruh guru aktif beserta jumlah guru per status.
+// Fungsi ini mengimplementasikan guru.ServiceGuruInterface.
+func (s *guruService) GetRekapBebanMengajar(ctx context.Context) (*guru.RekapBebanMengajar, error) {
+	// Periksa apakah service atau data repository nil
+	if s == nil || s.guruData == nil {
+		return nil, errors.New("guru service: Nil repository")
+	}
+
+	beban, err := s.guruData.SelectBebanMengajar(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("guru service: gagal mengambil beban mengajar: %w", err)
+	}
+
+	rekap := &guru.RekapBebanMengajar{Batas: s.batas, Guru: []guru.BebanMengajar{}}
+	for i := range beban {
+		s.hitungBebanMengajar(&beban[i])
+		switch beban[i].Status {
+		case guru.StatusBebanKurang:
+			rekap.JumlahKurang++
+		case guru.StatusBebanBerlebih:
+			rekap.JumlahBerlebih++
+		default:
+			rekap.JumlahNormal++
+		}
+		rekap.TotalJam += beban[i].TotalJam
+		rekap.Guru = append(rekap.Guru, beban[i])
+	}
+	rekap.JumlahGuru = len(rekap.Guru)
+
+	return rekap, nil
+}
+
+// hitungBebanMengajar mengisi daftar kelas yang diajar, jumlah mapel, jumlah kelas,
+// total jam per minggu, dan status beban mengajar b berdasarkan batas service.
+func (s *guruService) hitungBebanMengajar(b *guru.BebanMengajar) {
+	b.Kelas = []guru.KelasAjar{}
+	seen := map[string]bool{}
+	b.TotalJam = 0
+	for _, m := range b.MataPelajaran {
+		b.TotalJam += m.Jam_Per_Minggu
+		// Kelas yang sama cukup dihitung sekali walaupun diajar lewat beberapa mata pelajaran
+		if m.Kelas_ID != "" && !seen[m.Kelas_ID] {
+			seen[m.Kelas_ID] = true
+			b.Kelas = append(b.Kelas, guru.KelasAjar{ID: m.Kelas_ID, Kelas: m.Nama_Kelas})
+		}
+	}
+	b.JumlahMapel = len(b.MataPelajaran)
+	b.JumlahKelas = len(b.Kelas)
+
+	switch {
+	case b.TotalJam < s.batas.MinJam:
+		b.Status = guru.StatusBebanKurang
+	case s.batas.MaxJam > 0 && b.TotalJam > s.batas.MaxJam:
+		b.Status = guru.StatusBebanBerlebih
+	default:
+		b.Status = guru.StatusBebanNormal
 	}
+}
+
+var (
+	// nipRegex memvalidasi NIP pegawai negeri: tepat 18 digit angka.
+	nipRegex = regexp.MustCompile(`^[0-9]{18}$`)
+	// nuptkRegex memvalidasi NUPTK: tepat 16 digit angka.
+	nuptkRegex = regexp.MustCompile(`^[0-9]{16}$`)
+)
+
+// validasiKepegawaian memeriksa dan merapikan data kepegawaian guru yang diisi.
+// Field kosong dilewati sehingga fungsi ini bisa dipakai untuk insert maupun update sebagian.
+func validasiKepegawaian(c *guru.GuruCore) error {
+	c.NIP = strings.TrimSpace(c.NIP)
+	if c.NIP != "" && !nipRegex.MatchString(c.NIP) {
+		return errors.New("validation error: nip harus 18 digit angka")
+	}
+	c.NUPTK = strings.TrimSpace(c.NUPTK)
+	if c.NUPTK != "" && !nuptkRegex.MatchString(c.NUPTK) {
+		return errors.New("validation error: nuptk harus 16 digit angka")
+	}
+
+	var err error
+	if c.Status_Kepegawaian, err = normalisasiDaftar("status_kepegawaian", c.Status_Kepegawaian, guru.DaftarStatusKepegawaian); err != nil {
+		return err
+	}
+	if c.Pendidikan_Terakhir, err = normalisasiDaftar("pendidikan_terakhir", c.Pendidikan_Terakhir, guru.DaftarPendidikan); err != nil {
+		return err
+	}
+	if c.Telepon, err = helper.NormalizePhone("telepon", c.Telepon); err != nil {
+		return err
+	}
+	if c.Tanggal_Masuk, err = helper.NormalizeDate("tanggal_masuk", c.Tanggal_Masuk, true); err != nil {
+		return err
+	}
+	c.Sertifikasi = rapikanSertifikasi(c.Sertifikasi)
+	return nil
+}
 
-	// Jika tidak ada error maka kembalikan nil.
+// cekStatusPNS memastikan guru berstatus PNS memiliki NIP.
+func cekStatusPNS(c *guru.GuruCore) error {
+	if c.Status_Kepegawaian == guru.StatusPNS && c.NIP == "" {
+		return errors.New("validation error: nip harus diisi untuk guru berstatus PNS")
+	}
 	return nil
 }
+
+// gabungKepegawaian mengisi data kepegawaian yang kosong pada update dengan nilai dari data lama.
+// Sertifikasi nil berarti tidak diubah, sedangkan slice kosong menghapus semua sertifikasi.
+func gabungKepegawaian(update *guru.GuruCore, lama *guru.GuruCore) {
+	field := []struct {
+		baru *string
+		lama string
+	}{
+		{&update.NIP, lama.NIP},
+		{&update.NUPTK, lama.NUPTK},
+		{&update.Status_Kepegawaian, lama.Status_Kepegawaian},
+		{&update.Pendidikan_Terakhir, lama.Pendidikan_Terakhir},
+		{&update.Telepon, lama.Telepon},
+		{&update.Tanggal_Masuk, lama.Tanggal_Masuk},
+	}
+	for _, f := range field {
+		if *f.baru == "" {
+			*f.baru = f.lama
+		}
+	}
+	if update.Sertifikasi == nil {
+		update.Sertifikasi = lama.Sertifikasi
+	}
+}
+
+// normalisasiDaftar mencocokkan nilai dengan daftar tanpa membedakan huruf besar/kecil
+// dan mengembalikan penulisan bakunya. Parameter field dipakai pada pesan validation error.
+func normalisasiDaftar(field, nilai string, daftar []string) (string, error) {
+	nilai = strings.TrimSpace(nilai)
+	if nilai == "" {
+		return "", nil
+	}
+	for _, d := range daftar {
+		if strings.EqualFold(d, nilai) {
+			return d, nil
+		}
+	}
+	return "", fmt.Errorf("validation error: %s '%s' tidak dikenal, gunakan salah satu dari %s", field, nilai, strings.Join(daftar, ", "))
+}
+
+// rapikanSertifikasi membuang spasi, nilai kosong, dan duplikat (tanpa membedakan huruf besar/kecil)
+// dari daftar sertifikasi. Slice nil dikembalikan apa adanya agar update tetap bisa membedakannya.
+func rapikanSertifikasi(sertifikasi []string) []string {
+	if sertifikasi == nil {
+		return nil
+	}
+	hasil := []string{}
+	sudah := map[string]bool{}
+	for _, s := range sertifikasi {
+		s = strings.TrimSpace(s)
+		if s == "" || sudah[strings.ToLower(s)] {
+			continue
+		}
+		sudah[strings.ToLower(s)] = true
+		hasil = append(hasil, s)
+	}
+	return hasil
+}
@@ -1,13 +1,15 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"go_rest_native_sekolah/features/guru"
+	"go_rest_native_sekolah/features/users"
+	"go_rest_native_sekolah/helper"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -17,25 +19,29 @@ type mockDataGuru struct {
 	mock.Mock
 }
 
-func (m *mockDataGuru) SelectAllGuru() ([]guru.GuruCore, error) {
-	args := m.Called()
+func (m *mockDataGuru) SelectAllGuru(ctx context.Context, filter guru.FilterGuru) ([]guru.GuruCore, error) {
+	// Meniru pgx: query dengan context yang sudah dibatalkan langsung gagal
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	args := m.Called(filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]guru.GuruCore), args.Error(1)
 }
 
-func (m *mockDataGuru) InsertGuru(insert *guru.GuruCore) error {
+func (m *mockDataGuru) InsertGuru(ctx context.Context, insert *guru.GuruCore) error {
 	args := m.Called(insert)
 	return args.Error(0)
 }
 
-func (m *mockDataGuru) Update(insert *guru.GuruCore, id string) error {
+func (m *mockDataGuru) Update(ctx context.Context, insert *guru.GuruCore, id string) error {
 	args := m.Called(insert, id)
 	return args.Error(0)
 }
 
-func (m *mockDataGuru) SelectById(id string) (*guru.GuruCore, error) {
+func (m *mockDataGuru) SelectById(ctx context.Context, id string) (*guru.GuruCore, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -43,11 +49,95 @@ func (m *mockDataGuru) SelectById(id string) (*guru.GuruCore, error) {
 	return args.Get(0).(*guru.GuruCore), args.Error(1)
 }
 
-func (m *mockDataGuru) DeleteById(id string) error {
+func (m *mockDataGuru) DeleteById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataGuru) LockById(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockDataGuru) ListDependents(ctx context.Context, id string) ([]helper.Dependent, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]helper.Dependent), args.Error(1)
+}
+
+func (m *mockDataGuru) ReassignDependents(ctx context.Context, id, targetID string) error {
+	args := m.Called(id, targetID)
+	return args.Error(0)
+}
+
+func (m *mockDataGuru) CascadeDelete(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *mockDataGuru) SelectBebanMengajar(ctx context.Context, id string) ([]guru.BebanMengajar, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]guru.BebanMengajar), args.Error(1)
+}
+
+// Mock untuk DataUserInterface yang dipakai di dalam transaksi
+type mockDataUser struct {
+	mock.Mock
+}
+
+func (m *mockDataUser) SelectAllUser(ctx context.Context) ([]users.UserCore, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]users.UserCore), args.Error(1)
+}
+
+func (m *mockDataUser) SelectUserById(ctx context.Context, id string) (*users.UserCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*users.UserCore), args.Error(1)
+}
+
+func (m *mockDataUser) SelectUserByEmail(ctx context.Context, email string) (*users.UserCore, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*users.UserCore), args.Error(1)
+}
+
+func (m *mockDataUser) InsertUser(ctx context.Context, input *users.UserCore) error {
+	args := m.Called(input)
+	return args.Error(0)
+}
+
+func (m *mockDataUser) UpdateUser(ctx context.Context, input *users.UserCore, id string) error {
+	args := m.Called(input, id)
+	return args.Error(0)
+}
+
+func (m *mockDataUser) DeleteUserById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+// fakeUnitOfWork menjalankan fn langsung dengan repository mock tanpa transaksi sungguhan
+type fakeUnitOfWork struct {
+	repos guru.Repositories
+}
+
+func (f fakeUnitOfWork) Do(ctx context.Context, fn func(repos guru.Repositories) error) error {
+	return fn(f.repos)
+}
+
 // Test GetAllGuru
 func TestGetAllGuru(t *testing.T) {
 	mockRepo := new(mockDataGuru)
@@ -72,10 +162,10 @@ func TestGetAllGuru(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("SelectAllGuru").Return(expectedGurus, nil).Once()
+		mockRepo.On("SelectAllGuru", guru.FilterGuru{}).Return(expectedGurus, nil).Once()
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		result, err := svc.GetAllGuru()
+		svc := &guruService{guruData: mockRepo}
+		result, err := svc.GetAllGuru(context.Background(), guru.FilterGuru{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedGurus, result)
@@ -84,10 +174,10 @@ func TestGetAllGuru(t *testing.T) {
 	})
 
 	t.Run("failed get all guru - repository error", func(t *testing.T) {
-		mockRepo.On("SelectAllGuru").Return(nil, errors.New("database error")).Once()
+		mockRepo.On("SelectAllGuru", guru.FilterGuru{}).Return(nil, errors.New("database error")).Once()
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		result, err := svc.GetAllGuru()
+		svc := &guruService{guruData: mockRepo}
+		result, err := svc.GetAllGuru(context.Background(), guru.FilterGuru{})
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -96,13 +186,48 @@ func TestGetAllGuru(t *testing.T) {
 	})
 
 	t.Run("failed - nil repository", func(t *testing.T) {
-		svc := &guruService{guruData: nil, db: nil}
-		result, err := svc.GetAllGuru()
+		svc := &guruService{guruData: nil}
+		result, err := svc.GetAllGuru(context.Background(), guru.FilterGuru{})
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
 		assert.Contains(t, err.Error(), "Nil repository")
 	})
+
+	t.Run("failed - context dibatalkan diteruskan ke repository", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		svc := &guruService{guruData: mockRepo}
+		result, err := svc.GetAllGuru(ctx, guru.FilterGuru{})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "SelectAllGuru")
+	})
+
+	t.Run("success get all guru - filter dinormalisasi", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockRepo.On("SelectAllGuru", guru.FilterGuru{Status_Kepegawaian: guru.StatusHonorer, Pendidikan_Terakhir: "S1", Sertifikasi: "Matematika"}).Return([]guru.GuruCore{}, nil).Once()
+
+		svc := &guruService{guruData: mockRepo}
+		_, err := svc.GetAllGuru(context.Background(), guru.FilterGuru{Status_Kepegawaian: "honorer", Pendidikan_Terakhir: "s1", Sertifikasi: " Matematika "})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed get all guru - status kepegawaian tidak dikenal", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+
+		svc := &guruService{guruData: mockRepo}
+		_, err := svc.GetAllGuru(context.Background(), guru.FilterGuru{Status_Kepegawaian: "kontrak"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "validation error")
+		mockRepo.AssertNotCalled(t, "SelectAllGuru", mock.Anything)
+	})
 }
 
 // Test InsertGuru
@@ -117,8 +242,8 @@ func TestInsertGuru(t *testing.T) {
 			Alamat:  "Jl. Merdeka No. 1",
 		}
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.InsertGuru(invalidGuru)
+		svc := &guruService{guruData: mockRepo}
+		err := svc.InsertGuru(context.Background(), invalidGuru)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "email tidak valid")
@@ -132,8 +257,8 @@ func TestInsertGuru(t *testing.T) {
 			Alamat:  "Jl. Merdeka No. 1",
 		}
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.InsertGuru(invalidGuru)
+		svc := &guruService{guruData: mockRepo}
+		err := svc.InsertGuru(context.Background(), invalidGuru)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "harus diisi")
@@ -147,8 +272,8 @@ func TestInsertGuru(t *testing.T) {
 			Alamat:  "Jl. Merdeka No. 1",
 		}
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.InsertGuru(invalidGuru)
+		svc := &guruService{guruData: mockRepo}
+		err := svc.InsertGuru(context.Background(), invalidGuru)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "harus diisi")
@@ -162,8 +287,8 @@ func TestInsertGuru(t *testing.T) {
 			Alamat:  "",
 		}
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.InsertGuru(invalidGuru)
+		svc := &guruService{guruData: mockRepo}
+		err := svc.InsertGuru(context.Background(), invalidGuru)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "harus diisi")
@@ -176,14 +301,166 @@ func TestInsertGuru(t *testing.T) {
 			Alamat:  "Jl. Merdeka No. 1",
 		}
 
-		svc := &guruService{guruData: nil, db: nil}
-		err := svc.InsertGuru(newGuru)
+		svc := &guruService{guruData: nil}
+		err := svc.InsertGuru(context.Background(), newGuru)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "Repository kosong")
 	})
 }
 
+// Test InsertGuru dalam transaksi guru dan users
+func TestInsertGuruUnitOfWork(t *testing.T) {
+	t.Run("success insert guru - create new user account", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockUser := new(mockDataUser)
+		newGuru := &guru.GuruCore{
+			Nama:     "John Doe",
+			Email:    "john@example.com",
+			Alamat:   "Jl. Merdeka No. 1",
+			Password: "rahasia123",
+		}
+
+		mockUser.On("SelectUserByEmail", "john@example.com").Return(nil, pgx.ErrNoRows).Once()
+		mockUser.On("InsertUser", mock.MatchedBy(func(u *users.UserCore) bool {
+			u.ID = "user-001"
+			return u.Email == "john@example.com" && u.Username == "john@example.com" && u.Role == "guru"
+		})).Return(nil).Once()
+		mockRepo.On("InsertGuru", newGuru).Return(nil).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo, Users: mockUser}}}
+		err := svc.InsertGuru(context.Background(), newGuru)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user-001", newGuru.ID_User)
+		mockRepo.AssertExpectations(t)
+		mockUser.AssertExpectations(t)
+	})
+
+	t.Run("success insert guru - link existing user account", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockUser := new(mockDataUser)
+		newGuru := &guru.GuruCore{
+			Nama:   "John Doe",
+			Email:  "john@example.com",
+			Alamat: "Jl. Merdeka No. 1",
+		}
+
+		mockUser.On("SelectUserByEmail", "john@example.com").Return(&users.UserCore{ID: "user-002"}, nil).Once()
+		mockRepo.On("InsertGuru", newGuru).Return(nil).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo, Users: mockUser}}}
+		err := svc.InsertGuru(context.Background(), newGuru)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user-002", newGuru.ID_User)
+		mockUser.AssertNotCalled(t, "InsertUser", mock.Anything)
+	})
+
+	t.Run("failed insert guru - new account without password", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockUser := new(mockDataUser)
+		newGuru := &guru.GuruCore{
+			Nama:   "John Doe",
+			Email:  "john@example.com",
+			Alamat: "Jl. Merdeka No. 1",
+		}
+
+		mockUser.On("SelectUserByEmail", "john@example.com").Return(nil, pgx.ErrNoRows).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo, Users: mockUser}}}
+		err := svc.InsertGuru(context.Background(), newGuru)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "password harus diisi")
+		mockRepo.AssertNotCalled(t, "InsertGuru", mock.Anything)
+	})
+
+	t.Run("failed insert guru - error from guru repository is returned", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockUser := new(mockDataUser)
+		newGuru := &guru.GuruCore{
+			Nama:     "John Doe",
+			Email:    "john@example.com",
+			Alamat:   "Jl. Merdeka No. 1",
+			Password: "rahasia123",
+		}
+
+		mockUser.On("SelectUserByEmail", "john@example.com").Return(nil, pgx.ErrNoRows).Once()
+		mockUser.On("InsertUser", mock.Anything).Return(nil).Once()
+		mockRepo.On("InsertGuru", newGuru).Return(errors.New("insert failed")).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo, Users: mockUser}}}
+		err := svc.InsertGuru(context.Background(), newGuru)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "insert failed")
+	})
+}
+
+func TestInsertGuruKepegawaian(t *testing.T) {
+	t.Run("success insert guru - data kepegawaian dirapikan", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockUser := new(mockDataUser)
+		newGuru := &guru.GuruCore{
+			Nama:                "John Doe",
+			Email:               "john@example.com",
+			Alamat:              "Jl. Merdeka No. 1",
+			NIP:                 "198001012005011001",
+			NUPTK:               "1234567890123456",
+			Status_Kepegawaian:  "pns",
+			Pendidikan_Terakhir: "s2",
+			Sertifikasi:         []string{" Matematika", "matematika", "", "Fisika"},
+			Telepon:             "0812-3456-7890",
+			Tanggal_Masuk:       "2005-01-01",
+		}
+
+		mockUser.On("SelectUserByEmail", "john@example.com").Return(&users.UserCore{ID: "user-002"}, nil).Once()
+		mockRepo.On("InsertGuru", newGuru).Return(nil).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo, Users: mockUser}}}
+		err := svc.InsertGuru(context.Background(), newGuru)
+
+		assert.NoError(t, err)
+		assert.Equal(t, guru.StatusPNS, newGuru.Status_Kepegawaian)
+		assert.Equal(t, "S2", newGuru.Pendidikan_Terakhir)
+		assert.Equal(t, []string{"Matematika", "Fisika"}, newGuru.Sertifikasi)
+		assert.Equal(t, "081234567890", newGuru.Telepon)
+		mockRepo.AssertExpectations(t)
+	})
+
+	invalid := []struct {
+		name  string
+		data  guru.GuruCore
+		pesan string
+	}{
+		{"nip bukan 18 digit", guru.GuruCore{NIP: "12345"}, "nip"},
+		{"nuptk bukan 16 digit", guru.GuruCore{NUPTK: "12ab"}, "nuptk"},
+		{"status tidak dikenal", guru.GuruCore{Status_Kepegawaian: "kontrak"}, "status_kepegawaian"},
+		{"pendidikan tidak dikenal", guru.GuruCore{Pendidikan_Terakhir: "SMP"}, "pendidikan_terakhir"},
+		{"telepon tidak valid", guru.GuruCore{Telepon: "12"}, "telepon"},
+		{"tanggal masuk di masa depan", guru.GuruCore{Tanggal_Masuk: "2999-01-01"}, "tanggal_masuk"},
+		{"PNS tanpa NIP", guru.GuruCore{Status_Kepegawaian: "PNS"}, "nip harus diisi"},
+	}
+	for _, tc := range invalid {
+		t.Run("failed insert guru - "+tc.name, func(t *testing.T) {
+			mockRepo := new(mockDataGuru)
+			data := tc.data
+			data.Nama = "John Doe"
+			data.Email = "john@example.com"
+			data.Alamat = "Jl. Merdeka No. 1"
+
+			svc := &guruService{guruData: mockRepo, uow: fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo}}}
+			err := svc.InsertGuru(context.Background(), &data)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "validation error")
+			assert.Contains(t, err.Error(), tc.pesan)
+			mockRepo.AssertNotCalled(t, "InsertGuru", mock.Anything)
+		})
+	}
+}
+
 // Test SelectById
 func TestSelectGuruById(t *testing.T) {
 	mockRepo := new(mockDataGuru)
@@ -200,8 +477,8 @@ func TestSelectGuruById(t *testing.T) {
 
 		mockRepo.On("SelectById", "1").Return(expectedGuru, nil).Once()
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		result, err := svc.SelectById("1")
+		svc := &guruService{guruData: mockRepo}
+		result, err := svc.SelectById(context.Background(), "1")
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedGuru, result)
@@ -211,8 +488,8 @@ func TestSelectGuruById(t *testing.T) {
 	t.Run("failed get guru by id - not found", func(t *testing.T) {
 		mockRepo.On("SelectById", "999").Return(nil, pgx.ErrNoRows).Once()
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		result, err := svc.SelectById("999")
+		svc := &guruService{guruData: mockRepo}
+		result, err := svc.SelectById(context.Background(), "999")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -241,8 +518,8 @@ func TestUpdateGuru(t *testing.T) {
 		mockRepo.On("SelectById", "1").Return(existingGuru, nil).Once()
 		mockRepo.On("Update", updatedGuru, "1").Return(nil).Once()
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.UpdateGuru(updatedGuru, "1")
+		svc := &guruService{guruData: mockRepo}
+		err := svc.UpdateGuru(context.Background(), updatedGuru, "1")
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -251,46 +528,204 @@ func TestUpdateGuru(t *testing.T) {
 	t.Run("failed update guru - not found", func(t *testing.T) {
 		mockRepo.On("SelectById", "999").Return(nil, pgx.ErrNoRows).Once()
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.UpdateGuru(&guru.GuruCore{}, "999")
+		svc := &guruService{guruData: mockRepo}
+		err := svc.UpdateGuru(context.Background(), &guru.GuruCore{}, "999")
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "tidak ditemukan")
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("failed update guru - version conflict", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockRepo.On("SelectById", "1").Return(&guru.GuruCore{ID: "1", Nama: "John Doe", Version: 5}, nil).Once()
+
+		svc := &guruService{guruData: mockRepo}
+		err := svc.UpdateGuru(context.Background(), &guru.GuruCore{Nama: "John Updated", Version: 4}, "1")
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
 	t.Run("failed update guru - empty id", func(t *testing.T) {
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.UpdateGuru(&guru.GuruCore{}, "")
+		svc := &guruService{guruData: mockRepo}
+		err := svc.UpdateGuru(context.Background(), &guru.GuruCore{}, "")
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "id harus diisi")
 	})
+
+	t.Run("success update guru - data kepegawaian lama dipertahankan", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		existingGuru := &guru.GuruCore{
+			ID:                 "1",
+			Nama:               "John Doe",
+			Email:              "john@example.com",
+			Alamat:             "Jl. Merdeka No. 1",
+			NIP:                "198001012005011001",
+			Status_Kepegawaian: guru.StatusHonorer,
+			Sertifikasi:        []string{"Matematika"},
+			Tanggal_Masuk:      "2005-01-01",
+		}
+
+		mockRepo.On("SelectById", "1").Return(existingGuru, nil).Once()
+		mockRepo.On("Update", mock.MatchedBy(func(g *guru.GuruCore) bool {
+			return g.NIP == "198001012005011001" && g.Status_Kepegawaian == guru.StatusPNS &&
+				g.Tanggal_Masuk == "2005-01-01" && len(g.Sertifikasi) == 1 && g.Sertifikasi[0] == "Matematika"
+		}), "1").Return(nil).Once()
+
+		svc := &guruService{guruData: mockRepo}
+		err := svc.UpdateGuru(context.Background(), &guru.GuruCore{Status_Kepegawaian: "PNS"}, "1")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed update guru - PNS tanpa NIP", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockRepo.On("SelectById", "1").Return(&guru.GuruCore{ID: "1", Nama: "John Doe"}, nil).Once()
+
+		svc := &guruService{guruData: mockRepo}
+		err := svc.UpdateGuru(context.Background(), &guru.GuruCore{Status_Kepegawaian: "PNS"}, "1")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "nip harus diisi")
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
 }
 
 // Test DeleteById
 func TestDeleteGuruById(t *testing.T) {
 	mockRepo := new(mockDataGuru)
+	uow := fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo}}
+	dependents := []helper.Dependent{
+		{Tabel: "kelas", ID: "kelas-001", Nama: "10A", Langsung: true},
+		{Tabel: "siswa", ID: "siswa-001", Nama: "Ahmad Rauf"},
+	}
 
 	t.Run("success delete guru", func(t *testing.T) {
-		mockRepo.On("DeleteById", "1").Return(nil).Once()
+		mockRepo.On("DeleteById", "1", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "1").Return(nil, nil).Once()
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.DeleteById("1")
+		svc := &guruService{guruData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "1", 1, helper.DeleteOptions{})
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("failed delete guru - not found", func(t *testing.T) {
-		mockRepo.On("DeleteById", "999").Return(errors.New("data not found")).Once()
+		mockRepo.On("DeleteById", "999", 1).Return(errors.New("data not found")).Once()
 
-		svc := &guruService{guruData: mockRepo, db: nil}
-		err := svc.DeleteById("999")
+		svc := &guruService{guruData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "999", 1, helper.DeleteOptions{})
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("failed delete guru - blocked by dependents", func(t *testing.T) {
+		mockRepo.On("DeleteById", "2", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "2").Return(dependents, nil).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: uow, deletePolicy: helper.DeletePolicyBlock}
+		err := svc.DeleteById(context.Background(), "2", 1, helper.DeleteOptions{})
+
+		var blocked *helper.DeleteBlockedError
+		assert.ErrorAs(t, err, &blocked)
+		// Siswa hanya terdampak tidak langsung sehingga tidak ikut memblokir
+		assert.Len(t, blocked.Impact.Dependents, 1)
+		assert.Equal(t, "kelas-001", blocked.Impact.Dependents[0].ID)
+	})
+
+	t.Run("success delete guru - reassign", func(t *testing.T) {
+		mockRepo.On("DeleteById", "3", 1).Return(nil).Once()
+		mockRepo.On("LockById", "4").Return(nil).Once()
+		mockRepo.On("ListDependents", "3").Return(dependents, nil).Once()
+		mockRepo.On("ReassignDependents", "3", "4").Return(nil).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "3", 1, helper.DeleteOptions{Policy: helper.DeletePolicyReassign, TargetID: "4"})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed delete guru - reassign target not found", func(t *testing.T) {
+		mockRepo.On("DeleteById", "5", 1).Return(nil).Once()
+		mockRepo.On("LockById", "404").Return(pgx.ErrNoRows).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "5", 1, helper.DeleteOptions{Policy: helper.DeletePolicyReassign, TargetID: "404"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "guru target tidak ditemukan")
+	})
+
+	t.Run("failed delete guru - reassign without target", func(t *testing.T) {
+		svc := &guruService{guruData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "6", 1, helper.DeleteOptions{Policy: helper.DeletePolicyReassign})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "target wajib diisi")
+	})
+
+	t.Run("success delete guru - cascade from default policy", func(t *testing.T) {
+		mockRepo.On("DeleteById", "7", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "7").Return(dependents, nil).Once()
+		mockRepo.On("CascadeDelete", "7").Return(nil).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: uow, deletePolicy: helper.DeletePolicyCascade}
+		err := svc.DeleteById(context.Background(), "7", 1, helper.DeleteOptions{})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// Test PreviewDelete
+func TestPreviewDeleteGuru(t *testing.T) {
+	mockRepo := new(mockDataGuru)
+	dependents := []helper.Dependent{
+		{Tabel: "kelas", ID: "kelas-001", Nama: "10A", Langsung: true},
+		{Tabel: "siswa", ID: "siswa-001", Nama: "Ahmad Rauf"},
+	}
+
+	t.Run("success preview cascade includes indirect dependents", func(t *testing.T) {
+		mockRepo.On("SelectById", "1").Return(&guru.GuruCore{ID: "1"}, nil).Once()
+		mockRepo.On("ListDependents", "1").Return(dependents, nil).Once()
+
+		svc := &guruService{guruData: mockRepo}
+		impact, err := svc.PreviewDelete(context.Background(), "1", helper.DeleteOptions{Policy: helper.DeletePolicyCascade})
+
+		assert.NoError(t, err)
+		assert.True(t, impact.DapatHapus)
+		assert.Equal(t, "dihapus", impact.Aksi)
+		assert.Len(t, impact.Dependents, 2)
+		mockRepo.AssertNotCalled(t, "DeleteById", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success preview block", func(t *testing.T) {
+		mockRepo.On("SelectById", "1").Return(&guru.GuruCore{ID: "1"}, nil).Once()
+		mockRepo.On("ListDependents", "1").Return(dependents, nil).Once()
+
+		svc := &guruService{guruData: mockRepo}
+		impact, err := svc.PreviewDelete(context.Background(), "1", helper.DeleteOptions{})
+
+		assert.NoError(t, err)
+		assert.False(t, impact.DapatHapus)
+		assert.Equal(t, helper.DeletePolicyBlock, impact.Policy)
+		assert.Len(t, impact.Dependents, 1)
+	})
+
+	t.Run("failed preview - guru not found", func(t *testing.T) {
+		mockRepo.On("SelectById", "999").Return(nil, pgx.ErrNoRows).Once()
+
+		svc := &guruService{guruData: mockRepo}
+		_, err := svc.PreviewDelete(context.Background(), "999", helper.DeleteOptions{})
+
+		assert.ErrorIs(t, err, errGuruNotFound)
+	})
 }
 
 // Test Panic when nil repository
@@ -301,5 +736,114 @@ func TestNewServiceGuruPanic(t *testing.T) {
 		}
 	}()
 
-	NewServiceGuru(nil, &pgxpool.Pool{})
+	NewServiceGuru(nil, nil, "", guru.BatasBebanMengajar{})
+}
+
+// Test Bulk guru
+func TestBulkGuru(t *testing.T) {
+	t.Run("success atomic bulk - create and update guru", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockUser := new(mockDataUser)
+		ops := []helper.BulkOperation[guru.GuruCore]{
+			{Action: helper.BulkActionCreate, Data: guru.GuruCore{Nama: "Siti", Email: "siti@example.com", Alamat: "Jl. Melati"}},
+			{Action: helper.BulkActionUpdate, ID: "guru-001", Version: 2, Data: guru.GuruCore{Alamat: "Jl. Mawar"}},
+		}
+
+		mockUser.On("SelectUserByEmail", "siti@example.com").Return(&users.UserCore{ID: "user-001"}, nil).Once()
+		mockRepo.On("InsertGuru", mock.Anything).Return(nil).Once()
+		mockRepo.On("SelectById", "guru-001").Return(&guru.GuruCore{ID: "guru-001", Nama: "Budi", Email: "budi@example.com", Version: 2}, nil).Once()
+		mockRepo.On("Update", mock.Anything, "guru-001").Return(nil).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo, Users: mockUser}}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModeAtomic, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Berhasil)
+		mockRepo.AssertExpectations(t)
+		mockUser.AssertExpectations(t)
+	})
+
+	t.Run("partial bulk - version conflict on one delete", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		ops := []helper.BulkOperation[guru.GuruCore]{
+			{Action: helper.BulkActionDelete, ID: "guru-001", Version: 1},
+			{Action: helper.BulkActionDelete, ID: "guru-002", Version: 1},
+		}
+
+		mockRepo.On("DeleteById", "guru-001", 1).Return(helper.ErrVersionConflict).Once()
+		mockRepo.On("DeleteById", "guru-002", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "guru-002").Return(nil, nil).Once()
+
+		svc := &guruService{guruData: mockRepo, uow: fakeUnitOfWork{repos: guru.Repositories{Guru: mockRepo}}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModePartial, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 412, result.Items[0].Status)
+		assert.Equal(t, 200, result.Items[1].Status)
+		assert.Equal(t, 207, result.StatusCode())
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// Test GetBebanMengajar dan GetRekapBebanMengajar
+func TestBebanMengajarGuru(t *testing.T) {
+	batas := guru.BatasBebanMengajar{MinJam: 6, MaxJam: 10}
+
+	t.Run("success beban mengajar - total dan kelas dihitung", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockRepo.On("SelectBebanMengajar", "guru-001").Return([]guru.BebanMengajar{{
+			ID_Guru:   "guru-001",
+			Nama:      "Budi",
+			WaliKelas: []guru.KelasAjar{{ID: "kelas-001", Kelas: "10A"}},
+			MataPelajaran: []guru.MapelAjar{
+				{ID: "mapel-001", Nama_Pelajaran: "Matematika", Kelas_ID: "kelas-001", Nama_Kelas: "10A", Jam_Per_Minggu: 4},
+				{ID: "mapel-002", Nama_Pelajaran: "Fisika", Kelas_ID: "kelas-001", Nama_Kelas: "10A", Jam_Per_Minggu: 3},
+				{ID: "mapel-003", Nama_Pelajaran: "Matematika", Kelas_ID: "kelas-002", Nama_Kelas: "10B", Jam_Per_Minggu: 4},
+			},
+		}}, nil).Once()
+
+		svc := &guruService{guruData: mockRepo, batas: batas}
+		beban, err := svc.GetBebanMengajar(context.Background(), "guru-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, beban.JumlahMapel)
+		assert.Equal(t, 2, beban.JumlahKelas)
+		assert.Equal(t, 11, beban.TotalJam)
+		assert.Equal(t, guru.StatusBebanBerlebih, beban.Status)
+		assert.Len(t, beban.WaliKelas, 1)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed beban mengajar - guru tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockRepo.On("SelectBebanMengajar", "999").Return([]guru.BebanMengajar{}, nil).Once()
+
+		svc := &guruService{guruData: mockRepo, batas: batas}
+		beban, err := svc.GetBebanMengajar(context.Background(), "999")
+
+		assert.Nil(t, beban)
+		assert.ErrorIs(t, err, errGuruNotFound)
+	})
+
+	t.Run("success rekap beban mengajar - jumlah per status", func(t *testing.T) {
+		mockRepo := new(mockDataGuru)
+		mockRepo.On("SelectBebanMengajar", "").Return([]guru.BebanMengajar{
+			{ID_Guru: "guru-001", MataPelajaran: []guru.MapelAjar{{ID: "mapel-001", Jam_Per_Minggu: 8}}},
+			{ID_Guru: "guru-002"},
+			{ID_Guru: "guru-003", MataPelajaran: []guru.MapelAjar{{ID: "mapel-002", Jam_Per_Minggu: 12}}},
+		}, nil).Once()
+
+		svc := &guruService{guruData: mockRepo, batas: batas}
+		rekap, err := svc.GetRekapBebanMengajar(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, rekap.JumlahGuru)
+		assert.Equal(t, 1, rekap.JumlahKurang)
+		assert.Equal(t, 1, rekap.JumlahNormal)
+		assert.Equal(t, 1, rekap.JumlahBerlebih)
+		assert.Equal(t, 20, rekap.TotalJam)
+		assert.Equal(t, batas, rekap.Batas)
+		assert.Equal(t, guru.StatusBebanKurang, rekap.Guru[1].Status)
+		mockRepo.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	izinguru "go_rest_native_sekolah/features/izin_guru"
+	"go_rest_native_sekolah/helper"
+	"net/http"
+	"strings"
+)
+
+// IzinController menghandle HTTP request izin guru dan guru pengganti.
+type IzinController struct {
+	izinService izinguru.ServiceIzinInterface
+}
+
+// NewIzinController membuat IzinController dengan service izin guru.
+func NewIzinController(service izinguru.ServiceIzinInterface) *IzinController {
+	return &IzinController{izinService: service}
+}
+
+// writeIzinError menulis response untuk error dari service izin guru.
+// Mengembalikan false jika error tidak dikenali sehingga pemanggil perlu meneruskannya.
+func writeIzinError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, helper.ErrVersionConflict):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case strings.Contains(err.Error(), "validation"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "akses ditolak"):
+		helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, err.Error(), nil))
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+// Izin menghandle GET /izin-guru?guru_id=&status=&dari=&sampai= untuk daftar izin. Semua filter opsional;
+// guru hanya mendapat izinnya sendiri.
+func (ic *IzinController) Izin(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+
+	q := r.URL.Query()
+	filter := izinguru.FilterIzin{
+		Guru_ID: strings.TrimSpace(q.Get("guru_id")),
+		Status:  q.Get("status"),
+		Dari:    q.Get("dari"),
+		Sampai:  q.Get("sampai"),
+	}
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	result, err := ic.izinService.GetAll(r.Context(), filter, meta)
+	if err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data izin guru", FormatIzinList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// InsertIzin menghandle POST /izin-guru/tambah. Body JSON berisi jenis (sakit, izin, cuti, atau dinas),
+// tanggal_mulai, tanggal_selesai (opsional), alasan, dan guru_id (wajib untuk admin, diabaikan untuk guru).
+func (ic *IzinController) InsertIzin(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+
+	var req IzinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data := IzinRequestToCore(req)
+	if err := ic.izinService.Insert(r.Context(), &data, meta); err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	created, err := ic.izinService.GetById(r.Context(), data.ID, meta)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil mengajukan izin guru", FormatIzinList([]izinguru.IzinCore{*created}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, created.Version)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// GetIzinById menghandle GET /izin-guru/{id}. Response membawa header ETag untuk keputusan dan pembatalan.
+func (ic *IzinController) GetIzinById(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data, err := ic.izinService.GetById(r.Context(), r.PathValue("id"), meta)
+	if err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data izin guru", FormatIzinList([]izinguru.IzinCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// DeleteIzin menghandle DELETE /izin-guru/{id} untuk membatalkan izin. Header If-Match wajib diisi dengan ETag terbaru.
+func (ic *IzinController) DeleteIzin(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := ic.izinService.DeleteById(r.Context(), r.PathValue("id"), version, meta); err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil membatalkan izin guru", nil))
+	return nil
+}
+
+// Putuskan menghandle PUT /izin-guru/{id}/keputusan. Body JSON berisi status (disetujui atau ditolak) dan catatan.
+// Header If-Match wajib diisi dengan ETag terbaru.
+func (ic *IzinController) Putuskan(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	var req KeputusanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := ic.izinService.Putuskan(r.Context(), id, req.Status, req.Catatan, version, meta); err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	updated, err := ic.izinService.GetById(r.Context(), id, meta)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil memutuskan izin guru", FormatIzinList([]izinguru.IzinCore{*updated}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updated.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// Sesi menghandle GET /izin-guru/{id}/sesi untuk pertemuan yang ditinggalkan guru selama izin beserta penggantinya.
+func (ic *IzinController) Sesi(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+
+	result, err := ic.izinService.Sesi(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil mengambil pertemuan selama izin", result))
+	return nil
+}
+
+// Kandidat menghandle GET /izin-guru/{id}/sesi/kandidat?jadwal_id=&tanggal= untuk daftar guru yang bisa
+// menggantikan satu pertemuan, urut dari yang paling cocok.
+func (ic *IzinController) Kandidat(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+
+	q := r.URL.Query()
+	result, err := ic.izinService.Kandidat(r.Context(), r.PathValue("id"), q.Get("jadwal_id"), q.Get("tanggal"))
+	if err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil mencari guru pengganti", result))
+	return nil
+}
+
+// SetPengganti menghandle PUT /izin-guru/{id}/pengganti. Body JSON berisi jadwal_id, tanggal, dan guru_id;
+// guru_id kosong menghapus pengganti. Response berisi pertemuan selama izin yang sudah diperbarui.
+func (ic *IzinController) SetPengganti(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	var req PenggantiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := ic.izinService.SetPengganti(r.Context(), id, req.Jadwal_ID, req.Tanggal, req.Guru_ID, meta); err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	result, err := ic.izinService.Sesi(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil mengatur guru pengganti", result))
+	return nil
+}
+
+// Pengganti menghandle GET /izin-guru/pengganti?izin_id=&guru_id=&dari=&sampai= untuk catatan pertemuan
+// yang digantikan. guru_id adalah guru pengganti; semua filter opsional.
+func (ic *IzinController) Pengganti(w http.ResponseWriter, r *http.Request) error {
+	if ic == nil || ic.izinService == nil {
+		return errors.New("izin guru controller: service is nil")
+	}
+
+	q := r.URL.Query()
+	filter := izinguru.FilterPengganti{
+		Izin_ID:      strings.TrimSpace(q.Get("izin_id")),
+		Pengganti_ID: strings.TrimSpace(q.Get("guru_id")),
+		Dari:         q.Get("dari"),
+		Sampai:       q.Get("sampai"),
+	}
+	result, err := ic.izinService.GetPengganti(r.Context(), filter)
+	if err != nil {
+		if writeIzinError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil mengambil data guru pengganti", result))
+	return nil
+}
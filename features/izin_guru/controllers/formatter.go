@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	izinguru "go_rest_native_sekolah/features/izin_guru"
+	"time"
+)
+
+// IzinFormatter digunakan untuk memformat izin guru pada response API.
+type IzinFormatter struct {
+	ID                string     `json:"id"`                // ID adalah ID unik izin
+	Guru_ID           string     `json:"guru_id"`           // Guru_ID adalah guru yang tidak hadir
+	Nama_Guru         string     `json:"nama_guru"`         // Nama_Guru adalah nama guru
+	Jenis             string     `json:"jenis"`             // Jenis adalah sakit, izin, cuti, atau dinas
+	Tanggal_Mulai     string     `json:"tanggal_mulai"`     // Tanggal_Mulai adalah hari pertama tidak hadir (YYYY-MM-DD)
+	Tanggal_Selesai   string     `json:"tanggal_selesai"`   // Tanggal_Selesai adalah hari terakhir tidak hadir (YYYY-MM-DD)
+	Alasan            string     `json:"alasan"`            // Alasan adalah keterangan dari pengaju
+	Status            string     `json:"status"`            // Status adalah menunggu, disetujui, atau ditolak
+	Diajukan_Oleh     string     `json:"diajukan_oleh"`     // Diajukan_Oleh adalah ID user pengaju
+	Diputuskan_Oleh   string     `json:"diputuskan_oleh"`   // Diputuskan_Oleh adalah ID user yang memutuskan
+	Diputuskan_At     *time.Time `json:"diputuskan_at"`     // Diputuskan_At adalah waktu keputusan, null selama menunggu
+	Catatan_Keputusan string     `json:"catatan_keputusan"` // Catatan_Keputusan adalah catatan keputusan
+	Update_At         time.Time  `json:"update_at"`         // Update_At adalah waktu perubahan terakhir
+	Version           int        `json:"version"`           // Version adalah versi data izin, sama dengan ETag
+}
+
+// IzinRequest digunakan untuk membaca body JSON pengajuan izin. Guru_ID hanya dipakai admin.
+type IzinRequest struct {
+	Guru_ID         string `json:"guru_id"`
+	Jenis           string `json:"jenis"`
+	Tanggal_Mulai   string `json:"tanggal_mulai"`
+	Tanggal_Selesai string `json:"tanggal_selesai"`
+	Alasan          string `json:"alasan"`
+}
+
+// KeputusanRequest digunakan untuk membaca body JSON keputusan izin.
+type KeputusanRequest struct {
+	Status  string `json:"status"`
+	Catatan string `json:"catatan"`
+}
+
+// PenggantiRequest digunakan untuk membaca body JSON pencatatan guru pengganti.
+// Guru_ID kosong menghapus pengganti pertemuan tersebut.
+type PenggantiRequest struct {
+	Jadwal_ID string `json:"jadwal_id"`
+	Tanggal   string `json:"tanggal"`
+	Guru_ID   string `json:"guru_id"`
+}
+
+// FormatIzinList mengubah slice IzinCore menjadi slice IzinFormatter.
+func FormatIzinList(cores []izinguru.IzinCore) []IzinFormatter {
+	formatted := make([]IzinFormatter, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, IzinFormatter{
+			ID:                core.ID,
+			Guru_ID:           core.Guru_ID,
+			Nama_Guru:         core.Nama_Guru,
+			Jenis:             core.Jenis,
+			Tanggal_Mulai:     core.Tanggal_Mulai,
+			Tanggal_Selesai:   core.Tanggal_Selesai,
+			Alasan:            core.Alasan,
+			Status:            core.Status,
+			Diajukan_Oleh:     core.Diajukan_Oleh,
+			Diputuskan_Oleh:   core.Diputuskan_Oleh,
+			Diputuskan_At:     core.Diputuskan_At,
+			Catatan_Keputusan: core.Catatan_Keputusan,
+			Update_At:         core.Update_At,
+			Version:           core.Version,
+		})
+	}
+	return formatted
+}
+
+// IzinRequestToCore mengubah IzinRequest menjadi IzinCore.
+func IzinRequestToCore(req IzinRequest) izinguru.IzinCore {
+	return izinguru.IzinCore{
+		Guru_ID:         req.Guru_ID,
+		Jenis:           req.Jenis,
+		Tanggal_Mulai:   req.Tanggal_Mulai,
+		Tanggal_Selesai: req.Tanggal_Selesai,
+		Alasan:          req.Alasan,
+	}
+}
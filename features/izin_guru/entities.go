@@ -0,0 +1,176 @@
+package izinguru
+
+import (
+	"context"
+	"go_rest_native_sekolah/features/kalender"
+	"go_rest_native_sekolah/helper"
+	"time"
+)
+
+// Jenis izin guru.
+const (
+	JenisSakit = "sakit"
+	JenisIzin  = "izin"
+	JenisCuti  = "cuti"
+	JenisDinas = "dinas"
+)
+
+// Status pengajuan izin guru.
+const (
+	StatusMenunggu  = "menunggu"
+	StatusDisetujui = "disetujui"
+	StatusDitolak   = "ditolak"
+)
+
+var (
+	// JenisValid berisi semua jenis izin yang diterima.
+	JenisValid = []string{JenisSakit, JenisIzin, JenisCuti, JenisDinas}
+	// KeputusanValid berisi status yang boleh diberikan admin saat memutuskan pengajuan.
+	KeputusanValid = []string{StatusDisetujui, StatusDitolak}
+	// PengajuRoles adalah role yang boleh mengajukan dan melihat izin. Guru hanya melihat izinnya sendiri.
+	PengajuRoles = []string{"admin", "guru"}
+	// PengelolaRoles adalah role yang boleh memutuskan izin dan mengatur guru pengganti.
+	PengelolaRoles = []string{"admin"}
+)
+
+type (
+	// IzinCore merepresentasikan pengajuan izin tidak hadir seorang guru pada rentang tanggal inklusif.
+	// Tanggal memakai format helper.DateLayout.
+	IzinCore struct {
+		ID                string     `json:"id"`                // ID adalah identifikasi unik pengajuan.
+		Guru_ID           string     `json:"guru_id"`           // Guru_ID adalah guru yang tidak hadir.
+		Nama_Guru         string     `json:"nama_guru"`         // Nama_Guru diambil dari tabel guru.
+		Jenis             string     `json:"jenis"`             // Jenis adalah sakit, izin, cuti, atau dinas.
+		Tanggal_Mulai     string     `json:"tanggal_mulai"`     // Tanggal_Mulai adalah hari pertama tidak hadir.
+		Tanggal_Selesai   string     `json:"tanggal_selesai"`   // Tanggal_Selesai adalah hari terakhir tidak hadir, default sama dengan Tanggal_Mulai.
+		Alasan            string     `json:"alasan"`            // Alasan adalah keterangan dari pengaju.
+		Status            string     `json:"status"`            // Status adalah menunggu, disetujui, atau ditolak.
+		Diajukan_Oleh     string     `json:"diajukan_oleh"`     // Diajukan_Oleh adalah ID user pengaju.
+		Diputuskan_Oleh   string     `json:"diputuskan_oleh"`   // Diputuskan_Oleh adalah ID user admin yang memutuskan.
+		Diputuskan_At     *time.Time `json:"diputuskan_at"`     // Diputuskan_At adalah waktu keputusan, nil selama menunggu.
+		Catatan_Keputusan string     `json:"catatan_keputusan"` // Catatan_Keputusan adalah catatan admin saat memutuskan.
+		Update_At         time.Time  `json:"update_at"`         // Update_At adalah waktu perubahan terakhir.
+		Version           int        `json:"version"`           // Version dipakai untuk optimistic locking.
+	}
+
+	// FilterIzin berisi filter opsional daftar izin. Field kosong berarti tidak difilter.
+	FilterIzin struct {
+		Guru_ID string // Guru_ID mengambil izin guru ini.
+		Status  string // Status mengambil izin dengan status ini.
+		Dari    string // Dari mengambil izin yang berakhir pada atau setelah tanggal ini.
+		Sampai  string // Sampai mengambil izin yang dimulai pada atau sebelum tanggal ini.
+	}
+
+	// SlotCore adalah satu slot jadwal mengajar mingguan beserta pengajarnya.
+	SlotCore struct {
+		Jadwal_ID         string   // Jadwal_ID adalah ID slot di tabel jadwal_mengajar.
+		Mata_Pelajaran_ID string   // Mata_Pelajaran_ID adalah penugasan mapel ke kelas.
+		Mapel_ID          string   // Mapel_ID adalah katalog mapel penugasan.
+		Nama_Pelajaran    string   // Nama_Pelajaran adalah nama mapel.
+		Kelas_ID          string   // Kelas_ID adalah kelas yang diajar.
+		Nama_Kelas        string   // Nama_Kelas adalah nama kelas.
+		Hari              int      // Hari adalah time.Weekday slot.
+		Jam_Mulai         string   // Jam_Mulai berformat HH:MM.
+		Jam_Selesai       string   // Jam_Selesai berformat HH:MM.
+		Pengajar          []string // Pengajar adalah ID guru pengajar slot.
+	}
+
+	// SesiCore adalah satu pertemuan yang ditinggalkan guru selama izin: satu slot pada satu tanggal efektif.
+	SesiCore struct {
+		Tanggal           string `json:"tanggal"`           // Tanggal adalah tanggal pertemuan.
+		Jadwal_ID         string `json:"jadwal_id"`         // Jadwal_ID adalah slot jadwal mengajar.
+		Mata_Pelajaran_ID string `json:"mata_pelajaran_id"` // Mata_Pelajaran_ID adalah penugasan mapel ke kelas.
+		Nama_Pelajaran    string `json:"nama_pelajaran"`    // Nama_Pelajaran adalah nama mapel.
+		Kelas_ID          string `json:"kelas_id"`          // Kelas_ID adalah kelas yang ditinggalkan.
+		Nama_Kelas        string `json:"nama_kelas"`        // Nama_Kelas adalah nama kelas.
+		Jam_Mulai         string `json:"jam_mulai"`         // Jam_Mulai berformat HH:MM.
+		Jam_Selesai       string `json:"jam_selesai"`       // Jam_Selesai berformat HH:MM.
+		Pengganti_ID      string `json:"pengganti_id"`      // Pengganti_ID adalah guru pengganti yang sudah dicatat, kosong jika belum ada.
+		Nama_Pengganti    string `json:"nama_pengganti"`    // Nama_Pengganti adalah nama guru pengganti.
+	}
+
+	// KandidatCore adalah guru yang bisa menggantikan satu pertemuan, diurutkan dari yang paling cocok.
+	KandidatCore struct {
+		Guru_ID          string `json:"guru_id"`          // Guru_ID adalah ID guru kandidat.
+		Nama             string `json:"nama"`             // Nama adalah nama guru kandidat.
+		Mapel_Sama       bool   `json:"mapel_sama"`       // Mapel_Sama bernilai true jika guru juga mengajar mapel yang sama.
+		Kelas_Sama       bool   `json:"kelas_sama"`       // Kelas_Sama bernilai true jika guru juga mengajar di kelas tersebut.
+		Jumlah_Pengganti int    `json:"jumlah_pengganti"` // Jumlah_Pengganti adalah banyaknya pertemuan yang sudah ia gantikan pada minggu yang sama.
+	}
+
+	// PenggantiCore adalah catatan guru yang menggantikan satu pertemuan.
+	PenggantiCore struct {
+		ID             string    `json:"id"`             // ID adalah identifikasi unik catatan.
+		Izin_ID        string    `json:"izin_id"`        // Izin_ID adalah izin yang menyebabkan penggantian.
+		Jadwal_ID      string    `json:"jadwal_id"`      // Jadwal_ID adalah slot yang digantikan.
+		Tanggal        string    `json:"tanggal"`        // Tanggal adalah tanggal pertemuan.
+		Guru_ID        string    `json:"guru_id"`        // Guru_ID adalah guru yang izin.
+		Nama_Guru      string    `json:"nama_guru"`      // Nama_Guru adalah nama guru yang izin.
+		Pengganti_ID   string    `json:"pengganti_id"`   // Pengganti_ID adalah guru yang menggantikan.
+		Nama_Pengganti string    `json:"nama_pengganti"` // Nama_Pengganti adalah nama guru yang menggantikan.
+		Nama_Pelajaran string    `json:"nama_pelajaran"` // Nama_Pelajaran adalah nama mapel slot.
+		Nama_Kelas     string    `json:"nama_kelas"`     // Nama_Kelas adalah nama kelas slot.
+		Jam_Mulai      string    `json:"jam_mulai"`      // Jam_Mulai berformat HH:MM.
+		Jam_Selesai    string    `json:"jam_selesai"`    // Jam_Selesai berformat HH:MM.
+		Dicatat_Oleh   string    `json:"dicatat_oleh"`   // Dicatat_Oleh adalah ID user admin yang mencatat.
+		Update_At      time.Time `json:"update_at"`      // Update_At adalah waktu pencatatan.
+	}
+
+	// FilterPengganti berisi filter opsional daftar penggantian. Field kosong berarti tidak difilter.
+	FilterPengganti struct {
+		Izin_ID      string // Izin_ID mengambil penggantian untuk izin ini.
+		Pengganti_ID string // Pengganti_ID mengambil pertemuan yang digantikan guru ini.
+		Dari         string // Dari mengambil pertemuan pada atau setelah tanggal ini.
+		Sampai       string // Sampai mengambil pertemuan pada atau sebelum tanggal ini.
+	}
+
+	// GuruCore adalah guru aktif beserta mapel dan kelas yang ia ajar, dipakai untuk mencari pengganti.
+	GuruCore struct {
+		ID       string   // ID adalah identifikasi unik guru.
+		Nama     string   // Nama adalah nama guru.
+		Mapel_ID []string // Mapel_ID adalah katalog mapel yang ia ajar.
+		Kelas_ID []string // Kelas_ID adalah kelas yang ia ajar.
+	}
+
+	// HariEfektifInterface adalah bagian service kalender yang dipakai untuk mencari tanggal pertemuan.
+	// kalender.ServiceKalenderInterface memenuhi interface ini.
+	HariEfektifInterface interface {
+		HariEfektif(ctx context.Context, dari, sampai, kelasID string) (*kalender.HariEfektifCore, error)
+	}
+
+	// DataIzinInterface mendefinisikan operasi tabel izin_guru dan penggantian_guru.
+	DataIzinInterface interface {
+		SelectAll(ctx context.Context, filter FilterIzin) ([]IzinCore, error)                 // Mengambil izin sesuai filter, terbaru lebih dulu.
+		SelectById(ctx context.Context, id string) (*IzinCore, error)                         // Mengambil satu izin, pgx.ErrNoRows jika tidak ada.
+		Insert(ctx context.Context, insert *IzinCore) error                                   // Menyimpan pengajuan baru.
+		Putuskan(ctx context.Context, keputusan *IzinCore, id string) error                   // Menyimpan keputusan jika versinya masih sama.
+		DeleteById(ctx context.Context, id string, version int) error                         // Menghapus izin beserta penggantiannya jika versinya masih sama.
+		SelectGuruByUser(ctx context.Context, userID string) (string, error)                  // Mengambil ID guru milik user, pgx.ErrNoRows jika user bukan guru.
+		GuruAda(ctx context.Context, guruID string) (bool, error)                             // Memeriksa apakah guru aktif ada.
+		SelectGuru(ctx context.Context) ([]GuruCore, error)                                   // Mengambil semua guru aktif beserta mapel dan kelas yang ia ajar.
+		SelectSlot(ctx context.Context, guruID string) ([]SlotCore, error)                    // Mengambil slot yang diajar guru, semua slot jika guruID kosong.
+		SelectPengganti(ctx context.Context, filter FilterPengganti) ([]PenggantiCore, error) // Mengambil catatan penggantian.
+		SimpanPengganti(ctx context.Context, p *PenggantiCore) error                          // Menyimpan atau mengganti pengganti satu pertemuan.
+		HapusPengganti(ctx context.Context, izinID, jadwalID, tanggal string) error           // Menghapus pengganti satu pertemuan, pgx.ErrNoRows jika tidak ada.
+	}
+
+	// ServiceIzinInterface mendefinisikan logika bisnis izin guru dan guru pengganti.
+	// Guru hanya boleh mengajukan, melihat, dan membatalkan izinnya sendiri; admin boleh semuanya.
+	ServiceIzinInterface interface {
+		GetAll(ctx context.Context, filter FilterIzin, pengguna helper.MetaToken) ([]IzinCore, error) // Mengambil daftar izin.
+		GetById(ctx context.Context, id string, pengguna helper.MetaToken) (*IzinCore, error)         // Mengambil satu izin.
+		Insert(ctx context.Context, insert *IzinCore, pengguna helper.MetaToken) error                // Memvalidasi dan menyimpan pengajuan izin.
+		// Putuskan menyetujui atau menolak izin yang masih menunggu.
+		Putuskan(ctx context.Context, id, status, catatan string, version int, pengguna helper.MetaToken) error
+		DeleteById(ctx context.Context, id string, version int, pengguna helper.MetaToken) error // Membatalkan izin.
+		// Sesi mengambil pertemuan guru yang jatuh pada hari efektif selama izin beserta penggantinya.
+		Sesi(ctx context.Context, id string) ([]SesiCore, error)
+		// Kandidat mengambil guru yang bisa menggantikan satu pertemuan: tidak sedang izin, tidak mengajar,
+		// dan belum menggantikan pertemuan lain pada jam yang beririsan.
+		Kandidat(ctx context.Context, id, jadwalID, tanggal string) ([]KandidatCore, error)
+		// SetPengganti mencatat guru pengganti satu pertemuan pada izin yang sudah disetujui.
+		// penggantiID kosong menghapus catatan pengganti.
+		SetPengganti(ctx context.Context, id, jadwalID, tanggal, penggantiID string, pengguna helper.MetaToken) error
+		GetPengganti(ctx context.Context, filter FilterPengganti) ([]PenggantiCore, error) // Mengambil catatan penggantian.
+	}
+)
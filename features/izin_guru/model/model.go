@@ -0,0 +1,66 @@
+package model
+
+import (
+	izinguru "go_rest_native_sekolah/features/izin_guru"
+	"time"
+)
+
+// Izin merepresentasikan satu baris tabel izin_guru beserta nama gurunya.
+type Izin struct {
+	ID                string     `json:"id"`                // ID adalah identifikasi unik pengajuan.
+	Guru_ID           string     `json:"guru_id"`           // Guru_ID adalah guru yang tidak hadir.
+	Nama_Guru         string     `json:"nama_guru"`         // Nama_Guru diambil dari tabel guru.
+	Jenis             string     `json:"jenis"`             // Jenis adalah sakit, izin, cuti, atau dinas.
+	Tanggal_Mulai     string     `json:"tanggal_mulai"`     // Tanggal_Mulai disimpan sebagai DATE.
+	Tanggal_Selesai   string     `json:"tanggal_selesai"`   // Tanggal_Selesai disimpan sebagai DATE.
+	Alasan            string     `json:"alasan"`            // Alasan adalah keterangan dari pengaju.
+	Status            string     `json:"status"`            // Status adalah menunggu, disetujui, atau ditolak.
+	Diajukan_Oleh     string     `json:"diajukan_oleh"`     // Diajukan_Oleh adalah ID user pengaju.
+	Diputuskan_Oleh   string     `json:"diputuskan_oleh"`   // Diputuskan_Oleh adalah ID user yang memutuskan.
+	Diputuskan_At     *time.Time `json:"diputuskan_at"`     // Diputuskan_At bernilai NULL selama menunggu.
+	Catatan_Keputusan string     `json:"catatan_keputusan"` // Catatan_Keputusan adalah catatan keputusan.
+	Update_At         time.Time  `json:"update_at"`         // Update_At adalah waktu perubahan terakhir.
+	Version           int        `json:"version"`           // Version adalah versi data untuk optimistic concurrency.
+}
+
+// TableName mengembalikan nama tabel izin guru di database.
+func (i *Izin) TableName() string {
+	return "izin_guru"
+}
+
+// FormatterRequest mengubah IzinCore menjadi Izin untuk disimpan ke database.
+func FormatterRequest(req izinguru.IzinCore) Izin {
+	return Izin{
+		ID:                req.ID,
+		Guru_ID:           req.Guru_ID,
+		Jenis:             req.Jenis,
+		Tanggal_Mulai:     req.Tanggal_Mulai,
+		Tanggal_Selesai:   req.Tanggal_Selesai,
+		Alasan:            req.Alasan,
+		Status:            req.Status,
+		Diajukan_Oleh:     req.Diajukan_Oleh,
+		Diputuskan_Oleh:   req.Diputuskan_Oleh,
+		Catatan_Keputusan: req.Catatan_Keputusan,
+		Version:           req.Version,
+	}
+}
+
+// FormatterResponse mengubah Izin dari database menjadi IzinCore.
+func FormatterResponse(res Izin) izinguru.IzinCore {
+	return izinguru.IzinCore{
+		ID:                res.ID,
+		Guru_ID:           res.Guru_ID,
+		Nama_Guru:         res.Nama_Guru,
+		Jenis:             res.Jenis,
+		Tanggal_Mulai:     res.Tanggal_Mulai,
+		Tanggal_Selesai:   res.Tanggal_Selesai,
+		Alasan:            res.Alasan,
+		Status:            res.Status,
+		Diajukan_Oleh:     res.Diajukan_Oleh,
+		Diputuskan_Oleh:   res.Diputuskan_Oleh,
+		Diputuskan_At:     res.Diputuskan_At,
+		Catatan_Keputusan: res.Catatan_Keputusan,
+		Update_At:         res.Update_At,
+		Version:           res.Version,
+	}
+}
@@ -0,0 +1,375 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	izinguru "go_rest_native_sekolah/features/izin_guru"
+	"go_rest_native_sekolah/helper"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// izinQuery menghandle query ke tabel izin_guru dan penggantian_guru.
+type izinQuery struct {
+	db helper.DBTX
+}
+
+// NewDataIzin membuat objek izinQuery dengan parameter db.
+// Jika parameter db nil maka akan terjadi panic.
+func NewDataIzin(db helper.DBTX) izinguru.DataIzinInterface {
+	if db == nil {
+		panic("izin guru model: Nil database")
+	}
+	return &izinQuery{db: db}
+}
+
+// kolomIzin adalah daftar kolom yang diambil untuk setiap izin, sesuai urutan scan di selectIzin.
+// Query yang memakainya harus memberi alias i pada tabel izin_guru dan g pada tabel guru.
+const kolomIzin = `i.id, i.guru_id, COALESCE(g.nama, ''), i.jenis, TO_CHAR(i.tanggal_mulai, 'YYYY-MM-DD'),
+	TO_CHAR(i.tanggal_selesai, 'YYYY-MM-DD'), COALESCE(i.alasan, ''), i.status, COALESCE(i.diajukan_oleh, ''),
+	COALESCE(i.diputuskan_oleh, ''), i.diputuskan_at, COALESCE(i.catatan_keputusan, ''), i.update_at, i.version`
+
+// dariIzin adalah klausa FROM untuk kolomIzin.
+const dariIzin = ` FROM izin_guru i LEFT JOIN guru g ON g.id = i.guru_id WHERE i.delete_at IS NULL`
+
+// selectIzin menjalankan query izin dan mengubah semua barisnya menjadi IzinCore.
+func (q *izinQuery) selectIzin(ctx context.Context, query string, args ...any) ([]izinguru.IzinCore, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectIzin error query", "error", err)
+		return nil, fmt.Errorf("select izin guru failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []izinguru.IzinCore{}
+	for rows.Next() {
+		var data Izin
+		err := rows.Scan(&data.ID, &data.Guru_ID, &data.Nama_Guru, &data.Jenis, &data.Tanggal_Mulai,
+			&data.Tanggal_Selesai, &data.Alasan, &data.Status, &data.Diajukan_Oleh, &data.Diputuskan_Oleh,
+			&data.Diputuskan_At, &data.Catatan_Keputusan, &data.Update_At, &data.Version)
+		if err != nil {
+			return nil, fmt.Errorf("select izin guru failed: %w", err)
+		}
+		result = append(result, FormatterResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select izin guru failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectAll implements izinguru.DataIzinInterface.
+func (q *izinQuery) SelectAll(ctx context.Context, filter izinguru.FilterIzin) ([]izinguru.IzinCore, error) {
+	var kondisi []string
+	var args []any
+	tambah := func(format string, nilai any) {
+		args = append(args, nilai)
+		kondisi = append(kondisi, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Guru_ID != "" {
+		tambah("i.guru_id = $%d", filter.Guru_ID)
+	}
+	if filter.Status != "" {
+		tambah("i.status = $%d", filter.Status)
+	}
+	if filter.Dari != "" {
+		tambah("i.tanggal_selesai >= $%d::date", filter.Dari)
+	}
+	if filter.Sampai != "" {
+		tambah("i.tanggal_mulai <= $%d::date", filter.Sampai)
+	}
+
+	where := ""
+	if len(kondisi) > 0 {
+		where = " AND " + strings.Join(kondisi, " AND ")
+	}
+	return q.selectIzin(ctx, "SELECT "+kolomIzin+dariIzin+where+" ORDER BY i.tanggal_mulai DESC, i.id", args...)
+}
+
+// SelectById implements izinguru.DataIzinInterface.
+func (q *izinQuery) SelectById(ctx context.Context, id string) (*izinguru.IzinCore, error) {
+	result, err := q.selectIzin(ctx, "SELECT "+kolomIzin+dariIzin+" AND i.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// Insert implements izinguru.DataIzinInterface.
+// ID dibuat otomatis jika kosong; Update_At dan Version diisi dari database.
+func (q *izinQuery) Insert(ctx context.Context, insert *izinguru.IzinCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	data := FormatterRequest(*insert)
+	query := `INSERT INTO izin_guru (id, guru_id, jenis, tanggal_mulai, tanggal_selesai, alasan, status, diajukan_oleh)
+		VALUES ($1, $2, $3, $4::date, $5::date, NULLIF($6, ''), $7, NULLIF($8, ''))
+		RETURNING update_at, version`
+	err := q.db.QueryRow(ctx, query, data.ID, data.Guru_ID, data.Jenis, data.Tanggal_Mulai, data.Tanggal_Selesai,
+		data.Alasan, data.Status, data.Diajukan_Oleh).Scan(&insert.Update_At, &insert.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert izin guru error", "error", err)
+		return fmt.Errorf("insert izin guru failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully inserted izin guru", "id", insert.ID)
+	return nil
+}
+
+// Putuskan implements izinguru.DataIzinInterface.
+// Mengembalikan helper.ErrVersionConflict jika versinya sudah berubah dan pgx.ErrNoRows jika izin tidak ada.
+func (q *izinQuery) Putuskan(ctx context.Context, keputusan *izinguru.IzinCore, id string) error {
+	if keputusan == nil {
+		return errors.New("update data is nil")
+	}
+
+	data := FormatterRequest(*keputusan)
+	query := `UPDATE izin_guru
+		SET status = $1, diputuskan_oleh = NULLIF($2, ''), diputuskan_at = CURRENT_TIMESTAMP,
+			catatan_keputusan = NULLIF($3, ''), update_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $4 AND delete_at IS NULL AND version = $5`
+	tag, err := q.db.Exec(ctx, query, data.Status, data.Diputuskan_Oleh, data.Catatan_Keputusan, id, data.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Putuskan izin guru error", "error", err)
+		return fmt.Errorf("update izin guru failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "izin_guru", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully decided izin guru", "id", id, "status", data.Status)
+	return nil
+}
+
+// DeleteById implements izinguru.DataIzinInterface.
+// Izin hanya ditandai terhapus (soft delete), sedangkan catatan penggantiannya dihapus pada statement yang sama.
+func (q *izinQuery) DeleteById(ctx context.Context, id string, version int) error {
+	var jumlah int
+	err := q.db.QueryRow(ctx, `WITH hapus AS (
+			UPDATE izin_guru SET delete_at = NOW(), version = version + 1
+			WHERE id = $1 AND delete_at IS NULL AND version = $2 RETURNING id
+		), bersih AS (
+			DELETE FROM penggantian_guru WHERE izin_id IN (SELECT id FROM hapus)
+		)
+		SELECT COUNT(*) FROM hapus`, id, version).Scan(&jumlah)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete izin guru error", "error", err)
+		return fmt.Errorf("delete izin guru failed: %w", err)
+	}
+	if jumlah == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "izin_guru", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted izin guru", "id", id)
+	return nil
+}
+
+// SelectGuruByUser implements izinguru.DataIzinInterface.
+func (q *izinQuery) SelectGuruByUser(ctx context.Context, userID string) (string, error) {
+	var guruID string
+	err := q.db.QueryRow(ctx, "SELECT id FROM guru WHERE id_user = $1 AND delete_at IS NULL", userID).Scan(&guruID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", err
+		}
+		helper.LoggerFromContext(ctx).Error("SelectGuruByUser error query", "error", err)
+		return "", fmt.Errorf("select guru failed: %w", err)
+	}
+	return guruID, nil
+}
+
+// GuruAda implements izinguru.DataIzinInterface.
+func (q *izinQuery) GuruAda(ctx context.Context, guruID string) (bool, error) {
+	var ada bool
+	err := q.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM guru WHERE id = $1 AND delete_at IS NULL)", guruID).Scan(&ada)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("GuruAda error query", "error", err)
+		return false, fmt.Errorf("cek guru failed: %w", err)
+	}
+	return ada, nil
+}
+
+// SelectGuru implements izinguru.DataIzinInterface.
+// Mapel dan kelas diambil dari penugasan mata pelajaran aktif yang diajar guru.
+func (q *izinQuery) SelectGuru(ctx context.Context) ([]izinguru.GuruCore, error) {
+	rows, err := q.db.Query(ctx, `SELECT g.id, g.nama,
+			COALESCE(array_agg(DISTINCT mp.mapel_id) FILTER (WHERE mp.id IS NOT NULL), '{}'),
+			COALESCE(array_agg(DISTINCT mp.kelas_id) FILTER (WHERE mp.kelas_id IS NOT NULL), '{}')
+		FROM guru g
+		LEFT JOIN mata_pelajaran_guru mpg ON mpg.id_guru = g.id
+		LEFT JOIN mata_pelajaran mp ON mp.id = mpg.mata_pelajaran_id AND mp.delete_at IS NULL
+		WHERE g.delete_at IS NULL
+		GROUP BY g.id, g.nama
+		ORDER BY g.nama, g.id`)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectGuru error query", "error", err)
+		return nil, fmt.Errorf("select guru failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []izinguru.GuruCore{}
+	for rows.Next() {
+		var g izinguru.GuruCore
+		if err := rows.Scan(&g.ID, &g.Nama, &g.Mapel_ID, &g.Kelas_ID); err != nil {
+			return nil, fmt.Errorf("select guru failed: %w", err)
+		}
+		result = append(result, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select guru failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectSlot implements izinguru.DataIzinInterface.
+func (q *izinQuery) SelectSlot(ctx context.Context, guruID string) ([]izinguru.SlotCore, error) {
+	query := `SELECT j.id, mp.id, mp.mapel_id, COALESCE(m.nama, ''), COALESCE(mp.kelas_id, ''), COALESCE(k.kelas, ''),
+			j.hari, TO_CHAR(j.jam_mulai, 'HH24:MI'), TO_CHAR(j.jam_selesai, 'HH24:MI'),
+			COALESCE((SELECT array_agg(mpg.id_guru ORDER BY mpg.id_guru) FROM mata_pelajaran_guru mpg
+				WHERE mpg.mata_pelajaran_id = mp.id), '{}')
+		FROM jadwal_mengajar j
+		JOIN mata_pelajaran mp ON mp.id = j.mata_pelajaran_id
+		JOIN mapel m ON m.id = mp.mapel_id
+		LEFT JOIN kelas k ON k.id = mp.kelas_id
+		WHERE j.delete_at IS NULL AND mp.delete_at IS NULL`
+	var args []any
+	if guruID != "" {
+		query += " AND EXISTS (SELECT 1 FROM mata_pelajaran_guru mpg WHERE mpg.mata_pelajaran_id = mp.id AND mpg.id_guru = $1)"
+		args = append(args, guruID)
+	}
+	query += " ORDER BY j.hari, j.jam_mulai, k.kelas, j.id"
+
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectSlot error query", "error", err)
+		return nil, fmt.Errorf("select jadwal mengajar failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []izinguru.SlotCore{}
+	for rows.Next() {
+		var s izinguru.SlotCore
+		err := rows.Scan(&s.Jadwal_ID, &s.Mata_Pelajaran_ID, &s.Mapel_ID, &s.Nama_Pelajaran, &s.Kelas_ID, &s.Nama_Kelas,
+			&s.Hari, &s.Jam_Mulai, &s.Jam_Selesai, &s.Pengajar)
+		if err != nil {
+			return nil, fmt.Errorf("select jadwal mengajar failed: %w", err)
+		}
+		result = append(result, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select jadwal mengajar failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectPengganti implements izinguru.DataIzinInterface.
+// Catatan milik izin yang sudah dihapus tidak ikut diambil.
+func (q *izinQuery) SelectPengganti(ctx context.Context, filter izinguru.FilterPengganti) ([]izinguru.PenggantiCore, error) {
+	var kondisi []string
+	var args []any
+	tambah := func(format string, nilai any) {
+		args = append(args, nilai)
+		kondisi = append(kondisi, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Izin_ID != "" {
+		tambah("p.izin_id = $%d", filter.Izin_ID)
+	}
+	if filter.Pengganti_ID != "" {
+		tambah("p.pengganti_id = $%d", filter.Pengganti_ID)
+	}
+	if filter.Dari != "" {
+		tambah("p.tanggal >= $%d::date", filter.Dari)
+	}
+	if filter.Sampai != "" {
+		tambah("p.tanggal <= $%d::date", filter.Sampai)
+	}
+
+	query := `SELECT p.id, p.izin_id, p.jadwal_id, TO_CHAR(p.tanggal, 'YYYY-MM-DD'), i.guru_id, COALESCE(g.nama, ''),
+			p.pengganti_id, COALESCE(gp.nama, ''), COALESCE(m.nama, ''), COALESCE(k.kelas, ''),
+			TO_CHAR(j.jam_mulai, 'HH24:MI'), TO_CHAR(j.jam_selesai, 'HH24:MI'), COALESCE(p.dicatat_oleh, ''), p.update_at
+		FROM penggantian_guru p
+		JOIN izin_guru i ON i.id = p.izin_id AND i.delete_at IS NULL
+		JOIN jadwal_mengajar j ON j.id = p.jadwal_id
+		JOIN mata_pelajaran mp ON mp.id = j.mata_pelajaran_id
+		LEFT JOIN mapel m ON m.id = mp.mapel_id
+		LEFT JOIN kelas k ON k.id = mp.kelas_id
+		LEFT JOIN guru g ON g.id = i.guru_id
+		LEFT JOIN guru gp ON gp.id = p.pengganti_id`
+	if len(kondisi) > 0 {
+		query += " WHERE " + strings.Join(kondisi, " AND ")
+	}
+	query += " ORDER BY p.tanggal, j.jam_mulai, k.kelas, p.id"
+
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectPengganti error query", "error", err)
+		return nil, fmt.Errorf("select penggantian guru failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []izinguru.PenggantiCore{}
+	for rows.Next() {
+		var p izinguru.PenggantiCore
+		err := rows.Scan(&p.ID, &p.Izin_ID, &p.Jadwal_ID, &p.Tanggal, &p.Guru_ID, &p.Nama_Guru, &p.Pengganti_ID,
+			&p.Nama_Pengganti, &p.Nama_Pelajaran, &p.Nama_Kelas, &p.Jam_Mulai, &p.Jam_Selesai, &p.Dicatat_Oleh, &p.Update_At)
+		if err != nil {
+			return nil, fmt.Errorf("select penggantian guru failed: %w", err)
+		}
+		result = append(result, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select penggantian guru failed: %w", err)
+	}
+	return result, nil
+}
+
+// SimpanPengganti implements izinguru.DataIzinInterface.
+// Satu pertemuan (jadwal_id, tanggal) hanya punya satu pengganti sehingga pengganti lama diganti.
+func (q *izinQuery) SimpanPengganti(ctx context.Context, p *izinguru.PenggantiCore) error {
+	if p == nil {
+		return errors.New("insert data is nil")
+	}
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+
+	query := `INSERT INTO penggantian_guru (id, izin_id, jadwal_id, tanggal, pengganti_id, dicatat_oleh)
+		VALUES ($1, $2, $3, $4::date, $5, NULLIF($6, ''))
+		ON CONFLICT (jadwal_id, tanggal) DO UPDATE
+		SET izin_id = EXCLUDED.izin_id, pengganti_id = EXCLUDED.pengganti_id, dicatat_oleh = EXCLUDED.dicatat_oleh,
+			update_at = CURRENT_TIMESTAMP
+		RETURNING id, update_at`
+	err := q.db.QueryRow(ctx, query, p.ID, p.Izin_ID, p.Jadwal_ID, p.Tanggal, p.Pengganti_ID, p.Dicatat_Oleh).
+		Scan(&p.ID, &p.Update_At)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SimpanPengganti error", "error", err)
+		return fmt.Errorf("simpan penggantian guru failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully saved penggantian guru", "id", p.ID, "jadwal_id", p.Jadwal_ID, "tanggal", p.Tanggal)
+	return nil
+}
+
+// HapusPengganti implements izinguru.DataIzinInterface.
+func (q *izinQuery) HapusPengganti(ctx context.Context, izinID, jadwalID, tanggal string) error {
+	tag, err := q.db.Exec(ctx,
+		"DELETE FROM penggantian_guru WHERE izin_id = $1 AND jadwal_id = $2 AND tanggal = $3::date", izinID, jadwalID, tanggal)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("HapusPengganti error", "error", err)
+		return fmt.Errorf("hapus penggantian guru failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted penggantian guru", "izin_id", izinID, "jadwal_id", jadwalID, "tanggal", tanggal)
+	return nil
+}
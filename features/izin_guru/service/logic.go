@@ -0,0 +1,539 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	izinguru "go_rest_native_sekolah/features/izin_guru"
+	"go_rest_native_sekolah/features/kalender"
+	"go_rest_native_sekolah/helper"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errIzinNotFound      = errors.New("izin guru service: Data tidak ditemukan")
+	errGuruNotFound      = errors.New("izin guru service: Guru tidak ditemukan")
+	errSesiNotFound      = errors.New("izin guru service: Sesi tidak ditemukan")
+	errPenggantiNotFound = errors.New("izin guru service: Pengganti tidak ditemukan")
+	errBukanGuru         = errors.New("izin guru service: akses ditolak, akun ini tidak terhubung ke data guru")
+	errBukanMilik        = errors.New("izin guru service: akses ditolak, guru hanya boleh mengelola izinnya sendiri")
+)
+
+// maxAlasan adalah panjang maksimum alasan dan catatan keputusan, sama dengan kolom di database.
+const maxAlasan = 500
+
+// izinService merepresentasikan service untuk izin guru dan guru pengganti.
+type izinService struct {
+	izinData izinguru.DataIzinInterface    // izinData berisi akses ke tabel izin_guru dan penggantian_guru
+	kalender izinguru.HariEfektifInterface // kalender menghitung hari efektif selama izin
+}
+
+// NewServiceIzin membuat service izin guru.
+// Parameter hariEfektif dipakai untuk mencari tanggal pertemuan yang jatuh pada hari efektif selama izin.
+// Jika parameter repo atau hariEfektif nil maka akan terjadi panic.
+func NewServiceIzin(repo izinguru.DataIzinInterface, hariEfektif izinguru.HariEfektifInterface) izinguru.ServiceIzinInterface {
+	if repo == nil || hariEfektif == nil {
+		panic("izin guru service: Nil repository atau kalender")
+	}
+	return &izinService{izinData: repo, kalender: hariEfektif}
+}
+
+// GetAll implements izinguru.ServiceIzinInterface.
+// Guru hanya mendapat izinnya sendiri, apa pun isi filter Guru_ID.
+func (s *izinService) GetAll(ctx context.Context, filter izinguru.FilterIzin, pengguna helper.MetaToken) ([]izinguru.IzinCore, error) {
+	filter.Status = strings.ToLower(strings.TrimSpace(filter.Status))
+	if filter.Status != "" && filter.Status != izinguru.StatusMenunggu && !slices.Contains(izinguru.KeputusanValid, filter.Status) {
+		return nil, fmt.Errorf("validation error: status harus salah satu dari %s, %s",
+			izinguru.StatusMenunggu, strings.Join(izinguru.KeputusanValid, ", "))
+	}
+	var err error
+	if filter.Dari, err = helper.NormalizeDate("dari", filter.Dari, false); err != nil {
+		return nil, err
+	}
+	if filter.Sampai, err = helper.NormalizeDate("sampai", filter.Sampai, false); err != nil {
+		return nil, err
+	}
+	if pengguna.Role != "admin" {
+		if filter.Guru_ID, err = s.guruPengguna(ctx, pengguna); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.izinData.SelectAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("izin guru service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// GetById implements izinguru.ServiceIzinInterface.
+func (s *izinService) GetById(ctx context.Context, id string, pengguna helper.MetaToken) (*izinguru.IzinCore, error) {
+	return s.izinPengguna(ctx, id, pengguna)
+}
+
+// Insert implements izinguru.ServiceIzinInterface.
+// Guru selalu mengajukan untuk dirinya sendiri; admin wajib mengisi Guru_ID. Pengajuan ditolak jika beririsan
+// dengan izin lain guru yang sama yang belum ditolak.
+func (s *izinService) Insert(ctx context.Context, insert *izinguru.IzinCore, pengguna helper.MetaToken) error {
+	if insert == nil {
+		return errors.New("izin guru service: input is nil")
+	}
+
+	insert.Guru_ID = strings.TrimSpace(insert.Guru_ID)
+	if pengguna.Role == "admin" {
+		if insert.Guru_ID == "" {
+			return errors.New("validation error: guru_id harus diisi")
+		}
+		ada, err := s.izinData.GuruAda(ctx, insert.Guru_ID)
+		if err != nil {
+			return fmt.Errorf("izin guru service: gagal mengambil guru: %w", err)
+		}
+		if !ada {
+			return errGuruNotFound
+		}
+	} else {
+		guruID, err := s.guruPengguna(ctx, pengguna)
+		if err != nil {
+			return err
+		}
+		if insert.Guru_ID != "" && insert.Guru_ID != guruID {
+			return errBukanMilik
+		}
+		insert.Guru_ID = guruID
+	}
+
+	insert.Jenis = strings.ToLower(strings.TrimSpace(insert.Jenis))
+	if !slices.Contains(izinguru.JenisValid, insert.Jenis) {
+		return fmt.Errorf("validation error: jenis harus salah satu dari %s", strings.Join(izinguru.JenisValid, ", "))
+	}
+
+	mulai, err := helper.ParseRequiredDate("tanggal_mulai", insert.Tanggal_Mulai)
+	if err != nil {
+		return err
+	}
+	selesai := mulai
+	if strings.TrimSpace(insert.Tanggal_Selesai) != "" {
+		if selesai, err = helper.ParseRequiredDate("tanggal_selesai", insert.Tanggal_Selesai); err != nil {
+			return err
+		}
+	}
+	if selesai.Before(mulai) {
+		return errors.New("validation error: tanggal_selesai tidak boleh sebelum tanggal_mulai")
+	}
+	if hari := int(selesai.Sub(mulai).Hours()/24) + 1; hari > kalender.MaxRentangHari {
+		return fmt.Errorf("validation error: izin paling lama %d hari", kalender.MaxRentangHari)
+	}
+	insert.Tanggal_Mulai = mulai.Format(helper.DateLayout)
+	insert.Tanggal_Selesai = selesai.Format(helper.DateLayout)
+
+	insert.Alasan = strings.TrimSpace(insert.Alasan)
+	if utf8.RuneCountInString(insert.Alasan) > maxAlasan {
+		return fmt.Errorf("validation error: alasan maksimal %d karakter", maxAlasan)
+	}
+
+	lain, err := s.izinData.SelectAll(ctx, izinguru.FilterIzin{
+		Guru_ID: insert.Guru_ID, Dari: insert.Tanggal_Mulai, Sampai: insert.Tanggal_Selesai,
+	})
+	if err != nil {
+		return fmt.Errorf("izin guru service: gagal mengambil data: %w", err)
+	}
+	for _, l := range lain {
+		if l.Status != izinguru.StatusDitolak {
+			return fmt.Errorf("validation error: guru sudah punya izin %s (%s) pada %s sampai %s",
+				l.Jenis, l.Status, l.Tanggal_Mulai, l.Tanggal_Selesai)
+		}
+	}
+
+	insert.ID = ""
+	insert.Status = izinguru.StatusMenunggu
+	insert.Diajukan_Oleh = pengguna.ID
+	insert.Diputuskan_Oleh = ""
+	insert.Diputuskan_At = nil
+	insert.Catatan_Keputusan = ""
+	if err := s.izinData.Insert(ctx, insert); err != nil {
+		return fmt.Errorf("izin guru service: gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+// Putuskan implements izinguru.ServiceIzinInterface.
+// Keputusan bersifat final: izin yang sudah disetujui atau ditolak tidak bisa diputuskan ulang.
+func (s *izinService) Putuskan(ctx context.Context, id, status, catatan string, version int, pengguna helper.MetaToken) error {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if !slices.Contains(izinguru.KeputusanValid, status) {
+		return fmt.Errorf("validation error: status harus salah satu dari %s", strings.Join(izinguru.KeputusanValid, ", "))
+	}
+	catatan = strings.TrimSpace(catatan)
+	if utf8.RuneCountInString(catatan) > maxAlasan {
+		return fmt.Errorf("validation error: catatan maksimal %d karakter", maxAlasan)
+	}
+
+	existing, err := s.getIzin(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing.Version != version {
+		return helper.ErrVersionConflict
+	}
+	if existing.Status != izinguru.StatusMenunggu {
+		return fmt.Errorf("validation error: izin sudah %s", existing.Status)
+	}
+
+	keputusan := izinguru.IzinCore{
+		Status:            status,
+		Diputuskan_Oleh:   pengguna.ID,
+		Catatan_Keputusan: catatan,
+		Version:           version,
+	}
+	if err := s.izinData.Putuskan(ctx, &keputusan, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errIzinNotFound
+		}
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		return fmt.Errorf("izin guru service: gagal menyimpan keputusan: %w", err)
+	}
+	return nil
+}
+
+// DeleteById implements izinguru.ServiceIzinInterface.
+// Guru hanya boleh membatalkan izinnya sendiri yang masih menunggu. Catatan pengganti ikut terhapus.
+func (s *izinService) DeleteById(ctx context.Context, id string, version int, pengguna helper.MetaToken) error {
+	existing, err := s.izinPengguna(ctx, id, pengguna)
+	if err != nil {
+		return err
+	}
+	if existing.Version != version {
+		return helper.ErrVersionConflict
+	}
+	if pengguna.Role != "admin" && existing.Status != izinguru.StatusMenunggu {
+		return fmt.Errorf("validation error: izin yang sudah %s hanya bisa dibatalkan admin", existing.Status)
+	}
+
+	if err := s.izinData.DeleteById(ctx, id, version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errIzinNotFound
+		}
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		return fmt.Errorf("izin guru service: gagal menghapus data: %w", err)
+	}
+	return nil
+}
+
+// Sesi implements izinguru.ServiceIzinInterface.
+func (s *izinService) Sesi(ctx context.Context, id string) ([]izinguru.SesiCore, error) {
+	izin, err := s.getIzin(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.sesiIzin(ctx, izin)
+}
+
+// sesiIzin menyusun pertemuan guru selama izin. Setiap slot guru menjadi satu pertemuan pada tanggal yang
+// harinya sama dengan hari slot dan merupakan hari efektif kelasnya, sehingga libur kelas tidak ikut dihitung.
+func (s *izinService) sesiIzin(ctx context.Context, izin *izinguru.IzinCore) ([]izinguru.SesiCore, error) {
+	slots, err := s.izinData.SelectSlot(ctx, izin.Guru_ID)
+	if err != nil {
+		return nil, fmt.Errorf("izin guru service: gagal mengambil jadwal mengajar: %w", err)
+	}
+	pengganti, err := s.izinData.SelectPengganti(ctx, izinguru.FilterPengganti{Izin_ID: izin.ID})
+	if err != nil {
+		return nil, fmt.Errorf("izin guru service: gagal mengambil pengganti: %w", err)
+	}
+
+	efektif := map[string][]string{}
+	result := []izinguru.SesiCore{}
+	for _, slot := range slots {
+		if slot.Kelas_ID == "" {
+			continue
+		}
+		tanggal, ok := efektif[slot.Kelas_ID]
+		if !ok {
+			hari, err := s.kalender.HariEfektif(ctx, izin.Tanggal_Mulai, izin.Tanggal_Selesai, slot.Kelas_ID)
+			if err != nil {
+				return nil, fmt.Errorf("izin guru service: gagal menghitung hari efektif: %w", err)
+			}
+			tanggal = hari.Tanggal
+			efektif[slot.Kelas_ID] = tanggal
+		}
+
+		for _, tgl := range tanggal {
+			t, err := time.Parse(helper.DateLayout, tgl)
+			if err != nil || int(t.Weekday()) != slot.Hari {
+				continue
+			}
+			sesi := izinguru.SesiCore{
+				Tanggal:           tgl,
+				Jadwal_ID:         slot.Jadwal_ID,
+				Mata_Pelajaran_ID: slot.Mata_Pelajaran_ID,
+				Nama_Pelajaran:    slot.Nama_Pelajaran,
+				Kelas_ID:          slot.Kelas_ID,
+				Nama_Kelas:        slot.Nama_Kelas,
+				Jam_Mulai:         slot.Jam_Mulai,
+				Jam_Selesai:       slot.Jam_Selesai,
+			}
+			for _, p := range pengganti {
+				if p.Jadwal_ID == sesi.Jadwal_ID && p.Tanggal == sesi.Tanggal {
+					sesi.Pengganti_ID = p.Pengganti_ID
+					sesi.Nama_Pengganti = p.Nama_Pengganti
+				}
+			}
+			result = append(result, sesi)
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b izinguru.SesiCore) int {
+		return cmp.Or(
+			cmp.Compare(a.Tanggal, b.Tanggal),
+			cmp.Compare(a.Jam_Mulai, b.Jam_Mulai),
+			cmp.Compare(a.Nama_Kelas, b.Nama_Kelas),
+		)
+	})
+	return result, nil
+}
+
+// Kandidat implements izinguru.ServiceIzinInterface.
+// Kandidat diurutkan dari guru yang mengajar mapel yang sama, lalu yang mengajar di kelas yang sama,
+// lalu yang paling sedikit menggantikan pada minggu tersebut, lalu nama.
+func (s *izinService) Kandidat(ctx context.Context, id, jadwalID, tanggal string) ([]izinguru.KandidatCore, error) {
+	izin, err := s.getIzin(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sesi, err := s.cariSesi(ctx, izin, jadwalID, tanggal)
+	if err != nil {
+		return nil, err
+	}
+	hari, _ := time.Parse(helper.DateLayout, sesi.Tanggal)
+
+	guru, err := s.izinData.SelectGuru(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("izin guru service: gagal mengambil guru: %w", err)
+	}
+	slots, err := s.izinData.SelectSlot(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("izin guru service: gagal mengambil jadwal mengajar: %w", err)
+	}
+	izinHariIni, err := s.izinData.SelectAll(ctx, izinguru.FilterIzin{
+		Status: izinguru.StatusDisetujui, Dari: sesi.Tanggal, Sampai: sesi.Tanggal,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("izin guru service: gagal mengambil data: %w", err)
+	}
+	senin := hari.AddDate(0, 0, -((int(hari.Weekday()) + 6) % 7))
+	pengganti, err := s.izinData.SelectPengganti(ctx, izinguru.FilterPengganti{
+		Dari: senin.Format(helper.DateLayout), Sampai: senin.AddDate(0, 0, 6).Format(helper.DateLayout),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("izin guru service: gagal mengambil pengganti: %w", err)
+	}
+
+	// Guru yang sedang izin, mengajar, atau menggantikan pertemuan lain pada jam yang beririsan tidak tersedia
+	sibuk := map[string]bool{izin.Guru_ID: true}
+	for _, l := range izinHariIni {
+		sibuk[l.Guru_ID] = true
+	}
+	var mapelID string
+	for _, slot := range slots {
+		if slot.Jadwal_ID == sesi.Jadwal_ID {
+			mapelID = slot.Mapel_ID
+		}
+		if slot.Hari == int(hari.Weekday()) && beririsan(slot.Jam_Mulai, slot.Jam_Selesai, sesi.Jam_Mulai, sesi.Jam_Selesai) {
+			for _, g := range slot.Pengajar {
+				sibuk[g] = true
+			}
+		}
+	}
+	jumlah := map[string]int{}
+	for _, p := range pengganti {
+		if p.Jadwal_ID == sesi.Jadwal_ID && p.Tanggal == sesi.Tanggal {
+			continue
+		}
+		jumlah[p.Pengganti_ID]++
+		if p.Tanggal == sesi.Tanggal && beririsan(p.Jam_Mulai, p.Jam_Selesai, sesi.Jam_Mulai, sesi.Jam_Selesai) {
+			sibuk[p.Pengganti_ID] = true
+		}
+	}
+
+	result := []izinguru.KandidatCore{}
+	for _, g := range guru {
+		if sibuk[g.ID] {
+			continue
+		}
+		result = append(result, izinguru.KandidatCore{
+			Guru_ID:          g.ID,
+			Nama:             g.Nama,
+			Mapel_Sama:       mapelID != "" && slices.Contains(g.Mapel_ID, mapelID),
+			Kelas_Sama:       slices.Contains(g.Kelas_ID, sesi.Kelas_ID),
+			Jumlah_Pengganti: jumlah[g.ID],
+		})
+	}
+	slices.SortStableFunc(result, func(a, b izinguru.KandidatCore) int {
+		return cmp.Or(
+			compareTrue(a.Mapel_Sama, b.Mapel_Sama),
+			compareTrue(a.Kelas_Sama, b.Kelas_Sama),
+			cmp.Compare(a.Jumlah_Pengganti, b.Jumlah_Pengganti),
+			cmp.Compare(a.Nama, b.Nama),
+		)
+	})
+	return result, nil
+}
+
+// SetPengganti implements izinguru.ServiceIzinInterface.
+// Guru pengganti harus termasuk kandidat pertemuan tersebut.
+func (s *izinService) SetPengganti(ctx context.Context, id, jadwalID, tanggal, penggantiID string, pengguna helper.MetaToken) error {
+	izin, err := s.getIzin(ctx, id)
+	if err != nil {
+		return err
+	}
+	if izin.Status != izinguru.StatusDisetujui {
+		return errors.New("validation error: pengganti hanya bisa dicatat untuk izin yang sudah disetujui")
+	}
+	sesi, err := s.cariSesi(ctx, izin, jadwalID, tanggal)
+	if err != nil {
+		return err
+	}
+
+	penggantiID = strings.TrimSpace(penggantiID)
+	if penggantiID == "" {
+		if err := s.izinData.HapusPengganti(ctx, izin.ID, sesi.Jadwal_ID, sesi.Tanggal); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errPenggantiNotFound
+			}
+			return fmt.Errorf("izin guru service: gagal menghapus pengganti: %w", err)
+		}
+		return nil
+	}
+
+	kandidat, err := s.Kandidat(ctx, izin.ID, sesi.Jadwal_ID, sesi.Tanggal)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(kandidat, func(k izinguru.KandidatCore) bool { return k.Guru_ID == penggantiID }) {
+		return errors.New("validation error: guru tidak tersedia untuk menggantikan pertemuan ini")
+	}
+
+	err = s.izinData.SimpanPengganti(ctx, &izinguru.PenggantiCore{
+		Izin_ID:      izin.ID,
+		Jadwal_ID:    sesi.Jadwal_ID,
+		Tanggal:      sesi.Tanggal,
+		Pengganti_ID: penggantiID,
+		Dicatat_Oleh: pengguna.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("izin guru service: gagal menyimpan pengganti: %w", err)
+	}
+	return nil
+}
+
+// GetPengganti implements izinguru.ServiceIzinInterface.
+func (s *izinService) GetPengganti(ctx context.Context, filter izinguru.FilterPengganti) ([]izinguru.PenggantiCore, error) {
+	var err error
+	if filter.Dari, err = helper.NormalizeDate("dari", filter.Dari, false); err != nil {
+		return nil, err
+	}
+	if filter.Sampai, err = helper.NormalizeDate("sampai", filter.Sampai, false); err != nil {
+		return nil, err
+	}
+	result, err := s.izinData.SelectPengganti(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("izin guru service: gagal mengambil pengganti: %w", err)
+	}
+	return result, nil
+}
+
+// getIzin mengambil izin dan menerjemahkan pgx.ErrNoRows menjadi errIzinNotFound.
+func (s *izinService) getIzin(ctx context.Context, id string) (*izinguru.IzinCore, error) {
+	result, err := s.izinData.SelectById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errIzinNotFound
+		}
+		return nil, fmt.Errorf("izin guru service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// izinPengguna mengambil izin dan memastikan guru hanya mengakses izinnya sendiri.
+func (s *izinService) izinPengguna(ctx context.Context, id string, pengguna helper.MetaToken) (*izinguru.IzinCore, error) {
+	result, err := s.getIzin(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pengguna.Role == "admin" {
+		return result, nil
+	}
+	guruID, err := s.guruPengguna(ctx, pengguna)
+	if err != nil {
+		return nil, err
+	}
+	if result.Guru_ID != guruID {
+		return nil, errBukanMilik
+	}
+	return result, nil
+}
+
+// guruPengguna mengambil ID guru milik pengguna.
+func (s *izinService) guruPengguna(ctx context.Context, pengguna helper.MetaToken) (string, error) {
+	guruID, err := s.izinData.SelectGuruByUser(ctx, pengguna.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errBukanGuru
+		}
+		return "", fmt.Errorf("izin guru service: gagal mengambil guru: %w", err)
+	}
+	return guruID, nil
+}
+
+// cariSesi mencari pertemuan jadwalID pada tanggal di antara pertemuan yang ditinggalkan selama izin.
+func (s *izinService) cariSesi(ctx context.Context, izin *izinguru.IzinCore, jadwalID, tanggal string) (*izinguru.SesiCore, error) {
+	jadwalID = strings.TrimSpace(jadwalID)
+	if jadwalID == "" {
+		return nil, errors.New("validation error: jadwal_id harus diisi")
+	}
+	t, err := helper.ParseRequiredDate("tanggal", tanggal)
+	if err != nil {
+		return nil, err
+	}
+	tanggal = t.Format(helper.DateLayout)
+	if tanggal < izin.Tanggal_Mulai || tanggal > izin.Tanggal_Selesai {
+		return nil, fmt.Errorf("validation error: tanggal harus di antara %s dan %s", izin.Tanggal_Mulai, izin.Tanggal_Selesai)
+	}
+
+	sesi, err := s.sesiIzin(ctx, izin)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sesi {
+		if sesi[i].Jadwal_ID == jadwalID && sesi[i].Tanggal == tanggal {
+			return &sesi[i], nil
+		}
+	}
+	return nil, errSesiNotFound
+}
+
+// beririsan memeriksa apakah dua rentang jam berformat HH:MM saling beririsan.
+func beririsan(mulaiA, selesaiA, mulaiB, selesaiB string) bool {
+	return mulaiA < selesaiB && mulaiB < selesaiA
+}
+
+// compareTrue mengurutkan nilai true sebelum false.
+func compareTrue(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return -1
+	default:
+		return 1
+	}
+}
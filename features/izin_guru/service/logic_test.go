@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	izinguru "go_rest_native_sekolah/features/izin_guru"
+	"go_rest_native_sekolah/features/kalender"
+	"go_rest_native_sekolah/helper"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock untuk DataIzinInterface
+type mockDataIzin struct {
+	mock.Mock
+}
+
+func (m *mockDataIzin) SelectAll(ctx context.Context, filter izinguru.FilterIzin) ([]izinguru.IzinCore, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]izinguru.IzinCore), args.Error(1)
+}
+
+func (m *mockDataIzin) SelectById(ctx context.Context, id string) (*izinguru.IzinCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*izinguru.IzinCore), args.Error(1)
+}
+
+func (m *mockDataIzin) Insert(ctx context.Context, insert *izinguru.IzinCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataIzin) Putuskan(ctx context.Context, keputusan *izinguru.IzinCore, id string) error {
+	args := m.Called(keputusan, id)
+	return args.Error(0)
+}
+
+func (m *mockDataIzin) DeleteById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataIzin) SelectGuruByUser(ctx context.Context, userID string) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockDataIzin) GuruAda(ctx context.Context, guruID string) (bool, error) {
+	args := m.Called(guruID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockDataIzin) SelectGuru(ctx context.Context) ([]izinguru.GuruCore, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]izinguru.GuruCore), args.Error(1)
+}
+
+func (m *mockDataIzin) SelectSlot(ctx context.Context, guruID string) ([]izinguru.SlotCore, error) {
+	args := m.Called(guruID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]izinguru.SlotCore), args.Error(1)
+}
+
+func (m *mockDataIzin) SelectPengganti(ctx context.Context, filter izinguru.FilterPengganti) ([]izinguru.PenggantiCore, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]izinguru.PenggantiCore), args.Error(1)
+}
+
+func (m *mockDataIzin) SimpanPengganti(ctx context.Context, p *izinguru.PenggantiCore) error {
+	args := m.Called(p)
+	return args.Error(0)
+}
+
+func (m *mockDataIzin) HapusPengganti(ctx context.Context, izinID, jadwalID, tanggal string) error {
+	args := m.Called(izinID, jadwalID, tanggal)
+	return args.Error(0)
+}
+
+// Mock untuk HariEfektifInterface
+type mockKalender struct {
+	mock.Mock
+}
+
+func (m *mockKalender) HariEfektif(ctx context.Context, dari, sampai, kelasID string) (*kalender.HariEfektifCore, error) {
+	args := m.Called(dari, sampai, kelasID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*kalender.HariEfektifCore), args.Error(1)
+}
+
+var (
+	admin    = helper.MetaToken{ID: "u-admin", Role: "admin"}
+	guruBudi = helper.MetaToken{ID: "u-budi", Role: "guru"}
+)
+
+// izinUji adalah izin guru g1 (Budi) yang sudah disetujui untuk Senin 19 sampai Rabu 21 Oktober 2026.
+func izinUji() *izinguru.IzinCore {
+	return &izinguru.IzinCore{
+		ID: "i1", Guru_ID: "g1", Nama_Guru: "Budi", Jenis: izinguru.JenisSakit,
+		Tanggal_Mulai: "2026-10-19", Tanggal_Selesai: "2026-10-21", Status: izinguru.StatusDisetujui, Version: 2,
+	}
+}
+
+// slotBudi adalah jadwal g1: matematika 7A Senin 07:00-08:30 dan Rabu 09:00-10:30.
+var slotBudi = []izinguru.SlotCore{
+	{Jadwal_ID: "j1", Mata_Pelajaran_ID: "mp1", Mapel_ID: "mtk", Nama_Pelajaran: "Matematika", Kelas_ID: "k1", Nama_Kelas: "7A",
+		Hari: 1, Jam_Mulai: "07:00", Jam_Selesai: "08:30", Pengajar: []string{"g1"}},
+	{Jadwal_ID: "j2", Mata_Pelajaran_ID: "mp1", Mapel_ID: "mtk", Nama_Pelajaran: "Matematika", Kelas_ID: "k1", Nama_Kelas: "7A",
+		Hari: 3, Jam_Mulai: "09:00", Jam_Selesai: "10:30", Pengajar: []string{"g1"}},
+}
+
+// efektif7A adalah hari efektif 7A selama izin; Selasa 20 Oktober libur.
+var efektif7A = &kalender.HariEfektifCore{Tanggal: []string{"2026-10-19", "2026-10-21"}}
+
+func TestInsertIzin(t *testing.T) {
+	t.Run("success insert - guru mengajukan untuk dirinya sendiri", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectGuruByUser", "u-budi").Return("g1", nil).Once()
+		mockRepo.On("SelectAll", izinguru.FilterIzin{Guru_ID: "g1", Dari: "2026-10-19", Sampai: "2026-10-19"}).
+			Return([]izinguru.IzinCore{{Status: izinguru.StatusDitolak}}, nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*izinguru.IzinCore")).Return(nil).Once()
+
+		data := &izinguru.IzinCore{Jenis: " Sakit ", Tanggal_Mulai: "2026-10-19", Status: izinguru.StatusDisetujui}
+		err := NewServiceIzin(mockRepo, new(mockKalender)).Insert(context.Background(), data, guruBudi)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "g1", data.Guru_ID)
+		assert.Equal(t, izinguru.JenisSakit, data.Jenis)
+		assert.Equal(t, "2026-10-19", data.Tanggal_Selesai)
+		assert.Equal(t, izinguru.StatusMenunggu, data.Status)
+		assert.Equal(t, "u-budi", data.Diajukan_Oleh)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - guru mengajukan untuk guru lain", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectGuruByUser", "u-budi").Return("g1", nil).Once()
+
+		data := &izinguru.IzinCore{Guru_ID: "g2", Jenis: "izin", Tanggal_Mulai: "2026-10-19"}
+		err := NewServiceIzin(mockRepo, new(mockKalender)).Insert(context.Background(), data, guruBudi)
+
+		assert.ErrorIs(t, err, errBukanMilik)
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("error - beririsan dengan izin lain", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("GuruAda", "g1").Return(true, nil).Once()
+		mockRepo.On("SelectAll", izinguru.FilterIzin{Guru_ID: "g1", Dari: "2026-10-19", Sampai: "2026-10-23"}).
+			Return([]izinguru.IzinCore{{Jenis: "cuti", Status: izinguru.StatusMenunggu, Tanggal_Mulai: "2026-10-22", Tanggal_Selesai: "2026-10-30"}}, nil).Once()
+
+		data := &izinguru.IzinCore{Guru_ID: "g1", Jenis: "dinas", Tanggal_Mulai: "2026-10-19", Tanggal_Selesai: "2026-10-23"}
+		err := NewServiceIzin(mockRepo, new(mockKalender)).Insert(context.Background(), data, admin)
+
+		assert.ErrorContains(t, err, "validation error: guru sudah punya izin cuti (menunggu)")
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("error - tanggal selesai sebelum tanggal mulai", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("GuruAda", "g1").Return(true, nil).Once()
+
+		data := &izinguru.IzinCore{Guru_ID: "g1", Jenis: "izin", Tanggal_Mulai: "2026-10-19", Tanggal_Selesai: "2026-10-18"}
+		err := NewServiceIzin(mockRepo, new(mockKalender)).Insert(context.Background(), data, admin)
+
+		assert.ErrorContains(t, err, "validation error: tanggal_selesai")
+	})
+
+	t.Run("error - guru tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("GuruAda", "g9").Return(false, nil).Once()
+
+		data := &izinguru.IzinCore{Guru_ID: "g9", Jenis: "izin", Tanggal_Mulai: "2026-10-19"}
+		err := NewServiceIzin(mockRepo, new(mockKalender)).Insert(context.Background(), data, admin)
+
+		assert.ErrorIs(t, err, errGuruNotFound)
+	})
+}
+
+func TestPutuskanIzin(t *testing.T) {
+	t.Run("success setujui", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		menunggu := izinUji()
+		menunggu.Status = izinguru.StatusMenunggu
+		mockRepo.On("SelectById", "i1").Return(menunggu, nil).Once()
+		mockRepo.On("Putuskan", &izinguru.IzinCore{Status: izinguru.StatusDisetujui, Diputuskan_Oleh: "u-admin", Catatan_Keputusan: "Cepat sembuh", Version: 2}, "i1").
+			Return(nil).Once()
+
+		err := NewServiceIzin(mockRepo, new(mockKalender)).Putuskan(context.Background(), "i1", "Disetujui", " Cepat sembuh ", 2, admin)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - sudah diputuskan", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectById", "i1").Return(izinUji(), nil).Once()
+
+		err := NewServiceIzin(mockRepo, new(mockKalender)).Putuskan(context.Background(), "i1", "ditolak", "", 2, admin)
+
+		assert.ErrorContains(t, err, "validation error: izin sudah disetujui")
+		mockRepo.AssertNotCalled(t, "Putuskan", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - versi berbeda", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectById", "i1").Return(izinUji(), nil).Once()
+
+		err := NewServiceIzin(mockRepo, new(mockKalender)).Putuskan(context.Background(), "i1", "ditolak", "", 1, admin)
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+	})
+}
+
+func TestDeleteIzin(t *testing.T) {
+	t.Run("error - guru membatalkan izin yang sudah disetujui", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectById", "i1").Return(izinUji(), nil).Once()
+		mockRepo.On("SelectGuruByUser", "u-budi").Return("g1", nil).Once()
+
+		err := NewServiceIzin(mockRepo, new(mockKalender)).DeleteById(context.Background(), "i1", 2, guruBudi)
+
+		assert.ErrorContains(t, err, "validation error: izin yang sudah disetujui hanya bisa dibatalkan admin")
+		mockRepo.AssertNotCalled(t, "DeleteById", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - guru membatalkan izin guru lain", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectById", "i1").Return(izinUji(), nil).Once()
+		mockRepo.On("SelectGuruByUser", "u-budi").Return("g2", nil).Once()
+
+		err := NewServiceIzin(mockRepo, new(mockKalender)).DeleteById(context.Background(), "i1", 2, guruBudi)
+
+		assert.ErrorIs(t, err, errBukanMilik)
+	})
+
+	t.Run("success - admin menghapus izin yang sudah disetujui", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectById", "i1").Return(izinUji(), nil).Once()
+		mockRepo.On("DeleteById", "i1", 2).Return(nil).Once()
+
+		err := NewServiceIzin(mockRepo, new(mockKalender)).DeleteById(context.Background(), "i1", 2, admin)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestSesiIzin(t *testing.T) {
+	t.Run("success - hanya hari efektif yang sesuai hari slot", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockKal := new(mockKalender)
+		mockRepo.On("SelectById", "i1").Return(izinUji(), nil).Once()
+		mockRepo.On("SelectSlot", "g1").Return(slotBudi, nil).Once()
+		mockRepo.On("SelectPengganti", izinguru.FilterPengganti{Izin_ID: "i1"}).
+			Return([]izinguru.PenggantiCore{{Jadwal_ID: "j2", Tanggal: "2026-10-21", Pengganti_ID: "g3", Nama_Pengganti: "Citra"}}, nil).Once()
+		mockKal.On("HariEfektif", "2026-10-19", "2026-10-21", "k1").Return(efektif7A, nil).Once()
+
+		result, err := NewServiceIzin(mockRepo, mockKal).Sesi(context.Background(), "i1")
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, "2026-10-19", result[0].Tanggal)
+		assert.Equal(t, "j1", result[0].Jadwal_ID)
+		assert.Empty(t, result[0].Pengganti_ID)
+		assert.Equal(t, "2026-10-21", result[1].Tanggal)
+		assert.Equal(t, "g3", result[1].Pengganti_ID)
+		mockKal.AssertExpectations(t)
+	})
+}
+
+// siapkanKandidat menyiapkan mock untuk mencari pengganti pertemuan j1 pada Senin 19 Oktober 2026.
+// g2 mengajar kelas lain pada jam yang sama, g3 dan g4 kosong, g5 sedang izin, dan g6 sudah menggantikan
+// pertemuan lain pada jam yang sama.
+func siapkanKandidat(mockRepo *mockDataIzin, mockKal *mockKalender) {
+	mockRepo.On("SelectById", "i1").Return(izinUji(), nil)
+	mockRepo.On("SelectSlot", "g1").Return(slotBudi, nil)
+	mockRepo.On("SelectPengganti", izinguru.FilterPengganti{Izin_ID: "i1"}).Return([]izinguru.PenggantiCore{}, nil)
+	mockKal.On("HariEfektif", "2026-10-19", "2026-10-21", "k1").Return(efektif7A, nil)
+
+	mockRepo.On("SelectGuru").Return([]izinguru.GuruCore{
+		{ID: "g1", Nama: "Budi", Mapel_ID: []string{"mtk"}, Kelas_ID: []string{"k1"}},
+		{ID: "g2", Nama: "Andi", Mapel_ID: []string{"ipa"}, Kelas_ID: []string{"k2"}},
+		{ID: "g3", Nama: "Citra", Mapel_ID: []string{"ipa"}, Kelas_ID: []string{"k1"}},
+		{ID: "g4", Nama: "Dewi", Mapel_ID: []string{"mtk"}, Kelas_ID: []string{"k2"}},
+		{ID: "g5", Nama: "Eko", Mapel_ID: []string{"mtk"}, Kelas_ID: []string{"k1"}},
+		{ID: "g6", Nama: "Fajar", Mapel_ID: []string{"mtk"}, Kelas_ID: []string{"k1"}},
+		{ID: "g7", Nama: "Gita"},
+	}, nil)
+	mockRepo.On("SelectSlot", "").Return(append([]izinguru.SlotCore{
+		{Jadwal_ID: "j3", Mapel_ID: "ipa", Kelas_ID: "k2", Hari: 1, Jam_Mulai: "08:00", Jam_Selesai: "09:00", Pengajar: []string{"g2"}},
+		{Jadwal_ID: "j4", Mapel_ID: "mtk", Kelas_ID: "k2", Hari: 2, Jam_Mulai: "07:00", Jam_Selesai: "08:30", Pengajar: []string{"g4"}},
+	}, slotBudi...), nil)
+	mockRepo.On("SelectAll", izinguru.FilterIzin{Status: izinguru.StatusDisetujui, Dari: "2026-10-19", Sampai: "2026-10-19"}).
+		Return([]izinguru.IzinCore{{Guru_ID: "g1"}, {Guru_ID: "g5"}}, nil)
+	mockRepo.On("SelectPengganti", izinguru.FilterPengganti{Dari: "2026-10-19", Sampai: "2026-10-25"}).Return([]izinguru.PenggantiCore{
+		{Jadwal_ID: "j8", Tanggal: "2026-10-19", Pengganti_ID: "g6", Jam_Mulai: "08:00", Jam_Selesai: "09:00"},
+		{Jadwal_ID: "j9", Tanggal: "2026-10-20", Pengganti_ID: "g4", Jam_Mulai: "10:00", Jam_Selesai: "11:00"},
+	}, nil)
+}
+
+func TestKandidat(t *testing.T) {
+	t.Run("success - guru sibuk dikecualikan dan mapel sama lebih dulu", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockKal := new(mockKalender)
+		siapkanKandidat(mockRepo, mockKal)
+
+		result, err := NewServiceIzin(mockRepo, mockKal).Kandidat(context.Background(), "i1", "j1", "2026-10-19")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []izinguru.KandidatCore{
+			{Guru_ID: "g4", Nama: "Dewi", Mapel_Sama: true, Jumlah_Pengganti: 1},
+			{Guru_ID: "g3", Nama: "Citra", Kelas_Sama: true},
+			{Guru_ID: "g7", Nama: "Gita"},
+		}, result)
+	})
+
+	t.Run("error - tanggal bukan pertemuan slot", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockKal := new(mockKalender)
+		siapkanKandidat(mockRepo, mockKal)
+
+		_, err := NewServiceIzin(mockRepo, mockKal).Kandidat(context.Background(), "i1", "j1", "2026-10-20")
+
+		assert.ErrorIs(t, err, errSesiNotFound)
+	})
+
+	t.Run("error - tanggal di luar izin", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectById", "i1").Return(izinUji(), nil).Once()
+
+		_, err := NewServiceIzin(mockRepo, new(mockKalender)).Kandidat(context.Background(), "i1", "j1", "2026-10-26")
+
+		assert.ErrorContains(t, err, "validation error: tanggal harus di antara 2026-10-19 dan 2026-10-21")
+	})
+}
+
+func TestSetPengganti(t *testing.T) {
+	t.Run("success simpan", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockKal := new(mockKalender)
+		siapkanKandidat(mockRepo, mockKal)
+		mockRepo.On("SimpanPengganti", &izinguru.PenggantiCore{
+			Izin_ID: "i1", Jadwal_ID: "j1", Tanggal: "2026-10-19", Pengganti_ID: "g4", Dicatat_Oleh: "u-admin",
+		}).Return(nil).Once()
+
+		err := NewServiceIzin(mockRepo, mockKal).SetPengganti(context.Background(), "i1", "j1", "2026-10-19", "g4", admin)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - guru sedang mengajar", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockKal := new(mockKalender)
+		siapkanKandidat(mockRepo, mockKal)
+
+		err := NewServiceIzin(mockRepo, mockKal).SetPengganti(context.Background(), "i1", "j1", "2026-10-19", "g2", admin)
+
+		assert.ErrorContains(t, err, "validation error: guru tidak tersedia")
+		mockRepo.AssertNotCalled(t, "SimpanPengganti", mock.Anything)
+	})
+
+	t.Run("success hapus pengganti", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockKal := new(mockKalender)
+		siapkanKandidat(mockRepo, mockKal)
+		mockRepo.On("HapusPengganti", "i1", "j1", "2026-10-19").Return(nil).Once()
+
+		err := NewServiceIzin(mockRepo, mockKal).SetPengganti(context.Background(), "i1", "j1", "2026-10-19", "", admin)
+
+		assert.NoError(t, err)
+		mockRepo.AssertCalled(t, "HapusPengganti", "i1", "j1", "2026-10-19")
+		mockRepo.AssertNotCalled(t, "SimpanPengganti", mock.Anything)
+	})
+
+	t.Run("error - izin belum disetujui", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		menunggu := izinUji()
+		menunggu.Status = izinguru.StatusMenunggu
+		mockRepo.On("SelectById", "i1").Return(menunggu, nil).Once()
+
+		err := NewServiceIzin(mockRepo, new(mockKalender)).SetPengganti(context.Background(), "i1", "j1", "2026-10-19", "g4", admin)
+
+		assert.ErrorContains(t, err, "validation error: pengganti hanya bisa dicatat untuk izin yang sudah disetujui")
+	})
+
+	t.Run("error - izin tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataIzin)
+		mockRepo.On("SelectById", "i9").Return(nil, pgx.ErrNoRows).Once()
+
+		err := NewServiceIzin(mockRepo, new(mockKalender)).SetPengganti(context.Background(), "i9", "j1", "2026-10-19", "g4", admin)
+
+		assert.ErrorIs(t, err, errIzinNotFound)
+	})
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	jadwalmengajar "go_rest_native_sekolah/features/jadwal_mengajar"
+	"go_rest_native_sekolah/helper"
+	"net/http"
+	"strings"
+)
+
+// JadwalController menghandle HTTP request jadwal mengajar mingguan.
+type JadwalController struct {
+	jadwalService jadwalmengajar.ServiceJadwalInterface
+}
+
+// NewJadwalController membuat JadwalController dengan service jadwal mengajar.
+func NewJadwalController(service jadwalmengajar.ServiceJadwalInterface) *JadwalController {
+	return &JadwalController{jadwalService: service}
+}
+
+// writeJadwalError menulis response untuk error dari service jadwal mengajar.
+// Mengembalikan false jika error tidak dikenali sehingga pemanggil perlu meneruskannya.
+func writeJadwalError(w http.ResponseWriter, err error) bool {
+	switch {
+	case strings.Contains(err.Error(), "validation"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+// Jadwal menghandle GET /jadwal-mengajar?guru_id=&kelas_id=&hari= untuk daftar slot. Semua filter opsional.
+func (jc *JadwalController) Jadwal(w http.ResponseWriter, r *http.Request) error {
+	if jc == nil || jc.jadwalService == nil {
+		return errors.New("jadwal mengajar controller: service is nil")
+	}
+
+	q := r.URL.Query()
+	filter := jadwalmengajar.FilterJadwal{
+		Guru_ID:  strings.TrimSpace(q.Get("guru_id")),
+		Kelas_ID: strings.TrimSpace(q.Get("kelas_id")),
+		Hari:     q.Get("hari"),
+	}
+	result, err := jc.jadwalService.GetAll(r.Context(), filter)
+	if err != nil {
+		if writeJadwalError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data jadwal mengajar", FormatJadwalList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// InsertJadwal menghandle POST /jadwal-mengajar/tambah. Body JSON berisi mata_pelajaran_id, hari,
+// jam_mulai, dan jam_selesai.
+func (jc *JadwalController) InsertJadwal(w http.ResponseWriter, r *http.Request) error {
+	if jc == nil || jc.jadwalService == nil {
+		return errors.New("jadwal mengajar controller: service is nil")
+	}
+
+	var req JadwalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	data := JadwalRequestToCore(req)
+	if err := jc.jadwalService.Insert(r.Context(), &data); err != nil {
+		if writeJadwalError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil menambah jadwal mengajar", FormatJadwalList([]jadwalmengajar.JadwalCore{data}))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// GetJadwalById menghandle GET /jadwal-mengajar/{id}.
+func (jc *JadwalController) GetJadwalById(w http.ResponseWriter, r *http.Request) error {
+	if jc == nil || jc.jadwalService == nil {
+		return errors.New("jadwal mengajar controller: service is nil")
+	}
+
+	data, err := jc.jadwalService.GetById(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeJadwalError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data jadwal mengajar", FormatJadwalList([]jadwalmengajar.JadwalCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// DeleteJadwal menghandle DELETE /jadwal-mengajar/{id}. Catatan guru pengganti untuk slot ini ikut terhapus.
+func (jc *JadwalController) DeleteJadwal(w http.ResponseWriter, r *http.Request) error {
+	if jc == nil || jc.jadwalService == nil {
+		return errors.New("jadwal mengajar controller: service is nil")
+	}
+
+	if err := jc.jadwalService.DeleteById(r.Context(), r.PathValue("id")); err != nil {
+		if writeJadwalError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil menghapus jadwal mengajar", nil))
+	return nil
+}
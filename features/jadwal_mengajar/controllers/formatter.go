@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	jadwalmengajar "go_rest_native_sekolah/features/jadwal_mengajar"
+	"time"
+)
+
+// JadwalFormatter digunakan untuk memformat slot jadwal mengajar pada response API.
+type JadwalFormatter struct {
+	ID                string              `json:"id"`                // ID adalah ID unik slot
+	Mata_Pelajaran_ID string              `json:"mata_pelajaran_id"` // Mata_Pelajaran_ID adalah penugasan mapel ke kelas
+	Nama_Pelajaran    string              `json:"nama_pelajaran"`    // Nama_Pelajaran adalah nama mapel
+	Kelas_ID          string              `json:"kelas_id"`          // Kelas_ID adalah kelas yang diajar
+	Nama_Kelas        string              `json:"nama_kelas"`        // Nama_Kelas adalah nama kelas
+	Hari              string              `json:"hari"`              // Hari adalah nama hari, misalnya senin
+	Jam_Mulai         string              `json:"jam_mulai"`         // Jam_Mulai adalah jam mulai (HH:MM)
+	Jam_Selesai       string              `json:"jam_selesai"`       // Jam_Selesai adalah jam selesai (HH:MM)
+	Pengajar          []PengajarFormatter `json:"pengajar"`          // Pengajar adalah guru pengajar slot
+	Update_At         time.Time           `json:"update_at"`         // Update_At adalah waktu slot dibuat
+}
+
+// PengajarFormatter digunakan untuk memformat guru pengajar slot.
+type PengajarFormatter struct {
+	ID   string `json:"id"`   // ID adalah ID unik guru
+	Nama string `json:"nama"` // Nama adalah nama guru
+}
+
+// JadwalRequest digunakan untuk membaca body JSON tambah slot.
+type JadwalRequest struct {
+	Mata_Pelajaran_ID string `json:"mata_pelajaran_id"`
+	Hari              string `json:"hari"`
+	Jam_Mulai         string `json:"jam_mulai"`
+	Jam_Selesai       string `json:"jam_selesai"`
+}
+
+// FormatJadwalList mengubah slice JadwalCore menjadi slice JadwalFormatter.
+func FormatJadwalList(cores []jadwalmengajar.JadwalCore) []JadwalFormatter {
+	formatted := make([]JadwalFormatter, 0, len(cores))
+	for _, core := range cores {
+		pengajar := make([]PengajarFormatter, 0, len(core.Pengajar))
+		for _, p := range core.Pengajar {
+			pengajar = append(pengajar, PengajarFormatter{ID: p.ID, Nama: p.Nama})
+		}
+		formatted = append(formatted, JadwalFormatter{
+			ID:                core.ID,
+			Mata_Pelajaran_ID: core.Mata_Pelajaran_ID,
+			Nama_Pelajaran:    core.Nama_Pelajaran,
+			Kelas_ID:          core.Kelas_ID,
+			Nama_Kelas:        core.Nama_Kelas,
+			Hari:              core.Hari,
+			Jam_Mulai:         core.Jam_Mulai,
+			Jam_Selesai:       core.Jam_Selesai,
+			Pengajar:          pengajar,
+			Update_At:         core.Update_At,
+		})
+	}
+	return formatted
+}
+
+// JadwalRequestToCore mengubah JadwalRequest menjadi JadwalCore.
+func JadwalRequestToCore(req JadwalRequest) jadwalmengajar.JadwalCore {
+	return jadwalmengajar.JadwalCore{
+		Mata_Pelajaran_ID: req.Mata_Pelajaran_ID,
+		Hari:              req.Hari,
+		Jam_Mulai:         req.Jam_Mulai,
+		Jam_Selesai:       req.Jam_Selesai,
+	}
+}
@@ -0,0 +1,63 @@
+package jadwalmengajar
+
+import (
+	"context"
+	"time"
+)
+
+// NamaHari berisi nama hari dalam bahasa Indonesia dengan indeks time.Weekday (Minggu = 0).
+var NamaHari = []string{"minggu", "senin", "selasa", "rabu", "kamis", "jumat", "sabtu"}
+
+// PengelolaRoles adalah role yang boleh menambah dan menghapus jadwal mengajar.
+var PengelolaRoles = []string{"admin"}
+
+type (
+	// JadwalCore merepresentasikan satu slot jadwal mengajar mingguan: satu penugasan mata pelajaran
+	// (mapel pada satu kelas) pada satu hari dan rentang jam. Pengajarnya mengikuti mata_pelajaran_guru.
+	JadwalCore struct {
+		ID                string         `json:"id"`                // ID adalah identifikasi unik slot.
+		Mata_Pelajaran_ID string         `json:"mata_pelajaran_id"` // Mata_Pelajaran_ID adalah penugasan mapel ke kelas yang diajarkan.
+		Nama_Pelajaran    string         `json:"nama_pelajaran"`    // Nama_Pelajaran diambil dari katalog mapel.
+		Kelas_ID          string         `json:"kelas_id"`          // Kelas_ID adalah kelas penugasan.
+		Nama_Kelas        string         `json:"nama_kelas"`        // Nama_Kelas adalah nama kelas.
+		Hari              string         `json:"hari"`              // Hari adalah salah satu NamaHari, misalnya senin.
+		Jam_Mulai         string         `json:"jam_mulai"`         // Jam_Mulai berformat HH:MM.
+		Jam_Selesai       string         `json:"jam_selesai"`       // Jam_Selesai berformat HH:MM dan harus setelah Jam_Mulai.
+		Pengajar          []PengajarCore `json:"pengajar"`          // Pengajar adalah guru pengajar penugasan.
+		Update_At         time.Time      `json:"update_at"`         // Update_At adalah waktu slot dibuat.
+	}
+
+	// PengajarCore adalah guru pengajar sebuah slot jadwal.
+	PengajarCore struct {
+		ID   string `json:"id"`   // ID adalah identifikasi unik guru.
+		Nama string `json:"nama"` // Nama adalah nama guru.
+	}
+
+	// FilterJadwal berisi filter opsional daftar jadwal. Field kosong berarti tidak difilter.
+	FilterJadwal struct {
+		Guru_ID  string // Guru_ID mengambil slot yang diajar guru ini.
+		Kelas_ID string // Kelas_ID mengambil slot kelas ini.
+		Hari     string // Hari mengambil slot pada hari ini.
+	}
+
+	// DataJadwalInterface mendefinisikan operasi tabel jadwal_mengajar.
+	DataJadwalInterface interface {
+		SelectAll(ctx context.Context, filter FilterJadwal) ([]JadwalCore, error) // Mengambil slot sesuai filter, urut hari, jam, dan kelas.
+		SelectById(ctx context.Context, id string) (*JadwalCore, error)           // Mengambil satu slot, pgx.ErrNoRows jika tidak ada.
+		Insert(ctx context.Context, insert *JadwalCore) error                     // Menyimpan slot baru.
+		DeleteById(ctx context.Context, id string) error                          // Menghapus (soft delete) slot, pgx.ErrNoRows jika tidak ada.
+		// SelectMataPelajaran mengambil penugasan mata pelajaran aktif beserta kelas dan pengajarnya
+		// (Hari dan jam kosong), pgx.ErrNoRows jika tidak ada.
+		SelectMataPelajaran(ctx context.Context, id string) (*JadwalCore, error)
+	}
+
+	// ServiceJadwalInterface mendefinisikan logika bisnis jadwal mengajar mingguan.
+	ServiceJadwalInterface interface {
+		GetAll(ctx context.Context, filter FilterJadwal) ([]JadwalCore, error) // Mengambil daftar slot.
+		GetById(ctx context.Context, id string) (*JadwalCore, error)           // Mengambil satu slot.
+		// Insert memvalidasi dan menyimpan slot baru. Slot ditolak jika kelas atau salah satu pengajarnya
+		// sudah punya slot lain yang jamnya beririsan pada hari yang sama.
+		Insert(ctx context.Context, insert *JadwalCore) error
+		DeleteById(ctx context.Context, id string) error // Menghapus slot.
+	}
+)
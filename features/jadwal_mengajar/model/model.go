@@ -0,0 +1,56 @@
+package model
+
+import (
+	jadwalmengajar "go_rest_native_sekolah/features/jadwal_mengajar"
+	"slices"
+	"time"
+)
+
+// Jadwal merepresentasikan satu baris tabel jadwal_mengajar beserta nama pelajaran dan kelasnya.
+type Jadwal struct {
+	ID                string    `json:"id"`                // ID adalah identifikasi unik slot.
+	Mata_Pelajaran_ID string    `json:"mata_pelajaran_id"` // Mata_Pelajaran_ID adalah penugasan mapel ke kelas.
+	Nama_Pelajaran    string    `json:"nama_pelajaran"`    // Nama_Pelajaran diambil dari tabel mapel.
+	Kelas_ID          string    `json:"kelas_id"`          // Kelas_ID diambil dari tabel mata_pelajaran.
+	Nama_Kelas        string    `json:"nama_kelas"`        // Nama_Kelas diambil dari tabel kelas.
+	Hari              int       `json:"hari"`              // Hari disimpan sebagai time.Weekday (Minggu = 0).
+	Jam_Mulai         string    `json:"jam_mulai"`         // Jam_Mulai disimpan sebagai TIME.
+	Jam_Selesai       string    `json:"jam_selesai"`       // Jam_Selesai disimpan sebagai TIME.
+	Update_At         time.Time `json:"update_at"`         // Update_At adalah waktu slot dibuat.
+}
+
+// TableName mengembalikan nama tabel jadwal mengajar di database.
+func (j *Jadwal) TableName() string {
+	return "jadwal_mengajar"
+}
+
+// FormatterRequest mengubah JadwalCore menjadi Jadwal untuk disimpan ke database.
+// Hari yang tidak dikenal menjadi -1 dan akan ditolak constraint database.
+func FormatterRequest(req jadwalmengajar.JadwalCore) Jadwal {
+	return Jadwal{
+		ID:                req.ID,
+		Mata_Pelajaran_ID: req.Mata_Pelajaran_ID,
+		Hari:              slices.Index(jadwalmengajar.NamaHari, req.Hari),
+		Jam_Mulai:         req.Jam_Mulai,
+		Jam_Selesai:       req.Jam_Selesai,
+	}
+}
+
+// FormatterResponse mengubah Jadwal dari database menjadi JadwalCore tanpa pengajar.
+func FormatterResponse(res Jadwal) jadwalmengajar.JadwalCore {
+	core := jadwalmengajar.JadwalCore{
+		ID:                res.ID,
+		Mata_Pelajaran_ID: res.Mata_Pelajaran_ID,
+		Nama_Pelajaran:    res.Nama_Pelajaran,
+		Kelas_ID:          res.Kelas_ID,
+		Nama_Kelas:        res.Nama_Kelas,
+		Jam_Mulai:         res.Jam_Mulai,
+		Jam_Selesai:       res.Jam_Selesai,
+		Update_At:         res.Update_At,
+		Pengajar:          []jadwalmengajar.PengajarCore{},
+	}
+	if res.Hari >= 0 && res.Hari < len(jadwalmengajar.NamaHari) {
+		core.Hari = jadwalmengajar.NamaHari[res.Hari]
+	}
+	return core
+}
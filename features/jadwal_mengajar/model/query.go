@@ -0,0 +1,181 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	jadwalmengajar "go_rest_native_sekolah/features/jadwal_mengajar"
+	"go_rest_native_sekolah/helper"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// jadwalQuery menghandle query ke tabel jadwal_mengajar.
+type jadwalQuery struct {
+	db helper.DBTX
+}
+
+// NewDataJadwal membuat objek jadwalQuery dengan parameter db.
+// Jika parameter db nil maka akan terjadi panic.
+func NewDataJadwal(db helper.DBTX) jadwalmengajar.DataJadwalInterface {
+	if db == nil {
+		panic("jadwal mengajar model: Nil database")
+	}
+	return &jadwalQuery{db: db}
+}
+
+// kolomPengajar mengambil ID dan nama pengajar penugasan mp sebagai dua array dengan urutan yang sama,
+// guru utama lebih dulu.
+const kolomPengajar = `COALESCE((SELECT array_agg(g.id ORDER BY mpg.utama DESC, g.nama, g.id)
+		FROM mata_pelajaran_guru mpg JOIN guru g ON g.id = mpg.id_guru
+		WHERE mpg.mata_pelajaran_id = mp.id AND g.delete_at IS NULL), '{}'),
+	COALESCE((SELECT array_agg(g.nama ORDER BY mpg.utama DESC, g.nama, g.id)
+		FROM mata_pelajaran_guru mpg JOIN guru g ON g.id = mpg.id_guru
+		WHERE mpg.mata_pelajaran_id = mp.id AND g.delete_at IS NULL), '{}')`
+
+// kolomJadwal adalah daftar kolom yang diambil untuk setiap slot, sesuai urutan scan di selectJadwal.
+const kolomJadwal = `SELECT j.id, j.mata_pelajaran_id, COALESCE(m.nama, ''), COALESCE(mp.kelas_id, ''), COALESCE(k.kelas, ''),
+	j.hari, TO_CHAR(j.jam_mulai, 'HH24:MI'), TO_CHAR(j.jam_selesai, 'HH24:MI'), j.update_at, ` + kolomPengajar + `
+	FROM jadwal_mengajar j
+	JOIN mata_pelajaran mp ON mp.id = j.mata_pelajaran_id
+	JOIN mapel m ON m.id = mp.mapel_id
+	LEFT JOIN kelas k ON k.id = mp.kelas_id
+	WHERE j.delete_at IS NULL AND mp.delete_at IS NULL`
+
+// selectJadwal menjalankan query slot dan mengubah semua barisnya menjadi JadwalCore.
+func (q *jadwalQuery) selectJadwal(ctx context.Context, query string, args ...any) ([]jadwalmengajar.JadwalCore, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectJadwal error query", "error", err)
+		return nil, fmt.Errorf("select jadwal mengajar failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []jadwalmengajar.JadwalCore{}
+	for rows.Next() {
+		var data Jadwal
+		var guruID, guruNama []string
+		err := rows.Scan(&data.ID, &data.Mata_Pelajaran_ID, &data.Nama_Pelajaran, &data.Kelas_ID, &data.Nama_Kelas,
+			&data.Hari, &data.Jam_Mulai, &data.Jam_Selesai, &data.Update_At, &guruID, &guruNama)
+		if err != nil {
+			return nil, fmt.Errorf("select jadwal mengajar failed: %w", err)
+		}
+		core := FormatterResponse(data)
+		core.Pengajar = pengajarList(guruID, guruNama)
+		result = append(result, core)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select jadwal mengajar failed: %w", err)
+	}
+	return result, nil
+}
+
+// pengajarList menggabungkan array ID dan nama guru menjadi PengajarCore.
+func pengajarList(ids, nama []string) []jadwalmengajar.PengajarCore {
+	result := make([]jadwalmengajar.PengajarCore, 0, len(ids))
+	for i, id := range ids {
+		p := jadwalmengajar.PengajarCore{ID: id}
+		if i < len(nama) {
+			p.Nama = nama[i]
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// SelectAll implements jadwalmengajar.DataJadwalInterface.
+func (q *jadwalQuery) SelectAll(ctx context.Context, filter jadwalmengajar.FilterJadwal) ([]jadwalmengajar.JadwalCore, error) {
+	var kondisi []string
+	var args []any
+	tambah := func(format string, nilai any) {
+		args = append(args, nilai)
+		kondisi = append(kondisi, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Guru_ID != "" {
+		tambah("EXISTS (SELECT 1 FROM mata_pelajaran_guru mpg WHERE mpg.mata_pelajaran_id = mp.id AND mpg.id_guru = $%d)", filter.Guru_ID)
+	}
+	if filter.Kelas_ID != "" {
+		tambah("mp.kelas_id = $%d", filter.Kelas_ID)
+	}
+	if filter.Hari != "" {
+		tambah("j.hari = $%d", slices.Index(jadwalmengajar.NamaHari, filter.Hari))
+	}
+
+	where := ""
+	if len(kondisi) > 0 {
+		where = " AND " + strings.Join(kondisi, " AND ")
+	}
+	return q.selectJadwal(ctx, kolomJadwal+where+" ORDER BY j.hari, j.jam_mulai, k.kelas, j.id", args...)
+}
+
+// SelectById implements jadwalmengajar.DataJadwalInterface.
+func (q *jadwalQuery) SelectById(ctx context.Context, id string) (*jadwalmengajar.JadwalCore, error) {
+	result, err := q.selectJadwal(ctx, kolomJadwal+" AND j.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// Insert implements jadwalmengajar.DataJadwalInterface.
+// ID dibuat otomatis jika kosong; Update_At diisi dari database.
+func (q *jadwalQuery) Insert(ctx context.Context, insert *jadwalmengajar.JadwalCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	data := FormatterRequest(*insert)
+	err := q.db.QueryRow(ctx, `INSERT INTO jadwal_mengajar (id, mata_pelajaran_id, hari, jam_mulai, jam_selesai)
+		VALUES ($1, $2, $3, $4::time, $5::time) RETURNING update_at`,
+		data.ID, data.Mata_Pelajaran_ID, data.Hari, data.Jam_Mulai, data.Jam_Selesai).Scan(&insert.Update_At)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert jadwal mengajar error", "error", err)
+		return fmt.Errorf("insert jadwal mengajar failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully inserted jadwal mengajar", "id", insert.ID)
+	return nil
+}
+
+// DeleteById implements jadwalmengajar.DataJadwalInterface.
+// Slot hanya ditandai terhapus (soft delete) agar catatan guru pengganti yang merujuknya tetap tersimpan.
+func (q *jadwalQuery) DeleteById(ctx context.Context, id string) error {
+	tag, err := q.db.Exec(ctx, "UPDATE jadwal_mengajar SET delete_at = NOW() WHERE id = $1 AND delete_at IS NULL", id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete jadwal mengajar error", "error", err)
+		return fmt.Errorf("delete jadwal mengajar failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted jadwal mengajar", "id", id)
+	return nil
+}
+
+// SelectMataPelajaran implements jadwalmengajar.DataJadwalInterface.
+func (q *jadwalQuery) SelectMataPelajaran(ctx context.Context, id string) (*jadwalmengajar.JadwalCore, error) {
+	var core jadwalmengajar.JadwalCore
+	var guruID, guruNama []string
+	err := q.db.QueryRow(ctx, `SELECT mp.id, COALESCE(m.nama, ''), COALESCE(mp.kelas_id, ''), COALESCE(k.kelas, ''), `+kolomPengajar+`
+		FROM mata_pelajaran mp JOIN mapel m ON m.id = mp.mapel_id LEFT JOIN kelas k ON k.id = mp.kelas_id
+		WHERE mp.id = $1 AND mp.delete_at IS NULL`, id).Scan(&core.Mata_Pelajaran_ID, &core.Nama_Pelajaran,
+		&core.Kelas_ID, &core.Nama_Kelas, &guruID, &guruNama)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		helper.LoggerFromContext(ctx).Error("SelectMataPelajaran error query", "error", err)
+		return nil, fmt.Errorf("select mata pelajaran failed: %w", err)
+	}
+	core.Pengajar = pengajarList(guruID, guruNama)
+	return &core, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	jadwalmengajar "go_rest_native_sekolah/features/jadwal_mengajar"
+	"go_rest_native_sekolah/helper"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errJadwalNotFound        = errors.New("jadwal mengajar service: Data tidak ditemukan")
+	errMataPelajaranNotFound = errors.New("jadwal mengajar service: Mata pelajaran tidak ditemukan")
+)
+
+// jadwalService merepresentasikan service untuk jadwal mengajar mingguan.
+type jadwalService struct {
+	jadwalData  jadwalmengajar.DataJadwalInterface // jadwalData berisi akses ke tabel jadwal_mengajar
+	hariSekolah []time.Weekday                     // hariSekolah adalah hari yang boleh diisi slot
+}
+
+// NewServiceJadwal membuat service jadwal mengajar.
+// Parameter hariSekolah membatasi hari yang boleh diisi slot, sama dengan hari sekolah kalender akademik.
+// Jika parameter repo nil atau hariSekolah kosong maka akan terjadi panic.
+func NewServiceJadwal(repo jadwalmengajar.DataJadwalInterface, hariSekolah []time.Weekday) jadwalmengajar.ServiceJadwalInterface {
+	if repo == nil {
+		panic("jadwal mengajar service: Nil repository")
+	}
+	if len(hariSekolah) == 0 {
+		panic("jadwal mengajar service: Hari sekolah kosong")
+	}
+	return &jadwalService{jadwalData: repo, hariSekolah: hariSekolah}
+}
+
+// GetAll implements jadwalmengajar.ServiceJadwalInterface.
+func (s *jadwalService) GetAll(ctx context.Context, filter jadwalmengajar.FilterJadwal) ([]jadwalmengajar.JadwalCore, error) {
+	filter.Hari = strings.ToLower(strings.TrimSpace(filter.Hari))
+	if filter.Hari != "" && !slices.Contains(jadwalmengajar.NamaHari, filter.Hari) {
+		return nil, fmt.Errorf("validation error: hari harus salah satu dari %s", strings.Join(jadwalmengajar.NamaHari, ", "))
+	}
+	result, err := s.jadwalData.SelectAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("jadwal mengajar service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// GetById implements jadwalmengajar.ServiceJadwalInterface.
+func (s *jadwalService) GetById(ctx context.Context, id string) (*jadwalmengajar.JadwalCore, error) {
+	result, err := s.jadwalData.SelectById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errJadwalNotFound
+		}
+		return nil, fmt.Errorf("jadwal mengajar service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// Insert implements jadwalmengajar.ServiceJadwalInterface.
+func (s *jadwalService) Insert(ctx context.Context, insert *jadwalmengajar.JadwalCore) error {
+	if insert == nil {
+		return errors.New("jadwal mengajar service: input is nil")
+	}
+
+	insert.Hari = strings.ToLower(strings.TrimSpace(insert.Hari))
+	hari := slices.Index(jadwalmengajar.NamaHari, insert.Hari)
+	if hari < 0 {
+		return fmt.Errorf("validation error: hari harus salah satu dari %s", strings.Join(jadwalmengajar.NamaHari, ", "))
+	}
+	if !slices.Contains(s.hariSekolah, time.Weekday(hari)) {
+		return fmt.Errorf("validation error: %s bukan hari sekolah", insert.Hari)
+	}
+
+	mulai, err := helper.ParseRequiredTime("jam_mulai", insert.Jam_Mulai)
+	if err != nil {
+		return err
+	}
+	selesai, err := helper.ParseRequiredTime("jam_selesai", insert.Jam_Selesai)
+	if err != nil {
+		return err
+	}
+	if !selesai.After(mulai) {
+		return errors.New("validation error: jam_selesai harus setelah jam_mulai")
+	}
+	insert.Jam_Mulai = mulai.Format(helper.TimeLayout)
+	insert.Jam_Selesai = selesai.Format(helper.TimeLayout)
+
+	insert.Mata_Pelajaran_ID = strings.TrimSpace(insert.Mata_Pelajaran_ID)
+	if insert.Mata_Pelajaran_ID == "" {
+		return errors.New("validation error: mata_pelajaran_id harus diisi")
+	}
+	mp, err := s.jadwalData.SelectMataPelajaran(ctx, insert.Mata_Pelajaran_ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errMataPelajaranNotFound
+		}
+		return fmt.Errorf("jadwal mengajar service: gagal mengambil mata pelajaran: %w", err)
+	}
+	if mp.Kelas_ID == "" {
+		return errors.New("validation error: mata pelajaran belum memiliki kelas")
+	}
+
+	lain, err := s.jadwalData.SelectAll(ctx, jadwalmengajar.FilterJadwal{Hari: insert.Hari})
+	if err != nil {
+		return fmt.Errorf("jadwal mengajar service: gagal mengambil data: %w", err)
+	}
+	for _, j := range lain {
+		if j.Jam_Mulai >= insert.Jam_Selesai || j.Jam_Selesai <= insert.Jam_Mulai {
+			continue
+		}
+		if j.Kelas_ID == mp.Kelas_ID {
+			return fmt.Errorf("validation error: kelas %s sudah ada %s pada %s %s-%s",
+				j.Nama_Kelas, j.Nama_Pelajaran, j.Hari, j.Jam_Mulai, j.Jam_Selesai)
+		}
+		for _, p := range j.Pengajar {
+			if slices.ContainsFunc(mp.Pengajar, func(b jadwalmengajar.PengajarCore) bool { return b.ID == p.ID }) {
+				return fmt.Errorf("validation error: guru %s sudah mengajar %s di kelas %s pada %s %s-%s",
+					p.Nama, j.Nama_Pelajaran, j.Nama_Kelas, j.Hari, j.Jam_Mulai, j.Jam_Selesai)
+			}
+		}
+	}
+
+	insert.ID = ""
+	insert.Nama_Pelajaran = mp.Nama_Pelajaran
+	insert.Kelas_ID = mp.Kelas_ID
+	insert.Nama_Kelas = mp.Nama_Kelas
+	insert.Pengajar = mp.Pengajar
+	if err := s.jadwalData.Insert(ctx, insert); err != nil {
+		return fmt.Errorf("jadwal mengajar service: gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+// DeleteById implements jadwalmengajar.ServiceJadwalInterface.
+func (s *jadwalService) DeleteById(ctx context.Context, id string) error {
+	if err := s.jadwalData.DeleteById(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errJadwalNotFound
+		}
+		return fmt.Errorf("jadwal mengajar service: gagal menghapus data: %w", err)
+	}
+	return nil
+}
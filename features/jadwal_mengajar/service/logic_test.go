@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	jadwalmengajar "go_rest_native_sekolah/features/jadwal_mengajar"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock untuk DataJadwalInterface
+type mockDataJadwal struct {
+	mock.Mock
+}
+
+func (m *mockDataJadwal) SelectAll(ctx context.Context, filter jadwalmengajar.FilterJadwal) ([]jadwalmengajar.JadwalCore, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]jadwalmengajar.JadwalCore), args.Error(1)
+}
+
+func (m *mockDataJadwal) SelectById(ctx context.Context, id string) (*jadwalmengajar.JadwalCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*jadwalmengajar.JadwalCore), args.Error(1)
+}
+
+func (m *mockDataJadwal) Insert(ctx context.Context, insert *jadwalmengajar.JadwalCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataJadwal) DeleteById(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockDataJadwal) SelectMataPelajaran(ctx context.Context, id string) (*jadwalmengajar.JadwalCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*jadwalmengajar.JadwalCore), args.Error(1)
+}
+
+// hariKerja adalah hari sekolah Senin sampai Jumat.
+var hariKerja = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// mapelUji adalah matematika kelas 7A yang diajar guru g1.
+var mapelUji = &jadwalmengajar.JadwalCore{
+	Mata_Pelajaran_ID: "mp1", Nama_Pelajaran: "Matematika", Kelas_ID: "k1", Nama_Kelas: "7A",
+	Pengajar: []jadwalmengajar.PengajarCore{{ID: "g1", Nama: "Budi"}},
+}
+
+func TestInsertJadwal(t *testing.T) {
+	senin := jadwalmengajar.FilterJadwal{Hari: "senin"}
+
+	t.Run("success insert - jam dinormalisasi", func(t *testing.T) {
+		mockRepo := new(mockDataJadwal)
+		mockRepo.On("SelectMataPelajaran", "mp1").Return(mapelUji, nil).Once()
+		mockRepo.On("SelectAll", senin).Return([]jadwalmengajar.JadwalCore{
+			{Kelas_ID: "k1", Hari: "senin", Jam_Mulai: "07:00", Jam_Selesai: "07:30"},
+		}, nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*jadwalmengajar.JadwalCore")).Return(nil).Once()
+
+		data := &jadwalmengajar.JadwalCore{Mata_Pelajaran_ID: "mp1", Hari: " Senin ", Jam_Mulai: "7:30", Jam_Selesai: "09:00"}
+		err := NewServiceJadwal(mockRepo, hariKerja).Insert(context.Background(), data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "senin", data.Hari)
+		assert.Equal(t, "07:30", data.Jam_Mulai)
+		assert.Equal(t, "k1", data.Kelas_ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - bukan hari sekolah", func(t *testing.T) {
+		mockRepo := new(mockDataJadwal)
+		data := &jadwalmengajar.JadwalCore{Mata_Pelajaran_ID: "mp1", Hari: "minggu", Jam_Mulai: "07:30", Jam_Selesai: "09:00"}
+		err := NewServiceJadwal(mockRepo, hariKerja).Insert(context.Background(), data)
+
+		assert.ErrorContains(t, err, "validation error: minggu bukan hari sekolah")
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("error - jam selesai sebelum jam mulai", func(t *testing.T) {
+		mockRepo := new(mockDataJadwal)
+		data := &jadwalmengajar.JadwalCore{Mata_Pelajaran_ID: "mp1", Hari: "senin", Jam_Mulai: "09:00", Jam_Selesai: "07:30"}
+		err := NewServiceJadwal(mockRepo, hariKerja).Insert(context.Background(), data)
+
+		assert.ErrorContains(t, err, "validation error: jam_selesai harus setelah jam_mulai")
+	})
+
+	t.Run("error - mata pelajaran tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataJadwal)
+		mockRepo.On("SelectMataPelajaran", "mp9").Return(nil, pgx.ErrNoRows).Once()
+
+		data := &jadwalmengajar.JadwalCore{Mata_Pelajaran_ID: "mp9", Hari: "senin", Jam_Mulai: "07:30", Jam_Selesai: "09:00"}
+		err := NewServiceJadwal(mockRepo, hariKerja).Insert(context.Background(), data)
+
+		assert.ErrorIs(t, err, errMataPelajaranNotFound)
+	})
+
+	t.Run("error - kelas sudah punya slot pada jam yang sama", func(t *testing.T) {
+		mockRepo := new(mockDataJadwal)
+		mockRepo.On("SelectMataPelajaran", "mp1").Return(mapelUji, nil).Once()
+		mockRepo.On("SelectAll", senin).Return([]jadwalmengajar.JadwalCore{
+			{Kelas_ID: "k1", Nama_Kelas: "7A", Nama_Pelajaran: "IPA", Hari: "senin", Jam_Mulai: "08:00", Jam_Selesai: "09:30"},
+		}, nil).Once()
+
+		data := &jadwalmengajar.JadwalCore{Mata_Pelajaran_ID: "mp1", Hari: "senin", Jam_Mulai: "07:30", Jam_Selesai: "09:00"}
+		err := NewServiceJadwal(mockRepo, hariKerja).Insert(context.Background(), data)
+
+		assert.ErrorContains(t, err, "validation error: kelas 7A sudah ada IPA pada senin 08:00-09:30")
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("error - guru mengajar kelas lain pada jam yang sama", func(t *testing.T) {
+		mockRepo := new(mockDataJadwal)
+		mockRepo.On("SelectMataPelajaran", "mp1").Return(mapelUji, nil).Once()
+		mockRepo.On("SelectAll", senin).Return([]jadwalmengajar.JadwalCore{
+			{Kelas_ID: "k2", Nama_Kelas: "7B", Nama_Pelajaran: "Matematika", Hari: "senin", Jam_Mulai: "07:00", Jam_Selesai: "08:00",
+				Pengajar: []jadwalmengajar.PengajarCore{{ID: "g1", Nama: "Budi"}}},
+		}, nil).Once()
+
+		data := &jadwalmengajar.JadwalCore{Mata_Pelajaran_ID: "mp1", Hari: "senin", Jam_Mulai: "07:30", Jam_Selesai: "09:00"}
+		err := NewServiceJadwal(mockRepo, hariKerja).Insert(context.Background(), data)
+
+		assert.ErrorContains(t, err, "validation error: guru Budi sudah mengajar Matematika di kelas 7B")
+	})
+}
+
+func TestGetAllJadwal(t *testing.T) {
+	t.Run("error - hari tidak valid", func(t *testing.T) {
+		mockRepo := new(mockDataJadwal)
+		_, err := NewServiceJadwal(mockRepo, hariKerja).GetAll(context.Background(), jadwalmengajar.FilterJadwal{Hari: "monday"})
+
+		assert.ErrorContains(t, err, "validation error: hari")
+		mockRepo.AssertNotCalled(t, "SelectAll", mock.Anything)
+	})
+}
+
+func TestDeleteJadwal(t *testing.T) {
+	t.Run("error - tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataJadwal)
+		mockRepo.On("DeleteById", "j9").Return(pgx.ErrNoRows).Once()
+
+		err := NewServiceJadwal(mockRepo, hariKerja).DeleteById(context.Background(), "j9")
+
+		assert.ErrorIs(t, err, errJadwalNotFound)
+	})
+}
@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/kalender"
+	"go_rest_native_sekolah/helper"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// KalenderController menghandle HTTP request kalender akademik, feed iCalendar, dan hari efektif.
+type KalenderController struct {
+	kalenderService kalender.ServiceKalenderInterface
+}
+
+// NewKalenderController membuat KalenderController dengan service kalender akademik.
+func NewKalenderController(service kalender.ServiceKalenderInterface) *KalenderController {
+	return &KalenderController{kalenderService: service}
+}
+
+// writeKalenderError menulis response untuk error dari service kalender.
+// Mengembalikan false jika error tidak dikenali sehingga pemanggil perlu meneruskannya.
+func writeKalenderError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, helper.ErrVersionConflict):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case strings.Contains(err.Error(), "validation"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+// Kalender menghandle GET /kalender?tahun_ajaran=&jenis=&kelas_id=&dari=&sampai= untuk daftar agenda.
+// Semua filter opsional; kelas_id juga mengambil agenda untuk semua kelas.
+func (kc *KalenderController) Kalender(w http.ResponseWriter, r *http.Request) error {
+	if kc == nil || kc.kalenderService == nil {
+		return errors.New("kalender controller: service is nil")
+	}
+
+	q := r.URL.Query()
+	filter := kalender.FilterKalender{
+		Tahun_Ajaran: q.Get("tahun_ajaran"),
+		Jenis:        q.Get("jenis"),
+		Dari:         q.Get("dari"),
+		Sampai:       q.Get("sampai"),
+	}
+	if kelasID := strings.TrimSpace(q.Get("kelas_id")); kelasID != "" {
+		filter.Kelas_ID = []string{kelasID}
+	}
+
+	result, err := kc.kalenderService.GetAll(r.Context(), filter)
+	if err != nil {
+		if writeKalenderError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data kalender akademik", FormatKalenderList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// InsertKalender menghandle POST /kalender/tambah. Body JSON berisi tahun_ajaran, jenis (libur, ujian,
+// atau kegiatan), judul, deskripsi, tanggal_mulai, tanggal_selesai, dan kelas_id (kosong untuk semua kelas).
+func (kc *KalenderController) InsertKalender(w http.ResponseWriter, r *http.Request) error {
+	if kc == nil || kc.kalenderService == nil {
+		return errors.New("kalender controller: service is nil")
+	}
+
+	var req KalenderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data := KalenderRequestToCore(req)
+	data.Dibuat_Oleh = meta.ID
+
+	if err := kc.kalenderService.Insert(r.Context(), &data); err != nil {
+		if writeKalenderError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	created, err := kc.kalenderService.GetById(r.Context(), data.ID)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil menambah agenda kalender akademik", FormatKalenderList([]kalender.KalenderCore{*created}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, created.Version)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// GetKalenderById menghandle GET /kalender/{id}. Response membawa header ETag untuk update dan delete.
+func (kc *KalenderController) GetKalenderById(w http.ResponseWriter, r *http.Request) error {
+	if kc == nil || kc.kalenderService == nil {
+		return errors.New("kalender controller: service is nil")
+	}
+
+	data, err := kc.kalenderService.GetById(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeKalenderError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data kalender akademik", FormatKalenderList([]kalender.KalenderCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// UpdateKalender menghandle PUT /kalender/{id}. Field yang kosong atau tidak dikirim tetap memakai nilai lama;
+// kirim kelas_id "" untuk memberlakukan agenda ke semua kelas. Header If-Match wajib diisi dengan ETag terbaru.
+func (kc *KalenderController) UpdateKalender(w http.ResponseWriter, r *http.Request) error {
+	if kc == nil || kc.kalenderService == nil {
+		return errors.New("kalender controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	var req KalenderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+	data := KalenderRequestToCore(req)
+	data.Version = version
+
+	// Kelas hanya diubah jika field kelas_id dikirim
+	if req.Kelas_ID == nil {
+		existing, err := kc.kalenderService.GetById(r.Context(), id)
+		if err != nil {
+			if writeKalenderError(w, err) {
+				return nil
+			}
+			return err
+		}
+		data.Kelas_ID = existing.Kelas_ID
+	}
+
+	if err := kc.kalenderService.Update(r.Context(), &data, id); err != nil {
+		if writeKalenderError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	updated, err := kc.kalenderService.GetById(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengupdate agenda kalender akademik", FormatKalenderList([]kalender.KalenderCore{*updated}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updated.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// DeleteKalender menghandle DELETE /kalender/{id}. Header If-Match wajib diisi dengan ETag terbaru.
+func (kc *KalenderController) DeleteKalender(w http.ResponseWriter, r *http.Request) error {
+	if kc == nil || kc.kalenderService == nil {
+		return errors.New("kalender controller: service is nil")
+	}
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	if err := kc.kalenderService.DeleteById(r.Context(), r.PathValue("id"), version); err != nil {
+		if writeKalenderError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil menghapus agenda kalender akademik", nil))
+	return nil
+}
+
+// HariEfektif menghandle GET /kalender/hari-efektif?dari=&sampai=&kelas_id= untuk jumlah dan daftar
+// hari efektif sekolah pada rentang tanggal inklusif. kelas_id opsional.
+func (kc *KalenderController) HariEfektif(w http.ResponseWriter, r *http.Request) error {
+	if kc == nil || kc.kalenderService == nil {
+		return errors.New("kalender controller: service is nil")
+	}
+
+	q := r.URL.Query()
+	result, err := kc.kalenderService.HariEfektif(r.Context(), q.Get("dari"), q.Get("sampai"), q.Get("kelas_id"))
+	if err != nil {
+		if writeKalenderError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil menghitung hari efektif", result)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// ICalKelas menghandle GET /kalender/kelas/{id}/ical untuk feed iCalendar agenda satu kelas.
+func (kc *KalenderController) ICalKelas(w http.ResponseWriter, r *http.Request) error {
+	if kc == nil || kc.kalenderService == nil {
+		return errors.New("kalender controller: service is nil")
+	}
+
+	id := r.PathValue("id")
+	result, err := kc.kalenderService.AgendaKelas(r.Context(), id)
+	if err != nil {
+		if writeKalenderError(w, err) {
+			return nil
+		}
+		return err
+	}
+	writeICal(w, "kalender-kelas-"+id+".ics", FormatICal("Kalender Akademik Kelas", result))
+	return nil
+}
+
+// ICalGuru menghandle GET /kalender/guru/{id}/ical untuk feed iCalendar agenda kelas yang diwalikan atau diajar guru.
+func (kc *KalenderController) ICalGuru(w http.ResponseWriter, r *http.Request) error {
+	if kc == nil || kc.kalenderService == nil {
+		return errors.New("kalender controller: service is nil")
+	}
+
+	id := r.PathValue("id")
+	result, err := kc.kalenderService.AgendaGuru(r.Context(), id)
+	if err != nil {
+		if writeKalenderError(w, err) {
+			return nil
+		}
+		return err
+	}
+	writeICal(w, "kalender-guru-"+id+".ics", FormatICal("Kalender Akademik Guru", result))
+	return nil
+}
+
+// writeICal menulis dokumen iCalendar sebagai file .ics bernama namaFile.
+func writeICal(w http.ResponseWriter, namaFile, isi string) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": namaFile}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(isi))
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"go_rest_native_sekolah/features/kalender"
+	"time"
+)
+
+// KalenderFormatter digunakan untuk memformat agenda kalender akademik pada response API.
+type KalenderFormatter struct {
+	ID              string    `json:"id"`              // ID adalah ID unik agenda
+	Tahun_Ajaran    string    `json:"tahun_ajaran"`    // Tahun_Ajaran adalah tahun ajaran agenda, misalnya 2026/2027
+	Jenis           string    `json:"jenis"`           // Jenis adalah libur, ujian, atau kegiatan
+	Judul           string    `json:"judul"`           // Judul adalah nama agenda
+	Deskripsi       string    `json:"deskripsi"`       // Deskripsi adalah keterangan agenda
+	Tanggal_Mulai   string    `json:"tanggal_mulai"`   // Tanggal_Mulai adalah hari pertama agenda (YYYY-MM-DD)
+	Tanggal_Selesai string    `json:"tanggal_selesai"` // Tanggal_Selesai adalah hari terakhir agenda (YYYY-MM-DD)
+	Kelas_ID        string    `json:"kelas_id"`        // Kelas_ID adalah kelas yang terkena agenda, kosong berarti semua kelas
+	Nama_Kelas      string    `json:"nama_kelas"`      // Nama_Kelas adalah nama kelas
+	Dibuat_Oleh     string    `json:"dibuat_oleh"`     // Dibuat_Oleh adalah ID user pembuat
+	Update_At       time.Time `json:"update_at"`       // Update_At adalah waktu perubahan terakhir
+	Version         int       `json:"version"`         // Version adalah versi data agenda, sama dengan ETag
+}
+
+// KalenderRequest digunakan untuk membaca body JSON tambah dan update agenda.
+// Kelas_ID berupa pointer agar update tanpa field kelas_id tidak mengubah kelas agenda.
+type KalenderRequest struct {
+	Tahun_Ajaran    string  `json:"tahun_ajaran"`
+	Jenis           string  `json:"jenis"`
+	Judul           string  `json:"judul"`
+	Deskripsi       string  `json:"deskripsi"`
+	Tanggal_Mulai   string  `json:"tanggal_mulai"`
+	Tanggal_Selesai string  `json:"tanggal_selesai"`
+	Kelas_ID        *string `json:"kelas_id"`
+}
+
+// FormatKalenderList mengubah slice KalenderCore menjadi slice KalenderFormatter.
+func FormatKalenderList(cores []kalender.KalenderCore) []KalenderFormatter {
+	formatted := make([]KalenderFormatter, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, KalenderFormatter{
+			ID:              core.ID,
+			Tahun_Ajaran:    core.Tahun_Ajaran,
+			Jenis:           core.Jenis,
+			Judul:           core.Judul,
+			Deskripsi:       core.Deskripsi,
+			Tanggal_Mulai:   core.Tanggal_Mulai,
+			Tanggal_Selesai: core.Tanggal_Selesai,
+			Kelas_ID:        core.Kelas_ID,
+			Nama_Kelas:      core.Nama_Kelas,
+			Dibuat_Oleh:     core.Dibuat_Oleh,
+			Update_At:       core.Update_At,
+			Version:         core.Version,
+		})
+	}
+	return formatted
+}
+
+// KalenderRequestToCore mengubah KalenderRequest menjadi KalenderCore.
+// Kelas_ID yang tidak dikirim bernilai kosong (semua kelas).
+func KalenderRequestToCore(req KalenderRequest) kalender.KalenderCore {
+	core := kalender.KalenderCore{
+		Tahun_Ajaran:    req.Tahun_Ajaran,
+		Jenis:           req.Jenis,
+		Judul:           req.Judul,
+		Deskripsi:       req.Deskripsi,
+		Tanggal_Mulai:   req.Tanggal_Mulai,
+		Tanggal_Selesai: req.Tanggal_Selesai,
+	}
+	if req.Kelas_ID != nil {
+		core.Kelas_ID = *req.Kelas_ID
+	}
+	return core
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"go_rest_native_sekolah/features/kalender"
+	"go_rest_native_sekolah/helper"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icalMaxBaris adalah panjang maksimum satu baris iCalendar dalam byte sebelum dilipat (RFC 5545 bagian 3.1).
+const icalMaxBaris = 75
+
+// icalEscaper meng-escape karakter khusus pada nilai TEXT iCalendar.
+// CRLF, LF, dan CR yang berdiri sendiri sama-sama menjadi \n karena baris mentah akan merusak struktur dokumen.
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// FormatICal mengubah daftar agenda menjadi dokumen iCalendar (.ics) dengan nama kalender nama.
+// Setiap agenda menjadi event sehari penuh; DTEND bersifat eksklusif sehingga diisi sehari setelah Tanggal_Selesai.
+func FormatICal(nama string, cores []kalender.KalenderCore) string {
+	var b strings.Builder
+	tulis := func(baris string) {
+		b.WriteString(lipatBaris(baris))
+		b.WriteString("\r\n")
+	}
+
+	tulis("BEGIN:VCALENDAR")
+	tulis("VERSION:2.0")
+	tulis("PRODID:-//go_rest_native_sekolah//Kalender Akademik//ID")
+	tulis("CALSCALE:GREGORIAN")
+	tulis("METHOD:PUBLISH")
+	tulis("X-WR-CALNAME:" + icalEscaper.Replace(nama))
+	for _, core := range cores {
+		mulai, errMulai := time.Parse(helper.DateLayout, core.Tanggal_Mulai)
+		selesai, errSelesai := time.Parse(helper.DateLayout, core.Tanggal_Selesai)
+		if errMulai != nil || errSelesai != nil {
+			continue
+		}
+		tulis("BEGIN:VEVENT")
+		tulis("UID:" + core.ID + "@kalender-akademik")
+		tulis("DTSTAMP:" + core.Update_At.UTC().Format("20060102T150405Z"))
+		tulis("DTSTART;VALUE=DATE:" + mulai.Format("20060102"))
+		tulis("DTEND;VALUE=DATE:" + selesai.AddDate(0, 0, 1).Format("20060102"))
+		tulis("SUMMARY:" + icalEscaper.Replace(core.Judul))
+		if core.Deskripsi != "" {
+			tulis("DESCRIPTION:" + icalEscaper.Replace(core.Deskripsi))
+		}
+		tulis("CATEGORIES:" + strings.ToUpper(core.Jenis))
+		// Agenda sehari penuh tidak memblokir jadwal di aplikasi kalender
+		tulis("TRANSP:TRANSPARENT")
+		tulis("END:VEVENT")
+	}
+	tulis("END:VCALENDAR")
+	return b.String()
+}
+
+// lipatBaris memecah baris yang lebih panjang dari icalMaxBaris byte menjadi beberapa baris lanjutan
+// yang diawali spasi, tanpa memotong karakter UTF-8 di tengah.
+func lipatBaris(baris string) string {
+	if len(baris) <= icalMaxBaris {
+		return baris
+	}
+	var b strings.Builder
+	batas := icalMaxBaris
+	for len(baris) > batas {
+		potong := batas
+		for potong > 0 && !utf8.RuneStart(baris[potong]) {
+			potong--
+		}
+		b.WriteString(baris[:potong])
+		b.WriteString("\r\n ")
+		baris = baris[potong:]
+		// Baris lanjutan diawali satu spasi sehingga isinya paling banyak icalMaxBaris-1 byte
+		batas = icalMaxBaris - 1
+	}
+	b.WriteString(baris)
+	return b.String()
+}
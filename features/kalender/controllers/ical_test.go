@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"go_rest_native_sekolah/features/kalender"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatICal(t *testing.T) {
+	diubah := time.Date(2026, 10, 19, 8, 30, 0, 0, time.FixedZone("WIB", 7*3600))
+	cores := []kalender.KalenderCore{
+		{
+			ID:              "k1",
+			Jenis:           "libur",
+			Judul:           "Libur akhir semester ganjil untuk seluruh siswa kelas 7 sampai 9 — selamat berlibur dan sampai jumpa di semester genap",
+			Deskripsi:       "Rapor dibagikan; hadir pukul 07.00, bawa map\\arsip.\nOrang tua wajib hadir.\r\nTerima kasih\rPanitia",
+			Tanggal_Mulai:   "2026-12-21",
+			Tanggal_Selesai: "2027-01-02",
+			Update_At:       diubah,
+		},
+		{
+			ID:              "k2",
+			Jenis:           "kegiatan",
+			Judul:           "Malam tahun baru",
+			Tanggal_Mulai:   "2026-12-31",
+			Tanggal_Selesai: "2026-12-31",
+			Update_At:       diubah,
+		},
+		// Tanggal rusak dilewati tanpa merusak dokumen
+		{ID: "k3", Judul: "Rusak", Tanggal_Mulai: "31-12-2026", Tanggal_Selesai: "2026-12-31"},
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//go_rest_native_sekolah//Kalender Akademik//ID",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Kalender\, Kelas 7A\; 2026/2027`,
+		"BEGIN:VEVENT",
+		"UID:k1@kalender-akademik",
+		"DTSTAMP:20261019T013000Z",
+		"DTSTART;VALUE=DATE:20261221",
+		"DTEND;VALUE=DATE:20270103",
+		"SUMMARY:Libur akhir semester ganjil untuk seluruh siswa kelas 7 sampai 9 ",
+		" — selamat berlibur dan sampai jumpa di semester genap",
+		`DESCRIPTION:Rapor dibagikan\; hadir pukul 07.00\, bawa map\\arsip.\nOrang t`,
+		` ua wajib hadir.\nTerima kasih\nPanitia`,
+		"CATEGORIES:LIBUR",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:k2@kalender-akademik",
+		"DTSTAMP:20261019T013000Z",
+		"DTSTART;VALUE=DATE:20261231",
+		"DTEND;VALUE=DATE:20270101",
+		"SUMMARY:Malam tahun baru",
+		"CATEGORIES:KEGIATAN",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	got := FormatICal("Kalender, Kelas 7A; 2026/2027", cores)
+	assert.Equal(t, want, got)
+
+	// Setiap baris fisik paling panjang 75 byte, UTF-8 valid, dan tidak ada CR atau LF yang berdiri sendiri
+	for _, baris := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(baris), icalMaxBaris, baris)
+		assert.True(t, utf8.ValidString(baris), baris)
+		assert.NotContains(t, baris, "\r")
+		assert.NotContains(t, baris, "\n")
+	}
+}
+
+func TestLipatBaris(t *testing.T) {
+	tests := []struct {
+		name  string
+		baris string
+	}{
+		{"pas 75 byte tidak dilipat", "SUMMARY:" + strings.Repeat("a", 67)},
+		{"76 byte ascii", "SUMMARY:" + strings.Repeat("a", 68)},
+		{"karakter dua byte", "SUMMARY:" + strings.Repeat("é", 100)},
+		{"karakter tiga byte di batas", "SUMMARY:" + strings.Repeat("a", 66) + strings.Repeat("—", 40)},
+		{"karakter empat byte", "DESCRIPTION:" + strings.Repeat("📚", 50)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hasil := lipatBaris(tc.baris)
+			bagian := strings.Split(hasil, "\r\n")
+
+			for i, b := range bagian {
+				assert.LessOrEqual(t, len(b), icalMaxBaris, "baris %d", i)
+				assert.True(t, utf8.ValidString(b), "baris %d terpotong di tengah karakter", i)
+				if i > 0 {
+					assert.True(t, strings.HasPrefix(b, " "), "baris lanjutan %d harus diawali spasi", i)
+					// Baris lanjutan diisi sepenuh mungkin tanpa memotong karakter
+					if i < len(bagian)-1 {
+						assert.Greater(t, len(b), icalMaxBaris-utf8.UTFMax)
+					}
+				}
+			}
+			// Unfolding (hapus CRLF + spasi) mengembalikan baris asli
+			assert.Equal(t, tc.baris, strings.ReplaceAll(hasil, "\r\n ", ""))
+			assert.Equal(t, len(tc.baris) <= icalMaxBaris, len(bagian) == 1)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package kalender
+
+import (
+	"context"
+	"time"
+)
+
+// Jenis agenda kalender akademik.
+const (
+	JenisLibur    = "libur"    // Hari libur; tanggalnya tidak dihitung sebagai hari efektif
+	JenisUjian    = "ujian"    // Pekan ujian; tetap dihitung sebagai hari efektif
+	JenisKegiatan = "kegiatan" // Kegiatan sekolah; tetap dihitung sebagai hari efektif
+)
+
+// JenisValid berisi semua jenis agenda yang boleh disimpan.
+var JenisValid = []string{JenisLibur, JenisUjian, JenisKegiatan}
+
+// PengelolaRoles adalah role yang boleh menambah, mengubah, dan menghapus agenda kalender akademik.
+var PengelolaRoles = []string{"admin"}
+
+// MaxRentangHari adalah panjang maksimum rentang tanggal untuk perhitungan hari efektif.
+const MaxRentangHari = 366
+
+type (
+	// KalenderCore merepresentasikan satu agenda kalender akademik pada satu tahun ajaran.
+	// Tanggal memakai format helper.DateLayout dan Tanggal_Selesai ikut dihitung (inklusif).
+	KalenderCore struct {
+		ID              string    `json:"id"`              // ID adalah identifikasi unik agenda.
+		Tahun_Ajaran    string    `json:"tahun_ajaran"`    // Tahun_Ajaran berformat YYYY/YYYY, misalnya 2026/2027.
+		Jenis           string    `json:"jenis"`           // Jenis adalah libur, ujian, atau kegiatan.
+		Judul           string    `json:"judul"`           // Judul adalah nama agenda.
+		Deskripsi       string    `json:"deskripsi"`       // Deskripsi adalah keterangan agenda.
+		Tanggal_Mulai   string    `json:"tanggal_mulai"`   // Tanggal_Mulai adalah hari pertama agenda.
+		Tanggal_Selesai string    `json:"tanggal_selesai"` // Tanggal_Selesai adalah hari terakhir agenda; sama dengan Tanggal_Mulai untuk agenda satu hari.
+		Kelas_ID        string    `json:"kelas_id"`        // Kelas_ID adalah kelas yang terkena agenda, kosong berarti semua kelas.
+		Nama_Kelas      string    `json:"nama_kelas"`      // Nama_Kelas adalah nama kelas jika Kelas_ID diisi.
+		Dibuat_Oleh     string    `json:"dibuat_oleh"`     // Dibuat_Oleh adalah ID user pembuat agenda.
+		Update_At       time.Time `json:"update_at"`       // Update_At adalah waktu perubahan terakhir.
+		Version         int       `json:"version"`         // Version adalah versi data untuk optimistic concurrency, dikirim sebagai ETag.
+	}
+
+	// FilterKalender berisi filter opsional daftar agenda. Field kosong berarti tidak difilter.
+	FilterKalender struct {
+		Tahun_Ajaran string
+		Jenis        string
+		// Kelas_ID membatasi agenda ke kelas-kelas ini ditambah agenda untuk semua kelas.
+		// Nil berarti tidak difilter, sedangkan slice kosong hanya mengambil agenda untuk semua kelas.
+		Kelas_ID []string
+		// Dari dan Sampai (format helper.DateLayout) membatasi agenda yang beririsan dengan rentang tersebut.
+		Dari   string
+		Sampai string
+	}
+
+	// HariEfektifCore adalah hasil perhitungan hari efektif sekolah pada satu rentang tanggal.
+	HariEfektifCore struct {
+		Dari         string   `json:"dari"`         // Dari adalah tanggal awal rentang.
+		Sampai       string   `json:"sampai"`       // Sampai adalah tanggal akhir rentang (inklusif).
+		Kelas_ID     string   `json:"kelas_id"`     // Kelas_ID adalah kelas yang dihitung, kosong berarti hanya libur semua kelas.
+		Hari_Sekolah int      `json:"hari_sekolah"` // Hari_Sekolah adalah banyaknya hari sekolah sebelum dikurangi libur.
+		Hari_Libur   int      `json:"hari_libur"`   // Hari_Libur adalah banyaknya hari sekolah yang jatuh pada libur.
+		Hari_Efektif int      `json:"hari_efektif"` // Hari_Efektif adalah Hari_Sekolah dikurangi Hari_Libur.
+		Tanggal      []string `json:"tanggal"`      // Tanggal adalah daftar tanggal hari efektif secara berurutan.
+	}
+
+	// DataKalenderInterface mendefinisikan operasi tabel kalender_akademik.
+	DataKalenderInterface interface {
+		SelectAll(ctx context.Context, filter FilterKalender) ([]KalenderCore, error) // Mengambil agenda aktif sesuai filter, urut tanggal mulai.
+		SelectById(ctx context.Context, id string) (*KalenderCore, error)             // Mengambil agenda aktif berdasarkan ID, pgx.ErrNoRows jika tidak ada.
+		Insert(ctx context.Context, insert *KalenderCore) error                       // Menyimpan agenda baru.
+		Update(ctx context.Context, update *KalenderCore, id string) error            // Mengubah agenda jika versinya masih sama dengan update.Version.
+		DeleteById(ctx context.Context, id string, version int) error                 // Menghapus (soft delete) agenda jika versinya masih sama dengan version.
+		KelasAda(ctx context.Context, kelasID string) (bool, error)                   // Memeriksa apakah kelas aktif ada.
+		KelasGuru(ctx context.Context, guruID string) ([]string, error)               // Mengambil kelas yang diwalikan atau diajar guru, pgx.ErrNoRows jika guru tidak ada.
+	}
+
+	// ServiceKalenderInterface mendefinisikan logika bisnis kalender akademik.
+	// HariEfektif dipakai modul lain yang perlu mengetahui hari sekolah, misalnya presensi dan penjadwalan.
+	ServiceKalenderInterface interface {
+		GetAll(ctx context.Context, filter FilterKalender) ([]KalenderCore, error)               // Mengambil daftar agenda.
+		GetById(ctx context.Context, id string) (*KalenderCore, error)                           // Mengambil satu agenda.
+		Insert(ctx context.Context, insert *KalenderCore) error                                  // Memvalidasi dan menyimpan agenda baru.
+		Update(ctx context.Context, update *KalenderCore, id string) error                       // Mengubah agenda; field kosong memakai nilai lama.
+		DeleteById(ctx context.Context, id string, version int) error                            // Menghapus agenda.
+		AgendaKelas(ctx context.Context, kelasID string) ([]KalenderCore, error)                 // Mengambil agenda yang berlaku untuk satu kelas.
+		AgendaGuru(ctx context.Context, guruID string) ([]KalenderCore, error)                   // Mengambil agenda yang berlaku untuk kelas yang diwalikan atau diajar guru.
+		HariEfektif(ctx context.Context, dari, sampai, kelasID string) (*HariEfektifCore, error) // Menghitung hari efektif sekolah pada rentang tanggal inklusif.
+	}
+)
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"go_rest_native_sekolah/features/kalender"
+	"time"
+)
+
+// Kalender merepresentasikan satu baris tabel kalender_akademik beserta nama kelasnya.
+type Kalender struct {
+	ID              string     `json:"id"`              // ID adalah identifikasi unik agenda.
+	Tahun_Ajaran    string     `json:"tahun_ajaran"`    // Tahun_Ajaran berformat YYYY/YYYY.
+	Jenis           string     `json:"jenis"`           // Jenis adalah libur, ujian, atau kegiatan.
+	Judul           string     `json:"judul"`           // Judul adalah nama agenda.
+	Deskripsi       string     `json:"deskripsi"`       // Deskripsi adalah keterangan agenda.
+	Tanggal_Mulai   string     `json:"tanggal_mulai"`   // Tanggal_Mulai disimpan sebagai DATE.
+	Tanggal_Selesai string     `json:"tanggal_selesai"` // Tanggal_Selesai disimpan sebagai DATE.
+	Kelas_ID        string     `json:"kelas_id"`        // Kelas_ID kosong (NULL) berarti semua kelas.
+	Nama_Kelas      string     `json:"nama_kelas"`      // Nama_Kelas diambil dari tabel kelas.
+	Dibuat_Oleh     string     `json:"dibuat_oleh"`     // Dibuat_Oleh adalah ID user pembuat.
+	Update_At       time.Time  `json:"update_at"`       // Update_At adalah waktu perubahan terakhir.
+	Delete_At       *time.Time `json:"delete_at"`       // Delete_At adalah waktu agenda dihapus, jika ada.
+	Version         int        `json:"version"`         // Version adalah versi data untuk optimistic concurrency.
+}
+
+// TableName mengembalikan nama tabel kalender akademik di database.
+func (k *Kalender) TableName() string {
+	return "kalender_akademik"
+}
+
+// FormatterRequest mengubah KalenderCore menjadi Kalender untuk disimpan ke database.
+func FormatterRequest(req kalender.KalenderCore) Kalender {
+	return Kalender{
+		ID:              req.ID,
+		Tahun_Ajaran:    req.Tahun_Ajaran,
+		Jenis:           req.Jenis,
+		Judul:           req.Judul,
+		Deskripsi:       req.Deskripsi,
+		Tanggal_Mulai:   req.Tanggal_Mulai,
+		Tanggal_Selesai: req.Tanggal_Selesai,
+		Kelas_ID:        req.Kelas_ID,
+		Dibuat_Oleh:     req.Dibuat_Oleh,
+		Version:         req.Version,
+	}
+}
+
+// FormatterResponse mengubah Kalender dari database menjadi KalenderCore.
+func FormatterResponse(res Kalender) kalender.KalenderCore {
+	return kalender.KalenderCore{
+		ID:              res.ID,
+		Tahun_Ajaran:    res.Tahun_Ajaran,
+		Jenis:           res.Jenis,
+		Judul:           res.Judul,
+		Deskripsi:       res.Deskripsi,
+		Tanggal_Mulai:   res.Tanggal_Mulai,
+		Tanggal_Selesai: res.Tanggal_Selesai,
+		Kelas_ID:        res.Kelas_ID,
+		Nama_Kelas:      res.Nama_Kelas,
+		Dibuat_Oleh:     res.Dibuat_Oleh,
+		Update_At:       res.Update_At,
+		Version:         res.Version,
+	}
+}
@@ -0,0 +1,220 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/kalender"
+	"go_rest_native_sekolah/helper"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// kalenderQuery menghandle query ke tabel kalender_akademik.
+type kalenderQuery struct {
+	db helper.DBTX
+}
+
+// NewDataKalender membuat objek kalenderQuery dengan parameter db.
+// Jika parameter db nil maka akan terjadi panic.
+func NewDataKalender(db helper.DBTX) kalender.DataKalenderInterface {
+	if db == nil {
+		panic("kalender model: Nil database")
+	}
+	return &kalenderQuery{db: db}
+}
+
+// kolomKalender adalah daftar kolom yang diambil untuk setiap agenda, sesuai urutan scan di selectKalender.
+// Query yang memakainya harus memberi alias ka pada tabel kalender_akademik dan k pada tabel kelas.
+const kolomKalender = `ka.id, ka.tahun_ajaran, ka.jenis, ka.judul, COALESCE(ka.deskripsi, ''),
+	TO_CHAR(ka.tanggal_mulai, 'YYYY-MM-DD'), TO_CHAR(ka.tanggal_selesai, 'YYYY-MM-DD'),
+	COALESCE(ka.kelas_id, ''), COALESCE(k.kelas, ''), COALESCE(ka.dibuat_oleh, ''), ka.update_at, ka.version`
+
+// dariKalender adalah klausa FROM untuk kolomKalender.
+const dariKalender = ` FROM kalender_akademik ka LEFT JOIN kelas k ON k.id = ka.kelas_id WHERE ka.delete_at IS NULL`
+
+// selectKalender menjalankan query agenda dan mengubah semua barisnya menjadi KalenderCore.
+func (q *kalenderQuery) selectKalender(ctx context.Context, query string, args ...any) ([]kalender.KalenderCore, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectKalender error query", "error", err)
+		return nil, fmt.Errorf("select kalender failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []kalender.KalenderCore{}
+	for rows.Next() {
+		var data Kalender
+		err := rows.Scan(&data.ID, &data.Tahun_Ajaran, &data.Jenis, &data.Judul, &data.Deskripsi,
+			&data.Tanggal_Mulai, &data.Tanggal_Selesai, &data.Kelas_ID, &data.Nama_Kelas, &data.Dibuat_Oleh,
+			&data.Update_At, &data.Version)
+		if err != nil {
+			return nil, fmt.Errorf("select kalender failed: %w", err)
+		}
+		result = append(result, FormatterResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select kalender failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectAll implements kalender.DataKalenderInterface.
+func (q *kalenderQuery) SelectAll(ctx context.Context, filter kalender.FilterKalender) ([]kalender.KalenderCore, error) {
+	where, args := filterKalender(filter)
+	return q.selectKalender(ctx,
+		"SELECT "+kolomKalender+dariKalender+where+" ORDER BY ka.tanggal_mulai, ka.tanggal_selesai, ka.id", args...)
+}
+
+// filterKalender menyusun kondisi WHERE tambahan dan argumennya dari filter.
+func filterKalender(filter kalender.FilterKalender) (string, []any) {
+	var kondisi []string
+	var args []any
+	tambah := func(format string, nilai any) {
+		args = append(args, nilai)
+		kondisi = append(kondisi, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Tahun_Ajaran != "" {
+		tambah("ka.tahun_ajaran = $%d", filter.Tahun_Ajaran)
+	}
+	if filter.Jenis != "" {
+		tambah("ka.jenis = $%d", filter.Jenis)
+	}
+	if filter.Kelas_ID != nil {
+		tambah("(ka.kelas_id IS NULL OR ka.kelas_id = ANY($%d::text[]))", filter.Kelas_ID)
+	}
+	if filter.Dari != "" {
+		tambah("ka.tanggal_selesai >= $%d::date", filter.Dari)
+	}
+	if filter.Sampai != "" {
+		tambah("ka.tanggal_mulai <= $%d::date", filter.Sampai)
+	}
+
+	if len(kondisi) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(kondisi, " AND "), args
+}
+
+// SelectById implements kalender.DataKalenderInterface.
+func (q *kalenderQuery) SelectById(ctx context.Context, id string) (*kalender.KalenderCore, error) {
+	result, err := q.selectKalender(ctx, "SELECT "+kolomKalender+dariKalender+" AND ka.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// Insert implements kalender.DataKalenderInterface.
+// ID dibuat otomatis jika kosong; Update_At dan Version diisi dari database.
+func (q *kalenderQuery) Insert(ctx context.Context, insert *kalender.KalenderCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	data := FormatterRequest(*insert)
+	query := `INSERT INTO kalender_akademik (id, tahun_ajaran, jenis, judul, deskripsi, tanggal_mulai, tanggal_selesai, kelas_id, dibuat_oleh)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6::date, $7::date, NULLIF($8, ''), NULLIF($9, ''))
+		RETURNING update_at, version`
+	err := q.db.QueryRow(ctx, query, data.ID, data.Tahun_Ajaran, data.Jenis, data.Judul, data.Deskripsi,
+		data.Tanggal_Mulai, data.Tanggal_Selesai, data.Kelas_ID, data.Dibuat_Oleh).Scan(&insert.Update_At, &insert.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert kalender error", "error", err)
+		return fmt.Errorf("insert kalender failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully inserted kalender", "id", insert.ID)
+	return nil
+}
+
+// Update implements kalender.DataKalenderInterface.
+// Mengembalikan helper.ErrVersionConflict jika versinya sudah berubah dan pgx.ErrNoRows jika agenda tidak ada.
+func (q *kalenderQuery) Update(ctx context.Context, update *kalender.KalenderCore, id string) error {
+	if update == nil {
+		return errors.New("update data is nil")
+	}
+
+	data := FormatterRequest(*update)
+	query := `UPDATE kalender_akademik
+		SET tahun_ajaran = $1, jenis = $2, judul = $3, deskripsi = NULLIF($4, ''), tanggal_mulai = $5::date,
+			tanggal_selesai = $6::date, kelas_id = NULLIF($7, ''), update_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $8 AND delete_at IS NULL AND version = $9`
+	tag, err := q.db.Exec(ctx, query, data.Tahun_Ajaran, data.Jenis, data.Judul, data.Deskripsi, data.Tanggal_Mulai,
+		data.Tanggal_Selesai, data.Kelas_ID, id, data.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Update kalender error", "error", err)
+		return fmt.Errorf("update kalender failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "kalender_akademik", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully updated kalender", "id", id)
+	return nil
+}
+
+// DeleteById implements kalender.DataKalenderInterface.
+// Agenda hanya ditandai terhapus (soft delete).
+func (q *kalenderQuery) DeleteById(ctx context.Context, id string, version int) error {
+	tag, err := q.db.Exec(ctx,
+		"UPDATE kalender_akademik SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2",
+		id, version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete kalender error", "error", err)
+		return fmt.Errorf("delete kalender failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "kalender_akademik", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted kalender", "id", id)
+	return nil
+}
+
+// KelasAda implements kalender.DataKalenderInterface.
+func (q *kalenderQuery) KelasAda(ctx context.Context, kelasID string) (bool, error) {
+	var ada bool
+	err := q.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM kelas WHERE id = $1 AND delete_at IS NULL)", kelasID).Scan(&ada)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("KelasAda error query", "error", err)
+		return false, fmt.Errorf("cek kelas failed: %w", err)
+	}
+	return ada, nil
+}
+
+// KelasGuru implements kalender.DataKalenderInterface.
+// Kelas diambil dari wali kelas (kelas.id_guru) dan mata pelajaran aktif yang diajar guru.
+func (q *kalenderQuery) KelasGuru(ctx context.Context, guruID string) ([]string, error) {
+	var ada bool
+	err := q.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM guru WHERE id = $1 AND delete_at IS NULL)", guruID).Scan(&ada)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("KelasGuru error query guru", "error", err)
+		return nil, fmt.Errorf("select kelas guru failed: %w", err)
+	}
+	if !ada {
+		return nil, pgx.ErrNoRows
+	}
+
+	rows, err := q.db.Query(ctx, `SELECT k.id FROM kelas k WHERE k.id_guru = $1 AND k.delete_at IS NULL
+		UNION
+		SELECT mp.kelas_id FROM mata_pelajaran mp
+		JOIN mata_pelajaran_guru mpg ON mpg.mata_pelajaran_id = mp.id
+		WHERE mpg.id_guru = $1 AND mp.delete_at IS NULL AND mp.kelas_id IS NOT NULL`, guruID)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("KelasGuru error query", "error", err)
+		return nil, fmt.Errorf("select kelas guru failed: %w", err)
+	}
+	kelas, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("select kelas guru failed: %w", err)
+	}
+	return kelas, nil
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/kalender"
+	"go_rest_native_sekolah/helper"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errKalenderNotFound = errors.New("kalender service: Data tidak ditemukan")
+	errKelasNotFound    = errors.New("kalender service: Kelas tidak ditemukan")
+	errGuruNotFound     = errors.New("kalender service: Guru tidak ditemukan")
+)
+
+// maxJudul adalah panjang maksimum judul agenda, sama dengan kolom judul di database.
+const maxJudul = 200
+
+// tahunAjaranRegex memvalidasi format tahun ajaran YYYY/YYYY.
+var tahunAjaranRegex = regexp.MustCompile(`^([0-9]{4})/([0-9]{4})$`)
+
+// kalenderService merepresentasikan service untuk kalender akademik.
+type kalenderService struct {
+	kalenderData kalender.DataKalenderInterface // kalenderData berisi akses ke tabel kalender_akademik
+	hariSekolah  []time.Weekday                 // hariSekolah adalah hari dalam seminggu yang merupakan hari sekolah
+}
+
+// NewServiceKalender membuat service kalender akademik.
+// Parameter hariSekolah menentukan hari yang dihitung sebagai hari sekolah, misalnya Senin sampai Jumat.
+// Jika parameter repo nil atau hariSekolah kosong maka akan terjadi panic.
+func NewServiceKalender(repo kalender.DataKalenderInterface, hariSekolah []time.Weekday) kalender.ServiceKalenderInterface {
+	if repo == nil {
+		panic("kalender service: Nil repository")
+	}
+	if len(hariSekolah) == 0 {
+		panic("kalender service: Hari sekolah kosong")
+	}
+	return &kalenderService{kalenderData: repo, hariSekolah: hariSekolah}
+}
+
+// GetAll implements kalender.ServiceKalenderInterface.
+func (s *kalenderService) GetAll(ctx context.Context, filter kalender.FilterKalender) ([]kalender.KalenderCore, error) {
+	filter.Jenis = strings.ToLower(strings.TrimSpace(filter.Jenis))
+	if filter.Jenis != "" && !slices.Contains(kalender.JenisValid, filter.Jenis) {
+		return nil, fmt.Errorf("validation error: jenis harus salah satu dari %s", strings.Join(kalender.JenisValid, ", "))
+	}
+	var err error
+	if filter.Dari, err = helper.NormalizeDate("dari", filter.Dari, false); err != nil {
+		return nil, err
+	}
+	if filter.Sampai, err = helper.NormalizeDate("sampai", filter.Sampai, false); err != nil {
+		return nil, err
+	}
+
+	result, err := s.kalenderData.SelectAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("kalender service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// GetById implements kalender.ServiceKalenderInterface.
+func (s *kalenderService) GetById(ctx context.Context, id string) (*kalender.KalenderCore, error) {
+	result, err := s.kalenderData.SelectById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errKalenderNotFound
+		}
+		return nil, fmt.Errorf("kalender service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// Insert implements kalender.ServiceKalenderInterface.
+// Tanggal_Selesai yang kosong disamakan dengan Tanggal_Mulai.
+func (s *kalenderService) Insert(ctx context.Context, insert *kalender.KalenderCore) error {
+	if insert == nil {
+		return errors.New("kalender service: input is nil")
+	}
+	if err := s.validasi(ctx, insert); err != nil {
+		return err
+	}
+
+	insert.ID = ""
+	if err := s.kalenderData.Insert(ctx, insert); err != nil {
+		return fmt.Errorf("kalender service: gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+// Update implements kalender.ServiceKalenderInterface.
+// Field kosong memakai nilai lama, kecuali Kelas_ID yang kosong berarti agenda berlaku untuk semua kelas.
+func (s *kalenderService) Update(ctx context.Context, update *kalender.KalenderCore, id string) error {
+	if update == nil {
+		return errors.New("kalender service: input is nil")
+	}
+	existing, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Tolak update jika data sudah diubah sejak client mengambilnya (If-Match)
+	if update.Version != existing.Version {
+		return helper.ErrVersionConflict
+	}
+
+	if strings.TrimSpace(update.Tahun_Ajaran) == "" {
+		update.Tahun_Ajaran = existing.Tahun_Ajaran
+	}
+	if strings.TrimSpace(update.Jenis) == "" {
+		update.Jenis = existing.Jenis
+	}
+	if strings.TrimSpace(update.Judul) == "" {
+		update.Judul = existing.Judul
+	}
+	if strings.TrimSpace(update.Deskripsi) == "" {
+		update.Deskripsi = existing.Deskripsi
+	}
+	if strings.TrimSpace(update.Tanggal_Mulai) == "" {
+		update.Tanggal_Mulai = existing.Tanggal_Mulai
+	}
+	if strings.TrimSpace(update.Tanggal_Selesai) == "" {
+		update.Tanggal_Selesai = existing.Tanggal_Selesai
+	}
+	if err := s.validasi(ctx, update); err != nil {
+		return err
+	}
+
+	if err := s.kalenderData.Update(ctx, update, id); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errKalenderNotFound
+		}
+		return fmt.Errorf("kalender service: gagal update data: %w", err)
+	}
+	return nil
+}
+
+// DeleteById implements kalender.ServiceKalenderInterface.
+func (s *kalenderService) DeleteById(ctx context.Context, id string, version int) error {
+	if err := s.kalenderData.DeleteById(ctx, id, version); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errKalenderNotFound
+		}
+		return fmt.Errorf("kalender service: gagal menghapus data: %w", err)
+	}
+	return nil
+}
+
+// AgendaKelas implements kalender.ServiceKalenderInterface.
+// Agenda untuk semua kelas ikut diambil.
+func (s *kalenderService) AgendaKelas(ctx context.Context, kelasID string) ([]kalender.KalenderCore, error) {
+	if err := s.cekKelas(ctx, kelasID); err != nil {
+		return nil, err
+	}
+	result, err := s.kalenderData.SelectAll(ctx, kalender.FilterKalender{Kelas_ID: []string{kelasID}})
+	if err != nil {
+		return nil, fmt.Errorf("kalender service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// AgendaGuru implements kalender.ServiceKalenderInterface.
+// Guru yang tidak mengajar kelas mana pun tetap mendapat agenda untuk semua kelas.
+func (s *kalenderService) AgendaGuru(ctx context.Context, guruID string) ([]kalender.KalenderCore, error) {
+	kelas, err := s.kalenderData.KelasGuru(ctx, guruID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errGuruNotFound
+		}
+		return nil, fmt.Errorf("kalender service: gagal mengambil kelas guru: %w", err)
+	}
+	if kelas == nil {
+		kelas = []string{}
+	}
+	result, err := s.kalenderData.SelectAll(ctx, kalender.FilterKalender{Kelas_ID: kelas})
+	if err != nil {
+		return nil, fmt.Errorf("kalender service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// HariEfektif implements kalender.ServiceKalenderInterface.
+// Hari efektif adalah hari sekolah yang tidak jatuh pada agenda libur. Jika kelasID kosong hanya libur
+// untuk semua kelas yang dihitung; jika diisi, libur khusus kelas tersebut ikut dikurangkan.
+// Pekan ujian dan kegiatan tetap dihitung sebagai hari efektif.
+func (s *kalenderService) HariEfektif(ctx context.Context, dari, sampai, kelasID string) (*kalender.HariEfektifCore, error) {
+	awal, err := helper.ParseRequiredDate("dari", dari)
+	if err != nil {
+		return nil, err
+	}
+	akhir, err := helper.ParseRequiredDate("sampai", sampai)
+	if err != nil {
+		return nil, err
+	}
+	if akhir.Before(awal) {
+		return nil, errors.New("validation error: sampai tidak boleh sebelum dari")
+	}
+	if int(akhir.Sub(awal).Hours()/24)+1 > kalender.MaxRentangHari {
+		return nil, fmt.Errorf("validation error: rentang tanggal maksimal %d hari", kalender.MaxRentangHari)
+	}
+
+	kelasID = strings.TrimSpace(kelasID)
+	filter := kalender.FilterKalender{
+		Jenis:    kalender.JenisLibur,
+		Kelas_ID: []string{},
+		Dari:     awal.Format(helper.DateLayout),
+		Sampai:   akhir.Format(helper.DateLayout),
+	}
+	if kelasID != "" {
+		if err := s.cekKelas(ctx, kelasID); err != nil {
+			return nil, err
+		}
+		filter.Kelas_ID = []string{kelasID}
+	}
+	libur, err := s.kalenderData.SelectAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("kalender service: gagal mengambil data libur: %w", err)
+	}
+
+	tanggalLibur := map[string]bool{}
+	for _, l := range libur {
+		mulai, errMulai := time.Parse(helper.DateLayout, l.Tanggal_Mulai)
+		selesai, errSelesai := time.Parse(helper.DateLayout, l.Tanggal_Selesai)
+		if errMulai != nil || errSelesai != nil {
+			return nil, fmt.Errorf("kalender service: tanggal libur tidak valid pada agenda %s", l.ID)
+		}
+		for d := mulai; !d.After(selesai); d = d.AddDate(0, 0, 1) {
+			tanggalLibur[d.Format(helper.DateLayout)] = true
+		}
+	}
+
+	result := &kalender.HariEfektifCore{
+		Dari:     filter.Dari,
+		Sampai:   filter.Sampai,
+		Kelas_ID: kelasID,
+		Tanggal:  []string{},
+	}
+	for d := awal; !d.After(akhir); d = d.AddDate(0, 0, 1) {
+		if !slices.Contains(s.hariSekolah, d.Weekday()) {
+			continue
+		}
+		result.Hari_Sekolah++
+		tanggal := d.Format(helper.DateLayout)
+		if tanggalLibur[tanggal] {
+			result.Hari_Libur++
+			continue
+		}
+		result.Tanggal = append(result.Tanggal, tanggal)
+	}
+	result.Hari_Efektif = len(result.Tanggal)
+	return result, nil
+}
+
+// validasi merapikan dan memeriksa agenda sebelum disimpan.
+func (s *kalenderService) validasi(ctx context.Context, k *kalender.KalenderCore) error {
+	k.Tahun_Ajaran = strings.TrimSpace(k.Tahun_Ajaran)
+	match := tahunAjaranRegex.FindStringSubmatch(k.Tahun_Ajaran)
+	if match == nil {
+		return errors.New("validation error: tahun_ajaran harus berformat YYYY/YYYY, misalnya 2026/2027")
+	}
+	tahunAwal, _ := strconv.Atoi(match[1])
+	tahunAkhir, _ := strconv.Atoi(match[2])
+	if tahunAkhir != tahunAwal+1 {
+		return errors.New("validation error: tahun_ajaran harus dua tahun berurutan, misalnya 2026/2027")
+	}
+
+	k.Jenis = strings.ToLower(strings.TrimSpace(k.Jenis))
+	if !slices.Contains(kalender.JenisValid, k.Jenis) {
+		return fmt.Errorf("validation error: jenis harus salah satu dari %s", strings.Join(kalender.JenisValid, ", "))
+	}
+
+	k.Judul = strings.TrimSpace(k.Judul)
+	if k.Judul == "" {
+		return errors.New("validation error: judul harus diisi")
+	}
+	if utf8.RuneCountInString(k.Judul) > maxJudul {
+		return fmt.Errorf("validation error: judul maksimal %d karakter", maxJudul)
+	}
+	k.Deskripsi = strings.TrimSpace(k.Deskripsi)
+
+	mulai, err := helper.ParseRequiredDate("tanggal_mulai", k.Tanggal_Mulai)
+	if err != nil {
+		return err
+	}
+	selesai := mulai
+	if strings.TrimSpace(k.Tanggal_Selesai) != "" {
+		if selesai, err = helper.ParseRequiredDate("tanggal_selesai", k.Tanggal_Selesai); err != nil {
+			return err
+		}
+	}
+	if selesai.Before(mulai) {
+		return errors.New("validation error: tanggal_selesai tidak boleh sebelum tanggal_mulai")
+	}
+	// Agenda harus berada di dalam tahun kalender milik tahun ajarannya
+	if mulai.Year() < tahunAwal || selesai.Year() > tahunAkhir {
+		return fmt.Errorf("validation error: tanggal agenda harus berada di tahun %d atau %d", tahunAwal, tahunAkhir)
+	}
+	k.Tanggal_Mulai = mulai.Format(helper.DateLayout)
+	k.Tanggal_Selesai = selesai.Format(helper.DateLayout)
+
+	k.Kelas_ID = strings.TrimSpace(k.Kelas_ID)
+	if k.Kelas_ID != "" {
+		ada, err := s.kalenderData.KelasAda(ctx, k.Kelas_ID)
+		if err != nil {
+			return fmt.Errorf("kalender service: gagal cek kelas: %w", err)
+		}
+		if !ada {
+			return fmt.Errorf("validation error: kelas '%s' tidak ditemukan", k.Kelas_ID)
+		}
+	}
+	return nil
+}
+
+// cekKelas memastikan kelas aktif ada.
+func (s *kalenderService) cekKelas(ctx context.Context, kelasID string) error {
+	ada, err := s.kalenderData.KelasAda(ctx, kelasID)
+	if err != nil {
+		return fmt.Errorf("kalender service: gagal cek kelas: %w", err)
+	}
+	if !ada {
+		return errKelasNotFound
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"go_rest_native_sekolah/features/kalender"
+	"go_rest_native_sekolah/helper"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock untuk DataKalenderInterface
+type mockDataKalender struct {
+	mock.Mock
+}
+
+func (m *mockDataKalender) SelectAll(ctx context.Context, filter kalender.FilterKalender) ([]kalender.KalenderCore, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]kalender.KalenderCore), args.Error(1)
+}
+
+func (m *mockDataKalender) SelectById(ctx context.Context, id string) (*kalender.KalenderCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*kalender.KalenderCore), args.Error(1)
+}
+
+func (m *mockDataKalender) Insert(ctx context.Context, insert *kalender.KalenderCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataKalender) Update(ctx context.Context, update *kalender.KalenderCore, id string) error {
+	args := m.Called(update, id)
+	return args.Error(0)
+}
+
+func (m *mockDataKalender) DeleteById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataKalender) KelasAda(ctx context.Context, kelasID string) (bool, error) {
+	args := m.Called(kelasID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockDataKalender) KelasGuru(ctx context.Context, guruID string) ([]string, error) {
+	args := m.Called(guruID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// seninSampaiJumat adalah hari sekolah bawaan yang dipakai selama pengujian.
+var seninSampaiJumat = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+func newTestService(repo *mockDataKalender) *kalenderService {
+	return &kalenderService{kalenderData: repo, hariSekolah: seninSampaiJumat}
+}
+
+func TestInsertKalender(t *testing.T) {
+	t.Run("success insert - tanggal selesai mengikuti tanggal mulai", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("Insert", mock.AnythingOfType("*kalender.KalenderCore")).Return(nil).Once()
+
+		data := &kalender.KalenderCore{Tahun_Ajaran: "2026/2027", Jenis: " Libur ", Judul: " Hari Guru ", Tanggal_Mulai: "2026-11-25"}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, kalender.JenisLibur, data.Jenis)
+		assert.Equal(t, "Hari Guru", data.Judul)
+		assert.Equal(t, "2026-11-25", data.Tanggal_Selesai)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success insert - agenda khusus kelas", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("KelasAda", "kelas-1").Return(true, nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*kalender.KalenderCore")).Return(nil).Once()
+
+		data := &kalender.KalenderCore{Tahun_Ajaran: "2026/2027", Jenis: kalender.JenisUjian, Judul: "UAS", Tanggal_Mulai: "2026-12-07", Tanggal_Selesai: "2026-12-11", Kelas_ID: "kelas-1"}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed insert - validasi", func(t *testing.T) {
+		svc := newTestService(new(mockDataKalender))
+		valid := kalender.KalenderCore{Tahun_Ajaran: "2026/2027", Jenis: kalender.JenisLibur, Judul: "Libur", Tanggal_Mulai: "2026-12-21", Tanggal_Selesai: "2027-01-01"}
+
+		cases := []struct {
+			name  string
+			ubah  func(k *kalender.KalenderCore)
+			pesan string
+		}{
+			{"tahun ajaran salah format", func(k *kalender.KalenderCore) { k.Tahun_Ajaran = "2026" }, "validation error: tahun_ajaran harus berformat YYYY/YYYY, misalnya 2026/2027"},
+			{"tahun ajaran tidak berurutan", func(k *kalender.KalenderCore) { k.Tahun_Ajaran = "2026/2028" }, "validation error: tahun_ajaran harus dua tahun berurutan, misalnya 2026/2027"},
+			{"jenis tidak dikenal", func(k *kalender.KalenderCore) { k.Jenis = "rapat" }, "validation error: jenis harus salah satu dari libur, ujian, kegiatan"},
+			{"judul kosong", func(k *kalender.KalenderCore) { k.Judul = " " }, "validation error: judul harus diisi"},
+			{"tanggal mulai kosong", func(k *kalender.KalenderCore) { k.Tanggal_Mulai = "" }, "validation error: tanggal_mulai harus diisi"},
+			{"tanggal salah format", func(k *kalender.KalenderCore) { k.Tanggal_Mulai = "21-12-2026" }, "validation error: tanggal_mulai harus berformat YYYY-MM-DD"},
+			{"selesai sebelum mulai", func(k *kalender.KalenderCore) { k.Tanggal_Selesai = "2026-12-20" }, "validation error: tanggal_selesai tidak boleh sebelum tanggal_mulai"},
+			{"di luar tahun ajaran", func(k *kalender.KalenderCore) { k.Tanggal_Selesai = "2028-01-02" }, "validation error: tanggal agenda harus berada di tahun 2026 atau 2027"},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				data := valid
+				c.ubah(&data)
+				err := svc.Insert(context.Background(), &data)
+				assert.EqualError(t, err, c.pesan)
+			})
+		}
+	})
+
+	t.Run("failed insert - kelas tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("KelasAda", "kelas-x").Return(false, nil).Once()
+
+		data := &kalender.KalenderCore{Tahun_Ajaran: "2026/2027", Jenis: kalender.JenisKegiatan, Judul: "Study tour", Tanggal_Mulai: "2027-02-10", Kelas_ID: "kelas-x"}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.EqualError(t, err, "validation error: kelas 'kelas-x' tidak ditemukan")
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+}
+
+func TestUpdateKalender(t *testing.T) {
+	existing := &kalender.KalenderCore{ID: "k-1", Tahun_Ajaran: "2026/2027", Jenis: kalender.JenisUjian, Judul: "UTS",
+		Tanggal_Mulai: "2026-10-05", Tanggal_Selesai: "2026-10-09", Kelas_ID: "kelas-1", Version: 4}
+
+	t.Run("success update - field kosong memakai nilai lama dan kelas dikosongkan", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("SelectById", "k-1").Return(existing, nil).Once()
+		mockRepo.On("Update", mock.AnythingOfType("*kalender.KalenderCore"), "k-1").Return(nil).Once()
+
+		data := &kalender.KalenderCore{Tanggal_Selesai: "2026-10-10", Version: 4}
+		err := newTestService(mockRepo).Update(context.Background(), data, "k-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "UTS", data.Judul)
+		assert.Equal(t, "2026-10-05", data.Tanggal_Mulai)
+		assert.Equal(t, "2026-10-10", data.Tanggal_Selesai)
+		assert.Empty(t, data.Kelas_ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed update - versi berbeda", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("SelectById", "k-1").Return(existing, nil).Once()
+
+		err := newTestService(mockRepo).Update(context.Background(), &kalender.KalenderCore{Version: 3}, "k-1")
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed update - tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("SelectById", "k-x").Return(nil, pgx.ErrNoRows).Once()
+
+		err := newTestService(mockRepo).Update(context.Background(), &kalender.KalenderCore{}, "k-x")
+
+		assert.ErrorIs(t, err, errKalenderNotFound)
+	})
+}
+
+func TestDeleteKalender(t *testing.T) {
+	t.Run("success delete", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("DeleteById", "k-1", 2).Return(nil).Once()
+
+		assert.NoError(t, newTestService(mockRepo).DeleteById(context.Background(), "k-1", 2))
+	})
+
+	t.Run("failed delete - versi berbeda", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("DeleteById", "k-1", 1).Return(helper.ErrVersionConflict).Once()
+
+		assert.ErrorIs(t, newTestService(mockRepo).DeleteById(context.Background(), "k-1", 1), helper.ErrVersionConflict)
+	})
+}
+
+func TestAgenda(t *testing.T) {
+	t.Run("success agenda kelas", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("KelasAda", "kelas-1").Return(true, nil).Once()
+		mockRepo.On("SelectAll", kalender.FilterKalender{Kelas_ID: []string{"kelas-1"}}).Return([]kalender.KalenderCore{{ID: "k-1"}}, nil).Once()
+
+		result, err := newTestService(mockRepo).AgendaKelas(context.Background(), "kelas-1")
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("failed agenda kelas - kelas tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("KelasAda", "kelas-x").Return(false, nil).Once()
+
+		_, err := newTestService(mockRepo).AgendaKelas(context.Background(), "kelas-x")
+
+		assert.ErrorIs(t, err, errKelasNotFound)
+	})
+
+	t.Run("success agenda guru - tanpa kelas hanya agenda semua kelas", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("KelasGuru", "guru-1").Return([]string(nil), nil).Once()
+		mockRepo.On("SelectAll", kalender.FilterKalender{Kelas_ID: []string{}}).Return([]kalender.KalenderCore{}, nil).Once()
+
+		_, err := newTestService(mockRepo).AgendaGuru(context.Background(), "guru-1")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed agenda guru - guru tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("KelasGuru", "guru-x").Return(nil, pgx.ErrNoRows).Once()
+
+		_, err := newTestService(mockRepo).AgendaGuru(context.Background(), "guru-x")
+
+		assert.ErrorIs(t, err, errGuruNotFound)
+	})
+}
+
+func TestHariEfektif(t *testing.T) {
+	t.Run("success - akhir pekan dan libur tidak dihitung", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		// 2026-12-21 (Senin) sampai 2027-01-03 (Minggu): 10 hari sekolah
+		mockRepo.On("SelectAll", kalender.FilterKalender{Jenis: kalender.JenisLibur, Kelas_ID: []string{}, Dari: "2026-12-21", Sampai: "2027-01-03"}).
+			Return([]kalender.KalenderCore{
+				{ID: "natal", Tanggal_Mulai: "2026-12-24", Tanggal_Selesai: "2026-12-26"},
+				{ID: "tahun-baru", Tanggal_Mulai: "2027-01-01", Tanggal_Selesai: "2027-01-01"},
+			}, nil).Once()
+
+		result, err := newTestService(mockRepo).HariEfektif(context.Background(), "2026-12-21", "2027-01-03", "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 10, result.Hari_Sekolah)
+		assert.Equal(t, 3, result.Hari_Libur)
+		assert.Equal(t, 7, result.Hari_Efektif)
+		assert.Equal(t, []string{"2026-12-21", "2026-12-22", "2026-12-23", "2026-12-28", "2026-12-29", "2026-12-30", "2026-12-31"}, result.Tanggal)
+	})
+
+	t.Run("success - libur khusus kelas ikut dihitung", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("KelasAda", "kelas-1").Return(true, nil).Once()
+		mockRepo.On("SelectAll", kalender.FilterKalender{Jenis: kalender.JenisLibur, Kelas_ID: []string{"kelas-1"}, Dari: "2027-03-01", Sampai: "2027-03-05"}).
+			Return([]kalender.KalenderCore{{ID: "k-1", Tanggal_Mulai: "2027-03-03", Tanggal_Selesai: "2027-03-03", Kelas_ID: "kelas-1"}}, nil).Once()
+
+		result, err := newTestService(mockRepo).HariEfektif(context.Background(), "2027-03-01", "2027-03-05", "kelas-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, result.Hari_Sekolah)
+		assert.Equal(t, 4, result.Hari_Efektif)
+		assert.Equal(t, "kelas-1", result.Kelas_ID)
+	})
+
+	t.Run("success - hari sabtu sebagai hari sekolah", func(t *testing.T) {
+		mockRepo := new(mockDataKalender)
+		mockRepo.On("SelectAll", mock.Anything).Return([]kalender.KalenderCore{}, nil).Once()
+		svc := &kalenderService{kalenderData: mockRepo, hariSekolah: append(seninSampaiJumat, time.Saturday)}
+
+		result, err := svc.HariEfektif(context.Background(), "2027-03-01", "2027-03-07", "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 6, result.Hari_Efektif)
+	})
+
+	t.Run("failed - rentang tidak valid", func(t *testing.T) {
+		svc := newTestService(new(mockDataKalender))
+
+		_, err := svc.HariEfektif(context.Background(), "", "2027-03-07", "")
+		assert.EqualError(t, err, "validation error: dari harus diisi")
+
+		_, err = svc.HariEfektif(context.Background(), "2027-03-07", "2027-03-01", "")
+		assert.EqualError(t, err, "validation error: sampai tidak boleh sebelum dari")
+
+		_, err = svc.HariEfektif(context.Background(), "2026-01-01", "2027-06-30", "")
+		assert.EqualError(t, err, "validation error: rentang tanggal maksimal 366 hari")
+	})
+}
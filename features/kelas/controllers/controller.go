@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"go_rest_native_sekolah/features/kelas"
 	"go_rest_native_sekolah/helper"
-	"log"
 	"net/http"
 	"strings"
 )
@@ -71,7 +70,7 @@ func (kc *KelasController) Insert(w http.ResponseWriter, r *http.Request) error
 	kelasCore = FormatKelasRequestToCore(kelasReq)
 
 	// Insert data kelas ke dalam database menggunakan service kelas.
-	err := kc.KelasService.Insert(&kelasCore)
+	err := kc.KelasService.Insert(r.Context(), &kelasCore)
 	if err != nil {
 		http.Error(w, "gagal menyimpan data kelas", http.StatusInternalServerError)
 		return fmt.Errorf("kelas controller: gagal insert kelas: %v", err)
@@ -94,7 +93,7 @@ func (kc *KelasController) Insert(w http.ResponseWriter, r *http.Request) error
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
 func (kc *KelasController) Kelas(w http.ResponseWriter, r *http.Request) error {
 	// Mengambil semua data kelas dari database melalui service kelas.
-	kelas, err := kc.KelasService.SelectAll()
+	kelas, err := kc.KelasService.SelectAll(r.Context())
 	if err != nil {
 		// Jika terjadi error maka akan mengembalikan error dengan pesan "Error retrieving data".
 		return fmt.Errorf("kelas controller: Error retrieving data: %v", err)
@@ -128,7 +127,7 @@ func (kc *KelasController) GetKelasById(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Panggil service untuk mengambil data kelas berdasarkan ID
-	kelasData, err := kc.KelasService.SelectById(id)
+	kelasData, err := kc.KelasService.SelectById(r.Context(), id)
 	if err != nil {
 		// Jika terjadi error saat mengambil data kelas, kembalikan error dengan pesan yang sesuai.
 		return fmt.Errorf("kelas controller: gagal mengambil data kelas berdasarkan ID: %v", err)
@@ -142,6 +141,8 @@ func (kc *KelasController) GetKelasById(w http.ResponseWriter, r *http.Request)
 	// Jika terjadi error saat encoding maka kembalikan error dengan status 500.
 	response := helper.APIResponse(http.StatusOK, "Success", formatedKelas)
 	w.Header().Set("Content-Type", "application/json")
+	// ETag dipakai client sebagai If-Match saat update atau delete.
+	helper.SetETag(w, kelasData.Version)
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
 		// Jika terjadi error saat encoding response maka kembalikan error dengan pesan "Error encoding response".
@@ -152,6 +153,42 @@ func (kc *KelasController) GetKelasById(w http.ResponseWriter, r *http.Request)
 	return nil
 }
 
+// GetKelasDetail digunakan untuk menghandle HTTP request GET /kelas/{id}/detail.
+// Response berisi data kelas, wali kelas, daftar siswa aktif, jumlah siswa, dan mata pelajaran di kelas.
+// Fungsi ini akan mengembalikan error jika terjadi kesalahan.
+func (kc *KelasController) GetKelasDetail(w http.ResponseWriter, r *http.Request) error {
+	// Ambil ID kelas dari path (/kelas/{id}/detail)
+	id := r.PathValue("id")
+	if id == "" {
+		// Jika ID tidak ditemukan, kembalikan error dengan status 400.
+		http.Error(w, "parameter 'id' wajib diisi", http.StatusBadRequest)
+		return fmt.Errorf("kelas controller: ID kelas tidak ditemukan dalam path")
+	}
+
+	// Panggil service untuk mengambil detail kelas berdasarkan ID
+	detail, err := kc.KelasService.SelectDetail(r.Context(), id)
+	if err != nil {
+		// Jika kelas tidak ditemukan, kirimkan response dengan status 404.
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			http.Error(w, "Data kelas tidak ditemukan", http.StatusNotFound)
+			return err
+		}
+		return fmt.Errorf("kelas controller: gagal mengambil detail kelas: %v", err)
+	}
+
+	// Buat response API berisi detail kelas yang telah di-format.
+	response := helper.APIResponse(http.StatusOK, "Success", FormatKelasDetail(*detail))
+	w.Header().Set("Content-Type", "application/json")
+	// ETag dipakai client sebagai If-Match saat update atau delete.
+	helper.SetETag(w, detail.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("kelas controller: Error encoding response: %v", err)
+	}
+
+	// Jika tidak ada error maka kembalikan nil.
+	return nil
+}
+
 // UpdateKelas digunakan untuk menghandle HTTP request untuk memperbarui data kelas berdasarkan ID yang dikirimkan.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
 func (kc *KelasController) UpdateKelas(w http.ResponseWriter, r *http.Request) error {
@@ -164,25 +201,38 @@ func (kc *KelasController) UpdateKelas(w http.ResponseWriter, r *http.Request) e
 	}
 
 	// Log permintaan update untuk ID tertentu.
-	log.Printf("Request update kelas dengan ID: %s", idStr)
+	helper.LoggerFromContext(r.Context()).Debug("Request update kelas", "id", idStr)
+
+	// Ambil versi data dari header If-Match agar update tidak menimpa perubahan orang lain.
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
 
 	// Dekode request body menjadi objek KelasFormatter.
 	var kelasReq KelasFormatter
-	err := json.NewDecoder(r.Body).Decode(&kelasReq)
+	err = json.NewDecoder(r.Body).Decode(&kelasReq)
 	if err != nil {
 		// Jika terjadi error saat decoding maka kembalikan error dengan status 400.
-		log.Printf("Error decoding request body: %v", err)
+		helper.LoggerFromContext(r.Context()).Error("Error decoding request body", "error", err)
 		http.Error(w, "Gagal memproses data input", http.StatusBadRequest)
 		return err
 	}
 
 	// Format data KelasFormatter menjadi objek KelasCore.
 	updateKelas := FormatKelasRequestToCore(kelasReq)
+	updateKelas.Version = version
 
 	// Panggil service untuk memperbarui data kelas berdasarkan ID.
-	err = kc.KelasService.Update(&updateKelas, idStr)
+	err = kc.KelasService.Update(r.Context(), &updateKelas, idStr)
 	if err != nil {
 		// Jika terjadi error saat memperbarui data kelas maka kembalikan error sesuai dengan status error.
+		// Jika kelas sudah diubah request lain, kirimkan response dengan status 412.
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		}
 		// Jika terjadi error validasi, kirimkan response dengan status 400.
 		if strings.Contains(err.Error(), "validation") {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -201,7 +251,7 @@ func (kc *KelasController) UpdateKelas(w http.ResponseWriter, r *http.Request) e
 	}
 
 	// Ambil data kelas yang telah diupdate dari database.
-	kelasUpdate, err := kc.KelasService.SelectById(idStr)
+	kelasUpdate, err := kc.KelasService.SelectById(r.Context(), idStr)
 	if err != nil {
 		// Jika terjadi error saat mengambil data kelas maka kembalikan error dengan status 500.
 		http.Error(w, "gagal mengambil data kelas", http.StatusInternalServerError)
@@ -216,6 +266,7 @@ func (kc *KelasController) UpdateKelas(w http.ResponseWriter, r *http.Request) e
 	// Jika terjadi error saat encoding maka kembalikan error dengan status 500.
 	respon := helper.APIResponse(http.StatusOK, "success update kelas", formatKelas)
 	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, kelasUpdate.Version)
 	err = json.NewEncoder(w).Encode(respon)
 	if err != nil {
 		return fmt.Errorf("kelas controller: error encoding response: %v", err)
@@ -236,10 +287,43 @@ func (kc *KelasController) DeleteKelas(w http.ResponseWriter, r *http.Request) e
 		return fmt.Errorf("kelas controller: ID kelas tidak ditemukan dalam query parameter")
 	}
 
+	// Ambil versi data dari header If-Match agar tidak menghapus kelas yang sudah diubah orang lain.
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
+
+	// Baca policy hapus dari query parameter (?policy=block|reassign|cascade&target=)
+	opts, err := helper.DeleteOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
 	// Panggil service untuk menghapus data kelas berdasarkan ID
 	// Jika terjadi error saat menghapus data kelas, kembalikan error dengan pesan yang sesuai.
-	err := kc.KelasService.DeleteById(id)
+	err = kc.KelasService.DeleteById(r.Context(), id, version, opts)
 	if err != nil {
+		// Jika kelas sudah diubah request lain, kirimkan response dengan status 412.
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		}
+		// Jika kelas masih dipakai dan policy block, kirimkan daftar data yang merujuk dengan status 409.
+		var blocked *helper.DeleteBlockedError
+		if errors.As(err, &blocked) {
+			helper.JSONResponse(w, http.StatusConflict, helper.APIResponse(http.StatusConflict, "kelas masih dipakai: "+blocked.Error(), blocked.Impact))
+			return nil
+		}
+		if strings.Contains(strings.ToLower(err.Error()), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		if strings.Contains(err.Error(), "no rows affected") {
+			http.Error(w, "ID kelas tidak ditemukan", http.StatusNotFound)
+			return err
+		}
 		http.Error(w, "gagal menghapus data kelas", http.StatusInternalServerError)
 		return fmt.Errorf("kelas controller: gagal menghapus data kelas: %v", err)
 	}
@@ -257,3 +341,72 @@ func (kc *KelasController) DeleteKelas(w http.ResponseWriter, r *http.Request) e
 	// Jika tidak ada error maka kembalikan nil.
 	return nil
 }
+
+// PreviewDeleteKelas digunakan untuk menghandle HTTP request dry-run penghapusan kelas.
+// Response berisi siswa dan mata pelajaran yang akan terdampak dengan policy yang dipilih,
+// tanpa menghapus data apa pun.
+// Fungsi ini akan mengembalikan error jika terjadi kesalahan.
+func (kc *KelasController) PreviewDeleteKelas(w http.ResponseWriter, r *http.Request) error {
+	// Ambil ID kelas dari parameter query (?id=)
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "parameter 'id' wajib diisi", http.StatusBadRequest)
+		return fmt.Errorf("kelas controller: ID kelas tidak ditemukan dalam query parameter")
+	}
+
+	// Baca policy hapus dari query parameter (?policy=block|reassign|cascade&target=)
+	opts, err := helper.DeleteOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	impact, err := kc.KelasService.PreviewDelete(r.Context(), id, opts)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			http.Error(w, "ID kelas tidak ditemukan", http.StatusNotFound)
+			return err
+		}
+		http.Error(w, "gagal mengambil data terdampak", http.StatusInternalServerError)
+		return fmt.Errorf("kelas controller: gagal mengambil data terdampak: %v", err)
+	}
+
+	respon := helper.APIResponse(http.StatusOK, "dampak penghapusan kelas id: "+id, impact)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(respon); err != nil {
+		return fmt.Errorf("kelas controller: error encoding response: %v", err)
+	}
+
+	return nil
+}
+
+// BulkKelas digunakan untuk menghandle HTTP request POST yang menjalankan banyak operasi
+// create, update, dan delete kelas sekaligus. Operasi delete dapat memilih policy dan target.
+// Response berisi hasil per operasi. Fungsi ini akan mengembalikan error jika terjadi kesalahan.
+func (kc *KelasController) BulkKelas(w http.ResponseWriter, r *http.Request) error {
+	// Cek controller dan service tidak nil.
+	if kc == nil || kc.KelasService == nil {
+		return errors.New("Nil controller")
+	}
+
+	// Baca dan validasi daftar operasi dari body JSON.
+	req, err := helper.DecodeBulkRequest[KelasFormatter](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	// Ubah data setiap operasi ke core lalu jalankan di service.
+	result, err := kc.KelasService.Bulk(r.Context(), req.Mode, helper.MapBulkOperations(req.Operations, FormatKelasRequestToCore))
+	if err != nil {
+		return fmt.Errorf("kelas controller: gagal menjalankan bulk: %v", err)
+	}
+
+	// Kirim hasil per operasi dengan status sesuai hasil bulk.
+	helper.JSONResponse(w, result.StatusCode(), helper.APIResponse(result.StatusCode(), result.Message(), result))
+	return nil
+}
@@ -16,6 +16,8 @@ type KelasFormatter struct {
 	ID_Guru string `json:"id_guru"`
 	// Nama_Guru adalah nama guru yang mengajar di kelas ini
 	Nama_Guru string `json:"nama_guru"`
+	// Version adalah versi data yang juga dikirim sebagai ETag
+	Version int `json:"version"`
 }
 
 // FormatKelasList digunakan untuk mengubah slice KelasCore menjadi slice KelasFormatter.
@@ -36,6 +38,8 @@ func FormatKelasList(cores []kelas.KelasCore) []KelasFormatter {
 			ID_Guru: core.ID_Guru,
 			// Nama_Guru adalah nama guru yang mengajar di kelas ini
 			Nama_Guru: core.Nama_Guru,
+			// Version adalah versi data yang juga dikirim sebagai ETag
+			Version: core.Version,
 		})
 	}
 	// Mengembalikan slice KelasFormatter yang telah di format
@@ -62,3 +66,101 @@ func FormatKelasRequestToCore(req KelasFormatter) kelas.KelasCore {
 	// Mengembalikan objek KelasCore yang telah di format
 	return core
 }
+
+// KelasDetailFormatter digunakan untuk memformat detail kelas agar sesuai dengan kebutuhan response API.
+// Struktur ini berisi data kelas, wali kelas, daftar siswa, jumlah siswa, dan mata pelajaran di kelas.
+type KelasDetailFormatter struct {
+	// ID adalah ID unik untuk setiap kelas
+	ID string `json:"id"`
+	// Kelas adalah nama kelas
+	Kelas string `json:"kelas"`
+	// Version adalah versi data yang juga dikirim sebagai ETag
+	Version int `json:"version"`
+	// WaliKelas adalah guru wali kelas, null jika kelas belum punya wali kelas
+	WaliKelas *WaliKelasFormatter `json:"wali_kelas"`
+	// JumlahSiswa adalah jumlah siswa aktif di kelas
+	JumlahSiswa int `json:"jumlah_siswa"`
+	// Siswa adalah daftar siswa aktif di kelas
+	Siswa []SiswaKelasFormatter `json:"siswa"`
+	// MataPelajaran adalah daftar mata pelajaran di kelas beserta gurunya
+	MataPelajaran []MapelKelasFormatter `json:"mata_pelajaran"`
+}
+
+// WaliKelasFormatter digunakan untuk memformat data wali kelas di detail kelas.
+type WaliKelasFormatter struct {
+	ID     string `json:"id"`
+	Nama   string `json:"nama"`
+	Email  string `json:"email"`
+	Alamat string `json:"alamat"`
+}
+
+// SiswaKelasFormatter digunakan untuk memformat data siswa di detail kelas.
+type SiswaKelasFormatter struct {
+	ID     string `json:"id"`
+	Nama   string `json:"nama"`
+	Email  string `json:"email"`
+	Alamat string `json:"alamat"`
+}
+
+// MapelKelasFormatter digunakan untuk memformat data mata pelajaran di detail kelas.
+// ID_Guru dan Guru berisi guru utama, sedangkan Pengajar berisi semua guru pengajar.
+type MapelKelasFormatter struct {
+	ID             string                   `json:"id"`
+	Nama_Pelajaran string                   `json:"mata_pelajaran"`
+	ID_Guru        string                   `json:"id_guru"`
+	Guru           string                   `json:"guru"`
+	Deskripsi      string                   `json:"deskripsi"`
+	Pengajar       []PengajarKelasFormatter `json:"pengajar"`
+}
+
+// PengajarKelasFormatter digunakan untuk memformat satu guru pengajar mata pelajaran di detail kelas.
+type PengajarKelasFormatter struct {
+	ID_Guru string `json:"id_guru"`
+	Nama    string `json:"nama"`
+	Utama   bool   `json:"utama"`
+}
+
+// FormatKelasDetail digunakan untuk mengubah objek KelasDetail menjadi objek KelasDetailFormatter.
+// Daftar siswa dan mata pelajaran selalu berupa array (bukan null) walaupun kosong.
+func FormatKelasDetail(detail kelas.KelasDetail) KelasDetailFormatter {
+	formatted := KelasDetailFormatter{
+		ID:            detail.ID,
+		Kelas:         detail.Kelas,
+		Version:       detail.Version,
+		JumlahSiswa:   detail.JumlahSiswa,
+		Siswa:         make([]SiswaKelasFormatter, 0, len(detail.Siswa)),
+		MataPelajaran: make([]MapelKelasFormatter, 0, len(detail.MataPelajaran)),
+	}
+	// Wali kelas hanya diisi jika kelas punya guru wali kelas
+	if detail.WaliKelas != nil {
+		formatted.WaliKelas = &WaliKelasFormatter{
+			ID:     detail.WaliKelas.ID,
+			Nama:   detail.WaliKelas.Nama,
+			Email:  detail.WaliKelas.Email,
+			Alamat: detail.WaliKelas.Alamat,
+		}
+	}
+	for _, s := range detail.Siswa {
+		formatted.Siswa = append(formatted.Siswa, SiswaKelasFormatter{
+			ID:     s.ID,
+			Nama:   s.Nama,
+			Email:  s.Email,
+			Alamat: s.Alamat,
+		})
+	}
+	for _, m := range detail.MataPelajaran {
+		mapel := MapelKelasFormatter{
+			ID:             m.ID,
+			Nama_Pelajaran: m.Nama_Pelajaran,
+			ID_Guru:        m.ID_Guru,
+			Guru:           m.Nama_Guru,
+			Deskripsi:      m.Deskripsi,
+			Pengajar:       make([]PengajarKelasFormatter, 0, len(m.Pengajar)),
+		}
+		for _, p := range m.Pengajar {
+			mapel.Pengajar = append(mapel.Pengajar, PengajarKelasFormatter{ID_Guru: p.ID, Nama: p.Nama, Utama: p.Utama})
+		}
+		formatted.MataPelajaran = append(formatted.MataPelajaran, mapel)
+	}
+	return formatted
+}
@@ -1,5 +1,10 @@
 package kelas
 
+import (
+	"context"
+	"go_rest_native_sekolah/helper"
+)
+
 // KelasCore adalah struct yang merepresentasikan data kelas di database
 // Struktur ini digunakan untuk menyimpan informasi terkait kelas
 // seperti ID kelas, nama kelas, ID guru, nama guru, waktu terakhir diperbarui, dan waktu dihapus
@@ -10,6 +15,50 @@ type KelasCore struct {
 	Nama_Guru string `json:"nama_guru"` // Nama guru
 	Update_At string `json:"update_at"` // Waktu terakhir diperbarui
 	Delete_At string `json:"delete_at"` // Waktu dihapus
+	Version   int    `json:"version"`   // Versi data untuk optimistic concurrency, dikirim sebagai ETag
+}
+
+// KelasDetail adalah struct yang berisi data lengkap satu kelas
+// yaitu data kelas, wali kelas, daftar siswa aktif, dan mata pelajaran yang diajarkan di kelas tersebut
+type KelasDetail struct {
+	KelasCore
+	WaliKelas     *WaliKelas   // Guru wali kelas, nil jika kelas belum punya wali kelas
+	Siswa         []SiswaKelas // Siswa aktif di kelas, diurutkan berdasarkan nama
+	JumlahSiswa   int          // Jumlah siswa aktif di kelas
+	MataPelajaran []MapelKelas // Mata pelajaran aktif di kelas beserta guru pengajarnya
+}
+
+// WaliKelas adalah struct yang berisi data guru wali kelas
+type WaliKelas struct {
+	ID     string // ID guru
+	Nama   string // Nama guru
+	Email  string // Email guru
+	Alamat string // Alamat guru
+}
+
+// SiswaKelas adalah struct yang berisi data siswa di dalam detail kelas
+type SiswaKelas struct {
+	ID     string // ID siswa
+	Nama   string // Nama siswa
+	Email  string // Email siswa
+	Alamat string // Alamat siswa
+}
+
+// MapelKelas adalah struct yang berisi data mata pelajaran di dalam detail kelas
+type MapelKelas struct {
+	ID             string          // ID mata pelajaran
+	Nama_Pelajaran string          // Nama mata pelajaran
+	ID_Guru        string          // ID guru utama, kosong jika belum ada
+	Nama_Guru      string          // Nama guru utama, kosong jika belum ada
+	Deskripsi      string          // Deskripsi mata pelajaran
+	Pengajar       []PengajarKelas // Semua guru aktif yang mengajar (team teaching), guru utama lebih dulu
+}
+
+// PengajarKelas adalah struct yang berisi satu guru pengajar mata pelajaran di detail kelas
+type PengajarKelas struct {
+	ID    string // ID guru
+	Nama  string // Nama guru
+	Utama bool   // true untuk guru utama
 }
 
 // DataKelasInterface adalah interface yang berhubungan dengan data kelas
@@ -19,20 +68,37 @@ type DataKelasInterface interface {
 	// SelectAll digunakan untuk mengambil semua data kelas di database
 	// Fungsi ini mengembalikan slice KelasCore yang berisi data kelas
 	// Jika terjadi error maka fungsi ini akan mengembalikan error
-	SelectAll() ([]KelasCore, error)
+	SelectAll(ctx context.Context) ([]KelasCore, error)
 	// SelectById digunakan untuk mengambil data kelas berdasarkan ID yang diberikan
 	// Fungsi ini akan mengembalikan objek KelasCore yang sesuai dengan ID tersebut
 	// dan error jika terjadi kesalahan dalam pengambilan data
-	SelectById(id string) (*KelasCore, error)
+	SelectById(ctx context.Context, id string) (*KelasCore, error)
 	// Insert digunakan untuk menginsert data kelas ke dalam database
 	// Fungsi ini mengembalikan error jika terjadi kesalahan
-	Insert(insert *KelasCore) error
+	Insert(ctx context.Context, insert *KelasCore) error
 	// Update digunakan untuk mengupdate data kelas berdasarkan ID yang diberikan
 	// Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses update
-	Update(insert *KelasCore, id string) error
-	// DeleteById digunakan untuk menghapus data kelas berdasarkan ID yang diberikan
-	// Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses hapus
-	DeleteById(id string) error
+	Update(ctx context.Context, insert *KelasCore, id string) error
+	// DeleteById digunakan untuk menghapus data kelas berdasarkan ID jika versinya masih sama dengan version
+	// Fungsi ini akan mengembalikan helper.ErrVersionConflict jika versi sudah berubah
+	DeleteById(ctx context.Context, id string, version int) error
+	// LockById digunakan untuk memastikan kelas aktif ada dan menguncinya sampai transaksi selesai
+	// Fungsi ini mengembalikan pgx.ErrNoRows jika kelas tidak ditemukan
+	LockById(ctx context.Context, id string) error
+	// ListDependents digunakan untuk mengambil siswa dan mata pelajaran aktif yang merujuk ke kelas,
+	// serta tugas, jadwal mengajar, dan jadwal ujian mata pelajaran tersebut sebagai dependents tidak langsung
+	// Fungsi ini mengembalikan error jika terjadi kesalahan
+	ListDependents(ctx context.Context, id string) ([]helper.Dependent, error)
+	// ReassignDependents digunakan untuk memindahkan siswa dan mata pelajaran ke kelas targetID
+	// Fungsi ini mengembalikan error jika terjadi kesalahan
+	ReassignDependents(ctx context.Context, id, targetID string) error
+	// CascadeDelete digunakan untuk ikut menghapus (soft delete) siswa dan mata pelajaran di kelas,
+	// beserta tugas, jadwal mengajar, dan jadwal ujian mata pelajaran tersebut
+	// Fungsi ini mengembalikan error jika terjadi kesalahan
+	CascadeDelete(ctx context.Context, id string) error
+	// SelectDetail digunakan untuk mengambil data kelas beserta wali kelas, siswa, dan mata pelajarannya
+	// Fungsi ini mengembalikan pgx.ErrNoRows jika kelas tidak ditemukan
+	SelectDetail(ctx context.Context, id string) (*KelasDetail, error)
 }
 
 // ServiceKelasInterface adalah interface yang berhubungan dengan service kelas
@@ -42,18 +108,29 @@ type ServiceKelasInterface interface {
 	// SelectAll digunakan untuk mengambil semua data kelas di database
 	// Fungsi ini mengembalikan slice KelasCore yang berisi data kelas
 	// Jika terjadi error maka fungsi ini akan mengembalikan error
-	SelectAll() ([]KelasCore, error)
+	SelectAll(ctx context.Context) ([]KelasCore, error)
 	// SelectById digunakan untuk mengambil data kelas berdasarkan ID yang diberikan
 	// Fungsi ini akan mengembalikan objek KelasCore yang sesuai dengan ID tersebut
 	// dan error jika terjadi kesalahan dalam pengambilan data
-	SelectById(id string) (*KelasCore, error)
+	SelectById(ctx context.Context, id string) (*KelasCore, error)
 	// Insert digunakan untuk menginsert data kelas ke dalam database
 	// Fungsi ini mengembalikan error jika terjadi kesalahan
-	Insert(insert *KelasCore) error
+	Insert(ctx context.Context, insert *KelasCore) error
 	// Update digunakan untuk mengupdate data kelas berdasarkan ID yang diberikan
 	// Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses update
-	Update(insert *KelasCore, id string) error
+	Update(ctx context.Context, insert *KelasCore, id string) error
 	// DeleteById digunakan untuk menghapus data kelas berdasarkan ID yang diberikan
-	// Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses hapus
-	DeleteById(id string) error
+	// Siswa dan mata pelajaran di kelas tersebut ditangani sesuai opts.Policy
+	// Fungsi ini akan mengembalikan *helper.DeleteBlockedError jika penghapusan ditolak
+	// dan helper.ErrVersionConflict jika version berbeda dengan versi kelas saat ini
+	DeleteById(ctx context.Context, id string, version int, opts helper.DeleteOptions) error
+	// PreviewDelete digunakan untuk melihat data yang terdampak jika kelas dihapus tanpa menghapusnya
+	// Fungsi ini akan mengembalikan error jika terjadi kesalahan
+	PreviewDelete(ctx context.Context, id string, opts helper.DeleteOptions) (*helper.DeleteImpact, error)
+	// SelectDetail digunakan untuk mengambil data kelas beserta wali kelas, siswa, dan mata pelajarannya
+	// Fungsi ini akan mengembalikan error jika kelas tidak ditemukan atau terjadi kesalahan
+	SelectDetail(ctx context.Context, id string) (*KelasDetail, error)
+	// Bulk digunakan untuk menjalankan banyak operasi create, update, dan delete kelas sekaligus sesuai mode
+	// Fungsi ini mengembalikan hasil per operasi dan error jika transaksi gagal
+	Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[KelasCore]) (*helper.BulkResult, error)
 }
@@ -26,6 +26,9 @@ type Kelas struct {
 
 	// Delete_At adalah waktu ketika kelas ini dihapus, dapat bernilai null jika belum dihapus.
 	Delete_At string `json:"delete_at"`
+
+	// Version adalah versi data yang bertambah setiap kali kelas diubah atau dihapus.
+	Version int `json:"version"`
 }
 
 // TableName digunakan untuk mengembalikan nama tabel yang digunakan dalam database.
@@ -63,5 +66,6 @@ func FormatterResponse(res Kelas) kelas.KelasCore {
 		Nama_Guru: res.Nama_Guru, // Mengisi field Nama_Guru dengan nama guru dari objek Kelas
 		Update_At: res.Update_At, // Mengisi field Update_At dengan waktu terakhir kelas diupdate dari objek Kelas
 		Delete_At: res.Delete_At, // Mengisi field Delete_At dengan waktu ketika kelas dihapus dari objek Kelas
+		Version:   res.Version,   // Mengisi field Version dengan versi data dari objek Kelas
 	}
 }
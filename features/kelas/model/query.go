@@ -6,11 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"go_rest_native_sekolah/features/kelas"
-	"log"
+	"go_rest_native_sekolah/helper"
 	"strings"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 )
 
 // kelasQuery merepresentasikan query yang berhubungan dengan tabel kelas.
@@ -18,13 +18,14 @@ import (
 // Fungsi ini digunakan untuk menghandle query ke database.
 type kelasQuery struct {
 	// db berisi koneksi database yang digunakan untuk menghandle query.
-	db *pgxpool.Pool
+	db helper.DBTX
 }
 
 // NewDataKelas membuat objek kelasQuery yang berisi koneksi database.
 // Fungsi ini digunakan untuk menginisialisasi objek kelasQuery yang berisi koneksi database.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
 // Jika parameter db nil maka akan terjadi panic.
-func NewDataKelas(db *pgxpool.Pool) kelas.DataKelasInterface {
+func NewDataKelas(db helper.DBTX) kelas.DataKelasInterface {
 	// Jika db nil maka akan terjadi panic
 	if db == nil {
 		panic("Nil database")
@@ -36,7 +37,7 @@ func NewDataKelas(db *pgxpool.Pool) kelas.DataKelasInterface {
 // Insert implements kelas.DataKelasInterface.
 // Fungsi ini digunakan untuk menginsert data kelas ke dalam database.
 // Fungsi ini mengembalikan error jika terjadi kesalahan.
-func (k *kelasQuery) Insert(insert *kelas.KelasCore) error {
+func (k *kelasQuery) Insert(ctx context.Context, insert *kelas.KelasCore) error {
 	// Jika parameter db nil maka akan terjadi panic.
 	if k.db == nil {
 		return errors.New("kelas model: Nil database connection")
@@ -62,9 +63,9 @@ func (k *kelasQuery) Insert(insert *kelas.KelasCore) error {
 	case insert.Nama_Guru != "" && insert.ID_Guru == "":
 		// Jika hanya Nama_Guru diisi → cari ID-nya
 		var idGuru string
-		err := k.db.QueryRow(context.Background(), "SELECT id FROM guru WHERE nama = $1", insert.Nama_Guru).Scan(&idGuru)
+		err := k.db.QueryRow(ctx, "SELECT id FROM guru WHERE nama = $1", insert.Nama_Guru).Scan(&idGuru)
 		if err != nil {
-			log.Printf("InsertKelas: nama guru '%s' tidak ditemukan", insert.Nama_Guru)
+			helper.LoggerFromContext(ctx).Warn("InsertKelas: nama guru tidak ditemukan", "nama_guru", insert.Nama_Guru)
 			return fmt.Errorf("guru dengan nama '%s' tidak ditemukan", insert.Nama_Guru)
 		}
 		insert.ID_Guru = idGuru
@@ -72,9 +73,9 @@ func (k *kelasQuery) Insert(insert *kelas.KelasCore) error {
 	case insert.ID_Guru != "" && insert.Nama_Guru == "":
 		// Jika hanya ID_Guru diisi → cari nama-nya
 		var namaGuru string
-		err := k.db.QueryRow(context.Background(), "SELECT nama FROM guru WHERE id = $1", insert.ID_Guru).Scan(&namaGuru)
+		err := k.db.QueryRow(ctx, "SELECT nama FROM guru WHERE id = $1", insert.ID_Guru).Scan(&namaGuru)
 		if err != nil {
-			log.Printf("InsertKelas: ID guru '%s' tidak ditemukan", insert.ID_Guru)
+			helper.LoggerFromContext(ctx).Warn("InsertKelas: ID guru tidak ditemukan", "id_guru", insert.ID_Guru)
 			return fmt.Errorf("guru dengan ID '%s' tidak ditemukan", insert.ID_Guru)
 		}
 		insert.Nama_Guru = namaGuru
@@ -82,13 +83,13 @@ func (k *kelasQuery) Insert(insert *kelas.KelasCore) error {
 	case insert.ID_Guru != "" && insert.Nama_Guru != "":
 		// Jika keduanya diisi → validasi apakah cocok
 		var existingName string
-		err := k.db.QueryRow(context.Background(), "SELECT nama FROM guru WHERE id = $1", insert.ID_Guru).Scan(&existingName)
+		err := k.db.QueryRow(ctx, "SELECT nama FROM guru WHERE id = $1", insert.ID_Guru).Scan(&existingName)
 		if err != nil {
-			log.Printf("InsertKelas: ID guru '%s' tidak ditemukan", insert.ID_Guru)
+			helper.LoggerFromContext(ctx).Warn("InsertKelas: ID guru tidak ditemukan", "id_guru", insert.ID_Guru)
 			return fmt.Errorf("guru dengan ID '%s' tidak ditemukan", insert.ID_Guru)
 		}
 		if strings.TrimSpace(existingName) != strings.TrimSpace(insert.Nama_Guru) {
-			log.Printf("InsertKelas: Nama guru tidak cocok dengan ID guru. Dapat: '%s', seharusnya: '%s'", insert.Nama_Guru, existingName)
+			helper.LoggerFromContext(ctx).Warn("InsertKelas: Nama guru tidak cocok dengan ID guru", "nama_guru", insert.Nama_Guru, "seharusnya", existingName)
 			return fmt.Errorf("nama guru '%s' tidak cocok dengan ID guru '%s'", insert.Nama_Guru, insert.ID_Guru)
 		}
 	}
@@ -103,13 +104,13 @@ func (k *kelasQuery) Insert(insert *kelas.KelasCore) error {
 
 	// --- Jalankan query INSERT ---
 	query := `INSERT INTO kelas (id, kelas, id_guru) VALUES ($1, $2, $3)`
-	_, err := k.db.Exec(context.Background(), query,
+	_, err := k.db.Exec(ctx, query,
 		insert.ID,
 		insert.Kelas,
 		idGuruParam,
 	)
 	if err != nil {
-		log.Printf("InsertKelas error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("InsertKelas error exec", "error", err)
 		return fmt.Errorf("insert failed: %w", err)
 	}
 
@@ -119,7 +120,7 @@ func (k *kelasQuery) Insert(insert *kelas.KelasCore) error {
 // SelectAll digunakan untuk mengambil semua data kelas dari database.
 // Fungsi ini mengembalikan slice kelas.KelasCore yang berisi data kelas.
 // Jika terjadi error maka fungsi ini akan mengembalikan error.
-func (k *kelasQuery) SelectAll() ([]kelas.KelasCore, error) {
+func (k *kelasQuery) SelectAll(ctx context.Context) ([]kelas.KelasCore, error) {
 	// Validasi koneksi database
 	if k.db == nil {
 		// Jika koneksi database nil, kembalikan error
@@ -129,7 +130,7 @@ func (k *kelasQuery) SelectAll() ([]kelas.KelasCore, error) {
 	// Query untuk mengambil semua data kelas dan nama guru yang terkait
 	query := `
 		SELECT 
-			k.id, k.kelas, k.id_guru, g.nama AS nama_guru
+			k.id, k.kelas, k.id_guru, g.nama AS nama_guru, k.version
 		FROM 
 			kelas k
 		LEFT JOIN 
@@ -139,10 +140,10 @@ func (k *kelasQuery) SelectAll() ([]kelas.KelasCore, error) {
 	`
 
 	// Jalankan query dan simpan hasilnya dalam rows
-	rows, err := k.db.Query(context.Background(), query)
+	rows, err := k.db.Query(ctx, query)
 	if err != nil {
 		// Jika terjadi error saat eksekusi query, log error dan kembalikan
-		log.Printf("SelectAll error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("SelectAll error exec", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
 	defer rows.Close() // Pastikan rows ditutup setelah selesai digunakan
@@ -161,12 +162,12 @@ func (k *kelasQuery) SelectAll() ([]kelas.KelasCore, error) {
 		// Pindai setiap baris ke dalam variabel kelas
 		// Fungsi Scan digunakan untuk memindai setiap baris yang diiterasi
 		// dan menyimpannya dalam variabel kelas
-		err := rows.Scan(&kelas.ID, &kelas.Kelas, &idGuru, &namaGuru)
+		err := rows.Scan(&kelas.ID, &kelas.Kelas, &idGuru, &namaGuru, &kelas.Version)
 		if err != nil {
 			// Jika terjadi error saat scan, log error dan kembalikan
 			// Fungsi log.Printf digunakan untuk mencatat log error
 			// dan mengembalikan error
-			log.Printf("SelectAll error scan: %v", err)
+			helper.LoggerFromContext(ctx).Error("SelectAll error scan", "error", err)
 			return nil, fmt.Errorf("select failed: %w", err)
 		}
 
@@ -190,12 +191,12 @@ func (k *kelasQuery) SelectAll() ([]kelas.KelasCore, error) {
 	// Cek error setelah iterasi
 	if err := rows.Err(); err != nil {
 		// Jika terjadi error pada rows, log error dan kembalikan
-		log.Printf("SelectAll error rows: %v", err)
+		helper.LoggerFromContext(ctx).Error("SelectAll error rows", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
 
 	// Log jumlah kelas yang berhasil diambil
-	log.Printf("Successfully fetched %d kelas from database", len(result))
+	helper.LoggerFromContext(ctx).Info("Successfully fetched kelas from database", "count", len(result))
 	// Kembalikan hasil dalam bentuk slice kelas.KelasCore
 	return result, nil
 }
@@ -203,7 +204,7 @@ func (k *kelasQuery) SelectAll() ([]kelas.KelasCore, error) {
 // SelectById digunakan untuk mengambil data kelas berdasarkan ID yang diberikan.
 // Fungsi ini akan mengembalikan objek kelas.KelasCore yang sesuai dengan ID tersebut
 // dan error jika terjadi kesalahan dalam pengambilan data.
-func (k *kelasQuery) SelectById(id string) (*kelas.KelasCore, error) {
+func (k *kelasQuery) SelectById(ctx context.Context, id string) (*kelas.KelasCore, error) {
 	// Memeriksa apakah koneksi ke database ada atau tidak
 	if k.db == nil {
 		// Jika koneksi database nil, kembalikan error
@@ -212,7 +213,7 @@ func (k *kelasQuery) SelectById(id string) (*kelas.KelasCore, error) {
 
 	// Query SQL untuk mengambil data kelas berdasarkan ID dan memastikan data belum dihapus
 	query := `SELECT 
-			k.id, k.kelas, k.id_guru, g.nama AS nama_guru
+			k.id, k.kelas, k.id_guru, g.nama AS nama_guru, k.version
 		FROM 
 			kelas k
 		LEFT JOIN 
@@ -223,15 +224,16 @@ func (k *kelasQuery) SelectById(id string) (*kelas.KelasCore, error) {
 	var kelas kelas.KelasCore // Deklarasi variabel kelas untuk menyimpan hasil query
 
 	// Eksekusi query dan scan hasilnya ke dalam variabel kelas
-	err := k.db.QueryRow(context.Background(), query, id).Scan(
+	err := k.db.QueryRow(ctx, query, id).Scan(
 		&kelas.ID,        // Scan kolom id ke dalam kelas.ID
 		&kelas.Kelas,     // Scan kolom kelas ke dalam kelas.Kelas
 		&kelas.ID_Guru,   // Scan kolom id_guru ke dalam kelas.ID_Guru
 		&kelas.Nama_Guru, // Scan kolom nama_guru ke dalam kelas.Nama_Guru
+		&kelas.Version,   // Scan kolom version ke dalam kelas.Version
 	)
 	if err != nil {
 		// Jika terjadi error saat eksekusi query, log error dan kembalikan
-		log.Printf("SelectById error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("SelectById error exec", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
 
@@ -241,32 +243,39 @@ func (k *kelasQuery) SelectById(id string) (*kelas.KelasCore, error) {
 
 // Update digunakan untuk mengupdate data kelas berdasarkan ID yang diberikan.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses update.
-func (k *kelasQuery) Update(insert *kelas.KelasCore, id string) error {
+func (k *kelasQuery) Update(ctx context.Context, insert *kelas.KelasCore, id string) error {
 	// Memeriksa apakah koneksi ke database ada atau tidak
 	if k.db == nil {
 		// Jika koneksi database nil, kembalikan error
 		return errors.New("Nil database connection")
 	}
 
-	// Query SQL untuk mengupdate data kelas berdasarkan ID
-	query := `UPDATE kelas SET kelas = $1, id_guru = $2 WHERE id = $3`
+	// Query SQL untuk mengupdate data kelas berdasarkan ID,
+	// hanya jika versinya masih sama dengan versi yang dibaca client (optimistic concurrency)
+	query := `UPDATE kelas SET kelas = $1, id_guru = $2, update_at = NOW(), version = version + 1
+		WHERE id = $3 AND delete_at IS NULL AND version = $4`
 
 	// Eksekusi query update dengan parameter yang diberikan
-	res, err := k.db.Exec(context.Background(), query,
+	res, err := k.db.Exec(ctx, query,
 		insert.Kelas,   // Menggunakan nilai kelas baru dari parameter insert
 		insert.ID_Guru, // Menggunakan ID guru baru dari parameter insert
 		id,             // ID dari kelas yang akan diupdate
+		insert.Version, // Versi kelas yang diharapkan
 	)
 	if err != nil {
 		// Jika terjadi error saat eksekusi query, log error dan kembalikan
-		log.Printf("UpdateKelas error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("UpdateKelas error exec", "error", err)
 		return fmt.Errorf("update failed: %w", err)
 	}
 
 	// Memeriksa apakah ada baris yang terpengaruh oleh update
 	if res.RowsAffected() == 0 {
+		// Jika kelas masih ada berarti versinya sudah berubah
+		if err := helper.CheckVersionConflict(ctx, k.db, "kelas", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
 		// Jika tidak ada baris yang terpengaruh, log dan kembalikan error
-		log.Printf("Updatekelas: no rows updated for id %s", id)
+		helper.LoggerFromContext(ctx).Warn("Updatekelas: no rows updated", "id", id)
 		return errors.New("update failed: no rows affected")
 	}
 
@@ -274,11 +283,11 @@ func (k *kelasQuery) Update(insert *kelas.KelasCore, id string) error {
 	return nil
 }
 
-// DeleteById implements kelas.DataKelasInterface.
 // DeleteById implements kelas.DataKelasInterface.
 // Fungsi ini digunakan untuk menghapus data kelas berdasarkan ID yang diberikan.
+// Kelas hanya dihapus jika versinya masih sama dengan version.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses hapus.
-func (k *kelasQuery) DeleteById(id string) error {
+func (k *kelasQuery) DeleteById(ctx context.Context, id string, version int) error {
 	// Memeriksa apakah koneksi ke database ada atau tidak
 	if k.db == nil {
 		// Jika koneksi database nil, kembalikan error
@@ -287,23 +296,276 @@ func (k *kelasQuery) DeleteById(id string) error {
 
 	// Query SQL untuk menghapus data kelas berdasarkan ID
 	// dengan menggunakan soft delete, yaitu mengupdate kolom delete_at menjadi NOW()
-	query := "UPDATE kelas SET delete_at = NOW() WHERE id = $1 AND delete_at IS NULL"
+	query := "UPDATE kelas SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2"
 
 	// Eksekusi query delete dengan parameter yang diberikan
-	res, err := k.db.Exec(context.Background(), query, id)
+	res, err := k.db.Exec(ctx, query, id, version)
 	if err != nil {
 		// Jika terjadi error saat eksekusi query, log error dan kembalikan
-		log.Printf("DeleteById error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("DeleteById error exec", "error", err)
 		return fmt.Errorf("delete failed: %w", err)
 	}
 
 	// Memeriksa apakah ada baris yang terpengaruh oleh delete
 	if res.RowsAffected() == 0 {
+		// Jika kelas masih ada berarti versinya sudah berubah
+		if err := helper.CheckVersionConflict(ctx, k.db, "kelas", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
 		// Jika tidak ada baris yang terpengaruh, log dan kembalikan error
-		log.Printf("DeleteById: no rows deleted for id %s", id)
+		helper.LoggerFromContext(ctx).Warn("DeleteById: no rows deleted", "id", id)
 		return errors.New("delete failed: no rows affected")
 	}
 
 	// Kembalikan nil jika delete berhasil tanpa error
 	return nil
 }
+
+// LockById implements kelas.DataKelasInterface.
+// Fungsi ini digunakan untuk memastikan kelas aktif ada dan menguncinya (FOR SHARE)
+// sehingga kelas tidak bisa dihapus sampai transaksi selesai.
+// Fungsi ini akan mengembalikan pgx.ErrNoRows jika kelas tidak ditemukan.
+func (k *kelasQuery) LockById(ctx context.Context, id string) error {
+	// Memeriksa apakah koneksi ke database ada atau tidak
+	if k.db == nil {
+		return errors.New("Nil database connection")
+	}
+
+	query := "SELECT id FROM kelas WHERE id = $1 AND delete_at IS NULL FOR SHARE"
+
+	var found string
+	if err := k.db.QueryRow(ctx, query, id).Scan(&found); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgx.ErrNoRows
+		}
+		helper.LoggerFromContext(ctx).Error("LockById error query", "error", err)
+		return fmt.Errorf("lock kelas failed: %w", err)
+	}
+
+	return nil
+}
+
+// ListDependents implements kelas.DataKelasInterface.
+// Fungsi ini digunakan untuk mengambil siswa dan mata pelajaran aktif yang masih merujuk ke kelas.
+// Tugas, jadwal mengajar, dan jadwal ujian yang belum lewat dari mata pelajaran tersebut dikembalikan
+// sebagai dependents tidak langsung karena hanya ikut terhapus pada policy cascade.
+// Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses query.
+func (k *kelasQuery) ListDependents(ctx context.Context, id string) ([]helper.Dependent, error) {
+	// Memeriksa apakah koneksi ke database ada atau tidak
+	if k.db == nil {
+		return nil, errors.New("Nil database connection")
+	}
+
+	// Query SQL untuk mengambil siswa dan mata pelajaran yang belum dihapus pada kelas tersebut
+	query := `
+		SELECT 'siswa', id, nama, TRUE FROM siswa WHERE kelas_id = $1 AND delete_at IS NULL
+		UNION ALL
+		SELECT 'mata_pelajaran', m.id, mp.nama, TRUE FROM mata_pelajaran m JOIN mapel mp ON mp.id = m.mapel_id
+		WHERE m.kelas_id = $1 AND m.delete_at IS NULL
+		UNION ALL
+		SELECT 'tugas', t.id, t.judul, FALSE
+		FROM tugas t JOIN mata_pelajaran m ON m.id = t.mata_pelajaran_id
+		WHERE m.kelas_id = $1 AND m.delete_at IS NULL AND t.delete_at IS NULL
+		UNION ALL
+		SELECT 'jadwal_mengajar', j.id,
+			CONCAT_WS(' ', mp.nama, (ARRAY['minggu','senin','selasa','rabu','kamis','jumat','sabtu'])[j.hari + 1],
+				LEFT(j.jam_mulai::text, 5)), FALSE
+		FROM jadwal_mengajar j JOIN mata_pelajaran m ON m.id = j.mata_pelajaran_id JOIN mapel mp ON mp.id = m.mapel_id
+		WHERE m.kelas_id = $1 AND m.delete_at IS NULL AND j.delete_at IS NULL
+		UNION ALL
+		SELECT 'jadwal_ujian', j.sesi_id, CONCAT_WS(' ', u.nama, mp.nama, TO_CHAR(s.tanggal, 'YYYY-MM-DD')), FALSE
+		FROM jadwal_ujian j JOIN sesi_ujian s ON s.id = j.sesi_id JOIN ujian u ON u.id = s.ujian_id
+		JOIN mata_pelajaran m ON m.id = j.mata_pelajaran_id JOIN mapel mp ON mp.id = m.mapel_id
+		WHERE m.kelas_id = $1 AND m.delete_at IS NULL AND s.tanggal >= CURRENT_DATE AND u.delete_at IS NULL
+		ORDER BY 4 DESC, 1, 3`
+
+	rows, err := k.db.Query(ctx, query, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("ListDependents error query", "error", err)
+		return nil, fmt.Errorf("list dependents failed: %w", err)
+	}
+	defer rows.Close()
+
+	var dependents []helper.Dependent
+	for rows.Next() {
+		var d helper.Dependent
+		if err := rows.Scan(&d.Tabel, &d.ID, &d.Nama, &d.Langsung); err != nil {
+			return nil, fmt.Errorf("list dependents failed: %w", err)
+		}
+		dependents = append(dependents, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list dependents failed: %w", err)
+	}
+
+	return dependents, nil
+}
+
+// ReassignDependents implements kelas.DataKelasInterface.
+// Fungsi ini digunakan untuk memindahkan siswa dan mata pelajaran aktif dari kelas id ke kelas targetID.
+// Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses update.
+func (k *kelasQuery) ReassignDependents(ctx context.Context, id, targetID string) error {
+	// Memeriksa apakah koneksi ke database ada atau tidak
+	if k.db == nil {
+		return errors.New("Nil database connection")
+	}
+
+	queries := []string{
+		"UPDATE siswa SET kelas_id = $2, update_at = NOW(), version = version + 1 WHERE kelas_id = $1 AND delete_at IS NULL",
+		"UPDATE mata_pelajaran SET kelas_id = $2, update_at = NOW(), version = version + 1 WHERE kelas_id = $1 AND delete_at IS NULL",
+	}
+	for _, query := range queries {
+		if _, err := k.db.Exec(ctx, query, id, targetID); err != nil {
+			helper.LoggerFromContext(ctx).Error("ReassignDependents error exec", "error", err)
+			return fmt.Errorf("reassign dependents failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CascadeDelete implements kelas.DataKelasInterface.
+// Fungsi ini digunakan untuk ikut menghapus (soft delete) siswa dan mata pelajaran aktif di kelas,
+// beserta tugas dan jadwal mengajar mata pelajaran tersebut. Jadwal ujiannya dikeluarkan dari sesi
+// yang belum lewat; sesi yang sudah lewat tetap disimpan sebagai riwayat.
+// Fungsi ini akan mengembalikan error jika terjadi kesalahan dalam proses update.
+func (k *kelasQuery) CascadeDelete(ctx context.Context, id string) error {
+	// Memeriksa apakah koneksi ke database ada atau tidak
+	if k.db == nil {
+		return errors.New("Nil database connection")
+	}
+
+	queries := []string{
+		"UPDATE siswa SET delete_at = NOW(), version = version + 1 WHERE kelas_id = $1 AND delete_at IS NULL",
+		`WITH terhapus AS (
+			UPDATE mata_pelajaran SET delete_at = NOW(), version = version + 1
+			WHERE kelas_id = $1 AND delete_at IS NULL
+			RETURNING id
+		), tugas_terhapus AS (
+			UPDATE tugas SET delete_at = NOW(), version = version + 1
+			WHERE delete_at IS NULL AND mata_pelajaran_id IN (SELECT id FROM terhapus)
+		), jadwal_terhapus AS (
+			UPDATE jadwal_mengajar SET delete_at = NOW(), update_at = NOW()
+			WHERE delete_at IS NULL AND mata_pelajaran_id IN (SELECT id FROM terhapus)
+		)
+		DELETE FROM jadwal_ujian j USING sesi_ujian s
+		WHERE s.id = j.sesi_id AND s.tanggal >= CURRENT_DATE AND j.mata_pelajaran_id IN (SELECT id FROM terhapus)`,
+	}
+	for _, query := range queries {
+		if _, err := k.db.Exec(ctx, query, id); err != nil {
+			helper.LoggerFromContext(ctx).Error("CascadeDelete error exec", "error", err)
+			return fmt.Errorf("cascade delete failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SelectDetail implements kelas.DataKelasInterface.
+// Fungsi ini digunakan untuk mengambil data kelas beserta wali kelas, siswa aktif, dan mata pelajaran aktif.
+// Data diambil dengan tiga query tetap (kelas + wali kelas, siswa, mata pelajaran + guru)
+// sehingga jumlah query tidak bertambah mengikuti jumlah siswa atau mata pelajaran.
+// Fungsi ini akan mengembalikan pgx.ErrNoRows jika kelas tidak ditemukan.
+func (k *kelasQuery) SelectDetail(ctx context.Context, id string) (*kelas.KelasDetail, error) {
+	// Memeriksa apakah koneksi ke database ada atau tidak
+	if k.db == nil {
+		return nil, errors.New("Nil database connection")
+	}
+
+	// Query SQL untuk mengambil data kelas beserta wali kelas yang masih aktif
+	query := `SELECT
+			k.id, k.kelas, k.version, g.id, g.nama, g.email, g.alamat
+		FROM
+			kelas k
+		LEFT JOIN
+			guru g ON k.id_guru = g.id AND g.delete_at IS NULL
+		WHERE
+			k.id = $1 AND k.delete_at IS NULL`
+
+	var detail kelas.KelasDetail
+	var idGuru, namaGuru, emailGuru, alamatGuru sql.NullString
+	err := k.db.QueryRow(ctx, query, id).Scan(
+		&detail.ID, &detail.Kelas, &detail.Version,
+		&idGuru, &namaGuru, &emailGuru, &alamatGuru,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pgx.ErrNoRows
+		}
+		helper.LoggerFromContext(ctx).Error("SelectDetail error exec", "error", err)
+		return nil, fmt.Errorf("select detail failed: %w", err)
+	}
+	if idGuru.Valid {
+		detail.ID_Guru = idGuru.String
+		detail.Nama_Guru = namaGuru.String
+		detail.WaliKelas = &kelas.WaliKelas{
+			ID:     idGuru.String,
+			Nama:   namaGuru.String,
+			Email:  emailGuru.String,
+			Alamat: alamatGuru.String,
+		}
+	}
+
+	// Query SQL untuk mengambil semua siswa aktif di kelas
+	rows, err := k.db.Query(ctx, `SELECT id, nama, email, alamat FROM siswa
+		WHERE kelas_id = $1 AND delete_at IS NULL ORDER BY nama`, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectDetail error query siswa", "error", err)
+		return nil, fmt.Errorf("select detail failed: %w", err)
+	}
+	detail.Siswa = []kelas.SiswaKelas{}
+	for rows.Next() {
+		var s kelas.SiswaKelas
+		if err := rows.Scan(&s.ID, &s.Nama, &s.Email, &s.Alamat); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("select detail failed: %w", err)
+		}
+		detail.Siswa = append(detail.Siswa, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select detail failed: %w", err)
+	}
+	detail.JumlahSiswa = len(detail.Siswa)
+
+	// Query SQL untuk mengambil semua mata pelajaran aktif di kelas beserta semua guru pengajarnya.
+	// Pengajar dikumpulkan dengan json_agg agar tetap satu baris per mata pelajaran; key JSON sama dengan
+	// nama field PengajarKelas sehingga bisa langsung di-scan.
+	rows, err = k.db.Query(ctx, `SELECT m.id, mp.nama, mp.deskripsi, COALESCE(g.pengajar, '[]')
+		FROM mata_pelajaran m
+		JOIN mapel mp ON mp.id = m.mapel_id
+		LEFT JOIN LATERAL (
+			SELECT json_agg(json_build_object('ID', g.id, 'Nama', g.nama, 'Utama', mpg.utama)
+				ORDER BY mpg.utama DESC, g.nama) AS pengajar
+			FROM mata_pelajaran_guru mpg
+			JOIN guru g ON g.id = mpg.id_guru AND g.delete_at IS NULL
+			WHERE mpg.mata_pelajaran_id = m.id
+		) g ON TRUE
+		WHERE m.kelas_id = $1 AND m.delete_at IS NULL
+		ORDER BY mp.nama`, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectDetail error query mata pelajaran", "error", err)
+		return nil, fmt.Errorf("select detail failed: %w", err)
+	}
+	defer rows.Close()
+	detail.MataPelajaran = []kelas.MapelKelas{}
+	for rows.Next() {
+		var m kelas.MapelKelas
+		var deskripsi sql.NullString
+		if err := rows.Scan(&m.ID, &m.Nama_Pelajaran, &deskripsi, &m.Pengajar); err != nil {
+			return nil, fmt.Errorf("select detail failed: %w", err)
+		}
+		m.Deskripsi = deskripsi.String
+		// ID_Guru dan Nama_Guru tetap diisi guru pertama (guru utama) untuk client lama
+		if len(m.Pengajar) > 0 {
+			m.ID_Guru = m.Pengajar[0].ID
+			m.Nama_Guru = m.Pengajar[0].Nama
+		}
+		detail.MataPelajaran = append(detail.MataPelajaran, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select detail failed: %w", err)
+	}
+
+	return &detail, nil
+}
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go_rest_native_sekolah/features/kelas"
+	"go_rest_native_sekolah/helper"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -12,29 +14,33 @@ import (
 // Struct ini memiliki satu field yaitu kelasData yang berisi interface DataKelasInterface.
 // kelasData digunakan untuk mengakses data kelas dari repository.
 type kelasService struct {
-	kelasData kelas.DataKelasInterface // Menyimpan referensi ke interface DataKelasInterface
+	kelasData    kelas.DataKelasInterface                    // Menyimpan referensi ke interface DataKelasInterface
+	uow          helper.UnitOfWork[kelas.DataKelasInterface] // Menjalankan repository kelas dalam satu transaksi
+	deletePolicy helper.DeletePolicy                         // Policy hapus bawaan jika client tidak memilih policy
 }
 
 // NewServiceKelas digunakan untuk membuat objek service kelas yang berhubungan dengan data kelas.
 // Fungsi ini memiliki parameter repo yang berisi interface DataKelasInterface.
-// Parameter repo digunakan untuk mengakses data kelas dari repository.
+// Parameter repo digunakan untuk mengakses data kelas dari repository,
+// sedangkan uow dipakai untuk penghapusan yang harus berjalan dalam satu transaksi.
+// Parameter deletePolicy adalah policy hapus bawaan; nilai kosong berarti helper.DeletePolicyBlock.
 // Jika parameter repo nil maka akan terjadi panic.
-func NewServiceKelas(repo kelas.DataKelasInterface) kelas.ServiceKelasInterface {
+func NewServiceKelas(repo kelas.DataKelasInterface, uow helper.UnitOfWork[kelas.DataKelasInterface], deletePolicy helper.DeletePolicy) kelas.ServiceKelasInterface {
 	// Cek apakah parameter repo nil
 	if repo == nil {
-		// Jika parameter repo nil maka akan terjadi Error
-		errors.New("Nil repository")
+		// Jika parameter repo nil maka akan terjadi panic
+		panic("Nil repository")
 	}
 
 	// Membuat objek service kelas dengan parameter repo
-	return &kelasService{kelasData: repo}
+	return &kelasService{kelasData: repo, uow: uow, deletePolicy: deletePolicy}
 }
 
 // Insert implements kelas.ServiceKelasInterface.
 // Fungsi ini digunakan untuk menginsert data kelas ke dalam database.
 // Fungsi ini memiliki parameter insert yang berisi objek kelas.KelasCore.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat menginsert data.
-func (k *kelasService) Insert(insert *kelas.KelasCore) error {
+func (k *kelasService) Insert(ctx context.Context, insert *kelas.KelasCore) error {
 	// Memeriksa apakah koneksi ke repository ada atau tidak
 	if k.kelasData == nil {
 		// Jika koneksi repository nil maka akan return error
@@ -48,12 +54,12 @@ func (k *kelasService) Insert(insert *kelas.KelasCore) error {
 	}
 
 	// Menginsert data kelas ke dalam database menggunakan repository
-	return k.kelasData.Insert(insert)
+	return k.kelasData.Insert(ctx, insert)
 }
 
 // SelectAll digunakan untuk mengambil semua data kelas dari repository.
 // Fungsi ini mengembalikan slice KelasCore dan error jika terjadi kesalahan.
-func (k *kelasService) SelectAll() ([]kelas.KelasCore, error) {
+func (k *kelasService) SelectAll(ctx context.Context) ([]kelas.KelasCore, error) {
 	// Memeriksa apakah koneksi ke repository ada atau tidak
 	if k.kelasData == nil {
 		// Jika repository nil, kembalikan error
@@ -61,10 +67,10 @@ func (k *kelasService) SelectAll() ([]kelas.KelasCore, error) {
 	}
 
 	// Mengambil semua data kelas dari repository
-	kelass, err := k.kelasData.SelectAll()
+	kelass, err := k.kelasData.SelectAll(ctx)
 	if err != nil {
 		// Jika terjadi error saat pengambilan data, kembalikan error
-		return nil, errors.New("kelas service: gagal mengambil data")
+		return nil, fmt.Errorf("kelas service: gagal mengambil data: %w", err)
 	}
 
 	// Mengembalikan data kelas yang berhasil diambil
@@ -75,7 +81,7 @@ func (k *kelasService) SelectAll() ([]kelas.KelasCore, error) {
 // Fungsi ini digunakan untuk mengambil data kelas berdasarkan ID yang diberikan.
 // Fungsi ini mengembalikan objek kelas.KelasCore yang sesuai dengan ID tersebut
 // dan error jika terjadi kesalahan dalam pengambilan data.
-func (k *kelasService) SelectById(id string) (*kelas.KelasCore, error) {
+func (k *kelasService) SelectById(ctx context.Context, id string) (*kelas.KelasCore, error) {
 	// Memeriksa apakah koneksi ke repository ada atau tidak
 	// Jika repository nil, kembalikan error
 	if k.kelasData == nil {
@@ -85,7 +91,7 @@ func (k *kelasService) SelectById(id string) (*kelas.KelasCore, error) {
 	// Mengambil data kelas berdasarkan ID yang diberikan
 	// Fungsi ini akan mengembalikan error jika terjadi kesalahan
 	// dalam pengambilan data.
-	kelas, err := k.kelasData.SelectById(id)
+	kelas, err := k.kelasData.SelectById(ctx, id)
 	if err != nil {
 		// Jika terjadi error saat pengambilan data, kembalikan error
 		// dengan pesan yang sesuai.
@@ -98,7 +104,7 @@ func (k *kelasService) SelectById(id string) (*kelas.KelasCore, error) {
 
 // Update digunakan untuk memperbarui data kelas berdasarkan ID yang diberikan.
 // Fungsi ini mengembalikan error jika terjadi kesalahan dalam proses update.
-func (k *kelasService) Update(insert *kelas.KelasCore, id string) error {
+func (k *kelasService) Update(ctx context.Context, insert *kelas.KelasCore, id string) error {
 	// Memeriksa apakah service atau data repository nil
 	if k == nil || k.kelasData == nil {
 		return errors.New("Nil repository")
@@ -110,7 +116,7 @@ func (k *kelasService) Update(insert *kelas.KelasCore, id string) error {
 	}
 
 	// Mengambil data kelas yang ada berdasarkan ID
-	exisData, err := k.kelasData.SelectById(id)
+	exisData, err := k.kelasData.SelectById(ctx, id)
 	if err != nil {
 		// Jika data tidak ditemukan, kembalikan error
 		if err == pgx.ErrNoRows {
@@ -120,6 +126,11 @@ func (k *kelasService) Update(insert *kelas.KelasCore, id string) error {
 		return fmt.Errorf("Id salah atau gagal mengambil data lama: %w", err)
 	}
 
+	// Tolak update jika kelas sudah diubah sejak client mengambilnya (If-Match)
+	if insert.Version != exisData.Version {
+		return helper.ErrVersionConflict
+	}
+
 	// Gabungkan data baru dengan data lama
 	// Jika field baru kosong, gunakan field dari data lama
 	if insert.Kelas == "" {
@@ -133,7 +144,7 @@ func (k *kelasService) Update(insert *kelas.KelasCore, id string) error {
 	}
 
 	// Memperbarui data kelas ke dalam database
-	if err := k.kelasData.Update(insert, id); err != nil {
+	if err := k.kelasData.Update(ctx, insert, id); err != nil {
 		// Kembalikan error jika terjadi kesalahan saat memperbarui data
 		return err
 	}
@@ -144,11 +155,13 @@ func (k *kelasService) Update(insert *kelas.KelasCore, id string) error {
 
 // DeleteById implements kelas.ServiceKelasInterface.
 // Fungsi ini digunakan untuk menghapus data kelas berdasarkan ID yang diberikan.
-// Fungsi ini memiliki parameter id yang berisi string ID kelas yang ingin dihapus.
+// Siswa dan mata pelajaran di kelas tersebut ditangani sesuai policy hapus:
+// block menolak penghapusan, reassign memindahkan ke kelas target, dan cascade ikut menghapusnya.
+// Semua langkah dijalankan dalam satu transaksi.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat menghapus data.
-func (k *kelasService) DeleteById(id string) error {
-	// Memeriksa apakah service atau data repository nil
-	if k == nil || k.kelasData == nil {
+func (k *kelasService) DeleteById(ctx context.Context, id string, version int, opts helper.DeleteOptions) error {
+	// Memeriksa apakah service, data repository, atau unit of work nil
+	if k == nil || k.kelasData == nil || k.uow == nil {
 		// Jika repository nil, kembalikan error
 		return errors.New("Nil repository")
 	}
@@ -159,15 +172,152 @@ func (k *kelasService) DeleteById(id string) error {
 		return errors.New("Validation error: id harus diisi")
 	}
 
-	// Menghapus data kelas berdasarkan ID yang diberikan
-	// Fungsi ini akan mengembalikan error jika terjadi kesalahan
-	// dalam penghapusan data.
-	if err := k.kelasData.DeleteById(id); err != nil {
-		// Jika terjadi error saat menghapus data, kembalikan error
-		// dengan pesan yang sesuai.
-		return fmt.Errorf("gagal menghapus data kelas: %w", err)
+	// Mengisi policy bawaan dan memeriksa kelas target untuk reassign
+	opts, err := helper.ValidateDeleteOptions(id, opts, k.deletePolicy)
+	if err != nil {
+		return err
 	}
 
-	// Kembalikan nil jika hapus berhasil tanpa error
-	return nil
+	return k.uow.Do(ctx, func(repo kelas.DataKelasInterface) error {
+		// Soft delete lebih dulu agar baris kelas terkunci sampai transaksi selesai,
+		// sehingga tidak ada siswa yang dipindahkan ke kelas ini di tengah proses.
+		// Penghapusan ditolak jika versi kelas sudah berubah sejak diambil client.
+		if err := repo.DeleteById(ctx, id, version); err != nil {
+			return fmt.Errorf("gagal menghapus data kelas: %w", err)
+		}
+
+		// Kelas target harus masih aktif dan dikunci agar tidak ikut terhapus
+		if opts.Policy == helper.DeletePolicyReassign {
+			if err := repo.LockById(ctx, opts.TargetID); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errors.New("validation error: kelas target tidak ditemukan")
+				}
+				return fmt.Errorf("gagal memeriksa kelas target: %w", err)
+			}
+		}
+
+		// Mengambil siswa dan mata pelajaran yang masih merujuk ke kelas ini
+		dependents, err := repo.ListDependents(ctx, id)
+		if err != nil {
+			return fmt.Errorf("gagal menghapus data kelas: %w", err)
+		}
+		if len(dependents) == 0 {
+			// Tidak ada data yang terdampak, kembalikan nil agar transaksi di-commit
+			return nil
+		}
+
+		switch opts.Policy {
+		case helper.DeletePolicyReassign:
+			err = repo.ReassignDependents(ctx, id, opts.TargetID)
+		case helper.DeletePolicyCascade:
+			err = repo.CascadeDelete(ctx, id)
+		default:
+			// Policy block: batalkan penghapusan dan kembalikan daftar data yang masih merujuk
+			return &helper.DeleteBlockedError{Impact: helper.NewDeleteImpact(id, opts, dependents)}
+		}
+		if err != nil {
+			return fmt.Errorf("gagal menghapus data kelas: %w", err)
+		}
+
+		// Kembalikan nil agar transaksi di-commit
+		return nil
+	})
+}
+
+// PreviewDelete implements kelas.ServiceKelasInterface.
+// Fungsi ini digunakan untuk melihat siswa dan mata pelajaran yang terdampak
+// jika kelas dihapus dengan policy tertentu, tanpa mengubah data apa pun.
+// Fungsi ini akan mengembalikan error jika kelas atau kelas target tidak ditemukan.
+func (k *kelasService) PreviewDelete(ctx context.Context, id string, opts helper.DeleteOptions) (*helper.DeleteImpact, error) {
+	// Memeriksa apakah service atau data repository nil
+	if k == nil || k.kelasData == nil {
+		return nil, errors.New("Nil repository")
+	}
+
+	// Memeriksa apakah ID kosong
+	if id == "" {
+		return nil, errors.New("Validation error: id harus diisi")
+	}
+
+	// Mengisi policy bawaan dan memeriksa kelas target untuk reassign
+	opts, err := helper.ValidateDeleteOptions(id, opts, k.deletePolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Memastikan kelas yang akan dihapus (dan kelas target) masih ada
+	if _, err := k.kelasData.SelectById(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("Data tidak ditemukan")
+		}
+		return nil, fmt.Errorf("kelas service: gagal mengambil data kelas %w", err)
+	}
+	if opts.Policy == helper.DeletePolicyReassign {
+		if _, err := k.kelasData.SelectById(ctx, opts.TargetID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, errors.New("validation error: kelas target tidak ditemukan")
+			}
+			return nil, fmt.Errorf("kelas service: gagal mengambil data kelas target %w", err)
+		}
+	}
+
+	// Mengambil siswa dan mata pelajaran yang masih merujuk ke kelas ini
+	dependents, err := k.kelasData.ListDependents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("kelas service: gagal mengambil data terdampak: %w", err)
+	}
+
+	return helper.NewDeleteImpact(id, opts, dependents), nil
+}
+
+// SelectDetail implements kelas.ServiceKelasInterface.
+// Fungsi ini digunakan untuk mengambil data kelas beserta wali kelas, siswa, dan mata pelajarannya.
+// Fungsi ini akan mengembalikan error jika kelas tidak ditemukan atau terjadi kesalahan.
+func (k *kelasService) SelectDetail(ctx context.Context, id string) (*kelas.KelasDetail, error) {
+	// Memeriksa apakah service atau data repository nil
+	if k == nil || k.kelasData == nil {
+		return nil, errors.New("kelas service: Nil repository")
+	}
+
+	// Memeriksa apakah ID kosong
+	if id == "" {
+		return nil, errors.New("Validation error: id harus diisi")
+	}
+
+	detail, err := k.kelasData.SelectDetail(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("Data tidak ditemukan")
+		}
+		return nil, fmt.Errorf("kelas service: gagal mengambil detail kelas: %w", err)
+	}
+
+	return detail, nil
+}
+
+// Bulk implements kelas.ServiceKelasInterface.
+// Fungsi ini digunakan untuk menjalankan banyak operasi create, update, dan delete kelas sekaligus.
+// Setiap operasi memakai validasi yang sama dengan endpoint satuan, dengan repository dari transaksi bulk.
+// Operasi delete memakai policy hapus dari operasi tersebut atau policy bawaan service.
+func (k *kelasService) Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[kelas.KelasCore]) (*helper.BulkResult, error) {
+	// Memeriksa apakah service, data repository, atau unit of work nil
+	if k == nil || k.kelasData == nil || k.uow == nil {
+		return nil, errors.New("Nil repository")
+	}
+
+	return helper.RunBulk(ctx, k.uow, mode, ops, func(ctx context.Context, repo kelas.DataKelasInterface, op helper.BulkOperation[kelas.KelasCore]) (string, error) {
+		// Service yang memakai repository dan transaksi milik operasi bulk
+		tx := &kelasService{kelasData: repo, uow: helper.JoinUnitOfWork(repo), deletePolicy: k.deletePolicy}
+		data := op.Data
+		switch op.Action {
+		case helper.BulkActionCreate:
+			err := tx.Insert(ctx, &data)
+			return data.ID, err
+		case helper.BulkActionUpdate:
+			data.Version = op.Version
+			return op.ID, tx.Update(ctx, &data, op.ID)
+		default:
+			return op.ID, tx.DeleteById(ctx, op.ID, op.Version, op.DeleteOptions())
+		}
+	})
 }
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"go_rest_native_sekolah/features/kelas"
+	"go_rest_native_sekolah/helper"
 	"testing"
 
 	"github.com/jackc/pgx/v5"
@@ -15,7 +17,11 @@ type mockDataKelas struct {
 	mock.Mock
 }
 
-func (m *mockDataKelas) SelectAll() ([]kelas.KelasCore, error) {
+func (m *mockDataKelas) SelectAll(ctx context.Context) ([]kelas.KelasCore, error) {
+	// Meniru pgx: query dengan context yang sudah dibatalkan langsung gagal
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -23,7 +29,7 @@ func (m *mockDataKelas) SelectAll() ([]kelas.KelasCore, error) {
 	return args.Get(0).([]kelas.KelasCore), args.Error(1)
 }
 
-func (m *mockDataKelas) SelectById(id string) (*kelas.KelasCore, error) {
+func (m *mockDataKelas) SelectById(ctx context.Context, id string) (*kelas.KelasCore, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -31,21 +37,61 @@ func (m *mockDataKelas) SelectById(id string) (*kelas.KelasCore, error) {
 	return args.Get(0).(*kelas.KelasCore), args.Error(1)
 }
 
-func (m *mockDataKelas) Insert(insert *kelas.KelasCore) error {
+func (m *mockDataKelas) Insert(ctx context.Context, insert *kelas.KelasCore) error {
 	args := m.Called(insert)
 	return args.Error(0)
 }
 
-func (m *mockDataKelas) Update(insert *kelas.KelasCore, id string) error {
+func (m *mockDataKelas) Update(ctx context.Context, insert *kelas.KelasCore, id string) error {
 	args := m.Called(insert, id)
 	return args.Error(0)
 }
 
-func (m *mockDataKelas) DeleteById(id string) error {
+func (m *mockDataKelas) DeleteById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataKelas) LockById(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *mockDataKelas) ListDependents(ctx context.Context, id string) ([]helper.Dependent, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]helper.Dependent), args.Error(1)
+}
+
+func (m *mockDataKelas) ReassignDependents(ctx context.Context, id, targetID string) error {
+	args := m.Called(id, targetID)
+	return args.Error(0)
+}
+
+func (m *mockDataKelas) CascadeDelete(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockDataKelas) SelectDetail(ctx context.Context, id string) (*kelas.KelasDetail, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*kelas.KelasDetail), args.Error(1)
+}
+
+// fakeUnitOfWork menjalankan fn langsung dengan repository mock tanpa transaksi sungguhan
+type fakeUnitOfWork struct {
+	repo kelas.DataKelasInterface
+}
+
+func (f fakeUnitOfWork) Do(ctx context.Context, fn func(repo kelas.DataKelasInterface) error) error {
+	return fn(f.repo)
+}
+
 // Test SelectAll
 func TestSelectAllKelas(t *testing.T) {
 	mockRepo := new(mockDataKelas)
@@ -71,7 +117,7 @@ func TestSelectAllKelas(t *testing.T) {
 		mockRepo.On("SelectAll").Return(expectedKelas, nil).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		result, err := svc.SelectAll()
+		result, err := svc.SelectAll(context.Background())
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedKelas, result)
@@ -83,7 +129,7 @@ func TestSelectAllKelas(t *testing.T) {
 		mockRepo.On("SelectAll").Return(nil, errors.New("database error")).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		result, err := svc.SelectAll()
+		result, err := svc.SelectAll(context.Background())
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -92,11 +138,24 @@ func TestSelectAllKelas(t *testing.T) {
 
 	t.Run("failed - nil repository", func(t *testing.T) {
 		svc := &kelasService{kelasData: nil}
-		result, err := svc.SelectAll()
+		result, err := svc.SelectAll(context.Background())
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
 	})
+
+	t.Run("failed - context dibatalkan diteruskan ke repository", func(t *testing.T) {
+		mockRepo := new(mockDataKelas)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		svc := &kelasService{kelasData: mockRepo}
+		result, err := svc.SelectAll(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "SelectAll")
+	})
 }
 
 // Test SelectById
@@ -115,7 +174,7 @@ func TestSelectKelasByID(t *testing.T) {
 		mockRepo.On("SelectById", "kelas-001").Return(expectedKelas, nil).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		result, err := svc.SelectById("kelas-001")
+		result, err := svc.SelectById(context.Background(), "kelas-001")
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedKelas, result)
@@ -126,7 +185,7 @@ func TestSelectKelasByID(t *testing.T) {
 		mockRepo.On("SelectById", "999").Return(nil, pgx.ErrNoRows).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		result, err := svc.SelectById("999")
+		result, err := svc.SelectById(context.Background(), "999")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -148,7 +207,7 @@ func TestInsertKelas(t *testing.T) {
 		mockRepo.On("Insert", newKelas).Return(nil).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		err := svc.Insert(newKelas)
+		err := svc.Insert(context.Background(), newKelas)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -161,7 +220,7 @@ func TestInsertKelas(t *testing.T) {
 		}
 
 		svc := &kelasService{kelasData: nil}
-		err := svc.Insert(newKelas)
+		err := svc.Insert(context.Background(), newKelas)
 
 		assert.Error(t, err)
 	})
@@ -175,7 +234,7 @@ func TestInsertKelas(t *testing.T) {
 		mockRepo.On("Insert", newKelas).Return(errors.New("insert failed")).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		err := svc.Insert(newKelas)
+		err := svc.Insert(context.Background(), newKelas)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -202,7 +261,7 @@ func TestUpdateKelas(t *testing.T) {
 		mockRepo.On("Update", updatedKelas, "kelas-001").Return(nil).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		err := svc.Update(updatedKelas, "kelas-001")
+		err := svc.Update(context.Background(), updatedKelas, "kelas-001")
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -212,34 +271,221 @@ func TestUpdateKelas(t *testing.T) {
 		mockRepo.On("SelectById", "999").Return(nil, pgx.ErrNoRows).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		err := svc.Update(&kelas.KelasCore{}, "999")
+		err := svc.Update(context.Background(), &kelas.KelasCore{}, "999")
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("failed update kelas - version conflict", func(t *testing.T) {
+		mockRepo := new(mockDataKelas)
+		mockRepo.On("SelectById", "kelas-001").Return(&kelas.KelasCore{ID: "kelas-001", Kelas: "10A", Version: 2}, nil).Once()
+
+		svc := &kelasService{kelasData: mockRepo}
+		err := svc.Update(context.Background(), &kelas.KelasCore{Kelas: "10B", Version: 1}, "kelas-001")
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
 }
 
 // Test Delete
 func TestDeleteKelas(t *testing.T) {
 	mockRepo := new(mockDataKelas)
+	uow := fakeUnitOfWork{repo: mockRepo}
+	dependents := []helper.Dependent{
+		{Tabel: "siswa", ID: "siswa-001", Nama: "Ahmad Rauf", Langsung: true},
+		{Tabel: "mata_pelajaran", ID: "mapel-001", Nama: "Matematika", Langsung: true},
+	}
 
 	t.Run("success delete kelas", func(t *testing.T) {
-		mockRepo.On("DeleteById", "kelas-001").Return(nil).Once()
+		mockRepo.On("DeleteById", "kelas-001", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "kelas-001").Return(nil, nil).Once()
 
-		svc := &kelasService{kelasData: mockRepo}
-		err := svc.DeleteById("kelas-001")
+		svc := &kelasService{kelasData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "kelas-001", 1, helper.DeleteOptions{})
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("failed delete kelas - not found", func(t *testing.T) {
-		mockRepo.On("DeleteById", "999").Return(errors.New("data not found")).Once()
+		mockRepo.On("DeleteById", "999", 1).Return(errors.New("data not found")).Once()
+
+		svc := &kelasService{kelasData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "999", 1, helper.DeleteOptions{})
+
+		assert.Error(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed delete kelas - blocked by siswa and mapel", func(t *testing.T) {
+		mockRepo := new(mockDataKelas)
+		mockRepo.On("DeleteById", "kelas-002", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "kelas-002").Return(dependents, nil).Once()
+
+		svc := &kelasService{kelasData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.DeleteById(context.Background(), "kelas-002", 1, helper.DeleteOptions{Policy: helper.DeletePolicyBlock})
+
+		var blocked *helper.DeleteBlockedError
+		assert.ErrorAs(t, err, &blocked)
+		assert.Len(t, blocked.Impact.Dependents, 2)
+		mockRepo.AssertNotCalled(t, "CascadeDelete", mock.Anything)
+		mockRepo.AssertNotCalled(t, "ReassignDependents", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success delete kelas - reassign to another kelas", func(t *testing.T) {
+		mockRepo.On("DeleteById", "kelas-003", 1).Return(nil).Once()
+		mockRepo.On("LockById", "kelas-004").Return(nil).Once()
+		mockRepo.On("ListDependents", "kelas-003").Return(dependents, nil).Once()
+		mockRepo.On("ReassignDependents", "kelas-003", "kelas-004").Return(nil).Once()
+
+		svc := &kelasService{kelasData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "kelas-003", 1, helper.DeleteOptions{Policy: helper.DeletePolicyReassign, TargetID: "kelas-004"})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed delete kelas - reassign to itself", func(t *testing.T) {
+		svc := &kelasService{kelasData: mockRepo, uow: uow}
+		err := svc.DeleteById(context.Background(), "kelas-003", 1, helper.DeleteOptions{Policy: helper.DeletePolicyReassign, TargetID: "kelas-003"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "validation error")
+	})
+
+	t.Run("success delete kelas - cascade from default policy", func(t *testing.T) {
+		mockRepo.On("DeleteById", "kelas-005", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "kelas-005").Return(dependents, nil).Once()
+		mockRepo.On("CascadeDelete", "kelas-005").Return(nil).Once()
+
+		svc := &kelasService{kelasData: mockRepo, uow: uow, deletePolicy: helper.DeletePolicyCascade}
+		err := svc.DeleteById(context.Background(), "kelas-005", 1, helper.DeleteOptions{})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// Test PreviewDelete
+func TestPreviewDeleteKelas(t *testing.T) {
+	mockRepo := new(mockDataKelas)
+
+	t.Run("success preview delete kelas", func(t *testing.T) {
+		mockRepo.On("SelectById", "kelas-001").Return(&kelas.KelasCore{ID: "kelas-001"}, nil).Once()
+		mockRepo.On("SelectById", "kelas-002").Return(&kelas.KelasCore{ID: "kelas-002"}, nil).Once()
+		mockRepo.On("ListDependents", "kelas-001").Return([]helper.Dependent{
+			{Tabel: "siswa", ID: "siswa-001", Nama: "Ahmad Rauf", Langsung: true},
+		}, nil).Once()
 
 		svc := &kelasService{kelasData: mockRepo}
-		err := svc.DeleteById("999")
+		impact, err := svc.PreviewDelete(context.Background(), "kelas-001", helper.DeleteOptions{Policy: helper.DeletePolicyReassign, TargetID: "kelas-002"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "dipindahkan", impact.Aksi)
+		assert.Equal(t, "kelas-002", impact.TargetID)
+		assert.Len(t, impact.Dependents, 1)
+		mockRepo.AssertNotCalled(t, "DeleteById", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed preview delete kelas - not found", func(t *testing.T) {
+		mockRepo.On("SelectById", "999").Return(nil, pgx.ErrNoRows).Once()
+
+		svc := &kelasService{kelasData: mockRepo}
+		_, err := svc.PreviewDelete(context.Background(), "999", helper.DeleteOptions{})
 
 		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "tidak ditemukan")
+	})
+}
+
+// Test SelectDetail
+func TestSelectDetailKelas(t *testing.T) {
+	mockRepo := new(mockDataKelas)
+
+	t.Run("success get detail kelas", func(t *testing.T) {
+		expected := &kelas.KelasDetail{
+			KelasCore:   kelas.KelasCore{ID: "kelas-001", Kelas: "10A", ID_Guru: "guru-001", Nama_Guru: "Budi Santoso", Version: 2},
+			WaliKelas:   &kelas.WaliKelas{ID: "guru-001", Nama: "Budi Santoso"},
+			Siswa:       []kelas.SiswaKelas{{ID: "siswa-001", Nama: "Ahmad Rauf"}},
+			JumlahSiswa: 1,
+			MataPelajaran: []kelas.MapelKelas{{
+				ID: "mapel-001", Nama_Pelajaran: "Matematika", ID_Guru: "guru-002", Nama_Guru: "Siti",
+				Pengajar: []kelas.PengajarKelas{{ID: "guru-002", Nama: "Siti", Utama: true}, {ID: "guru-003", Nama: "Rina"}},
+			}},
+		}
+		mockRepo.On("SelectDetail", "kelas-001").Return(expected, nil).Once()
+
+		svc := &kelasService{kelasData: mockRepo}
+		detail, err := svc.SelectDetail(context.Background(), "kelas-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, detail)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("failed get detail kelas - not found", func(t *testing.T) {
+		mockRepo.On("SelectDetail", "999").Return(nil, pgx.ErrNoRows).Once()
+
+		svc := &kelasService{kelasData: mockRepo}
+		_, err := svc.SelectDetail(context.Background(), "999")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "tidak ditemukan")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed get detail kelas - empty id", func(t *testing.T) {
+		svc := &kelasService{kelasData: mockRepo}
+		_, err := svc.SelectDetail(context.Background(), "")
+
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "SelectDetail", "")
+	})
+}
+
+// Test Bulk
+func TestBulkKelas(t *testing.T) {
+	dependents := []helper.Dependent{{Tabel: "siswa", ID: "siswa-001", Nama: "Ahmad Rauf", Langsung: true}}
+
+	t.Run("partial bulk - blocked delete reported with dependents", func(t *testing.T) {
+		mockRepo := new(mockDataKelas)
+		ops := []helper.BulkOperation[kelas.KelasCore]{
+			{Action: helper.BulkActionCreate, Data: kelas.KelasCore{Kelas: "12A", ID_Guru: "guru-001"}},
+			{Action: helper.BulkActionDelete, ID: "kelas-001", Version: 1},
+			{Action: helper.BulkActionDelete, ID: "kelas-002", Version: 1, Policy: helper.DeletePolicyCascade},
+		}
+
+		mockRepo.On("Insert", mock.Anything).Return(nil).Once()
+		mockRepo.On("DeleteById", "kelas-001", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "kelas-001").Return(dependents, nil).Once()
+		mockRepo.On("DeleteById", "kelas-002", 1).Return(nil).Once()
+		mockRepo.On("ListDependents", "kelas-002").Return(dependents, nil).Once()
+		mockRepo.On("CascadeDelete", "kelas-002").Return(nil).Once()
+
+		svc := &kelasService{kelasData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}, deletePolicy: helper.DeletePolicyBlock}
+		result, err := svc.Bulk(context.Background(), helper.BulkModePartial, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Berhasil)
+		assert.Equal(t, 409, result.Items[1].Status)
+		assert.IsType(t, &helper.DeleteImpact{}, result.Items[1].Detail)
+		assert.Equal(t, 200, result.Items[2].Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed atomic bulk - reassign without target", func(t *testing.T) {
+		mockRepo := new(mockDataKelas)
+		ops := []helper.BulkOperation[kelas.KelasCore]{
+			{Action: helper.BulkActionDelete, ID: "kelas-001", Version: 1, Policy: helper.DeletePolicyReassign},
+		}
+
+		svc := &kelasService{kelasData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModeAtomic, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 400, result.StatusCode())
+		mockRepo.AssertNotCalled(t, "DeleteById", mock.Anything, mock.Anything)
+	})
 }
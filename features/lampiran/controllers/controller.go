@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/lampiran"
+	"go_rest_native_sekolah/helper"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// multipartOverhead adalah ruang tambahan di atas batas ukuran file untuk field form dan boundary multipart.
+const multipartOverhead = 1 << 20
+
+// LampiranController menghandle HTTP request unggah, daftar, unduh, dan hapus lampiran.
+type LampiranController struct {
+	lampiranService lampiran.ServiceLampiranInterface
+	maxUkuran       int64 // maxUkuran adalah batas ukuran satu file dalam byte
+}
+
+// NewLampiranController membuat LampiranController dengan service dan batas ukuran file maxUkuran (byte).
+func NewLampiranController(service lampiran.ServiceLampiranInterface, maxUkuran int64) *LampiranController {
+	return &LampiranController{lampiranService: service, maxUkuran: maxUkuran}
+}
+
+// writeLampiranError menulis response untuk error dari service lampiran.
+// Mengembalikan false jika error tidak dikenali sehingga pemanggil perlu meneruskannya.
+func writeLampiranError(w http.ResponseWriter, err error) bool {
+	switch {
+	case strings.Contains(err.Error(), "validation"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "akses ditolak"):
+		helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, err.Error(), nil))
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+// Upload menghandle POST /lampiran/upload dengan body multipart/form-data:
+// file (wajib), pemilik_tipe (siswa, guru, atau users), pemilik_id, dan kategori (opsional, bawaan dokumen).
+func (lc *LampiranController) Upload(w http.ResponseWriter, r *http.Request) error {
+	if lc == nil || lc.lampiranService == nil {
+		return errors.New("lampiran controller: service is nil")
+	}
+
+	// Tolak body yang jauh melebihi batas sebelum dibaca seluruhnya
+	r.Body = http.MaxBytesReader(w, r.Body, lc.maxUkuran+multipartOverhead)
+	if err := r.ParseMultipartForm(lc.maxUkuran + multipartOverhead); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, fmt.Sprintf("ukuran file melebihi batas %d byte", lc.maxUkuran), http.StatusRequestEntityTooLarge)
+			return nil
+		}
+		http.Error(w, "gagal membaca form multipart", http.StatusBadRequest)
+		return nil
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "field file wajib diisi", http.StatusBadRequest)
+		return nil
+	}
+	defer file.Close()
+
+	isi, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("gagal membaca file: %v", err)
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data := lampiran.LampiranCore{
+		Pemilik_Tipe: r.FormValue("pemilik_tipe"),
+		Pemilik_ID:   r.FormValue("pemilik_id"),
+		Kategori:     r.FormValue("kategori"),
+		Nama_File:    header.Filename,
+	}
+
+	if err := lc.lampiranService.Upload(r.Context(), &data, isi, meta); err != nil {
+		if writeLampiranError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil mengunggah lampiran", FormatLampiranList([]lampiran.LampiranCore{data}))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// Lampiran menghandle GET /lampiran?pemilik_tipe=&pemilik_id= untuk daftar lampiran milik satu pemilik.
+func (lc *LampiranController) Lampiran(w http.ResponseWriter, r *http.Request) error {
+	if lc == nil || lc.lampiranService == nil {
+		return errors.New("lampiran controller: service is nil")
+	}
+
+	q := r.URL.Query()
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	result, err := lc.lampiranService.GetByPemilik(r.Context(), q.Get("pemilik_tipe"), q.Get("pemilik_id"), meta)
+	if err != nil {
+		if writeLampiranError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data lampiran", FormatLampiranList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// GetLampiranById menghandle GET /lampiran/{id} untuk metadata satu lampiran.
+func (lc *LampiranController) GetLampiranById(w http.ResponseWriter, r *http.Request) error {
+	if lc == nil || lc.lampiranService == nil {
+		return errors.New("lampiran controller: service is nil")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data, err := lc.lampiranService.Lihat(r.Context(), r.PathValue("id"), meta)
+	if err != nil {
+		if writeLampiranError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data lampiran", FormatLampiranList([]lampiran.LampiranCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// Unduh menghandle GET /lampiran/{id}/unduh dan mengirim isi file apa adanya.
+// Query ?thumbnail=true mengirim thumbnail JPEG untuk lampiran gambar.
+func (lc *LampiranController) Unduh(w http.ResponseWriter, r *http.Request) error {
+	if lc == nil || lc.lampiranService == nil {
+		return errors.New("lampiran controller: service is nil")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	berkas, err := lc.lampiranService.Unduh(r.Context(), r.PathValue("id"), r.URL.Query().Get("thumbnail") == "true", meta)
+	if err != nil {
+		if writeLampiranError(w, err) {
+			return nil
+		}
+		return err
+	}
+	defer berkas.Isi.Close()
+
+	w.Header().Set("Content-Type", berkas.Content_Type)
+	// Browser tidak boleh menebak jenis file lain dari isinya
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": berkas.Lampiran.Nama_File}))
+	if _, err := io.Copy(w, berkas.Isi); err != nil {
+		// Header sudah terkirim, jadi error hanya dicatat
+		helper.LoggerFromContext(r.Context()).Warn("Gagal mengirim file lampiran", "id", berkas.Lampiran.ID, "error", err)
+	}
+	return nil
+}
+
+// DeleteLampiran menghandle DELETE /lampiran/{id}. Isi file dan thumbnail ikut dihapus dari storage.
+func (lc *LampiranController) DeleteLampiran(w http.ResponseWriter, r *http.Request) error {
+	if lc == nil || lc.lampiranService == nil {
+		return errors.New("lampiran controller: service is nil")
+	}
+
+	if err := lc.lampiranService.DeleteById(r.Context(), r.PathValue("id")); err != nil {
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			http.Error(w, "Data lampiran tidak ditemukan", http.StatusNotFound)
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil menghapus lampiran", nil))
+	return nil
+}
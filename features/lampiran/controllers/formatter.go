@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"go_rest_native_sekolah/features/lampiran"
+	"time"
+)
+
+// LampiranFormatter digunakan untuk memformat metadata lampiran pada response API.
+type LampiranFormatter struct {
+	ID            string    `json:"id"`                      // ID adalah ID unik lampiran
+	Pemilik_Tipe  string    `json:"pemilik_tipe"`            // Pemilik_Tipe adalah siswa, guru, atau users
+	Pemilik_ID    string    `json:"pemilik_id"`              // Pemilik_ID adalah ID pemilik lampiran
+	Kategori      string    `json:"kategori"`                // Kategori adalah kategori lampiran
+	Nama_File     string    `json:"nama_file"`               // Nama_File adalah nama file asli
+	Content_Type  string    `json:"content_type"`            // Content_Type adalah jenis isi file
+	Ukuran        int64     `json:"ukuran"`                  // Ukuran adalah besar file dalam byte
+	URL           string    `json:"url"`                     // URL adalah endpoint untuk mengunduh file
+	Thumbnail_URL string    `json:"thumbnail_url,omitempty"` // Thumbnail_URL adalah endpoint thumbnail, hanya untuk gambar
+	Diunggah_Oleh string    `json:"diunggah_oleh"`           // Diunggah_Oleh adalah ID user yang mengunggah
+	Create_At     time.Time `json:"create_at"`               // Create_At adalah waktu lampiran diunggah
+}
+
+// FormatLampiranList mengubah slice LampiranCore menjadi slice LampiranFormatter.
+func FormatLampiranList(cores []lampiran.LampiranCore) []LampiranFormatter {
+	formatted := make([]LampiranFormatter, 0, len(cores))
+	for _, core := range cores {
+		f := LampiranFormatter{
+			ID:            core.ID,
+			Pemilik_Tipe:  core.Pemilik_Tipe,
+			Pemilik_ID:    core.Pemilik_ID,
+			Kategori:      core.Kategori,
+			Nama_File:     core.Nama_File,
+			Content_Type:  core.Content_Type,
+			Ukuran:        core.Ukuran,
+			URL:           "/lampiran/" + core.ID + "/unduh",
+			Diunggah_Oleh: core.Diunggah_Oleh,
+			Create_At:     core.Create_At,
+		}
+		if core.Thumbnail_Key != "" {
+			f.Thumbnail_URL = f.URL + "?thumbnail=true"
+		}
+		formatted = append(formatted, f)
+	}
+	return formatted
+}
@@ -0,0 +1,91 @@
+package lampiran
+
+import (
+	"context"
+	"go_rest_native_sekolah/helper"
+	"io"
+	"time"
+)
+
+// Jenis pemilik lampiran. Setiap lampiran terhubung ke satu siswa, guru, atau user.
+const (
+	PemilikSiswa = "siswa"
+	PemilikGuru  = "guru"
+	PemilikUsers = "users"
+)
+
+// DaftarPemilik adalah nilai pemilik_tipe yang diterima.
+var DaftarPemilik = []string{PemilikSiswa, PemilikGuru, PemilikUsers}
+
+// Kategori lampiran.
+const (
+	KategoriFoto       = "foto"           // Pas foto, hanya gambar
+	KategoriSertifikat = "sertifikat"     // Sertifikat pendidik atau pelatihan
+	KategoriAkta       = "akta_kelahiran" // Scan akta kelahiran
+	KategoriDokumen    = "dokumen"        // Dokumen lain
+	KategoriTugas      = "tugas"          // Lampiran tugas dari guru atau jawaban tugas dari siswa
+)
+
+// TipeKonten berisi content type yang diterima untuk setiap kategori.
+// Content type ditentukan dari isi file, bukan dari nama file atau header yang dikirim client.
+var TipeKonten = map[string][]string{
+	KategoriFoto:       {"image/jpeg", "image/png"},
+	KategoriSertifikat: {"image/jpeg", "image/png", "application/pdf"},
+	KategoriAkta:       {"image/jpeg", "image/png", "application/pdf"},
+	KategoriDokumen:    {"image/jpeg", "image/png", "application/pdf"},
+	KategoriTugas:      {"image/jpeg", "image/png", "application/pdf"},
+}
+
+type (
+	// LampiranCore merepresentasikan satu file yang diunggah dan terhubung ke siswa, guru, atau user.
+	// Isi file disimpan di helper.Storage dengan key Storage_Key; tabel lampiran hanya menyimpan metadatanya.
+	LampiranCore struct {
+		ID            string    `json:"id"`            // ID adalah identifikasi unik lampiran.
+		Pemilik_Tipe  string    `json:"pemilik_tipe"`  // Pemilik_Tipe adalah salah satu dari DaftarPemilik.
+		Pemilik_ID    string    `json:"pemilik_id"`    // Pemilik_ID adalah ID siswa, guru, atau user pemilik lampiran.
+		Kategori      string    `json:"kategori"`      // Kategori adalah KategoriFoto, KategoriSertifikat, KategoriAkta, KategoriDokumen, atau KategoriTugas.
+		Nama_File     string    `json:"nama_file"`     // Nama_File adalah nama file asli dari client.
+		Content_Type  string    `json:"content_type"`  // Content_Type adalah jenis isi file yang terdeteksi.
+		Ukuran        int64     `json:"ukuran"`        // Ukuran adalah besar file dalam byte.
+		Storage_Key   string    `json:"-"`             // Storage_Key adalah key isi file di helper.Storage.
+		Thumbnail_Key string    `json:"-"`             // Thumbnail_Key adalah key thumbnail di helper.Storage, kosong jika bukan gambar.
+		Diunggah_Oleh string    `json:"diunggah_oleh"` // Diunggah_Oleh adalah ID user yang mengunggah lampiran.
+		Create_At     time.Time `json:"create_at"`     // Create_At adalah waktu lampiran diunggah.
+	}
+
+	// Berkas adalah isi file yang sedang dibaca dari penyimpanan beserta metadatanya.
+	// Pemanggil wajib menutup Isi setelah selesai.
+	Berkas struct {
+		Lampiran     *LampiranCore
+		Content_Type string
+		Isi          io.ReadCloser
+	}
+
+	// DataLampiranInterface mendefinisikan operasi tabel lampiran.
+	DataLampiranInterface interface {
+		Insert(ctx context.Context, insert *LampiranCore) error                              // Menyimpan metadata lampiran baru.
+		SelectById(ctx context.Context, id string) (*LampiranCore, error)                    // Mengambil lampiran aktif berdasarkan ID.
+		SelectByPemilik(ctx context.Context, tipe, pemilikID string) ([]LampiranCore, error) // Mengambil lampiran aktif milik satu pemilik.
+		DeleteById(ctx context.Context, id string) error                                     // Menghapus (soft delete) lampiran berdasarkan ID.
+		PemilikAda(ctx context.Context, tipe, pemilikID string) (bool, error)                // Memeriksa apakah siswa, guru, atau user pemilik masih aktif.
+		// PemilikPengguna memeriksa apakah siswa, guru, atau user pemilik adalah akun userID sendiri:
+		// user dengan ID yang sama, guru dengan id_user userID, atau siswa dengan email akun tersebut.
+		PemilikPengguna(ctx context.Context, tipe, pemilikID, userID string) (bool, error)
+		// SoalTugasPengguna memeriksa apakah lampiran adalah file soal tugas aktif di kelas siswa akun userID.
+		SoalTugasPengguna(ctx context.Context, lampiranID, userID string) (bool, error)
+	}
+
+	// ServiceLampiranInterface mendefinisikan logika bisnis unggah dan unduh lampiran.
+	// Admin dan guru boleh mengakses semua lampiran; user lain hanya lampiran miliknya sendiri
+	// dan file soal tugas di kelasnya.
+	ServiceLampiranInterface interface {
+		// Upload memvalidasi dan menyimpan isi file ke storage, membuat thumbnail untuk gambar,
+		// lalu menyimpan metadatanya. Field ID, Content_Type, Ukuran, key storage, dan Diunggah_Oleh diisi oleh service.
+		Upload(ctx context.Context, insert *LampiranCore, isi []byte, pengguna helper.MetaToken) error
+		GetByPemilik(ctx context.Context, tipe, pemilikID string, pengguna helper.MetaToken) ([]LampiranCore, error) // Mengambil daftar lampiran milik satu pemilik.
+		GetById(ctx context.Context, id string) (*LampiranCore, error)                                               // Mengambil metadata lampiran tanpa pemeriksaan akses, untuk service lain.
+		Lihat(ctx context.Context, id string, pengguna helper.MetaToken) (*LampiranCore, error)                      // Mengambil metadata lampiran yang boleh diakses pengguna.
+		Unduh(ctx context.Context, id string, thumbnail bool, pengguna helper.MetaToken) (*Berkas, error)            // Membuka isi file atau thumbnail lampiran.
+		DeleteById(ctx context.Context, id string) error                                                             // Menghapus lampiran beserta isi filenya.
+	}
+)
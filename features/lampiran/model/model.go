@@ -0,0 +1,62 @@
+package model
+
+import (
+	"go_rest_native_sekolah/features/lampiran"
+	"time"
+)
+
+// Lampiran merepresentasikan metadata file lampiran di tabel lampiran.
+// Isi file tidak disimpan di database, melainkan di storage dengan key Storage_Key.
+type Lampiran struct {
+	ID            string     `json:"id"`            // ID adalah identifikasi unik lampiran.
+	Pemilik_Tipe  string     `json:"pemilik_tipe"`  // Pemilik_Tipe adalah siswa, guru, atau users.
+	Pemilik_ID    string     `json:"pemilik_id"`    // Pemilik_ID adalah ID data pemilik lampiran.
+	Kategori      string     `json:"kategori"`      // Kategori adalah kategori lampiran.
+	Nama_File     string     `json:"nama_file"`     // Nama_File adalah nama file asli dari client.
+	Content_Type  string     `json:"content_type"`  // Content_Type adalah jenis isi file.
+	Ukuran        int64      `json:"ukuran"`        // Ukuran adalah besar file dalam byte.
+	Storage_Key   string     `json:"storage_key"`   // Storage_Key adalah key isi file di storage.
+	Thumbnail_Key string     `json:"thumbnail_key"` // Thumbnail_Key adalah key thumbnail di storage, kosong jika tidak ada.
+	Diunggah_Oleh string     `json:"diunggah_oleh"` // Diunggah_Oleh adalah ID user yang mengunggah.
+	Create_At     time.Time  `json:"create_at"`     // Create_At adalah waktu lampiran diunggah.
+	Delete_At     *time.Time `json:"delete_at"`     // Delete_At adalah waktu lampiran dihapus, jika ada.
+}
+
+// TableName mengembalikan nama tabel lampiran di database.
+func (l *Lampiran) TableName() string {
+	return "lampiran"
+}
+
+// FormatterRequest mengubah LampiranCore menjadi Lampiran untuk disimpan ke database.
+func FormatterRequest(req lampiran.LampiranCore) Lampiran {
+	return Lampiran{
+		ID:            req.ID,
+		Pemilik_Tipe:  req.Pemilik_Tipe,
+		Pemilik_ID:    req.Pemilik_ID,
+		Kategori:      req.Kategori,
+		Nama_File:     req.Nama_File,
+		Content_Type:  req.Content_Type,
+		Ukuran:        req.Ukuran,
+		Storage_Key:   req.Storage_Key,
+		Thumbnail_Key: req.Thumbnail_Key,
+		Diunggah_Oleh: req.Diunggah_Oleh,
+		Create_At:     req.Create_At,
+	}
+}
+
+// FormatterResponse mengubah Lampiran dari database menjadi LampiranCore.
+func FormatterResponse(res Lampiran) lampiran.LampiranCore {
+	return lampiran.LampiranCore{
+		ID:            res.ID,
+		Pemilik_Tipe:  res.Pemilik_Tipe,
+		Pemilik_ID:    res.Pemilik_ID,
+		Kategori:      res.Kategori,
+		Nama_File:     res.Nama_File,
+		Content_Type:  res.Content_Type,
+		Ukuran:        res.Ukuran,
+		Storage_Key:   res.Storage_Key,
+		Thumbnail_Key: res.Thumbnail_Key,
+		Diunggah_Oleh: res.Diunggah_Oleh,
+		Create_At:     res.Create_At,
+	}
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/lampiran"
+	"go_rest_native_sekolah/helper"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// lampiranQuery menghandle query ke tabel lampiran.
+type lampiranQuery struct {
+	db helper.DBTX
+}
+
+// NewDataLampiran membuat objek lampiranQuery dengan parameter db.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
+// Jika parameter db nil maka akan terjadi panic.
+func NewDataLampiran(db helper.DBTX) lampiran.DataLampiranInterface {
+	if db == nil {
+		panic("lampiran model: Nil database")
+	}
+	return &lampiranQuery{db: db}
+}
+
+// kolomLampiran adalah daftar kolom yang diambil untuk setiap lampiran, sesuai urutan scanLampiran.
+const kolomLampiran = `id, pemilik_tipe, pemilik_id, kategori, nama_file, content_type, ukuran,
+	storage_key, COALESCE(thumbnail_key, ''), COALESCE(diunggah_oleh, ''), create_at`
+
+// scanLampiran membaca satu baris hasil query dengan kolom kolomLampiran ke dalam dst.
+func scanLampiran(row pgx.Row, dst *Lampiran) error {
+	return row.Scan(&dst.ID, &dst.Pemilik_Tipe, &dst.Pemilik_ID, &dst.Kategori, &dst.Nama_File,
+		&dst.Content_Type, &dst.Ukuran, &dst.Storage_Key, &dst.Thumbnail_Key, &dst.Diunggah_Oleh, &dst.Create_At)
+}
+
+// Insert implements lampiran.DataLampiranInterface.
+// ID dibuat otomatis jika kosong dan Create_At diisi dari database.
+func (q *lampiranQuery) Insert(ctx context.Context, insert *lampiran.LampiranCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	data := FormatterRequest(*insert)
+	query := `INSERT INTO lampiran (id, pemilik_tipe, pemilik_id, kategori, nama_file, content_type, ukuran,
+			storage_key, thumbnail_key, diunggah_oleh)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''))
+		RETURNING create_at`
+	err := q.db.QueryRow(ctx, query, data.ID, data.Pemilik_Tipe, data.Pemilik_ID, data.Kategori, data.Nama_File,
+		data.Content_Type, data.Ukuran, data.Storage_Key, data.Thumbnail_Key, data.Diunggah_Oleh).Scan(&insert.Create_At)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert lampiran error", "error", err)
+		return fmt.Errorf("insert lampiran failed: %w", err)
+	}
+	return nil
+}
+
+// SelectById implements lampiran.DataLampiranInterface.
+// Mengembalikan pgx.ErrNoRows jika lampiran tidak ada atau sudah dihapus.
+func (q *lampiranQuery) SelectById(ctx context.Context, id string) (*lampiran.LampiranCore, error) {
+	var data Lampiran
+	err := scanLampiran(q.db.QueryRow(ctx,
+		"SELECT "+kolomLampiran+" FROM lampiran WHERE id = $1 AND delete_at IS NULL", id), &data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pgx.ErrNoRows
+		}
+		return nil, fmt.Errorf("gagal mengambil data lampiran: %w", err)
+	}
+	result := FormatterResponse(data)
+	return &result, nil
+}
+
+// SelectByPemilik implements lampiran.DataLampiranInterface.
+// Lampiran diurutkan dari yang terbaru.
+func (q *lampiranQuery) SelectByPemilik(ctx context.Context, tipe, pemilikID string) ([]lampiran.LampiranCore, error) {
+	rows, err := q.db.Query(ctx,
+		"SELECT "+kolomLampiran+` FROM lampiran
+		WHERE pemilik_tipe = $1 AND pemilik_id = $2 AND delete_at IS NULL
+		ORDER BY create_at DESC`, tipe, pemilikID)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectByPemilik error query", "error", err)
+		return nil, fmt.Errorf("select lampiran failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []lampiran.LampiranCore{}
+	for rows.Next() {
+		var data Lampiran
+		if err := scanLampiran(rows, &data); err != nil {
+			return nil, fmt.Errorf("select lampiran failed: %w", err)
+		}
+		result = append(result, FormatterResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select lampiran failed: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteById implements lampiran.DataLampiranInterface.
+// Lampiran hanya ditandai terhapus (soft delete); isi file dihapus oleh service.
+func (q *lampiranQuery) DeleteById(ctx context.Context, id string) error {
+	tag, err := q.db.Exec(ctx, "UPDATE lampiran SET delete_at = NOW() WHERE id = $1 AND delete_at IS NULL", id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete lampiran error", "error", err)
+		return fmt.Errorf("delete lampiran failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// tabelPemilik memetakan pemilik_tipe ke nama tabelnya. Nama tabel tidak pernah berasal dari input client.
+var tabelPemilik = map[string]string{
+	lampiran.PemilikSiswa: "siswa",
+	lampiran.PemilikGuru:  "guru",
+	lampiran.PemilikUsers: "users",
+}
+
+// PemilikAda implements lampiran.DataLampiranInterface.
+func (q *lampiranQuery) PemilikAda(ctx context.Context, tipe, pemilikID string) (bool, error) {
+	tabel, ok := tabelPemilik[tipe]
+	if !ok {
+		return false, fmt.Errorf("pemilik_tipe tidak dikenal: %q", tipe)
+	}
+	var ada bool
+	err := q.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM "+tabel+" WHERE id = $1 AND delete_at IS NULL)", pemilikID).Scan(&ada)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("PemilikAda error query", "error", err)
+		return false, fmt.Errorf("cek pemilik lampiran failed: %w", err)
+	}
+	return ada, nil
+}
+
+// pemilikPengguna berisi query yang memeriksa apakah pemilik lampiran adalah akun user ($2) sendiri.
+// Siswa dikenali dari email user yang sama dengan email siswa aktif.
+var pemilikPengguna = map[string]string{
+	lampiran.PemilikSiswa: `SELECT EXISTS (SELECT 1 FROM siswa s JOIN users u ON LOWER(u.email) = LOWER(s.email)
+		WHERE s.id = $1 AND u.id = $2 AND s.delete_at IS NULL AND u.delete_at IS NULL)`,
+	lampiran.PemilikGuru:  "SELECT EXISTS (SELECT 1 FROM guru WHERE id = $1 AND id_user = $2 AND delete_at IS NULL)",
+	lampiran.PemilikUsers: "SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND id = $2 AND delete_at IS NULL)",
+}
+
+// PemilikPengguna implements lampiran.DataLampiranInterface.
+func (q *lampiranQuery) PemilikPengguna(ctx context.Context, tipe, pemilikID, userID string) (bool, error) {
+	query, ok := pemilikPengguna[tipe]
+	if !ok {
+		return false, fmt.Errorf("pemilik_tipe tidak dikenal: %q", tipe)
+	}
+	var milik bool
+	if err := q.db.QueryRow(ctx, query, pemilikID, userID).Scan(&milik); err != nil {
+		helper.LoggerFromContext(ctx).Error("PemilikPengguna error query", "error", err)
+		return false, fmt.Errorf("cek pemilik lampiran failed: %w", err)
+	}
+	return milik, nil
+}
+
+// SoalTugasPengguna implements lampiran.DataLampiranInterface.
+func (q *lampiranQuery) SoalTugasPengguna(ctx context.Context, lampiranID, userID string) (bool, error) {
+	var soal bool
+	err := q.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM tugas t
+		JOIN mata_pelajaran mp ON mp.id = t.mata_pelajaran_id
+		JOIN siswa s ON s.kelas_id = mp.kelas_id
+		JOIN users u ON LOWER(u.email) = LOWER(s.email)
+		WHERE t.lampiran_id = $1 AND u.id = $2 AND t.delete_at IS NULL AND mp.delete_at IS NULL
+			AND s.delete_at IS NULL AND u.delete_at IS NULL)`, lampiranID, userID).Scan(&soal)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SoalTugasPengguna error query", "error", err)
+		return false, fmt.Errorf("cek soal tugas failed: %w", err)
+	}
+	return soal, nil
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/lampiran"
+	"go_rest_native_sekolah/helper"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errLampiranNotFound = errors.New("lampiran service: Data tidak ditemukan")
+	errPemilikNotFound  = errors.New("lampiran service: Pemilik lampiran tidak ditemukan")
+	errFileNotFound     = errors.New("lampiran service: File tidak ditemukan di storage")
+	errBukanPemilik     = errors.New("lampiran service: akses ditolak, lampiran bukan milik pengguna")
+)
+
+// ekstensiFile adalah ekstensi key storage untuk setiap content type yang diterima.
+var ekstensiFile = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// maxNamaFile adalah panjang maksimum nama file asli yang disimpan.
+const maxNamaFile = 255
+
+// lampiranService merepresentasikan service untuk lampiran.
+type lampiranService struct {
+	lampiranData  lampiran.DataLampiranInterface // lampiranData berisi akses ke tabel lampiran
+	storage       helper.Storage                 // storage menyimpan isi file dan thumbnail
+	maxUkuran     int64                          // maxUkuran adalah batas ukuran satu file dalam byte
+	thumbnailSize int                            // thumbnailSize adalah sisi terpanjang thumbnail dalam piksel
+	maxPiksel     int                            // maxPiksel adalah batas lebar x tinggi gambar yang diterima
+}
+
+// NewServiceLampiran membuat service lampiran.
+// Parameter storage adalah tempat isi file disimpan (lokal atau S3), maxUkuran adalah batas ukuran
+// satu file dalam byte, thumbnailSize adalah sisi terpanjang thumbnail gambar, dan maxPiksel adalah batas
+// lebar x tinggi gambar yang diterima.
+// Jika parameter repo atau storage nil maka akan terjadi panic.
+func NewServiceLampiran(repo lampiran.DataLampiranInterface, storage helper.Storage, maxUkuran int64, thumbnailSize, maxPiksel int) lampiran.ServiceLampiranInterface {
+	if repo == nil || storage == nil {
+		panic("lampiran service: Nil repository atau storage")
+	}
+	return &lampiranService{
+		lampiranData:  repo,
+		storage:       storage,
+		maxUkuran:     maxUkuran,
+		thumbnailSize: thumbnailSize,
+		maxPiksel:     maxPiksel,
+	}
+}
+
+// Upload implements lampiran.ServiceLampiranInterface.
+// Content type ditentukan dari isi file. Gambar dibuatkan thumbnail JPEG; gambar yang tidak bisa dibaca ditolak.
+// Selain admin dan guru, pengguna hanya boleh mengunggah untuk dirinya sendiri.
+// Jika penyimpanan metadata gagal, file yang sudah diunggah ke storage dihapus kembali.
+func (s *lampiranService) Upload(ctx context.Context, insert *lampiran.LampiranCore, isi []byte, pengguna helper.MetaToken) error {
+	if insert == nil {
+		return errors.New("lampiran service: input is nil")
+	}
+
+	// Validasi pemilik dan kategori
+	insert.Pemilik_Tipe = strings.ToLower(strings.TrimSpace(insert.Pemilik_Tipe))
+	if !slices.Contains(lampiran.DaftarPemilik, insert.Pemilik_Tipe) {
+		return fmt.Errorf("validation error: pemilik_tipe harus salah satu dari %s", strings.Join(lampiran.DaftarPemilik, ", "))
+	}
+	insert.Pemilik_ID = strings.TrimSpace(insert.Pemilik_ID)
+	if insert.Pemilik_ID == "" {
+		return errors.New("validation error: pemilik_id harus diisi")
+	}
+	insert.Kategori = strings.ToLower(strings.TrimSpace(insert.Kategori))
+	if insert.Kategori == "" {
+		insert.Kategori = lampiran.KategoriDokumen
+	}
+	diterima, ok := lampiran.TipeKonten[insert.Kategori]
+	if !ok {
+		return fmt.Errorf("validation error: kategori '%s' tidak dikenal", insert.Kategori)
+	}
+
+	// Validasi ukuran dan jenis isi file
+	if len(isi) == 0 {
+		return errors.New("validation error: file kosong")
+	}
+	if s.maxUkuran > 0 && int64(len(isi)) > s.maxUkuran {
+		return fmt.Errorf("validation error: ukuran file melebihi batas %d byte", s.maxUkuran)
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(isi), ";")
+	if !slices.Contains(diterima, contentType) {
+		return fmt.Errorf("validation error: jenis file %s tidak diterima untuk kategori %s, gunakan %s",
+			contentType, insert.Kategori, strings.Join(diterima, ", "))
+	}
+
+	// Pemilik harus masih aktif, dan selain admin dan guru harus akun pengguna sendiri
+	if err := s.cekPemilik(ctx, insert.Pemilik_Tipe, insert.Pemilik_ID, pengguna); err != nil {
+		return err
+	}
+
+	// Gambar dibuatkan thumbnail sebelum apa pun disimpan, sekaligus memastikan gambarnya bisa dibaca
+	var thumbnail []byte
+	if strings.HasPrefix(contentType, "image/") {
+		var err error
+		thumbnail, err = helper.MakeThumbnail(isi, s.thumbnailSize, s.maxPiksel)
+		if err != nil {
+			return fmt.Errorf("validation error: %v", err)
+		}
+	}
+
+	insert.ID = uuid.New().String()
+	insert.Nama_File = rapikanNamaFile(insert.Nama_File, ekstensiFile[contentType])
+	insert.Content_Type = contentType
+	insert.Ukuran = int64(len(isi))
+	insert.Storage_Key = insert.Pemilik_Tipe + "/" + insert.ID + ekstensiFile[contentType]
+	insert.Thumbnail_Key = ""
+	insert.Diunggah_Oleh = pengguna.ID
+
+	if err := s.storage.Put(ctx, insert.Storage_Key, isi, contentType); err != nil {
+		return fmt.Errorf("lampiran service: gagal menyimpan file: %w", err)
+	}
+	if thumbnail != nil {
+		insert.Thumbnail_Key = insert.Pemilik_Tipe + "/" + insert.ID + "_thumb.jpg"
+		if err := s.storage.Put(ctx, insert.Thumbnail_Key, thumbnail, "image/jpeg"); err != nil {
+			s.hapusFile(ctx, insert)
+			return fmt.Errorf("lampiran service: gagal menyimpan thumbnail: %w", err)
+		}
+	}
+
+	if err := s.lampiranData.Insert(ctx, insert); err != nil {
+		s.hapusFile(ctx, insert)
+		return fmt.Errorf("lampiran service: gagal menyimpan data lampiran: %w", err)
+	}
+	return nil
+}
+
+// GetByPemilik implements lampiran.ServiceLampiranInterface.
+// Selain admin dan guru, pengguna hanya boleh melihat daftar lampiran miliknya sendiri.
+func (s *lampiranService) GetByPemilik(ctx context.Context, tipe, pemilikID string, pengguna helper.MetaToken) ([]lampiran.LampiranCore, error) {
+	tipe = strings.ToLower(strings.TrimSpace(tipe))
+	if !slices.Contains(lampiran.DaftarPemilik, tipe) {
+		return nil, fmt.Errorf("validation error: pemilik_tipe harus salah satu dari %s", strings.Join(lampiran.DaftarPemilik, ", "))
+	}
+	pemilikID = strings.TrimSpace(pemilikID)
+	if pemilikID == "" {
+		return nil, errors.New("validation error: pemilik_id harus diisi")
+	}
+	if !staf(pengguna) {
+		if err := s.pemilikPengguna(ctx, tipe, pemilikID, pengguna); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.lampiranData.SelectByPemilik(ctx, tipe, pemilikID)
+	if err != nil {
+		return nil, fmt.Errorf("lampiran service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// GetById implements lampiran.ServiceLampiranInterface.
+func (s *lampiranService) GetById(ctx context.Context, id string) (*lampiran.LampiranCore, error) {
+	result, err := s.lampiranData.SelectById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errLampiranNotFound
+		}
+		return nil, fmt.Errorf("lampiran service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// Lihat implements lampiran.ServiceLampiranInterface.
+func (s *lampiranService) Lihat(ctx context.Context, id string, pengguna helper.MetaToken) (*lampiran.LampiranCore, error) {
+	data, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.bolehAkses(ctx, data, pengguna); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Unduh implements lampiran.ServiceLampiranInterface.
+// Jika thumbnail true, thumbnail JPEG yang dibuka; lampiran yang bukan gambar tidak punya thumbnail.
+func (s *lampiranService) Unduh(ctx context.Context, id string, thumbnail bool, pengguna helper.MetaToken) (*lampiran.Berkas, error) {
+	data, err := s.Lihat(ctx, id, pengguna)
+	if err != nil {
+		return nil, err
+	}
+
+	key, contentType := data.Storage_Key, data.Content_Type
+	if thumbnail {
+		if data.Thumbnail_Key == "" {
+			return nil, errors.New("lampiran service: Thumbnail tidak ditemukan, lampiran bukan gambar")
+		}
+		key, contentType = data.Thumbnail_Key, "image/jpeg"
+	}
+
+	isi, err := s.storage.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, helper.ErrObjectNotFound) {
+			return nil, errFileNotFound
+		}
+		return nil, fmt.Errorf("lampiran service: gagal membaca file: %w", err)
+	}
+	return &lampiran.Berkas{Lampiran: data, Content_Type: contentType, Isi: isi}, nil
+}
+
+// DeleteById implements lampiran.ServiceLampiranInterface.
+// Data lampiran dihapus lebih dulu agar tidak ada metadata yang menunjuk ke file yang sudah hilang.
+func (s *lampiranService) DeleteById(ctx context.Context, id string) error {
+	data, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.lampiranData.DeleteById(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errLampiranNotFound
+		}
+		return fmt.Errorf("lampiran service: gagal menghapus data: %w", err)
+	}
+	s.hapusFile(ctx, data)
+	return nil
+}
+
+// staf bernilai true untuk admin dan guru yang boleh mengelola lampiran siapa pun.
+func staf(pengguna helper.MetaToken) bool {
+	return pengguna.Role == "admin" || pengguna.Role == "guru"
+}
+
+// cekPemilik memastikan pemilik lampiran baru masih aktif. Selain admin dan guru, pemiliknya harus
+// akun pengguna sendiri; pemilik seperti itu pasti masih aktif sehingga tidak perlu dicek lagi.
+func (s *lampiranService) cekPemilik(ctx context.Context, tipe, pemilikID string, pengguna helper.MetaToken) error {
+	if !staf(pengguna) {
+		return s.pemilikPengguna(ctx, tipe, pemilikID, pengguna)
+	}
+	ada, err := s.lampiranData.PemilikAda(ctx, tipe, pemilikID)
+	if err != nil {
+		return fmt.Errorf("lampiran service: gagal cek pemilik: %w", err)
+	}
+	if !ada {
+		return errPemilikNotFound
+	}
+	return nil
+}
+
+// pemilikPengguna memastikan pemilik lampiran adalah akun pengguna sendiri.
+func (s *lampiranService) pemilikPengguna(ctx context.Context, tipe, pemilikID string, pengguna helper.MetaToken) error {
+	if pengguna.ID == "" {
+		return errBukanPemilik
+	}
+	milik, err := s.lampiranData.PemilikPengguna(ctx, tipe, pemilikID, pengguna.ID)
+	if err != nil {
+		return fmt.Errorf("lampiran service: gagal cek pemilik: %w", err)
+	}
+	if !milik {
+		return errBukanPemilik
+	}
+	return nil
+}
+
+// bolehAkses memastikan pengguna boleh melihat atau mengunduh lampiran data.
+// Selain admin dan guru, pengguna hanya boleh mengakses lampiran miliknya sendiri
+// atau file soal tugas di kelasnya.
+func (s *lampiranService) bolehAkses(ctx context.Context, data *lampiran.LampiranCore, pengguna helper.MetaToken) error {
+	if staf(pengguna) {
+		return nil
+	}
+	err := s.pemilikPengguna(ctx, data.Pemilik_Tipe, data.Pemilik_ID, pengguna)
+	if !errors.Is(err, errBukanPemilik) || data.Kategori != lampiran.KategoriTugas {
+		return err
+	}
+	soal, err := s.lampiranData.SoalTugasPengguna(ctx, data.ID, pengguna.ID)
+	if err != nil {
+		return fmt.Errorf("lampiran service: gagal cek soal tugas: %w", err)
+	}
+	if !soal {
+		return errBukanPemilik
+	}
+	return nil
+}
+
+// hapusFile menghapus isi file dan thumbnail lampiran dari storage.
+// Kegagalan hanya dicatat di log karena data lampiran sudah tidak menunjuk ke file tersebut.
+func (s *lampiranService) hapusFile(ctx context.Context, data *lampiran.LampiranCore) {
+	for _, key := range []string{data.Storage_Key, data.Thumbnail_Key} {
+		if key == "" {
+			continue
+		}
+		if err := s.storage.Delete(ctx, key); err != nil {
+			helper.LoggerFromContext(ctx).Warn("Gagal menghapus file lampiran", "key", key, "error", err)
+		}
+	}
+}
+
+// rapikanNamaFile mengambil nama dasar file dari client tanpa folder dan memotongnya sampai maxNamaFile.
+// Nama kosong diganti "lampiran" dengan ekstensi sesuai jenis file.
+func rapikanNamaFile(nama, ekstensi string) string {
+	nama = strings.TrimSpace(filepath.Base(strings.ReplaceAll(nama, "\\", "/")))
+	if nama == "" || nama == "." || nama == "/" {
+		return "lampiran" + ekstensi
+	}
+	if len(nama) > maxNamaFile {
+		// Buang sisa karakter UTF-8 yang terpotong di ujung
+		nama = strings.ToValidUTF8(nama[:maxNamaFile], "")
+	}
+	return nama
+}
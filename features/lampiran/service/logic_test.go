@@ -0,0 +1,506 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go_rest_native_sekolah/config"
+	"go_rest_native_sekolah/features/lampiran"
+	"go_rest_native_sekolah/helper"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock untuk DataLampiranInterface
+type mockDataLampiran struct {
+	mock.Mock
+}
+
+func (m *mockDataLampiran) Insert(ctx context.Context, insert *lampiran.LampiranCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataLampiran) SelectById(ctx context.Context, id string) (*lampiran.LampiranCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*lampiran.LampiranCore), args.Error(1)
+}
+
+func (m *mockDataLampiran) SelectByPemilik(ctx context.Context, tipe, pemilikID string) ([]lampiran.LampiranCore, error) {
+	args := m.Called(tipe, pemilikID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]lampiran.LampiranCore), args.Error(1)
+}
+
+func (m *mockDataLampiran) DeleteById(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockDataLampiran) PemilikAda(ctx context.Context, tipe, pemilikID string) (bool, error) {
+	args := m.Called(tipe, pemilikID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockDataLampiran) PemilikPengguna(ctx context.Context, tipe, pemilikID, userID string) (bool, error) {
+	args := m.Called(tipe, pemilikID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockDataLampiran) SoalTugasPengguna(ctx context.Context, lampiranID, userID string) (bool, error) {
+	args := m.Called(lampiranID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+// Pengguna yang dipakai selama pengujian.
+var (
+	admin = helper.MetaToken{ID: "admin-1", Role: "admin"}
+	guru  = helper.MetaToken{ID: "user-guru", Role: "guru"}
+	siswa = helper.MetaToken{ID: "user-siswa", Role: "user"}
+)
+
+// memStorage adalah helper.Storage di memori untuk pengujian.
+type memStorage struct {
+	mu      sync.Mutex
+	objek   map[string][]byte
+	gagalDi string // key yang Put-nya dibuat gagal
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{objek: map[string][]byte{}}
+}
+
+func (s *memStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key == s.gagalDi {
+		return errors.New("disk penuh")
+	}
+	s.objek[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objek[key]
+	if !ok {
+		return nil, helper.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objek, key)
+	return nil
+}
+
+// gambarPNG membuat gambar PNG polos berukuran w x h untuk pengujian.
+func gambarPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 30, B: 30, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var isiPDF = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n%%EOF\n")
+
+func TestUploadLampiran(t *testing.T) {
+	t.Run("success upload foto - thumbnail dibuat", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		mockRepo.On("PemilikAda", lampiran.PemilikSiswa, "siswa-1").Return(true, nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*lampiran.LampiranCore")).Return(nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		data := &lampiran.LampiranCore{Pemilik_Tipe: "Siswa", Pemilik_ID: "siswa-1", Kategori: "foto", Nama_File: "C:\\foto\\budi.png"}
+		err := svc.Upload(context.Background(), data, gambarPNG(t, 400, 200), admin)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", data.Content_Type)
+		assert.Equal(t, "budi.png", data.Nama_File)
+		assert.Equal(t, "siswa/"+data.ID+".png", data.Storage_Key)
+		assert.Equal(t, "admin-1", data.Diunggah_Oleh)
+		assert.Contains(t, storage.objek, data.Storage_Key)
+
+		thumb, err := jpeg.Decode(bytes.NewReader(storage.objek[data.Thumbnail_Key]))
+		assert.NoError(t, err)
+		assert.Equal(t, 64, thumb.Bounds().Dx())
+		assert.Equal(t, 32, thumb.Bounds().Dy())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success upload akta pdf - tanpa thumbnail", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		mockRepo.On("PemilikAda", lampiran.PemilikSiswa, "siswa-1").Return(true, nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*lampiran.LampiranCore")).Return(nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		data := &lampiran.LampiranCore{Pemilik_Tipe: "siswa", Pemilik_ID: "siswa-1", Kategori: "akta_kelahiran"}
+		err := svc.Upload(context.Background(), data, isiPDF, admin)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "application/pdf", data.Content_Type)
+		assert.Equal(t, "lampiran.pdf", data.Nama_File)
+		assert.Empty(t, data.Thumbnail_Key)
+		assert.Len(t, storage.objek, 1)
+	})
+
+	invalid := []struct {
+		name  string
+		data  lampiran.LampiranCore
+		isi   []byte
+		pesan string
+	}{
+		{"pemilik tipe tidak dikenal", lampiran.LampiranCore{Pemilik_Tipe: "kelas", Pemilik_ID: "1"}, isiPDF, "pemilik_tipe"},
+		{"pemilik id kosong", lampiran.LampiranCore{Pemilik_Tipe: "guru"}, isiPDF, "pemilik_id"},
+		{"kategori tidak dikenal", lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "1", Kategori: "video"}, isiPDF, "kategori"},
+		{"file kosong", lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "1"}, nil, "file kosong"},
+		{"file terlalu besar", lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "1"}, bytes.Repeat([]byte("a"), 2048), "melebihi batas"},
+		{"foto berupa pdf", lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "1", Kategori: "foto"}, isiPDF, "tidak diterima"},
+		{"file teks", lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "1"}, []byte("hanya teks"), "tidak diterima"},
+	}
+	for _, tc := range invalid {
+		t.Run("failed upload - "+tc.name, func(t *testing.T) {
+			mockRepo := new(mockDataLampiran)
+			storage := newMemStorage()
+
+			svc := NewServiceLampiran(mockRepo, storage, 1024, 64, 1<<20)
+			data := tc.data
+			err := svc.Upload(context.Background(), &data, tc.isi, admin)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "validation error")
+			assert.Contains(t, err.Error(), tc.pesan)
+			assert.Empty(t, storage.objek)
+			mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+		})
+	}
+
+	t.Run("failed upload - dimensi gambar melebihi batas piksel", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		mockRepo.On("PemilikAda", lampiran.PemilikGuru, "guru-1").Return(true, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 400*200-1)
+		err := svc.Upload(context.Background(), &lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "guru-1"}, gambarPNG(t, 400, 200), admin)
+
+		assert.ErrorContains(t, err, "validation error: dimensi gambar terlalu besar: 400x200 piksel")
+		assert.Empty(t, storage.objek)
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("failed upload - gambar rusak", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("PemilikAda", lampiran.PemilikGuru, "guru-1").Return(true, nil).Once()
+		rusak := gambarPNG(t, 10, 10)[:40]
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		err := svc.Upload(context.Background(), &lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "guru-1"}, rusak, admin)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "gambar tidak dapat dibaca")
+	})
+
+	t.Run("failed upload - pemilik tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		mockRepo.On("PemilikAda", lampiran.PemilikGuru, "guru-x").Return(false, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		err := svc.Upload(context.Background(), &lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "guru-x"}, isiPDF, admin)
+
+		assert.ErrorIs(t, err, errPemilikNotFound)
+		assert.Empty(t, storage.objek)
+	})
+
+	t.Run("failed upload - insert gagal, file dihapus kembali", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		mockRepo.On("PemilikAda", lampiran.PemilikUsers, "user-1").Return(true, nil).Once()
+		mockRepo.On("Insert", mock.Anything).Return(errors.New("database error")).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		err := svc.Upload(context.Background(), &lampiran.LampiranCore{Pemilik_Tipe: "users", Pemilik_ID: "user-1", Kategori: "foto"}, gambarPNG(t, 20, 20), admin)
+
+		assert.Error(t, err)
+		assert.Empty(t, storage.objek)
+	})
+
+	t.Run("success upload - siswa untuk dirinya sendiri", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		mockRepo.On("PemilikPengguna", lampiran.PemilikSiswa, "siswa-1", "user-siswa").Return(true, nil).Once()
+		mockRepo.On("Insert", mock.Anything).Return(nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		data := &lampiran.LampiranCore{Pemilik_Tipe: "siswa", Pemilik_ID: "siswa-1", Kategori: "tugas"}
+		err := svc.Upload(context.Background(), data, isiPDF, siswa)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user-siswa", data.Diunggah_Oleh)
+		mockRepo.AssertNotCalled(t, "PemilikAda", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed upload - siswa untuk pemilik lain", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		mockRepo.On("PemilikPengguna", lampiran.PemilikSiswa, "siswa-2", "user-siswa").Return(false, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		err := svc.Upload(context.Background(), &lampiran.LampiranCore{Pemilik_Tipe: "siswa", Pemilik_ID: "siswa-2"}, isiPDF, siswa)
+
+		assert.ErrorIs(t, err, errBukanPemilik)
+		assert.Empty(t, storage.objek)
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+}
+
+// s3Tiruan adalah server HTTP pengganti S3 untuk pengujian S3Storage.
+// Objek disimpan berdasarkan path (path-style: /<bucket>/<key>) dan request tanpa tanda tangan SigV4 ditolak.
+func s3Tiruan(t *testing.T) (*httptest.Server, map[string][]byte) {
+	t.Helper()
+	var mu sync.Mutex
+	objek := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=minio/") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objek[r.URL.Path] = data
+		case http.MethodGet:
+			data, ok := objek[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodDelete:
+			delete(objek, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, objek
+}
+
+func TestLampiranS3Storage(t *testing.T) {
+	t.Run("success upload, unduh, dan hapus lewat S3 tiruan", func(t *testing.T) {
+		server, objek := s3Tiruan(t)
+		storage, err := helper.NewS3Storage(config.StorageConfig{
+			S3Endpoint:  server.URL,
+			S3Region:    "us-east-1",
+			S3Bucket:    "sekolah",
+			S3AccessKey: "minio",
+			S3SecretKey: "rahasia",
+		})
+		assert.NoError(t, err)
+
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("PemilikAda", lampiran.PemilikGuru, "guru-1").Return(true, nil).Once()
+		mockRepo.On("Insert", mock.Anything).Return(nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		data := &lampiran.LampiranCore{Pemilik_Tipe: "guru", Pemilik_ID: "guru-1", Kategori: "sertifikat"}
+		assert.NoError(t, svc.Upload(context.Background(), data, isiPDF, guru))
+		assert.Equal(t, isiPDF, objek["/sekolah/"+data.Storage_Key])
+
+		mockRepo.On("SelectById", data.ID).Return(data, nil)
+		berkas, err := svc.Unduh(context.Background(), data.ID, false, admin)
+		assert.NoError(t, err)
+		isi, _ := io.ReadAll(berkas.Isi)
+		berkas.Isi.Close()
+		assert.Equal(t, isiPDF, isi)
+		assert.Equal(t, "application/pdf", berkas.Content_Type)
+
+		mockRepo.On("DeleteById", data.ID).Return(nil).Once()
+		assert.NoError(t, svc.DeleteById(context.Background(), data.ID))
+		assert.Empty(t, objek)
+	})
+}
+
+func TestUnduhLampiran(t *testing.T) {
+	t.Run("failed unduh - lampiran tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("SelectById", "x").Return(nil, pgx.ErrNoRows).Once()
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		_, err := svc.Unduh(context.Background(), "x", false, admin)
+
+		assert.ErrorIs(t, err, errLampiranNotFound)
+	})
+
+	t.Run("failed unduh - thumbnail untuk pdf", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("SelectById", "1").Return(&lampiran.LampiranCore{ID: "1", Storage_Key: "siswa/1.pdf", Content_Type: "application/pdf"}, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		_, err := svc.Unduh(context.Background(), "1", true, admin)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "tidak ditemukan")
+	})
+
+	t.Run("failed unduh - file hilang dari storage", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("SelectById", "1").Return(&lampiran.LampiranCore{ID: "1", Storage_Key: "siswa/1.pdf"}, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		_, err := svc.Unduh(context.Background(), "1", false, admin)
+
+		assert.ErrorIs(t, err, errFileNotFound)
+	})
+
+	milikSiswa := &lampiran.LampiranCore{ID: "1", Pemilik_Tipe: "siswa", Pemilik_ID: "siswa-1", Kategori: "akta_kelahiran", Storage_Key: "siswa/1.pdf"}
+	soalGuru := &lampiran.LampiranCore{ID: "2", Pemilik_Tipe: "guru", Pemilik_ID: "guru-1", Kategori: "tugas", Storage_Key: "guru/2.pdf"}
+
+	t.Run("success unduh - siswa pemilik lampiran", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		storage.objek["siswa/1.pdf"] = isiPDF
+		mockRepo.On("SelectById", "1").Return(milikSiswa, nil).Once()
+		mockRepo.On("PemilikPengguna", lampiran.PemilikSiswa, "siswa-1", "user-siswa").Return(true, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		berkas, err := svc.Unduh(context.Background(), "1", false, siswa)
+
+		assert.NoError(t, err)
+		berkas.Isi.Close()
+	})
+
+	t.Run("success unduh - soal tugas di kelas siswa", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		storage.objek["guru/2.pdf"] = isiPDF
+		mockRepo.On("SelectById", "2").Return(soalGuru, nil).Once()
+		mockRepo.On("PemilikPengguna", lampiran.PemilikGuru, "guru-1", "user-siswa").Return(false, nil).Once()
+		mockRepo.On("SoalTugasPengguna", "2", "user-siswa").Return(true, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		berkas, err := svc.Unduh(context.Background(), "2", false, siswa)
+
+		assert.NoError(t, err)
+		berkas.Isi.Close()
+	})
+
+	t.Run("failed unduh - soal tugas kelas lain", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("SelectById", "2").Return(soalGuru, nil).Once()
+		mockRepo.On("PemilikPengguna", lampiran.PemilikGuru, "guru-1", "user-siswa").Return(false, nil).Once()
+		mockRepo.On("SoalTugasPengguna", "2", "user-siswa").Return(false, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		_, err := svc.Unduh(context.Background(), "2", false, siswa)
+
+		assert.ErrorIs(t, err, errBukanPemilik)
+	})
+
+	t.Run("failed unduh - lampiran milik siswa lain", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		storage := newMemStorage()
+		storage.objek["siswa/1.pdf"] = isiPDF
+		mockRepo.On("SelectById", "1").Return(milikSiswa, nil).Once()
+		mockRepo.On("PemilikPengguna", lampiran.PemilikSiswa, "siswa-1", "user-lain").Return(false, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, storage, 1<<20, 64, 1<<20)
+		_, err := svc.Unduh(context.Background(), "1", false, helper.MetaToken{ID: "user-lain", Role: "user"})
+
+		assert.ErrorIs(t, err, errBukanPemilik)
+		mockRepo.AssertNotCalled(t, "SoalTugasPengguna", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed lihat metadata - lampiran milik siswa lain", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("SelectById", "1").Return(milikSiswa, nil).Once()
+		mockRepo.On("PemilikPengguna", lampiran.PemilikSiswa, "siswa-1", "user-lain").Return(false, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		_, err := svc.Lihat(context.Background(), "1", helper.MetaToken{ID: "user-lain", Role: "user"})
+
+		assert.ErrorIs(t, err, errBukanPemilik)
+	})
+}
+
+func TestGetLampiranByPemilik(t *testing.T) {
+	t.Run("success daftar lampiran", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("SelectByPemilik", lampiran.PemilikSiswa, "siswa-1").Return([]lampiran.LampiranCore{{ID: "1"}}, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		result, err := svc.GetByPemilik(context.Background(), "SISWA", "siswa-1", admin)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("failed daftar lampiran - pemilik id kosong", func(t *testing.T) {
+		svc := NewServiceLampiran(new(mockDataLampiran), newMemStorage(), 1<<20, 64, 1<<20)
+		_, err := svc.GetByPemilik(context.Background(), "siswa", "", admin)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "validation error")
+	})
+
+	t.Run("success daftar lampiran - siswa pemilik", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("PemilikPengguna", lampiran.PemilikSiswa, "siswa-1", "user-siswa").Return(true, nil).Once()
+		mockRepo.On("SelectByPemilik", lampiran.PemilikSiswa, "siswa-1").Return([]lampiran.LampiranCore{{ID: "1"}}, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		result, err := svc.GetByPemilik(context.Background(), "siswa", "siswa-1", siswa)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("failed daftar lampiran - siswa melihat pemilik lain", func(t *testing.T) {
+		mockRepo := new(mockDataLampiran)
+		mockRepo.On("PemilikPengguna", lampiran.PemilikGuru, "guru-1", "user-siswa").Return(false, nil).Once()
+
+		svc := NewServiceLampiran(mockRepo, newMemStorage(), 1<<20, 64, 1<<20)
+		_, err := svc.GetByPemilik(context.Background(), "guru", "guru-1", siswa)
+
+		assert.ErrorIs(t, err, errBukanPemilik)
+		mockRepo.AssertNotCalled(t, "SelectByPemilik", mock.Anything, mock.Anything)
+	})
+}
+
+func TestNewServiceLampiranPanic(t *testing.T) {
+	assert.Panics(t, func() {
+		NewServiceLampiran(nil, newMemStorage(), 1<<20, 64, 1<<20)
+	})
+}
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	matapelajaran "go_rest_native_sekolah/features/mata_pelajaran"
 	"go_rest_native_sekolah/helper"
-	"log"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -66,20 +66,36 @@ func (mpc *MataPelajaranController) InsertMapel(w http.ResponseWriter, r *http.R
 			return fmt.Errorf("error parsing form-data: %v", err)
 		}
 		mapelReq = FormatterMataPelajaran{
+			Mapel_ID:       r.FormValue("mapel_id"),
+			Kode:           r.FormValue("kode"),
 			Nama_Pelajaran: r.FormValue("nama_pelajaran"),
+			Kelompok:       r.FormValue("kelompok"),
 			ID_Guru:        r.FormValue("id_guru"),
 			Guru:           r.FormValue("guru"),
 			Kelas_ID:       r.FormValue("kelas_id"),
 			Nama_Kelas:     r.FormValue("nama_kelas"),
+			Periode:        r.FormValue("periode"),
 			Deskripsi:      r.FormValue("deskripsi"),
 		}
+		// Guru pendamping untuk team teaching dikirim sebagai field pengajar yang berulang.
+		for _, idGuru := range r.Form["pengajar"] {
+			mapelReq.Pengajar = append(mapelReq.Pengajar, FormatterPengajar{ID_Guru: idGuru})
+		}
+		if jam := r.FormValue("jam_per_minggu"); jam != "" {
+			jamPerMinggu, err := strconv.Atoi(jam)
+			if err != nil {
+				http.Error(w, "jam_per_minggu harus berupa angka", http.StatusBadRequest)
+				return fmt.Errorf("error parsing jam_per_minggu: %v", err)
+			}
+			mapelReq.Jam_Per_Minggu = jamPerMinggu
+		}
 	}
 
 	// Ubah request ke Core
 	mapelCore = FormatterMapelRequestToCore(mapelReq)
 
 	// Insert data mata pelajaran ke dalam database menggunakan service mata pelajaran.
-	err := mpc.MataPelajaranService.InsertMapel(&mapelCore)
+	err := mpc.MataPelajaranService.InsertMapel(r.Context(), &mapelCore)
 	if err != nil {
 		return err
 	}
@@ -111,7 +127,7 @@ func (mpc *MataPelajaranController) Mapel(w http.ResponseWriter, r *http.Request
 		// Jika controller atau service nil maka akan dikembalikan error.
 		return errors.New("Nil controller")
 	}
-	mapel, err := mpc.MataPelajaranService.SelectAllMapel()
+	mapel, err := mpc.MataPelajaranService.SelectAllMapel(r.Context())
 	// Panggil fungsi SelectAllMapel pada service untuk mengambil data mata pelajaran.
 	if err != nil {
 		// Jika terjadi error maka akan dikembalikan dalam bentuk response JSON.
@@ -148,7 +164,7 @@ func (mpc *MataPelajaranController) GetMapelById(w http.ResponseWriter, r *http.
 		// Jika parameter 'id' kosong maka akan dikembalikan error dengan kode status 400 Bad Request.
 		return errors.New("missing 'id' query parameter")
 	}
-	mapelData, err := mpc.MataPelajaranService.SelectMapelById(id)
+	mapelData, err := mpc.MataPelajaranService.SelectMapelById(r.Context(), id)
 	// Panggil fungsi SelectMapelById pada service untuk mengambil data mata pelajaran yang dicari.
 	if err != nil {
 		// Jika terjadi error maka akan dikembalikan dalam bentuk response JSON.
@@ -159,6 +175,8 @@ func (mpc *MataPelajaranController) GetMapelById(w http.ResponseWriter, r *http.
 	respon := helper.APIResponse(http.StatusOK, "Success get data mapelById", formatMapel)
 	// Buatkan response JSON yang dibutuhkan.
 	w.Header().Set("Content-Type", "application/json")
+	// ETag dipakai client sebagai If-Match saat update atau delete.
+	helper.SetETag(w, mapelData.Version)
 	err = json.NewEncoder(w).Encode(respon)
 	if err != nil {
 		// Jika terjadi error saat mengencode JSON maka akan dikembalikan dalam bentuk
@@ -183,26 +201,38 @@ func (mpc *MataPelajaranController) UpdateMapel(w http.ResponseWriter, r *http.R
 		// Jika parameter 'id' kosong maka akan dikembalikan error dengan kode status 400 Bad Request.
 		return errors.New("missing 'id' query parameter")
 	}
+	version, err := helper.IfMatchVersion(r)
+	// Ambil versi data dari header If-Match agar update tidak menimpa perubahan orang lain.
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
 	var mapelReq FormatterMataPelajaran
 	// Deklarasikan objek yang digunakan untuk mengubah data inputan menjadi objek mata pelajaran core.
-	err := json.NewDecoder(r.Body).Decode(&mapelReq)
+	err = json.NewDecoder(r.Body).Decode(&mapelReq)
 	// Dekode data yang dikirimkan lewat body menjadi objek mata pelajaran.
 	if err != nil {
-		log.Printf("Error decoding request body: %v", err)
+		helper.LoggerFromContext(r.Context()).Error("Error decoding request body", "error", err)
 		http.Error(w, "gagal memproses data input", http.StatusBadRequest)
 		// Jika terjadi error saat decoding maka akan dikembalikan error dengan kode status 400 Bad Request.
 		return err
 	}
 	mapelUpdate := FormatterMapelRequestToCore(mapelReq)
+	mapelUpdate.Version = version
 	// Ubah data yang diambil menjadi format objek mata pelajaran core.
-	err = mpc.MataPelajaranService.UpdateMapel(&mapelUpdate, id)
+	err = mpc.MataPelajaranService.UpdateMapel(r.Context(), &mapelUpdate, id)
 	// Panggil fungsi UpdateMapel pada service untuk mengupdate data mata pelajaran yang dicari.
 	if err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			// Jika data sudah diubah request lain maka dikembalikan kode status 412 Precondition Failed.
+			return err
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		// Jika terjadi error maka akan dikembalikan dalam bentuk response JSON dengan kode status 400 Bad Request.
 		return err
 	}
-	mapelData, err := mpc.MataPelajaranService.SelectMapelById(id)
+	mapelData, err := mpc.MataPelajaranService.SelectMapelById(r.Context(), id)
 	// Panggil fungsi SelectMapelById pada service untuk mengambil data mata pelajaran yang diupdate.
 	if err != nil {
 		http.Error(w, "Gagal mengambil data setelah update", http.StatusInternalServerError)
@@ -214,6 +244,7 @@ func (mpc *MataPelajaranController) UpdateMapel(w http.ResponseWriter, r *http.R
 	respon := helper.APIResponse(http.StatusOK, "Berhasil mengupdate data mapel", formatMapel)
 	// Buatkan response JSON yang dibutuhkan.
 	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, mapelData.Version)
 	err = json.NewEncoder(w).Encode(respon)
 	if err != nil {
 		// Jika terjadi error saat mengencode JSON maka akan dikembalikan dalam bentuk
@@ -234,9 +265,20 @@ func (mpc *MataPelajaranController) DeleteMapel(w http.ResponseWriter, r *http.R
 		// Jika parameter 'id' kosong maka akan dikembalikan error dengan kode status 400 Bad Request.
 		return errors.New("missing 'id' query parameter")
 	}
-	err := mpc.MataPelajaranService.DeleteMapel(id)
+	version, err := helper.IfMatchVersion(r)
+	// Ambil versi data dari header If-Match agar tidak menghapus data yang sudah diubah orang lain.
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
+	err = mpc.MataPelajaranService.DeleteMapel(r.Context(), id, version)
 	// Panggil fungsi DeleteMapel pada service untuk menghapus data mata pelajaran yang dicari.
 	if err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			// Jika data sudah diubah request lain maka dikembalikan kode status 412 Precondition Failed.
+			return err
+		}
 		return err
 		// Jika terjadi error maka akan dikembalikan dalam bentuk response JSON dengan kode status 500 Internal Server Error.
 	}
@@ -251,3 +293,196 @@ func (mpc *MataPelajaranController) DeleteMapel(w http.ResponseWriter, r *http.R
 	}
 	return nil // Jika tidak ada error maka kembalikan nil
 }
+
+// BulkMapel digunakan untuk menghandle permintaan HTTP POST yang menjalankan
+// banyak operasi create, update, dan delete mata pelajaran sekaligus,
+// misalnya memindahkan beberapa mata pelajaran ke guru baru.
+// Response berisi hasil per operasi.
+func (mpc *MataPelajaranController) BulkMapel(w http.ResponseWriter, r *http.Request) error {
+	// Memeriksa apakah controller dan service tidak nil.
+	if mpc == nil || mpc.MataPelajaranService == nil {
+		return errors.New("Nil controller")
+	}
+
+	// Baca dan validasi daftar operasi dari body JSON.
+	req, err := helper.DecodeBulkRequest[FormatterMataPelajaran](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	// Ubah data setiap operasi ke core lalu jalankan di service.
+	result, err := mpc.MataPelajaranService.Bulk(r.Context(), req.Mode, helper.MapBulkOperations(req.Operations, FormatterMapelRequestToCore))
+	if err != nil {
+		return fmt.Errorf("gagal menjalankan bulk mata pelajaran: %v", err)
+	}
+
+	// Kirim hasil per operasi dengan status sesuai hasil bulk.
+	helper.JSONResponse(w, result.StatusCode(), helper.APIResponse(result.StatusCode(), result.Message(), result))
+	return nil
+}
+
+// Katalog digunakan untuk menghandle permintaan HTTP GET yang mengembalikan
+// semua mapel di katalog beserta jumlah kelas yang memakainya.
+func (mpc *MataPelajaranController) Katalog(w http.ResponseWriter, r *http.Request) error {
+	if mpc == nil || mpc.MataPelajaranService == nil {
+		return errors.New("Nil controller")
+	}
+
+	katalog, err := mpc.MataPelajaranService.GetAllKatalog(r.Context())
+	if err != nil {
+		return err
+	}
+
+	respon := helper.APIResponse(http.StatusOK, "Success get katalog mapel", FormatterKatalogList(katalog))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(respon); err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	return nil
+}
+
+// InsertKatalog digunakan untuk menghandle permintaan HTTP POST yang menambah mapel ke katalog.
+// Body JSON berisi kode, nama, deskripsi, dan kelompok; hanya nama yang wajib diisi.
+func (mpc *MataPelajaranController) InsertKatalog(w http.ResponseWriter, r *http.Request) error {
+	if mpc == nil || mpc.MataPelajaranService == nil {
+		return errors.New("Nil controller")
+	}
+
+	var req FormatterKatalogMapel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return fmt.Errorf("error decoding JSON: %v", err)
+	}
+
+	katalog := FormatterKatalogRequestToCore(req)
+	if err := mpc.MataPelajaranService.InsertKatalog(r.Context(), &katalog); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		return err
+	}
+
+	respon := helper.APIResponse(http.StatusCreated, "Success insert katalog mapel", FormatterKatalogList([]matapelajaran.KatalogMapelCore{katalog}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, katalog.Version)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(respon); err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	return nil
+}
+
+// GetKatalogById digunakan untuk menghandle permintaan HTTP GET /mapel/katalog/{id}.
+// Response berisi satu mapel di katalog dengan header ETag untuk update dan delete.
+func (mpc *MataPelajaranController) GetKatalogById(w http.ResponseWriter, r *http.Request) error {
+	if mpc == nil || mpc.MataPelajaranService == nil {
+		return errors.New("Nil controller")
+	}
+
+	katalog, err := mpc.MataPelajaranService.GetKatalogById(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "tidak ditemukan") {
+			http.Error(w, "Data mapel tidak ditemukan", http.StatusNotFound)
+			return nil
+		}
+		return err
+	}
+
+	respon := helper.APIResponse(http.StatusOK, "Success get katalog mapel by id", FormatterKatalogList([]matapelajaran.KatalogMapelCore{*katalog}))
+	w.Header().Set("Content-Type", "application/json")
+	// ETag dipakai client sebagai If-Match saat update atau delete.
+	helper.SetETag(w, katalog.Version)
+	if err := json.NewEncoder(w).Encode(respon); err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	return nil
+}
+
+// UpdateKatalog digunakan untuk menghandle permintaan HTTP PUT /mapel/katalog/{id}.
+// Field yang tidak dikirim tetap memakai nilai lama. Header If-Match wajib diisi dengan ETag terbaru.
+func (mpc *MataPelajaranController) UpdateKatalog(w http.ResponseWriter, r *http.Request) error {
+	if mpc == nil || mpc.MataPelajaranService == nil {
+		return errors.New("Nil controller")
+	}
+	id := r.PathValue("id")
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
+
+	var req FormatterKatalogMapel
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal memproses data input", http.StatusBadRequest)
+		return err
+	}
+	katalog := FormatterKatalogRequestToCore(req)
+	katalog.Version = version
+
+	if err := mpc.MataPelajaranService.UpdateKatalog(r.Context(), &katalog, id); err != nil {
+		switch {
+		case errors.Is(err, helper.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		case strings.Contains(err.Error(), "tidak ditemukan") || strings.Contains(err.Error(), "no rows affected"):
+			http.Error(w, "Data mapel tidak ditemukan", http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return err
+	}
+
+	updated, err := mpc.MataPelajaranService.GetKatalogById(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Gagal mengambil data setelah update", http.StatusInternalServerError)
+		return err
+	}
+
+	respon := helper.APIResponse(http.StatusOK, "Berhasil mengupdate katalog mapel", FormatterKatalogList([]matapelajaran.KatalogMapelCore{*updated}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updated.Version)
+	if err := json.NewEncoder(w).Encode(respon); err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	return nil
+}
+
+// DeleteKatalog digunakan untuk menghandle permintaan HTTP DELETE /mapel/katalog/{id}.
+// Mapel yang masih dipakai penugasan aktif ditolak dengan status 409 beserta daftar penugasannya.
+func (mpc *MataPelajaranController) DeleteKatalog(w http.ResponseWriter, r *http.Request) error {
+	if mpc == nil || mpc.MataPelajaranService == nil {
+		return errors.New("Nil controller")
+	}
+	id := r.PathValue("id")
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
+
+	if err := mpc.MataPelajaranService.DeleteKatalog(r.Context(), id, version); err != nil {
+		var blocked *helper.DeleteBlockedError
+		switch {
+		case errors.Is(err, helper.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		case errors.As(err, &blocked):
+			helper.JSONResponse(w, http.StatusConflict, helper.APIResponse(http.StatusConflict, "mapel masih dipakai: "+blocked.Error(), blocked.Impact))
+			return nil
+		case strings.Contains(err.Error(), "no rows affected"):
+			http.Error(w, "Data mapel tidak ditemukan", http.StatusNotFound)
+			return err
+		}
+		return err
+	}
+
+	respon := helper.APIResponse(http.StatusOK, "Berhasil menghapus katalog mapel", nil)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(respon); err != nil {
+		return fmt.Errorf("error encoding JSON: %v", err)
+	}
+	return nil
+}
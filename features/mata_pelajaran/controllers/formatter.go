@@ -5,13 +5,38 @@ import matapelajaran "go_rest_native_sekolah/features/mata_pelajaran"
 // FormatterMataPelajaran digunakan untuk memformat data mata pelajaran agar sesuai dengan kebutuhan response API.
 // Struktur ini merepresentasikan data mata pelajaran yang akan dikirimkan sebagai respons.
 type FormatterMataPelajaran struct {
-	ID             string `json:"id"`             // ID adalah ID unik untuk setiap mata pelajaran
-	Nama_Pelajaran string `json:"mata_pelajaran"` // Nama_Pelajaran adalah nama dari mata pelajaran
-	ID_Guru        string `json:"id_guru"`        // ID_Guru adalah ID dari guru yang mengajar mata pelajaran ini
-	Guru           string `json:"guru"`           // Guru adalah nama dari guru yang mengajar mata pelajaran ini
-	Kelas_ID       string `json:"kelas_id"`       // Kelas_ID adalah ID dari kelas tempat mata pelajaran ini diajarkan
-	Nama_Kelas     string `json:"nama_kelas"`     // Nama_Kelas adalah nama dari kelas tempat mata pelajaran ini diajarkan
-	Deskripsi      string `json:"deskripsi"`      // Deskripsi adalah penjelasan singkat tentang mata pelajaran ini
+	ID             string              `json:"id"`             // ID adalah ID unik untuk setiap penugasan mata pelajaran
+	Mapel_ID       string              `json:"mapel_id"`       // Mapel_ID adalah ID mapel di katalog
+	Kode           string              `json:"kode"`           // Kode adalah kode mapel di katalog
+	Nama_Pelajaran string              `json:"mata_pelajaran"` // Nama_Pelajaran adalah nama dari mata pelajaran
+	Kelompok       string              `json:"kelompok"`       // Kelompok adalah kelompok mapel di katalog
+	ID_Guru        string              `json:"id_guru"`        // ID_Guru adalah ID dari guru utama yang mengajar mata pelajaran ini
+	Guru           string              `json:"guru"`           // Guru adalah nama dari guru utama yang mengajar mata pelajaran ini
+	Pengajar       []FormatterPengajar `json:"pengajar"`       // Pengajar adalah semua guru yang mengajar mata pelajaran ini
+	Kelas_ID       string              `json:"kelas_id"`       // Kelas_ID adalah ID dari kelas tempat mata pelajaran ini diajarkan
+	Nama_Kelas     string              `json:"nama_kelas"`     // Nama_Kelas adalah nama dari kelas tempat mata pelajaran ini diajarkan
+	Periode        string              `json:"periode"`        // Periode adalah periode penugasan mata pelajaran ini
+	Deskripsi      string              `json:"deskripsi"`      // Deskripsi adalah penjelasan singkat tentang mata pelajaran ini
+	Jam_Per_Minggu int                 `json:"jam_per_minggu"` // Jam_Per_Minggu adalah jumlah jam pelajaran ini per minggu
+	Version        int                 `json:"version"`        // Version adalah versi data mata pelajaran, sama dengan ETag
+}
+
+// FormatterPengajar digunakan untuk memformat satu guru pengajar mata pelajaran.
+type FormatterPengajar struct {
+	ID_Guru string `json:"id_guru"`        // ID_Guru adalah ID guru pengajar
+	Nama    string `json:"nama,omitempty"` // Nama adalah nama guru pengajar
+	Utama   bool   `json:"utama"`          // Utama bernilai true untuk guru utama
+}
+
+// FormatterKatalogMapel digunakan untuk memformat satu mapel di katalog sebagai request maupun response API.
+type FormatterKatalogMapel struct {
+	ID              string `json:"id"`               // ID adalah ID unik mapel di katalog
+	Kode            string `json:"kode"`             // Kode adalah kode unik mapel
+	Nama            string `json:"nama"`             // Nama adalah nama mata pelajaran
+	Deskripsi       string `json:"deskripsi"`        // Deskripsi adalah penjelasan singkat tentang mata pelajaran
+	Kelompok        string `json:"kelompok"`         // Kelompok adalah kelompok mata pelajaran
+	JumlahPenugasan int    `json:"jumlah_penugasan"` // JumlahPenugasan adalah jumlah kelas yang memakai mapel ini
+	Version         int    `json:"version"`          // Version adalah versi data mapel, sama dengan ETag
 }
 
 // FormatterMapelList digunakan untuk mengubah slice MataPelajaranCore menjadi slice FormatterMataPelajaran.
@@ -21,13 +46,20 @@ func FormatterMapelList(cores []matapelajaran.MataPelajaranCore) []FormatterMata
 	formatted := make([]FormatterMataPelajaran, 0) // Membuat slice FormatterMataPelajaran yang kosong untuk diisi dengan data-data mata pelajaran
 	for _, core := range cores {                   // Melakukan perulangan untuk setiap data mata pelajaran di dalam slice cores
 		formatted = append(formatted, FormatterMataPelajaran{ // Membuat objek FormatterMataPelajaran dan mengisi dengan data-data mata pelajaran
-			ID:             core.ID,             // ID adalah ID unik untuk setiap mata pelajaran
-			Nama_Pelajaran: core.Nama_Pelajaran, // Nama_Pelajaran adalah nama dari mata pelajaran
-			ID_Guru:        core.ID_Guru,        // ID_Guru adalah ID dari guru yang mengajar mata pelajaran ini
-			Guru:           core.Guru,           // Guru adalah nama dari guru yang mengajar mata pelajaran ini
-			Kelas_ID:       core.Kelas_ID,       // Kelas_ID adalah ID dari kelas tempat mata pelajaran ini diajarkan
-			Nama_Kelas:     core.Nama_Kelas,     // Nama_Kelas adalah nama dari kelas tempat mata pelajaran ini diajarkan
-			Deskripsi:      core.Deskripsi,      // Deskripsi adalah penjelasan singkat tentang mata pelajaran ini
+			ID:             core.ID,                           // ID adalah ID unik untuk setiap penugasan mata pelajaran
+			Mapel_ID:       core.Mapel_ID,                     // Mapel_ID adalah ID mapel di katalog
+			Kode:           core.Kode,                         // Kode adalah kode mapel di katalog
+			Nama_Pelajaran: core.Nama_Pelajaran,               // Nama_Pelajaran adalah nama dari mata pelajaran
+			Kelompok:       core.Kelompok,                     // Kelompok adalah kelompok mapel di katalog
+			ID_Guru:        core.ID_Guru,                      // ID_Guru adalah ID dari guru utama yang mengajar mata pelajaran ini
+			Guru:           core.Guru,                         // Guru adalah nama dari guru utama yang mengajar mata pelajaran ini
+			Pengajar:       formatPengajarList(core.Pengajar), // Pengajar adalah semua guru yang mengajar mata pelajaran ini
+			Kelas_ID:       core.Kelas_ID,                     // Kelas_ID adalah ID dari kelas tempat mata pelajaran ini diajarkan
+			Nama_Kelas:     core.Nama_Kelas,                   // Nama_Kelas adalah nama dari kelas tempat mata pelajaran ini diajarkan
+			Periode:        core.Periode,                      // Periode adalah periode penugasan mata pelajaran ini
+			Deskripsi:      core.Deskripsi,                    // Deskripsi adalah penjelasan singkat tentang mata pelajaran ini
+			Jam_Per_Minggu: core.Jam_Per_Minggu,               // Jam_Per_Minggu adalah jumlah jam pelajaran ini per minggu
+			Version:        core.Version,                      // Version adalah versi data mata pelajaran, sama dengan ETag
 		})
 	}
 	return formatted // Mengembalikan slice FormatterMataPelajaran yang telah di format
@@ -40,11 +72,66 @@ func FormatterMapelRequestToCore(req FormatterMataPelajaran) matapelajaran.MataP
 	// Mengembalikan objek MataPelajaranCore yang berisi data-data mata pelajaran dari objek FormatterMataPelajaran
 	return matapelajaran.MataPelajaranCore{
 		ID:             req.ID,             // Mengisi field ID dengan ID dari objek FormatterMataPelajaran
+		Mapel_ID:       req.Mapel_ID,       // Mengisi field Mapel_ID dengan ID mapel di katalog dari objek FormatterMataPelajaran
+		Kode:           req.Kode,           // Mengisi field Kode dengan kode mapel dari objek FormatterMataPelajaran
 		Nama_Pelajaran: req.Nama_Pelajaran, // Mengisi field Nama_Pelajaran dengan nama pelajaran dari objek FormatterMataPelajaran
+		Kelompok:       req.Kelompok,       // Mengisi field Kelompok dengan kelompok mapel dari objek FormatterMataPelajaran
+		Periode:        req.Periode,        // Mengisi field Periode dengan periode penugasan dari objek FormatterMataPelajaran
+		Pengajar:       pengajarToCore(req.Pengajar),
 		ID_Guru:        req.ID_Guru,        // Mengisi field ID_Guru dengan ID guru dari objek FormatterMataPelajaran
 		Guru:           req.Guru,           // Mengisi field Guru dengan nama guru dari objek FormatterMataPelajaran
 		Kelas_ID:       req.Kelas_ID,       // Mengisi field Kelas_ID dengan ID kelas dari objek FormatterMataPelajaran
 		Nama_Kelas:     req.Nama_Kelas,     // Mengisi field Nama_Kelas dengan nama kelas dari objek FormatterMataPelajaran
 		Deskripsi:      req.Deskripsi,      // Mengisi field Deskripsi dengan deskripsi dari objek FormatterMataPelajaran
+		Jam_Per_Minggu: req.Jam_Per_Minggu, // Mengisi field Jam_Per_Minggu dengan jam pelajaran per minggu dari objek FormatterMataPelajaran
+	}
+}
+
+// formatPengajarList mengubah daftar PengajarCore menjadi daftar FormatterPengajar yang tidak pernah nil.
+func formatPengajarList(cores []matapelajaran.PengajarCore) []FormatterPengajar {
+	formatted := make([]FormatterPengajar, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, FormatterPengajar{ID_Guru: core.ID_Guru, Nama: core.Nama, Utama: core.Utama})
+	}
+	return formatted
+}
+
+// pengajarToCore mengubah daftar pengajar dari request menjadi PengajarCore.
+// Nilai nil tetap nil agar service tahu daftar pengajar tidak dikirim client.
+func pengajarToCore(req []FormatterPengajar) []matapelajaran.PengajarCore {
+	if req == nil {
+		return nil
+	}
+	cores := make([]matapelajaran.PengajarCore, 0, len(req))
+	for _, p := range req {
+		cores = append(cores, matapelajaran.PengajarCore{ID_Guru: p.ID_Guru, Utama: p.Utama})
+	}
+	return cores
+}
+
+// FormatterKatalogList digunakan untuk mengubah slice KatalogMapelCore menjadi slice FormatterKatalogMapel.
+func FormatterKatalogList(cores []matapelajaran.KatalogMapelCore) []FormatterKatalogMapel {
+	formatted := make([]FormatterKatalogMapel, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, FormatterKatalogMapel{
+			ID:              core.ID,
+			Kode:            core.Kode,
+			Nama:            core.Nama,
+			Deskripsi:       core.Deskripsi,
+			Kelompok:        core.Kelompok,
+			JumlahPenugasan: core.JumlahPenugasan,
+			Version:         core.Version,
+		})
+	}
+	return formatted
+}
+
+// FormatterKatalogRequestToCore digunakan untuk mengubah request FormatterKatalogMapel menjadi KatalogMapelCore.
+func FormatterKatalogRequestToCore(req FormatterKatalogMapel) matapelajaran.KatalogMapelCore {
+	return matapelajaran.KatalogMapelCore{
+		Kode:      req.Kode,
+		Nama:      req.Nama,
+		Deskripsi: req.Deskripsi,
+		Kelompok:  req.Kelompok,
 	}
 }
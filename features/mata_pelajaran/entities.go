@@ -1,26 +1,76 @@
 package matapelajaran
 
+import (
+	"context"
+	"go_rest_native_sekolah/helper"
+)
+
 // MataPelajaranCore adalah struktur data yang berisi field2 yang akan diisi
-// oleh data mata pelajaran.
+// oleh data mata pelajaran. Satu MataPelajaranCore adalah penugasan satu mapel
+// dari katalog ke satu kelas pada satu periode, beserta guru pengajarnya.
 type MataPelajaranCore struct {
-	// ID adalah field yang berisi ID unik untuk setiap mata pelajaran.
+	// ID adalah field yang berisi ID unik untuk setiap penugasan mata pelajaran.
 	ID string `json:"id"`
-	// Nama_Pelajaran adalah field yang berisi nama mata pelajaran.
+	// Mapel_ID adalah field yang berisi ID mapel di katalog.
+	Mapel_ID string `json:"mapel_id"`
+	// Kode adalah field yang berisi kode mapel di katalog.
+	Kode string `json:"kode"`
+	// Nama_Pelajaran adalah field yang berisi nama mata pelajaran dari katalog.
 	Nama_Pelajaran string `json:"mata_pelajaran"`
-	// ID_Guru adalah field yang berisi ID guru yang mengajar mata pelajaran.
+	// Kelompok adalah field yang berisi kelompok mapel di katalog, misalnya "Wajib" atau "Peminatan".
+	Kelompok string `json:"kelompok"`
+	// ID_Guru adalah field yang berisi ID guru utama yang mengajar mata pelajaran.
 	ID_Guru string `json:"id_guru"`
-	// Guru adalah field yang berisi nama guru yang mengajar mata pelajaran.
+	// Guru adalah field yang berisi nama guru utama yang mengajar mata pelajaran.
 	Guru string `json:"guru"`
+	// Pengajar adalah field yang berisi semua guru yang mengajar mata pelajaran (team teaching).
+	// Nilai nil pada update berarti daftar pengajar tidak diubah.
+	Pengajar []PengajarCore `json:"pengajar"`
 	// Kelas_ID adalah field yang berisi ID kelas yang mengajar mata pelajaran.
 	Kelas_ID string `json:"kelas_id"`
 	// Nama_Kelas adalah field yang berisi nama kelas yang mengajar mata pelajaran.
 	Nama_Kelas string `json:"nama_kelas"`
-	// Deskripsi adalah field yang berisi deskripsi singkat tentang mata pelajaran.
+	// Periode adalah field yang berisi periode penugasan, misalnya "2025/2026-1".
+	Periode string `json:"periode"`
+	// Deskripsi adalah field yang berisi deskripsi singkat tentang mata pelajaran dari katalog.
 	Deskripsi string `json:"deskripsi"`
+	// Jam_Per_Minggu adalah field yang berisi jumlah jam pelajaran per minggu, dipakai untuk menghitung beban mengajar guru.
+	Jam_Per_Minggu int `json:"jam_per_minggu"`
 	// Update_At adalah field yang berisi waktu update terakhir data mata pelajaran.
 	Update_At string `json:"update_at"`
 	// Delete_At adalah field yang berisi waktu delete data mata pelajaran.
 	Delete_At string `json:"delete_at"`
+	// Version adalah field yang berisi versi data untuk optimistic concurrency, dikirim sebagai ETag.
+	Version int `json:"version"`
+}
+
+// PengajarCore adalah struktur data yang berisi satu guru pengajar sebuah penugasan mata pelajaran.
+type PengajarCore struct {
+	// ID_Guru adalah field yang berisi ID guru pengajar.
+	ID_Guru string `json:"id_guru"`
+	// Nama adalah field yang berisi nama guru pengajar.
+	Nama string `json:"nama"`
+	// Utama adalah field yang bernilai true untuk guru utama; setiap penugasan punya paling banyak satu guru utama.
+	Utama bool `json:"utama"`
+}
+
+// KatalogMapelCore adalah struktur data yang berisi satu mata pelajaran di katalog.
+// Katalog menyimpan nama dan deskripsi sekali saja walaupun mapel diajarkan di banyak kelas.
+type KatalogMapelCore struct {
+	// ID adalah field yang berisi ID unik mapel di katalog.
+	ID string `json:"id"`
+	// Kode adalah field yang berisi kode unik mapel, misalnya "MTK". Boleh kosong.
+	Kode string `json:"kode"`
+	// Nama adalah field yang berisi nama mata pelajaran.
+	Nama string `json:"nama"`
+	// Deskripsi adalah field yang berisi deskripsi singkat tentang mata pelajaran.
+	Deskripsi string `json:"deskripsi"`
+	// Kelompok adalah field yang berisi kelompok mata pelajaran, misalnya "Wajib" atau "Peminatan".
+	Kelompok string `json:"kelompok"`
+	// JumlahPenugasan adalah field yang berisi jumlah penugasan aktif mapel ini di kelas.
+	JumlahPenugasan int `json:"jumlah_penugasan"`
+	// Version adalah field yang berisi versi data untuk optimistic concurrency, dikirim sebagai ETag.
+	Version int `json:"version"`
 }
 
 // DataMataPelajaranInterface adalah interface yang berisi method2 yang digunakan
@@ -28,19 +78,35 @@ type MataPelajaranCore struct {
 type DataMataPelajaranInterface interface {
 	// SelectAllMapel adalah method yang digunakan untuk mengambil semua data mata pelajaran
 	// dari database.
-	SelectAllMapel() ([]MataPelajaranCore, error)
+	SelectAllMapel(ctx context.Context) ([]MataPelajaranCore, error)
 	// SelectMapelById adalah method yang digunakan untuk mengambil data mata pelajaran
 	// berdasarkan ID dari database.
-	SelectMapelById(id string) (*MataPelajaranCore, error)
+	SelectMapelById(ctx context.Context, id string) (*MataPelajaranCore, error)
 	// InsertMapel adalah method yang digunakan untuk menginsert data mata pelajaran
 	// ke dalam database.
-	InsertMapel(insert *MataPelajaranCore) error
+	InsertMapel(ctx context.Context, insert *MataPelajaranCore) error
 	// UpdateMapel adalah method yang digunakan untuk mengupdate data mata pelajaran
 	// berdasarkan ID di database.
-	UpdateMapel(insert *MataPelajaranCore, id string) error
+	UpdateMapel(ctx context.Context, insert *MataPelajaranCore, id string) error
 	// DeleteMapel adalah method yang digunakan untuk menghapus data mata pelajaran
-	// berdasarkan ID di database.
-	DeleteMapel(id string) error
+	// berdasarkan ID di database jika versinya masih sama dengan version.
+	DeleteMapel(ctx context.Context, id string, version int) error
+	// SelectAllKatalog adalah method yang digunakan untuk mengambil semua mapel aktif di katalog.
+	SelectAllKatalog(ctx context.Context) ([]KatalogMapelCore, error)
+	// SelectKatalogById adalah method yang digunakan untuk mengambil mapel di katalog berdasarkan ID.
+	// Method ini mengembalikan pgx.ErrNoRows jika mapel tidak ditemukan.
+	SelectKatalogById(ctx context.Context, id string) (*KatalogMapelCore, error)
+	// InsertKatalog adalah method yang digunakan untuk menginsert mapel baru ke katalog.
+	InsertKatalog(ctx context.Context, insert *KatalogMapelCore) error
+	// UpdateKatalog adalah method yang digunakan untuk mengupdate mapel di katalog
+	// jika versinya masih sama dengan insert.Version.
+	UpdateKatalog(ctx context.Context, insert *KatalogMapelCore, id string) error
+	// DeleteKatalog adalah method yang digunakan untuk menghapus mapel di katalog
+	// jika versinya masih sama dengan version.
+	DeleteKatalog(ctx context.Context, id string, version int) error
+	// ListPenugasanKatalog adalah method yang digunakan untuk mengambil penugasan aktif
+	// yang masih memakai mapel di katalog.
+	ListPenugasanKatalog(ctx context.Context, id string) ([]helper.Dependent, error)
 }
 
 // ServiceMapelInterface adalah interface yang berisi method2 yang digunakan
@@ -48,17 +114,32 @@ type DataMataPelajaranInterface interface {
 type ServiceMapelInterface interface {
 	// SelectAllMapel adalah method yang digunakan untuk mengambil semua data mata pelajaran
 	// dari database.
-	SelectAllMapel() ([]MataPelajaranCore, error)
+	SelectAllMapel(ctx context.Context) ([]MataPelajaranCore, error)
 	// SelectMapelById adalah method yang digunakan untuk mengambil data mata pelajaran
 	// berdasarkan ID dari database.
-	SelectMapelById(id string) (*MataPelajaranCore, error)
+	SelectMapelById(ctx context.Context, id string) (*MataPelajaranCore, error)
 	// InsertMapel adalah method yang digunakan untuk menginsert data mata pelajaran
 	// ke dalam database.
-	InsertMapel(insert *MataPelajaranCore) error
+	InsertMapel(ctx context.Context, insert *MataPelajaranCore) error
 	// UpdateMapel adalah method yang digunakan untuk mengupdate data mata pelajaran
 	// berdasarkan ID di database.
-	UpdateMapel(insert *MataPelajaranCore, id string) error
+	UpdateMapel(ctx context.Context, insert *MataPelajaranCore, id string) error
 	// DeleteMapel adalah method yang digunakan untuk menghapus data mata pelajaran
-	// berdasarkan ID di database.
-	DeleteMapel(id string) error
+	// berdasarkan ID di database jika versinya masih sama dengan version.
+	DeleteMapel(ctx context.Context, id string, version int) error
+	// Bulk adalah method yang digunakan untuk menjalankan banyak operasi create, update,
+	// dan delete mata pelajaran sekaligus sesuai mode dan mengembalikan hasil per operasi.
+	Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[MataPelajaranCore]) (*helper.BulkResult, error)
+	// GetAllKatalog adalah method yang digunakan untuk mengambil semua mapel di katalog.
+	GetAllKatalog(ctx context.Context) ([]KatalogMapelCore, error)
+	// GetKatalogById adalah method yang digunakan untuk mengambil mapel di katalog berdasarkan ID.
+	GetKatalogById(ctx context.Context, id string) (*KatalogMapelCore, error)
+	// InsertKatalog adalah method yang digunakan untuk menambah mapel ke katalog.
+	InsertKatalog(ctx context.Context, insert *KatalogMapelCore) error
+	// UpdateKatalog adalah method yang digunakan untuk mengupdate mapel di katalog.
+	// Version harus sama dengan versi mapel saat ini, jika tidak dikembalikan helper.ErrVersionConflict.
+	UpdateKatalog(ctx context.Context, insert *KatalogMapelCore, id string) error
+	// DeleteKatalog adalah method yang digunakan untuk menghapus mapel dari katalog.
+	// Method ini mengembalikan *helper.DeleteBlockedError jika mapel masih dipakai penugasan aktif.
+	DeleteKatalog(ctx context.Context, id string, version int) error
 }
@@ -8,9 +8,18 @@ type MataPelajaran struct {
 	// ID adalah field yang digunakan untuk menyimpan ID mata pelajaran.
 	ID string `json:"id"`
 
-	// Nama_Pelajaran adalah field yang digunakan untuk menyimpan nama mata pelajaran.
+	// Mapel_ID adalah field yang digunakan untuk menyimpan ID mapel di katalog.
+	Mapel_ID string `json:"mapel_id"`
+
+	// Kode adalah field yang digunakan untuk menyimpan kode mapel dari katalog.
+	Kode string `json:"kode"`
+
+	// Nama_Pelajaran adalah field yang digunakan untuk menyimpan nama mata pelajaran dari katalog.
 	Nama_Pelajaran string `json:"mata_pelajaran"`
 
+	// Kelompok adalah field yang digunakan untuk menyimpan kelompok mapel dari katalog.
+	Kelompok string `json:"kelompok"`
+
 	// ID_Guru adalah field yang digunakan untuk menyimpan ID guru yang mengajar mata pelajaran.
 	ID_Guru string `json:"id_guru"`
 
@@ -23,14 +32,23 @@ type MataPelajaran struct {
 	// Nama_Kelas adalah field yang digunakan untuk menyimpan nama kelas yang mengajar mata pelajaran.
 	Nama_Kelas string `json:"nama_kelas"`
 
-	// Deskripsi adalah field yang digunakan untuk menyimpan deskripsi mata pelajaran.
+	// Periode adalah field yang digunakan untuk menyimpan periode penugasan mata pelajaran.
+	Periode string `json:"periode"`
+
+	// Deskripsi adalah field yang digunakan untuk menyimpan deskripsi mata pelajaran dari katalog.
 	Deskripsi string `json:"deskripsi"`
 
+	// Jam_Per_Minggu adalah field yang digunakan untuk menyimpan jumlah jam pelajaran per minggu.
+	Jam_Per_Minggu int `json:"jam_per_minggu"`
+
 	// Update_At adalah field yang digunakan untuk menyimpan waktu terakhir data mata pelajaran diupdate.
 	Update_At string `json:"update_at"`
 
 	// Delete_At adalah field yang digunakan untuk menyimpan waktu terakhir data mata pelajaran dihapus.
 	Delete_At string `json:"delete_at"`
+
+	// Version adalah field yang digunakan untuk menyimpan versi data yang bertambah setiap kali diubah atau dihapus.
+	Version int `json:"version"`
 }
 
 // TableName adalah metode yang digunakan untuk mengembalikan nama tabel
@@ -47,6 +65,14 @@ func (m *MataPelajaran) TableName() string {
 func FormatterRequest(req matapelajaran.MataPelajaranCore) MataPelajaran {
 	// Mengisi field ID dengan ID dari objek MataPelajaranCore
 	ID := req.ID
+	// Mengisi field Mapel_ID dengan ID mapel di katalog dari objek MataPelajaranCore
+	Mapel_ID := req.Mapel_ID
+	// Mengisi field Kode dengan kode mapel dari objek MataPelajaranCore
+	Kode := req.Kode
+	// Mengisi field Kelompok dengan kelompok mapel dari objek MataPelajaranCore
+	Kelompok := req.Kelompok
+	// Mengisi field Periode dengan periode penugasan dari objek MataPelajaranCore
+	Periode := req.Periode
 	// Mengisi field Nama_Pelajaran dengan nama pelajaran dari objek MataPelajaranCore
 	Nama_Pelajaran := req.Nama_Pelajaran
 	// Mengisi field ID_Guru dengan ID guru yang mengajar mata pelajaran dari objek MataPelajaranCore
@@ -59,18 +85,25 @@ func FormatterRequest(req matapelajaran.MataPelajaranCore) MataPelajaran {
 	Nama_Kelas := req.Nama_Kelas
 	// Mengisi field Deskripsi dengan deskripsi mata pelajaran dari objek MataPelajaranCore
 	Deskripsi := req.Deskripsi
+	// Mengisi field Jam_Per_Minggu dengan jumlah jam pelajaran per minggu dari objek MataPelajaranCore
+	Jam_Per_Minggu := req.Jam_Per_Minggu
 	// Mengisi field Update_At dengan waktu terakhir data mata pelajaran diupdate dari objek MataPelajaranCore
 	Update_At := req.Update_At
 
 	// Mengembalikan objek MataPelajaran yang telah di format
 	return MataPelajaran{
 		ID:             ID,
+		Mapel_ID:       Mapel_ID,
+		Kode:           Kode,
 		Nama_Pelajaran: Nama_Pelajaran,
+		Kelompok:       Kelompok,
+		Periode:        Periode,
 		ID_Guru:        ID_Guru,
 		Guru:           Guru,
 		Kelas_ID:       Kelas_ID,
 		Nama_Kelas:     Nama_Kelas,
 		Deskripsi:      Deskripsi,
+		Jam_Per_Minggu: Jam_Per_Minggu,
 		Update_At:      Update_At,
 	}
 }
@@ -81,6 +114,14 @@ func FormatterRequest(req matapelajaran.MataPelajaranCore) MataPelajaran {
 func FormatterResponse(res MataPelajaran) matapelajaran.MataPelajaranCore {
 	// Mengisi field ID dengan ID dari objek MataPelajaran
 	ID := res.ID
+	// Mengisi field Mapel_ID dengan ID mapel di katalog dari objek MataPelajaran
+	Mapel_ID := res.Mapel_ID
+	// Mengisi field Kode dengan kode mapel dari objek MataPelajaran
+	Kode := res.Kode
+	// Mengisi field Kelompok dengan kelompok mapel dari objek MataPelajaran
+	Kelompok := res.Kelompok
+	// Mengisi field Periode dengan periode penugasan dari objek MataPelajaran
+	Periode := res.Periode
 	// Mengisi field Nama_Pelajaran dengan nama pelajaran dari objek MataPelajaran
 	Nama_Pelajaran := res.Nama_Pelajaran
 	// Mengisi field ID_Guru dengan ID guru yang mengajar mata pelajaran dari objek MataPelajaran
@@ -93,15 +134,25 @@ func FormatterResponse(res MataPelajaran) matapelajaran.MataPelajaranCore {
 	Nama_Kelas := res.Nama_Kelas
 	// Mengisi field Deskripsi dengan deskripsi mata pelajaran dari objek MataPelajaran
 	Deskripsi := res.Deskripsi
+	// Mengisi field Jam_Per_Minggu dengan jumlah jam pelajaran per minggu dari objek MataPelajaran
+	Jam_Per_Minggu := res.Jam_Per_Minggu
+	// Mengisi field Version dengan versi data dari objek MataPelajaran
+	Version := res.Version
 
 	// Mengembalikan objek MataPelajaranCore yang telah di format
 	return matapelajaran.MataPelajaranCore{
 		ID:             ID,
+		Mapel_ID:       Mapel_ID,
+		Kode:           Kode,
 		Nama_Pelajaran: Nama_Pelajaran,
+		Kelompok:       Kelompok,
+		Periode:        Periode,
 		ID_Guru:        ID_Guru,
 		Guru:           Guru,
 		Kelas_ID:       Kelas_ID,
 		Nama_Kelas:     Nama_Kelas,
 		Deskripsi:      Deskripsi,
+		Jam_Per_Minggu: Jam_Per_Minggu,
+		Version:        Version,
 	}
 }
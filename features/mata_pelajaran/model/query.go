@@ -5,25 +5,25 @@ import (
 	"errors"
 	"fmt"
 	matapelajaran "go_rest_native_sekolah/features/mata_pelajaran"
-	"log"
+	"go_rest_native_sekolah/helper"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// mataPelajaranQuery adalah struktur data yang berisi pointer ke objek pgxpool.Pool.
+// mataPelajaranQuery adalah struktur data yang berisi koneksi database (pool atau transaksi).
 // Struktur data ini digunakan untuk menghandle query ke database yang berhubungan dengan tabel mata_pelajaran.
 type mataPelajaranQuery struct {
-	db *pgxpool.Pool // db adalah pointer ke objek pgxpool.Pool yang berisi koneksi database.
+	db helper.DBTX // db adalah pool atau transaksi yang berisi koneksi database.
 }
 
 // NewDataMataPelajaran membuat objek mataPelajaranQuery yang berisi koneksi database.
 // Fungsi ini digunakan untuk menginisialisasi objek mataPelajaranQuery yang berisi koneksi database.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
 // Jika parameter db nil maka akan terjadi panic.
 // Fungsi ini digunakan untuk menghandle query ke database yang berhubungan dengan tabel mata_pelajaran.
-func NewDataMataPelajaran(db *pgxpool.Pool) matapelajaran.DataMataPelajaranInterface {
+func NewDataMataPelajaran(db helper.DBTX) matapelajaran.DataMataPelajaranInterface {
 	// Jika db nil maka akan terjadi panic
 	if db == nil {
 		panic("Nil database")
@@ -35,7 +35,7 @@ func NewDataMataPelajaran(db *pgxpool.Pool) matapelajaran.DataMataPelajaranInter
 
 // InsertMapel digunakan untuk menginsert data mata pelajaran ke dalam database.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore) error {
+func (m *mataPelajaranQuery) InsertMapel(ctx context.Context, insert *matapelajaran.MataPelajaranCore) error {
 	// Cek koneksi database, jika nil kembalikan error.
 	if m.db == nil {
 		return errors.New("Nil database")
@@ -51,16 +51,21 @@ func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore
 		insert.ID = uuid.New().String()
 	}
 
+	// Cari mapel di katalog berdasarkan Mapel_ID, Kode, atau nama; buat baru jika belum ada.
+	if err := m.resolveKatalog(ctx, insert); err != nil {
+		return err
+	}
+
 	// Validasi dan sinkronisasi Nama_Guru & ID_Guru.
 	switch {
 	case insert.Guru != "" && insert.ID_Guru == "":
 		// Jika hanya Nama_Guru diisi, cari ID_Guru berdasarkan Nama_Guru.
 		insert.Guru = strings.TrimSpace(insert.Guru)
 		var guruID string
-		err := m.db.QueryRow(context.Background(),
+		err := m.db.QueryRow(ctx,
 			"SELECT id FROM guru WHERE TRIM(nama) ILIKE TRIM($1)", insert.Guru).Scan(&guruID)
 		if err != nil {
-			log.Printf("InsertMapel: nama guru '%s' tidak ditemukan", insert.Guru)
+			helper.LoggerFromContext(ctx).Warn("InsertMapel: nama guru tidak ditemukan", "guru", insert.Guru)
 			return fmt.Errorf("guru dengan nama '%s' tidak ditemukan", insert.Guru)
 		}
 		insert.ID_Guru = guruID
@@ -68,10 +73,10 @@ func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore
 	case insert.ID_Guru != "" && insert.Guru == "":
 		// Jika hanya ID_Guru diisi, cari Nama_Guru berdasarkan ID_Guru.
 		var namaGuru string
-		err := m.db.QueryRow(context.Background(),
+		err := m.db.QueryRow(ctx,
 			"SELECT nama FROM guru WHERE id = $1", insert.ID_Guru).Scan(&namaGuru)
 		if err != nil {
-			log.Printf("InsertMapel: ID guru '%s' tidak ditemukan", insert.ID_Guru)
+			helper.LoggerFromContext(ctx).Warn("InsertMapel: ID guru tidak ditemukan", "id_guru", insert.ID_Guru)
 			return fmt.Errorf("guru dengan ID '%s' tidak ditemukan", insert.ID_Guru)
 		}
 		insert.Guru = namaGuru
@@ -80,14 +85,14 @@ func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore
 		// Jika keduanya diisi, validasi apakah cocok.
 		insert.Guru = strings.TrimSpace(insert.Guru)
 		var existingName string
-		err := m.db.QueryRow(context.Background(),
+		err := m.db.QueryRow(ctx,
 			"SELECT nama FROM guru WHERE id = $1", insert.ID_Guru).Scan(&existingName)
 		if err != nil {
-			log.Printf("InsertMapel: ID guru '%s' tidak ditemukan", insert.ID_Guru)
+			helper.LoggerFromContext(ctx).Warn("InsertMapel: ID guru tidak ditemukan", "id_guru", insert.ID_Guru)
 			return fmt.Errorf("guru dengan ID '%s' tidak ditemukan", insert.ID_Guru)
 		}
 		if strings.ToLower(strings.TrimSpace(existingName)) != strings.ToLower(insert.Guru) {
-			log.Printf("InsertMapel: Nama guru tidak cocok. Dapat: '%s', seharusnya: '%s'", insert.Guru, existingName)
+			helper.LoggerFromContext(ctx).Warn("InsertMapel: Nama guru tidak cocok", "guru", insert.Guru, "seharusnya", existingName)
 			return fmt.Errorf("nama guru '%s' tidak cocok dengan ID guru '%s'", insert.Guru, insert.ID_Guru)
 		}
 	}
@@ -98,10 +103,10 @@ func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore
 		// Jika hanya Nama_Kelas diisi, cari Kelas_ID berdasarkan Nama_Kelas.
 		insert.Nama_Kelas = strings.TrimSpace(insert.Nama_Kelas)
 		var kelasID string
-		err := m.db.QueryRow(context.Background(),
+		err := m.db.QueryRow(ctx,
 			"SELECT id FROM kelas WHERE TRIM(kelas) ILIKE TRIM($1)", insert.Nama_Kelas).Scan(&kelasID)
 		if err != nil {
-			log.Printf("InsertMapel: nama kelas '%s' tidak ditemukan", insert.Nama_Kelas)
+			helper.LoggerFromContext(ctx).Warn("InsertMapel: nama kelas tidak ditemukan", "nama_kelas", insert.Nama_Kelas)
 			return fmt.Errorf("kelas dengan nama '%s' tidak ditemukan", insert.Nama_Kelas)
 		}
 		insert.Kelas_ID = kelasID
@@ -109,10 +114,10 @@ func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore
 	case insert.Kelas_ID != "" && insert.Nama_Kelas == "":
 		// Jika hanya Kelas_ID diisi, cari Nama_Kelas berdasarkan Kelas_ID.
 		var namaKelas string
-		err := m.db.QueryRow(context.Background(),
+		err := m.db.QueryRow(ctx,
 			"SELECT kelas FROM kelas WHERE id = $1", insert.Kelas_ID).Scan(&namaKelas)
 		if err != nil {
-			log.Printf("InsertMapel: ID kelas '%s' tidak ditemukan", insert.Kelas_ID)
+			helper.LoggerFromContext(ctx).Warn("InsertMapel: ID kelas tidak ditemukan", "kelas_id", insert.Kelas_ID)
 			return fmt.Errorf("kelas dengan ID '%s' tidak ditemukan", insert.Kelas_ID)
 		}
 		insert.Nama_Kelas = namaKelas
@@ -121,26 +126,19 @@ func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore
 		// Jika keduanya diisi, validasi apakah cocok.
 		insert.Nama_Kelas = strings.TrimSpace(insert.Nama_Kelas)
 		var existingKelas string
-		err := m.db.QueryRow(context.Background(),
+		err := m.db.QueryRow(ctx,
 			"SELECT kelas FROM kelas WHERE id = $1", insert.Kelas_ID).Scan(&existingKelas)
 		if err != nil {
-			log.Printf("InsertMapel: ID kelas '%s' tidak ditemukan", insert.Kelas_ID)
+			helper.LoggerFromContext(ctx).Warn("InsertMapel: ID kelas tidak ditemukan", "kelas_id", insert.Kelas_ID)
 			return fmt.Errorf("kelas dengan ID '%s' tidak ditemukan", insert.Kelas_ID)
 		}
 		if strings.ToLower(strings.TrimSpace(existingKelas)) != strings.ToLower(insert.Nama_Kelas) {
-			log.Printf("InsertMapel: Nama kelas tidak cocok. Dapat: '%s', seharusnya: '%s'", insert.Nama_Kelas, existingKelas)
+			helper.LoggerFromContext(ctx).Warn("InsertMapel: Nama kelas tidak cocok", "nama_kelas", insert.Nama_Kelas, "seharusnya", existingKelas)
 			return fmt.Errorf("nama kelas '%s' tidak cocok dengan ID kelas '%s'", insert.Nama_Kelas, insert.Kelas_ID)
 		}
 	}
 
-	// Siapkan ID_Guru & Kelas_ID agar bisa null jika kosong.
-	var idGuruParam interface{}
-	if insert.ID_Guru == "" {
-		idGuruParam = nil
-	} else {
-		idGuruParam = insert.ID_Guru
-	}
-
+	// Siapkan Kelas_ID agar bisa null jika kosong.
 	var idKelasParam interface{}
 	if insert.Kelas_ID == "" {
 		idKelasParam = nil
@@ -148,15 +146,25 @@ func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore
 		idKelasParam = insert.Kelas_ID
 	}
 
-	// Eksekusi query insert data mata pelajaran ke dalam database.
-	_, err := m.db.Exec(context.Background(),
-		"INSERT INTO mata_pelajaran (id, nama_pelajaran, id_guru, kelas_id, deskripsi) VALUES ($1, $2, $3, $4, $5)",
-		insert.ID, insert.Nama_Pelajaran, idGuruParam, idKelasParam, insert.Deskripsi)
+	// Guru yang dicari dari nama menjadi guru utama, sama seperti ID_Guru.
+	if err := m.resolvePengajar(ctx, insert); err != nil {
+		return err
+	}
+
+	// Eksekusi query insert penugasan mata pelajaran ke dalam database.
+	_, err := m.db.Exec(ctx,
+		"INSERT INTO mata_pelajaran (id, mapel_id, kelas_id, periode, jam_per_minggu) VALUES ($1, $2, $3, $4, $5)",
+		insert.ID, insert.Mapel_ID, idKelasParam, insert.Periode, insert.Jam_Per_Minggu)
 	if err != nil {
-		log.Printf("InsertMapel error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("InsertMapel error exec", "error", err)
 		return fmt.Errorf("insert failed: %w", err)
 	}
 
+	// Simpan guru pengajar penugasan.
+	if err := m.simpanPengajar(ctx, insert.ID, insert.Pengajar); err != nil {
+		return err
+	}
+
 	// Kembalikan nil jika tidak terjadi kesalahan.
 	return nil
 }
@@ -165,30 +173,35 @@ func (m *mataPelajaranQuery) InsertMapel(insert *matapelajaran.MataPelajaranCore
 // Fungsi ini digunakan untuk mengambil semua data mata pelajaran dari database.
 // Fungsi ini mengembalikan slice MataPelajaranCore yang berisi data mata pelajaran.
 // Jika terjadi error maka fungsi ini akan mengembalikan error.
-func (m *mataPelajaranQuery) SelectAllMapel() ([]matapelajaran.MataPelajaranCore, error) {
+func (m *mataPelajaranQuery) SelectAllMapel(ctx context.Context) ([]matapelajaran.MataPelajaranCore, error) {
 	if m.db == nil {
 		// Jika database tidak ada, kembalikan error.
 		return nil, errors.New("Nil database")
 	}
-	// Buat query untuk mengambil semua data mata pelajaran.
+	// Buat query untuk mengambil semua penugasan mata pelajaran beserta data katalognya.
 	query := `SELECT 
     mp.id,
-    mp.nama_pelajaran,
-    mp.id_guru,
-    g.nama AS nama_guru,
-    mp.kelas_id,
-    k.kelas AS nama_kelas,
-    mp.deskripsi
+    mp.mapel_id,
+    COALESCE(m.kode, '') AS kode,
+    m.nama AS nama_pelajaran,
+    COALESCE(m.kelompok, '') AS kelompok,
+    COALESCE(mp.kelas_id, '') AS kelas_id,
+    COALESCE(k.kelas, '') AS nama_kelas,
+    mp.periode,
+    COALESCE(m.deskripsi, '') AS deskripsi,
+    mp.jam_per_minggu,
+    mp.version
 FROM mata_pelajaran mp
-LEFT JOIN guru g ON mp.id_guru = g.id
+JOIN mapel m ON mp.mapel_id = m.id
 LEFT JOIN kelas k ON mp.kelas_id = k.id
-WHERE mp.delete_at IS NULL;`
+WHERE mp.delete_at IS NULL
+ORDER BY m.nama, k.kelas;`
 
 	// Jalankan query dan simpan hasilnya dalam rows.
-	rows, err := m.db.Query(context.Background(), query)
+	rows, err := m.db.Query(ctx, query)
 	if err != nil {
 		// Jika terjadi error saat eksekusi query, log error dan kembalikan.
-		log.Printf("SelectAllMapel error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("SelectAllMapel error exec", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
 	defer rows.Close() // Pastikan rows ditutup setelah selesai digunakan.
@@ -205,17 +218,26 @@ WHERE mp.delete_at IS NULL;`
 		// Pindai setiap baris ke dalam variabel mp.
 		// Fungsi Scan digunakan untuk memindai setiap baris yang diiterasi
 		// dan menyimpannya dalam variabel mp.
-		err = rows.Scan(&mp.ID, &mp.Nama_Pelajaran, &mp.ID_Guru, &mp.Guru, &mp.Kelas_ID, &mp.Nama_Kelas, &mp.Deskripsi)
+		err = rows.Scan(&mp.ID, &mp.Mapel_ID, &mp.Kode, &mp.Nama_Pelajaran, &mp.Kelompok, &mp.Kelas_ID, &mp.Nama_Kelas, &mp.Periode, &mp.Deskripsi, &mp.Jam_Per_Minggu, &mp.Version)
 		if err != nil {
 			// Jika terjadi error saat scan, log error dan kembalikan.
-			log.Printf("SelectAllMapel error scan: %v", err)
+			helper.LoggerFromContext(ctx).Error("SelectAllMapel error scan", "error", err)
 			return nil, fmt.Errorf("scan failed: %w", err)
 		}
 		// Ubah data mp menjadi MataPelajaranCore dan tambahkan ke result.
 		core := FormatterResponse(mp)
 		result = append(result, core)
 	}
-	log.Printf("Successfully fetched %d mata pelajaran from database", len(result))
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+	rows.Close()
+
+	// Lengkapi setiap penugasan dengan guru pengajarnya.
+	if err := m.isiPengajar(ctx, result); err != nil {
+		return nil, err
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully fetched mata pelajaran from database", "count", len(result))
 	// Kembalikan slice MataPelajaranCore yang berisi data mata pelajaran.
 	return result, nil
 }
@@ -224,7 +246,7 @@ WHERE mp.delete_at IS NULL;`
 // Fungsi ini digunakan untuk mengambil data mata pelajaran berdasarkan id.
 // Fungsi ini mengembalikan objek MataPelajaranCore yang berisi data mata pelajaran.
 // Jika tidak ada data maka fungsi ini akan mengembalikan error.
-func (m *mataPelajaranQuery) SelectMapelById(id string) (*matapelajaran.MataPelajaranCore, error) {
+func (m *mataPelajaranQuery) SelectMapelById(ctx context.Context, id string) (*matapelajaran.MataPelajaranCore, error) {
 	if m.db == nil {
 		// Jika database tidak ada, kembalikan error.
 		return nil, errors.New("Nil database")
@@ -234,17 +256,21 @@ func (m *mataPelajaranQuery) SelectMapelById(id string) (*matapelajaran.MataPela
 		return nil, errors.New("ID cannot be empty")
 	}
 
-	// Buat query untuk mengambil data mata pelajaran berdasarkan id.
+	// Buat query untuk mengambil penugasan mata pelajaran berdasarkan id beserta data katalognya.
 	query := `SELECT 
 		mp.id,
-		mp.nama_pelajaran,
-		mp.id_guru,
-		g.nama AS nama_guru,
-		mp.kelas_id,
-		k.kelas AS nama_kelas,
-		mp.deskripsi
+		mp.mapel_id,
+		COALESCE(m.kode, '') AS kode,
+		m.nama AS nama_pelajaran,
+		COALESCE(m.kelompok, '') AS kelompok,
+		COALESCE(mp.kelas_id, '') AS kelas_id,
+		COALESCE(k.kelas, '') AS nama_kelas,
+		mp.periode,
+		COALESCE(m.deskripsi, '') AS deskripsi,
+		mp.jam_per_minggu,
+		mp.version
 	FROM mata_pelajaran mp
-	LEFT JOIN guru g ON mp.id_guru = g.id
+	JOIN mapel m ON mp.mapel_id = m.id
 	LEFT JOIN kelas k ON mp.kelas_id = k.id
 	WHERE mp.id = $1 AND mp.delete_at IS NULL`
 
@@ -254,14 +280,18 @@ func (m *mataPelajaranQuery) SelectMapelById(id string) (*matapelajaran.MataPela
 	// Jalankan query untuk mendapatkan data mata pelajaran berdasarkan id, dan pindai hasilnya ke dalam variabel mp
 	// Fungsi QueryRow digunakan untuk mengeksekusi query yang mengembalikan satu baris hasil.
 	// Kemudian, fungsi Scan digunakan untuk memindai hasil query ke dalam variabel mp.
-	err := m.db.QueryRow(context.Background(), query, id).Scan(
+	err := m.db.QueryRow(ctx, query, id).Scan(
 		&mp.ID,             // Memindai ID mata pelajaran
+		&mp.Mapel_ID,       // Memindai ID mapel di katalog
+		&mp.Kode,           // Memindai kode mapel
 		&mp.Nama_Pelajaran, // Memindai nama mata pelajaran
-		&mp.ID_Guru,        // Memindai ID guru
-		&mp.Guru,           // Memindai nama guru
+		&mp.Kelompok,       // Memindai kelompok mapel
 		&mp.Kelas_ID,       // Memindai ID kelas
 		&mp.Nama_Kelas,     // Memindai nama kelas
+		&mp.Periode,        // Memindai periode penugasan
 		&mp.Deskripsi,      // Memindai deskripsi mata pelajaran
+		&mp.Jam_Per_Minggu, // Memindai jam pelajaran per minggu
+		&mp.Version,        // Memindai versi data mata pelajaran
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -269,19 +299,23 @@ func (m *mataPelajaranQuery) SelectMapelById(id string) (*matapelajaran.MataPela
 			return nil, errors.New("ID not found")
 		}
 		// Jika terjadi error saat query maka log error dan kembalikan.
-		log.Printf("QueryRow error: %v", err)
+		helper.LoggerFromContext(ctx).Error("QueryRow error", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
+	// Lengkapi penugasan dengan guru pengajarnya.
+	result := []matapelajaran.MataPelajaranCore{mp}
+	if err := m.isiPengajar(ctx, result); err != nil {
+		return nil, err
+	}
 	// Jika data berhasil diambil maka log pesan sukses dan kembalikan data.
-	log.Printf("Successfully fetched mata pelajaran with id: %s", id)
-	return &mp, nil
+	helper.LoggerFromContext(ctx).Info("Successfully fetched mata pelajaran", "id", id)
+	return &result[0], nil
 }
 
-// UpdateMapel implements matapelajaran.DataMataPelajaranInterface.
 // UpdateMapel implements matapelajaran.DataMataPelajaranInterface.
 // Fungsi ini digunakan untuk mengupdate data mata pelajaran berdasarkan id.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (m *mataPelajaranQuery) UpdateMapel(update *matapelajaran.MataPelajaranCore, id string) error {
+func (m *mataPelajaranQuery) UpdateMapel(ctx context.Context, update *matapelajaran.MataPelajaranCore, id string) error {
 	// Cek apakah database ada atau tidak.
 	if m.db == nil {
 		return errors.New("Nil database")
@@ -291,50 +325,76 @@ func (m *mataPelajaranQuery) UpdateMapel(update *matapelajaran.MataPelajaranCore
 		return errors.New("ID cannot be empty")
 	}
 
-	// Buat query untuk mengupdate data mata pelajaran berdasarkan id.
-	// Query ini akan mengupdate nama_pelajaran, id_guru, kelas_id, dan deskripsi.
-	// Dan akan mengupdate update_at dengan waktu sekarang.
+	// Cari mapel di katalog; service mengisi Mapel_ID lama jika client tidak mengganti mapel.
+	if err := m.resolveKatalog(ctx, update); err != nil {
+		return err
+	}
+	// Pastikan semua guru pengajar ada dan isi namanya.
+	if err := m.resolvePengajar(ctx, update); err != nil {
+		return err
+	}
+
+	// Siapkan Kelas_ID agar bisa null jika kosong.
+	var idKelasParam interface{}
+	if update.Kelas_ID != "" {
+		idKelasParam = update.Kelas_ID
+	}
+
+	// Buat query untuk mengupdate penugasan mata pelajaran berdasarkan id.
+	// Query ini akan mengupdate mapel_id, kelas_id, periode, dan jam_per_minggu.
+	// Dan akan mengupdate update_at dengan waktu sekarang serta menaikkan version.
+	// Update hanya berhasil jika version masih sama dengan versi yang dibaca client.
 	query := `
 	UPDATE mata_pelajaran 
-	SET nama_pelajaran = $1,
-		id_guru = $2,
-		kelas_id = $3,
-		deskripsi = $4,
-		update_at = CURRENT_TIMESTAMP
-	WHERE id = $5 AND delete_at IS NULL;
+	SET mapel_id = $1,
+		kelas_id = $2,
+		periode = $3,
+		jam_per_minggu = $4,
+		update_at = CURRENT_TIMESTAMP,
+		version = version + 1
+	WHERE id = $5 AND delete_at IS NULL AND version = $6;
 	`
 
 	// Jalankan query untuk mengupdate data mata pelajaran.
 	// Fungsi Exec digunakan untuk mengeksekusi query yang tidak mengembalikan hasil.
 	res, err := m.db.Exec(
-		context.Background(), query,
-		update.Nama_Pelajaran,
-		update.ID_Guru,
-		update.Kelas_ID,
-		update.Deskripsi,
+		ctx, query,
+		update.Mapel_ID,
+		idKelasParam,
+		update.Periode,
+		update.Jam_Per_Minggu,
 		id,
+		update.Version,
 	)
 	if err != nil {
 		// Jika terjadi error saat query maka log error dan kembalikan.
-		log.Printf("UpdateMapel error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("UpdateMapel error exec", "error", err)
 		return fmt.Errorf("update failed: %w", err)
 	}
 	if res.RowsAffected() == 0 {
+		// Jika mata pelajaran masih ada berarti versinya sudah berubah.
+		if err := helper.CheckVersionConflict(ctx, m.db, "mata_pelajaran", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
 		// Jika tidak ada baris yang terpengaruh maka log dan kembalikan error.
-		log.Printf("UpdateUser: no rows updated for id %s", id)
+		helper.LoggerFromContext(ctx).Warn("UpdateMapel: no rows updated", "id", id)
 		return errors.New("update failed: no rows affected")
 	}
+	// Ganti daftar guru pengajar dengan daftar yang baru.
+	if err := m.simpanPengajar(ctx, id, update.Pengajar); err != nil {
+		return err
+	}
 	// Jika data berhasil diupdate maka log pesan sukses dan kembalikan nil.
-	log.Printf("Successfully updated mata_pelajaran with id: %s", id)
+	helper.LoggerFromContext(ctx).Info("Successfully updated mata_pelajaran", "id", id)
 	return nil
 }
 
 // DeleteMapel mengupdate kolom delete_at dengan waktu sekarang pada data mata pelajaran
-// yang dicari berdasarkan id. Jika data berhasil diupdate maka fungsi ini akan
+// yang dicari berdasarkan id dan version. Jika data berhasil diupdate maka fungsi ini akan
 // mengembalikan nil. Jika tidak ada data yang terpengaruh maka fungsi ini akan
 // mengembalikan error. Jika terjadi error saat query maka fungsi ini akan log
 // error dan kembalikan error.
-func (m *mataPelajaranQuery) DeleteMapel(id string) error {
+func (m *mataPelajaranQuery) DeleteMapel(ctx context.Context, id string, version int) error {
 	if m.db == nil {
 		// Jika database tidak ada maka kembalikan error.
 		return errors.New("Nil database")
@@ -345,24 +405,370 @@ func (m *mataPelajaranQuery) DeleteMapel(id string) error {
 	}
 
 	// Buat query untuk mengupdate kolom delete_at dengan waktu sekarang.
-	// Query ini menggunakan parameter $1 untuk menggantikan nilai id.
-	query := "UPDATE mata_pelajaran SET delete_at = NOW() WHERE id = $1 AND delete_at IS NULL"
+	// Query ini menggunakan parameter $1 untuk menggantikan nilai id dan $2 untuk version.
+	query := "UPDATE mata_pelajaran SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2"
 
 	// Jalankan query dan simpan hasilnya dalam res.
 	// Fungsi Exec digunakan untuk mengeksekusi query yang tidak mengembalikan hasil.
-	res, err := m.db.Exec(context.Background(), query, id)
+	res, err := m.db.Exec(ctx, query, id, version)
 	if err != nil {
 		// Jika terjadi error saat query maka log error dan kembalikan.
-		log.Printf("DeleteMapel error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("DeleteMapel error exec", "error", err)
 		return fmt.Errorf("delete failed: %w", err)
 	}
 	if res.RowsAffected() == 0 {
+		// Jika mata pelajaran masih ada berarti versinya sudah berubah.
+		if err := helper.CheckVersionConflict(ctx, m.db, "mata_pelajaran", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
 		// Jika tidak ada baris yang terpengaruh maka log dan kembalikan error.
-		log.Printf("DeleteMapel: no rows deleted for id %s", id)
+		helper.LoggerFromContext(ctx).Warn("DeleteMapel: no rows deleted", "id", id)
 		return errors.New("delete failed: no rows affected")
 	}
 
 	// Jika data berhasil diupdate maka log pesan sukses dan kembalikan nil.
-	log.Printf("Successfully deleted mata_pelajaran with id: %s", id)
+	helper.LoggerFromContext(ctx).Info("Successfully deleted mata_pelajaran", "id", id)
+	return nil
+}
+
+// resolveKatalog mencari mapel di katalog untuk penugasan c berdasarkan Mapel_ID, Kode, atau
+// Nama_Pelajaran (tanpa memperhatikan huruf besar/kecil), lalu mengisi data katalognya ke c.
+// Jika mapel dicari dengan kode atau nama dan belum ada, mapel baru dibuat di katalog
+// dengan Deskripsi dan Kelompok dari c.
+func (m *mataPelajaranQuery) resolveKatalog(ctx context.Context, c *matapelajaran.MataPelajaranCore) error {
+	var katalog []matapelajaran.KatalogMapelCore
+	var err error
+	switch {
+	case c.Mapel_ID != "":
+		katalog, err = m.selectKatalog(ctx, "m.id = $1", c.Mapel_ID)
+		if err == nil && len(katalog) == 0 {
+			return fmt.Errorf("mapel dengan ID '%s' tidak ditemukan", c.Mapel_ID)
+		}
+	case c.Kode != "":
+		katalog, err = m.selectKatalog(ctx, "UPPER(m.kode) = UPPER($1)", c.Kode)
+		if err == nil && len(katalog) == 0 && c.Nama_Pelajaran == "" {
+			return fmt.Errorf("mapel dengan kode '%s' tidak ditemukan", c.Kode)
+		}
+	case c.Nama_Pelajaran != "":
+		katalog, err = m.selectKatalog(ctx, "LOWER(TRIM(m.nama)) = LOWER(TRIM($1))", c.Nama_Pelajaran)
+	default:
+		return errors.New("validation error: mapel_id, kode, atau nama mata pelajaran wajib diisi")
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(katalog) == 0 {
+		// Mapel belum ada di katalog, buat baru dari data penugasan.
+		baru := matapelajaran.KatalogMapelCore{
+			Kode:      c.Kode,
+			Nama:      strings.TrimSpace(c.Nama_Pelajaran),
+			Deskripsi: c.Deskripsi,
+			Kelompok:  c.Kelompok,
+		}
+		if err := m.InsertKatalog(ctx, &baru); err != nil {
+			return err
+		}
+		katalog = append(katalog, baru)
+	}
+
+	c.Mapel_ID = katalog[0].ID
+	c.Kode = katalog[0].Kode
+	c.Nama_Pelajaran = katalog[0].Nama
+	c.Kelompok = katalog[0].Kelompok
+	c.Deskripsi = katalog[0].Deskripsi
+	return nil
+}
+
+// resolvePengajar memastikan semua guru pengajar c masih aktif dan mengisi namanya.
+// Guru dari ID_Guru yang belum ada di daftar pengajar dijadikan guru utama.
+// Setelah itu ID_Guru dan Guru diisi dengan guru utama agar response lama tetap sama.
+func (m *mataPelajaranQuery) resolvePengajar(ctx context.Context, c *matapelajaran.MataPelajaranCore) error {
+	if c.ID_Guru != "" {
+		ada := false
+		for _, p := range c.Pengajar {
+			if p.ID_Guru == c.ID_Guru {
+				ada = true
+			}
+		}
+		if !ada {
+			for i := range c.Pengajar {
+				c.Pengajar[i].Utama = false
+			}
+			c.Pengajar = append([]matapelajaran.PengajarCore{{ID_Guru: c.ID_Guru, Utama: true}}, c.Pengajar...)
+		}
+	}
+
+	c.ID_Guru, c.Guru = "", ""
+	for i := range c.Pengajar {
+		p := &c.Pengajar[i]
+		err := m.db.QueryRow(ctx, "SELECT nama FROM guru WHERE id = $1 AND delete_at IS NULL", p.ID_Guru).Scan(&p.Nama)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				helper.LoggerFromContext(ctx).Warn("resolvePengajar: ID guru tidak ditemukan", "id_guru", p.ID_Guru)
+				return fmt.Errorf("guru dengan ID '%s' tidak ditemukan", p.ID_Guru)
+			}
+			return fmt.Errorf("select guru failed: %w", err)
+		}
+		if p.Utama || c.ID_Guru == "" {
+			c.ID_Guru, c.Guru = p.ID_Guru, p.Nama
+		}
+	}
+	return nil
+}
+
+// simpanPengajar mengganti seluruh guru pengajar penugasan id dengan daftar pengajar.
+func (m *mataPelajaranQuery) simpanPengajar(ctx context.Context, id string, pengajar []matapelajaran.PengajarCore) error {
+	if _, err := m.db.Exec(ctx, "DELETE FROM mata_pelajaran_guru WHERE mata_pelajaran_id = $1", id); err != nil {
+		helper.LoggerFromContext(ctx).Error("simpanPengajar error delete", "error", err)
+		return fmt.Errorf("simpan pengajar failed: %w", err)
+	}
+	for _, p := range pengajar {
+		_, err := m.db.Exec(ctx,
+			"INSERT INTO mata_pelajaran_guru (mata_pelajaran_id, id_guru, utama) VALUES ($1, $2, $3)",
+			id, p.ID_Guru, p.Utama)
+		if err != nil {
+			helper.LoggerFromContext(ctx).Error("simpanPengajar error insert", "error", err)
+			return fmt.Errorf("simpan pengajar failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// isiPengajar mengambil guru pengajar aktif untuk setiap penugasan di cores.
+// Guru utama diurutkan paling depan dan dipakai untuk mengisi ID_Guru dan Guru.
+func (m *mataPelajaranQuery) isiPengajar(ctx context.Context, cores []matapelajaran.MataPelajaranCore) error {
+	if len(cores) == 0 {
+		return nil
+	}
+	ids := make([]string, len(cores))
+	index := make(map[string]int, len(cores))
+	for i := range cores {
+		ids[i] = cores[i].ID
+		index[cores[i].ID] = i
+		cores[i].Pengajar = []matapelajaran.PengajarCore{}
+	}
+
+	rows, err := m.db.Query(ctx, `SELECT mpg.mata_pelajaran_id, g.id, g.nama, mpg.utama
+		FROM mata_pelajaran_guru mpg
+		JOIN guru g ON g.id = mpg.id_guru AND g.delete_at IS NULL
+		WHERE mpg.mata_pelajaran_id = ANY($1)
+		ORDER BY mpg.utama DESC, g.nama`, ids)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("isiPengajar error query", "error", err)
+		return fmt.Errorf("select pengajar failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var p matapelajaran.PengajarCore
+		if err := rows.Scan(&id, &p.ID_Guru, &p.Nama, &p.Utama); err != nil {
+			return fmt.Errorf("scan pengajar failed: %w", err)
+		}
+		c := &cores[index[id]]
+		if len(c.Pengajar) == 0 {
+			c.ID_Guru, c.Guru = p.ID_Guru, p.Nama
+		}
+		c.Pengajar = append(c.Pengajar, p)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("scan pengajar failed: %w", err)
+	}
+	return nil
+}
+
+// selectKatalog mengambil mapel aktif di katalog yang memenuhi kondisi where beserta jumlah penugasan aktifnya.
+// Parameter where adalah kondisi SQL tetap dengan placeholder untuk args, bukan input client.
+func (m *mataPelajaranQuery) selectKatalog(ctx context.Context, where string, args ...any) ([]matapelajaran.KatalogMapelCore, error) {
+	query := `SELECT m.id, COALESCE(m.kode, ''), m.nama, COALESCE(m.deskripsi, ''), COALESCE(m.kelompok, ''), m.version,
+			(SELECT COUNT(*) FROM mata_pelajaran mp WHERE mp.mapel_id = m.id AND mp.delete_at IS NULL)
+		FROM mapel m
+		WHERE m.delete_at IS NULL AND ` + where + `
+		ORDER BY m.nama`
+
+	rows, err := m.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectKatalog error query", "error", err)
+		return nil, fmt.Errorf("select katalog failed: %w", err)
+	}
+	defer rows.Close()
+
+	katalog := []matapelajaran.KatalogMapelCore{}
+	for rows.Next() {
+		var k matapelajaran.KatalogMapelCore
+		if err := rows.Scan(&k.ID, &k.Kode, &k.Nama, &k.Deskripsi, &k.Kelompok, &k.Version, &k.JumlahPenugasan); err != nil {
+			return nil, fmt.Errorf("scan katalog failed: %w", err)
+		}
+		katalog = append(katalog, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("scan katalog failed: %w", err)
+	}
+	return katalog, nil
+}
+
+// SelectAllKatalog implements matapelajaran.DataMataPelajaranInterface.
+// Fungsi ini digunakan untuk mengambil semua mapel aktif di katalog, diurutkan berdasarkan nama.
+func (m *mataPelajaranQuery) SelectAllKatalog(ctx context.Context) ([]matapelajaran.KatalogMapelCore, error) {
+	if m.db == nil {
+		return nil, errors.New("Nil database")
+	}
+	return m.selectKatalog(ctx, "TRUE")
+}
+
+// SelectKatalogById implements matapelajaran.DataMataPelajaranInterface.
+// Fungsi ini mengembalikan pgx.ErrNoRows jika mapel tidak ditemukan.
+func (m *mataPelajaranQuery) SelectKatalogById(ctx context.Context, id string) (*matapelajaran.KatalogMapelCore, error) {
+	if m.db == nil {
+		return nil, errors.New("Nil database")
+	}
+	katalog, err := m.selectKatalog(ctx, "m.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(katalog) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &katalog[0], nil
+}
+
+// cekKodeKatalog memastikan kode mapel belum dipakai mapel lain selain id, termasuk mapel yang sudah dihapus.
+func (m *mataPelajaranQuery) cekKodeKatalog(ctx context.Context, kode, id string) error {
+	if kode == "" {
+		return nil
+	}
+	var dipakai bool
+	err := m.db.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM mapel WHERE UPPER(kode) = UPPER($1) AND id <> $2)", kode, id).Scan(&dipakai)
+	if err != nil {
+		return fmt.Errorf("cek kode mapel failed: %w", err)
+	}
+	if dipakai {
+		return fmt.Errorf("validation error: kode mapel '%s' sudah dipakai", kode)
+	}
+	return nil
+}
+
+// InsertKatalog implements matapelajaran.DataMataPelajaranInterface.
+// Fungsi ini digunakan untuk menginsert mapel baru ke katalog. Kode, deskripsi, dan kelompok boleh kosong.
+func (m *mataPelajaranQuery) InsertKatalog(ctx context.Context, insert *matapelajaran.KatalogMapelCore) error {
+	if m.db == nil {
+		return errors.New("Nil database")
+	}
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+	if err := m.cekKodeKatalog(ctx, insert.Kode, insert.ID); err != nil {
+		return err
+	}
+
+	_, err := m.db.Exec(ctx,
+		"INSERT INTO mapel (id, kode, nama, deskripsi, kelompok) VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), NULLIF($5, ''))",
+		insert.ID, insert.Kode, insert.Nama, insert.Deskripsi, insert.Kelompok)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("InsertKatalog error exec", "error", err)
+		return fmt.Errorf("insert katalog failed: %w", err)
+	}
+	insert.Version = 1
 	return nil
 }
+
+// UpdateKatalog implements matapelajaran.DataMataPelajaranInterface.
+// Fungsi ini digunakan untuk mengupdate mapel di katalog jika versinya masih sama dengan update.Version.
+// Perubahan nama dan deskripsi langsung terlihat di semua penugasan mapel tersebut.
+func (m *mataPelajaranQuery) UpdateKatalog(ctx context.Context, update *matapelajaran.KatalogMapelCore, id string) error {
+	if m.db == nil {
+		return errors.New("Nil database")
+	}
+	if id == "" {
+		return errors.New("ID cannot be empty")
+	}
+	if err := m.cekKodeKatalog(ctx, update.Kode, id); err != nil {
+		return err
+	}
+
+	res, err := m.db.Exec(ctx, `UPDATE mapel
+		SET kode = NULLIF($1, ''), nama = $2, deskripsi = NULLIF($3, ''), kelompok = NULLIF($4, ''),
+			update_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $5 AND delete_at IS NULL AND version = $6`,
+		update.Kode, update.Nama, update.Deskripsi, update.Kelompok, id, update.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("UpdateKatalog error exec", "error", err)
+		return fmt.Errorf("update katalog failed: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		// Jika mapel masih ada berarti versinya sudah berubah.
+		if err := helper.CheckVersionConflict(ctx, m.db, "mapel", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		helper.LoggerFromContext(ctx).Warn("UpdateKatalog: no rows updated", "id", id)
+		return errors.New("update katalog failed: no rows affected")
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully updated mapel", "id", id)
+	return nil
+}
+
+// DeleteKatalog implements matapelajaran.DataMataPelajaranInterface.
+// Fungsi ini menghapus (soft delete) mapel di katalog jika versinya masih sama dengan version.
+func (m *mataPelajaranQuery) DeleteKatalog(ctx context.Context, id string, version int) error {
+	if m.db == nil {
+		return errors.New("Nil database")
+	}
+	if id == "" {
+		return errors.New("ID tidak boleh kosong")
+	}
+
+	res, err := m.db.Exec(ctx,
+		"UPDATE mapel SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2",
+		id, version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("DeleteKatalog error exec", "error", err)
+		return fmt.Errorf("delete katalog failed: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		// Jika mapel masih ada berarti versinya sudah berubah.
+		if err := helper.CheckVersionConflict(ctx, m.db, "mapel", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		helper.LoggerFromContext(ctx).Warn("DeleteKatalog: no rows deleted", "id", id)
+		return errors.New("delete katalog failed: no rows affected")
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted mapel", "id", id)
+	return nil
+}
+
+// ListPenugasanKatalog implements matapelajaran.DataMataPelajaranInterface.
+// Fungsi ini mengambil penugasan aktif yang masih memakai mapel id, dengan nama "<mapel> - <kelas>".
+func (m *mataPelajaranQuery) ListPenugasanKatalog(ctx context.Context, id string) ([]helper.Dependent, error) {
+	if m.db == nil {
+		return nil, errors.New("Nil database")
+	}
+
+	rows, err := m.db.Query(ctx, `SELECT 'mata_pelajaran', mp.id, CONCAT_WS(' - ', m.nama, k.kelas), TRUE
+		FROM mata_pelajaran mp
+		JOIN mapel m ON m.id = mp.mapel_id
+		LEFT JOIN kelas k ON k.id = mp.kelas_id
+		WHERE mp.mapel_id = $1 AND mp.delete_at IS NULL
+		ORDER BY 3`, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("ListPenugasanKatalog error query", "error", err)
+		return nil, fmt.Errorf("gagal mengambil penugasan mapel: %w", err)
+	}
+	defer rows.Close()
+
+	var dependents []helper.Dependent
+	for rows.Next() {
+		var d helper.Dependent
+		if err := rows.Scan(&d.Tabel, &d.ID, &d.Nama, &d.Langsung); err != nil {
+			return nil, fmt.Errorf("gagal membaca penugasan mapel: %w", err)
+		}
+		dependents = append(dependents, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("gagal membaca penugasan mapel: %w", err)
+	}
+	return dependents, nil
+}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	matapelajaran "go_rest_native_sekolah/features/mata_pelajaran"
+	"go_rest_native_sekolah/helper"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -15,6 +18,7 @@ type mataPelajaranServiceinterface struct {
 	mataPelajaranData matapelajaran.DataMataPelajaranInterface // field mataPelajaranData berisi pointer ke DataMataPelajaranInterface.
 	// DataMataPelajaranInterface adalah interface yang berisi method-method untuk menghandle
 	// query ke database yang berhubungan dengan tabel mata_pelajaran.
+	uow helper.UnitOfWork[matapelajaran.DataMataPelajaranInterface] // uow menjalankan repository mata pelajaran dalam satu transaksi.
 }
 
 // NewMataPelajaranService adalah fungsi yang digunakan untuk membuat
@@ -23,23 +27,25 @@ type mataPelajaranServiceinterface struct {
 // diisi ke dalam field mataPelajaranData di dalam struct
 // mataPelajaranServiceinterface.
 //
+// Parameter uow dipakai untuk menjalankan operasi bulk dalam transaksi.
+//
 // Jika parameter yang diinputkan adalah nil maka akan terjadi Eror.
 // Jika parameter yang diinputkan bukan nil maka akan dibuatkan
 // instance dari MataPelajaranServiceInterface yang berisi pointer
 // ke DataMataPelajaranInterface.
-func NewMataPelajaranService(repo matapelajaran.DataMataPelajaranInterface) matapelajaran.ServiceMapelInterface {
+func NewMataPelajaranService(repo matapelajaran.DataMataPelajaranInterface, uow helper.UnitOfWork[matapelajaran.DataMataPelajaranInterface]) matapelajaran.ServiceMapelInterface {
 	if repo == nil {
 		// Jika parameter yang diinputkan adalah nil maka akan terjadi panic.
-		errors.New("Nil repository")
+		panic("Nil repository")
 	}
 	// Membuatkan instance dari MataPelajaranServiceInterface yang berisi pointer
 	// ke DataMataPelajaranInterface.
-	return &mataPelajaranServiceinterface{mataPelajaranData: repo}
+	return &mataPelajaranServiceinterface{mataPelajaranData: repo, uow: uow}
 }
 
 // InsertMapel digunakan untuk menginsert data mata pelajaran ke dalam database.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat proses insert.
-func (m *mataPelajaranServiceinterface) InsertMapel(insert *matapelajaran.MataPelajaranCore) error {
+func (m *mataPelajaranServiceinterface) InsertMapel(ctx context.Context, insert *matapelajaran.MataPelajaranCore) error {
 	// Memeriksa apakah mataPelajaranData adalah nil.
 	// Jika nil, kembalikan error karena repository tidak dapat diakses.
 	if m.mataPelajaranData == nil {
@@ -52,22 +58,42 @@ func (m *mataPelajaranServiceinterface) InsertMapel(insert *matapelajaran.MataPe
 		return errors.New("insert data is nil")
 	}
 
-	// Memeriksa apakah nama pelajaran dalam data yang akan diinsert kosong.
-	// Jika kosong, kembalikan error karena nama pelajaran wajib diisi.
-	if insert.Nama_Pelajaran == "" {
+	// Memeriksa apakah mapel dalam data yang akan diinsert kosong.
+	// Jika kosong, kembalikan error karena mapel_id, kode, atau nama pelajaran wajib diisi.
+	if insert.Nama_Pelajaran == "" && insert.Mapel_ID == "" && insert.Kode == "" {
 		return errors.New("Validation error: insert mapel is nil")
 	}
 
-	// Memanggil fungsi InsertMapel pada mataPelajaranData untuk memasukkan data ke dalam database.
+	// Memeriksa apakah jam per minggu negatif.
+	// Jika negatif, kembalikan error karena jam pelajaran tidak mungkin kurang dari nol.
+	if insert.Jam_Per_Minggu < 0 {
+		return errors.New("Validation error: jam_per_minggu tidak boleh negatif")
+	}
+
+	// Menyusun daftar guru pengajar dengan satu guru utama.
+	insert.Kode = strings.ToUpper(strings.TrimSpace(insert.Kode))
+	insert.Periode = strings.TrimSpace(insert.Periode)
+	if err := susunPengajar(insert); err != nil {
+		return err
+	}
+
+	if m.uow == nil {
+		return errors.New("Nil unit of work")
+	}
+
+	// Memanggil fungsi InsertMapel dalam satu transaksi karena penugasan, katalog baru,
+	// dan guru pengajar disimpan di tabel yang berbeda.
 	// Jika terjadi error saat proses insert, error tersebut akan diteruskan.
-	return m.mataPelajaranData.InsertMapel(insert)
+	return m.uow.Do(ctx, func(repo matapelajaran.DataMataPelajaranInterface) error {
+		return repo.InsertMapel(ctx, insert)
+	})
 }
 
 // SelectAllMapel implements matapelajaran.ServiceMapelInterface.
 // Fungsi ini digunakan untuk mengambil semua data mata pelajaran yang tersedia di database.
 // Fungsi ini akan mengembalikan slice of MataPelajaranCore yang berisi data mata pelajaran.
 // Jika terjadi error maka fungsi ini akan mengembalikan error.
-func (m *mataPelajaranServiceinterface) SelectAllMapel() ([]matapelajaran.MataPelajaranCore, error) {
+func (m *mataPelajaranServiceinterface) SelectAllMapel(ctx context.Context) ([]matapelajaran.MataPelajaranCore, error) {
 	// Memeriksa apakah mataPelajaranData adalah nil.
 	// Jika nil, kembalikan error karena repository tidak dapat diakses.
 	if m.mataPelajaranData == nil {
@@ -76,9 +102,9 @@ func (m *mataPelajaranServiceinterface) SelectAllMapel() ([]matapelajaran.MataPe
 
 	// Memanggil fungsi SelectAllMapel pada mataPelajaranData untuk mengambil data.
 	// Jika terjadi error saat mengambil data, error tersebut akan diteruskan.
-	mapels, err := m.mataPelajaranData.SelectAllMapel()
+	mapels, err := m.mataPelajaranData.SelectAllMapel(ctx)
 	if err != nil {
-		return nil, errors.New("MataPelajaranService: gagal mengambil data")
+		return nil, fmt.Errorf("MataPelajaranService: gagal mengambil data: %w", err)
 	}
 
 	// Mengembalikan slice of MataPelajaranCore yang berisi data mata pelajaran.
@@ -89,7 +115,7 @@ func (m *mataPelajaranServiceinterface) SelectAllMapel() ([]matapelajaran.MataPe
 // Fungsi ini digunakan untuk mengambil data mata pelajaran berdasarkan ID.
 // Fungsi ini akan mengembalikan pointer ke struct MataPelajaranCore yang berisi data mata pelajaran.
 // Jika terjadi error maka fungsi ini akan mengembalikan error.
-func (m *mataPelajaranServiceinterface) SelectMapelById(id string) (*matapelajaran.MataPelajaranCore, error) {
+func (m *mataPelajaranServiceinterface) SelectMapelById(ctx context.Context, id string) (*matapelajaran.MataPelajaranCore, error) {
 	// Memeriksa apakah mataPelajaranData adalah nil.
 	// Jika nil, kembalikan error karena repository tidak dapat diakses.
 	if m.mataPelajaranData == nil {
@@ -98,9 +124,9 @@ func (m *mataPelajaranServiceinterface) SelectMapelById(id string) (*matapelajar
 
 	// Memanggil fungsi SelectMapelById pada mataPelajaranData untuk mengambil data berdasarkan ID.
 	// Jika terjadi error saat mengambil data, error tersebut akan diteruskan.
-	mapel, err := m.mataPelajaranData.SelectMapelById(id)
+	mapel, err := m.mataPelajaranData.SelectMapelById(ctx, id)
 	if err != nil {
-		return nil, errors.New("MataPelajaranService: gagal mengambil data")
+		return nil, fmt.Errorf("MataPelajaranService: gagal mengambil data: %w", err)
 	}
 
 	// Mengembalikan pointer ke struct MataPelajaranCore yang berisi data mata pelajaran.
@@ -108,7 +134,7 @@ func (m *mataPelajaranServiceinterface) SelectMapelById(id string) (*matapelajar
 }
 
 // UpdateMapel implements matapelajaran.ServiceMapelInterface.
-func (m *mataPelajaranServiceinterface) UpdateMapel(update *matapelajaran.MataPelajaranCore, id string) error {
+func (m *mataPelajaranServiceinterface) UpdateMapel(ctx context.Context, update *matapelajaran.MataPelajaranCore, id string) error {
 	if m == nil || m.mataPelajaranData == nil {
 		return errors.New("Nil repository")
 	}
@@ -116,9 +142,12 @@ func (m *mataPelajaranServiceinterface) UpdateMapel(update *matapelajaran.MataPe
 	if id == "" {
 		return errors.New("Validation error: id is nil")
 	}
+	if update.Jam_Per_Minggu < 0 {
+		return errors.New("Validation error: jam_per_minggu tidak boleh negatif")
+	}
 
 	// Ambil data lama berdasarkan ID
-	existingData, err := m.mataPelajaranData.SelectMapelById(id)
+	existingData, err := m.mataPelajaranData.SelectMapelById(ctx, id)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return errors.New("mata pelajaran service: Data tidak ditemukan")
@@ -126,32 +155,109 @@ func (m *mataPelajaranServiceinterface) UpdateMapel(update *matapelajaran.MataPe
 		return fmt.Errorf("gagal mengambil data lama: %w", err)
 	}
 
-	// Merge data jika field baru kosong
-	if update.Nama_Pelajaran == "" {
-		update.Nama_Pelajaran = existingData.Nama_Pelajaran
+	// Tolak update jika data sudah diubah sejak client mengambilnya (If-Match)
+	if update.Version != existingData.Version {
+		return helper.ErrVersionConflict
 	}
-	if update.ID_Guru == "" {
-		update.ID_Guru = existingData.ID_Guru
+
+	// Merge data jika field baru kosong.
+	// Mapel di katalog hanya diganti jika client mengirim mapel_id, kode, atau nama pelajaran;
+	// deskripsi mengikuti katalog dan diubah lewat endpoint katalog.
+	update.Kode = strings.ToUpper(strings.TrimSpace(update.Kode))
+	if update.Mapel_ID == "" && update.Kode == "" && update.Nama_Pelajaran == "" {
+		update.Mapel_ID = existingData.Mapel_ID
 	}
 	if update.Kelas_ID == "" {
 		update.Kelas_ID = existingData.Kelas_ID
 	}
-	if update.Deskripsi == "" {
-		update.Deskripsi = existingData.Deskripsi
+	if update.Periode = strings.TrimSpace(update.Periode); update.Periode == "" {
+		update.Periode = existingData.Periode
+	}
+	if update.Jam_Per_Minggu == 0 {
+		update.Jam_Per_Minggu = existingData.Jam_Per_Minggu
+	}
+
+	// Daftar pengajar diganti seluruhnya jika dikirim. Jika hanya id_guru yang dikirim,
+	// guru utama diganti dan guru pendamping tetap. Selain itu pengajar tidak berubah.
+	switch {
+	case update.Pengajar != nil:
+	case update.ID_Guru != "":
+		for _, p := range existingData.Pengajar {
+			if !p.Utama && p.ID_Guru != update.ID_Guru {
+				update.Pengajar = append(update.Pengajar, p)
+			}
+		}
+	default:
+		update.Pengajar = existingData.Pengajar
+	}
+	if err := susunPengajar(update); err != nil {
+		return err
 	}
 
-	// Lakukan update ke database
-	if err := m.mataPelajaranData.UpdateMapel(update, id); err != nil {
+	if m.uow == nil {
+		return errors.New("Nil unit of work")
+	}
+
+	// Lakukan update penugasan dan guru pengajar ke database dalam satu transaksi
+	err = m.uow.Do(ctx, func(repo matapelajaran.DataMataPelajaranInterface) error {
+		return repo.UpdateMapel(ctx, update, id)
+	})
+	if err != nil {
 		return fmt.Errorf("gagal update data mata pelajaran: %w", err)
 	}
 
 	return nil // Mengembalikan nil jika update berhasil
 }
 
+// susunPengajar menggabungkan ID_Guru dan daftar Pengajar menjadi daftar pengajar tanpa duplikat.
+// ID_Guru yang diisi selalu menjadi guru utama dan guru lain menjadi pendamping.
+// Jika tidak ada guru utama, guru pertama dijadikan utama.
+// Fungsi ini mengembalikan validation error jika ID guru kosong atau ada lebih dari satu guru utama.
+func susunPengajar(c *matapelajaran.MataPelajaranCore) error {
+	if c.Pengajar == nil && c.ID_Guru == "" {
+		return nil
+	}
+
+	pengajar := []matapelajaran.PengajarCore{}
+	seen := map[string]bool{}
+	if c.ID_Guru != "" {
+		pengajar = append(pengajar, matapelajaran.PengajarCore{ID_Guru: c.ID_Guru, Utama: true})
+		seen[c.ID_Guru] = true
+	}
+	utama := len(pengajar)
+	for _, p := range c.Pengajar {
+		p.ID_Guru = strings.TrimSpace(p.ID_Guru)
+		if p.ID_Guru == "" {
+			return errors.New("Validation error: id_guru pengajar wajib diisi")
+		}
+		if seen[p.ID_Guru] {
+			continue
+		}
+		seen[p.ID_Guru] = true
+		if c.ID_Guru != "" {
+			// ID_Guru sudah menjadi guru utama, guru lain menjadi pendamping
+			p.Utama = false
+		}
+		if p.Utama {
+			utama++
+			if utama > 1 {
+				return errors.New("Validation error: guru utama hanya boleh satu")
+			}
+		}
+		pengajar = append(pengajar, p)
+	}
+	if utama == 0 && len(pengajar) > 0 {
+		pengajar[0].Utama = true
+	}
+
+	c.Pengajar = pengajar
+	return nil
+}
+
 // DeleteMapel implements matapelajaran.ServiceMapelInterface.
 // Fungsi ini digunakan untuk menghapus data mata pelajaran berdasarkan ID.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat proses hapus.
-func (m *mataPelajaranServiceinterface) DeleteMapel(id string) error {
+func (m *mataPelajaranServiceinterface) DeleteMapel(ctx context.Context, id string, version int) error {
 	// Memeriksa apakah mataPelajaranData adalah nil.
 	// Jika nil, kembalikan error karena repository tidak dapat diakses.
 	if m == nil || m.mataPelajaranData == nil {
@@ -164,10 +270,157 @@ func (m *mataPelajaranServiceinterface) DeleteMapel(id string) error {
 	}
 	// Memanggil fungsi DeleteMapel pada mataPelajaranData untuk menghapus data berdasarkan ID.
 	// Jika terjadi error saat proses hapus, error tersebut akan diteruskan.
-	if err := m.mataPelajaranData.DeleteMapel(id); err != nil {
+	if err := m.mataPelajaranData.DeleteMapel(ctx, id, version); err != nil {
 		// Jika terjadi error maka kembalikan error dengan pesan "gagal menghapus data mata pelajaran".
 		return fmt.Errorf("gagal menghapus data mata pelajaran: %w", err)
 	}
 	// Jika proses hapus berhasil maka kembalikan nil.
 	return nil
 }
+
+// Bulk implements matapelajaran.ServiceMapelInterface.
+// Fungsi ini digunakan untuk menjalankan banyak operasi create, update, dan delete mata pelajaran sekaligus,
+// misalnya memindahkan beberapa mata pelajaran ke guru baru.
+// Setiap operasi memakai validasi yang sama dengan endpoint satuan, dengan repository dari transaksi bulk.
+func (m *mataPelajaranServiceinterface) Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[matapelajaran.MataPelajaranCore]) (*helper.BulkResult, error) {
+	// Memeriksa apakah mataPelajaranData dan unit of work adalah nil.
+	if m == nil || m.mataPelajaranData == nil || m.uow == nil {
+		return nil, errors.New("Nil repository")
+	}
+
+	return helper.RunBulk(ctx, m.uow, mode, ops, func(ctx context.Context, repo matapelajaran.DataMataPelajaranInterface, op helper.BulkOperation[matapelajaran.MataPelajaranCore]) (string, error) {
+		// Service yang memakai repository dari transaksi milik operasi bulk.
+		tx := &mataPelajaranServiceinterface{mataPelajaranData: repo, uow: helper.JoinUnitOfWork(repo)}
+		data := op.Data
+		switch op.Action {
+		case helper.BulkActionCreate:
+			err := tx.InsertMapel(ctx, &data)
+			return data.ID, err
+		case helper.BulkActionUpdate:
+			data.Version = op.Version
+			return op.ID, tx.UpdateMapel(ctx, &data, op.ID)
+		default:
+			return op.ID, tx.DeleteMapel(ctx, op.ID, op.Version)
+		}
+	})
+}
+
+// GetAllKatalog implements matapelajaran.ServiceMapelInterface.
+// Fungsi ini digunakan untuk mengambil semua mapel di katalog beserta jumlah penugasannya.
+func (m *mataPelajaranServiceinterface) GetAllKatalog(ctx context.Context) ([]matapelajaran.KatalogMapelCore, error) {
+	if m == nil || m.mataPelajaranData == nil {
+		return nil, errors.New("Nil repository")
+	}
+
+	katalog, err := m.mataPelajaranData.SelectAllKatalog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("MataPelajaranService: gagal mengambil katalog: %w", err)
+	}
+	return katalog, nil
+}
+
+// GetKatalogById implements matapelajaran.ServiceMapelInterface.
+// Fungsi ini digunakan untuk mengambil satu mapel di katalog berdasarkan ID.
+func (m *mataPelajaranServiceinterface) GetKatalogById(ctx context.Context, id string) (*matapelajaran.KatalogMapelCore, error) {
+	if m == nil || m.mataPelajaranData == nil {
+		return nil, errors.New("Nil repository")
+	}
+	if id == "" {
+		return nil, errors.New("Validation error: id is nil")
+	}
+
+	katalog, err := m.mataPelajaranData.SelectKatalogById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("mata pelajaran service: Data tidak ditemukan")
+		}
+		return nil, fmt.Errorf("MataPelajaranService: gagal mengambil katalog: %w", err)
+	}
+	return katalog, nil
+}
+
+// InsertKatalog implements matapelajaran.ServiceMapelInterface.
+// Fungsi ini digunakan untuk menambah mapel ke katalog. Nama wajib diisi dan kode disimpan dalam huruf besar.
+func (m *mataPelajaranServiceinterface) InsertKatalog(ctx context.Context, insert *matapelajaran.KatalogMapelCore) error {
+	if m == nil || m.mataPelajaranData == nil {
+		return errors.New("Nil repository")
+	}
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+
+	insert.Nama = strings.TrimSpace(insert.Nama)
+	insert.Kode = strings.ToUpper(strings.TrimSpace(insert.Kode))
+	if insert.Nama == "" {
+		return errors.New("Validation error: nama mapel wajib diisi")
+	}
+
+	return m.mataPelajaranData.InsertKatalog(ctx, insert)
+}
+
+// UpdateKatalog implements matapelajaran.ServiceMapelInterface.
+// Field yang kosong tetap memakai nilai lama. Fungsi ini mengembalikan helper.ErrVersionConflict
+// jika mapel sudah diubah sejak client mengambilnya.
+func (m *mataPelajaranServiceinterface) UpdateKatalog(ctx context.Context, update *matapelajaran.KatalogMapelCore, id string) error {
+	if m == nil || m.mataPelajaranData == nil {
+		return errors.New("Nil repository")
+	}
+	if id == "" {
+		return errors.New("Validation error: id is nil")
+	}
+
+	existing, err := m.GetKatalogById(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Tolak update jika data sudah diubah sejak client mengambilnya (If-Match)
+	if update.Version != existing.Version {
+		return helper.ErrVersionConflict
+	}
+
+	// Merge data jika field baru kosong
+	if update.Nama = strings.TrimSpace(update.Nama); update.Nama == "" {
+		update.Nama = existing.Nama
+	}
+	if update.Kode = strings.ToUpper(strings.TrimSpace(update.Kode)); update.Kode == "" {
+		update.Kode = existing.Kode
+	}
+	if update.Deskripsi == "" {
+		update.Deskripsi = existing.Deskripsi
+	}
+	if update.Kelompok == "" {
+		update.Kelompok = existing.Kelompok
+	}
+
+	if err := m.mataPelajaranData.UpdateKatalog(ctx, update, id); err != nil {
+		return fmt.Errorf("gagal update katalog mapel: %w", err)
+	}
+	return nil
+}
+
+// DeleteKatalog implements matapelajaran.ServiceMapelInterface.
+// Mapel yang masih dipakai penugasan aktif tidak bisa dihapus; hapus atau pindahkan penugasannya terlebih dahulu.
+func (m *mataPelajaranServiceinterface) DeleteKatalog(ctx context.Context, id string, version int) error {
+	if m == nil || m.mataPelajaranData == nil || m.uow == nil {
+		return errors.New("Nil repository")
+	}
+	if id == "" {
+		return errors.New("Validation error: id is nil")
+	}
+
+	// Pengecekan penugasan dan penghapusan dijalankan dalam satu transaksi.
+	return m.uow.Do(ctx, func(repo matapelajaran.DataMataPelajaranInterface) error {
+		penugasan, err := repo.ListPenugasanKatalog(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(penugasan) > 0 {
+			opts := helper.DeleteOptions{Policy: helper.DeletePolicyBlock}
+			return &helper.DeleteBlockedError{Impact: helper.NewDeleteImpact(id, opts, penugasan)}
+		}
+		if err := repo.DeleteKatalog(ctx, id, version); err != nil {
+			return fmt.Errorf("gagal menghapus katalog mapel: %w", err)
+		}
+		return nil
+	})
+}
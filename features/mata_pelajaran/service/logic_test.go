@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
 	matapelajaran "go_rest_native_sekolah/features/mata_pelajaran"
+	"go_rest_native_sekolah/helper"
 	"testing"
 
 	"github.com/jackc/pgx/v5"
@@ -15,7 +17,11 @@ type mockDataMataPelajaran struct {
 	mock.Mock
 }
 
-func (m *mockDataMataPelajaran) SelectAllMapel() ([]matapelajaran.MataPelajaranCore, error) {
+func (m *mockDataMataPelajaran) SelectAllMapel(ctx context.Context) ([]matapelajaran.MataPelajaranCore, error) {
+	// Meniru pgx: query dengan context yang sudah dibatalkan langsung gagal
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -23,7 +29,7 @@ func (m *mockDataMataPelajaran) SelectAllMapel() ([]matapelajaran.MataPelajaranC
 	return args.Get(0).([]matapelajaran.MataPelajaranCore), args.Error(1)
 }
 
-func (m *mockDataMataPelajaran) SelectMapelById(id string) (*matapelajaran.MataPelajaranCore, error) {
+func (m *mockDataMataPelajaran) SelectMapelById(ctx context.Context, id string) (*matapelajaran.MataPelajaranCore, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -31,21 +37,69 @@ func (m *mockDataMataPelajaran) SelectMapelById(id string) (*matapelajaran.MataP
 	return args.Get(0).(*matapelajaran.MataPelajaranCore), args.Error(1)
 }
 
-func (m *mockDataMataPelajaran) InsertMapel(insert *matapelajaran.MataPelajaranCore) error {
+func (m *mockDataMataPelajaran) InsertMapel(ctx context.Context, insert *matapelajaran.MataPelajaranCore) error {
 	args := m.Called(insert)
 	return args.Error(0)
 }
 
-func (m *mockDataMataPelajaran) UpdateMapel(insert *matapelajaran.MataPelajaranCore, id string) error {
+func (m *mockDataMataPelajaran) UpdateMapel(ctx context.Context, insert *matapelajaran.MataPelajaranCore, id string) error {
 	args := m.Called(insert, id)
 	return args.Error(0)
 }
 
-func (m *mockDataMataPelajaran) DeleteMapel(id string) error {
+func (m *mockDataMataPelajaran) DeleteMapel(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataMataPelajaran) SelectAllKatalog(ctx context.Context) ([]matapelajaran.KatalogMapelCore, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]matapelajaran.KatalogMapelCore), args.Error(1)
+}
+
+func (m *mockDataMataPelajaran) SelectKatalogById(ctx context.Context, id string) (*matapelajaran.KatalogMapelCore, error) {
 	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*matapelajaran.KatalogMapelCore), args.Error(1)
+}
+
+func (m *mockDataMataPelajaran) InsertKatalog(ctx context.Context, insert *matapelajaran.KatalogMapelCore) error {
+	args := m.Called(insert)
 	return args.Error(0)
 }
 
+func (m *mockDataMataPelajaran) UpdateKatalog(ctx context.Context, update *matapelajaran.KatalogMapelCore, id string) error {
+	args := m.Called(update, id)
+	return args.Error(0)
+}
+
+func (m *mockDataMataPelajaran) DeleteKatalog(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataMataPelajaran) ListPenugasanKatalog(ctx context.Context, id string) ([]helper.Dependent, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]helper.Dependent), args.Error(1)
+}
+
+// fakeUnitOfWork menjalankan fn langsung dengan repository mock tanpa transaksi sungguhan
+type fakeUnitOfWork struct {
+	repo matapelajaran.DataMataPelajaranInterface
+}
+
+func (f fakeUnitOfWork) Do(ctx context.Context, fn func(repo matapelajaran.DataMataPelajaranInterface) error) error {
+	return fn(f.repo)
+}
+
 // Test SelectAllMapel
 func TestSelectAllMapel(t *testing.T) {
 	mockRepo := new(mockDataMataPelajaran)
@@ -77,7 +131,7 @@ func TestSelectAllMapel(t *testing.T) {
 		mockRepo.On("SelectAllMapel").Return(expectedMapel, nil).Once()
 
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		result, err := svc.SelectAllMapel()
+		result, err := svc.SelectAllMapel(context.Background())
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedMapel, result)
@@ -89,7 +143,7 @@ func TestSelectAllMapel(t *testing.T) {
 		mockRepo.On("SelectAllMapel").Return(nil, errors.New("database error")).Once()
 
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		result, err := svc.SelectAllMapel()
+		result, err := svc.SelectAllMapel(context.Background())
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -98,11 +152,24 @@ func TestSelectAllMapel(t *testing.T) {
 
 	t.Run("failed - nil repository", func(t *testing.T) {
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: nil}
-		result, err := svc.SelectAllMapel()
+		result, err := svc.SelectAllMapel(context.Background())
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
 	})
+
+	t.Run("failed - context dibatalkan diteruskan ke repository", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
+		result, err := svc.SelectAllMapel(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "SelectAllMapel")
+	})
 }
 
 // Test SelectMapelById
@@ -124,7 +191,7 @@ func TestSelectMapelById(t *testing.T) {
 		mockRepo.On("SelectMapelById", "mapel-001").Return(expectedMapel, nil).Once()
 
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		result, err := svc.SelectMapelById("mapel-001")
+		result, err := svc.SelectMapelById(context.Background(), "mapel-001")
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedMapel, result)
@@ -135,7 +202,7 @@ func TestSelectMapelById(t *testing.T) {
 		mockRepo.On("SelectMapelById", "999").Return(nil, pgx.ErrNoRows).Once()
 
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		result, err := svc.SelectMapelById("999")
+		result, err := svc.SelectMapelById(context.Background(), "999")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -158,13 +225,74 @@ func TestInsertMapel(t *testing.T) {
 
 		mockRepo.On("InsertMapel", newMapel).Return(nil).Once()
 
-		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		err := svc.InsertMapel(newMapel)
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.InsertMapel(context.Background(), newMapel)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []matapelajaran.PengajarCore{{ID_Guru: "guru-001", Utama: true}}, newMapel.Pengajar)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success insert mapel - team teaching dari katalog", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		newMapel := &matapelajaran.MataPelajaranCore{
+			Kode:     " mtk ",
+			Kelas_ID: "kelas-001",
+			Periode:  " 2024/2025-1 ",
+			Pengajar: []matapelajaran.PengajarCore{
+				{ID_Guru: "guru-002"},
+				{ID_Guru: "guru-001", Utama: true},
+				{ID_Guru: "guru-002"},
+			},
+		}
+
+		mockRepo.On("InsertMapel", newMapel).Return(nil).Once()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.InsertMapel(context.Background(), newMapel)
 
 		assert.NoError(t, err)
+		assert.Equal(t, "MTK", newMapel.Kode)
+		assert.Equal(t, "2024/2025-1", newMapel.Periode)
+		assert.Equal(t, []matapelajaran.PengajarCore{
+			{ID_Guru: "guru-002"},
+			{ID_Guru: "guru-001", Utama: true},
+		}, newMapel.Pengajar)
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("failed insert mapel - guru utama lebih dari satu", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		newMapel := &matapelajaran.MataPelajaranCore{
+			Nama_Pelajaran: "Matematika",
+			Pengajar: []matapelajaran.PengajarCore{
+				{ID_Guru: "guru-001", Utama: true},
+				{ID_Guru: "guru-002", Utama: true},
+			},
+		}
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.InsertMapel(context.Background(), newMapel)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "guru utama")
+		mockRepo.AssertNotCalled(t, "InsertMapel", mock.Anything)
+	})
+
+	t.Run("failed insert mapel - jam per minggu negatif", func(t *testing.T) {
+		newMapel := &matapelajaran.MataPelajaranCore{
+			Nama_Pelajaran: "Matematika",
+			Jam_Per_Minggu: -2,
+		}
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
+		err := svc.InsertMapel(context.Background(), newMapel)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "jam_per_minggu")
+		mockRepo.AssertNotCalled(t, "InsertMapel", newMapel)
+	})
+
 	t.Run("failed insert mapel - nil repository", func(t *testing.T) {
 		newMapel := &matapelajaran.MataPelajaranCore{
 			Nama_Pelajaran: "Matematika",
@@ -173,7 +301,7 @@ func TestInsertMapel(t *testing.T) {
 		}
 
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: nil}
-		err := svc.InsertMapel(newMapel)
+		err := svc.InsertMapel(context.Background(), newMapel)
 
 		assert.Error(t, err)
 	})
@@ -187,8 +315,8 @@ func TestInsertMapel(t *testing.T) {
 
 		mockRepo.On("InsertMapel", newMapel).Return(errors.New("insert failed")).Once()
 
-		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		err := svc.InsertMapel(newMapel)
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.InsertMapel(context.Background(), newMapel)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -216,10 +344,64 @@ func TestUpdateMapel(t *testing.T) {
 		mockRepo.On("SelectMapelById", "mapel-001").Return(existingMapel, nil).Once()
 		mockRepo.On("UpdateMapel", updatedMapel, "mapel-001").Return(nil).Once()
 
-		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		err := svc.UpdateMapel(updatedMapel, "mapel-001")
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.UpdateMapel(context.Background(), updatedMapel, "mapel-001")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success update mapel - ganti guru utama, guru pendamping tetap", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		existingMapel := &matapelajaran.MataPelajaranCore{
+			ID:       "mapel-001",
+			Mapel_ID: "katalog-001",
+			ID_Guru:  "guru-001",
+			Kelas_ID: "kelas-001",
+			Periode:  "2024/2025-1",
+			Pengajar: []matapelajaran.PengajarCore{
+				{ID_Guru: "guru-001", Utama: true},
+				{ID_Guru: "guru-003"},
+			},
+			Version: 2,
+		}
+		update := &matapelajaran.MataPelajaranCore{ID_Guru: "guru-002", Version: 2}
+
+		mockRepo.On("SelectMapelById", "mapel-001").Return(existingMapel, nil).Once()
+		mockRepo.On("UpdateMapel", update, "mapel-001").Return(nil).Once()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.UpdateMapel(context.Background(), update, "mapel-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "katalog-001", update.Mapel_ID)
+		assert.Equal(t, "kelas-001", update.Kelas_ID)
+		assert.Equal(t, "2024/2025-1", update.Periode)
+		assert.Equal(t, []matapelajaran.PengajarCore{
+			{ID_Guru: "guru-002", Utama: true},
+			{ID_Guru: "guru-003"},
+		}, update.Pengajar)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success update mapel - tanpa guru, pengajar lama tetap", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		pengajar := []matapelajaran.PengajarCore{
+			{ID_Guru: "guru-001", Utama: true},
+			{ID_Guru: "guru-003"},
+		}
+		existingMapel := &matapelajaran.MataPelajaranCore{ID: "mapel-001", Mapel_ID: "katalog-001", Pengajar: pengajar, Version: 1}
+		update := &matapelajaran.MataPelajaranCore{Jam_Per_Minggu: 4, Version: 1}
+
+		mockRepo.On("SelectMapelById", "mapel-001").Return(existingMapel, nil).Once()
+		mockRepo.On("UpdateMapel", update, "mapel-001").Return(nil).Once()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.UpdateMapel(context.Background(), update, "mapel-001")
 
 		assert.NoError(t, err)
+		assert.Equal(t, pengajar, update.Pengajar)
+		assert.Equal(t, 4, update.Jam_Per_Minggu)
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -227,11 +409,24 @@ func TestUpdateMapel(t *testing.T) {
 		mockRepo.On("SelectMapelById", "999").Return(nil, pgx.ErrNoRows).Once()
 
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		err := svc.UpdateMapel(&matapelajaran.MataPelajaranCore{}, "999")
+		err := svc.UpdateMapel(context.Background(), &matapelajaran.MataPelajaranCore{}, "999")
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("failed update mapel - version conflict", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		existingMapel := &matapelajaran.MataPelajaranCore{ID: "mapel-001", Nama_Pelajaran: "Matematika", Version: 4}
+
+		mockRepo.On("SelectMapelById", "mapel-001").Return(existingMapel, nil).Once()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
+		err := svc.UpdateMapel(context.Background(), &matapelajaran.MataPelajaranCore{Deskripsi: "Baru", Version: 3}, "mapel-001")
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		mockRepo.AssertNotCalled(t, "UpdateMapel", mock.Anything, mock.Anything)
+	})
 }
 
 // Test DeleteMapel
@@ -239,22 +434,102 @@ func TestDeleteMapel(t *testing.T) {
 	mockRepo := new(mockDataMataPelajaran)
 
 	t.Run("success delete mapel", func(t *testing.T) {
-		mockRepo.On("DeleteMapel", "mapel-001").Return(nil).Once()
+		mockRepo.On("DeleteMapel", "mapel-001", 1).Return(nil).Once()
 
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		err := svc.DeleteMapel("mapel-001")
+		err := svc.DeleteMapel(context.Background(), "mapel-001", 1)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("failed delete mapel - not found", func(t *testing.T) {
-		mockRepo.On("DeleteMapel", "999").Return(errors.New("data not found")).Once()
+		mockRepo.On("DeleteMapel", "999", 1).Return(errors.New("data not found")).Once()
 
 		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo}
-		err := svc.DeleteMapel("999")
+		err := svc.DeleteMapel(context.Background(), "999", 1)
+
+		assert.Error(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// Test BulkMapel
+func TestBulkMapel(t *testing.T) {
+	t.Run("success atomic bulk - reassign mapel to new guru", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		ops := []helper.BulkOperation[matapelajaran.MataPelajaranCore]{
+			{Action: helper.BulkActionUpdate, ID: "mapel-001", Version: 1, Data: matapelajaran.MataPelajaranCore{ID_Guru: "guru-baru"}},
+			{Action: helper.BulkActionUpdate, ID: "mapel-002", Version: 4, Data: matapelajaran.MataPelajaranCore{ID_Guru: "guru-baru"}},
+		}
+
+		mockRepo.On("SelectMapelById", "mapel-001").Return(&matapelajaran.MataPelajaranCore{ID: "mapel-001", Mapel_ID: "katalog-001", Nama_Pelajaran: "Matematika", ID_Guru: "guru-001", Version: 1}, nil).Once()
+		mockRepo.On("SelectMapelById", "mapel-002").Return(&matapelajaran.MataPelajaranCore{ID: "mapel-002", Mapel_ID: "katalog-002", Nama_Pelajaran: "Fisika", ID_Guru: "guru-002", Version: 4}, nil).Once()
+		mockRepo.On("UpdateMapel", mock.MatchedBy(func(m *matapelajaran.MataPelajaranCore) bool {
+			return m.ID_Guru == "guru-baru" && m.Mapel_ID != "" && len(m.Pengajar) == 1 && m.Pengajar[0].Utama
+		}), mock.Anything).Return(nil).Twice()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModeAtomic, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Berhasil)
+		assert.Equal(t, 200, result.StatusCode())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed atomic bulk - missing mapel cancels all", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		ops := []helper.BulkOperation[matapelajaran.MataPelajaranCore]{
+			{Action: helper.BulkActionDelete, ID: "mapel-001", Version: 1},
+			{Action: helper.BulkActionUpdate, ID: "mapel-999", Version: 1, Data: matapelajaran.MataPelajaranCore{ID_Guru: "guru-baru"}},
+		}
+
+		mockRepo.On("DeleteMapel", "mapel-001", 1).Return(nil).Once()
+		mockRepo.On("SelectMapelById", "mapel-999").Return(nil, pgx.ErrNoRows).Once()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModeAtomic, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Gagal)
+		assert.Equal(t, 404, result.Items[1].Status)
+		assert.Equal(t, 424, result.Items[0].Status)
+		mockRepo.AssertNotCalled(t, "UpdateMapel", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed bulk - nil unit of work", func(t *testing.T) {
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: new(mockDataMataPelajaran)}
+		_, err := svc.Bulk(context.Background(), helper.BulkModeAtomic, nil)
 
 		assert.Error(t, err)
+	})
+}
+
+// Test DeleteKatalog
+func TestDeleteKatalog(t *testing.T) {
+	t.Run("success delete katalog - tidak ada penugasan", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		mockRepo.On("ListPenugasanKatalog", "katalog-001").Return([]helper.Dependent{}, nil).Once()
+		mockRepo.On("DeleteKatalog", "katalog-001", 2).Return(nil).Once()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.DeleteKatalog(context.Background(), "katalog-001", 2)
+
+		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("failed delete katalog - masih dipakai penugasan", func(t *testing.T) {
+		mockRepo := new(mockDataMataPelajaran)
+		penugasan := []helper.Dependent{{Tabel: "mata_pelajaran", ID: "mapel-001", Nama: "Matematika - 10A", Langsung: true}}
+		mockRepo.On("ListPenugasanKatalog", "katalog-001").Return(penugasan, nil).Once()
+
+		svc := &mataPelajaranServiceinterface{mataPelajaranData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.DeleteKatalog(context.Background(), "katalog-001", 2)
+
+		var blocked *helper.DeleteBlockedError
+		assert.ErrorAs(t, err, &blocked)
+		mockRepo.AssertNotCalled(t, "DeleteKatalog", mock.Anything, mock.Anything)
+	})
 }
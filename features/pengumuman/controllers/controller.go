@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/pengumuman"
+	"go_rest_native_sekolah/helper"
+	"net/http"
+	"strings"
+)
+
+// PengumumanController menghandle HTTP request pengelolaan pengumuman dan feed pengumuman user.
+type PengumumanController struct {
+	pengumumanService pengumuman.ServicePengumumanInterface
+}
+
+// NewPengumumanController membuat PengumumanController dengan service pengumuman.
+func NewPengumumanController(service pengumuman.ServicePengumumanInterface) *PengumumanController {
+	return &PengumumanController{pengumumanService: service}
+}
+
+// writePengumumanError menulis response untuk error dari service pengumuman.
+// Mengembalikan false jika error tidak dikenali sehingga pemanggil perlu meneruskannya.
+func writePengumumanError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, helper.ErrVersionConflict):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case strings.Contains(err.Error(), "validation"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "akses ditolak"):
+		helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, err.Error(), nil))
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		http.Error(w, "Data pengumuman tidak ditemukan", http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+// Pengumuman menghandle GET /pengumuman untuk semua pengumuman, termasuk yang terjadwal dan kedaluwarsa.
+func (pc *PengumumanController) Pengumuman(w http.ResponseWriter, r *http.Request) error {
+	if pc == nil || pc.pengumumanService == nil {
+		return errors.New("pengumuman controller: service is nil")
+	}
+
+	result, err := pc.pengumumanService.GetAll(r.Context())
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data pengumuman", FormatPengumumanList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// InsertPengumuman menghandle POST /pengumuman/tambah. Body JSON berisi judul, isi, target
+// (semua, role, kelas, atau siswa), target_nilai, terbit_at, kedaluwarsa_at, dan disematkan.
+func (pc *PengumumanController) InsertPengumuman(w http.ResponseWriter, r *http.Request) error {
+	if pc == nil || pc.pengumumanService == nil {
+		return errors.New("pengumuman controller: service is nil")
+	}
+
+	var req PengumumanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data := PengumumanRequestToCore(req)
+	data.Dibuat_Oleh = meta.ID
+
+	if err := pc.pengumumanService.Insert(r.Context(), &data); err != nil {
+		if writePengumumanError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil menambah pengumuman", FormatPengumumanList([]pengumuman.PengumumanCore{data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// GetPengumumanById menghandle GET /pengumuman/{id}. Response membawa header ETag untuk update dan delete.
+func (pc *PengumumanController) GetPengumumanById(w http.ResponseWriter, r *http.Request) error {
+	if pc == nil || pc.pengumumanService == nil {
+		return errors.New("pengumuman controller: service is nil")
+	}
+
+	data, err := pc.pengumumanService.GetById(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writePengumumanError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data pengumuman", FormatPengumumanList([]pengumuman.PengumumanCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// UpdatePengumuman menghandle PUT /pengumuman/{id}. Field yang tidak dikirim tetap memakai nilai lama.
+// Header If-Match wajib diisi dengan ETag terbaru.
+func (pc *PengumumanController) UpdatePengumuman(w http.ResponseWriter, r *http.Request) error {
+	if pc == nil || pc.pengumumanService == nil {
+		return errors.New("pengumuman controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	var req PengumumanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+	data := PengumumanRequestToCore(req)
+	data.Version = version
+
+	// Sematan hanya diubah jika field disematkan dikirim
+	if req.Disematkan == nil {
+		existing, err := pc.pengumumanService.GetById(r.Context(), id)
+		if err != nil {
+			if writePengumumanError(w, err) {
+				return nil
+			}
+			return err
+		}
+		data.Disematkan = existing.Disematkan
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := pc.pengumumanService.Update(r.Context(), &data, id, meta); err != nil {
+		if writePengumumanError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	updated, err := pc.pengumumanService.GetById(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengupdate pengumuman", FormatPengumumanList([]pengumuman.PengumumanCore{*updated}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updated.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// DeletePengumuman menghandle DELETE /pengumuman/{id}. Header If-Match wajib diisi dengan ETag terbaru.
+func (pc *PengumumanController) DeletePengumuman(w http.ResponseWriter, r *http.Request) error {
+	if pc == nil || pc.pengumumanService == nil {
+		return errors.New("pengumuman controller: service is nil")
+	}
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := pc.pengumumanService.DeleteById(r.Context(), r.PathValue("id"), version, meta); err != nil {
+		if writePengumumanError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil menghapus pengumuman", nil))
+	return nil
+}
+
+// Pembaca menghandle GET /pengumuman/{id}/pembaca untuk daftar user yang sudah membaca pengumuman.
+func (pc *PengumumanController) Pembaca(w http.ResponseWriter, r *http.Request) error {
+	if pc == nil || pc.pengumumanService == nil {
+		return errors.New("pengumuman controller: service is nil")
+	}
+
+	result, err := pc.pengumumanService.GetPembaca(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writePengumumanError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data pembaca pengumuman", FormatPembacaList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// Feed menghandle GET /me/pengumuman untuk pengumuman yang sedang tayang bagi user yang login,
+// sesuai role, kelas, dan data siswanya. Pengumuman yang disematkan tampil paling atas.
+func (pc *PengumumanController) Feed(w http.ResponseWriter, r *http.Request) error {
+	if pc == nil || pc.pengumumanService == nil {
+		return errors.New("pengumuman controller: service is nil")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	result, err := pc.pengumumanService.Feed(r.Context(), meta)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil pengumuman", FormatPengumumanList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// TandaiDibaca menghandle POST /me/pengumuman/{id}/baca. Menandai ulang pengumuman yang sudah dibaca tetap berhasil.
+func (pc *PengumumanController) TandaiDibaca(w http.ResponseWriter, r *http.Request) error {
+	if pc == nil || pc.pengumumanService == nil {
+		return errors.New("pengumuman controller: service is nil")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := pc.pengumumanService.TandaiDibaca(r.Context(), r.PathValue("id"), meta); err != nil {
+		if writePengumumanError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Pengumuman ditandai sudah dibaca", nil))
+	return nil
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"go_rest_native_sekolah/features/pengumuman"
+	"time"
+)
+
+// PengumumanFormatter digunakan untuk memformat pengumuman pada response API.
+type PengumumanFormatter struct {
+	ID             string     `json:"id"`             // ID adalah ID unik pengumuman
+	Judul          string     `json:"judul"`          // Judul adalah judul pengumuman
+	Isi            string     `json:"isi"`            // Isi adalah teks pengumuman
+	Target         string     `json:"target"`         // Target adalah semua, role, kelas, atau siswa
+	Target_Nilai   []string   `json:"target_nilai"`   // Target_Nilai adalah role, ID kelas, atau ID siswa yang dituju
+	Dibuat_Oleh    string     `json:"dibuat_oleh"`    // Dibuat_Oleh adalah ID user pembuat
+	Terbit_At      time.Time  `json:"terbit_at"`      // Terbit_At adalah waktu mulai tayang
+	Kedaluwarsa_At *time.Time `json:"kedaluwarsa_at"` // Kedaluwarsa_At adalah waktu berhenti tayang, null jika tidak kedaluwarsa
+	Disematkan     bool       `json:"disematkan"`     // Disematkan bernilai true jika pengumuman tampil paling atas
+	Dibaca         bool       `json:"dibaca"`         // Dibaca bernilai true jika user yang meminta sudah membaca pengumuman
+	Jumlah_Dibaca  int        `json:"jumlah_dibaca"`  // Jumlah_Dibaca adalah banyaknya user yang sudah membaca
+	Update_At      time.Time  `json:"update_at"`      // Update_At adalah waktu perubahan terakhir
+	Version        int        `json:"version"`        // Version adalah versi data pengumuman, sama dengan ETag
+}
+
+// PengumumanRequest digunakan untuk membaca body JSON tambah dan update pengumuman.
+// Disematkan berupa pointer agar update tanpa field disematkan tidak melepas sematan.
+type PengumumanRequest struct {
+	Judul          string     `json:"judul"`
+	Isi            string     `json:"isi"`
+	Target         string     `json:"target"`
+	Target_Nilai   []string   `json:"target_nilai"`
+	Terbit_At      time.Time  `json:"terbit_at"`
+	Kedaluwarsa_At *time.Time `json:"kedaluwarsa_at"`
+	Disematkan     *bool      `json:"disematkan"`
+}
+
+// PembacaFormatter digunakan untuk memformat satu pembaca pengumuman.
+type PembacaFormatter struct {
+	User_ID   string    `json:"user_id"`   // User_ID adalah ID user pembaca
+	Username  string    `json:"username"`  // Username adalah username pembaca
+	Role      string    `json:"role"`      // Role adalah role pembaca
+	Dibaca_At time.Time `json:"dibaca_at"` // Dibaca_At adalah waktu pengumuman pertama kali dibaca
+}
+
+// FormatPengumumanList mengubah slice PengumumanCore menjadi slice PengumumanFormatter.
+func FormatPengumumanList(cores []pengumuman.PengumumanCore) []PengumumanFormatter {
+	formatted := make([]PengumumanFormatter, 0, len(cores))
+	for _, core := range cores {
+		nilai := core.Target_Nilai
+		if nilai == nil {
+			nilai = []string{}
+		}
+		formatted = append(formatted, PengumumanFormatter{
+			ID:             core.ID,
+			Judul:          core.Judul,
+			Isi:            core.Isi,
+			Target:         core.Target,
+			Target_Nilai:   nilai,
+			Dibuat_Oleh:    core.Dibuat_Oleh,
+			Terbit_At:      core.Terbit_At,
+			Kedaluwarsa_At: core.Kedaluwarsa_At,
+			Disematkan:     core.Disematkan,
+			Dibaca:         core.Dibaca,
+			Jumlah_Dibaca:  core.Jumlah_Dibaca,
+			Update_At:      core.Update_At,
+			Version:        core.Version,
+		})
+	}
+	return formatted
+}
+
+// FormatPembacaList mengubah slice PembacaCore menjadi slice PembacaFormatter.
+func FormatPembacaList(cores []pengumuman.PembacaCore) []PembacaFormatter {
+	formatted := make([]PembacaFormatter, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, PembacaFormatter{
+			User_ID:   core.User_ID,
+			Username:  core.Username,
+			Role:      core.Role,
+			Dibaca_At: core.Dibaca_At,
+		})
+	}
+	return formatted
+}
+
+// PengumumanRequestToCore mengubah PengumumanRequest menjadi PengumumanCore.
+// Disematkan yang tidak dikirim bernilai false.
+func PengumumanRequestToCore(req PengumumanRequest) pengumuman.PengumumanCore {
+	return pengumuman.PengumumanCore{
+		Judul:          req.Judul,
+		Isi:            req.Isi,
+		Target:         req.Target,
+		Target_Nilai:   req.Target_Nilai,
+		Terbit_At:      req.Terbit_At,
+		Kedaluwarsa_At: req.Kedaluwarsa_At,
+		Disematkan:     req.Disematkan != nil && *req.Disematkan,
+	}
+}
@@ -0,0 +1,90 @@
+package pengumuman
+
+import (
+	"context"
+	"go_rest_native_sekolah/helper"
+	"time"
+)
+
+// Sasaran pengumuman. Nilai target menentukan arti Target_Nilai.
+const (
+	TargetSemua = "semua" // Semua user, Target_Nilai kosong
+	TargetRole  = "role"  // User dengan role tertentu, Target_Nilai berisi role
+	TargetKelas = "kelas" // Siswa dan guru di kelas tertentu, Target_Nilai berisi ID kelas
+	TargetSiswa = "siswa" // Siswa tertentu, Target_Nilai berisi ID siswa
+)
+
+// DaftarTarget adalah nilai target yang diterima.
+var DaftarTarget = []string{TargetSemua, TargetRole, TargetKelas, TargetSiswa}
+
+// DaftarRole adalah role user yang bisa menjadi sasaran pengumuman bertarget role.
+var DaftarRole = []string{"admin", "guru", "user"}
+
+// PembuatRoles adalah role yang boleh membuat, mengubah, dan menghapus pengumuman.
+var PembuatRoles = []string{"admin", "guru"}
+
+type (
+	// PengumumanCore merepresentasikan satu pengumuman sekolah beserta sasarannya.
+	// Pengumuman tampil di feed penerima mulai Terbit_At sampai sebelum Kedaluwarsa_At.
+	PengumumanCore struct {
+		ID             string     `json:"id"`             // ID adalah identifikasi unik pengumuman.
+		Judul          string     `json:"judul"`          // Judul adalah judul singkat pengumuman.
+		Isi            string     `json:"isi"`            // Isi adalah teks lengkap pengumuman.
+		Target         string     `json:"target"`         // Target adalah salah satu dari DaftarTarget.
+		Target_Nilai   []string   `json:"target_nilai"`   // Target_Nilai berisi role, ID kelas, atau ID siswa sesuai Target. Nil pada update berarti tidak diubah.
+		Dibuat_Oleh    string     `json:"dibuat_oleh"`    // Dibuat_Oleh adalah ID user pembuat pengumuman.
+		Terbit_At      time.Time  `json:"terbit_at"`      // Terbit_At adalah waktu pengumuman mulai tampil.
+		Kedaluwarsa_At *time.Time `json:"kedaluwarsa_at"` // Kedaluwarsa_At adalah waktu pengumuman berhenti tampil, nil berarti tidak kedaluwarsa.
+		Disematkan     bool       `json:"disematkan"`     // Disematkan bernilai true jika pengumuman selalu tampil paling atas.
+		Dibaca         bool       `json:"dibaca"`         // Dibaca bernilai true jika user yang meminta feed sudah membaca pengumuman.
+		Jumlah_Dibaca  int        `json:"jumlah_dibaca"`  // Jumlah_Dibaca adalah banyaknya user yang sudah membaca pengumuman.
+		Update_At      time.Time  `json:"update_at"`      // Update_At adalah waktu perubahan terakhir.
+		Version        int        `json:"version"`        // Version adalah versi data untuk optimistic concurrency, dikirim sebagai ETag.
+	}
+
+	// PembacaCore adalah satu user yang sudah membaca pengumuman.
+	PembacaCore struct {
+		User_ID   string    `json:"user_id"`   // User_ID adalah ID user pembaca.
+		Username  string    `json:"username"`  // Username adalah username pembaca.
+		Role      string    `json:"role"`      // Role adalah role pembaca.
+		Dibaca_At time.Time `json:"dibaca_at"` // Dibaca_At adalah waktu pertama kali pengumuman ditandai dibaca.
+	}
+
+	// Penerima adalah identitas user yang meminta feed, dipakai untuk mencocokkan sasaran pengumuman.
+	// Siswa dikenali dari email user yang sama dengan email siswa; kelas guru adalah kelas
+	// yang ia menjadi wali kelasnya atau yang ia ajar.
+	Penerima struct {
+		User_ID  string   // User_ID adalah ID user yang sedang login.
+		Role     string   // Role adalah role user yang sedang login.
+		Siswa_ID string   // Siswa_ID adalah ID siswa milik user, kosong jika user bukan siswa.
+		Kelas_ID []string // Kelas_ID adalah kelas siswa atau kelas yang diampu guru.
+	}
+
+	// DataPengumumanInterface mendefinisikan operasi tabel pengumuman, pengumuman_target, dan pengumuman_baca.
+	DataPengumumanInterface interface {
+		SelectAll(ctx context.Context) ([]PengumumanCore, error)                                   // Mengambil semua pengumuman aktif beserta sasarannya.
+		SelectById(ctx context.Context, id string) (*PengumumanCore, error)                        // Mengambil pengumuman aktif berdasarkan ID, pgx.ErrNoRows jika tidak ada.
+		Insert(ctx context.Context, insert *PengumumanCore) error                                  // Menyimpan pengumuman baru beserta sasarannya.
+		Update(ctx context.Context, update *PengumumanCore, id string) error                       // Mengubah pengumuman jika versinya masih sama dengan update.Version dan mengganti sasarannya.
+		DeleteById(ctx context.Context, id string, version int) error                              // Menghapus (soft delete) pengumuman jika versinya masih sama dengan version.
+		TargetTidakAda(ctx context.Context, target string, nilai []string) ([]string, error)       // Mengembalikan ID kelas atau siswa di nilai yang tidak ada atau sudah dihapus.
+		SelectPenerima(ctx context.Context, userID, role string) (*Penerima, error)                // Mengambil siswa dan kelas milik user.
+		SelectFeed(ctx context.Context, penerima Penerima) ([]PengumumanCore, error)               // Mengambil pengumuman yang sedang tayang untuk penerima.
+		SelectFeedById(ctx context.Context, id string, penerima Penerima) (*PengumumanCore, error) // Mengambil satu pengumuman di feed penerima, pgx.ErrNoRows jika tidak ada.
+		TandaiDibaca(ctx context.Context, id, userID string) error                                 // Mencatat bahwa user sudah membaca pengumuman; pencatatan ulang diabaikan.
+		SelectPembaca(ctx context.Context, id string) ([]PembacaCore, error)                       // Mengambil daftar user yang sudah membaca pengumuman.
+	}
+
+	// ServicePengumumanInterface mendefinisikan logika bisnis pengumuman.
+	// Parameter pengguna adalah user yang sedang login; guru hanya boleh mengubah dan menghapus pengumumannya sendiri.
+	ServicePengumumanInterface interface {
+		GetAll(ctx context.Context) ([]PengumumanCore, error)                                           // Mengambil semua pengumuman untuk dikelola admin dan guru.
+		GetById(ctx context.Context, id string) (*PengumumanCore, error)                                // Mengambil satu pengumuman.
+		Insert(ctx context.Context, insert *PengumumanCore) error                                       // Memvalidasi dan menyimpan pengumuman baru.
+		Update(ctx context.Context, update *PengumumanCore, id string, pengguna helper.MetaToken) error // Mengubah pengumuman; field kosong memakai nilai lama.
+		DeleteById(ctx context.Context, id string, version int, pengguna helper.MetaToken) error        // Menghapus pengumuman.
+		Feed(ctx context.Context, pengguna helper.MetaToken) ([]PengumumanCore, error)                  // Mengambil pengumuman yang sedang tayang untuk pengguna.
+		TandaiDibaca(ctx context.Context, id string, pengguna helper.MetaToken) error                   // Menandai pengumuman di feed pengguna sudah dibaca.
+		GetPembaca(ctx context.Context, id string) ([]PembacaCore, error)                               // Mengambil daftar pembaca pengumuman.
+	}
+)
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"go_rest_native_sekolah/features/pengumuman"
+	"time"
+)
+
+// Pengumuman merepresentasikan satu baris tabel pengumuman.
+// Sasaran pengumuman disimpan terpisah di tabel pengumuman_target.
+type Pengumuman struct {
+	ID             string     `json:"id"`             // ID adalah identifikasi unik pengumuman.
+	Judul          string     `json:"judul"`          // Judul adalah judul pengumuman.
+	Isi            string     `json:"isi"`            // Isi adalah teks pengumuman.
+	Target         string     `json:"target"`         // Target adalah semua, role, kelas, atau siswa.
+	Target_Nilai   []string   `json:"target_nilai"`   // Target_Nilai adalah nilai dari tabel pengumuman_target.
+	Dibuat_Oleh    string     `json:"dibuat_oleh"`    // Dibuat_Oleh adalah ID user pembuat.
+	Terbit_At      time.Time  `json:"terbit_at"`      // Terbit_At adalah waktu mulai tayang.
+	Kedaluwarsa_At *time.Time `json:"kedaluwarsa_at"` // Kedaluwarsa_At adalah waktu berhenti tayang, jika ada.
+	Disematkan     bool       `json:"disematkan"`     // Disematkan menandai pengumuman yang disematkan di atas.
+	Dibaca         bool       `json:"dibaca"`         // Dibaca hanya terisi pada query feed.
+	Jumlah_Dibaca  int        `json:"jumlah_dibaca"`  // Jumlah_Dibaca adalah jumlah baris pengumuman_baca.
+	Update_At      time.Time  `json:"update_at"`      // Update_At adalah waktu perubahan terakhir.
+	Delete_At      *time.Time `json:"delete_at"`      // Delete_At adalah waktu pengumuman dihapus, jika ada.
+	Version        int        `json:"version"`        // Version adalah versi data untuk optimistic concurrency.
+}
+
+// TableName mengembalikan nama tabel pengumuman di database.
+func (p *Pengumuman) TableName() string {
+	return "pengumuman"
+}
+
+// FormatterRequest mengubah PengumumanCore menjadi Pengumuman untuk disimpan ke database.
+func FormatterRequest(req pengumuman.PengumumanCore) Pengumuman {
+	return Pengumuman{
+		ID:             req.ID,
+		Judul:          req.Judul,
+		Isi:            req.Isi,
+		Target:         req.Target,
+		Target_Nilai:   req.Target_Nilai,
+		Dibuat_Oleh:    req.Dibuat_Oleh,
+		Terbit_At:      req.Terbit_At,
+		Kedaluwarsa_At: req.Kedaluwarsa_At,
+		Disematkan:     req.Disematkan,
+		Version:        req.Version,
+	}
+}
+
+// FormatterResponse mengubah Pengumuman dari database menjadi PengumumanCore.
+func FormatterResponse(res Pengumuman) pengumuman.PengumumanCore {
+	return pengumuman.PengumumanCore{
+		ID:             res.ID,
+		Judul:          res.Judul,
+		Isi:            res.Isi,
+		Target:         res.Target,
+		Target_Nilai:   res.Target_Nilai,
+		Dibuat_Oleh:    res.Dibuat_Oleh,
+		Terbit_At:      res.Terbit_At,
+		Kedaluwarsa_At: res.Kedaluwarsa_At,
+		Disematkan:     res.Disematkan,
+		Dibaca:         res.Dibaca,
+		Jumlah_Dibaca:  res.Jumlah_Dibaca,
+		Update_At:      res.Update_At,
+		Version:        res.Version,
+	}
+}
@@ -0,0 +1,318 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/pengumuman"
+	"go_rest_native_sekolah/helper"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// pengumumanQuery menghandle query ke tabel pengumuman, pengumuman_target, dan pengumuman_baca.
+type pengumumanQuery struct {
+	db helper.DBTX
+}
+
+// NewDataPengumuman membuat objek pengumumanQuery dengan parameter db.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
+// Jika parameter db nil maka akan terjadi panic.
+func NewDataPengumuman(db helper.DBTX) pengumuman.DataPengumumanInterface {
+	if db == nil {
+		panic("pengumuman model: Nil database")
+	}
+	return &pengumumanQuery{db: db}
+}
+
+// kolomPengumuman adalah daftar kolom yang diambil untuk setiap pengumuman, sesuai urutan scanPengumuman.
+// Query yang memakainya harus memberi alias p pada tabel pengumuman dan mengirim ID user peminta
+// sebagai parameter $1 untuk mengisi kolom dibaca.
+const kolomPengumuman = `p.id, p.judul, p.isi, p.target,
+	COALESCE((SELECT array_agg(t.nilai ORDER BY t.nilai) FROM pengumuman_target t WHERE t.pengumuman_id = p.id), '{}'),
+	COALESCE(p.dibuat_oleh, ''), p.terbit_at, p.kedaluwarsa_at, p.disematkan,
+	EXISTS (SELECT 1 FROM pengumuman_baca b WHERE b.pengumuman_id = p.id AND b.user_id = $1),
+	(SELECT COUNT(*) FROM pengumuman_baca b WHERE b.pengumuman_id = p.id),
+	p.update_at, p.version`
+
+// urutanPengumuman mengurutkan pengumuman yang disematkan lebih dulu, lalu dari yang terbaru terbit.
+const urutanPengumuman = " ORDER BY p.disematkan DESC, p.terbit_at DESC, p.id"
+
+// scanPengumuman membaca satu baris hasil query dengan kolom kolomPengumuman ke dalam dst.
+func scanPengumuman(row pgx.Row, dst *Pengumuman) error {
+	return row.Scan(&dst.ID, &dst.Judul, &dst.Isi, &dst.Target, &dst.Target_Nilai, &dst.Dibuat_Oleh,
+		&dst.Terbit_At, &dst.Kedaluwarsa_At, &dst.Disematkan, &dst.Dibaca, &dst.Jumlah_Dibaca,
+		&dst.Update_At, &dst.Version)
+}
+
+// selectPengumuman menjalankan query pengumuman dan mengubah semua barisnya menjadi PengumumanCore.
+func (q *pengumumanQuery) selectPengumuman(ctx context.Context, query string, args ...any) ([]pengumuman.PengumumanCore, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectPengumuman error query", "error", err)
+		return nil, fmt.Errorf("select pengumuman failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []pengumuman.PengumumanCore{}
+	for rows.Next() {
+		var data Pengumuman
+		if err := scanPengumuman(rows, &data); err != nil {
+			return nil, fmt.Errorf("select pengumuman failed: %w", err)
+		}
+		result = append(result, FormatterResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select pengumuman failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectAll implements pengumuman.DataPengumumanInterface.
+// Pengumuman yang belum terbit dan yang sudah kedaluwarsa ikut diambil.
+func (q *pengumumanQuery) SelectAll(ctx context.Context) ([]pengumuman.PengumumanCore, error) {
+	return q.selectPengumuman(ctx,
+		"SELECT "+kolomPengumuman+" FROM pengumuman p WHERE p.delete_at IS NULL"+urutanPengumuman, "")
+}
+
+// SelectById implements pengumuman.DataPengumumanInterface.
+func (q *pengumumanQuery) SelectById(ctx context.Context, id string) (*pengumuman.PengumumanCore, error) {
+	result, err := q.selectPengumuman(ctx,
+		"SELECT "+kolomPengumuman+" FROM pengumuman p WHERE p.id = $2 AND p.delete_at IS NULL", "", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// Insert implements pengumuman.DataPengumumanInterface.
+// ID dibuat otomatis jika kosong; Update_At dan Version diisi dari database.
+// Pengumuman dan sasarannya disimpan di dua tabel, jalankan di dalam helper.UnitOfWork.
+func (q *pengumumanQuery) Insert(ctx context.Context, insert *pengumuman.PengumumanCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	data := FormatterRequest(*insert)
+	query := `INSERT INTO pengumuman (id, judul, isi, target, dibuat_oleh, terbit_at, kedaluwarsa_at, disematkan)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		RETURNING update_at, version`
+	err := q.db.QueryRow(ctx, query, data.ID, data.Judul, data.Isi, data.Target, data.Dibuat_Oleh,
+		data.Terbit_At, data.Kedaluwarsa_At, data.Disematkan).Scan(&insert.Update_At, &insert.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert pengumuman error", "error", err)
+		return fmt.Errorf("insert pengumuman failed: %w", err)
+	}
+	if err := q.simpanTarget(ctx, insert.ID, data.Target_Nilai); err != nil {
+		return err
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully inserted pengumuman", "id", insert.ID)
+	return nil
+}
+
+// Update implements pengumuman.DataPengumumanInterface.
+// Sasaran lama diganti seluruhnya dengan update.Target_Nilai.
+// Mengembalikan helper.ErrVersionConflict jika versinya sudah berubah dan pgx.ErrNoRows jika pengumuman tidak ada.
+func (q *pengumumanQuery) Update(ctx context.Context, update *pengumuman.PengumumanCore, id string) error {
+	if update == nil {
+		return errors.New("update data is nil")
+	}
+
+	data := FormatterRequest(*update)
+	query := `UPDATE pengumuman
+		SET judul = $1, isi = $2, target = $3, terbit_at = $4, kedaluwarsa_at = $5, disematkan = $6,
+			update_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $7 AND delete_at IS NULL AND version = $8`
+	tag, err := q.db.Exec(ctx, query, data.Judul, data.Isi, data.Target, data.Terbit_At, data.Kedaluwarsa_At,
+		data.Disematkan, id, data.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Update pengumuman error", "error", err)
+		return fmt.Errorf("update pengumuman failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "pengumuman", id)
+	}
+	if err := q.simpanTarget(ctx, id, data.Target_Nilai); err != nil {
+		return err
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully updated pengumuman", "id", id)
+	return nil
+}
+
+// DeleteById implements pengumuman.DataPengumumanInterface.
+// Pengumuman hanya ditandai terhapus (soft delete); sasaran dan tanda baca tetap disimpan.
+func (q *pengumumanQuery) DeleteById(ctx context.Context, id string, version int) error {
+	tag, err := q.db.Exec(ctx,
+		"UPDATE pengumuman SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2",
+		id, version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete pengumuman error", "error", err)
+		return fmt.Errorf("delete pengumuman failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "pengumuman", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted pengumuman", "id", id)
+	return nil
+}
+
+// simpanTarget mengganti seluruh nilai sasaran pengumuman id dengan nilai.
+func (q *pengumumanQuery) simpanTarget(ctx context.Context, id string, nilai []string) error {
+	if _, err := q.db.Exec(ctx, "DELETE FROM pengumuman_target WHERE pengumuman_id = $1", id); err != nil {
+		helper.LoggerFromContext(ctx).Error("simpanTarget error delete", "error", err)
+		return fmt.Errorf("simpan target pengumuman failed: %w", err)
+	}
+	if len(nilai) == 0 {
+		return nil
+	}
+	_, err := q.db.Exec(ctx,
+		"INSERT INTO pengumuman_target (pengumuman_id, nilai) SELECT $1, UNNEST($2::text[])", id, nilai)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("simpanTarget error insert", "error", err)
+		return fmt.Errorf("simpan target pengumuman failed: %w", err)
+	}
+	return nil
+}
+
+// tabelTarget memetakan target pengumuman ke nama tabel yang dirujuk nilainya.
+// Nama tabel tidak pernah berasal dari input client.
+var tabelTarget = map[string]string{
+	pengumuman.TargetKelas: "kelas",
+	pengumuman.TargetSiswa: "siswa",
+}
+
+// TargetTidakAda implements pengumuman.DataPengumumanInterface.
+func (q *pengumumanQuery) TargetTidakAda(ctx context.Context, target string, nilai []string) ([]string, error) {
+	tabel, ok := tabelTarget[target]
+	if !ok {
+		return nil, fmt.Errorf("target tidak merujuk tabel: %q", target)
+	}
+	rows, err := q.db.Query(ctx, `SELECT n FROM UNNEST($1::text[]) AS n
+		WHERE NOT EXISTS (SELECT 1 FROM `+tabel+` x WHERE x.id = n AND x.delete_at IS NULL)
+		ORDER BY n`, nilai)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("TargetTidakAda error query", "error", err)
+		return nil, fmt.Errorf("cek target pengumuman failed: %w", err)
+	}
+	tidakAda, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("cek target pengumuman failed: %w", err)
+	}
+	return tidakAda, nil
+}
+
+// SelectPenerima implements pengumuman.DataPengumumanInterface.
+// Role user dikenali sebagai siswa jika emailnya sama dengan email siswa aktif.
+// Guru mendapat kelas yang ia walikan dan kelas dari mata pelajaran aktif yang ia ajar.
+func (q *pengumumanQuery) SelectPenerima(ctx context.Context, userID, role string) (*pengumuman.Penerima, error) {
+	penerima := &pengumuman.Penerima{User_ID: userID, Role: role, Kelas_ID: []string{}}
+
+	switch role {
+	case "user":
+		var kelasID string
+		err := q.db.QueryRow(ctx, `SELECT s.id, COALESCE(s.kelas_id, '')
+			FROM siswa s JOIN users u ON LOWER(u.email) = LOWER(s.email)
+			WHERE u.id = $1 AND s.delete_at IS NULL
+			LIMIT 1`, userID).Scan(&penerima.Siswa_ID, &kelasID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			helper.LoggerFromContext(ctx).Error("SelectPenerima error query siswa", "error", err)
+			return nil, fmt.Errorf("select penerima failed: %w", err)
+		}
+		if kelasID != "" {
+			penerima.Kelas_ID = append(penerima.Kelas_ID, kelasID)
+		}
+	case "guru":
+		rows, err := q.db.Query(ctx, `SELECT k.id FROM kelas k
+			JOIN guru g ON g.id = k.id_guru
+			WHERE g.id_user = $1 AND g.delete_at IS NULL AND k.delete_at IS NULL
+			UNION
+			SELECT mp.kelas_id FROM mata_pelajaran mp
+			JOIN mata_pelajaran_guru mpg ON mpg.mata_pelajaran_id = mp.id
+			JOIN guru g ON g.id = mpg.id_guru
+			WHERE g.id_user = $1 AND g.delete_at IS NULL AND mp.delete_at IS NULL AND mp.kelas_id IS NOT NULL`, userID)
+		if err != nil {
+			helper.LoggerFromContext(ctx).Error("SelectPenerima error query kelas guru", "error", err)
+			return nil, fmt.Errorf("select penerima failed: %w", err)
+		}
+		kelas, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return nil, fmt.Errorf("select penerima failed: %w", err)
+		}
+		penerima.Kelas_ID = kelas
+	}
+	return penerima, nil
+}
+
+// kondisiFeed memilih pengumuman yang sedang tayang dan menyasar penerima.
+// Parameter $1 sampai $4 berisi User_ID, Role, Kelas_ID, dan Siswa_ID penerima.
+// Pembuat pengumuman selalu melihat pengumumannya sendiri.
+const kondisiFeed = ` FROM pengumuman p
+	WHERE p.delete_at IS NULL
+		AND p.terbit_at <= NOW()
+		AND (p.kedaluwarsa_at IS NULL OR p.kedaluwarsa_at > NOW())
+		AND (p.target = 'semua'
+			OR p.dibuat_oleh = $1
+			OR (p.target = 'role' AND EXISTS (SELECT 1 FROM pengumuman_target t WHERE t.pengumuman_id = p.id AND t.nilai = $2))
+			OR (p.target = 'kelas' AND EXISTS (SELECT 1 FROM pengumuman_target t WHERE t.pengumuman_id = p.id AND t.nilai = ANY($3)))
+			OR (p.target = 'siswa' AND $4 <> '' AND EXISTS (SELECT 1 FROM pengumuman_target t WHERE t.pengumuman_id = p.id AND t.nilai = $4)))`
+
+// SelectFeed implements pengumuman.DataPengumumanInterface.
+// Pengumuman yang disematkan tampil paling atas, selebihnya dari yang terbaru terbit.
+func (q *pengumumanQuery) SelectFeed(ctx context.Context, penerima pengumuman.Penerima) ([]pengumuman.PengumumanCore, error) {
+	return q.selectPengumuman(ctx, "SELECT "+kolomPengumuman+kondisiFeed+urutanPengumuman,
+		penerima.User_ID, penerima.Role, penerima.Kelas_ID, penerima.Siswa_ID)
+}
+
+// SelectFeedById implements pengumuman.DataPengumumanInterface.
+func (q *pengumumanQuery) SelectFeedById(ctx context.Context, id string, penerima pengumuman.Penerima) (*pengumuman.PengumumanCore, error) {
+	result, err := q.selectPengumuman(ctx, "SELECT "+kolomPengumuman+kondisiFeed+" AND p.id = $5",
+		penerima.User_ID, penerima.Role, penerima.Kelas_ID, penerima.Siswa_ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// TandaiDibaca implements pengumuman.DataPengumumanInterface.
+// Waktu baca yang disimpan adalah waktu pertama kali pengumuman ditandai dibaca.
+func (q *pengumumanQuery) TandaiDibaca(ctx context.Context, id, userID string) error {
+	_, err := q.db.Exec(ctx, `INSERT INTO pengumuman_baca (pengumuman_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (pengumuman_id, user_id) DO NOTHING`, id, userID)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("TandaiDibaca error", "error", err)
+		return fmt.Errorf("tandai dibaca failed: %w", err)
+	}
+	return nil
+}
+
+// SelectPembaca implements pengumuman.DataPengumumanInterface.
+// Pembaca diurutkan dari yang paling dulu membaca.
+func (q *pengumumanQuery) SelectPembaca(ctx context.Context, id string) ([]pengumuman.PembacaCore, error) {
+	rows, err := q.db.Query(ctx, `SELECT u.id, u.username, u.role, b.dibaca_at
+		FROM pengumuman_baca b JOIN users u ON u.id = b.user_id
+		WHERE b.pengumuman_id = $1
+		ORDER BY b.dibaca_at, u.username`, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectPembaca error query", "error", err)
+		return nil, fmt.Errorf("select pembaca failed: %w", err)
+	}
+	pembaca, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (pengumuman.PembacaCore, error) {
+		var p pengumuman.PembacaCore
+		err := row.Scan(&p.User_ID, &p.Username, &p.Role, &p.Dibaca_At)
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select pembaca failed: %w", err)
+	}
+	return pembaca, nil
+}
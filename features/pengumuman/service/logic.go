@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/pengumuman"
+	"go_rest_native_sekolah/helper"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errPengumumanNotFound = errors.New("pengumuman service: Data tidak ditemukan")
+	errBukanPembuat       = errors.New("pengumuman service: akses ditolak, guru hanya boleh mengubah pengumuman yang ia buat")
+)
+
+// maxJudul adalah panjang maksimum judul pengumuman, sama dengan kolom judul di database.
+const maxJudul = 200
+
+// pengumumanService merepresentasikan service untuk pengumuman.
+type pengumumanService struct {
+	pengumumanData pengumuman.DataPengumumanInterface                    // pengumumanData berisi akses ke tabel pengumuman
+	uow            helper.UnitOfWork[pengumuman.DataPengumumanInterface] // uow menyimpan pengumuman dan sasarannya dalam satu transaksi
+	now            func() time.Time                                      // now mengembalikan waktu sekarang, diganti saat pengujian
+}
+
+// NewServicePengumuman membuat service pengumuman.
+// Parameter uow dipakai untuk menyimpan pengumuman beserta sasarannya dalam satu transaksi.
+// Jika parameter repo atau uow nil maka akan terjadi panic.
+func NewServicePengumuman(repo pengumuman.DataPengumumanInterface, uow helper.UnitOfWork[pengumuman.DataPengumumanInterface]) pengumuman.ServicePengumumanInterface {
+	if repo == nil || uow == nil {
+		panic("pengumuman service: Nil repository atau unit of work")
+	}
+	return &pengumumanService{pengumumanData: repo, uow: uow, now: time.Now}
+}
+
+// GetAll implements pengumuman.ServicePengumumanInterface.
+// Pengumuman yang belum terbit dan yang sudah kedaluwarsa ikut dikembalikan.
+func (s *pengumumanService) GetAll(ctx context.Context) ([]pengumuman.PengumumanCore, error) {
+	result, err := s.pengumumanData.SelectAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pengumuman service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// GetById implements pengumuman.ServicePengumumanInterface.
+func (s *pengumumanService) GetById(ctx context.Context, id string) (*pengumuman.PengumumanCore, error) {
+	result, err := s.pengumumanData.SelectById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errPengumumanNotFound
+		}
+		return nil, fmt.Errorf("pengumuman service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// Insert implements pengumuman.ServicePengumumanInterface.
+// Target kosong berarti semua user dan Terbit_At kosong berarti terbit sekarang.
+// Dibuat_Oleh diisi controller dengan ID user yang sedang login.
+func (s *pengumumanService) Insert(ctx context.Context, insert *pengumuman.PengumumanCore) error {
+	if insert == nil {
+		return errors.New("pengumuman service: input is nil")
+	}
+	if insert.Terbit_At.IsZero() {
+		insert.Terbit_At = s.now()
+	}
+	if err := s.validasi(ctx, insert); err != nil {
+		return err
+	}
+
+	insert.ID = ""
+	err := s.uow.Do(ctx, func(repo pengumuman.DataPengumumanInterface) error {
+		return repo.Insert(ctx, insert)
+	})
+	if err != nil {
+		return fmt.Errorf("pengumuman service: gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+// Update implements pengumuman.ServicePengumumanInterface.
+// Judul, isi, target, dan waktu terbit yang kosong memakai nilai lama. Target_Nilai nil memakai
+// sasaran lama selama target tidak diganti. Kedaluwarsa_At nil memakai nilai lama.
+func (s *pengumumanService) Update(ctx context.Context, update *pengumuman.PengumumanCore, id string, pengguna helper.MetaToken) error {
+	if update == nil {
+		return errors.New("pengumuman service: input is nil")
+	}
+	existing, err := s.milikPengguna(ctx, id, pengguna)
+	if err != nil {
+		return err
+	}
+	// Tolak update jika data sudah diubah sejak client mengambilnya (If-Match)
+	if update.Version != existing.Version {
+		return helper.ErrVersionConflict
+	}
+
+	if strings.TrimSpace(update.Judul) == "" {
+		update.Judul = existing.Judul
+	}
+	if strings.TrimSpace(update.Isi) == "" {
+		update.Isi = existing.Isi
+	}
+	update.Target = strings.ToLower(strings.TrimSpace(update.Target))
+	if update.Target == "" {
+		update.Target = existing.Target
+	}
+	if update.Target_Nilai == nil && update.Target == existing.Target {
+		update.Target_Nilai = existing.Target_Nilai
+	}
+	if update.Terbit_At.IsZero() {
+		update.Terbit_At = existing.Terbit_At
+	}
+	if update.Kedaluwarsa_At == nil {
+		update.Kedaluwarsa_At = existing.Kedaluwarsa_At
+	}
+	update.Dibuat_Oleh = existing.Dibuat_Oleh
+	if err := s.validasi(ctx, update); err != nil {
+		return err
+	}
+
+	err = s.uow.Do(ctx, func(repo pengumuman.DataPengumumanInterface) error {
+		return repo.Update(ctx, update, id)
+	})
+	if err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errPengumumanNotFound
+		}
+		return fmt.Errorf("pengumuman service: gagal update data: %w", err)
+	}
+	return nil
+}
+
+// DeleteById implements pengumuman.ServicePengumumanInterface.
+func (s *pengumumanService) DeleteById(ctx context.Context, id string, version int, pengguna helper.MetaToken) error {
+	if _, err := s.milikPengguna(ctx, id, pengguna); err != nil {
+		return err
+	}
+	if err := s.pengumumanData.DeleteById(ctx, id, version); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errPengumumanNotFound
+		}
+		return fmt.Errorf("pengumuman service: gagal menghapus data: %w", err)
+	}
+	return nil
+}
+
+// Feed implements pengumuman.ServicePengumumanInterface.
+func (s *pengumumanService) Feed(ctx context.Context, pengguna helper.MetaToken) ([]pengumuman.PengumumanCore, error) {
+	penerima, err := s.pengumumanData.SelectPenerima(ctx, pengguna.ID, pengguna.Role)
+	if err != nil {
+		return nil, fmt.Errorf("pengumuman service: gagal mengambil data penerima: %w", err)
+	}
+	result, err := s.pengumumanData.SelectFeed(ctx, *penerima)
+	if err != nil {
+		return nil, fmt.Errorf("pengumuman service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// TandaiDibaca implements pengumuman.ServicePengumumanInterface.
+// Pengumuman yang tidak tampil di feed pengguna dianggap tidak ditemukan.
+func (s *pengumumanService) TandaiDibaca(ctx context.Context, id string, pengguna helper.MetaToken) error {
+	penerima, err := s.pengumumanData.SelectPenerima(ctx, pengguna.ID, pengguna.Role)
+	if err != nil {
+		return fmt.Errorf("pengumuman service: gagal mengambil data penerima: %w", err)
+	}
+	if _, err := s.pengumumanData.SelectFeedById(ctx, id, *penerima); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errPengumumanNotFound
+		}
+		return fmt.Errorf("pengumuman service: gagal mengambil data: %w", err)
+	}
+	if err := s.pengumumanData.TandaiDibaca(ctx, id, pengguna.ID); err != nil {
+		return fmt.Errorf("pengumuman service: gagal menandai dibaca: %w", err)
+	}
+	return nil
+}
+
+// GetPembaca implements pengumuman.ServicePengumumanInterface.
+func (s *pengumumanService) GetPembaca(ctx context.Context, id string) ([]pengumuman.PembacaCore, error) {
+	if _, err := s.GetById(ctx, id); err != nil {
+		return nil, err
+	}
+	result, err := s.pengumumanData.SelectPembaca(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("pengumuman service: gagal mengambil data pembaca: %w", err)
+	}
+	return result, nil
+}
+
+// milikPengguna mengambil pengumuman id dan memastikan pengguna boleh mengubahnya.
+// Admin boleh mengubah semua pengumuman, guru hanya pengumuman yang ia buat.
+func (s *pengumumanService) milikPengguna(ctx context.Context, id string, pengguna helper.MetaToken) (*pengumuman.PengumumanCore, error) {
+	existing, err := s.GetById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pengguna.Role != "admin" && existing.Dibuat_Oleh != pengguna.ID {
+		return nil, errBukanPembuat
+	}
+	return existing, nil
+}
+
+// validasi merapikan dan memeriksa isi pengumuman sebelum disimpan.
+// Nilai sasaran dirapikan tanpa duplikat; kelas dan siswa yang dituju harus masih aktif.
+func (s *pengumumanService) validasi(ctx context.Context, p *pengumuman.PengumumanCore) error {
+	p.Judul = strings.TrimSpace(p.Judul)
+	if p.Judul == "" {
+		return errors.New("validation error: judul harus diisi")
+	}
+	if utf8.RuneCountInString(p.Judul) > maxJudul {
+		return fmt.Errorf("validation error: judul maksimal %d karakter", maxJudul)
+	}
+	p.Isi = strings.TrimSpace(p.Isi)
+	if p.Isi == "" {
+		return errors.New("validation error: isi harus diisi")
+	}
+	if p.Kedaluwarsa_At != nil && !p.Kedaluwarsa_At.After(p.Terbit_At) {
+		return errors.New("validation error: kedaluwarsa_at harus setelah terbit_at")
+	}
+
+	p.Target = strings.ToLower(strings.TrimSpace(p.Target))
+	if p.Target == "" {
+		p.Target = pengumuman.TargetSemua
+	}
+	if !slices.Contains(pengumuman.DaftarTarget, p.Target) {
+		return fmt.Errorf("validation error: target harus salah satu dari %s", strings.Join(pengumuman.DaftarTarget, ", "))
+	}
+
+	nilai := []string{}
+	for _, n := range p.Target_Nilai {
+		n = strings.TrimSpace(n)
+		if p.Target == pengumuman.TargetRole {
+			n = strings.ToLower(n)
+		}
+		if n != "" && !slices.Contains(nilai, n) {
+			nilai = append(nilai, n)
+		}
+	}
+	p.Target_Nilai = nilai
+
+	switch p.Target {
+	case pengumuman.TargetSemua:
+		if len(nilai) > 0 {
+			return errors.New("validation error: target_nilai harus kosong untuk target semua")
+		}
+	case pengumuman.TargetRole:
+		if len(nilai) == 0 {
+			return errors.New("validation error: target_nilai harus berisi minimal satu role")
+		}
+		for _, role := range nilai {
+			if !slices.Contains(pengumuman.DaftarRole, role) {
+				return fmt.Errorf("validation error: role '%s' tidak dikenal, gunakan %s", role, strings.Join(pengumuman.DaftarRole, ", "))
+			}
+		}
+	case pengumuman.TargetKelas, pengumuman.TargetSiswa:
+		if len(nilai) == 0 {
+			return fmt.Errorf("validation error: target_nilai harus berisi minimal satu ID %s", p.Target)
+		}
+		tidakAda, err := s.pengumumanData.TargetTidakAda(ctx, p.Target, nilai)
+		if err != nil {
+			return fmt.Errorf("pengumuman service: gagal cek target: %w", err)
+		}
+		if len(tidakAda) > 0 {
+			return fmt.Errorf("validation error: %s tidak ditemukan: %s", p.Target, strings.Join(tidakAda, ", "))
+		}
+	}
+	return nil
+}
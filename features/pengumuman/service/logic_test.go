@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go_rest_native_sekolah/features/pengumuman"
+	"go_rest_native_sekolah/helper"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock untuk DataPengumumanInterface
+type mockDataPengumuman struct {
+	mock.Mock
+}
+
+func (m *mockDataPengumuman) SelectAll(ctx context.Context) ([]pengumuman.PengumumanCore, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]pengumuman.PengumumanCore), args.Error(1)
+}
+
+func (m *mockDataPengumuman) SelectById(ctx context.Context, id string) (*pengumuman.PengumumanCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pengumuman.PengumumanCore), args.Error(1)
+}
+
+func (m *mockDataPengumuman) Insert(ctx context.Context, insert *pengumuman.PengumumanCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataPengumuman) Update(ctx context.Context, update *pengumuman.PengumumanCore, id string) error {
+	args := m.Called(update, id)
+	return args.Error(0)
+}
+
+func (m *mockDataPengumuman) DeleteById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataPengumuman) TargetTidakAda(ctx context.Context, target string, nilai []string) ([]string, error) {
+	args := m.Called(target, nilai)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockDataPengumuman) SelectPenerima(ctx context.Context, userID, role string) (*pengumuman.Penerima, error) {
+	args := m.Called(userID, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pengumuman.Penerima), args.Error(1)
+}
+
+func (m *mockDataPengumuman) SelectFeed(ctx context.Context, penerima pengumuman.Penerima) ([]pengumuman.PengumumanCore, error) {
+	args := m.Called(penerima)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]pengumuman.PengumumanCore), args.Error(1)
+}
+
+func (m *mockDataPengumuman) SelectFeedById(ctx context.Context, id string, penerima pengumuman.Penerima) (*pengumuman.PengumumanCore, error) {
+	args := m.Called(id, penerima)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pengumuman.PengumumanCore), args.Error(1)
+}
+
+func (m *mockDataPengumuman) TandaiDibaca(ctx context.Context, id, userID string) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
+
+func (m *mockDataPengumuman) SelectPembaca(ctx context.Context, id string) ([]pengumuman.PembacaCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]pengumuman.PembacaCore), args.Error(1)
+}
+
+// waktuUji adalah waktu sekarang yang dipakai service selama pengujian.
+var waktuUji = time.Date(2026, 7, 13, 7, 0, 0, 0, time.UTC)
+
+func newTestService(repo *mockDataPengumuman) *pengumumanService {
+	return &pengumumanService{pengumumanData: repo, uow: helper.JoinUnitOfWork[pengumuman.DataPengumumanInterface](repo), now: func() time.Time { return waktuUji }}
+}
+
+func TestInsertPengumuman(t *testing.T) {
+	t.Run("success insert - target kosong berarti semua dan terbit sekarang", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("Insert", mock.AnythingOfType("*pengumuman.PengumumanCore")).Return(nil).Once()
+
+		data := &pengumuman.PengumumanCore{Judul: "  Libur Semester ", Isi: "Sekolah libur mulai Senin", Dibuat_Oleh: "admin-1"}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Libur Semester", data.Judul)
+		assert.Equal(t, pengumuman.TargetSemua, data.Target)
+		assert.Empty(t, data.Target_Nilai)
+		assert.Equal(t, waktuUji, data.Terbit_At)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success insert - role dirapikan tanpa duplikat", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("Insert", mock.AnythingOfType("*pengumuman.PengumumanCore")).Return(nil).Once()
+
+		data := &pengumuman.PengumumanCore{Judul: "Rapat", Isi: "Rapat guru", Target: "Role", Target_Nilai: []string{"Guru", "guru ", "admin"}}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, pengumuman.TargetRole, data.Target)
+		assert.Equal(t, []string{"guru", "admin"}, data.Target_Nilai)
+	})
+
+	t.Run("success insert - kelas dicek ke database", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("TargetTidakAda", pengumuman.TargetKelas, []string{"kelas-1", "kelas-2"}).Return([]string{}, nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*pengumuman.PengumumanCore")).Return(nil).Once()
+
+		data := &pengumuman.PengumumanCore{Judul: "Study tour", Isi: "Kumpul jam 6", Target: "kelas", Target_Nilai: []string{"kelas-1", "kelas-2"}}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed insert - siswa tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("TargetTidakAda", pengumuman.TargetSiswa, []string{"s-1", "s-9"}).Return([]string{"s-9"}, nil).Once()
+
+		data := &pengumuman.PengumumanCore{Judul: "Remedial", Isi: "Remedial Jumat", Target: "siswa", Target_Nilai: []string{"s-1", "s-9"}}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "validation error: siswa tidak ditemukan: s-9")
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	kedaluwarsa := waktuUji.Add(-time.Hour)
+	invalid := []struct {
+		name  string
+		data  pengumuman.PengumumanCore
+		pesan string
+	}{
+		{"judul kosong", pengumuman.PengumumanCore{Isi: "isi"}, "judul"},
+		{"isi kosong", pengumuman.PengumumanCore{Judul: "judul"}, "isi"},
+		{"target tidak dikenal", pengumuman.PengumumanCore{Judul: "judul", Isi: "isi", Target: "ortu"}, "target harus"},
+		{"semua dengan nilai", pengumuman.PengumumanCore{Judul: "judul", Isi: "isi", Target_Nilai: []string{"guru"}}, "harus kosong"},
+		{"role tanpa nilai", pengumuman.PengumumanCore{Judul: "judul", Isi: "isi", Target: "role"}, "minimal satu role"},
+		{"role tidak dikenal", pengumuman.PengumumanCore{Judul: "judul", Isi: "isi", Target: "role", Target_Nilai: []string{"ortu"}}, "role 'ortu'"},
+		{"kelas tanpa nilai", pengumuman.PengumumanCore{Judul: "judul", Isi: "isi", Target: "kelas", Target_Nilai: []string{" "}}, "minimal satu ID kelas"},
+		{"kedaluwarsa sebelum terbit", pengumuman.PengumumanCore{Judul: "judul", Isi: "isi", Kedaluwarsa_At: &kedaluwarsa}, "kedaluwarsa_at"},
+	}
+	for _, tc := range invalid {
+		t.Run("failed insert - "+tc.name, func(t *testing.T) {
+			mockRepo := new(mockDataPengumuman)
+
+			data := tc.data
+			err := newTestService(mockRepo).Insert(context.Background(), &data)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "validation error")
+			assert.Contains(t, err.Error(), tc.pesan)
+			mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+		})
+	}
+}
+
+func TestUpdatePengumuman(t *testing.T) {
+	existing := func() *pengumuman.PengumumanCore {
+		return &pengumuman.PengumumanCore{
+			ID: "p-1", Judul: "Lama", Isi: "Isi lama", Target: pengumuman.TargetKelas, Target_Nilai: []string{"kelas-1"},
+			Dibuat_Oleh: "guru-user-1", Terbit_At: waktuUji, Version: 2,
+		}
+	}
+	guru := helper.MetaToken{ID: "guru-user-1", Role: "guru"}
+
+	t.Run("success update - field kosong memakai nilai lama", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-1").Return(existing(), nil).Once()
+		mockRepo.On("TargetTidakAda", pengumuman.TargetKelas, []string{"kelas-1"}).Return([]string{}, nil).Once()
+		mockRepo.On("Update", mock.AnythingOfType("*pengumuman.PengumumanCore"), "p-1").Return(nil).Once()
+
+		update := &pengumuman.PengumumanCore{Judul: "Baru", Disematkan: true, Version: 2}
+		err := newTestService(mockRepo).Update(context.Background(), update, "p-1", guru)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Baru", update.Judul)
+		assert.Equal(t, "Isi lama", update.Isi)
+		assert.Equal(t, pengumuman.TargetKelas, update.Target)
+		assert.Equal(t, []string{"kelas-1"}, update.Target_Nilai)
+		assert.Equal(t, waktuUji, update.Terbit_At)
+		assert.True(t, update.Disematkan)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success update - admin mengganti target ke semua", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-1").Return(existing(), nil).Once()
+		mockRepo.On("Update", mock.AnythingOfType("*pengumuman.PengumumanCore"), "p-1").Return(nil).Once()
+
+		update := &pengumuman.PengumumanCore{Target: "semua", Version: 2}
+		err := newTestService(mockRepo).Update(context.Background(), update, "p-1", helper.MetaToken{ID: "admin-1", Role: "admin"})
+
+		assert.NoError(t, err)
+		assert.Empty(t, update.Target_Nilai)
+		assert.Equal(t, "guru-user-1", update.Dibuat_Oleh)
+	})
+
+	t.Run("failed update - guru lain", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-1").Return(existing(), nil).Once()
+
+		err := newTestService(mockRepo).Update(context.Background(), &pengumuman.PengumumanCore{Version: 2}, "p-1", helper.MetaToken{ID: "guru-user-2", Role: "guru"})
+
+		assert.ErrorIs(t, err, errBukanPembuat)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed update - versi berbeda", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-1").Return(existing(), nil).Once()
+
+		err := newTestService(mockRepo).Update(context.Background(), &pengumuman.PengumumanCore{Version: 1}, "p-1", guru)
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+	})
+
+	t.Run("failed update - ganti target tanpa nilai", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-1").Return(existing(), nil).Once()
+
+		err := newTestService(mockRepo).Update(context.Background(), &pengumuman.PengumumanCore{Target: "siswa", Version: 2}, "p-1", guru)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "minimal satu ID siswa")
+	})
+
+	t.Run("failed update - tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-9").Return(nil, pgx.ErrNoRows).Once()
+
+		err := newTestService(mockRepo).Update(context.Background(), &pengumuman.PengumumanCore{Version: 1}, "p-9", guru)
+
+		assert.ErrorIs(t, err, errPengumumanNotFound)
+	})
+}
+
+func TestDeletePengumuman(t *testing.T) {
+	existing := &pengumuman.PengumumanCore{ID: "p-1", Dibuat_Oleh: "guru-user-1", Version: 3}
+
+	t.Run("success delete - pembuat", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-1").Return(existing, nil).Once()
+		mockRepo.On("DeleteById", "p-1", 3).Return(nil).Once()
+
+		err := newTestService(mockRepo).DeleteById(context.Background(), "p-1", 3, helper.MetaToken{ID: "guru-user-1", Role: "guru"})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed delete - versi berbeda", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-1").Return(existing, nil).Once()
+		mockRepo.On("DeleteById", "p-1", 2).Return(helper.ErrVersionConflict).Once()
+
+		err := newTestService(mockRepo).DeleteById(context.Background(), "p-1", 2, helper.MetaToken{ID: "admin-1", Role: "admin"})
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+	})
+
+	t.Run("failed delete - guru lain", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectById", "p-1").Return(existing, nil).Once()
+
+		err := newTestService(mockRepo).DeleteById(context.Background(), "p-1", 3, helper.MetaToken{ID: "guru-user-2", Role: "guru"})
+
+		assert.ErrorIs(t, err, errBukanPembuat)
+		mockRepo.AssertNotCalled(t, "DeleteById", mock.Anything, mock.Anything)
+	})
+}
+
+func TestFeedPengumuman(t *testing.T) {
+	siswa := helper.MetaToken{ID: "user-1", Role: "user"}
+	penerima := &pengumuman.Penerima{User_ID: "user-1", Role: "user", Siswa_ID: "s-1", Kelas_ID: []string{"kelas-1"}}
+
+	t.Run("success feed", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		feed := []pengumuman.PengumumanCore{{ID: "p-1", Disematkan: true}, {ID: "p-2"}}
+		mockRepo.On("SelectPenerima", "user-1", "user").Return(penerima, nil).Once()
+		mockRepo.On("SelectFeed", *penerima).Return(feed, nil).Once()
+
+		result, err := newTestService(mockRepo).Feed(context.Background(), siswa)
+
+		assert.NoError(t, err)
+		assert.Equal(t, feed, result)
+	})
+
+	t.Run("success tandai dibaca", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectPenerima", "user-1", "user").Return(penerima, nil).Once()
+		mockRepo.On("SelectFeedById", "p-1", *penerima).Return(&pengumuman.PengumumanCore{ID: "p-1"}, nil).Once()
+		mockRepo.On("TandaiDibaca", "p-1", "user-1").Return(nil).Once()
+
+		err := newTestService(mockRepo).TandaiDibaca(context.Background(), "p-1", siswa)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed tandai dibaca - bukan sasaran", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectPenerima", "user-1", "user").Return(penerima, nil).Once()
+		mockRepo.On("SelectFeedById", "p-2", *penerima).Return(nil, pgx.ErrNoRows).Once()
+
+		err := newTestService(mockRepo).TandaiDibaca(context.Background(), "p-2", siswa)
+
+		assert.ErrorIs(t, err, errPengumumanNotFound)
+		mockRepo.AssertNotCalled(t, "TandaiDibaca", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed feed - error penerima", func(t *testing.T) {
+		mockRepo := new(mockDataPengumuman)
+		mockRepo.On("SelectPenerima", "user-1", "user").Return(nil, errors.New("db down")).Once()
+
+		result, err := newTestService(mockRepo).Feed(context.Background(), siswa)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
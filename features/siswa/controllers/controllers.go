@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"go_rest_native_sekolah/features/siswa"
 	"go_rest_native_sekolah/helper"
-	"log"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -69,6 +69,18 @@ func (sc *SiswaController) InsertSiswa(w http.ResponseWriter, r *http.Request) e
 			Nama_Kelas: r.FormValue("nama_kelas"),
 			Email:      r.FormValue("email"),
 			Alamat:     r.FormValue("alamat"),
+
+			NIS:           r.FormValue("nis"),
+			NISN:          r.FormValue("nisn"),
+			Tempat_Lahir:  r.FormValue("tempat_lahir"),
+			Tanggal_Lahir: r.FormValue("tanggal_lahir"),
+			Jenis_Kelamin: r.FormValue("jenis_kelamin"),
+			Agama:         r.FormValue("agama"),
+			Telepon:       r.FormValue("telepon"),
+			Nama_Wali:     r.FormValue("nama_wali"),
+			Telepon_Wali:  r.FormValue("telepon_wali"),
+			Tanggal_Masuk: r.FormValue("tanggal_masuk"),
+			Foto:          r.FormValue("foto"),
 		}
 	}
 
@@ -76,8 +88,13 @@ func (sc *SiswaController) InsertSiswa(w http.ResponseWriter, r *http.Request) e
 	siswaCore = FormatSiswaRequestToCore(siswaReq)
 
 	// Insert ke service
-	err := sc.SiswaService.InsertSiswa(&siswaCore)
+	err := sc.SiswaService.InsertSiswa(r.Context(), &siswaCore)
 	if err != nil {
+		// Data yang tidak valid atau NIS/NISN yang sudah dipakai dijawab 400 Bad Request.
+		if strings.Contains(strings.ToLower(err.Error()), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil
+		}
 		return err
 	}
 
@@ -95,6 +112,8 @@ func (sc *SiswaController) InsertSiswa(w http.ResponseWriter, r *http.Request) e
 }
 
 // Siswa digunakan untuk menghandle HTTP request GET untuk mengambil semua data siswa.
+// Daftar bisa difilter dengan query parameter nama, kelas_id, nis, nisn, jenis_kelamin, agama,
+// tempat_lahir, tahun_lahir, dan tahun_masuk.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
 func (sc *SiswaController) Siswa(w http.ResponseWriter, r *http.Request) error {
 	// Cek apakah controller tidak nil dan service siswa tidak nil.
@@ -102,9 +121,21 @@ func (sc *SiswaController) Siswa(w http.ResponseWriter, r *http.Request) error {
 		return errors.New("Nil controller")
 	}
 
-	// Panggil service untuk mengambil semua data siswa.
-	siswa, err := sc.SiswaService.SelectAllSiswa()
+	// Baca filter dari query parameter.
+	filter, err := filterDariQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	// Panggil service untuk mengambil data siswa sesuai filter.
+	siswa, err := sc.SiswaService.SelectAllSiswa(r.Context(), filter)
 	if err != nil {
+		// Filter yang tidak valid dijawab 400 Bad Request.
+		if strings.Contains(strings.ToLower(err.Error()), "validation") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil
+		}
 		// Jika terjadi error saat mengambil data siswa, maka kembalikan error.
 		return err
 	}
@@ -137,7 +168,7 @@ func (sc *SiswaController) GetSiswaById(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "parameter 'id' wajib diisi", http.StatusBadRequest)
 		return errors.New("missing 'id' query parameter")
 	}
-	siswaData, err := sc.SiswaService.SelectById(id)
+	siswaData, err := sc.SiswaService.SelectById(r.Context(), id)
 	// Panggil service untuk mengambil data siswa berdasarkan ID.
 	if err != nil {
 		// Jika terjadi error saat mengambil data siswa, maka kembalikan error.
@@ -150,6 +181,8 @@ func (sc *SiswaController) GetSiswaById(w http.ResponseWriter, r *http.Request)
 	// Response ini berisi data siswa yang telah di-format dan di-encode menjadi JSON.
 	// Jika terjadi error saat encoding maka kembalikan error dengan status 500.
 	w.Header().Set("Content-Type", "application/json")
+	// ETag dipakai client sebagai If-Match saat update atau delete.
+	helper.SetETag(w, siswaData.Version)
 	err = json.NewEncoder(w).Encode(respon)
 	if err != nil {
 		// Jika terjadi error saat encoding response maka kembalikan error dengan pesan "Error encoding response".
@@ -169,28 +202,41 @@ func (sc *SiswaController) UpdateSiswa(w http.ResponseWriter, r *http.Request) e
 		return errors.New("missing 'id' query parameter")
 	}
 
+	// Ambil versi data dari header If-Match agar update tidak menimpa perubahan orang lain.
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
+
 	var siswaReq SiswaFormatter
 	// Dekode request body menjadi objek SiswaFormatter.
 	// Jika terjadi error maka kembalikan error dengan status 400 Bad Request.
-	err := json.NewDecoder(r.Body).Decode(&siswaReq)
+	err = json.NewDecoder(r.Body).Decode(&siswaReq)
 	if err != nil {
-		log.Printf("Error decoding request body: %v", err)
+		helper.LoggerFromContext(r.Context()).Error("Error decoding request body", "error", err)
 		http.Error(w, "gagal memproses data input", http.StatusBadRequest)
 		return err
 	}
 
 	siswaUpdate := FormatSiswaRequestToCore(siswaReq)
+	siswaUpdate.Version = version
 	// Format data SiswaFormatter menjadi objek SiswaCore.
 	// Jika terjadi error maka kembalikan error.
-	err = sc.SiswaService.Update(&siswaUpdate, id)
+	err = sc.SiswaService.Update(r.Context(), &siswaUpdate, id)
 	if err != nil {
+		// Jika data sudah diubah request lain, kembalikan status 412 Precondition Failed.
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		}
 		// Jika terjadi error saat memperbarui data siswa, maka kembalikan error.
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 
 	// Ambil data siswa yang telah diupdate dari database
-	siswaData, err := sc.SiswaService.SelectById(id)
+	siswaData, err := sc.SiswaService.SelectById(r.Context(), id)
 	if err != nil {
 		// Jika terjadi error saat mengambil data siswa maka kembalikan error dengan status 500
 		http.Error(w, "Gagal mengambil data setelah update", http.StatusInternalServerError)
@@ -203,6 +249,7 @@ func (sc *SiswaController) UpdateSiswa(w http.ResponseWriter, r *http.Request) e
 	// Jika terjadi error saat encoding maka kembalikan error dengan status 500
 	respon := helper.APIResponse(http.StatusOK, "Berhasil mengupdate data siswa", formatKelas)
 	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, siswaData.Version)
 	err = json.NewEncoder(w).Encode(respon)
 	if err != nil {
 		return fmt.Errorf("error encoding JSON: %v", err)
@@ -221,10 +268,21 @@ func (sc *SiswaController) DeleteSiswa(w http.ResponseWriter, r *http.Request) e
 		http.Error(w, "parameter 'id' wajib diisi", http.StatusBadRequest)
 		return errors.New("missing 'id' query parameter")
 	}
-	err := sc.SiswaService.DeleteById(id)
+	// Ambil versi data dari header If-Match agar tidak menghapus data yang sudah diubah orang lain.
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
+	err = sc.SiswaService.DeleteById(r.Context(), id, version)
 	// Panggil service untuk menghapus data siswa berdasarkan ID.
 	// Jika terjadi error saat menghapus data siswa, maka kembalikan error.
 	if err != nil {
+		// Jika data sudah diubah request lain, kembalikan status 412 Precondition Failed.
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		}
 		return err
 	}
 	respon := helper.APIResponse(http.StatusOK, "Berhasil menghapus data siswa", nil)
@@ -238,3 +296,64 @@ func (sc *SiswaController) DeleteSiswa(w http.ResponseWriter, r *http.Request) e
 	}
 	return nil
 }
+
+// BulkSiswa digunakan untuk menghandle HTTP request POST yang menjalankan banyak operasi
+// create, update, dan delete siswa sekaligus, misalnya menghapus siswa yang sudah lulus.
+// Response berisi hasil per operasi. Fungsi ini akan mengembalikan error jika terjadi kesalahan.
+func (sc *SiswaController) BulkSiswa(w http.ResponseWriter, r *http.Request) error {
+	// Cek apakah controller tidak nil dan service siswa tidak nil.
+	if sc == nil || sc.SiswaService == nil {
+		return errors.New("Nil controller")
+	}
+
+	// Baca dan validasi daftar operasi dari body JSON.
+	req, err := helper.DecodeBulkRequest[SiswaFormatter](r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	// Ubah data setiap operasi ke Core lalu jalankan di service.
+	result, err := sc.SiswaService.Bulk(r.Context(), req.Mode, helper.MapBulkOperations(req.Operations, FormatSiswaRequestToCore))
+	if err != nil {
+		return err
+	}
+
+	// Kirim hasil per operasi dengan status sesuai hasil bulk.
+	helper.JSONResponse(w, result.StatusCode(), helper.APIResponse(result.StatusCode(), result.Message(), result))
+	return nil
+}
+
+// filterDariQuery membaca filter daftar siswa dari query parameter request.
+// Fungsi ini mengembalikan error jika tahun_lahir atau tahun_masuk bukan angka.
+func filterDariQuery(r *http.Request) (siswa.FilterSiswa, error) {
+	q := r.URL.Query()
+	filter := siswa.FilterSiswa{
+		Nama:          q.Get("nama"),
+		Kelas_ID:      q.Get("kelas_id"),
+		NIS:           q.Get("nis"),
+		NISN:          q.Get("nisn"),
+		Jenis_Kelamin: q.Get("jenis_kelamin"),
+		Agama:         q.Get("agama"),
+		Tempat_Lahir:  q.Get("tempat_lahir"),
+	}
+	tahun := []struct {
+		nama  string
+		nilai *int
+	}{
+		{"tahun_lahir", &filter.Tahun_Lahir},
+		{"tahun_masuk", &filter.Tahun_Masuk},
+	}
+	for _, t := range tahun {
+		v := q.Get(t.nama)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return filter, fmt.Errorf("validation error: %s harus berupa tahun", t.nama)
+		}
+		*t.nilai = n
+	}
+	return filter, nil
+}
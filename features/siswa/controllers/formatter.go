@@ -17,6 +17,30 @@ type SiswaFormatter struct {
 	Email string `json:"email"`
 	// Alamat adalah field yang berisi alamat siswa
 	Alamat string `json:"alamat"`
+	// NIS adalah field yang berisi nomor induk siswa dari sekolah
+	NIS string `json:"nis"`
+	// NISN adalah field yang berisi nomor induk siswa nasional
+	NISN string `json:"nisn"`
+	// Tempat_Lahir adalah field yang berisi tempat lahir siswa
+	Tempat_Lahir string `json:"tempat_lahir"`
+	// Tanggal_Lahir adalah field yang berisi tanggal lahir siswa (YYYY-MM-DD)
+	Tanggal_Lahir string `json:"tanggal_lahir"`
+	// Jenis_Kelamin adalah field yang berisi jenis kelamin siswa (L atau P)
+	Jenis_Kelamin string `json:"jenis_kelamin"`
+	// Agama adalah field yang berisi agama siswa
+	Agama string `json:"agama"`
+	// Telepon adalah field yang berisi nomor telepon siswa
+	Telepon string `json:"telepon"`
+	// Nama_Wali adalah field yang berisi nama orang tua atau wali siswa
+	Nama_Wali string `json:"nama_wali"`
+	// Telepon_Wali adalah field yang berisi nomor telepon orang tua atau wali siswa
+	Telepon_Wali string `json:"telepon_wali"`
+	// Tanggal_Masuk adalah field yang berisi tanggal siswa diterima di sekolah (YYYY-MM-DD)
+	Tanggal_Masuk string `json:"tanggal_masuk"`
+	// Foto adalah field yang berisi URL foto siswa
+	Foto string `json:"foto"`
+	// Version adalah field yang berisi versi data siswa, sama dengan ETag
+	Version int `json:"version"`
 }
 
 // FormatterKelasList digunakan untuk mengubah slice SiswaCore menjadi slice SiswaFormatter.
@@ -41,6 +65,20 @@ func FormatterKelasList(cores []siswa.SiswaCore) []SiswaFormatter {
 			Email: core.Email,
 			// Alamat adalah field yang berisi alamat siswa
 			Alamat: core.Alamat,
+			// Data induk siswa
+			NIS:           core.NIS,
+			NISN:          core.NISN,
+			Tempat_Lahir:  core.Tempat_Lahir,
+			Tanggal_Lahir: core.Tanggal_Lahir,
+			Jenis_Kelamin: core.Jenis_Kelamin,
+			Agama:         core.Agama,
+			Telepon:       core.Telepon,
+			Nama_Wali:     core.Nama_Wali,
+			Telepon_Wali:  core.Telepon_Wali,
+			Tanggal_Masuk: core.Tanggal_Masuk,
+			Foto:          core.Foto,
+			// Version adalah field yang berisi versi data siswa, sama dengan ETag
+			Version: core.Version,
 		})
 	}
 	// Mengembalikan slice SiswaFormatter yang telah di format
@@ -60,5 +98,17 @@ func FormatSiswaRequestToCore(req SiswaFormatter) siswa.SiswaCore {
 		Nama_Kelas: req.Nama_Kelas, // Mengisi field Nama_Kelas dengan nama kelas dari objek SiswaFormatter
 		Email:      req.Email,      // Mengisi field Email dengan email dari objek SiswaFormatter
 		Alamat:     req.Alamat,     // Mengisi field Alamat dengan alamat dari objek SiswaFormatter
+		// Data induk siswa
+		NIS:           req.NIS,
+		NISN:          req.NISN,
+		Tempat_Lahir:  req.Tempat_Lahir,
+		Tanggal_Lahir: req.Tanggal_Lahir,
+		Jenis_Kelamin: req.Jenis_Kelamin,
+		Agama:         req.Agama,
+		Telepon:       req.Telepon,
+		Nama_Wali:     req.Nama_Wali,
+		Telepon_Wali:  req.Telepon_Wali,
+		Tanggal_Masuk: req.Tanggal_Masuk,
+		Foto:          req.Foto,
 	}
 }
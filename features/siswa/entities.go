@@ -1,40 +1,87 @@
 package siswa
 
-import "time"
+import (
+	"context"
+	"go_rest_native_sekolah/helper"
+	"time"
+)
+
+// Nilai jenis kelamin siswa.
+const (
+	JenisKelaminLaki      = "L"
+	JenisKelaminPerempuan = "P"
+)
+
+// DaftarAgama adalah nilai agama yang diterima untuk siswa.
+var DaftarAgama = []string{"Islam", "Kristen", "Katolik", "Hindu", "Buddha", "Konghucu"}
 
 type (
 	// SiswaCore adalah struktur data yang merepresentasikan informasi inti dari seorang siswa.
-	// Struktur ini berisi ID siswa, nama, ID kelas, nama kelas, email, alamat, dan informasi waktu pembaruan serta penghapusan.
+	// Struktur ini berisi ID siswa, nama, ID kelas, nama kelas, email, alamat, data induk siswa
+	// (NIS, NISN, kelahiran, jenis kelamin, agama, kontak, wali, tanggal masuk, foto),
+	// dan informasi waktu pembaruan serta penghapusan.
 	SiswaCore struct {
-		ID         string     `json:"id"`         // ID adalah identifikasi unik untuk setiap siswa.
-		Nama       string     `json:"nama"`       // Nama adalah nama lengkap siswa.
-		Kelas_ID   string     `json:"kelas_id"`   // Kelas_ID adalah ID dari kelas tempat siswa berada.
-		Nama_Kelas string     `json:"nama_kelas"` // Nama_Kelas adalah nama kelas tempat siswa berada.
-		Email      string     `json:"email"`      // Email adalah alamat email siswa.
-		Alamat     string     `json:"alamat"`     // Alamat adalah alamat tempat tinggal siswa.
-		Update_At  time.Time  `json:"update_at"`  // Update_At adalah waktu terakhir data siswa diperbarui.
-		Delete_At  *time.Time `json:"delete_at"`  // Delete_At adalah waktu di mana data siswa dihapus, jika ada.
+		ID            string     `json:"id"`            // ID adalah identifikasi unik untuk setiap siswa.
+		Nama          string     `json:"nama"`          // Nama adalah nama lengkap siswa.
+		Kelas_ID      string     `json:"kelas_id"`      // Kelas_ID adalah ID dari kelas tempat siswa berada.
+		Nama_Kelas    string     `json:"nama_kelas"`    // Nama_Kelas adalah nama kelas tempat siswa berada.
+		Email         string     `json:"email"`         // Email adalah alamat email siswa.
+		Alamat        string     `json:"alamat"`        // Alamat adalah alamat tempat tinggal siswa.
+		NIS           string     `json:"nis"`           // NIS adalah nomor induk siswa dari sekolah, unik di antara siswa aktif.
+		NISN          string     `json:"nisn"`          // NISN adalah nomor induk siswa nasional (10 digit), unik di antara siswa aktif.
+		Tempat_Lahir  string     `json:"tempat_lahir"`  // Tempat_Lahir adalah kota atau kabupaten tempat siswa lahir.
+		Tanggal_Lahir string     `json:"tanggal_lahir"` // Tanggal_Lahir adalah tanggal lahir siswa dengan format YYYY-MM-DD.
+		Jenis_Kelamin string     `json:"jenis_kelamin"` // Jenis_Kelamin adalah JenisKelaminLaki atau JenisKelaminPerempuan.
+		Agama         string     `json:"agama"`         // Agama adalah salah satu dari DaftarAgama.
+		Telepon       string     `json:"telepon"`       // Telepon adalah nomor telepon siswa.
+		Nama_Wali     string     `json:"nama_wali"`     // Nama_Wali adalah nama orang tua atau wali siswa.
+		Telepon_Wali  string     `json:"telepon_wali"`  // Telepon_Wali adalah nomor telepon orang tua atau wali siswa.
+		Tanggal_Masuk string     `json:"tanggal_masuk"` // Tanggal_Masuk adalah tanggal siswa diterima di sekolah dengan format YYYY-MM-DD.
+		Foto          string     `json:"foto"`          // Foto adalah URL foto siswa.
+		Update_At     time.Time  `json:"update_at"`     // Update_At adalah waktu terakhir data siswa diperbarui.
+		Delete_At     *time.Time `json:"delete_at"`     // Delete_At adalah waktu di mana data siswa dihapus, jika ada.
+		Version       int        `json:"version"`       // Version adalah versi data untuk optimistic concurrency, dikirim sebagai ETag.
+	}
+
+	// FilterSiswa berisi filter opsional untuk daftar siswa. Field kosong berarti tidak difilter.
+	FilterSiswa struct {
+		Nama          string // Nama mencari siswa yang namanya mengandung teks ini (tidak membedakan huruf besar/kecil).
+		Kelas_ID      string // Kelas_ID hanya mengambil siswa di kelas ini.
+		NIS           string // NIS hanya mengambil siswa dengan NIS ini.
+		NISN          string // NISN hanya mengambil siswa dengan NISN ini.
+		Jenis_Kelamin string // Jenis_Kelamin hanya mengambil siswa dengan jenis kelamin ini.
+		Agama         string // Agama hanya mengambil siswa dengan agama ini.
+		Tempat_Lahir  string // Tempat_Lahir hanya mengambil siswa yang lahir di tempat ini.
+		Tahun_Lahir   int    // Tahun_Lahir hanya mengambil siswa yang lahir pada tahun ini.
+		Tahun_Masuk   int    // Tahun_Masuk hanya mengambil siswa yang masuk pada tahun ini.
 	}
 
 	// DataSiswaInterface adalah antarmuka yang mendefinisikan metode untuk operasi data siswa.
 	// Antarmuka ini mencakup metode untuk mengambil semua data siswa, memasukkan data siswa,
 	// memperbarui data siswa, mengambil data siswa berdasarkan ID, dan menghapus data siswa berdasarkan ID.
+	// Setiap metode menerima context dari request agar log memakai request ID yang sama.
 	DataSiswaInterface interface {
-		SelectAllSiswa() ([]SiswaCore, error)      // Mengambil semua data siswa dari database.
-		InsertSiswa(insert *SiswaCore) error       // Memasukkan data siswa baru ke dalam database.
-		Update(insert *SiswaCore, id string) error // Memperbarui data siswa berdasarkan ID.
-		SelectById(id string) (*SiswaCore, error)  // Mengambil data siswa berdasarkan ID.
-		DeleteById(id string) error                // Menghapus data siswa berdasarkan ID.
+		SelectAllSiswa(ctx context.Context, filter FilterSiswa) ([]SiswaCore, error) // Mengambil data siswa yang sesuai filter dari database.
+		InsertSiswa(ctx context.Context, insert *SiswaCore) error                    // Memasukkan data siswa baru ke dalam database.
+		Update(ctx context.Context, insert *SiswaCore, id string) error              // Memperbarui data siswa berdasarkan ID.
+		SelectById(ctx context.Context, id string) (*SiswaCore, error)               // Mengambil data siswa berdasarkan ID.
+		DeleteById(ctx context.Context, id string, version int) error                // Menghapus data siswa berdasarkan ID jika versinya masih sama.
+		// LockKelas mengunci baris kelas aktif sampai transaksi selesai agar kelas tujuan
+		// tidak dihapus saat siswa dipindahkan. Mengembalikan pgx.ErrNoRows jika kelas tidak ada.
+		LockKelas(ctx context.Context, kelasID string) error
 	}
 
 	// ServiceSiswaInterface adalah antarmuka yang mendefinisikan layanan untuk operasi siswa.
 	// Antarmuka ini serupa dengan DataSiswaInterface, namun digunakan di lapisan layanan untuk
 	// mengabstraksi operasi-operasi yang dilakukan pada data siswa.
 	ServiceSiswaInterface interface {
-		SelectAllSiswa() ([]SiswaCore, error)      // Mengambil semua data siswa dari database.
-		InsertSiswa(insert *SiswaCore) error       // Memasukkan data siswa baru ke dalam database.
-		Update(insert *SiswaCore, id string) error // Memperbarui data siswa berdasarkan ID.
-		SelectById(id string) (*SiswaCore, error)  // Mengambil data siswa berdasarkan ID.
-		DeleteById(id string) error                // Menghapus data siswa berdasarkan ID.
+		SelectAllSiswa(ctx context.Context, filter FilterSiswa) ([]SiswaCore, error) // Mengambil data siswa yang sesuai filter dari database.
+		InsertSiswa(ctx context.Context, insert *SiswaCore) error                    // Memasukkan data siswa baru ke dalam database.
+		Update(ctx context.Context, insert *SiswaCore, id string) error              // Memperbarui data siswa berdasarkan ID.
+		SelectById(ctx context.Context, id string) (*SiswaCore, error)               // Mengambil data siswa berdasarkan ID.
+		DeleteById(ctx context.Context, id string, version int) error                // Menghapus data siswa berdasarkan ID jika versinya masih sama.
+		// Bulk menjalankan banyak operasi create, update, dan delete siswa sekaligus sesuai mode
+		// dan mengembalikan hasil per operasi.
+		Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[SiswaCore]) (*helper.BulkResult, error)
 	}
 )
@@ -8,14 +8,26 @@ import (
 // Siswa adalah struktur data yang merepresentasikan informasi siswa.
 // Struktur ini digunakan untuk menyimpan informasi siswa yang ada dalam database.
 type Siswa struct {
-	ID         string `json:"id"`         // ID adalah identifikasi unik untuk setiap siswa.
-	Kelas_ID   string `json:"kelas_id"`   // Kelas_ID adalah ID dari kelas tempat siswa berada.
-	Nama       string `json:"nama"`       // Nama adalah nama lengkap siswa.
-	Nama_Kelas string `json:"nama_kelas"` // Nama_Kelas adalah nama kelas tempat siswa berada.
-	Email      string `json:"email"`      // Email adalah alamat email siswa.
-	Alamat     string `json:"alamat"`     // Alamat adalah alamat tempat tinggal siswa.
-	Update_At  string `json:"update_at"`  // Update_At adalah waktu terakhir data siswa diperbarui.
-	Delete_At  string `json:"delete_at"`  // Delete_At adalah waktu ketika data siswa dihapus, jika ada.
+	ID            string `json:"id"`            // ID adalah identifikasi unik untuk setiap siswa.
+	Kelas_ID      string `json:"kelas_id"`      // Kelas_ID adalah ID dari kelas tempat siswa berada.
+	Nama          string `json:"nama"`          // Nama adalah nama lengkap siswa.
+	Nama_Kelas    string `json:"nama_kelas"`    // Nama_Kelas adalah nama kelas tempat siswa berada.
+	Email         string `json:"email"`         // Email adalah alamat email siswa.
+	Alamat        string `json:"alamat"`        // Alamat adalah alamat tempat tinggal siswa.
+	NIS           string `json:"nis"`           // NIS adalah nomor induk siswa dari sekolah.
+	NISN          string `json:"nisn"`          // NISN adalah nomor induk siswa nasional.
+	Tempat_Lahir  string `json:"tempat_lahir"`  // Tempat_Lahir adalah tempat lahir siswa.
+	Tanggal_Lahir string `json:"tanggal_lahir"` // Tanggal_Lahir adalah tanggal lahir siswa (YYYY-MM-DD).
+	Jenis_Kelamin string `json:"jenis_kelamin"` // Jenis_Kelamin adalah jenis kelamin siswa (L atau P).
+	Agama         string `json:"agama"`         // Agama adalah agama siswa.
+	Telepon       string `json:"telepon"`       // Telepon adalah nomor telepon siswa.
+	Nama_Wali     string `json:"nama_wali"`     // Nama_Wali adalah nama orang tua atau wali siswa.
+	Telepon_Wali  string `json:"telepon_wali"`  // Telepon_Wali adalah nomor telepon orang tua atau wali siswa.
+	Tanggal_Masuk string `json:"tanggal_masuk"` // Tanggal_Masuk adalah tanggal siswa diterima di sekolah (YYYY-MM-DD).
+	Foto          string `json:"foto"`          // Foto adalah URL foto siswa.
+	Update_At     string `json:"update_at"`     // Update_At adalah waktu terakhir data siswa diperbarui.
+	Delete_At     string `json:"delete_at"`     // Delete_At adalah waktu ketika data siswa dihapus, jika ada.
+	Version       int    `json:"version"`       // Version adalah versi data yang bertambah setiap kali siswa diubah atau dihapus.
 }
 
 // TableName mengembalikan nama tabel yang terkait dengan struktur data Siswa.
@@ -43,6 +55,18 @@ func FormatterRequest(req siswa.SiswaCore) Siswa {
 	siswa.Email = req.Email
 	// Alamat adalah alamat tempat tinggal siswa.
 	siswa.Alamat = req.Alamat
+	// Data induk siswa: nomor induk, kelahiran, agama, kontak, wali, tanggal masuk, dan foto.
+	siswa.NIS = req.NIS
+	siswa.NISN = req.NISN
+	siswa.Tempat_Lahir = req.Tempat_Lahir
+	siswa.Tanggal_Lahir = req.Tanggal_Lahir
+	siswa.Jenis_Kelamin = req.Jenis_Kelamin
+	siswa.Agama = req.Agama
+	siswa.Telepon = req.Telepon
+	siswa.Nama_Wali = req.Nama_Wali
+	siswa.Telepon_Wali = req.Telepon_Wali
+	siswa.Tanggal_Masuk = req.Tanggal_Masuk
+	siswa.Foto = req.Foto
 	// Update_At adalah waktu terakhir data siswa diperbarui.
 	siswa.Update_At = time.Now().Format("2006-01-02 15:04:05")
 	// Mengembalikan objek Siswa yang telah di format.
@@ -55,11 +79,23 @@ func FormatterRequest(req siswa.SiswaCore) Siswa {
 func FormatterResponse(res Siswa) siswa.SiswaCore {
 	// Mengembalikan objek SiswaCore yang berisi data-data siswa dari objek Siswa
 	return siswa.SiswaCore{
-		ID:         res.ID,         // Mengisi field ID dengan ID dari objek Siswa
-		Nama:       res.Nama,       // Mengisi field Nama dengan nama dari objek Siswa
-		Kelas_ID:   res.Kelas_ID,   // Mengisi field Kelas_ID dengan ID kelas dari objek Siswa
-		Nama_Kelas: res.Nama_Kelas, // Mengisi field Nama_Kelas dengan nama kelas dari objek Siswa
-		Email:      res.Email,      // Mengisi field Email dengan email dari objek Siswa
-		Alamat:     res.Alamat,     // Mengisi field Alamat dengan alamat dari objek Siswa
+		ID:            res.ID,         // Mengisi field ID dengan ID dari objek Siswa
+		Nama:          res.Nama,       // Mengisi field Nama dengan nama dari objek Siswa
+		Kelas_ID:      res.Kelas_ID,   // Mengisi field Kelas_ID dengan ID kelas dari objek Siswa
+		Nama_Kelas:    res.Nama_Kelas, // Mengisi field Nama_Kelas dengan nama kelas dari objek Siswa
+		Email:         res.Email,      // Mengisi field Email dengan email dari objek Siswa
+		Alamat:        res.Alamat,     // Mengisi field Alamat dengan alamat dari objek Siswa
+		NIS:           res.NIS,
+		NISN:          res.NISN,
+		Tempat_Lahir:  res.Tempat_Lahir,
+		Tanggal_Lahir: res.Tanggal_Lahir,
+		Jenis_Kelamin: res.Jenis_Kelamin,
+		Agama:         res.Agama,
+		Telepon:       res.Telepon,
+		Nama_Wali:     res.Nama_Wali,
+		Telepon_Wali:  res.Telepon_Wali,
+		Tanggal_Masuk: res.Tanggal_Masuk,
+		Foto:          res.Foto,
+		Version:       res.Version, // Mengisi field Version dengan versi data dari objek Siswa
 	}
 }
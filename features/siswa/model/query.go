@@ -5,23 +5,24 @@ import (
 	"errors"
 	"fmt"
 	"go_rest_native_sekolah/features/siswa"
-	"log"
+	"go_rest_native_sekolah/helper"
 	"strings"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 )
 
 // siswaQuery adalah struct yang digunakan untuk menghandle query ke database yang berhubungan dengan tabel siswa.
 // Struct ini memiliki satu field yaitu db yang berisi koneksi database.
 type siswaQuery struct {
-	db *pgxpool.Pool // Koneksi database yang digunakan untuk menghandle query ke database.
+	db helper.DBTX // Koneksi database yang digunakan untuk menghandle query ke database.
 }
 
 // NewSiswaData membuat objek siswaQuery yang berisi koneksi database.
 // Fungsi ini digunakan untuk menginisialisasi objek siswaQuery yang berisi koneksi database.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
 // Jika parameter db nil maka akan terjadi panic.
-func NewSiswaData(db *pgxpool.Pool) siswa.DataSiswaInterface {
+func NewSiswaData(db helper.DBTX) siswa.DataSiswaInterface {
 	// Jika db nil maka akan terjadi panic
 	if db == nil {
 		panic("Nil database")
@@ -34,7 +35,7 @@ func NewSiswaData(db *pgxpool.Pool) siswa.DataSiswaInterface {
 // InsertSiswa adalah fungsi yang digunakan untuk menginsert data siswa ke dalam database.
 // Fungsi ini menerima parameter objek siswa.SiswaCore yang berisi data-data siswa yang akan diinsert.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *siswaQuery) InsertSiswa(insert *siswa.SiswaCore) error {
+func (s *siswaQuery) InsertSiswa(ctx context.Context, insert *siswa.SiswaCore) error {
 	if s.db == nil {
 		// Jika parameter db nil maka akan terjadi panic.
 		return errors.New("Nil database")
@@ -63,11 +64,11 @@ func (s *siswaQuery) InsertSiswa(insert *siswa.SiswaCore) error {
 		insert.Nama_Kelas = strings.TrimSpace(insert.Nama_Kelas)
 
 		var kelasID string
-		err := s.db.QueryRow(context.Background(),
+		err := s.db.QueryRow(ctx,
 			"SELECT id FROM kelas WHERE TRIM(kelas) ILIKE TRIM($1)", insert.Nama_Kelas).Scan(&kelasID)
 		if err != nil {
 			// Jika tidak ada kelas dengan nama yang sesuai maka akan terjadi error.
-			log.Printf("InsertKelas: nama kelas '%s' tidak ditemukan", insert.Nama_Kelas)
+			helper.LoggerFromContext(ctx).Warn("InsertSiswa: nama kelas tidak ditemukan", "nama_kelas", insert.Nama_Kelas)
 			return fmt.Errorf("kelas dengan nama '%s' tidak ditemukan", insert.Nama_Kelas)
 		}
 		// Masukkan ID kelas ke dalam objek siswa.
@@ -76,11 +77,11 @@ func (s *siswaQuery) InsertSiswa(insert *siswa.SiswaCore) error {
 	case insert.Kelas_ID != "" && insert.Nama_Kelas == "":
 		// Jika hanya Kelas_ID diisi → cari nama-nya
 		var namaKelas string
-		err := s.db.QueryRow(context.Background(),
+		err := s.db.QueryRow(ctx,
 			"SELECT kelas FROM kelas WHERE id = $1", insert.Kelas_ID).Scan(&namaKelas)
 		if err != nil {
 			// Jika tidak ada kelas dengan ID yang sesuai maka akan terjadi error.
-			log.Printf("InsertKelas: ID kelas '%s' tidak ditemukan", insert.Kelas_ID)
+			helper.LoggerFromContext(ctx).Warn("InsertSiswa: ID kelas tidak ditemukan", "kelas_id", insert.Kelas_ID)
 			return fmt.Errorf("kelas dengan ID '%s' tidak ditemukan", insert.Kelas_ID)
 		}
 		// Masukkan nama kelas ke dalam objek siswa.
@@ -92,22 +93,27 @@ func (s *siswaQuery) InsertSiswa(insert *siswa.SiswaCore) error {
 		insert.Nama_Kelas = strings.TrimSpace(insert.Nama_Kelas)
 
 		var existingName string
-		err := s.db.QueryRow(context.Background(),
+		err := s.db.QueryRow(ctx,
 			"SELECT kelas FROM kelas WHERE id = $1", insert.Kelas_ID).Scan(&existingName)
 		if err != nil {
 			// Jika tidak ada kelas dengan ID yang sesuai maka akan terjadi error.
-			log.Printf("InsertKelas: ID kelas '%s' tidak ditemukan", insert.Kelas_ID)
+			helper.LoggerFromContext(ctx).Warn("InsertSiswa: ID kelas tidak ditemukan", "kelas_id", insert.Kelas_ID)
 			return fmt.Errorf("kelas dengan ID '%s' tidak ditemukan", insert.Kelas_ID)
 		}
 		// Validasi apakah nama kelas yang diinput sama dengan nama kelas yang ada di database.
 		if strings.TrimSpace(strings.ToLower(existingName)) != strings.ToLower(insert.Nama_Kelas) {
 			// Jika tidak sama maka akan terjadi error.
-			log.Printf("InsertKelas: Nama kelas tidak cocok dengan ID kelas. Dapat: '%s', seharusnya: '%s'",
-				insert.Nama_Kelas, existingName)
+			helper.LoggerFromContext(ctx).Warn("InsertSiswa: Nama kelas tidak cocok dengan ID kelas",
+				"kelas_id", insert.Kelas_ID, "nama_kelas", insert.Nama_Kelas, "nama_kelas_seharusnya", existingName)
 			return fmt.Errorf("nama kelas '%s' tidak cocok dengan ID kelas '%s'", insert.Nama_Kelas, insert.Kelas_ID)
 		}
 	}
 
+	// --- Pastikan NIS dan NISN belum dipakai siswa aktif lain ---
+	if err := s.cekNomorInduk(ctx, insert, insert.ID); err != nil {
+		return err
+	}
+
 	// --- Siapkan Kelas_ID untuk query INSERT (boleh null) ---
 	// Jika Kelas_ID kosong maka akan diisi dengan nilai null.
 	var idKelasParam interface{}
@@ -118,47 +124,136 @@ func (s *siswaQuery) InsertSiswa(insert *siswa.SiswaCore) error {
 	}
 
 	// --- Eksekusi query INSERT ke tabel siswa ---
-	_, err := s.db.Exec(context.Background(),
-		"INSERT INTO siswa (id, kelas_id, nama, email, alamat) VALUES ($1, $2, $3, $4, $5)",
-		insert.ID, idKelasParam, insert.Nama, insert.Email, insert.Alamat)
+	// Data induk yang kosong disimpan sebagai NULL.
+	_, err := s.db.Exec(ctx,
+		`INSERT INTO siswa (id, kelas_id, nama, email, alamat, nis, nisn, tempat_lahir, tanggal_lahir, jenis_kelamin,
+			agama, telepon, nama_wali, telepon_wali, tanggal_masuk, foto)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, '')::date, NULLIF($10, ''),
+			NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), NULLIF($14, ''), NULLIF($15, '')::date, NULLIF($16, ''))`,
+		insert.ID, idKelasParam, insert.Nama, insert.Email, insert.Alamat, insert.NIS, insert.NISN, insert.Tempat_Lahir,
+		insert.Tanggal_Lahir, insert.Jenis_Kelamin, insert.Agama, insert.Telepon, insert.Nama_Wali, insert.Telepon_Wali,
+		insert.Tanggal_Masuk, insert.Foto)
 	if err != nil {
 		// Jika terjadi kesalahan maka akan terjadi error.
-		log.Printf("InsertSiswa error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("InsertSiswa error exec", "error", err)
 		return fmt.Errorf("insert failed: %w", err)
 	}
 
 	return nil
 }
 
+// kolomSiswa adalah daftar kolom yang diambil untuk setiap siswa, sesuai urutan scanSiswa.
+// Kolom data induk yang NULL dikembalikan sebagai string kosong dan tanggal diformat YYYY-MM-DD.
+const kolomSiswa = `s.id, s.kelas_id, k.kelas AS nama_kelas, s.nama, s.email, s.alamat,
+	COALESCE(s.nis, ''), COALESCE(s.nisn, ''), COALESCE(s.tempat_lahir, ''),
+	COALESCE(TO_CHAR(s.tanggal_lahir, 'YYYY-MM-DD'), ''), COALESCE(s.jenis_kelamin, ''), COALESCE(s.agama, ''),
+	COALESCE(s.telepon, ''), COALESCE(s.nama_wali, ''), COALESCE(s.telepon_wali, ''),
+	COALESCE(TO_CHAR(s.tanggal_masuk, 'YYYY-MM-DD'), ''), COALESCE(s.foto, ''), s.version`
+
+// scanSiswa membaca satu baris hasil query dengan kolom kolomSiswa ke dalam dst.
+func scanSiswa(row pgx.Row, dst *Siswa) error {
+	return row.Scan(&dst.ID, &dst.Kelas_ID, &dst.Nama_Kelas, &dst.Nama, &dst.Email, &dst.Alamat,
+		&dst.NIS, &dst.NISN, &dst.Tempat_Lahir, &dst.Tanggal_Lahir, &dst.Jenis_Kelamin, &dst.Agama,
+		&dst.Telepon, &dst.Nama_Wali, &dst.Telepon_Wali, &dst.Tanggal_Masuk, &dst.Foto, &dst.Version)
+}
+
+// kondisiFilter menyusun kondisi WHERE tambahan dan argumennya dari filter daftar siswa.
+// Nama dan tempat lahir dicocokkan sebagian tanpa membedakan huruf besar/kecil.
+func kondisiFilter(filter siswa.FilterSiswa) (string, []any) {
+	var kondisi []string
+	var args []any
+	tambah := func(format string, nilai any) {
+		args = append(args, nilai)
+		kondisi = append(kondisi, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.Nama != "" {
+		tambah("s.nama ILIKE '%%' || $%d || '%%'", filter.Nama)
+	}
+	if filter.Kelas_ID != "" {
+		tambah("s.kelas_id = $%d", filter.Kelas_ID)
+	}
+	if filter.NIS != "" {
+		tambah("s.nis = $%d", filter.NIS)
+	}
+	if filter.NISN != "" {
+		tambah("s.nisn = $%d", filter.NISN)
+	}
+	if filter.Jenis_Kelamin != "" {
+		tambah("s.jenis_kelamin = $%d", filter.Jenis_Kelamin)
+	}
+	if filter.Agama != "" {
+		tambah("s.agama = $%d", filter.Agama)
+	}
+	if filter.Tempat_Lahir != "" {
+		tambah("s.tempat_lahir ILIKE '%%' || $%d || '%%'", filter.Tempat_Lahir)
+	}
+	if filter.Tahun_Lahir > 0 {
+		tambah("EXTRACT(YEAR FROM s.tanggal_lahir) = $%d", filter.Tahun_Lahir)
+	}
+	if filter.Tahun_Masuk > 0 {
+		tambah("EXTRACT(YEAR FROM s.tanggal_masuk) = $%d", filter.Tahun_Masuk)
+	}
+
+	if len(kondisi) == 0 {
+		return "", nil
+	}
+	return " AND " + strings.Join(kondisi, " AND "), args
+}
+
+// cekNomorInduk memastikan NIS dan NISN data tidak dipakai siswa aktif lain selain siswa id.
+// Fungsi ini mengembalikan validation error jika nomor induk sudah dipakai.
+func (s *siswaQuery) cekNomorInduk(ctx context.Context, data *siswa.SiswaCore, id string) error {
+	nomor := []struct{ kolom, nilai string }{
+		{"nis", data.NIS},
+		{"nisn", data.NISN},
+	}
+	for _, n := range nomor {
+		if n.nilai == "" {
+			continue
+		}
+		// Nama kolom berasal dari daftar tetap di atas, bukan dari input client.
+		var dipakai bool
+		err := s.db.QueryRow(ctx,
+			"SELECT EXISTS (SELECT 1 FROM siswa WHERE "+n.kolom+" = $1 AND id <> $2 AND delete_at IS NULL)",
+			n.nilai, id).Scan(&dipakai)
+		if err != nil {
+			helper.LoggerFromContext(ctx).Error("cekNomorInduk error query", "error", err)
+			return fmt.Errorf("cek %s failed: %w", n.kolom, err)
+		}
+		if dipakai {
+			return fmt.Errorf("validation error: %s '%s' sudah dipakai siswa lain", strings.ToUpper(n.kolom), n.nilai)
+		}
+	}
+	return nil
+}
+
 // SelectAllSiswa implements siswa.DataSiswaInterface.
-// Fungsi ini digunakan untuk mengambil seluruh data siswa dari database.
+// Fungsi ini digunakan untuk mengambil data siswa dari database yang sesuai dengan filter.
 // Fungsi ini akan mengembalikan array siswa.SiswaCore yang berisi data-data siswa.
 // Jika terjadi kesalahan maka akan mengembalikan error.
-func (s *siswaQuery) SelectAllSiswa() ([]siswa.SiswaCore, error) {
+func (s *siswaQuery) SelectAllSiswa(ctx context.Context, filter siswa.FilterSiswa) ([]siswa.SiswaCore, error) {
 	if s.db == nil {
 		// Jika koneksi database tidak ada maka akan mengembalikan error.
 		return nil, errors.New("Nil database")
 	}
 
-	query := `SELECT 
-    s.id, 
-    s.kelas_id, 
-    k.kelas AS nama_kelas,
-    s.nama, 
-    s.email, 
-    s.alamat
+	// Tambahkan kondisi dari filter; nilai filter dikirim sebagai parameter query.
+	where, args := kondisiFilter(filter)
+	query := `SELECT ` + kolomSiswa + `
 FROM 
     siswa s
 LEFT JOIN 
     kelas k ON s.kelas_id = k.id
 WHERE 
-    s.delete_at IS NULL`
+    s.delete_at IS NULL` + where + `
+ORDER BY s.nama`
 
 	// Eksekusi query ke database.
-	rows, err := s.db.Query(context.Background(), query)
+	rows, err := s.db.Query(ctx, query, args...)
 	if err != nil {
 		// Jika terjadi kesalahan maka akan mengembalikan error.
-		log.Printf("SelectAllSiswa error query: %v", err)
+		helper.LoggerFromContext(ctx).Error("SelectAllSiswa error query", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
 
@@ -175,10 +270,10 @@ WHERE
 		var siswa Siswa
 
 		// Ambil data siswa dari hasil query dan simpan ke dalam objek siswa.
-		err := rows.Scan(&siswa.ID, &siswa.Kelas_ID, &siswa.Nama_Kelas, &siswa.Nama, &siswa.Email, &siswa.Alamat)
+		err := scanSiswa(rows, &siswa)
 		if err != nil {
 			// Jika terjadi kesalahan maka akan mengembalikan error.
-			log.Printf("SelectAllSiswa error scan: %v", err)
+			helper.LoggerFromContext(ctx).Error("SelectAllSiswa error scan", "error", err)
 			return nil, fmt.Errorf("select failed: %w", err)
 		}
 
@@ -191,12 +286,12 @@ WHERE
 
 	// Jika terjadi kesalahan maka akan mengembalikan error.
 	if err := rows.Err(); err != nil {
-		log.Printf("SelectAll error rows: %v", err)
+		helper.LoggerFromContext(ctx).Error("SelectAllSiswa error rows", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
 
 	// Log berapa banyak data siswa yang berhasil diambil.
-	log.Printf("Successfully fetched %d kelas from database", len(result))
+	helper.LoggerFromContext(ctx).Info("Successfully fetched siswa from database", "count", len(result))
 
 	// Mengembalikan array result yang berisi data-data siswa.
 	return result, nil
@@ -207,7 +302,7 @@ WHERE
 // Fungsi ini digunakan untuk mengambil data siswa berdasarkan ID.
 // Fungsi ini akan mengembalikan data siswa yang sesuai dengan ID yang dikirimkan
 // dan error jika terjadi kesalahan.
-func (s *siswaQuery) SelectById(id string) (*siswa.SiswaCore, error) {
+func (s *siswaQuery) SelectById(ctx context.Context, id string) (*siswa.SiswaCore, error) {
 	if s == nil || s.db == nil {
 		// Jika koneksi database tidak ada maka kembalikan error.
 		return nil, errors.New("Nil database")
@@ -218,32 +313,33 @@ func (s *siswaQuery) SelectById(id string) (*siswa.SiswaCore, error) {
 	}
 
 	// Query untuk mengambil data siswa berdasarkan ID.
-	// Query ini akan mengambil kolom id, kelas_id, nama_kelas, nama, email, dan alamat
+	// Query ini akan mengambil kolom kolomSiswa
 	// berdasarkan ID yang dikirimkan dan delete_at IS NULL
 	// yang artinya data siswa yang diambil belum dihapus.
-	query := "SELECT s.id, s.kelas_id, k.kelas AS nama_kelas, s.nama, s.email, s.alamat FROM siswa s LEFT JOIN kelas k ON s.kelas_id = k.id WHERE s.id = $1 AND s.delete_at IS NULL"
+	query := "SELECT " + kolomSiswa + " FROM siswa s LEFT JOIN kelas k ON s.kelas_id = k.id WHERE s.id = $1 AND s.delete_at IS NULL"
 
 	// Jalankan query.
 	// Fungsi QueryRow akan mengembalikan row yang sesuai dengan query
 	// dan error jika terjadi kesalahan.
-	row := s.db.QueryRow(context.Background(), query, id)
+	row := s.db.QueryRow(ctx, query, id)
 
-	// Deklarasikan variabel result yang akan digunakan untuk menyimpan hasil query.
-	var result siswa.SiswaCore
+	// Deklarasikan variabel data yang akan digunakan untuk menyimpan hasil query.
+	var data Siswa
 
-	// Scan hasil query ke variabel result.
+	// Scan hasil query ke variabel data.
 	// Fungsi Scan akan mengembalikan error jika terjadi kesalahan.
-	err := row.Scan(&result.ID, &result.Kelas_ID, &result.Nama_Kelas, &result.Nama, &result.Email, &result.Alamat)
+	err := scanSiswa(row, &data)
 	if err != nil {
 		// Jika terjadi kesalahan maka kembalikan error.
-		log.Printf("SelectById error scan: %v", err)
+		helper.LoggerFromContext(ctx).Error("SelectById error scan", "error", err)
 		return nil, fmt.Errorf("select failed: %w", err)
 	}
 
 	// Log berapa banyak data siswa yang berhasil diambil.
-	log.Printf("Successfully fetched siswa from database with id:%s", id)
+	helper.LoggerFromContext(ctx).Info("Successfully fetched siswa from database", "id", id)
 
 	// Mengembalikan data siswa yang diambil.
+	result := FormatterResponse(data)
 	return &result, nil
 }
 
@@ -251,7 +347,7 @@ func (s *siswaQuery) SelectById(id string) (*siswa.SiswaCore, error) {
 // Update implements siswa.DataSiswaInterface.
 // Fungsi ini digunakan untuk mengupdate data siswa berdasarkan ID.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *siswaQuery) Update(insert *siswa.SiswaCore, id string) error {
+func (s *siswaQuery) Update(ctx context.Context, insert *siswa.SiswaCore, id string) error {
 	// Cek apakah koneksi database ada atau tidak.
 	if s == nil || s.db == nil {
 		return errors.New("Nil database")
@@ -261,36 +357,53 @@ func (s *siswaQuery) Update(insert *siswa.SiswaCore, id string) error {
 		return errors.New("ID tidak boleh kosong")
 	}
 
+	// Pastikan NIS dan NISN belum dipakai siswa aktif lain.
+	if err := s.cekNomorInduk(ctx, insert, id); err != nil {
+		return err
+	}
+
 	// Query untuk mengupdate data siswa berdasarkan ID.
-	// Query ini akan mengupdate kolom nama, email, alamat, dan kelas_id.
+	// Query ini akan mengupdate kolom nama, email, alamat, kelas_id, dan data induk siswa.
 	// berdasarkan ID yang dikirimkan dan delete_at IS NULL
-	// yang artinya data siswa yang diupdate belum dihapus.
-	query := "UPDATE siswa SET nama = $1, email = $2, alamat = $3, kelas_id = $4 WHERE id = $5"
+	// yang artinya data siswa yang diupdate belum dihapus,
+	// serta hanya jika versinya masih sama dengan versi yang dibaca client.
+	query := `UPDATE siswa SET nama = $1, email = $2, alamat = $3, kelas_id = $4,
+			nis = NULLIF($7, ''), nisn = NULLIF($8, ''), tempat_lahir = NULLIF($9, ''), tanggal_lahir = NULLIF($10, '')::date,
+			jenis_kelamin = NULLIF($11, ''), agama = NULLIF($12, ''), telepon = NULLIF($13, ''), nama_wali = NULLIF($14, ''),
+			telepon_wali = NULLIF($15, ''), tanggal_masuk = NULLIF($16, '')::date, foto = NULLIF($17, ''),
+			update_at = NOW(), version = version + 1
+		WHERE id = $5 AND delete_at IS NULL AND version = $6`
 	// Jalankan query untuk mengupdate data siswa.
 	// Fungsi Exec digunakan untuk mengeksekusi query yang tidak mengembalikan hasil.
-	res, err := s.db.Exec(context.Background(), query, insert.Nama, insert.Email, insert.Alamat, insert.Kelas_ID, id)
+	res, err := s.db.Exec(ctx, query, insert.Nama, insert.Email, insert.Alamat, insert.Kelas_ID, id, insert.Version,
+		insert.NIS, insert.NISN, insert.Tempat_Lahir, insert.Tanggal_Lahir, insert.Jenis_Kelamin, insert.Agama,
+		insert.Telepon, insert.Nama_Wali, insert.Telepon_Wali, insert.Tanggal_Masuk, insert.Foto)
 	if err != nil {
 		// Jika terjadi error saat query maka log error dan kembalikan.
-		log.Printf("Update error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("Update error exec", "error", err)
 		return fmt.Errorf("update failed: %w", err)
 	}
 	// Cek apakah ada baris yang terpengaruh.
 	if res.RowsAffected() == 0 {
+		// Jika siswa masih ada berarti versinya sudah berubah.
+		if err := helper.CheckVersionConflict(ctx, s.db, "siswa", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
 		// Jika tidak ada baris yang terpengaruh maka log dan kembalikan error.
-		log.Printf("UpdateUser: no rows updated for id %s", id)
+		helper.LoggerFromContext(ctx).Warn("UpdateSiswa: no rows updated", "id", id)
 		return errors.New("update failed: no rows affected")
 	}
 	// Log berapa banyak data siswa yang berhasil diupdate.
-	log.Printf("Successfully updated %s in database", id)
+	helper.LoggerFromContext(ctx).Info("Successfully updated siswa in database", "id", id)
 	// Mengembalikan nil jika update berhasil tanpa error.
 	return nil
 }
 
 // DeleteById implements siswa.DataSiswaInterface.
 // DeleteById implements siswa.DataSiswaInterface.
-// Fungsi ini digunakan untuk menghapus data siswa berdasarkan ID.
+// Fungsi ini digunakan untuk menghapus data siswa berdasarkan ID jika versinya masih sama dengan version.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *siswaQuery) DeleteById(id string) error {
+func (s *siswaQuery) DeleteById(ctx context.Context, id string, version int) error {
 	// Cek apakah koneksi database ada atau tidak.
 	// Jika tidak ada maka kembalikan error.
 	if s.db == nil {
@@ -301,26 +414,48 @@ func (s *siswaQuery) DeleteById(id string) error {
 	// Query ini menggunakan parameter $1 untuk menggantikan nilai id.
 	// Query ini akan mengupdate kolom delete_at dengan waktu sekarang
 	// jika data siswa dengan ID yang dikirimkan memang ada dan belum dihapus.
-	query := "UPDATE siswa SET delete_at = NOW() WHERE id = $1 AND delete_at IS NULL"
+	query := "UPDATE siswa SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2"
 
 	// Jalankan query untuk menghapus data siswa.
 	// Fungsi Exec digunakan untuk mengeksekusi query yang tidak mengembalikan hasil.
 	// Fungsi Exec juga akan mengembalikan error jika terjadi kesalahan.
-	res, err := s.db.Exec(context.Background(), query, id)
+	res, err := s.db.Exec(ctx, query, id, version)
 	if err != nil {
 		// Jika terjadi error saat query maka log error dan kembalikan.
-		log.Printf("DeleteById error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("DeleteById error exec", "error", err)
 		return fmt.Errorf("hapus gagal: %w", err)
 	}
 
 	// Cek apakah ada baris yang terpengaruh.
 	// Jika tidak ada baris yang terpengaruh maka log dan kembalikan error.
 	if res.RowsAffected() == 0 {
-		log.Printf("DeleteById: tidak ada baris yang dihapus untuk id %s", id)
+		// Jika siswa masih ada berarti versinya sudah berubah.
+		if err := helper.CheckVersionConflict(ctx, s.db, "siswa", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		helper.LoggerFromContext(ctx).Warn("DeleteById: tidak ada baris yang dihapus", "id", id)
 		return errors.New("hapus gagal: tidak ada baris yang terpengaruh")
 	}
 
 	// Jika data berhasil dihapus maka log pesan sukses dan kembalikan nil.
-	log.Printf("Berhasil menghapus siswa dengan id: %s", id)
+	helper.LoggerFromContext(ctx).Info("Berhasil menghapus siswa", "id", id)
+	return nil
+}
+
+// LockKelas implements siswa.DataSiswaInterface.
+// Fungsi ini mengunci baris kelas aktif dengan FOR SHARE sehingga kelas tidak bisa
+// dihapus oleh transaksi lain sampai transaksi pemindahan siswa selesai.
+// Jika dipanggil di luar transaksi, kunci langsung dilepas setelah query selesai.
+func (s *siswaQuery) LockKelas(ctx context.Context, kelasID string) error {
+	if s == nil || s.db == nil {
+		return errors.New("Nil database")
+	}
+
+	query := "SELECT id FROM kelas WHERE id = $1 AND delete_at IS NULL FOR SHARE"
+
+	var id string
+	if err := s.db.QueryRow(ctx, query, kelasID).Scan(&id); err != nil {
+		return fmt.Errorf("gagal mengunci kelas: %w", err)
+	}
 	return nil
 }
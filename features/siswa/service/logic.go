@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go_rest_native_sekolah/features/siswa"
+	"go_rest_native_sekolah/helper"
 	"regexp"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -13,15 +16,17 @@ import (
 // Struct ini memiliki satu field yaitu siswaData yang merupakan interface DataSiswaInterface.
 // siswaData digunakan untuk mengakses data siswa dari database.
 type siswaService struct {
-	siswaData siswa.DataSiswaInterface // Interface untuk mengakses data siswa dari database
+	siswaData siswa.DataSiswaInterface                    // Interface untuk mengakses data siswa dari database
+	uow       helper.UnitOfWork[siswa.DataSiswaInterface] // Menjalankan repository siswa dalam satu transaksi
 }
 
 // NewServiceSiswa digunakan untuk membuat objek siswaService yang akan digunakan
 // untuk menghandle logika bisnis yang berhubungan dengan data siswa.
 // Fungsi ini menerima parameter repo yang berupa interface DataSiswaInterface.
-// Parameter repo digunakan untuk mengakses data siswa dari database.
+// Parameter repo digunakan untuk mengakses data siswa dari database,
+// sedangkan uow dipakai untuk update yang harus berjalan dalam satu transaksi.
 // Jika parameter repo nil maka akan terjadi panic.
-func NewServiceSiswa(repo siswa.DataSiswaInterface) siswa.ServiceSiswaInterface {
+func NewServiceSiswa(repo siswa.DataSiswaInterface, uow helper.UnitOfWork[siswa.DataSiswaInterface]) siswa.ServiceSiswaInterface {
 	// Cek apakah parameter repo nil atau tidak.
 	// Jika nil maka akan terjadi panic.
 	if repo == nil {
@@ -30,13 +35,13 @@ func NewServiceSiswa(repo siswa.DataSiswaInterface) siswa.ServiceSiswaInterface
 	// Membuat objek siswaService yang berisi parameter repo.
 	// Objek siswaService ini akan digunakan untuk menghandle logika bisnis
 	// yang berhubungan dengan data siswa.
-	return &siswaService{siswaData: repo}
+	return &siswaService{siswaData: repo, uow: uow}
 }
 
 // InsertSiswa implements siswa.ServiceSiswaInterface.
 // InsertSiswa digunakan untuk memasukkan data siswa ke dalam database.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *siswaService) InsertSiswa(insert *siswa.SiswaCore) error {
+func (s *siswaService) InsertSiswa(ctx context.Context, insert *siswa.SiswaCore) error {
 	// Memeriksa apakah repository siswaData tidak nil.
 	if s.siswaData == nil {
 		return errors.New("Nil repository")
@@ -59,22 +64,39 @@ func (s *siswaService) InsertSiswa(insert *siswa.SiswaCore) error {
 	if !emailRegex.MatchString(insert.Email) {
 		return errors.New("validation error: email tidak valid")
 	}
+	// Memeriksa dan merapikan data induk siswa (NIS, NISN, kelahiran, agama, kontak).
+	if err := validasiProfil(insert); err != nil {
+		return err
+	}
 
 	// Memanggil fungsi InsertSiswa pada siswaData untuk menyimpan data siswa.
-	return s.siswaData.InsertSiswa(insert)
+	return s.siswaData.InsertSiswa(ctx, insert)
 }
 
 // SelectAllSiswa implements siswa.ServiceSiswaInterface.
 // SelectAllSiswa implements siswa.ServiceSiswaInterface.
-// Fungsi ini digunakan untuk mengambil semua data siswa dari database.
+// Fungsi ini digunakan untuk mengambil data siswa dari database yang sesuai dengan filter.
+// Jenis kelamin dan agama pada filter dirapikan dengan aturan yang sama seperti saat insert.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *siswaService) SelectAllSiswa() ([]siswa.SiswaCore, error) {
+func (s *siswaService) SelectAllSiswa(ctx context.Context, filter siswa.FilterSiswa) ([]siswa.SiswaCore, error) {
 	// Memeriksa apakah repository siswaData tidak nil.
 	if s.siswaData == nil {
 		return nil, errors.New("SiswaService: Nil repository")
 	}
+	// Merapikan filter agar cocok dengan nilai yang tersimpan di database.
+	var err error
+	if filter.Jenis_Kelamin, err = normalisasiJenisKelamin(filter.Jenis_Kelamin); err != nil {
+		return nil, err
+	}
+	if filter.Agama, err = normalisasiAgama(filter.Agama); err != nil {
+		return nil, err
+	}
+	filter.Nama = strings.TrimSpace(filter.Nama)
+	filter.NIS = strings.TrimSpace(filter.NIS)
+	filter.NISN = strings.TrimSpace(filter.NISN)
+	filter.Tempat_Lahir = strings.TrimSpace(filter.Tempat_Lahir)
 	// Memanggil fungsi SelectAllSiswa pada siswaData untuk mengambil data siswa.
-	kelass, err := s.siswaData.SelectAllSiswa()
+	kelass, err := s.siswaData.SelectAllSiswa(ctx, filter)
 	// Jika terjadi error maka kembalikan error.
 	if err != nil {
 		return nil, errors.New("SiswaService: gagal mengambil data")
@@ -87,9 +109,9 @@ func (s *siswaService) SelectAllSiswa() ([]siswa.SiswaCore, error) {
 // Fungsi ini digunakan untuk mengambil data siswa berdasarkan ID.
 // Fungsi ini akan mengembalikan data siswa yang sesuai dengan ID yang dikirimkan
 // dan error jika terjadi kesalahan.
-func (s *siswaService) SelectById(id string) (*siswa.SiswaCore, error) {
+func (s *siswaService) SelectById(ctx context.Context, id string) (*siswa.SiswaCore, error) {
 	// Memanggil fungsi SelectById pada siswaData untuk mengambil data siswa berdasarkan ID.
-	siswa, err := s.siswaData.SelectById(id)
+	siswa, err := s.siswaData.SelectById(ctx, id)
 	if err != nil {
 		// Jika terjadi error saat mengambil data siswa, mengembalikan error dengan pesan "SiswaService: gagal mengambil data".
 		return nil, errors.New("SiswaService: gagal mengambil data")
@@ -100,54 +122,80 @@ func (s *siswaService) SelectById(id string) (*siswa.SiswaCore, error) {
 
 // Update implements siswa.ServiceSiswaInterface.
 // Fungsi ini digunakan untuk memperbarui data siswa berdasarkan ID.
+// Pembacaan data lama, pengecekan kelas tujuan, dan update dijalankan dalam satu transaksi
+// sehingga siswa tidak bisa dipindahkan ke kelas yang sedang dihapus.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *siswaService) Update(insert *siswa.SiswaCore, id string) error {
-	// Memeriksa apakah repository siswaData tidak nil.
-	if s == nil || s.siswaData == nil {
+func (s *siswaService) Update(ctx context.Context, insert *siswa.SiswaCore, id string) error {
+	// Memeriksa apakah repository siswaData dan unit of work tidak nil.
+	if s == nil || s.siswaData == nil || s.uow == nil {
 		return errors.New("Nil repository")
 	}
 	// Memeriksa apakah ID tidak kosong.
 	if id == "" {
 		return errors.New("Validation error: id is nil")
 	}
-	// Mengambil data siswa yang akan diupdate berdasarkan ID.
-	existingData, err := s.siswaData.SelectById(id)
-	if err != nil {
-		// Jika terjadi error saat mengambil data siswa, kembalikan error.
-		if err == pgx.ErrNoRows {
-			// Jika data tidak ditemukan, kembalikan error.
-			return errors.New("guru service: Data tidak ditemukan")
-		}
-		return fmt.Errorf("Id salah atau gagal mengambil data lama: %w", err)
-	}
-	// Menggabungkan data lama dengan data baru.
-	// Jika field baru kosong, gunakan field dari data lama.
-	if insert.Nama == "" {
-		insert.Nama = existingData.Nama
-	}
-	if insert.Email == "" {
-		insert.Email = existingData.Email
-	}
-	if insert.Alamat == "" {
-		insert.Alamat = existingData.Alamat
-	}
-	if insert.Kelas_ID == "" {
-		insert.Kelas_ID = existingData.Kelas_ID
+	// Memeriksa dan merapikan data induk siswa yang dikirim.
+	if err := validasiProfil(insert); err != nil {
+		return err
 	}
-	// Memanggil fungsi Update pada siswaData untuk memperbarui data siswa.
-	if err := s.siswaData.Update(insert, id); err != nil {
-		// Jika terjadi error saat memperbarui data siswa, kembalikan error.
-		return fmt.Errorf("failed to update data: %w", err)
-	}
-	// Kembalikan nil jika berhasil memperbarui data siswa.
-	return nil
+
+	return s.uow.Do(ctx, func(repo siswa.DataSiswaInterface) error {
+		// Mengambil data siswa yang akan diupdate berdasarkan ID.
+		existingData, err := repo.SelectById(ctx, id)
+		if err != nil {
+			// Jika terjadi error saat mengambil data siswa, kembalikan error.
+			if err == pgx.ErrNoRows {
+				// Jika data tidak ditemukan, kembalikan error.
+				return errors.New("guru service: Data tidak ditemukan")
+			}
+			return fmt.Errorf("Id salah atau gagal mengambil data lama: %w", err)
+		}
+		// Tolak update jika siswa sudah diubah sejak client mengambilnya (If-Match).
+		if insert.Version != existingData.Version {
+			return helper.ErrVersionConflict
+		}
+		// Menggabungkan data lama dengan data baru.
+		// Jika field baru kosong, gunakan field dari data lama.
+		if insert.Nama == "" {
+			insert.Nama = existingData.Nama
+		}
+		if insert.Email == "" {
+			insert.Email = existingData.Email
+		}
+		if insert.Alamat == "" {
+			insert.Alamat = existingData.Alamat
+		}
+		if insert.Kelas_ID == "" {
+			insert.Kelas_ID = existingData.Kelas_ID
+		}
+		gabungProfil(insert, existingData)
+		if err := cekUrutanTanggal(insert); err != nil {
+			return err
+		}
+		// Jika siswa dipindahkan, pastikan kelas tujuan masih aktif dan kunci sampai transaksi selesai.
+		if insert.Kelas_ID != existingData.Kelas_ID {
+			if err := repo.LockKelas(ctx, insert.Kelas_ID); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errors.New("validation error: kelas tujuan tidak ditemukan")
+				}
+				return fmt.Errorf("gagal memeriksa kelas tujuan: %w", err)
+			}
+		}
+		// Memanggil fungsi Update pada repository transaksi untuk memperbarui data siswa.
+		if err := repo.Update(ctx, insert, id); err != nil {
+			// Jika terjadi error saat memperbarui data siswa, kembalikan error.
+			return fmt.Errorf("failed to update data: %w", err)
+		}
+		// Kembalikan nil jika berhasil memperbarui data siswa.
+		return nil
+	})
 }
 
 // DeleteById implements siswa.ServiceSiswaInterface.
 // DeleteById implements siswa.ServiceSiswaInterface.
 // Fungsi ini digunakan untuk menghapus data siswa berdasarkan ID yang dikirimkan.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (s *siswaService) DeleteById(id string) error {
+func (s *siswaService) DeleteById(ctx context.Context, id string, version int) error {
 	if s == nil || s.siswaData == nil {
 		// Jika koneksi database tidak ada, maka kembalikan error.
 		return errors.New("Nil repository")
@@ -156,10 +204,149 @@ func (s *siswaService) DeleteById(id string) error {
 		// Jika parameter id kosong, maka kembalikan error.
 		return errors.New("validation error: id harus diisi")
 	}
-	if err := s.siswaData.DeleteById(id); err != nil {
+	if err := s.siswaData.DeleteById(ctx, id, version); err != nil {
 		// Jika terjadi error saat menghapus data siswa, kembalikan error.
 		return fmt.Errorf("gagal menghapus data siswa: %w", err)
 	}
 
 	return nil // Kembalikan nil jika berhasil menghapus data siswa
 }
+
+// Bulk implements siswa.ServiceSiswaInterface.
+// Fungsi ini digunakan untuk menjalankan banyak operasi create, update, dan delete siswa sekaligus.
+// Setiap operasi memakai validasi yang sama dengan endpoint satuan, dengan repository dari transaksi bulk.
+// Fungsi ini akan mengembalikan hasil per operasi dan error jika transaksi gagal.
+func (s *siswaService) Bulk(ctx context.Context, mode helper.BulkMode, ops []helper.BulkOperation[siswa.SiswaCore]) (*helper.BulkResult, error) {
+	// Memeriksa apakah repository siswaData dan unit of work tidak nil.
+	if s == nil || s.siswaData == nil || s.uow == nil {
+		return nil, errors.New("Nil repository")
+	}
+
+	return helper.RunBulk(ctx, s.uow, mode, ops, func(ctx context.Context, repo siswa.DataSiswaInterface, op helper.BulkOperation[siswa.SiswaCore]) (string, error) {
+		// Service yang memakai repository dan transaksi milik operasi bulk.
+		tx := &siswaService{siswaData: repo, uow: helper.JoinUnitOfWork(repo)}
+		data := op.Data
+		switch op.Action {
+		case helper.BulkActionCreate:
+			err := tx.InsertSiswa(ctx, &data)
+			return data.ID, err
+		case helper.BulkActionUpdate:
+			data.Version = op.Version
+			return op.ID, tx.Update(ctx, &data, op.ID)
+		default:
+			return op.ID, tx.DeleteById(ctx, op.ID, op.Version)
+		}
+	})
+}
+
+var (
+	// nisRegex memvalidasi NIS sekolah: 4 sampai 20 digit angka.
+	nisRegex = regexp.MustCompile(`^[0-9]{4,20}$`)
+	// nisnRegex memvalidasi NISN: tepat 10 digit angka.
+	nisnRegex = regexp.MustCompile(`^[0-9]{10}$`)
+)
+
+// validasiProfil memeriksa dan merapikan data induk siswa yang diisi.
+// Field kosong dilewati sehingga fungsi ini bisa dipakai untuk insert maupun update sebagian.
+func validasiProfil(c *siswa.SiswaCore) error {
+	c.NIS = strings.TrimSpace(c.NIS)
+	if c.NIS != "" && !nisRegex.MatchString(c.NIS) {
+		return errors.New("validation error: nis harus 4-20 digit angka")
+	}
+	c.NISN = strings.TrimSpace(c.NISN)
+	if c.NISN != "" && !nisnRegex.MatchString(c.NISN) {
+		return errors.New("validation error: nisn harus 10 digit angka")
+	}
+
+	c.Tempat_Lahir = strings.TrimSpace(c.Tempat_Lahir)
+	c.Nama_Wali = strings.TrimSpace(c.Nama_Wali)
+	c.Foto = strings.TrimSpace(c.Foto)
+
+	var err error
+	if c.Jenis_Kelamin, err = normalisasiJenisKelamin(c.Jenis_Kelamin); err != nil {
+		return err
+	}
+	if c.Agama, err = normalisasiAgama(c.Agama); err != nil {
+		return err
+	}
+	if c.Telepon, err = helper.NormalizePhone("telepon", c.Telepon); err != nil {
+		return err
+	}
+	if c.Telepon_Wali, err = helper.NormalizePhone("telepon_wali", c.Telepon_Wali); err != nil {
+		return err
+	}
+	if c.Tanggal_Lahir, err = helper.NormalizeDate("tanggal_lahir", c.Tanggal_Lahir, true); err != nil {
+		return err
+	}
+	if c.Tanggal_Masuk, err = helper.NormalizeDate("tanggal_masuk", c.Tanggal_Masuk, false); err != nil {
+		return err
+	}
+	return cekUrutanTanggal(c)
+}
+
+// cekUrutanTanggal memastikan tanggal masuk tidak lebih awal dari tanggal lahir jika keduanya diisi.
+// Tanggal harus sudah divalidasi oleh validasiProfil.
+func cekUrutanTanggal(c *siswa.SiswaCore) error {
+	if c.Tanggal_Lahir == "" || c.Tanggal_Masuk == "" {
+		return nil
+	}
+	// Format YYYY-MM-DD bisa dibandingkan langsung sebagai string.
+	if c.Tanggal_Masuk < c.Tanggal_Lahir {
+		return errors.New("validation error: tanggal_masuk tidak boleh sebelum tanggal_lahir")
+	}
+	return nil
+}
+
+// gabungProfil mengisi data induk yang kosong pada update dengan nilai dari data lama.
+func gabungProfil(update *siswa.SiswaCore, lama *siswa.SiswaCore) {
+	field := []struct {
+		baru *string
+		lama string
+	}{
+		{&update.NIS, lama.NIS},
+		{&update.NISN, lama.NISN},
+		{&update.Tempat_Lahir, lama.Tempat_Lahir},
+		{&update.Tanggal_Lahir, lama.Tanggal_Lahir},
+		{&update.Jenis_Kelamin, lama.Jenis_Kelamin},
+		{&update.Agama, lama.Agama},
+		{&update.Telepon, lama.Telepon},
+		{&update.Nama_Wali, lama.Nama_Wali},
+		{&update.Telepon_Wali, lama.Telepon_Wali},
+		{&update.Tanggal_Masuk, lama.Tanggal_Masuk},
+		{&update.Foto, lama.Foto},
+	}
+	for _, f := range field {
+		if *f.baru == "" {
+			*f.baru = f.lama
+		}
+	}
+}
+
+// normalisasiJenisKelamin mengubah "L", "laki-laki", "P", atau "perempuan" (huruf besar/kecil bebas)
+// menjadi siswa.JenisKelaminLaki atau siswa.JenisKelaminPerempuan.
+func normalisasiJenisKelamin(nilai string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(nilai)) {
+	case "":
+		return "", nil
+	case "l", "laki-laki", "laki laki":
+		return siswa.JenisKelaminLaki, nil
+	case "p", "perempuan":
+		return siswa.JenisKelaminPerempuan, nil
+	}
+	return "", fmt.Errorf("validation error: jenis_kelamin '%s' tidak dikenal, gunakan L atau P", nilai)
+}
+
+// normalisasiAgama mencocokkan agama dengan siswa.DaftarAgama tanpa membedakan huruf besar/kecil
+// dan mengembalikan penulisan bakunya.
+func normalisasiAgama(nilai string) (string, error) {
+	nilai = strings.TrimSpace(nilai)
+	if nilai == "" {
+		return "", nil
+	}
+	for _, agama := range siswa.DaftarAgama {
+		if strings.EqualFold(agama, nilai) {
+			return agama, nil
+		}
+	}
+	return "", fmt.Errorf("validation error: agama '%s' tidak dikenal, gunakan salah satu dari %s", nilai, strings.Join(siswa.DaftarAgama, ", "))
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"go_rest_native_sekolah/features/siswa"
+	"go_rest_native_sekolah/helper"
 	"testing"
 	"time"
 
@@ -16,25 +18,25 @@ type mockDataSiswa struct {
 	mock.Mock
 }
 
-func (m *mockDataSiswa) SelectAllSiswa() ([]siswa.SiswaCore, error) {
-	args := m.Called()
+func (m *mockDataSiswa) SelectAllSiswa(ctx context.Context, filter siswa.FilterSiswa) ([]siswa.SiswaCore, error) {
+	args := m.Called(filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]siswa.SiswaCore), args.Error(1)
 }
 
-func (m *mockDataSiswa) InsertSiswa(insert *siswa.SiswaCore) error {
+func (m *mockDataSiswa) InsertSiswa(ctx context.Context, insert *siswa.SiswaCore) error {
 	args := m.Called(insert)
 	return args.Error(0)
 }
 
-func (m *mockDataSiswa) Update(insert *siswa.SiswaCore, id string) error {
+func (m *mockDataSiswa) Update(ctx context.Context, insert *siswa.SiswaCore, id string) error {
 	args := m.Called(insert, id)
 	return args.Error(0)
 }
 
-func (m *mockDataSiswa) SelectById(id string) (*siswa.SiswaCore, error) {
+func (m *mockDataSiswa) SelectById(ctx context.Context, id string) (*siswa.SiswaCore, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -42,11 +44,25 @@ func (m *mockDataSiswa) SelectById(id string) (*siswa.SiswaCore, error) {
 	return args.Get(0).(*siswa.SiswaCore), args.Error(1)
 }
 
-func (m *mockDataSiswa) DeleteById(id string) error {
-	args := m.Called(id)
+func (m *mockDataSiswa) DeleteById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataSiswa) LockKelas(ctx context.Context, kelasID string) error {
+	args := m.Called(kelasID)
 	return args.Error(0)
 }
 
+// fakeUnitOfWork menjalankan fn langsung dengan repository mock tanpa transaksi sungguhan
+type fakeUnitOfWork struct {
+	repo siswa.DataSiswaInterface
+}
+
+func (f fakeUnitOfWork) Do(ctx context.Context, fn func(repo siswa.DataSiswaInterface) error) error {
+	return fn(f.repo)
+}
+
 // Test SelectAllSiswa
 func TestSelectAllSiswa(t *testing.T) {
 	mockRepo := new(mockDataSiswa)
@@ -73,10 +89,10 @@ func TestSelectAllSiswa(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("SelectAllSiswa").Return(expectedSiswa, nil).Once()
+		mockRepo.On("SelectAllSiswa", siswa.FilterSiswa{}).Return(expectedSiswa, nil).Once()
 
 		svc := &siswaService{siswaData: mockRepo}
-		result, err := svc.SelectAllSiswa()
+		result, err := svc.SelectAllSiswa(context.Background(), siswa.FilterSiswa{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedSiswa, result)
@@ -85,10 +101,10 @@ func TestSelectAllSiswa(t *testing.T) {
 	})
 
 	t.Run("failed get all siswa - repository error", func(t *testing.T) {
-		mockRepo.On("SelectAllSiswa").Return(nil, errors.New("database error")).Once()
+		mockRepo.On("SelectAllSiswa", siswa.FilterSiswa{}).Return(nil, errors.New("database error")).Once()
 
 		svc := &siswaService{siswaData: mockRepo}
-		result, err := svc.SelectAllSiswa()
+		result, err := svc.SelectAllSiswa(context.Background(), siswa.FilterSiswa{})
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -97,10 +113,35 @@ func TestSelectAllSiswa(t *testing.T) {
 
 	t.Run("failed - nil repository", func(t *testing.T) {
 		svc := &siswaService{siswaData: nil}
-		result, err := svc.SelectAllSiswa()
+		result, err := svc.SelectAllSiswa(context.Background(), siswa.FilterSiswa{})
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("success get siswa - filter dirapikan", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		expectedFilter := siswa.FilterSiswa{Jenis_Kelamin: siswa.JenisKelaminPerempuan, Agama: "Katolik", NISN: "0012345678", Tahun_Masuk: 2023}
+
+		mockRepo.On("SelectAllSiswa", expectedFilter).Return([]siswa.SiswaCore{}, nil).Once()
+
+		svc := &siswaService{siswaData: mockRepo}
+		_, err := svc.SelectAllSiswa(context.Background(), siswa.FilterSiswa{Jenis_Kelamin: "perempuan", Agama: "katolik", NISN: " 0012345678 ", Tahun_Masuk: 2023})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed get siswa - filter jenis kelamin tidak dikenal", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+
+		svc := &siswaService{siswaData: mockRepo}
+		result, err := svc.SelectAllSiswa(context.Background(), siswa.FilterSiswa{Jenis_Kelamin: "X"})
 
 		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "validation")
 		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "SelectAllSiswa", mock.Anything)
 	})
 }
 
@@ -120,7 +161,7 @@ func TestInsertSiswa(t *testing.T) {
 		mockRepo.On("InsertSiswa", newSiswa).Return(nil).Once()
 
 		svc := &siswaService{siswaData: mockRepo}
-		err := svc.InsertSiswa(newSiswa)
+		err := svc.InsertSiswa(context.Background(), newSiswa)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -136,11 +177,70 @@ func TestInsertSiswa(t *testing.T) {
 		}
 
 		svc := &siswaService{siswaData: mockRepo}
-		err := svc.InsertSiswa(invalidSiswa)
+		err := svc.InsertSiswa(context.Background(), invalidSiswa)
 
 		assert.Error(t, err)
 	})
 
+	t.Run("success insert siswa - data induk dirapikan", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		newSiswa := &siswa.SiswaCore{
+			Nama:          "Ahmad Rauf",
+			Kelas_ID:      "kelas-001",
+			Email:         "ahmad@example.com",
+			Alamat:        "Jl. Gatot Subroto No. 1",
+			NIS:           " 2023001 ",
+			NISN:          "0012345678",
+			Tempat_Lahir:  " Bandung ",
+			Tanggal_Lahir: "2008-04-12",
+			Jenis_Kelamin: "laki-laki",
+			Agama:         "ISLAM",
+			Telepon:       "0812-3456-7890",
+			Nama_Wali:     "Rauf Senior",
+			Telepon_Wali:  "+62 812 0000 1111",
+			Tanggal_Masuk: "2023-07-17",
+		}
+
+		mockRepo.On("InsertSiswa", newSiswa).Return(nil).Once()
+
+		svc := &siswaService{siswaData: mockRepo}
+		err := svc.InsertSiswa(context.Background(), newSiswa)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2023001", newSiswa.NIS)
+		assert.Equal(t, "Bandung", newSiswa.Tempat_Lahir)
+		assert.Equal(t, siswa.JenisKelaminLaki, newSiswa.Jenis_Kelamin)
+		assert.Equal(t, "Islam", newSiswa.Agama)
+		assert.Equal(t, "081234567890", newSiswa.Telepon)
+		assert.Equal(t, "+6281200001111", newSiswa.Telepon_Wali)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed insert siswa - data induk tidak valid", func(t *testing.T) {
+		cases := map[string]siswa.SiswaCore{
+			"nisn":          {NISN: "12345"},
+			"nis":           {NIS: "A-01"},
+			"tanggal_lahir": {Tanggal_Lahir: "12-04-2008"},
+			"jenis_kelamin": {Jenis_Kelamin: "X"},
+			"agama":         {Agama: "Lainnya"},
+			"telepon":       {Telepon: "12ab"},
+			"tanggal_masuk": {Tanggal_Lahir: "2008-04-12", Tanggal_Masuk: "2007-07-17"},
+		}
+		for field, data := range cases {
+			mockRepo := new(mockDataSiswa)
+			data.Nama = "Ahmad Rauf"
+			data.Email = "ahmad@example.com"
+			data.Alamat = "Jl. Gatot Subroto No. 1"
+
+			svc := &siswaService{siswaData: mockRepo}
+			err := svc.InsertSiswa(context.Background(), &data)
+
+			assert.Error(t, err, field)
+			assert.Contains(t, err.Error(), field)
+			mockRepo.AssertNotCalled(t, "InsertSiswa", mock.Anything)
+		}
+	})
+
 	t.Run("failed insert siswa - nil repository", func(t *testing.T) {
 		newSiswa := &siswa.SiswaCore{
 			Nama:     "Ahmad Rauf",
@@ -150,7 +250,7 @@ func TestInsertSiswa(t *testing.T) {
 		}
 
 		svc := &siswaService{siswaData: nil}
-		err := svc.InsertSiswa(newSiswa)
+		err := svc.InsertSiswa(context.Background(), newSiswa)
 
 		assert.Error(t, err)
 	})
@@ -174,7 +274,7 @@ func TestSelectSiswaById(t *testing.T) {
 		mockRepo.On("SelectById", "siswa-001").Return(expectedSiswa, nil).Once()
 
 		svc := &siswaService{siswaData: mockRepo}
-		result, err := svc.SelectById("siswa-001")
+		result, err := svc.SelectById(context.Background(), "siswa-001")
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedSiswa, result)
@@ -185,7 +285,7 @@ func TestSelectSiswaById(t *testing.T) {
 		mockRepo.On("SelectById", "999").Return(nil, pgx.ErrNoRows).Once()
 
 		svc := &siswaService{siswaData: mockRepo}
-		result, err := svc.SelectById("999")
+		result, err := svc.SelectById(context.Background(), "999")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -215,8 +315,8 @@ func TestUpdateSiswa(t *testing.T) {
 		mockRepo.On("SelectById", "siswa-001").Return(existingSiswa, nil).Once()
 		mockRepo.On("Update", updatedSiswa, "siswa-001").Return(nil).Once()
 
-		svc := &siswaService{siswaData: mockRepo}
-		err := svc.Update(updatedSiswa, "siswa-001")
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.Update(context.Background(), updatedSiswa, "siswa-001")
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -225,12 +325,114 @@ func TestUpdateSiswa(t *testing.T) {
 	t.Run("failed update siswa - not found", func(t *testing.T) {
 		mockRepo.On("SelectById", "999").Return(nil, pgx.ErrNoRows).Once()
 
-		svc := &siswaService{siswaData: mockRepo}
-		err := svc.Update(&siswa.SiswaCore{}, "999")
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.Update(context.Background(), &siswa.SiswaCore{}, "999")
+
+		assert.Error(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success update siswa - move to another kelas", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		existingSiswa := &siswa.SiswaCore{
+			ID:       "siswa-001",
+			Nama:     "Ahmad Rauf",
+			Kelas_ID: "kelas-001",
+			Email:    "ahmad@example.com",
+			Alamat:   "Jl. Gatot Subroto No. 1",
+		}
+		updatedSiswa := &siswa.SiswaCore{Kelas_ID: "kelas-002"}
+
+		mockRepo.On("SelectById", "siswa-001").Return(existingSiswa, nil).Once()
+		mockRepo.On("LockKelas", "kelas-002").Return(nil).Once()
+		mockRepo.On("Update", updatedSiswa, "siswa-001").Return(nil).Once()
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.Update(context.Background(), updatedSiswa, "siswa-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Ahmad Rauf", updatedSiswa.Nama)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed update siswa - target kelas not found", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		existingSiswa := &siswa.SiswaCore{ID: "siswa-001", Kelas_ID: "kelas-001"}
+		updatedSiswa := &siswa.SiswaCore{Kelas_ID: "kelas-deleted"}
+
+		mockRepo.On("SelectById", "siswa-001").Return(existingSiswa, nil).Once()
+		mockRepo.On("LockKelas", "kelas-deleted").Return(pgx.ErrNoRows).Once()
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.Update(context.Background(), updatedSiswa, "siswa-001")
 
 		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "kelas tujuan tidak ditemukan")
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed update siswa - version conflict", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		existingSiswa := &siswa.SiswaCore{ID: "siswa-001", Kelas_ID: "kelas-001", Version: 3}
+		updatedSiswa := &siswa.SiswaCore{Nama: "Ahmad Rauf Updated", Version: 2}
+
+		mockRepo.On("SelectById", "siswa-001").Return(existingSiswa, nil).Once()
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.Update(context.Background(), updatedSiswa, "siswa-001")
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success update siswa - data induk lama tetap", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		existingSiswa := &siswa.SiswaCore{
+			ID:            "siswa-001",
+			Nama:          "Ahmad Rauf",
+			Kelas_ID:      "kelas-001",
+			NIS:           "2023001",
+			NISN:          "0012345678",
+			Tanggal_Lahir: "2008-04-12",
+			Agama:         "Islam",
+		}
+		updatedSiswa := &siswa.SiswaCore{Nama_Wali: "Rauf Senior", Telepon_Wali: "081200001111"}
+
+		mockRepo.On("SelectById", "siswa-001").Return(existingSiswa, nil).Once()
+		mockRepo.On("Update", updatedSiswa, "siswa-001").Return(nil).Once()
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.Update(context.Background(), updatedSiswa, "siswa-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2023001", updatedSiswa.NIS)
+		assert.Equal(t, "0012345678", updatedSiswa.NISN)
+		assert.Equal(t, "Islam", updatedSiswa.Agama)
+		assert.Equal(t, "Rauf Senior", updatedSiswa.Nama_Wali)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("failed update siswa - tanggal masuk sebelum tanggal lahir lama", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		existingSiswa := &siswa.SiswaCore{ID: "siswa-001", Kelas_ID: "kelas-001", Tanggal_Lahir: "2008-04-12"}
+		updatedSiswa := &siswa.SiswaCore{Tanggal_Masuk: "2001-07-17"}
+
+		mockRepo.On("SelectById", "siswa-001").Return(existingSiswa, nil).Once()
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		err := svc.Update(context.Background(), updatedSiswa, "siswa-001")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "tanggal_masuk")
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed update siswa - nil unit of work", func(t *testing.T) {
+		svc := &siswaService{siswaData: new(mockDataSiswa)}
+		err := svc.Update(context.Background(), &siswa.SiswaCore{}, "siswa-001")
+
+		assert.Error(t, err)
+	})
 }
 
 // Test DeleteSiswaById
@@ -238,22 +440,114 @@ func TestDeleteSiswaById(t *testing.T) {
 	mockRepo := new(mockDataSiswa)
 
 	t.Run("success delete siswa", func(t *testing.T) {
-		mockRepo.On("DeleteById", "siswa-001").Return(nil).Once()
+		mockRepo.On("DeleteById", "siswa-001", 1).Return(nil).Once()
 
 		svc := &siswaService{siswaData: mockRepo}
-		err := svc.DeleteById("siswa-001")
+		err := svc.DeleteById(context.Background(), "siswa-001", 1)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("failed delete siswa - not found", func(t *testing.T) {
-		mockRepo.On("DeleteById", "999").Return(errors.New("data not found")).Once()
+		mockRepo.On("DeleteById", "999", 1).Return(errors.New("data not found")).Once()
 
 		svc := &siswaService{siswaData: mockRepo}
-		err := svc.DeleteById("999")
+		err := svc.DeleteById(context.Background(), "999", 1)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("failed delete siswa - version conflict", func(t *testing.T) {
+		mockRepo.On("DeleteById", "siswa-001", 2).Return(helper.ErrVersionConflict).Once()
+
+		svc := &siswaService{siswaData: mockRepo}
+		err := svc.DeleteById(context.Background(), "siswa-001", 2)
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// Test BulkSiswa
+func TestBulkSiswa(t *testing.T) {
+	ops := []helper.BulkOperation[siswa.SiswaCore]{
+		{Action: helper.BulkActionCreate, Data: siswa.SiswaCore{Nama: "Budi", Email: "budi@example.com", Alamat: "Jl. Merdeka", Kelas_ID: "kelas-001"}},
+		{Action: helper.BulkActionDelete, ID: "siswa-001", Version: 1},
+		{Action: helper.BulkActionDelete, ID: "siswa-002", Version: 2},
+	}
+
+	t.Run("success atomic bulk", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		mockRepo.On("InsertSiswa", mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(0).(*siswa.SiswaCore).ID = "siswa-baru"
+		}).Return(nil).Once()
+		mockRepo.On("DeleteById", "siswa-001", 1).Return(nil).Once()
+		mockRepo.On("DeleteById", "siswa-002", 2).Return(nil).Once()
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModeAtomic, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, result.Berhasil)
+		assert.Equal(t, "siswa-baru", result.Items[0].ID)
+		assert.Equal(t, 201, result.Items[0].Status)
+		assert.Equal(t, 200, result.StatusCode())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed atomic bulk - stops and cancels all operations", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		mockRepo.On("InsertSiswa", mock.Anything).Return(nil).Once()
+		mockRepo.On("DeleteById", "siswa-001", 1).Return(helper.ErrVersionConflict).Once()
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModeAtomic, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, result.Berhasil)
+		assert.Equal(t, 3, result.Gagal)
+		assert.Equal(t, 412, result.Items[1].Status)
+		assert.Equal(t, 424, result.Items[0].Status)
+		assert.Equal(t, 424, result.Items[2].Status)
+		assert.Equal(t, 412, result.StatusCode())
+		mockRepo.AssertNotCalled(t, "DeleteById", "siswa-002", 2)
+	})
+
+	t.Run("partial bulk - failed operation does not cancel others", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		mockRepo.On("InsertSiswa", mock.Anything).Return(nil).Once()
+		mockRepo.On("DeleteById", "siswa-001", 1).Return(pgx.ErrNoRows).Once()
+		mockRepo.On("DeleteById", "siswa-002", 2).Return(nil).Once()
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModePartial, ops)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, result.Berhasil)
+		assert.Equal(t, 1, result.Gagal)
+		assert.Equal(t, 404, result.Items[1].Status)
+		assert.Equal(t, 207, result.StatusCode())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("partial bulk - invalid create is reported per item", func(t *testing.T) {
+		mockRepo := new(mockDataSiswa)
+		invalid := []helper.BulkOperation[siswa.SiswaCore]{{Action: helper.BulkActionCreate, Data: siswa.SiswaCore{Nama: "Tanpa Email", Alamat: "Jl. Merdeka"}}}
+
+		svc := &siswaService{siswaData: mockRepo, uow: fakeUnitOfWork{repo: mockRepo}}
+		result, err := svc.Bulk(context.Background(), helper.BulkModePartial, invalid)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 400, result.Items[0].Status)
+		mockRepo.AssertNotCalled(t, "InsertSiswa", mock.Anything)
+	})
+
+	t.Run("failed bulk - nil unit of work", func(t *testing.T) {
+		svc := &siswaService{siswaData: new(mockDataSiswa)}
+		_, err := svc.Bulk(context.Background(), helper.BulkModeAtomic, ops)
+
+		assert.Error(t, err)
+	})
 }
@@ -0,0 +1,352 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/tugas"
+	"go_rest_native_sekolah/helper"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// multipartOverhead adalah ruang tambahan di atas batas ukuran file untuk field form dan boundary multipart.
+const multipartOverhead = 1 << 20
+
+// TugasController menghandle HTTP request pengelolaan tugas, pengumpulan siswa, dan penilaian.
+type TugasController struct {
+	tugasService tugas.ServiceTugasInterface
+	maxUkuran    int64 // maxUkuran adalah batas ukuran file jawaban dalam byte
+}
+
+// NewTugasController membuat TugasController dengan service tugas dan batas ukuran file jawaban maxUkuran (byte).
+func NewTugasController(service tugas.ServiceTugasInterface, maxUkuran int64) *TugasController {
+	return &TugasController{tugasService: service, maxUkuran: maxUkuran}
+}
+
+// writeTugasError menulis response untuk error dari service tugas.
+// Mengembalikan false jika error tidak dikenali sehingga pemanggil perlu meneruskannya.
+func writeTugasError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, helper.ErrVersionConflict):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case strings.Contains(err.Error(), "validation"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "akses ditolak"):
+		helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, err.Error(), nil))
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+// Tugas menghandle GET /tugas?mata_pelajaran_id=&kelas_id= untuk daftar tugas. Kedua filter opsional.
+func (tc *TugasController) Tugas(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	q := r.URL.Query()
+	result, err := tc.tugasService.GetAll(r.Context(), tugas.FilterTugas{
+		Mata_Pelajaran_ID: q.Get("mata_pelajaran_id"),
+		Kelas_ID:          q.Get("kelas_id"),
+	})
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data tugas", FormatTugasList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// InsertTugas menghandle POST /tugas/tambah. Body JSON berisi mata_pelajaran_id, judul, deskripsi,
+// tenggat, dan lampiran_id (opsional, dari POST /lampiran/upload).
+func (tc *TugasController) InsertTugas(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	var req TugasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data := TugasRequestToCore(req)
+	if err := tc.tugasService.Insert(r.Context(), &data, meta); err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	created, err := tc.tugasService.GetById(r.Context(), data.ID)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil menambah tugas", FormatTugasList([]tugas.TugasCore{*created}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, created.Version)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// GetTugasById menghandle GET /tugas/{id}. Response membawa header ETag untuk update dan delete.
+func (tc *TugasController) GetTugasById(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	data, err := tc.tugasService.GetById(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data tugas", FormatTugasList([]tugas.TugasCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// UpdateTugas menghandle PUT /tugas/{id}. Field yang kosong tetap memakai nilai lama.
+// Header If-Match wajib diisi dengan ETag terbaru.
+func (tc *TugasController) UpdateTugas(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	var req TugasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+	data := TugasRequestToCore(req)
+	data.Version = version
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := tc.tugasService.Update(r.Context(), &data, id, meta); err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	updated, err := tc.tugasService.GetById(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengupdate tugas", FormatTugasList([]tugas.TugasCore{*updated}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updated.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// DeleteTugas menghandle DELETE /tugas/{id}. Header If-Match wajib diisi dengan ETag terbaru.
+func (tc *TugasController) DeleteTugas(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	if err := tc.tugasService.DeleteById(r.Context(), r.PathValue("id"), version, meta); err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil menghapus tugas", nil))
+	return nil
+}
+
+// Pengumpulan menghandle GET /tugas/{id}/pengumpulan untuk semua jawaban siswa pada satu tugas.
+func (tc *TugasController) Pengumpulan(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	result, err := tc.tugasService.GetPengumpulan(r.Context(), r.PathValue("id"), meta)
+	if err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data pengumpulan tugas", FormatPengumpulanList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// Kumpulkan menghandle POST /tugas/{id}/kumpul dengan body multipart/form-data:
+// file (file jawaban) dan catatan (jawaban teks). Minimal salah satu harus diisi.
+func (tc *TugasController) Kumpulkan(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	// Tolak body yang jauh melebihi batas sebelum dibaca seluruhnya
+	r.Body = http.MaxBytesReader(w, r.Body, tc.maxUkuran+multipartOverhead)
+	if err := r.ParseMultipartForm(tc.maxUkuran + multipartOverhead); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, fmt.Sprintf("ukuran file melebihi batas %d byte", tc.maxUkuran), http.StatusRequestEntityTooLarge)
+			return nil
+		}
+		http.Error(w, "gagal membaca form multipart", http.StatusBadRequest)
+		return nil
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var namaFile string
+	var isi []byte
+	file, header, err := r.FormFile("file")
+	switch {
+	case err == nil:
+		defer file.Close()
+		namaFile = header.Filename
+		if isi, err = io.ReadAll(file); err != nil {
+			return fmt.Errorf("gagal membaca file: %v", err)
+		}
+	case errors.Is(err, http.ErrMissingFile):
+		// Pengumpulan tanpa file, hanya catatan
+	default:
+		http.Error(w, "gagal membaca field file", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data := tugas.PengumpulanCore{Catatan: r.FormValue("catatan")}
+	if err := tc.tugasService.Kumpulkan(r.Context(), r.PathValue("id"), &data, namaFile, isi, meta); err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil mengumpulkan tugas", FormatPengumpulanList([]tugas.PengumpulanCore{data}))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// BeriNilai menghandle PUT /tugas/pengumpulan/{id}/nilai. Body JSON berisi nilai (wajib) dan komentar.
+func (tc *TugasController) BeriNilai(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	var req NilaiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+	if req.Nilai == nil {
+		http.Error(w, "validation error: nilai harus diisi", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data, err := tc.tugasService.BeriNilai(r.Context(), r.PathValue("id"), *req.Nilai, req.Komentar, meta)
+	if err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil menilai pengumpulan tugas", FormatPengumpulanList([]tugas.PengumpulanCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// RekapNilai menghandle GET /tugas/rekap-nilai?mata_pelajaran_id= untuk komponen nilai tugas setiap siswa.
+func (tc *TugasController) RekapNilai(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	result, err := tc.tugasService.RekapNilai(r.Context(), r.URL.Query().Get("mata_pelajaran_id"), meta)
+	if err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil rekap nilai tugas", FormatRekapNilaiList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// TugasSaya menghandle GET /me/tugas untuk tugas kelas siswa yang login beserta status pengumpulannya.
+func (tc *TugasController) TugasSaya(w http.ResponseWriter, r *http.Request) error {
+	if tc == nil || tc.tugasService == nil {
+		return errors.New("tugas controller: service is nil")
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	result, err := tc.tugasService.TugasSaya(r.Context(), meta)
+	if err != nil {
+		if writeTugasError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil tugas", FormatTugasList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
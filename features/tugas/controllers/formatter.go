@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"go_rest_native_sekolah/features/tugas"
+	"time"
+)
+
+// TugasFormatter digunakan untuk memformat tugas pada response API.
+type TugasFormatter struct {
+	ID                 string                `json:"id"`                    // ID adalah ID unik tugas
+	Mata_Pelajaran_ID  string                `json:"mata_pelajaran_id"`     // Mata_Pelajaran_ID adalah ID penugasan mata pelajaran
+	Nama_Pelajaran     string                `json:"nama_pelajaran"`        // Nama_Pelajaran adalah nama mapel
+	Kelas_ID           string                `json:"kelas_id"`              // Kelas_ID adalah kelas yang mendapat tugas
+	Nama_Kelas         string                `json:"nama_kelas"`            // Nama_Kelas adalah nama kelas
+	Judul              string                `json:"judul"`                 // Judul adalah judul tugas
+	Deskripsi          string                `json:"deskripsi"`             // Deskripsi adalah petunjuk pengerjaan
+	Tenggat            time.Time             `json:"tenggat"`               // Tenggat adalah batas waktu pengumpulan
+	Lampiran_ID        string                `json:"lampiran_id"`           // Lampiran_ID adalah lampiran soal, kosong jika tidak ada
+	Dibuat_Oleh        string                `json:"dibuat_oleh"`           // Dibuat_Oleh adalah ID user pembuat
+	Jumlah_Pengumpulan int                   `json:"jumlah_pengumpulan"`    // Jumlah_Pengumpulan adalah banyaknya siswa yang sudah mengumpulkan
+	Pengumpulan        *PengumpulanFormatter `json:"pengumpulan,omitempty"` // Pengumpulan adalah pengumpulan siswa yang meminta, hanya pada /me/tugas
+	Update_At          time.Time             `json:"update_at"`             // Update_At adalah waktu perubahan terakhir
+	Version            int                   `json:"version"`               // Version adalah versi data tugas, sama dengan ETag
+}
+
+// TugasRequest digunakan untuk membaca body JSON tambah dan update tugas.
+type TugasRequest struct {
+	Mata_Pelajaran_ID string    `json:"mata_pelajaran_id"`
+	Judul             string    `json:"judul"`
+	Deskripsi         string    `json:"deskripsi"`
+	Tenggat           time.Time `json:"tenggat"`
+	Lampiran_ID       string    `json:"lampiran_id"`
+}
+
+// PengumpulanFormatter digunakan untuk memformat satu pengumpulan tugas.
+type PengumpulanFormatter struct {
+	ID             string     `json:"id"`             // ID adalah ID unik pengumpulan
+	Tugas_ID       string     `json:"tugas_id"`       // Tugas_ID adalah tugas yang dijawab
+	Siswa_ID       string     `json:"siswa_id"`       // Siswa_ID adalah siswa yang mengumpulkan
+	Nama_Siswa     string     `json:"nama_siswa"`     // Nama_Siswa adalah nama siswa
+	Lampiran_ID    string     `json:"lampiran_id"`    // Lampiran_ID adalah file jawaban, kosong jika hanya catatan
+	Catatan        string     `json:"catatan"`        // Catatan adalah jawaban teks siswa
+	Dikumpulkan_At time.Time  `json:"dikumpulkan_at"` // Dikumpulkan_At adalah waktu pengumpulan terakhir
+	Terlambat      bool       `json:"terlambat"`      // Terlambat bernilai true jika dikumpulkan setelah tenggat
+	Nilai          *float64   `json:"nilai"`          // Nilai adalah nilai dari guru, null jika belum dinilai
+	Komentar       string     `json:"komentar"`       // Komentar adalah umpan balik guru
+	Dinilai_Oleh   string     `json:"dinilai_oleh"`   // Dinilai_Oleh adalah ID user penilai
+	Dinilai_At     *time.Time `json:"dinilai_at"`     // Dinilai_At adalah waktu penilaian, null jika belum dinilai
+}
+
+// NilaiRequest digunakan untuk membaca body JSON pemberian nilai.
+// Nilai berupa pointer agar nilai yang tidak dikirim bisa dibedakan dari nilai 0.
+type NilaiRequest struct {
+	Nilai    *float64 `json:"nilai"`
+	Komentar string   `json:"komentar"`
+}
+
+// RekapNilaiFormatter digunakan untuk memformat rekap nilai tugas satu siswa.
+type RekapNilaiFormatter struct {
+	Siswa_ID     string   `json:"siswa_id"`     // Siswa_ID adalah ID siswa
+	Nama_Siswa   string   `json:"nama_siswa"`   // Nama_Siswa adalah nama siswa
+	Jumlah_Tugas int      `json:"jumlah_tugas"` // Jumlah_Tugas adalah banyaknya tugas pada mata pelajaran
+	Dikumpulkan  int      `json:"dikumpulkan"`  // Dikumpulkan adalah banyaknya tugas yang dikumpulkan
+	Terlambat    int      `json:"terlambat"`    // Terlambat adalah banyaknya tugas yang dikumpulkan setelah tenggat
+	Dinilai      int      `json:"dinilai"`      // Dinilai adalah banyaknya pengumpulan yang sudah dinilai
+	Rata_Rata    *float64 `json:"rata_rata"`    // Rata_Rata adalah rata-rata nilai yang sudah diberikan, null jika belum ada
+	Nilai_Tugas  float64  `json:"nilai_tugas"`  // Nilai_Tugas adalah komponen nilai tugas; tugas yang tidak dikumpulkan bernilai 0
+}
+
+// FormatTugasList mengubah slice TugasCore menjadi slice TugasFormatter.
+func FormatTugasList(cores []tugas.TugasCore) []TugasFormatter {
+	formatted := make([]TugasFormatter, 0, len(cores))
+	for _, core := range cores {
+		var pengumpulan *PengumpulanFormatter
+		if core.Pengumpulan != nil {
+			p := FormatPengumpulan(*core.Pengumpulan)
+			pengumpulan = &p
+		}
+		formatted = append(formatted, TugasFormatter{
+			ID:                 core.ID,
+			Mata_Pelajaran_ID:  core.Mata_Pelajaran_ID,
+			Nama_Pelajaran:     core.Nama_Pelajaran,
+			Kelas_ID:           core.Kelas_ID,
+			Nama_Kelas:         core.Nama_Kelas,
+			Judul:              core.Judul,
+			Deskripsi:          core.Deskripsi,
+			Tenggat:            core.Tenggat,
+			Lampiran_ID:        core.Lampiran_ID,
+			Dibuat_Oleh:        core.Dibuat_Oleh,
+			Jumlah_Pengumpulan: core.Jumlah_Pengumpulan,
+			Pengumpulan:        pengumpulan,
+			Update_At:          core.Update_At,
+			Version:            core.Version,
+		})
+	}
+	return formatted
+}
+
+// FormatPengumpulan mengubah PengumpulanCore menjadi PengumpulanFormatter.
+func FormatPengumpulan(core tugas.PengumpulanCore) PengumpulanFormatter {
+	return PengumpulanFormatter{
+		ID:             core.ID,
+		Tugas_ID:       core.Tugas_ID,
+		Siswa_ID:       core.Siswa_ID,
+		Nama_Siswa:     core.Nama_Siswa,
+		Lampiran_ID:    core.Lampiran_ID,
+		Catatan:        core.Catatan,
+		Dikumpulkan_At: core.Dikumpulkan_At,
+		Terlambat:      core.Terlambat,
+		Nilai:          core.Nilai,
+		Komentar:       core.Komentar,
+		Dinilai_Oleh:   core.Dinilai_Oleh,
+		Dinilai_At:     core.Dinilai_At,
+	}
+}
+
+// FormatPengumpulanList mengubah slice PengumpulanCore menjadi slice PengumpulanFormatter.
+func FormatPengumpulanList(cores []tugas.PengumpulanCore) []PengumpulanFormatter {
+	formatted := make([]PengumpulanFormatter, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, FormatPengumpulan(core))
+	}
+	return formatted
+}
+
+// FormatRekapNilaiList mengubah slice RekapNilaiCore menjadi slice RekapNilaiFormatter.
+func FormatRekapNilaiList(cores []tugas.RekapNilaiCore) []RekapNilaiFormatter {
+	formatted := make([]RekapNilaiFormatter, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, RekapNilaiFormatter{
+			Siswa_ID:     core.Siswa_ID,
+			Nama_Siswa:   core.Nama_Siswa,
+			Jumlah_Tugas: core.Jumlah_Tugas,
+			Dikumpulkan:  core.Dikumpulkan,
+			Terlambat:    core.Terlambat,
+			Dinilai:      core.Dinilai,
+			Rata_Rata:    core.Rata_Rata,
+			Nilai_Tugas:  core.Nilai_Tugas,
+		})
+	}
+	return formatted
+}
+
+// TugasRequestToCore mengubah TugasRequest menjadi TugasCore.
+func TugasRequestToCore(req TugasRequest) tugas.TugasCore {
+	return tugas.TugasCore{
+		Mata_Pelajaran_ID: req.Mata_Pelajaran_ID,
+		Judul:             req.Judul,
+		Deskripsi:         req.Deskripsi,
+		Tenggat:           req.Tenggat,
+		Lampiran_ID:       req.Lampiran_ID,
+	}
+}
@@ -0,0 +1,116 @@
+package tugas
+
+import (
+	"context"
+	"go_rest_native_sekolah/helper"
+	"time"
+)
+
+// PengelolaRoles adalah role yang boleh membuat, mengubah, menghapus, dan menilai tugas.
+// Guru hanya boleh mengelola tugas untuk mata pelajaran yang ia ajar.
+var PengelolaRoles = []string{"admin", "guru"}
+
+// Batas nilai pengumpulan tugas.
+const (
+	NilaiMin = 0
+	NilaiMax = 100
+)
+
+type (
+	// TugasCore merepresentasikan satu tugas untuk satu penugasan mata pelajaran.
+	// Kelas tugas mengikuti kelas mata pelajaran sehingga tidak diisi client.
+	TugasCore struct {
+		ID                 string           `json:"id"`                    // ID adalah identifikasi unik tugas.
+		Mata_Pelajaran_ID  string           `json:"mata_pelajaran_id"`     // Mata_Pelajaran_ID adalah ID penugasan mata pelajaran.
+		Nama_Pelajaran     string           `json:"nama_pelajaran"`        // Nama_Pelajaran adalah nama mapel di katalog.
+		Kelas_ID           string           `json:"kelas_id"`              // Kelas_ID adalah kelas mata pelajaran.
+		Nama_Kelas         string           `json:"nama_kelas"`            // Nama_Kelas adalah nama kelas mata pelajaran.
+		Judul              string           `json:"judul"`                 // Judul adalah judul tugas.
+		Deskripsi          string           `json:"deskripsi"`             // Deskripsi adalah petunjuk pengerjaan tugas.
+		Tenggat            time.Time        `json:"tenggat"`               // Tenggat adalah batas waktu pengumpulan.
+		Lampiran_ID        string           `json:"lampiran_id"`           // Lampiran_ID adalah lampiran soal tugas, kosong jika tidak ada.
+		Dibuat_Oleh        string           `json:"dibuat_oleh"`           // Dibuat_Oleh adalah ID user pembuat tugas.
+		Jumlah_Pengumpulan int              `json:"jumlah_pengumpulan"`    // Jumlah_Pengumpulan adalah banyaknya siswa yang sudah mengumpulkan.
+		Pengumpulan        *PengumpulanCore `json:"pengumpulan,omitempty"` // Pengumpulan adalah pengumpulan milik siswa yang meminta, hanya pada daftar tugas siswa.
+		Update_At          time.Time        `json:"update_at"`             // Update_At adalah waktu perubahan terakhir.
+		Version            int              `json:"version"`               // Version adalah versi data untuk optimistic concurrency, dikirim sebagai ETag.
+	}
+
+	// PengumpulanCore adalah jawaban satu siswa untuk satu tugas.
+	// Siswa boleh mengumpulkan ulang selama jawabannya belum dinilai.
+	PengumpulanCore struct {
+		ID             string     `json:"id"`             // ID adalah identifikasi unik pengumpulan.
+		Tugas_ID       string     `json:"tugas_id"`       // Tugas_ID adalah tugas yang dijawab.
+		Siswa_ID       string     `json:"siswa_id"`       // Siswa_ID adalah siswa yang mengumpulkan.
+		Nama_Siswa     string     `json:"nama_siswa"`     // Nama_Siswa adalah nama siswa yang mengumpulkan.
+		Lampiran_ID    string     `json:"lampiran_id"`    // Lampiran_ID adalah file jawaban, kosong jika hanya berupa catatan.
+		Catatan        string     `json:"catatan"`        // Catatan adalah jawaban atau keterangan teks dari siswa.
+		Dikumpulkan_At time.Time  `json:"dikumpulkan_at"` // Dikumpulkan_At adalah waktu pengumpulan terakhir.
+		Terlambat      bool       `json:"terlambat"`      // Terlambat bernilai true jika dikumpulkan setelah tenggat.
+		Nilai          *float64   `json:"nilai"`          // Nilai adalah nilai dari guru antara NilaiMin dan NilaiMax, nil jika belum dinilai.
+		Komentar       string     `json:"komentar"`       // Komentar adalah umpan balik guru.
+		Dinilai_Oleh   string     `json:"dinilai_oleh"`   // Dinilai_Oleh adalah ID user yang memberi nilai.
+		Dinilai_At     *time.Time `json:"dinilai_at"`     // Dinilai_At adalah waktu pemberian nilai terakhir.
+	}
+
+	// RekapNilaiCore adalah ringkasan nilai tugas satu siswa pada satu mata pelajaran,
+	// dipakai sebagai komponen nilai tugas dalam penilaian.
+	RekapNilaiCore struct {
+		Siswa_ID     string   `json:"siswa_id"`     // Siswa_ID adalah ID siswa.
+		Nama_Siswa   string   `json:"nama_siswa"`   // Nama_Siswa adalah nama siswa.
+		Jumlah_Tugas int      `json:"jumlah_tugas"` // Jumlah_Tugas adalah banyaknya tugas aktif pada mata pelajaran.
+		Dikumpulkan  int      `json:"dikumpulkan"`  // Dikumpulkan adalah banyaknya tugas yang dikumpulkan siswa.
+		Terlambat    int      `json:"terlambat"`    // Terlambat adalah banyaknya tugas yang dikumpulkan setelah tenggat.
+		Dinilai      int      `json:"dinilai"`      // Dinilai adalah banyaknya pengumpulan yang sudah dinilai.
+		Rata_Rata    *float64 `json:"rata_rata"`    // Rata_Rata adalah rata-rata nilai pengumpulan yang sudah dinilai, nil jika belum ada.
+		Nilai_Tugas  float64  `json:"nilai_tugas"`  // Nilai_Tugas adalah total nilai dibagi Jumlah_Tugas; tugas yang tidak dikumpulkan bernilai 0.
+	}
+
+	// FilterTugas berisi filter opsional daftar tugas. Field kosong berarti tidak difilter.
+	FilterTugas struct {
+		Mata_Pelajaran_ID string
+		Kelas_ID          string
+	}
+
+	// SiswaPengumpul adalah data siswa milik user yang sedang login.
+	SiswaPengumpul struct {
+		ID       string
+		Kelas_ID string
+	}
+
+	// DataTugasInterface mendefinisikan operasi tabel tugas dan pengumpulan_tugas.
+	DataTugasInterface interface {
+		SelectAll(ctx context.Context, filter FilterTugas) ([]TugasCore, error)                        // Mengambil tugas aktif sesuai filter.
+		SelectById(ctx context.Context, id string) (*TugasCore, error)                                 // Mengambil tugas aktif berdasarkan ID, pgx.ErrNoRows jika tidak ada.
+		Insert(ctx context.Context, insert *TugasCore) error                                           // Menyimpan tugas baru.
+		Update(ctx context.Context, update *TugasCore, id string) error                                // Mengubah tugas jika versinya masih sama dengan update.Version.
+		DeleteById(ctx context.Context, id string, version int) error                                  // Menghapus (soft delete) tugas jika versinya masih sama dengan version.
+		KelasMataPelajaran(ctx context.Context, mataPelajaranID string) (string, error)                // Mengambil kelas mata pelajaran aktif, pgx.ErrNoRows jika tidak ada.
+		GuruMengajar(ctx context.Context, userID, mataPelajaranID string) (bool, error)                // Memeriksa apakah user adalah guru pengajar mata pelajaran.
+		SelectSiswaByUser(ctx context.Context, userID string) (*SiswaPengumpul, error)                 // Mengambil siswa milik user, pgx.ErrNoRows jika user bukan siswa.
+		SelectTugasSiswa(ctx context.Context, siswaID, kelasID string) ([]TugasCore, error)            // Mengambil tugas kelas siswa beserta pengumpulan siswa tersebut.
+		SelectPengumpulan(ctx context.Context, tugasID string) ([]PengumpulanCore, error)              // Mengambil semua pengumpulan untuk satu tugas.
+		SelectPengumpulanById(ctx context.Context, id string) (*PengumpulanCore, error)                // Mengambil satu pengumpulan, pgx.ErrNoRows jika tidak ada.
+		SelectPengumpulanSiswa(ctx context.Context, tugasID, siswaID string) (*PengumpulanCore, error) // Mengambil pengumpulan siswa untuk tugas, pgx.ErrNoRows jika belum ada.
+		SimpanPengumpulan(ctx context.Context, p *PengumpulanCore) error                               // Menyimpan pengumpulan baru atau mengganti pengumpulan siswa yang belum dinilai.
+		SimpanNilai(ctx context.Context, id string, nilai float64, komentar, dinilaiOleh string) error // Menyimpan nilai dan komentar guru untuk pengumpulan.
+		RekapNilai(ctx context.Context, mataPelajaranID string) ([]RekapNilaiCore, error)              // Mengambil rekap nilai tugas setiap siswa di kelas mata pelajaran.
+	}
+
+	// ServiceTugasInterface mendefinisikan logika bisnis tugas, pengumpulan, dan penilaian.
+	// Parameter pengguna adalah user yang sedang login.
+	ServiceTugasInterface interface {
+		GetAll(ctx context.Context, filter FilterTugas) ([]TugasCore, error)                                      // Mengambil daftar tugas.
+		GetById(ctx context.Context, id string) (*TugasCore, error)                                               // Mengambil satu tugas.
+		Insert(ctx context.Context, insert *TugasCore, pengguna helper.MetaToken) error                           // Memvalidasi dan menyimpan tugas baru.
+		Update(ctx context.Context, update *TugasCore, id string, pengguna helper.MetaToken) error                // Mengubah tugas; field kosong memakai nilai lama.
+		DeleteById(ctx context.Context, id string, version int, pengguna helper.MetaToken) error                  // Menghapus tugas.
+		TugasSaya(ctx context.Context, pengguna helper.MetaToken) ([]TugasCore, error)                            // Mengambil tugas kelas siswa yang sedang login beserta pengumpulannya.
+		GetPengumpulan(ctx context.Context, tugasID string, pengguna helper.MetaToken) ([]PengumpulanCore, error) // Mengambil semua pengumpulan untuk satu tugas.
+		// Kumpulkan menyimpan jawaban siswa yang sedang login. File jawaban (namaFile dan isi) disimpan
+		// sebagai lampiran; isi boleh kosong jika kumpul.Catatan diisi.
+		Kumpulkan(ctx context.Context, tugasID string, kumpul *PengumpulanCore, namaFile string, isi []byte, pengguna helper.MetaToken) error
+		BeriNilai(ctx context.Context, pengumpulanID string, nilai float64, komentar string, pengguna helper.MetaToken) (*PengumpulanCore, error) // Menilai satu pengumpulan.
+		RekapNilai(ctx context.Context, mataPelajaranID string, pengguna helper.MetaToken) ([]RekapNilaiCore, error)                              // Mengambil rekap nilai tugas per siswa.
+	}
+)
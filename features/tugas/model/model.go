@@ -0,0 +1,101 @@
+package model
+
+import (
+	"go_rest_native_sekolah/features/tugas"
+	"time"
+)
+
+// Tugas merepresentasikan satu baris tabel tugas beserta nama mapel dan kelasnya.
+type Tugas struct {
+	ID                 string     `json:"id"`                 // ID adalah identifikasi unik tugas.
+	Mata_Pelajaran_ID  string     `json:"mata_pelajaran_id"`  // Mata_Pelajaran_ID adalah ID penugasan mata pelajaran.
+	Nama_Pelajaran     string     `json:"nama_pelajaran"`     // Nama_Pelajaran diambil dari tabel mapel.
+	Kelas_ID           string     `json:"kelas_id"`           // Kelas_ID diambil dari tabel mata_pelajaran.
+	Nama_Kelas         string     `json:"nama_kelas"`         // Nama_Kelas diambil dari tabel kelas.
+	Judul              string     `json:"judul"`              // Judul adalah judul tugas.
+	Deskripsi          string     `json:"deskripsi"`          // Deskripsi adalah petunjuk pengerjaan.
+	Tenggat            time.Time  `json:"tenggat"`            // Tenggat adalah batas waktu pengumpulan.
+	Lampiran_ID        string     `json:"lampiran_id"`        // Lampiran_ID adalah lampiran soal, jika ada.
+	Dibuat_Oleh        string     `json:"dibuat_oleh"`        // Dibuat_Oleh adalah ID user pembuat.
+	Jumlah_Pengumpulan int        `json:"jumlah_pengumpulan"` // Jumlah_Pengumpulan adalah jumlah baris pengumpulan_tugas.
+	Update_At          time.Time  `json:"update_at"`          // Update_At adalah waktu perubahan terakhir.
+	Delete_At          *time.Time `json:"delete_at"`          // Delete_At adalah waktu tugas dihapus, jika ada.
+	Version            int        `json:"version"`            // Version adalah versi data untuk optimistic concurrency.
+}
+
+// TableName mengembalikan nama tabel tugas di database.
+func (t *Tugas) TableName() string {
+	return "tugas"
+}
+
+// PengumpulanTugas merepresentasikan satu baris tabel pengumpulan_tugas.
+type PengumpulanTugas struct {
+	ID             string     `json:"id"`             // ID adalah identifikasi unik pengumpulan.
+	Tugas_ID       string     `json:"tugas_id"`       // Tugas_ID adalah tugas yang dijawab.
+	Siswa_ID       string     `json:"siswa_id"`       // Siswa_ID adalah siswa yang mengumpulkan.
+	Nama_Siswa     string     `json:"nama_siswa"`     // Nama_Siswa diambil dari tabel siswa.
+	Lampiran_ID    string     `json:"lampiran_id"`    // Lampiran_ID adalah file jawaban, jika ada.
+	Catatan        string     `json:"catatan"`        // Catatan adalah jawaban teks.
+	Dikumpulkan_At time.Time  `json:"dikumpulkan_at"` // Dikumpulkan_At adalah waktu pengumpulan terakhir.
+	Terlambat      bool       `json:"terlambat"`      // Terlambat menandai pengumpulan setelah tenggat.
+	Nilai          *float64   `json:"nilai"`          // Nilai adalah nilai dari guru, jika sudah dinilai.
+	Komentar       string     `json:"komentar"`       // Komentar adalah umpan balik guru.
+	Dinilai_Oleh   string     `json:"dinilai_oleh"`   // Dinilai_Oleh adalah ID user penilai.
+	Dinilai_At     *time.Time `json:"dinilai_at"`     // Dinilai_At adalah waktu penilaian.
+}
+
+// TableName mengembalikan nama tabel pengumpulan tugas di database.
+func (p *PengumpulanTugas) TableName() string {
+	return "pengumpulan_tugas"
+}
+
+// FormatterRequest mengubah TugasCore menjadi Tugas untuk disimpan ke database.
+func FormatterRequest(req tugas.TugasCore) Tugas {
+	return Tugas{
+		ID:                req.ID,
+		Mata_Pelajaran_ID: req.Mata_Pelajaran_ID,
+		Judul:             req.Judul,
+		Deskripsi:         req.Deskripsi,
+		Tenggat:           req.Tenggat,
+		Lampiran_ID:       req.Lampiran_ID,
+		Dibuat_Oleh:       req.Dibuat_Oleh,
+		Version:           req.Version,
+	}
+}
+
+// FormatterResponse mengubah Tugas dari database menjadi TugasCore.
+func FormatterResponse(res Tugas) tugas.TugasCore {
+	return tugas.TugasCore{
+		ID:                 res.ID,
+		Mata_Pelajaran_ID:  res.Mata_Pelajaran_ID,
+		Nama_Pelajaran:     res.Nama_Pelajaran,
+		Kelas_ID:           res.Kelas_ID,
+		Nama_Kelas:         res.Nama_Kelas,
+		Judul:              res.Judul,
+		Deskripsi:          res.Deskripsi,
+		Tenggat:            res.Tenggat,
+		Lampiran_ID:        res.Lampiran_ID,
+		Dibuat_Oleh:        res.Dibuat_Oleh,
+		Jumlah_Pengumpulan: res.Jumlah_Pengumpulan,
+		Update_At:          res.Update_At,
+		Version:            res.Version,
+	}
+}
+
+// FormatterPengumpulanResponse mengubah PengumpulanTugas dari database menjadi PengumpulanCore.
+func FormatterPengumpulanResponse(res PengumpulanTugas) tugas.PengumpulanCore {
+	return tugas.PengumpulanCore{
+		ID:             res.ID,
+		Tugas_ID:       res.Tugas_ID,
+		Siswa_ID:       res.Siswa_ID,
+		Nama_Siswa:     res.Nama_Siswa,
+		Lampiran_ID:    res.Lampiran_ID,
+		Catatan:        res.Catatan,
+		Dikumpulkan_At: res.Dikumpulkan_At,
+		Terlambat:      res.Terlambat,
+		Nilai:          res.Nilai,
+		Komentar:       res.Komentar,
+		Dinilai_Oleh:   res.Dinilai_Oleh,
+		Dinilai_At:     res.Dinilai_At,
+	}
+}
@@ -0,0 +1,378 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/tugas"
+	"go_rest_native_sekolah/helper"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// tugasQuery menghandle query ke tabel tugas dan pengumpulan_tugas.
+type tugasQuery struct {
+	db helper.DBTX
+}
+
+// NewDataTugas membuat objek tugasQuery dengan parameter db.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
+// Jika parameter db nil maka akan terjadi panic.
+func NewDataTugas(db helper.DBTX) tugas.DataTugasInterface {
+	if db == nil {
+		panic("tugas model: Nil database")
+	}
+	return &tugasQuery{db: db}
+}
+
+// kolomTugas adalah daftar kolom yang diambil untuk setiap tugas, sesuai urutan scanTugas.
+// Query yang memakainya harus memakai dariTugas sebagai klausa FROM.
+const kolomTugas = `t.id, t.mata_pelajaran_id, COALESCE(m.nama, ''), COALESCE(mp.kelas_id, ''), COALESCE(k.kelas, ''),
+	t.judul, COALESCE(t.deskripsi, ''), t.tenggat, COALESCE(t.lampiran_id, ''), COALESCE(t.dibuat_oleh, ''),
+	(SELECT COUNT(*) FROM pengumpulan_tugas pt WHERE pt.tugas_id = t.id),
+	t.update_at, t.version`
+
+// dariTugas menggabungkan tugas dengan penugasan mata pelajaran, katalog mapel, dan kelasnya.
+const dariTugas = ` FROM tugas t
+	JOIN mata_pelajaran mp ON mp.id = t.mata_pelajaran_id
+	LEFT JOIN mapel m ON m.id = mp.mapel_id
+	LEFT JOIN kelas k ON k.id = mp.kelas_id
+	WHERE t.delete_at IS NULL`
+
+// scanTugas membaca satu baris hasil query dengan kolom kolomTugas ke dalam dst.
+func scanTugas(row pgx.Row, dst *Tugas) error {
+	return row.Scan(&dst.ID, &dst.Mata_Pelajaran_ID, &dst.Nama_Pelajaran, &dst.Kelas_ID, &dst.Nama_Kelas,
+		&dst.Judul, &dst.Deskripsi, &dst.Tenggat, &dst.Lampiran_ID, &dst.Dibuat_Oleh, &dst.Jumlah_Pengumpulan,
+		&dst.Update_At, &dst.Version)
+}
+
+// selectTugas menjalankan query tugas dan mengubah semua barisnya menjadi TugasCore.
+func (q *tugasQuery) selectTugas(ctx context.Context, query string, args ...any) ([]tugas.TugasCore, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectTugas error query", "error", err)
+		return nil, fmt.Errorf("select tugas failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []tugas.TugasCore{}
+	for rows.Next() {
+		var data Tugas
+		if err := scanTugas(rows, &data); err != nil {
+			return nil, fmt.Errorf("select tugas failed: %w", err)
+		}
+		result = append(result, FormatterResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select tugas failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectAll implements tugas.DataTugasInterface.
+// Tugas diurutkan dari tenggat terdekat.
+func (q *tugasQuery) SelectAll(ctx context.Context, filter tugas.FilterTugas) ([]tugas.TugasCore, error) {
+	return q.selectTugas(ctx, "SELECT "+kolomTugas+dariTugas+`
+		AND ($1 = '' OR t.mata_pelajaran_id = $1)
+		AND ($2 = '' OR mp.kelas_id = $2)
+		ORDER BY t.tenggat, t.judul`, filter.Mata_Pelajaran_ID, filter.Kelas_ID)
+}
+
+// SelectById implements tugas.DataTugasInterface.
+func (q *tugasQuery) SelectById(ctx context.Context, id string) (*tugas.TugasCore, error) {
+	result, err := q.selectTugas(ctx, "SELECT "+kolomTugas+dariTugas+" AND t.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// Insert implements tugas.DataTugasInterface.
+// ID dibuat otomatis jika kosong; Update_At dan Version diisi dari database.
+func (q *tugasQuery) Insert(ctx context.Context, insert *tugas.TugasCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	data := FormatterRequest(*insert)
+	query := `INSERT INTO tugas (id, mata_pelajaran_id, judul, deskripsi, tenggat, lampiran_id, dibuat_oleh)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''))
+		RETURNING update_at, version`
+	err := q.db.QueryRow(ctx, query, data.ID, data.Mata_Pelajaran_ID, data.Judul, data.Deskripsi, data.Tenggat,
+		data.Lampiran_ID, data.Dibuat_Oleh).Scan(&insert.Update_At, &insert.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert tugas error", "error", err)
+		return fmt.Errorf("insert tugas failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully inserted tugas", "id", insert.ID)
+	return nil
+}
+
+// Update implements tugas.DataTugasInterface.
+// Mengembalikan helper.ErrVersionConflict jika versinya sudah berubah dan pgx.ErrNoRows jika tugas tidak ada.
+func (q *tugasQuery) Update(ctx context.Context, update *tugas.TugasCore, id string) error {
+	if update == nil {
+		return errors.New("update data is nil")
+	}
+
+	data := FormatterRequest(*update)
+	query := `UPDATE tugas
+		SET mata_pelajaran_id = $1, judul = $2, deskripsi = $3, tenggat = $4, lampiran_id = NULLIF($5, ''),
+			update_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $6 AND delete_at IS NULL AND version = $7`
+	tag, err := q.db.Exec(ctx, query, data.Mata_Pelajaran_ID, data.Judul, data.Deskripsi, data.Tenggat,
+		data.Lampiran_ID, id, data.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Update tugas error", "error", err)
+		return fmt.Errorf("update tugas failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "tugas", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully updated tugas", "id", id)
+	return nil
+}
+
+// DeleteById implements tugas.DataTugasInterface.
+// Tugas hanya ditandai terhapus (soft delete); pengumpulan dan nilainya tetap disimpan.
+func (q *tugasQuery) DeleteById(ctx context.Context, id string, version int) error {
+	tag, err := q.db.Exec(ctx,
+		"UPDATE tugas SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2",
+		id, version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete tugas error", "error", err)
+		return fmt.Errorf("delete tugas failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "tugas", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted tugas", "id", id)
+	return nil
+}
+
+// KelasMataPelajaran implements tugas.DataTugasInterface.
+// Mengembalikan string kosong jika mata pelajaran belum punya kelas.
+func (q *tugasQuery) KelasMataPelajaran(ctx context.Context, mataPelajaranID string) (string, error) {
+	var kelasID string
+	err := q.db.QueryRow(ctx,
+		"SELECT COALESCE(kelas_id, '') FROM mata_pelajaran WHERE id = $1 AND delete_at IS NULL",
+		mataPelajaranID).Scan(&kelasID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", pgx.ErrNoRows
+		}
+		helper.LoggerFromContext(ctx).Error("KelasMataPelajaran error query", "error", err)
+		return "", fmt.Errorf("select mata pelajaran failed: %w", err)
+	}
+	return kelasID, nil
+}
+
+// GuruMengajar implements tugas.DataTugasInterface.
+// Guru utama maupun guru pendamping dianggap mengajar.
+func (q *tugasQuery) GuruMengajar(ctx context.Context, userID, mataPelajaranID string) (bool, error) {
+	var mengajar bool
+	err := q.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM mata_pelajaran_guru mpg
+		JOIN guru g ON g.id = mpg.id_guru
+		WHERE mpg.mata_pelajaran_id = $1 AND g.id_user = $2 AND g.delete_at IS NULL)`,
+		mataPelajaranID, userID).Scan(&mengajar)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("GuruMengajar error query", "error", err)
+		return false, fmt.Errorf("cek guru pengajar failed: %w", err)
+	}
+	return mengajar, nil
+}
+
+// SelectSiswaByUser implements tugas.DataTugasInterface.
+// Siswa dikenali dari email user yang sama dengan email siswa aktif.
+func (q *tugasQuery) SelectSiswaByUser(ctx context.Context, userID string) (*tugas.SiswaPengumpul, error) {
+	var siswa tugas.SiswaPengumpul
+	err := q.db.QueryRow(ctx, `SELECT s.id, COALESCE(s.kelas_id, '')
+		FROM siswa s JOIN users u ON LOWER(u.email) = LOWER(s.email)
+		WHERE u.id = $1 AND u.delete_at IS NULL AND s.delete_at IS NULL
+		LIMIT 1`, userID).Scan(&siswa.ID, &siswa.Kelas_ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pgx.ErrNoRows
+		}
+		helper.LoggerFromContext(ctx).Error("SelectSiswaByUser error query", "error", err)
+		return nil, fmt.Errorf("select siswa failed: %w", err)
+	}
+	return &siswa, nil
+}
+
+// SelectTugasSiswa implements tugas.DataTugasInterface.
+// Tugas diurutkan dari tenggat terdekat; Pengumpulan nil jika siswa belum mengumpulkan.
+func (q *tugasQuery) SelectTugasSiswa(ctx context.Context, siswaID, kelasID string) ([]tugas.TugasCore, error) {
+	result, err := q.selectTugas(ctx, "SELECT "+kolomTugas+dariTugas+`
+		AND mp.kelas_id = $1 AND mp.delete_at IS NULL
+		ORDER BY t.tenggat, t.judul`, kelasID)
+	if err != nil {
+		return nil, err
+	}
+
+	pengumpulan, err := q.selectPengumpulan(ctx, `pt.siswa_id = $1 AND pt.tugas_id IN (
+		SELECT t.id FROM tugas t JOIN mata_pelajaran mp ON mp.id = t.mata_pelajaran_id
+		WHERE t.delete_at IS NULL AND mp.kelas_id = $2)`, siswaID, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	milikSiswa := make(map[string]*tugas.PengumpulanCore, len(pengumpulan))
+	for i := range pengumpulan {
+		milikSiswa[pengumpulan[i].Tugas_ID] = &pengumpulan[i]
+	}
+	for i := range result {
+		result[i].Pengumpulan = milikSiswa[result[i].ID]
+	}
+	return result, nil
+}
+
+// kolomPengumpulan adalah daftar kolom yang diambil untuk setiap pengumpulan, sesuai urutan scanPengumpulan.
+const kolomPengumpulan = `pt.id, pt.tugas_id, pt.siswa_id, COALESCE(s.nama, ''), COALESCE(pt.lampiran_id, ''),
+	COALESCE(pt.catatan, ''), pt.dikumpulkan_at, pt.terlambat, pt.nilai::float8, COALESCE(pt.komentar, ''),
+	COALESCE(pt.dinilai_oleh, ''), pt.dinilai_at`
+
+// selectPengumpulan mengambil pengumpulan yang memenuhi kondisi where, diurutkan berdasarkan nama siswa.
+// Parameter where adalah kondisi SQL tetap dengan placeholder untuk args, bukan input client.
+func (q *tugasQuery) selectPengumpulan(ctx context.Context, where string, args ...any) ([]tugas.PengumpulanCore, error) {
+	rows, err := q.db.Query(ctx, "SELECT "+kolomPengumpulan+`
+		FROM pengumpulan_tugas pt LEFT JOIN siswa s ON s.id = pt.siswa_id
+		WHERE `+where+`
+		ORDER BY s.nama, pt.dikumpulkan_at`, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectPengumpulan error query", "error", err)
+		return nil, fmt.Errorf("select pengumpulan failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []tugas.PengumpulanCore{}
+	for rows.Next() {
+		var data PengumpulanTugas
+		err := rows.Scan(&data.ID, &data.Tugas_ID, &data.Siswa_ID, &data.Nama_Siswa, &data.Lampiran_ID, &data.Catatan,
+			&data.Dikumpulkan_At, &data.Terlambat, &data.Nilai, &data.Komentar, &data.Dinilai_Oleh, &data.Dinilai_At)
+		if err != nil {
+			return nil, fmt.Errorf("select pengumpulan failed: %w", err)
+		}
+		result = append(result, FormatterPengumpulanResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select pengumpulan failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectPengumpulan implements tugas.DataTugasInterface.
+func (q *tugasQuery) SelectPengumpulan(ctx context.Context, tugasID string) ([]tugas.PengumpulanCore, error) {
+	return q.selectPengumpulan(ctx, "pt.tugas_id = $1", tugasID)
+}
+
+// SelectPengumpulanById implements tugas.DataTugasInterface.
+func (q *tugasQuery) SelectPengumpulanById(ctx context.Context, id string) (*tugas.PengumpulanCore, error) {
+	result, err := q.selectPengumpulan(ctx, "pt.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// SelectPengumpulanSiswa implements tugas.DataTugasInterface.
+func (q *tugasQuery) SelectPengumpulanSiswa(ctx context.Context, tugasID, siswaID string) (*tugas.PengumpulanCore, error) {
+	result, err := q.selectPengumpulan(ctx, "pt.tugas_id = $1 AND pt.siswa_id = $2", tugasID, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// SimpanPengumpulan implements tugas.DataTugasInterface.
+// Pengumpulan siswa untuk tugas yang sama diganti selama belum dinilai; ID pengumpulan lama tetap dipakai.
+// Mengembalikan pgx.ErrNoRows jika pengumpulan lama sudah dinilai.
+func (q *tugasQuery) SimpanPengumpulan(ctx context.Context, p *tugas.PengumpulanCore) error {
+	if p == nil {
+		return errors.New("pengumpulan data is nil")
+	}
+	if p.ID == "" {
+		p.ID = uuid.New().String()
+	}
+
+	query := `INSERT INTO pengumpulan_tugas (id, tugas_id, siswa_id, lampiran_id, catatan, dikumpulkan_at, terlambat)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7)
+		ON CONFLICT (tugas_id, siswa_id) DO UPDATE
+		SET lampiran_id = EXCLUDED.lampiran_id, catatan = EXCLUDED.catatan,
+			dikumpulkan_at = EXCLUDED.dikumpulkan_at, terlambat = EXCLUDED.terlambat
+		WHERE pengumpulan_tugas.nilai IS NULL
+		RETURNING id`
+	err := q.db.QueryRow(ctx, query, p.ID, p.Tugas_ID, p.Siswa_ID, p.Lampiran_ID, p.Catatan,
+		p.Dikumpulkan_At, p.Terlambat).Scan(&p.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pgx.ErrNoRows
+		}
+		helper.LoggerFromContext(ctx).Error("SimpanPengumpulan error", "error", err)
+		return fmt.Errorf("simpan pengumpulan failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully saved pengumpulan tugas", "id", p.ID, "tugas_id", p.Tugas_ID)
+	return nil
+}
+
+// SimpanNilai implements tugas.DataTugasInterface.
+// Nilai yang sudah ada ditimpa sehingga guru bisa memperbaiki nilai.
+func (q *tugasQuery) SimpanNilai(ctx context.Context, id string, nilai float64, komentar, dinilaiOleh string) error {
+	tag, err := q.db.Exec(ctx, `UPDATE pengumpulan_tugas
+		SET nilai = $1, komentar = NULLIF($2, ''), dinilai_oleh = NULLIF($3, ''), dinilai_at = CURRENT_TIMESTAMP
+		WHERE id = $4`, nilai, komentar, dinilaiOleh, id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SimpanNilai error", "error", err)
+		return fmt.Errorf("simpan nilai failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// RekapNilai implements tugas.DataTugasInterface.
+// Semua siswa aktif di kelas mata pelajaran ikut ditampilkan, termasuk yang belum mengumpulkan.
+func (q *tugasQuery) RekapNilai(ctx context.Context, mataPelajaranID string) ([]tugas.RekapNilaiCore, error) {
+	rows, err := q.db.Query(ctx, `SELECT s.id, s.nama,
+			(SELECT COUNT(*) FROM tugas x WHERE x.mata_pelajaran_id = mp.id AND x.delete_at IS NULL),
+			COUNT(pt.id), COUNT(pt.id) FILTER (WHERE pt.terlambat), COUNT(pt.nilai),
+			AVG(pt.nilai)::float8, COALESCE(SUM(pt.nilai), 0)::float8
+		FROM mata_pelajaran mp
+		JOIN siswa s ON s.kelas_id = mp.kelas_id AND s.delete_at IS NULL
+		LEFT JOIN tugas t ON t.mata_pelajaran_id = mp.id AND t.delete_at IS NULL
+		LEFT JOIN pengumpulan_tugas pt ON pt.tugas_id = t.id AND pt.siswa_id = s.id
+		WHERE mp.id = $1 AND mp.delete_at IS NULL
+		GROUP BY mp.id, s.id, s.nama
+		ORDER BY s.nama`, mataPelajaranID)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("RekapNilai error query", "error", err)
+		return nil, fmt.Errorf("rekap nilai failed: %w", err)
+	}
+	rekap, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (tugas.RekapNilaiCore, error) {
+		var r tugas.RekapNilaiCore
+		var total float64
+		err := row.Scan(&r.Siswa_ID, &r.Nama_Siswa, &r.Jumlah_Tugas, &r.Dikumpulkan, &r.Terlambat, &r.Dinilai,
+			&r.Rata_Rata, &total)
+		if r.Jumlah_Tugas > 0 {
+			r.Nilai_Tugas = total / float64(r.Jumlah_Tugas)
+		}
+		return r, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rekap nilai failed: %w", err)
+	}
+	return rekap, nil
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/lampiran"
+	"go_rest_native_sekolah/features/tugas"
+	"go_rest_native_sekolah/helper"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errTugasNotFound       = errors.New("tugas service: Data tidak ditemukan")
+	errPengumpulanNotFound = errors.New("tugas service: Pengumpulan tidak ditemukan")
+	errBukanPengajar       = errors.New("tugas service: akses ditolak, guru hanya boleh mengelola tugas mata pelajaran yang ia ajar")
+	errBukanSiswa          = errors.New("tugas service: akses ditolak, akun ini tidak terhubung ke data siswa")
+	errSudahDinilai        = errors.New("validation error: pengumpulan sudah dinilai dan tidak bisa diganti")
+)
+
+// maxJudul adalah panjang maksimum judul tugas, sama dengan kolom judul di database.
+const maxJudul = 200
+
+// tugasService merepresentasikan service untuk tugas dan pengumpulannya.
+type tugasService struct {
+	tugasData       tugas.DataTugasInterface          // tugasData berisi akses ke tabel tugas dan pengumpulan_tugas
+	lampiranService lampiran.ServiceLampiranInterface // lampiranService menyimpan file soal dan jawaban tugas
+	now             func() time.Time                  // now mengembalikan waktu sekarang, diganti saat pengujian
+}
+
+// NewServiceTugas membuat service tugas.
+// File soal dan jawaban tugas disimpan lewat lampiranService dengan kategori lampiran.KategoriTugas.
+// Jika parameter repo atau lampiranService nil maka akan terjadi panic.
+func NewServiceTugas(repo tugas.DataTugasInterface, lampiranService lampiran.ServiceLampiranInterface) tugas.ServiceTugasInterface {
+	if repo == nil || lampiranService == nil {
+		panic("tugas service: Nil repository atau lampiran service")
+	}
+	return &tugasService{tugasData: repo, lampiranService: lampiranService, now: time.Now}
+}
+
+// GetAll implements tugas.ServiceTugasInterface.
+func (s *tugasService) GetAll(ctx context.Context, filter tugas.FilterTugas) ([]tugas.TugasCore, error) {
+	filter.Mata_Pelajaran_ID = strings.TrimSpace(filter.Mata_Pelajaran_ID)
+	filter.Kelas_ID = strings.TrimSpace(filter.Kelas_ID)
+	result, err := s.tugasData.SelectAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("tugas service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// GetById implements tugas.ServiceTugasInterface.
+func (s *tugasService) GetById(ctx context.Context, id string) (*tugas.TugasCore, error) {
+	result, err := s.tugasData.SelectById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errTugasNotFound
+		}
+		return nil, fmt.Errorf("tugas service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// Insert implements tugas.ServiceTugasInterface.
+// Tenggat tugas baru harus di masa depan. Dibuat_Oleh diisi dengan ID pengguna.
+func (s *tugasService) Insert(ctx context.Context, insert *tugas.TugasCore, pengguna helper.MetaToken) error {
+	if insert == nil {
+		return errors.New("tugas service: input is nil")
+	}
+	if err := s.validasi(ctx, insert, pengguna); err != nil {
+		return err
+	}
+	if !insert.Tenggat.After(s.now()) {
+		return errors.New("validation error: tenggat harus di masa depan")
+	}
+
+	insert.ID = ""
+	insert.Dibuat_Oleh = pengguna.ID
+	if err := s.tugasData.Insert(ctx, insert); err != nil {
+		return fmt.Errorf("tugas service: gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+// Update implements tugas.ServiceTugasInterface.
+// Field kosong memakai nilai lama. Mengubah tenggat tidak mengubah tanda terlambat pengumpulan yang sudah ada.
+func (s *tugasService) Update(ctx context.Context, update *tugas.TugasCore, id string, pengguna helper.MetaToken) error {
+	if update == nil {
+		return errors.New("tugas service: input is nil")
+	}
+	existing, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.bolehMengelola(ctx, existing.Mata_Pelajaran_ID, pengguna); err != nil {
+		return err
+	}
+	// Tolak update jika data sudah diubah sejak client mengambilnya (If-Match)
+	if update.Version != existing.Version {
+		return helper.ErrVersionConflict
+	}
+
+	if strings.TrimSpace(update.Mata_Pelajaran_ID) == "" {
+		update.Mata_Pelajaran_ID = existing.Mata_Pelajaran_ID
+	}
+	if strings.TrimSpace(update.Judul) == "" {
+		update.Judul = existing.Judul
+	}
+	if strings.TrimSpace(update.Deskripsi) == "" {
+		update.Deskripsi = existing.Deskripsi
+	}
+	if update.Tenggat.IsZero() {
+		update.Tenggat = existing.Tenggat
+	}
+	if strings.TrimSpace(update.Lampiran_ID) == "" {
+		update.Lampiran_ID = existing.Lampiran_ID
+	}
+	if err := s.validasi(ctx, update, pengguna); err != nil {
+		return err
+	}
+
+	if err := s.tugasData.Update(ctx, update, id); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errTugasNotFound
+		}
+		return fmt.Errorf("tugas service: gagal update data: %w", err)
+	}
+	return nil
+}
+
+// DeleteById implements tugas.ServiceTugasInterface.
+func (s *tugasService) DeleteById(ctx context.Context, id string, version int, pengguna helper.MetaToken) error {
+	existing, err := s.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.bolehMengelola(ctx, existing.Mata_Pelajaran_ID, pengguna); err != nil {
+		return err
+	}
+	if err := s.tugasData.DeleteById(ctx, id, version); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errTugasNotFound
+		}
+		return fmt.Errorf("tugas service: gagal menghapus data: %w", err)
+	}
+	return nil
+}
+
+// TugasSaya implements tugas.ServiceTugasInterface.
+// Siswa yang belum punya kelas mendapat daftar kosong.
+func (s *tugasService) TugasSaya(ctx context.Context, pengguna helper.MetaToken) ([]tugas.TugasCore, error) {
+	siswa, err := s.siswaPengguna(ctx, pengguna)
+	if err != nil {
+		return nil, err
+	}
+	if siswa.Kelas_ID == "" {
+		return []tugas.TugasCore{}, nil
+	}
+	result, err := s.tugasData.SelectTugasSiswa(ctx, siswa.ID, siswa.Kelas_ID)
+	if err != nil {
+		return nil, fmt.Errorf("tugas service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// GetPengumpulan implements tugas.ServiceTugasInterface.
+// Guru hanya boleh melihat pengumpulan tugas mata pelajaran yang ia ajar.
+func (s *tugasService) GetPengumpulan(ctx context.Context, tugasID string, pengguna helper.MetaToken) ([]tugas.PengumpulanCore, error) {
+	data, err := s.GetById(ctx, tugasID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.bolehMengelola(ctx, data.Mata_Pelajaran_ID, pengguna); err != nil {
+		return nil, err
+	}
+	result, err := s.tugasData.SelectPengumpulan(ctx, tugasID)
+	if err != nil {
+		return nil, fmt.Errorf("tugas service: gagal mengambil data pengumpulan: %w", err)
+	}
+	return result, nil
+}
+
+// Kumpulkan implements tugas.ServiceTugasInterface.
+// Tugas hanya bisa dikumpulkan siswa di kelas mata pelajarannya. Pengumpulan setelah tenggat tetap
+// diterima dan ditandai terlambat. Pengumpulan ulang mengganti jawaban lama beserta filenya.
+func (s *tugasService) Kumpulkan(ctx context.Context, tugasID string, kumpul *tugas.PengumpulanCore, namaFile string, isi []byte, pengguna helper.MetaToken) error {
+	if kumpul == nil {
+		return errors.New("tugas service: input is nil")
+	}
+	kumpul.Catatan = strings.TrimSpace(kumpul.Catatan)
+	if len(isi) == 0 && kumpul.Catatan == "" {
+		return errors.New("validation error: file atau catatan jawaban harus diisi")
+	}
+
+	siswa, err := s.siswaPengguna(ctx, pengguna)
+	if err != nil {
+		return err
+	}
+	data, err := s.GetById(ctx, tugasID)
+	if err != nil {
+		return err
+	}
+	// Tugas kelas lain diperlakukan seperti tidak ada
+	if data.Kelas_ID == "" || data.Kelas_ID != siswa.Kelas_ID {
+		return errTugasNotFound
+	}
+
+	lama, err := s.tugasData.SelectPengumpulanSiswa(ctx, tugasID, siswa.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("tugas service: gagal mengambil data pengumpulan: %w", err)
+	}
+	if lama != nil && lama.Nilai != nil {
+		return errSudahDinilai
+	}
+
+	kumpul.Tugas_ID = tugasID
+	kumpul.Siswa_ID = siswa.ID
+	kumpul.Lampiran_ID = ""
+	if len(isi) > 0 {
+		berkas := lampiran.LampiranCore{
+			Pemilik_Tipe: lampiran.PemilikSiswa,
+			Pemilik_ID:   siswa.ID,
+			Kategori:     lampiran.KategoriTugas,
+			Nama_File:    namaFile,
+		}
+		if err := s.lampiranService.Upload(ctx, &berkas, isi, pengguna); err != nil {
+			return err
+		}
+		kumpul.Lampiran_ID = berkas.ID
+	}
+	// Waktu disimpan dalam UTC karena kolom TIMESTAMP tidak menyimpan zona waktu
+	kumpul.Dikumpulkan_At = s.now().UTC()
+	kumpul.Terlambat = kumpul.Dikumpulkan_At.After(data.Tenggat)
+	kumpul.Nilai = nil
+
+	if err := s.tugasData.SimpanPengumpulan(ctx, kumpul); err != nil {
+		s.hapusLampiran(ctx, kumpul.Lampiran_ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Pengumpulan dinilai guru di antara pengecekan dan penyimpanan
+			return errSudahDinilai
+		}
+		return fmt.Errorf("tugas service: gagal menyimpan pengumpulan: %w", err)
+	}
+	if lama != nil && lama.Lampiran_ID != kumpul.Lampiran_ID {
+		s.hapusLampiran(ctx, lama.Lampiran_ID)
+	}
+	return nil
+}
+
+// BeriNilai implements tugas.ServiceTugasInterface.
+// Nilai yang sudah ada boleh diubah. Mengembalikan pengumpulan setelah dinilai.
+func (s *tugasService) BeriNilai(ctx context.Context, pengumpulanID string, nilai float64, komentar string, pengguna helper.MetaToken) (*tugas.PengumpulanCore, error) {
+	if nilai < tugas.NilaiMin || nilai > tugas.NilaiMax {
+		return nil, fmt.Errorf("validation error: nilai harus antara %d dan %d", tugas.NilaiMin, tugas.NilaiMax)
+	}
+
+	data, err := s.getPengumpulan(ctx, pengumpulanID)
+	if err != nil {
+		return nil, err
+	}
+	induk, err := s.GetById(ctx, data.Tugas_ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.bolehMengelola(ctx, induk.Mata_Pelajaran_ID, pengguna); err != nil {
+		return nil, err
+	}
+
+	if err := s.tugasData.SimpanNilai(ctx, pengumpulanID, nilai, strings.TrimSpace(komentar), pengguna.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errPengumpulanNotFound
+		}
+		return nil, fmt.Errorf("tugas service: gagal menyimpan nilai: %w", err)
+	}
+	return s.getPengumpulan(ctx, pengumpulanID)
+}
+
+// RekapNilai implements tugas.ServiceTugasInterface.
+// Guru hanya boleh melihat rekap mata pelajaran yang ia ajar.
+func (s *tugasService) RekapNilai(ctx context.Context, mataPelajaranID string, pengguna helper.MetaToken) ([]tugas.RekapNilaiCore, error) {
+	mataPelajaranID = strings.TrimSpace(mataPelajaranID)
+	if mataPelajaranID == "" {
+		return nil, errors.New("validation error: mata_pelajaran_id harus diisi")
+	}
+	if _, err := s.tugasData.KelasMataPelajaran(ctx, mataPelajaranID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("tugas service: Mata pelajaran tidak ditemukan")
+		}
+		return nil, fmt.Errorf("tugas service: gagal mengambil data mata pelajaran: %w", err)
+	}
+	if err := s.bolehMengelola(ctx, mataPelajaranID, pengguna); err != nil {
+		return nil, err
+	}
+	result, err := s.tugasData.RekapNilai(ctx, mataPelajaranID)
+	if err != nil {
+		return nil, fmt.Errorf("tugas service: gagal mengambil rekap nilai: %w", err)
+	}
+	return result, nil
+}
+
+// validasi merapikan dan memeriksa tugas sebelum disimpan, termasuk hak pengguna atas mata pelajarannya.
+func (s *tugasService) validasi(ctx context.Context, t *tugas.TugasCore, pengguna helper.MetaToken) error {
+	t.Judul = strings.TrimSpace(t.Judul)
+	if t.Judul == "" {
+		return errors.New("validation error: judul harus diisi")
+	}
+	if utf8.RuneCountInString(t.Judul) > maxJudul {
+		return fmt.Errorf("validation error: judul maksimal %d karakter", maxJudul)
+	}
+	t.Deskripsi = strings.TrimSpace(t.Deskripsi)
+	if t.Tenggat.IsZero() {
+		return errors.New("validation error: tenggat harus diisi")
+	}
+	// Kolom tenggat bertipe TIMESTAMP tanpa zona waktu dan dibaca kembali sebagai UTC,
+	// jadi tenggat dengan offset lain (misal +07:00) harus diubah ke UTC sebelum disimpan
+	t.Tenggat = t.Tenggat.UTC()
+
+	t.Mata_Pelajaran_ID = strings.TrimSpace(t.Mata_Pelajaran_ID)
+	if t.Mata_Pelajaran_ID == "" {
+		return errors.New("validation error: mata_pelajaran_id harus diisi")
+	}
+	kelasID, err := s.tugasData.KelasMataPelajaran(ctx, t.Mata_Pelajaran_ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("validation error: mata pelajaran '%s' tidak ditemukan", t.Mata_Pelajaran_ID)
+		}
+		return fmt.Errorf("tugas service: gagal mengambil data mata pelajaran: %w", err)
+	}
+	if kelasID == "" {
+		return errors.New("validation error: mata pelajaran belum punya kelas")
+	}
+	if err := s.bolehMengelola(ctx, t.Mata_Pelajaran_ID, pengguna); err != nil {
+		return err
+	}
+
+	t.Lampiran_ID = strings.TrimSpace(t.Lampiran_ID)
+	if t.Lampiran_ID != "" {
+		if _, err := s.lampiranService.GetById(ctx, t.Lampiran_ID); err != nil {
+			if strings.Contains(err.Error(), "tidak ditemukan") {
+				return fmt.Errorf("validation error: lampiran '%s' tidak ditemukan", t.Lampiran_ID)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// bolehMengelola memastikan pengguna boleh mengelola tugas mata pelajaran.
+// Admin boleh mengelola semua tugas, guru hanya tugas mata pelajaran yang ia ajar.
+func (s *tugasService) bolehMengelola(ctx context.Context, mataPelajaranID string, pengguna helper.MetaToken) error {
+	if pengguna.Role == "admin" {
+		return nil
+	}
+	if pengguna.Role != "guru" {
+		return errBukanPengajar
+	}
+	mengajar, err := s.tugasData.GuruMengajar(ctx, pengguna.ID, mataPelajaranID)
+	if err != nil {
+		return fmt.Errorf("tugas service: gagal cek guru pengajar: %w", err)
+	}
+	if !mengajar {
+		return errBukanPengajar
+	}
+	return nil
+}
+
+// siswaPengguna mengambil data siswa milik pengguna.
+func (s *tugasService) siswaPengguna(ctx context.Context, pengguna helper.MetaToken) (*tugas.SiswaPengumpul, error) {
+	siswa, err := s.tugasData.SelectSiswaByUser(ctx, pengguna.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errBukanSiswa
+		}
+		return nil, fmt.Errorf("tugas service: gagal mengambil data siswa: %w", err)
+	}
+	return siswa, nil
+}
+
+// getPengumpulan mengambil satu pengumpulan berdasarkan ID.
+func (s *tugasService) getPengumpulan(ctx context.Context, id string) (*tugas.PengumpulanCore, error) {
+	data, err := s.tugasData.SelectPengumpulanById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errPengumpulanNotFound
+		}
+		return nil, fmt.Errorf("tugas service: gagal mengambil data pengumpulan: %w", err)
+	}
+	return data, nil
+}
+
+// hapusLampiran menghapus file jawaban yang tidak lagi dipakai pengumpulan mana pun.
+// Kegagalan hanya dicatat di log karena pengumpulan sudah tidak menunjuk ke file tersebut.
+func (s *tugasService) hapusLampiran(ctx context.Context, id string) {
+	if id == "" {
+		return
+	}
+	if err := s.lampiranService.DeleteById(ctx, id); err != nil {
+		helper.LoggerFromContext(ctx).Warn("Gagal menghapus lampiran pengumpulan tugas", "id", id, "error", err)
+	}
+}
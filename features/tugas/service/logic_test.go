@@ -0,0 +1,637 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go_rest_native_sekolah/features/lampiran"
+	"go_rest_native_sekolah/features/tugas"
+	"go_rest_native_sekolah/helper"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock untuk DataTugasInterface
+type mockDataTugas struct {
+	mock.Mock
+}
+
+func (m *mockDataTugas) SelectAll(ctx context.Context, filter tugas.FilterTugas) ([]tugas.TugasCore, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]tugas.TugasCore), args.Error(1)
+}
+
+func (m *mockDataTugas) SelectById(ctx context.Context, id string) (*tugas.TugasCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*tugas.TugasCore), args.Error(1)
+}
+
+func (m *mockDataTugas) Insert(ctx context.Context, insert *tugas.TugasCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataTugas) Update(ctx context.Context, update *tugas.TugasCore, id string) error {
+	args := m.Called(update, id)
+	return args.Error(0)
+}
+
+func (m *mockDataTugas) DeleteById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataTugas) KelasMataPelajaran(ctx context.Context, mataPelajaranID string) (string, error) {
+	args := m.Called(mataPelajaranID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockDataTugas) GuruMengajar(ctx context.Context, userID, mataPelajaranID string) (bool, error) {
+	args := m.Called(userID, mataPelajaranID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockDataTugas) SelectSiswaByUser(ctx context.Context, userID string) (*tugas.SiswaPengumpul, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*tugas.SiswaPengumpul), args.Error(1)
+}
+
+func (m *mockDataTugas) SelectTugasSiswa(ctx context.Context, siswaID, kelasID string) ([]tugas.TugasCore, error) {
+	args := m.Called(siswaID, kelasID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]tugas.TugasCore), args.Error(1)
+}
+
+func (m *mockDataTugas) SelectPengumpulan(ctx context.Context, tugasID string) ([]tugas.PengumpulanCore, error) {
+	args := m.Called(tugasID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]tugas.PengumpulanCore), args.Error(1)
+}
+
+func (m *mockDataTugas) SelectPengumpulanById(ctx context.Context, id string) (*tugas.PengumpulanCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*tugas.PengumpulanCore), args.Error(1)
+}
+
+func (m *mockDataTugas) SelectPengumpulanSiswa(ctx context.Context, tugasID, siswaID string) (*tugas.PengumpulanCore, error) {
+	args := m.Called(tugasID, siswaID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*tugas.PengumpulanCore), args.Error(1)
+}
+
+func (m *mockDataTugas) SimpanPengumpulan(ctx context.Context, p *tugas.PengumpulanCore) error {
+	args := m.Called(p)
+	return args.Error(0)
+}
+
+func (m *mockDataTugas) SimpanNilai(ctx context.Context, id string, nilai float64, komentar, dinilaiOleh string) error {
+	args := m.Called(id, nilai, komentar, dinilaiOleh)
+	return args.Error(0)
+}
+
+func (m *mockDataTugas) RekapNilai(ctx context.Context, mataPelajaranID string) ([]tugas.RekapNilaiCore, error) {
+	args := m.Called(mataPelajaranID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]tugas.RekapNilaiCore), args.Error(1)
+}
+
+// Mock untuk ServiceLampiranInterface
+type mockLampiran struct {
+	mock.Mock
+}
+
+func (m *mockLampiran) Upload(ctx context.Context, insert *lampiran.LampiranCore, isi []byte, pengguna helper.MetaToken) error {
+	args := m.Called(insert, isi)
+	return args.Error(0)
+}
+
+func (m *mockLampiran) GetByPemilik(ctx context.Context, tipe, pemilikID string, pengguna helper.MetaToken) ([]lampiran.LampiranCore, error) {
+	args := m.Called(tipe, pemilikID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]lampiran.LampiranCore), args.Error(1)
+}
+
+func (m *mockLampiran) GetById(ctx context.Context, id string) (*lampiran.LampiranCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*lampiran.LampiranCore), args.Error(1)
+}
+
+func (m *mockLampiran) Lihat(ctx context.Context, id string, pengguna helper.MetaToken) (*lampiran.LampiranCore, error) {
+	args := m.Called(id, pengguna)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*lampiran.LampiranCore), args.Error(1)
+}
+
+func (m *mockLampiran) Unduh(ctx context.Context, id string, thumbnail bool, pengguna helper.MetaToken) (*lampiran.Berkas, error) {
+	args := m.Called(id, thumbnail)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*lampiran.Berkas), args.Error(1)
+}
+
+func (m *mockLampiran) DeleteById(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// waktuUji adalah waktu sekarang yang dipakai service selama pengujian.
+var waktuUji = time.Date(2026, 8, 3, 7, 0, 0, 0, time.UTC)
+
+var (
+	admin = helper.MetaToken{ID: "admin-1", Role: "admin"}
+	guru  = helper.MetaToken{ID: "user-guru", Role: "guru"}
+	siswa = helper.MetaToken{ID: "user-siswa", Role: "user"}
+)
+
+func newTestService(repo *mockDataTugas, berkas *mockLampiran) *tugasService {
+	return &tugasService{tugasData: repo, lampiranService: berkas, now: func() time.Time { return waktuUji }}
+}
+
+func nilaiPtr(v float64) *float64 {
+	return &v
+}
+
+func TestInsertTugas(t *testing.T) {
+	t.Run("success insert - guru pengajar", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-1").Return(true, nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*tugas.TugasCore")).Return(nil).Once()
+
+		data := &tugas.TugasCore{Mata_Pelajaran_ID: "mp-1", Judul: "  Latihan Bab 1 ", Tenggat: waktuUji.Add(48 * time.Hour)}
+		err := newTestService(mockRepo, new(mockLampiran)).Insert(context.Background(), data, guru)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Latihan Bab 1", data.Judul)
+		assert.Equal(t, "user-guru", data.Dibuat_Oleh)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success insert - tenggat dengan offset disimpan dalam UTC", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*tugas.TugasCore")).Return(nil).Once()
+
+		wib := time.FixedZone("WIB", 7*60*60)
+		tenggat := time.Date(2026, 8, 4, 14, 0, 0, 0, wib)
+		data := &tugas.TugasCore{Mata_Pelajaran_ID: "mp-1", Judul: "Latihan", Tenggat: tenggat}
+		err := newTestService(mockRepo, new(mockLampiran)).Insert(context.Background(), data, admin)
+
+		assert.NoError(t, err)
+		assert.Equal(t, time.UTC, data.Tenggat.Location())
+		assert.Equal(t, time.Date(2026, 8, 4, 7, 0, 0, 0, time.UTC), data.Tenggat)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success insert - admin dengan lampiran soal", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockBerkas := new(mockLampiran)
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+		mockBerkas.On("GetById", "lamp-1").Return(&lampiran.LampiranCore{ID: "lamp-1"}, nil).Once()
+		mockRepo.On("Insert", mock.AnythingOfType("*tugas.TugasCore")).Return(nil).Once()
+
+		data := &tugas.TugasCore{Mata_Pelajaran_ID: "mp-1", Judul: "Proyek", Tenggat: waktuUji.Add(time.Hour), Lampiran_ID: "lamp-1"}
+		err := newTestService(mockRepo, mockBerkas).Insert(context.Background(), data, admin)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockBerkas.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "GuruMengajar", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed insert - guru bukan pengajar", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-1").Return(false, nil).Once()
+
+		data := &tugas.TugasCore{Mata_Pelajaran_ID: "mp-1", Judul: "Latihan", Tenggat: waktuUji.Add(time.Hour)}
+		err := newTestService(mockRepo, new(mockLampiran)).Insert(context.Background(), data, guru)
+
+		assert.ErrorIs(t, err, errBukanPengajar)
+		assert.Contains(t, err.Error(), "akses ditolak")
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("failed insert - tenggat sudah lewat", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+
+		data := &tugas.TugasCore{Mata_Pelajaran_ID: "mp-1", Judul: "Latihan", Tenggat: waktuUji.Add(-time.Hour)}
+		err := newTestService(mockRepo, new(mockLampiran)).Insert(context.Background(), data, admin)
+
+		assert.EqualError(t, err, "validation error: tenggat harus di masa depan")
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("failed insert - mata pelajaran tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-x").Return("", pgx.ErrNoRows).Once()
+
+		data := &tugas.TugasCore{Mata_Pelajaran_ID: "mp-x", Judul: "Latihan", Tenggat: waktuUji.Add(time.Hour)}
+		err := newTestService(mockRepo, new(mockLampiran)).Insert(context.Background(), data, admin)
+
+		assert.EqualError(t, err, "validation error: mata pelajaran 'mp-x' tidak ditemukan")
+	})
+
+	t.Run("failed insert - lampiran tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockBerkas := new(mockLampiran)
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+		mockBerkas.On("GetById", "lamp-x").Return(nil, errors.New("lampiran service: Data tidak ditemukan")).Once()
+
+		data := &tugas.TugasCore{Mata_Pelajaran_ID: "mp-1", Judul: "Latihan", Tenggat: waktuUji.Add(time.Hour), Lampiran_ID: "lamp-x"}
+		err := newTestService(mockRepo, mockBerkas).Insert(context.Background(), data, admin)
+
+		assert.EqualError(t, err, "validation error: lampiran 'lamp-x' tidak ditemukan")
+	})
+
+	t.Run("failed insert - judul dan tenggat kosong", func(t *testing.T) {
+		svc := newTestService(new(mockDataTugas), new(mockLampiran))
+
+		err := svc.Insert(context.Background(), &tugas.TugasCore{Mata_Pelajaran_ID: "mp-1", Tenggat: waktuUji}, admin)
+		assert.EqualError(t, err, "validation error: judul harus diisi")
+
+		err = svc.Insert(context.Background(), &tugas.TugasCore{Mata_Pelajaran_ID: "mp-1", Judul: "Latihan"}, admin)
+		assert.EqualError(t, err, "validation error: tenggat harus diisi")
+	})
+}
+
+func TestUpdateTugas(t *testing.T) {
+	existing := &tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-1", Judul: "Latihan", Deskripsi: "Kerjakan", Tenggat: waktuUji.Add(-time.Hour), Version: 2}
+
+	t.Run("success update - field kosong memakai nilai lama dan tenggat lampau boleh", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-1").Return(existing, nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-1").Return(true, nil).Twice()
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+		mockRepo.On("Update", mock.AnythingOfType("*tugas.TugasCore"), "t-1").Return(nil).Once()
+
+		data := &tugas.TugasCore{Judul: "Latihan Revisi", Version: 2}
+		err := newTestService(mockRepo, new(mockLampiran)).Update(context.Background(), data, "t-1", guru)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Latihan Revisi", data.Judul)
+		assert.Equal(t, "Kerjakan", data.Deskripsi)
+		assert.Equal(t, existing.Tenggat, data.Tenggat)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed update - versi berbeda", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-1").Return(existing, nil).Once()
+
+		err := newTestService(mockRepo, new(mockLampiran)).Update(context.Background(), &tugas.TugasCore{Version: 1}, "t-1", admin)
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed update - guru bukan pengajar", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-1").Return(existing, nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-1").Return(false, nil).Once()
+
+		err := newTestService(mockRepo, new(mockLampiran)).Update(context.Background(), &tugas.TugasCore{Version: 2}, "t-1", guru)
+
+		assert.ErrorIs(t, err, errBukanPengajar)
+	})
+
+	t.Run("failed update - tugas tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-x").Return(nil, pgx.ErrNoRows).Once()
+
+		err := newTestService(mockRepo, new(mockLampiran)).Update(context.Background(), &tugas.TugasCore{}, "t-x", admin)
+
+		assert.ErrorIs(t, err, errTugasNotFound)
+	})
+}
+
+func TestDeleteTugas(t *testing.T) {
+	t.Run("success delete", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-1").Return(&tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-1"}, nil).Once()
+		mockRepo.On("DeleteById", "t-1", 3).Return(nil).Once()
+
+		err := newTestService(mockRepo, new(mockLampiran)).DeleteById(context.Background(), "t-1", 3, admin)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed delete - role user", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-1").Return(&tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-1"}, nil).Once()
+
+		err := newTestService(mockRepo, new(mockLampiran)).DeleteById(context.Background(), "t-1", 3, siswa)
+
+		assert.ErrorIs(t, err, errBukanPengajar)
+		mockRepo.AssertNotCalled(t, "DeleteById", mock.Anything, mock.Anything)
+	})
+}
+
+func TestKumpulkanTugas(t *testing.T) {
+	dataTugas := &tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-1", Kelas_ID: "kelas-1", Tenggat: waktuUji.Add(time.Hour)}
+	dataSiswa := &tugas.SiswaPengumpul{ID: "siswa-1", Kelas_ID: "kelas-1"}
+
+	t.Run("success kumpul - file diunggah sebagai lampiran tugas", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockBerkas := new(mockLampiran)
+		mockRepo.On("SelectSiswaByUser", "user-siswa").Return(dataSiswa, nil).Once()
+		mockRepo.On("SelectById", "t-1").Return(dataTugas, nil).Once()
+		mockRepo.On("SelectPengumpulanSiswa", "t-1", "siswa-1").Return(nil, pgx.ErrNoRows).Once()
+		mockBerkas.On("Upload", mock.MatchedBy(func(l *lampiran.LampiranCore) bool {
+			return l.Pemilik_Tipe == lampiran.PemilikSiswa && l.Pemilik_ID == "siswa-1" && l.Kategori == lampiran.KategoriTugas && l.Nama_File == "jawaban.pdf"
+		}), []byte("%PDF")).Run(func(args mock.Arguments) {
+			args.Get(0).(*lampiran.LampiranCore).ID = "lamp-1"
+		}).Return(nil).Once()
+		mockRepo.On("SimpanPengumpulan", mock.AnythingOfType("*tugas.PengumpulanCore")).Return(nil).Once()
+
+		data := &tugas.PengumpulanCore{Catatan: " sudah "}
+		err := newTestService(mockRepo, mockBerkas).Kumpulkan(context.Background(), "t-1", data, "jawaban.pdf", []byte("%PDF"), siswa)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "lamp-1", data.Lampiran_ID)
+		assert.Equal(t, "siswa-1", data.Siswa_ID)
+		assert.Equal(t, "sudah", data.Catatan)
+		assert.Equal(t, waktuUji, data.Dikumpulkan_At)
+		assert.False(t, data.Terlambat)
+		mockRepo.AssertExpectations(t)
+		mockBerkas.AssertExpectations(t)
+	})
+
+	t.Run("success kumpul - terlambat dan file lama dihapus", func(t *testing.T) {
+		lewat := &tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-1", Kelas_ID: "kelas-1", Tenggat: waktuUji.Add(-time.Minute)}
+		mockRepo := new(mockDataTugas)
+		mockBerkas := new(mockLampiran)
+		mockRepo.On("SelectSiswaByUser", "user-siswa").Return(dataSiswa, nil).Once()
+		mockRepo.On("SelectById", "t-1").Return(lewat, nil).Once()
+		mockRepo.On("SelectPengumpulanSiswa", "t-1", "siswa-1").Return(&tugas.PengumpulanCore{ID: "p-1", Lampiran_ID: "lamp-lama"}, nil).Once()
+		mockRepo.On("SimpanPengumpulan", mock.AnythingOfType("*tugas.PengumpulanCore")).Return(nil).Once()
+		mockBerkas.On("DeleteById", "lamp-lama").Return(nil).Once()
+
+		data := &tugas.PengumpulanCore{Catatan: "jawaban teks"}
+		err := newTestService(mockRepo, mockBerkas).Kumpulkan(context.Background(), "t-1", data, "", nil, siswa)
+
+		assert.NoError(t, err)
+		assert.True(t, data.Terlambat)
+		assert.Empty(t, data.Lampiran_ID)
+		mockBerkas.AssertExpectations(t)
+		mockBerkas.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success kumpul - jam server non-UTC dibandingkan dengan tenggat UTC", func(t *testing.T) {
+		// Tenggat dibaca dari kolom TIMESTAMP sebagai UTC, sedangkan jam server memakai WIB
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectSiswaByUser", "user-siswa").Return(dataSiswa, nil).Once()
+		mockRepo.On("SelectById", "t-1").Return(dataTugas, nil).Once()
+		mockRepo.On("SelectPengumpulanSiswa", "t-1", "siswa-1").Return(nil, pgx.ErrNoRows).Once()
+		mockRepo.On("SimpanPengumpulan", mock.AnythingOfType("*tugas.PengumpulanCore")).Return(nil).Once()
+
+		svc := newTestService(mockRepo, new(mockLampiran))
+		svc.now = func() time.Time { return waktuUji.In(time.FixedZone("WIB", 7*60*60)) }
+		data := &tugas.PengumpulanCore{Catatan: "jawaban teks"}
+		err := svc.Kumpulkan(context.Background(), "t-1", data, "", nil, siswa)
+
+		assert.NoError(t, err)
+		assert.False(t, data.Terlambat)
+		assert.Equal(t, time.UTC, data.Dikumpulkan_At.Location())
+		assert.True(t, waktuUji.Equal(data.Dikumpulkan_At))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed kumpul - sudah dinilai", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockBerkas := new(mockLampiran)
+		mockRepo.On("SelectSiswaByUser", "user-siswa").Return(dataSiswa, nil).Once()
+		mockRepo.On("SelectById", "t-1").Return(dataTugas, nil).Once()
+		mockRepo.On("SelectPengumpulanSiswa", "t-1", "siswa-1").Return(&tugas.PengumpulanCore{ID: "p-1", Nilai: nilaiPtr(80)}, nil).Once()
+
+		err := newTestService(mockRepo, mockBerkas).Kumpulkan(context.Background(), "t-1", &tugas.PengumpulanCore{Catatan: "revisi"}, "", nil, siswa)
+
+		assert.ErrorIs(t, err, errSudahDinilai)
+		mockRepo.AssertNotCalled(t, "SimpanPengumpulan", mock.Anything)
+	})
+
+	t.Run("failed kumpul - dinilai saat menyimpan, file baru dihapus", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockBerkas := new(mockLampiran)
+		mockRepo.On("SelectSiswaByUser", "user-siswa").Return(dataSiswa, nil).Once()
+		mockRepo.On("SelectById", "t-1").Return(dataTugas, nil).Once()
+		mockRepo.On("SelectPengumpulanSiswa", "t-1", "siswa-1").Return(nil, pgx.ErrNoRows).Once()
+		mockBerkas.On("Upload", mock.AnythingOfType("*lampiran.LampiranCore"), []byte("%PDF")).Run(func(args mock.Arguments) {
+			args.Get(0).(*lampiran.LampiranCore).ID = "lamp-baru"
+		}).Return(nil).Once()
+		mockRepo.On("SimpanPengumpulan", mock.AnythingOfType("*tugas.PengumpulanCore")).Return(pgx.ErrNoRows).Once()
+		mockBerkas.On("DeleteById", "lamp-baru").Return(nil).Once()
+
+		err := newTestService(mockRepo, mockBerkas).Kumpulkan(context.Background(), "t-1", &tugas.PengumpulanCore{}, "jawaban.pdf", []byte("%PDF"), siswa)
+
+		assert.ErrorIs(t, err, errSudahDinilai)
+		mockBerkas.AssertExpectations(t)
+	})
+
+	t.Run("failed kumpul - tugas kelas lain", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectSiswaByUser", "user-siswa").Return(&tugas.SiswaPengumpul{ID: "siswa-1", Kelas_ID: "kelas-2"}, nil).Once()
+		mockRepo.On("SelectById", "t-1").Return(dataTugas, nil).Once()
+
+		err := newTestService(mockRepo, new(mockLampiran)).Kumpulkan(context.Background(), "t-1", &tugas.PengumpulanCore{Catatan: "x"}, "", nil, siswa)
+
+		assert.ErrorIs(t, err, errTugasNotFound)
+	})
+
+	t.Run("failed kumpul - bukan siswa", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectSiswaByUser", "user-guru").Return(nil, pgx.ErrNoRows).Once()
+
+		err := newTestService(mockRepo, new(mockLampiran)).Kumpulkan(context.Background(), "t-1", &tugas.PengumpulanCore{Catatan: "x"}, "", nil, guru)
+
+		assert.ErrorIs(t, err, errBukanSiswa)
+		assert.Contains(t, err.Error(), "akses ditolak")
+	})
+
+	t.Run("failed kumpul - file dan catatan kosong", func(t *testing.T) {
+		err := newTestService(new(mockDataTugas), new(mockLampiran)).Kumpulkan(context.Background(), "t-1", &tugas.PengumpulanCore{Catatan: "  "}, "", nil, siswa)
+
+		assert.EqualError(t, err, "validation error: file atau catatan jawaban harus diisi")
+	})
+}
+
+func TestBeriNilai(t *testing.T) {
+	t.Run("success beri nilai", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectPengumpulanById", "p-1").Return(&tugas.PengumpulanCore{ID: "p-1", Tugas_ID: "t-1"}, nil).Once()
+		mockRepo.On("SelectById", "t-1").Return(&tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-1"}, nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-1").Return(true, nil).Once()
+		mockRepo.On("SimpanNilai", "p-1", 87.5, "Bagus", "user-guru").Return(nil).Once()
+		mockRepo.On("SelectPengumpulanById", "p-1").Return(&tugas.PengumpulanCore{ID: "p-1", Tugas_ID: "t-1", Nilai: nilaiPtr(87.5)}, nil).Once()
+
+		result, err := newTestService(mockRepo, new(mockLampiran)).BeriNilai(context.Background(), "p-1", 87.5, " Bagus ", guru)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 87.5, *result.Nilai)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed beri nilai - di luar rentang", func(t *testing.T) {
+		svc := newTestService(new(mockDataTugas), new(mockLampiran))
+
+		_, err := svc.BeriNilai(context.Background(), "p-1", 101, "", admin)
+		assert.EqualError(t, err, "validation error: nilai harus antara 0 dan 100")
+
+		_, err = svc.BeriNilai(context.Background(), "p-1", -1, "", admin)
+		assert.EqualError(t, err, "validation error: nilai harus antara 0 dan 100")
+	})
+
+	t.Run("failed beri nilai - pengumpulan tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectPengumpulanById", "p-x").Return(nil, pgx.ErrNoRows).Once()
+
+		_, err := newTestService(mockRepo, new(mockLampiran)).BeriNilai(context.Background(), "p-x", 80, "", admin)
+
+		assert.ErrorIs(t, err, errPengumpulanNotFound)
+	})
+}
+
+func TestTugasSaya(t *testing.T) {
+	t.Run("success - siswa tanpa kelas mendapat daftar kosong", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectSiswaByUser", "user-siswa").Return(&tugas.SiswaPengumpul{ID: "siswa-1"}, nil).Once()
+
+		result, err := newTestService(mockRepo, new(mockLampiran)).TugasSaya(context.Background(), siswa)
+
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+		mockRepo.AssertNotCalled(t, "SelectTugasSiswa", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success - tugas kelas siswa", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectSiswaByUser", "user-siswa").Return(&tugas.SiswaPengumpul{ID: "siswa-1", Kelas_ID: "kelas-1"}, nil).Once()
+		mockRepo.On("SelectTugasSiswa", "siswa-1", "kelas-1").Return([]tugas.TugasCore{{ID: "t-1"}}, nil).Once()
+
+		result, err := newTestService(mockRepo, new(mockLampiran)).TugasSaya(context.Background(), siswa)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+}
+
+func TestGetPengumpulan(t *testing.T) {
+	t.Run("success - guru pengajar mata pelajaran", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-1").Return(&tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-1"}, nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-1").Return(true, nil).Once()
+		mockRepo.On("SelectPengumpulan", "t-1").Return([]tugas.PengumpulanCore{{ID: "p-1"}}, nil).Once()
+
+		result, err := newTestService(mockRepo, new(mockLampiran)).GetPengumpulan(context.Background(), "t-1", guru)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed - guru mata pelajaran lain", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-1").Return(&tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-2"}, nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-2").Return(false, nil).Once()
+
+		_, err := newTestService(mockRepo, new(mockLampiran)).GetPengumpulan(context.Background(), "t-1", guru)
+
+		assert.ErrorIs(t, err, errBukanPengajar)
+		mockRepo.AssertNotCalled(t, "SelectPengumpulan", mock.Anything)
+	})
+
+	t.Run("failed - siswa", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("SelectById", "t-1").Return(&tugas.TugasCore{ID: "t-1", Mata_Pelajaran_ID: "mp-1"}, nil).Once()
+
+		_, err := newTestService(mockRepo, new(mockLampiran)).GetPengumpulan(context.Background(), "t-1", siswa)
+
+		assert.ErrorIs(t, err, errBukanPengajar)
+		mockRepo.AssertNotCalled(t, "SelectPengumpulan", mock.Anything)
+	})
+}
+
+func TestRekapNilai(t *testing.T) {
+	t.Run("success rekap", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+		mockRepo.On("RekapNilai", "mp-1").Return([]tugas.RekapNilaiCore{{Siswa_ID: "siswa-1", Jumlah_Tugas: 2, Nilai_Tugas: 45}}, nil).Once()
+
+		result, err := newTestService(mockRepo, new(mockLampiran)).RekapNilai(context.Background(), " mp-1 ", admin)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success rekap - guru pengajar", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-1").Return("kelas-1", nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-1").Return(true, nil).Once()
+		mockRepo.On("RekapNilai", "mp-1").Return([]tugas.RekapNilaiCore{}, nil).Once()
+
+		_, err := newTestService(mockRepo, new(mockLampiran)).RekapNilai(context.Background(), "mp-1", guru)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed rekap - guru mata pelajaran lain", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-2").Return("kelas-2", nil).Once()
+		mockRepo.On("GuruMengajar", "user-guru", "mp-2").Return(false, nil).Once()
+
+		_, err := newTestService(mockRepo, new(mockLampiran)).RekapNilai(context.Background(), "mp-2", guru)
+
+		assert.ErrorIs(t, err, errBukanPengajar)
+		mockRepo.AssertNotCalled(t, "RekapNilai", mock.Anything)
+	})
+
+	t.Run("failed rekap - mata_pelajaran_id kosong", func(t *testing.T) {
+		_, err := newTestService(new(mockDataTugas), new(mockLampiran)).RekapNilai(context.Background(), "", admin)
+
+		assert.EqualError(t, err, "validation error: mata_pelajaran_id harus diisi")
+	})
+
+	t.Run("failed rekap - mata pelajaran tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataTugas)
+		mockRepo.On("KelasMataPelajaran", "mp-x").Return("", pgx.ErrNoRows).Once()
+
+		_, err := newTestService(mockRepo, new(mockLampiran)).RekapNilai(context.Background(), "mp-x", admin)
+
+		assert.Contains(t, err.Error(), "tidak ditemukan")
+	})
+}
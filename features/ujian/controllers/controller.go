@@ -0,0 +1,503 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/ujian"
+	"go_rest_native_sekolah/helper"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// UjianController menghandle HTTP request ruang ujian, jadwal sesi, pengawas, dan denah tempat duduk.
+type UjianController struct {
+	ujianService ujian.ServiceUjianInterface
+}
+
+// NewUjianController membuat UjianController dengan service ujian.
+func NewUjianController(service ujian.ServiceUjianInterface) *UjianController {
+	return &UjianController{ujianService: service}
+}
+
+// writeUjianError menulis response untuk error dari service ujian.
+// Mengembalikan false jika error tidak dikenali sehingga pemanggil perlu meneruskannya.
+func writeUjianError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, helper.ErrVersionConflict):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case strings.Contains(err.Error(), "validation"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		return false
+	}
+	return true
+}
+
+// Ruang menghandle GET /ujian/ruang untuk daftar ruang ujian.
+func (uc *UjianController) Ruang(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	result, err := uc.ujianService.GetRuang(r.Context())
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data ruang ujian", FormatRuangList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// InsertRuang menghandle POST /ujian/ruang/tambah. Body JSON berisi nama dan kapasitas.
+func (uc *UjianController) InsertRuang(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	var req RuangRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	data := ujian.RuangCore{Nama: req.Nama, Kapasitas: req.Kapasitas}
+	if err := uc.ujianService.InsertRuang(r.Context(), &data); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil menambah ruang ujian", FormatRuangList([]ujian.RuangCore{data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// GetRuangById menghandle GET /ujian/ruang/{id}. Response membawa header ETag untuk update dan delete.
+func (uc *UjianController) GetRuangById(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	data, err := uc.ujianService.GetRuangById(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data ruang ujian", FormatRuangList([]ujian.RuangCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// UpdateRuang menghandle PUT /ujian/ruang/{id}. Field yang kosong tetap memakai nilai lama.
+// Header If-Match wajib diisi dengan ETag terbaru.
+func (uc *UjianController) UpdateRuang(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	var req RuangRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+	data := ujian.RuangCore{Nama: req.Nama, Kapasitas: req.Kapasitas, Version: version}
+
+	if err := uc.ujianService.UpdateRuang(r.Context(), &data, id); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	updated, err := uc.ujianService.GetRuangById(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengupdate ruang ujian", FormatRuangList([]ujian.RuangCore{*updated}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updated.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// DeleteRuang menghandle DELETE /ujian/ruang/{id}. Header If-Match wajib diisi dengan ETag terbaru.
+func (uc *UjianController) DeleteRuang(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	if err := uc.ujianService.DeleteRuang(r.Context(), r.PathValue("id"), version); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil menghapus ruang ujian", nil))
+	return nil
+}
+
+// Ujian menghandle GET /ujian?tahun_ajaran= untuk daftar ujian. Filter tahun_ajaran opsional.
+func (uc *UjianController) Ujian(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	result, err := uc.ujianService.GetAll(r.Context(), r.URL.Query().Get("tahun_ajaran"))
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data ujian", FormatUjianList(result))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// InsertUjian menghandle POST /ujian/tambah. Body JSON berisi nama, jenis (UTS atau UAS), tahun_ajaran,
+// tanggal_mulai, dan tanggal_selesai.
+func (uc *UjianController) InsertUjian(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	var req UjianRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	meta, _ := helper.MetaTokenFromContext(r.Context())
+	data := UjianRequestToCore(req)
+	data.Dibuat_Oleh = meta.ID
+
+	if err := uc.ujianService.Insert(r.Context(), &data); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil menambah ujian", FormatUjianList([]ujian.UjianCore{data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// GetUjianById menghandle GET /ujian/{id} untuk detail ujian beserta sesi, jadwal, ruang, dan pengawasnya.
+// Response membawa header ETag untuk update dan delete.
+func (uc *UjianController) GetUjianById(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	data, err := uc.ujianService.GetById(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengambil data ujian", FormatUjianList([]ujian.UjianCore{*data}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, data.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// UpdateUjian menghandle PUT /ujian/{id}. Field yang kosong tetap memakai nilai lama.
+// Header If-Match wajib diisi dengan ETag terbaru.
+func (uc *UjianController) UpdateUjian(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	var req UjianRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+	data := UjianRequestToCore(req)
+	data.Version = version
+
+	if err := uc.ujianService.Update(r.Context(), &data, id); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	updated, err := uc.ujianService.GetById(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusOK, "Berhasil mengupdate ujian", FormatUjianList([]ujian.UjianCore{*updated}))
+	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updated.Version)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("error encoding response: %v", err)
+	}
+	return nil
+}
+
+// DeleteUjian menghandle DELETE /ujian/{id}. Header If-Match wajib diisi dengan ETag terbaru.
+func (uc *UjianController) DeleteUjian(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return nil
+	}
+
+	if err := uc.ujianService.DeleteById(r.Context(), r.PathValue("id"), version); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil menghapus ujian", nil))
+	return nil
+}
+
+// InsertSesi menghandle POST /ujian/{id}/sesi/tambah. Body JSON berisi tanggal, jam_mulai, jam_selesai (HH:MM),
+// mata_pelajaran_id (daftar penugasan mapel-kelas yang diujikan), dan ruang_id (daftar ruang yang dipakai).
+func (uc *UjianController) InsertSesi(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	var req SesiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	data := SesiRequestToCore(r.PathValue("id"), req)
+	if err := uc.ujianService.InsertSesi(r.Context(), &data); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	created, err := uc.ujianService.GetSesi(r.Context(), data.ID)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse(http.StatusCreated, "Berhasil menambah sesi ujian", FormatSesiList([]ujian.SesiCore{*created}))
+	helper.JSONResponse(w, http.StatusCreated, response)
+	return nil
+}
+
+// GetSesi menghandle GET /ujian/sesi/{id} untuk detail sesi beserta jadwal, ruang, dan pengawasnya.
+func (uc *UjianController) GetSesi(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	data, err := uc.ujianService.GetSesi(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil mengambil data sesi ujian", FormatSesiList([]ujian.SesiCore{*data})))
+	return nil
+}
+
+// DeleteSesi menghandle DELETE /ujian/sesi/{id}. Jadwal, pengawas, dan denah sesi ikut terhapus.
+func (uc *UjianController) DeleteSesi(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	if err := uc.ujianService.DeleteSesi(r.Context(), r.PathValue("id")); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil menghapus sesi ujian", nil))
+	return nil
+}
+
+// AturPengawas menghandle POST /ujian/sesi/{id}/pengawas untuk memilih pengawas otomatis
+// bagi ruang sesi yang belum punya pengawas.
+func (uc *UjianController) AturPengawas(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	data, err := uc.ujianService.AturPengawas(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil mengatur pengawas ujian", FormatSesiList([]ujian.SesiCore{*data})))
+	return nil
+}
+
+// SetPengawas menghandle PUT /ujian/sesi/{id}/ruang/{ruang_id}/pengawas. Body JSON berisi guru_id;
+// kirim guru_id kosong untuk menghapus pengawas ruang tersebut.
+func (uc *UjianController) SetPengawas(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	var req PengawasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "gagal membaca JSON", http.StatusBadRequest)
+		return nil
+	}
+
+	if err := uc.ujianService.SetPengawas(r.Context(), id, r.PathValue("ruang_id"), req.Guru_ID); err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	data, err := uc.ujianService.GetSesi(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil mengatur pengawas ujian", FormatSesiList([]ujian.SesiCore{*data})))
+	return nil
+}
+
+// BuatDenah menghandle POST /ujian/sesi/{id}/denah untuk membuat ulang denah tempat duduk acak.
+func (uc *UjianController) BuatDenah(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+
+	result, err := uc.ujianService.BuatDenah(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	helper.JSONResponse(w, http.StatusCreated, helper.APIResponse(http.StatusCreated, "Berhasil membuat denah tempat duduk", result))
+	return nil
+}
+
+// Denah menghandle GET /ujian/sesi/{id}/denah?format=json|csv|pdf untuk denah tempat duduk sesi.
+// Format bawaan adalah json; csv dan pdf dikirim sebagai file unduhan.
+func (uc *UjianController) Denah(w http.ResponseWriter, r *http.Request) error {
+	if uc == nil || uc.ujianService == nil {
+		return errors.New("ujian controller: service is nil")
+	}
+	id := r.PathValue("id")
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format != "" && format != "json" && format != "csv" && format != "pdf" {
+		http.Error(w, "validation error: format harus json, csv, atau pdf", http.StatusBadRequest)
+		return nil
+	}
+
+	result, err := uc.ujianService.GetDenah(r.Context(), id)
+	if err != nil {
+		if writeUjianError(w, err) {
+			return nil
+		}
+		return err
+	}
+
+	switch format {
+	case "csv":
+		isi, err := FormatDenahCSV(result)
+		if err != nil {
+			return fmt.Errorf("error encoding csv: %v", err)
+		}
+		writeFile(w, "text/csv; charset=utf-8", "denah-"+id+".csv", isi)
+	case "pdf":
+		sesi, err := uc.ujianService.GetSesi(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		induk, err := uc.ujianService.GetById(r.Context(), sesi.Ujian_ID)
+		if err != nil {
+			return err
+		}
+		writeFile(w, "application/pdf", "denah-"+id+".pdf", FormatDenahPDF(*induk, *sesi, result))
+	default:
+		helper.JSONResponse(w, http.StatusOK, helper.APIResponse(http.StatusOK, "Berhasil mengambil denah tempat duduk", result))
+	}
+	return nil
+}
+
+// writeFile menulis isi sebagai file unduhan bernama namaFile.
+func writeFile(w http.ResponseWriter, contentType, namaFile string, isi []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": namaFile}))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(isi)
+}
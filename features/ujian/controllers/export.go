@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go_rest_native_sekolah/features/ujian"
+	"go_rest_native_sekolah/helper"
+	"strconv"
+	"strings"
+)
+
+// kolomDenah adalah header kolom denah tempat duduk pada export CSV dan PDF.
+var kolomDenah = []string{"Ruang", "No. Kursi", "NIS", "Nama Siswa", "Kelas"}
+
+// barisDenah mengubah satu kursi menjadi baris export sesuai kolomDenah.
+func barisDenah(k ujian.KursiCore) []string {
+	return []string{k.Nama_Ruang, strconv.Itoa(k.Nomor_Kursi), k.NIS, k.Nama_Siswa, k.Nama_Kelas}
+}
+
+// FormatDenahCSV membuat denah tempat duduk sebagai CSV dengan baris header.
+func FormatDenahCSV(kursi []ujian.KursiCore) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(kolomDenah); err != nil {
+		return nil, err
+	}
+	for _, k := range kursi {
+		if err := w.Write(barisDenah(k)); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatDenahPDF membuat denah tempat duduk sebagai PDF siap cetak. Keterangan di atas tabel berisi
+// waktu sesi, mata pelajaran setiap kelas, dan pengawas setiap ruang.
+func FormatDenahPDF(u ujian.UjianCore, sesi ujian.SesiCore, kursi []ujian.KursiCore) []byte {
+	keterangan := []string{
+		fmt.Sprintf("%s %s - %s, %s-%s", u.Jenis, u.Tahun_Ajaran, sesi.Tanggal, sesi.Jam_Mulai, sesi.Jam_Selesai),
+	}
+	mapel := make([]string, 0, len(sesi.Jadwal))
+	for _, j := range sesi.Jadwal {
+		mapel = append(mapel, fmt.Sprintf("%s: %s", j.Nama_Kelas, j.Nama_Pelajaran))
+	}
+	keterangan = append(keterangan, "Mata pelajaran: "+strings.Join(mapel, "; "))
+	for _, r := range sesi.Ruang {
+		pengawas := r.Nama_Pengawas
+		if pengawas == "" {
+			pengawas = "-"
+		}
+		keterangan = append(keterangan, fmt.Sprintf("Ruang %s - pengawas: %s", r.Nama_Ruang, pengawas))
+	}
+
+	baris := make([][]string, 0, len(kursi))
+	for _, k := range kursi {
+		baris = append(baris, barisDenah(k))
+	}
+	return helper.PDFTabel{
+		Judul:      "Denah Tempat Duduk " + u.Nama,
+		Keterangan: keterangan,
+		Kolom:      kolomDenah,
+		Baris:      baris,
+	}.Bytes()
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"go_rest_native_sekolah/features/ujian"
+	"time"
+)
+
+// RuangFormatter digunakan untuk memformat ruang ujian pada response API.
+type RuangFormatter struct {
+	ID        string    `json:"id"`        // ID adalah ID unik ruang
+	Nama      string    `json:"nama"`      // Nama adalah nama ruang
+	Kapasitas int       `json:"kapasitas"` // Kapasitas adalah jumlah kursi peserta
+	Update_At time.Time `json:"update_at"` // Update_At adalah waktu perubahan terakhir
+	Version   int       `json:"version"`   // Version adalah versi data ruang, sama dengan ETag
+}
+
+// UjianFormatter digunakan untuk memformat ujian pada response API. Sesi hanya diisi pada detail ujian.
+type UjianFormatter struct {
+	ID              string          `json:"id"`              // ID adalah ID unik ujian
+	Nama            string          `json:"nama"`            // Nama adalah nama periode ujian
+	Jenis           string          `json:"jenis"`           // Jenis adalah UTS atau UAS
+	Tahun_Ajaran    string          `json:"tahun_ajaran"`    // Tahun_Ajaran adalah tahun ajaran ujian, misalnya 2026/2027
+	Tanggal_Mulai   string          `json:"tanggal_mulai"`   // Tanggal_Mulai adalah hari pertama ujian (YYYY-MM-DD)
+	Tanggal_Selesai string          `json:"tanggal_selesai"` // Tanggal_Selesai adalah hari terakhir ujian (YYYY-MM-DD)
+	Dibuat_Oleh     string          `json:"dibuat_oleh"`     // Dibuat_Oleh adalah ID user pembuat
+	Update_At       time.Time       `json:"update_at"`       // Update_At adalah waktu perubahan terakhir
+	Version         int             `json:"version"`         // Version adalah versi data ujian, sama dengan ETag
+	Sesi            []SesiFormatter `json:"sesi,omitempty"`  // Sesi adalah sesi ujian beserta jadwal dan ruangnya
+}
+
+// SesiFormatter digunakan untuk memformat sesi ujian pada response API.
+type SesiFormatter struct {
+	ID          string                `json:"id"`          // ID adalah ID unik sesi
+	Ujian_ID    string                `json:"ujian_id"`    // Ujian_ID adalah ujian pemilik sesi
+	Tanggal     string                `json:"tanggal"`     // Tanggal adalah tanggal sesi (YYYY-MM-DD)
+	Jam_Mulai   string                `json:"jam_mulai"`   // Jam_Mulai adalah jam mulai (HH:MM)
+	Jam_Selesai string                `json:"jam_selesai"` // Jam_Selesai adalah jam selesai (HH:MM)
+	Jadwal      []ujian.JadwalCore    `json:"jadwal"`      // Jadwal adalah mata pelajaran per kelas yang diujikan
+	Ruang       []ujian.RuangSesiCore `json:"ruang"`       // Ruang adalah ruang yang dipakai beserta pengawasnya
+}
+
+// RuangRequest digunakan untuk membaca body JSON tambah dan update ruang.
+type RuangRequest struct {
+	Nama      string `json:"nama"`
+	Kapasitas int    `json:"kapasitas"`
+}
+
+// UjianRequest digunakan untuk membaca body JSON tambah dan update ujian.
+type UjianRequest struct {
+	Nama            string `json:"nama"`
+	Jenis           string `json:"jenis"`
+	Tahun_Ajaran    string `json:"tahun_ajaran"`
+	Tanggal_Mulai   string `json:"tanggal_mulai"`
+	Tanggal_Selesai string `json:"tanggal_selesai"`
+}
+
+// SesiRequest digunakan untuk membaca body JSON tambah sesi ujian.
+type SesiRequest struct {
+	Tanggal           string   `json:"tanggal"`
+	Jam_Mulai         string   `json:"jam_mulai"`
+	Jam_Selesai       string   `json:"jam_selesai"`
+	Mata_Pelajaran_ID []string `json:"mata_pelajaran_id"`
+	Ruang_ID          []string `json:"ruang_id"`
+}
+
+// PengawasRequest digunakan untuk membaca body JSON pengaturan pengawas manual.
+type PengawasRequest struct {
+	Guru_ID string `json:"guru_id"`
+}
+
+// FormatRuangList mengubah slice RuangCore menjadi slice RuangFormatter.
+func FormatRuangList(cores []ujian.RuangCore) []RuangFormatter {
+	formatted := make([]RuangFormatter, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, RuangFormatter{
+			ID:        core.ID,
+			Nama:      core.Nama,
+			Kapasitas: core.Kapasitas,
+			Update_At: core.Update_At,
+			Version:   core.Version,
+		})
+	}
+	return formatted
+}
+
+// FormatUjianList mengubah slice UjianCore menjadi slice UjianFormatter.
+func FormatUjianList(cores []ujian.UjianCore) []UjianFormatter {
+	formatted := make([]UjianFormatter, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, UjianFormatter{
+			ID:              core.ID,
+			Nama:            core.Nama,
+			Jenis:           core.Jenis,
+			Tahun_Ajaran:    core.Tahun_Ajaran,
+			Tanggal_Mulai:   core.Tanggal_Mulai,
+			Tanggal_Selesai: core.Tanggal_Selesai,
+			Dibuat_Oleh:     core.Dibuat_Oleh,
+			Update_At:       core.Update_At,
+			Version:         core.Version,
+			Sesi:            FormatSesiList(core.Sesi),
+		})
+	}
+	return formatted
+}
+
+// FormatSesiList mengubah slice SesiCore menjadi slice SesiFormatter.
+// Mengembalikan nil jika cores nil sehingga field sesi tidak ditampilkan pada daftar ujian.
+func FormatSesiList(cores []ujian.SesiCore) []SesiFormatter {
+	if cores == nil {
+		return nil
+	}
+	formatted := make([]SesiFormatter, 0, len(cores))
+	for _, core := range cores {
+		formatted = append(formatted, SesiFormatter{
+			ID:          core.ID,
+			Ujian_ID:    core.Ujian_ID,
+			Tanggal:     core.Tanggal,
+			Jam_Mulai:   core.Jam_Mulai,
+			Jam_Selesai: core.Jam_Selesai,
+			Jadwal:      core.Jadwal,
+			Ruang:       core.Ruang,
+		})
+	}
+	return formatted
+}
+
+// UjianRequestToCore mengubah UjianRequest menjadi UjianCore.
+func UjianRequestToCore(req UjianRequest) ujian.UjianCore {
+	return ujian.UjianCore{
+		Nama:            req.Nama,
+		Jenis:           req.Jenis,
+		Tahun_Ajaran:    req.Tahun_Ajaran,
+		Tanggal_Mulai:   req.Tanggal_Mulai,
+		Tanggal_Selesai: req.Tanggal_Selesai,
+	}
+}
+
+// SesiRequestToCore mengubah SesiRequest menjadi SesiCore untuk ujian ujianID.
+// Jadwal dan Ruang hanya berisi ID; detailnya dilengkapi service.
+func SesiRequestToCore(ujianID string, req SesiRequest) ujian.SesiCore {
+	core := ujian.SesiCore{
+		Ujian_ID:    ujianID,
+		Tanggal:     req.Tanggal,
+		Jam_Mulai:   req.Jam_Mulai,
+		Jam_Selesai: req.Jam_Selesai,
+	}
+	for _, id := range req.Mata_Pelajaran_ID {
+		core.Jadwal = append(core.Jadwal, ujian.JadwalCore{Mata_Pelajaran_ID: id})
+	}
+	for _, id := range req.Ruang_ID {
+		core.Ruang = append(core.Ruang, ujian.RuangSesiCore{Ruang_ID: id})
+	}
+	return core
+}
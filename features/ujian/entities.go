@@ -0,0 +1,155 @@
+package ujian
+
+import (
+	"context"
+	"time"
+)
+
+// Jenis ujian.
+const (
+	JenisUTS = "UTS" // Ujian tengah semester
+	JenisUAS = "UAS" // Ujian akhir semester
+)
+
+// JenisValid berisi semua jenis ujian yang boleh disimpan.
+var JenisValid = []string{JenisUTS, JenisUAS}
+
+// PengelolaRoles adalah role yang boleh mengatur ujian, ruang, sesi, pengawas, dan denah tempat duduk.
+var PengelolaRoles = []string{"admin"}
+
+// PembacaRoles adalah role yang boleh melihat jadwal ujian, pengawas, dan denah tempat duduk.
+var PembacaRoles = []string{"admin", "guru"}
+
+type (
+	// RuangCore merepresentasikan satu ruang ujian beserta kapasitas kursinya.
+	RuangCore struct {
+		ID        string    `json:"id"`        // ID adalah identifikasi unik ruang.
+		Nama      string    `json:"nama"`      // Nama adalah nama ruang, unik di antara ruang aktif.
+		Kapasitas int       `json:"kapasitas"` // Kapasitas adalah jumlah kursi peserta di dalam ruang.
+		Update_At time.Time `json:"update_at"` // Update_At adalah waktu perubahan terakhir.
+		Version   int       `json:"version"`   // Version adalah versi data untuk optimistic concurrency, dikirim sebagai ETag.
+	}
+
+	// UjianCore merepresentasikan satu periode ujian, misalnya UTS ganjil 2026/2027.
+	// Tanggal memakai format helper.DateLayout dan Tanggal_Selesai ikut dihitung (inklusif).
+	UjianCore struct {
+		ID              string     `json:"id"`              // ID adalah identifikasi unik ujian.
+		Nama            string     `json:"nama"`            // Nama adalah nama periode ujian.
+		Jenis           string     `json:"jenis"`           // Jenis adalah UTS atau UAS.
+		Tahun_Ajaran    string     `json:"tahun_ajaran"`    // Tahun_Ajaran berformat YYYY/YYYY, misalnya 2026/2027.
+		Tanggal_Mulai   string     `json:"tanggal_mulai"`   // Tanggal_Mulai adalah hari pertama ujian.
+		Tanggal_Selesai string     `json:"tanggal_selesai"` // Tanggal_Selesai adalah hari terakhir ujian.
+		Dibuat_Oleh     string     `json:"dibuat_oleh"`     // Dibuat_Oleh adalah ID user pembuat ujian.
+		Update_At       time.Time  `json:"update_at"`       // Update_At adalah waktu perubahan terakhir.
+		Version         int        `json:"version"`         // Version adalah versi data untuk optimistic concurrency, dikirim sebagai ETag.
+		Sesi            []SesiCore `json:"sesi"`            // Sesi hanya diisi pada detail ujian, urut tanggal dan jam.
+	}
+
+	// SesiCore merepresentasikan satu sesi ujian: rentang jam pada satu tanggal, mata pelajaran yang diujikan,
+	// dan ruang yang dipakai. Setiap kelas paling banyak mengikuti satu mata pelajaran dalam satu sesi.
+	SesiCore struct {
+		ID          string          `json:"id"`          // ID adalah identifikasi unik sesi.
+		Ujian_ID    string          `json:"ujian_id"`    // Ujian_ID adalah ujian pemilik sesi.
+		Tanggal     string          `json:"tanggal"`     // Tanggal berformat helper.DateLayout.
+		Jam_Mulai   string          `json:"jam_mulai"`   // Jam_Mulai berformat HH:MM.
+		Jam_Selesai string          `json:"jam_selesai"` // Jam_Selesai berformat HH:MM dan harus setelah Jam_Mulai.
+		Jadwal      []JadwalCore    `json:"jadwal"`      // Jadwal adalah mata pelajaran (per kelas) yang diujikan pada sesi ini.
+		Ruang       []RuangSesiCore `json:"ruang"`       // Ruang adalah ruang yang dipakai beserta pengawasnya, urut nama ruang.
+	}
+
+	// JadwalCore adalah satu mata pelajaran kelas yang diujikan pada sebuah sesi.
+	JadwalCore struct {
+		Mata_Pelajaran_ID string   `json:"mata_pelajaran_id"` // Mata_Pelajaran_ID adalah penugasan mapel ke kelas yang diujikan.
+		Nama_Pelajaran    string   `json:"nama_pelajaran"`    // Nama_Pelajaran diambil dari katalog mapel.
+		Kelas_ID          string   `json:"kelas_id"`          // Kelas_ID adalah kelas peserta ujian.
+		Nama_Kelas        string   `json:"nama_kelas"`        // Nama_Kelas adalah nama kelas peserta.
+		Jumlah_Siswa      int      `json:"jumlah_siswa"`      // Jumlah_Siswa adalah banyaknya siswa aktif di kelas.
+		Pengajar          []string `json:"pengajar"`          // Pengajar adalah ID guru pengajar mata pelajaran ini.
+	}
+
+	// RuangSesiCore adalah satu ruang yang dipakai pada sebuah sesi beserta guru pengawasnya.
+	RuangSesiCore struct {
+		Ruang_ID      string `json:"ruang_id"`      // Ruang_ID adalah ruang yang dipakai.
+		Nama_Ruang    string `json:"nama_ruang"`    // Nama_Ruang adalah nama ruang.
+		Kapasitas     int    `json:"kapasitas"`     // Kapasitas adalah jumlah kursi ruang.
+		Pengawas_ID   string `json:"pengawas_id"`   // Pengawas_ID adalah guru pengawas, kosong jika belum diatur.
+		Nama_Pengawas string `json:"nama_pengawas"` // Nama_Pengawas adalah nama guru pengawas.
+	}
+
+	// KursiCore adalah satu kursi pada denah tempat duduk sebuah sesi.
+	// Nomor kursi dihitung per ruang mulai dari 1, baris demi baris dari depan.
+	KursiCore struct {
+		Ruang_ID    string `json:"ruang_id"`    // Ruang_ID adalah ruang tempat siswa duduk.
+		Nama_Ruang  string `json:"nama_ruang"`  // Nama_Ruang adalah nama ruang.
+		Nomor_Kursi int    `json:"nomor_kursi"` // Nomor_Kursi adalah nomor kursi di dalam ruang.
+		Siswa_ID    string `json:"siswa_id"`    // Siswa_ID adalah siswa peserta ujian.
+		Nama_Siswa  string `json:"nama_siswa"`  // Nama_Siswa adalah nama siswa.
+		NIS         string `json:"nis"`         // NIS adalah nomor induk siswa.
+		Kelas_ID    string `json:"kelas_id"`    // Kelas_ID adalah kelas siswa.
+		Nama_Kelas  string `json:"nama_kelas"`  // Nama_Kelas adalah nama kelas siswa.
+	}
+
+	// GuruCore adalah guru aktif yang dapat ditugaskan sebagai pengawas.
+	GuruCore struct {
+		ID           string `json:"id"`           // ID adalah identifikasi unik guru.
+		Nama         string `json:"nama"`         // Nama adalah nama guru.
+		Jumlah_Tugas int    `json:"jumlah_tugas"` // Jumlah_Tugas adalah banyaknya ruang yang sudah diawasi guru pada ujian yang sama.
+	}
+
+	// DataUjianInterface mendefinisikan operasi tabel ruang_ujian, ujian, sesi_ujian, jadwal_ujian,
+	// ruang_sesi_ujian, dan denah_ujian.
+	DataUjianInterface interface {
+		SelectRuang(ctx context.Context) ([]RuangCore, error)                // Mengambil semua ruang aktif, urut nama.
+		SelectRuangById(ctx context.Context, id string) (*RuangCore, error)  // Mengambil ruang aktif berdasarkan ID, pgx.ErrNoRows jika tidak ada.
+		InsertRuang(ctx context.Context, insert *RuangCore) error            // Menyimpan ruang baru.
+		UpdateRuang(ctx context.Context, update *RuangCore, id string) error // Mengubah ruang jika versinya masih sama dengan update.Version.
+		DeleteRuang(ctx context.Context, id string, version int) error       // Menghapus (soft delete) ruang jika versinya masih sama dengan version.
+
+		SelectAll(ctx context.Context, tahunAjaran string) ([]UjianCore, error) // Mengambil ujian aktif, urut tanggal mulai; tahunAjaran kosong berarti semua.
+		SelectById(ctx context.Context, id string) (*UjianCore, error)          // Mengambil ujian aktif tanpa sesi, pgx.ErrNoRows jika tidak ada.
+		Insert(ctx context.Context, insert *UjianCore) error                    // Menyimpan ujian baru.
+		Update(ctx context.Context, update *UjianCore, id string) error         // Mengubah ujian jika versinya masih sama dengan update.Version.
+		DeleteById(ctx context.Context, id string, version int) error           // Menghapus (soft delete) ujian jika versinya masih sama dengan version.
+
+		SelectSesi(ctx context.Context, ujianID string) ([]SesiCore, error)       // Mengambil sesi ujian lengkap dengan jadwal dan ruangnya, urut tanggal dan jam.
+		SelectSesiById(ctx context.Context, id string) (*SesiCore, error)         // Mengambil satu sesi lengkap pada ujian aktif, pgx.ErrNoRows jika tidak ada.
+		SelectSesiBentrok(ctx context.Context, sesi SesiCore) ([]SesiCore, error) // Mengambil sesi lain pada ujian aktif yang jamnya beririsan dengan sesi.
+		InsertSesi(ctx context.Context, insert *SesiCore) error                   // Menyimpan sesi beserta jadwal dan ruangnya; jalankan di dalam transaksi.
+		DeleteSesi(ctx context.Context, id string) error                          // Menghapus sesi beserta jadwal, ruang, dan denahnya, pgx.ErrNoRows jika tidak ada.
+
+		SelectMataPelajaran(ctx context.Context, ids []string) ([]JadwalCore, error)                // Mengambil mata pelajaran aktif dengan ID tersebut.
+		MataPelajaranTerjadwal(ctx context.Context, ujianID string, ids []string) ([]string, error) // Mengambil ID mata pelajaran yang sudah punya sesi pada ujian.
+		SelectGuru(ctx context.Context, ujianID string) ([]GuruCore, error)                         // Mengambil guru aktif beserta jumlah tugas mengawas pada ujian.
+		SimpanPengawas(ctx context.Context, sesiID, ruangID, guruID string) error                   // Mengatur pengawas ruang pada sesi, pgx.ErrNoRows jika ruang tidak dipakai sesi.
+
+		SelectPeserta(ctx context.Context, sesiID string) ([]KursiCore, error)   // Mengambil siswa aktif dari kelas yang dijadwalkan pada sesi, tanpa ruang dan kursi.
+		SimpanDenah(ctx context.Context, sesiID string, kursi []KursiCore) error // Mengganti denah sesi; jalankan di dalam transaksi.
+		SelectDenah(ctx context.Context, sesiID string) ([]KursiCore, error)     // Mengambil denah sesi, urut nama ruang dan nomor kursi.
+	}
+
+	// ServiceUjianInterface mendefinisikan logika bisnis penjadwalan ujian, pengawas, dan denah tempat duduk.
+	ServiceUjianInterface interface {
+		GetRuang(ctx context.Context) ([]RuangCore, error)                   // Mengambil daftar ruang.
+		GetRuangById(ctx context.Context, id string) (*RuangCore, error)     // Mengambil satu ruang.
+		InsertRuang(ctx context.Context, insert *RuangCore) error            // Memvalidasi dan menyimpan ruang baru.
+		UpdateRuang(ctx context.Context, update *RuangCore, id string) error // Mengubah ruang; field kosong memakai nilai lama.
+		DeleteRuang(ctx context.Context, id string, version int) error       // Menghapus ruang.
+
+		GetAll(ctx context.Context, tahunAjaran string) ([]UjianCore, error) // Mengambil daftar ujian.
+		GetById(ctx context.Context, id string) (*UjianCore, error)          // Mengambil satu ujian beserta sesinya.
+		Insert(ctx context.Context, insert *UjianCore) error                 // Memvalidasi dan menyimpan ujian baru.
+		Update(ctx context.Context, update *UjianCore, id string) error      // Mengubah ujian; field kosong memakai nilai lama.
+		DeleteById(ctx context.Context, id string, version int) error        // Menghapus ujian.
+
+		GetSesi(ctx context.Context, id string) (*SesiCore, error) // Mengambil satu sesi.
+		// InsertSesi menjadwalkan mata pelajaran pada insert.Jadwal ke ruang pada insert.Ruang (cukup ID-nya)
+		// tanpa bentrok kelas maupun ruang dengan sesi lain.
+		InsertSesi(ctx context.Context, insert *SesiCore) error
+		DeleteSesi(ctx context.Context, id string) error // Menghapus sesi.
+
+		AturPengawas(ctx context.Context, sesiID string) (*SesiCore, error)    // Memilih pengawas otomatis untuk ruang sesi yang belum punya pengawas.
+		SetPengawas(ctx context.Context, sesiID, ruangID, guruID string) error // Mengatur pengawas satu ruang secara manual; guruID kosong menghapus pengawas.
+		BuatDenah(ctx context.Context, sesiID string) ([]KursiCore, error)     // Membuat ulang denah tempat duduk acak untuk sesi.
+		GetDenah(ctx context.Context, sesiID string) ([]KursiCore, error)      // Mengambil denah tempat duduk sesi.
+	}
+)
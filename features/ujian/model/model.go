@@ -0,0 +1,117 @@
+package model
+
+import (
+	"go_rest_native_sekolah/features/ujian"
+	"time"
+)
+
+// Ruang merepresentasikan satu baris tabel ruang_ujian.
+type Ruang struct {
+	ID        string     `json:"id"`        // ID adalah identifikasi unik ruang.
+	Nama      string     `json:"nama"`      // Nama adalah nama ruang.
+	Kapasitas int        `json:"kapasitas"` // Kapasitas adalah jumlah kursi peserta.
+	Update_At time.Time  `json:"update_at"` // Update_At adalah waktu perubahan terakhir.
+	Delete_At *time.Time `json:"delete_at"` // Delete_At adalah waktu ruang dihapus, jika ada.
+	Version   int        `json:"version"`   // Version adalah versi data untuk optimistic concurrency.
+}
+
+// TableName mengembalikan nama tabel ruang ujian di database.
+func (r *Ruang) TableName() string {
+	return "ruang_ujian"
+}
+
+// Ujian merepresentasikan satu baris tabel ujian.
+type Ujian struct {
+	ID              string     `json:"id"`              // ID adalah identifikasi unik ujian.
+	Nama            string     `json:"nama"`            // Nama adalah nama periode ujian.
+	Jenis           string     `json:"jenis"`           // Jenis adalah UTS atau UAS.
+	Tahun_Ajaran    string     `json:"tahun_ajaran"`    // Tahun_Ajaran berformat YYYY/YYYY.
+	Tanggal_Mulai   string     `json:"tanggal_mulai"`   // Tanggal_Mulai disimpan sebagai DATE.
+	Tanggal_Selesai string     `json:"tanggal_selesai"` // Tanggal_Selesai disimpan sebagai DATE.
+	Dibuat_Oleh     string     `json:"dibuat_oleh"`     // Dibuat_Oleh adalah ID user pembuat.
+	Update_At       time.Time  `json:"update_at"`       // Update_At adalah waktu perubahan terakhir.
+	Delete_At       *time.Time `json:"delete_at"`       // Delete_At adalah waktu ujian dihapus, jika ada.
+	Version         int        `json:"version"`         // Version adalah versi data untuk optimistic concurrency.
+}
+
+// TableName mengembalikan nama tabel ujian di database.
+func (u *Ujian) TableName() string {
+	return "ujian"
+}
+
+// Sesi merepresentasikan satu baris tabel sesi_ujian.
+type Sesi struct {
+	ID          string `json:"id"`          // ID adalah identifikasi unik sesi.
+	Ujian_ID    string `json:"ujian_id"`    // Ujian_ID adalah ujian pemilik sesi.
+	Tanggal     string `json:"tanggal"`     // Tanggal disimpan sebagai DATE.
+	Jam_Mulai   string `json:"jam_mulai"`   // Jam_Mulai disimpan sebagai TIME.
+	Jam_Selesai string `json:"jam_selesai"` // Jam_Selesai disimpan sebagai TIME.
+}
+
+// TableName mengembalikan nama tabel sesi ujian di database.
+func (s *Sesi) TableName() string {
+	return "sesi_ujian"
+}
+
+// RuangFormatterRequest mengubah RuangCore menjadi Ruang untuk disimpan ke database.
+func RuangFormatterRequest(req ujian.RuangCore) Ruang {
+	return Ruang{
+		ID:        req.ID,
+		Nama:      req.Nama,
+		Kapasitas: req.Kapasitas,
+		Version:   req.Version,
+	}
+}
+
+// RuangFormatterResponse mengubah Ruang dari database menjadi RuangCore.
+func RuangFormatterResponse(res Ruang) ujian.RuangCore {
+	return ujian.RuangCore{
+		ID:        res.ID,
+		Nama:      res.Nama,
+		Kapasitas: res.Kapasitas,
+		Update_At: res.Update_At,
+		Version:   res.Version,
+	}
+}
+
+// FormatterRequest mengubah UjianCore menjadi Ujian untuk disimpan ke database.
+func FormatterRequest(req ujian.UjianCore) Ujian {
+	return Ujian{
+		ID:              req.ID,
+		Nama:            req.Nama,
+		Jenis:           req.Jenis,
+		Tahun_Ajaran:    req.Tahun_Ajaran,
+		Tanggal_Mulai:   req.Tanggal_Mulai,
+		Tanggal_Selesai: req.Tanggal_Selesai,
+		Dibuat_Oleh:     req.Dibuat_Oleh,
+		Version:         req.Version,
+	}
+}
+
+// FormatterResponse mengubah Ujian dari database menjadi UjianCore.
+func FormatterResponse(res Ujian) ujian.UjianCore {
+	return ujian.UjianCore{
+		ID:              res.ID,
+		Nama:            res.Nama,
+		Jenis:           res.Jenis,
+		Tahun_Ajaran:    res.Tahun_Ajaran,
+		Tanggal_Mulai:   res.Tanggal_Mulai,
+		Tanggal_Selesai: res.Tanggal_Selesai,
+		Dibuat_Oleh:     res.Dibuat_Oleh,
+		Update_At:       res.Update_At,
+		Version:         res.Version,
+	}
+}
+
+// SesiFormatterResponse mengubah Sesi dari database menjadi SesiCore tanpa jadwal dan ruang.
+func SesiFormatterResponse(res Sesi) ujian.SesiCore {
+	return ujian.SesiCore{
+		ID:          res.ID,
+		Ujian_ID:    res.Ujian_ID,
+		Tanggal:     res.Tanggal,
+		Jam_Mulai:   res.Jam_Mulai,
+		Jam_Selesai: res.Jam_Selesai,
+		Jadwal:      []ujian.JadwalCore{},
+		Ruang:       []ujian.RuangSesiCore{},
+	}
+}
@@ -0,0 +1,606 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/ujian"
+	"go_rest_native_sekolah/helper"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ujianQuery menghandle query ke tabel ruang_ujian, ujian, sesi_ujian, jadwal_ujian, ruang_sesi_ujian, dan denah_ujian.
+type ujianQuery struct {
+	db helper.DBTX
+}
+
+// NewDataUjian membuat objek ujianQuery dengan parameter db.
+// Jika parameter db nil maka akan terjadi panic.
+func NewDataUjian(db helper.DBTX) ujian.DataUjianInterface {
+	if db == nil {
+		panic("ujian model: Nil database")
+	}
+	return &ujianQuery{db: db}
+}
+
+// SelectRuang implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectRuang(ctx context.Context) ([]ujian.RuangCore, error) {
+	return q.selectRuang(ctx,
+		"SELECT id, nama, kapasitas, update_at, version FROM ruang_ujian WHERE delete_at IS NULL ORDER BY nama, id")
+}
+
+// SelectRuangById implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectRuangById(ctx context.Context, id string) (*ujian.RuangCore, error) {
+	result, err := q.selectRuang(ctx,
+		"SELECT id, nama, kapasitas, update_at, version FROM ruang_ujian WHERE delete_at IS NULL AND id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// selectRuang menjalankan query ruang dan mengubah semua barisnya menjadi RuangCore.
+func (q *ujianQuery) selectRuang(ctx context.Context, query string, args ...any) ([]ujian.RuangCore, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectRuang error query", "error", err)
+		return nil, fmt.Errorf("select ruang ujian failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ujian.RuangCore{}
+	for rows.Next() {
+		var data Ruang
+		if err := rows.Scan(&data.ID, &data.Nama, &data.Kapasitas, &data.Update_At, &data.Version); err != nil {
+			return nil, fmt.Errorf("select ruang ujian failed: %w", err)
+		}
+		result = append(result, RuangFormatterResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select ruang ujian failed: %w", err)
+	}
+	return result, nil
+}
+
+// InsertRuang implements ujian.DataUjianInterface.
+// ID dibuat otomatis jika kosong; Update_At dan Version diisi dari database.
+func (q *ujianQuery) InsertRuang(ctx context.Context, insert *ujian.RuangCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	data := RuangFormatterRequest(*insert)
+	err := q.db.QueryRow(ctx,
+		"INSERT INTO ruang_ujian (id, nama, kapasitas) VALUES ($1, $2, $3) RETURNING update_at, version",
+		data.ID, data.Nama, data.Kapasitas).Scan(&insert.Update_At, &insert.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert ruang ujian error", "error", err)
+		return fmt.Errorf("insert ruang ujian failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully inserted ruang ujian", "id", insert.ID)
+	return nil
+}
+
+// UpdateRuang implements ujian.DataUjianInterface.
+// Mengembalikan helper.ErrVersionConflict jika versinya sudah berubah dan pgx.ErrNoRows jika ruang tidak ada.
+func (q *ujianQuery) UpdateRuang(ctx context.Context, update *ujian.RuangCore, id string) error {
+	if update == nil {
+		return errors.New("update data is nil")
+	}
+
+	data := RuangFormatterRequest(*update)
+	tag, err := q.db.Exec(ctx, `UPDATE ruang_ujian
+		SET nama = $1, kapasitas = $2, update_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $3 AND delete_at IS NULL AND version = $4`,
+		data.Nama, data.Kapasitas, id, data.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Update ruang ujian error", "error", err)
+		return fmt.Errorf("update ruang ujian failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "ruang_ujian", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully updated ruang ujian", "id", id)
+	return nil
+}
+
+// DeleteRuang implements ujian.DataUjianInterface.
+// Ruang hanya ditandai terhapus (soft delete) sehingga sesi dan denah lama tetap bisa ditampilkan.
+func (q *ujianQuery) DeleteRuang(ctx context.Context, id string, version int) error {
+	tag, err := q.db.Exec(ctx,
+		"UPDATE ruang_ujian SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2",
+		id, version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete ruang ujian error", "error", err)
+		return fmt.Errorf("delete ruang ujian failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "ruang_ujian", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted ruang ujian", "id", id)
+	return nil
+}
+
+// kolomUjian adalah daftar kolom yang diambil untuk setiap ujian, sesuai urutan scan di selectUjian.
+const kolomUjian = `SELECT id, nama, jenis, tahun_ajaran, TO_CHAR(tanggal_mulai, 'YYYY-MM-DD'),
+	TO_CHAR(tanggal_selesai, 'YYYY-MM-DD'), COALESCE(dibuat_oleh, ''), update_at, version
+	FROM ujian WHERE delete_at IS NULL`
+
+// selectUjian menjalankan query ujian dan mengubah semua barisnya menjadi UjianCore.
+func (q *ujianQuery) selectUjian(ctx context.Context, query string, args ...any) ([]ujian.UjianCore, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectUjian error query", "error", err)
+		return nil, fmt.Errorf("select ujian failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ujian.UjianCore{}
+	for rows.Next() {
+		var data Ujian
+		err := rows.Scan(&data.ID, &data.Nama, &data.Jenis, &data.Tahun_Ajaran, &data.Tanggal_Mulai,
+			&data.Tanggal_Selesai, &data.Dibuat_Oleh, &data.Update_At, &data.Version)
+		if err != nil {
+			return nil, fmt.Errorf("select ujian failed: %w", err)
+		}
+		result = append(result, FormatterResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select ujian failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectAll implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectAll(ctx context.Context, tahunAjaran string) ([]ujian.UjianCore, error) {
+	return q.selectUjian(ctx,
+		kolomUjian+" AND ($1 = '' OR tahun_ajaran = $1) ORDER BY tanggal_mulai DESC, nama, id", tahunAjaran)
+}
+
+// SelectById implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectById(ctx context.Context, id string) (*ujian.UjianCore, error) {
+	result, err := q.selectUjian(ctx, kolomUjian+" AND id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// Insert implements ujian.DataUjianInterface.
+// ID dibuat otomatis jika kosong; Update_At dan Version diisi dari database.
+func (q *ujianQuery) Insert(ctx context.Context, insert *ujian.UjianCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	data := FormatterRequest(*insert)
+	query := `INSERT INTO ujian (id, nama, jenis, tahun_ajaran, tanggal_mulai, tanggal_selesai, dibuat_oleh)
+		VALUES ($1, $2, $3, $4, $5::date, $6::date, NULLIF($7, ''))
+		RETURNING update_at, version`
+	err := q.db.QueryRow(ctx, query, data.ID, data.Nama, data.Jenis, data.Tahun_Ajaran, data.Tanggal_Mulai,
+		data.Tanggal_Selesai, data.Dibuat_Oleh).Scan(&insert.Update_At, &insert.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert ujian error", "error", err)
+		return fmt.Errorf("insert ujian failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully inserted ujian", "id", insert.ID)
+	return nil
+}
+
+// Update implements ujian.DataUjianInterface.
+// Mengembalikan helper.ErrVersionConflict jika versinya sudah berubah dan pgx.ErrNoRows jika ujian tidak ada.
+func (q *ujianQuery) Update(ctx context.Context, update *ujian.UjianCore, id string) error {
+	if update == nil {
+		return errors.New("update data is nil")
+	}
+
+	data := FormatterRequest(*update)
+	query := `UPDATE ujian
+		SET nama = $1, jenis = $2, tahun_ajaran = $3, tanggal_mulai = $4::date, tanggal_selesai = $5::date,
+			update_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $6 AND delete_at IS NULL AND version = $7`
+	tag, err := q.db.Exec(ctx, query, data.Nama, data.Jenis, data.Tahun_Ajaran, data.Tanggal_Mulai,
+		data.Tanggal_Selesai, id, data.Version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Update ujian error", "error", err)
+		return fmt.Errorf("update ujian failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "ujian", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully updated ujian", "id", id)
+	return nil
+}
+
+// DeleteById implements ujian.DataUjianInterface.
+// Ujian hanya ditandai terhapus (soft delete); sesinya tidak lagi dihitung saat mencari bentrok jadwal.
+func (q *ujianQuery) DeleteById(ctx context.Context, id string, version int) error {
+	tag, err := q.db.Exec(ctx,
+		"UPDATE ujian SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2",
+		id, version)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete ujian error", "error", err)
+		return fmt.Errorf("delete ujian failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return helper.CheckVersionConflict(ctx, q.db, "ujian", id)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted ujian", "id", id)
+	return nil
+}
+
+// kolomSesi adalah daftar kolom yang diambil untuk setiap sesi, sesuai urutan scan di selectSesi.
+// Hanya sesi milik ujian yang belum dihapus yang diambil.
+const kolomSesi = `SELECT s.id, s.ujian_id, TO_CHAR(s.tanggal, 'YYYY-MM-DD'), TO_CHAR(s.jam_mulai, 'HH24:MI'),
+	TO_CHAR(s.jam_selesai, 'HH24:MI')
+	FROM sesi_ujian s JOIN ujian u ON u.id = s.ujian_id WHERE u.delete_at IS NULL`
+
+// urutanSesi adalah klausa ORDER BY untuk kolomSesi.
+const urutanSesi = " ORDER BY s.tanggal, s.jam_mulai, s.id"
+
+// selectSesi menjalankan query sesi lalu melengkapi jadwal dan ruang setiap sesi.
+func (q *ujianQuery) selectSesi(ctx context.Context, query string, args ...any) ([]ujian.SesiCore, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("selectSesi error query", "error", err)
+		return nil, fmt.Errorf("select sesi ujian failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ujian.SesiCore{}
+	for rows.Next() {
+		var data Sesi
+		if err := rows.Scan(&data.ID, &data.Ujian_ID, &data.Tanggal, &data.Jam_Mulai, &data.Jam_Selesai); err != nil {
+			return nil, fmt.Errorf("select sesi ujian failed: %w", err)
+		}
+		result = append(result, SesiFormatterResponse(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select sesi ujian failed: %w", err)
+	}
+	rows.Close()
+
+	if err := q.lengkapiSesi(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// kolomJadwal adalah daftar kolom mata pelajaran yang diujikan, sesuai urutan scan di scanJadwal.
+// Query yang memakainya harus memberi alias mp pada mata_pelajaran, m pada mapel, dan k pada kelas.
+const kolomJadwal = `mp.id, COALESCE(m.nama, ''), COALESCE(mp.kelas_id, ''), COALESCE(k.kelas, ''),
+	(SELECT COUNT(*) FROM siswa s WHERE s.kelas_id = mp.kelas_id AND s.delete_at IS NULL),
+	COALESCE((SELECT array_agg(mpg.id_guru ORDER BY mpg.id_guru) FROM mata_pelajaran_guru mpg
+		WHERE mpg.mata_pelajaran_id = mp.id), '{}')`
+
+// dariJadwal adalah klausa JOIN untuk kolomJadwal setelah tabel mata_pelajaran mp.
+const dariJadwal = ` JOIN mapel m ON m.id = mp.mapel_id LEFT JOIN kelas k ON k.id = mp.kelas_id`
+
+// scanJadwal membaca satu baris kolomJadwal.
+func scanJadwal(row pgx.Row, prefix ...any) (ujian.JadwalCore, error) {
+	var data ujian.JadwalCore
+	dest := append(prefix, &data.Mata_Pelajaran_ID, &data.Nama_Pelajaran, &data.Kelas_ID, &data.Nama_Kelas,
+		&data.Jumlah_Siswa, &data.Pengajar)
+	err := row.Scan(dest...)
+	return data, err
+}
+
+// lengkapiSesi mengisi Jadwal dan Ruang setiap sesi dengan dua query.
+func (q *ujianQuery) lengkapiSesi(ctx context.Context, sesi []ujian.SesiCore) error {
+	if len(sesi) == 0 {
+		return nil
+	}
+	indeks := make(map[string]int, len(sesi))
+	ids := make([]string, len(sesi))
+	for i, s := range sesi {
+		indeks[s.ID] = i
+		ids[i] = s.ID
+	}
+
+	rows, err := q.db.Query(ctx, `SELECT j.sesi_id, `+kolomJadwal+`
+		FROM jadwal_ujian j JOIN mata_pelajaran mp ON mp.id = j.mata_pelajaran_id`+dariJadwal+`
+		WHERE j.sesi_id = ANY($1::text[]) ORDER BY k.kelas, m.nama, mp.id`, ids)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("lengkapiSesi error query jadwal", "error", err)
+		return fmt.Errorf("select jadwal ujian failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sesiID string
+		data, err := scanJadwal(rows, &sesiID)
+		if err != nil {
+			return fmt.Errorf("select jadwal ujian failed: %w", err)
+		}
+		sesi[indeks[sesiID]].Jadwal = append(sesi[indeks[sesiID]].Jadwal, data)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("select jadwal ujian failed: %w", err)
+	}
+	rows.Close()
+
+	rows, err = q.db.Query(ctx, `SELECT rs.sesi_id, r.id, r.nama, r.kapasitas, COALESCE(rs.pengawas_id, ''), COALESCE(g.nama, '')
+		FROM ruang_sesi_ujian rs JOIN ruang_ujian r ON r.id = rs.ruang_id LEFT JOIN guru g ON g.id = rs.pengawas_id
+		WHERE rs.sesi_id = ANY($1::text[]) ORDER BY r.nama, r.id`, ids)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("lengkapiSesi error query ruang", "error", err)
+		return fmt.Errorf("select ruang sesi ujian failed: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sesiID string
+		var data ujian.RuangSesiCore
+		if err := rows.Scan(&sesiID, &data.Ruang_ID, &data.Nama_Ruang, &data.Kapasitas, &data.Pengawas_ID, &data.Nama_Pengawas); err != nil {
+			return fmt.Errorf("select ruang sesi ujian failed: %w", err)
+		}
+		sesi[indeks[sesiID]].Ruang = append(sesi[indeks[sesiID]].Ruang, data)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("select ruang sesi ujian failed: %w", err)
+	}
+	return nil
+}
+
+// SelectSesi implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectSesi(ctx context.Context, ujianID string) ([]ujian.SesiCore, error) {
+	return q.selectSesi(ctx, kolomSesi+" AND s.ujian_id = $1"+urutanSesi, ujianID)
+}
+
+// SelectSesiById implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectSesiById(ctx context.Context, id string) (*ujian.SesiCore, error) {
+	result, err := q.selectSesi(ctx, kolomSesi+" AND s.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &result[0], nil
+}
+
+// SelectSesiBentrok implements ujian.DataUjianInterface.
+// Dua sesi bentrok jika tanggalnya sama dan rentang jamnya beririsan; sesi yang berakhir tepat saat
+// sesi lain dimulai tidak dianggap bentrok. Sesi dengan ID yang sama dengan sesi dikecualikan.
+func (q *ujianQuery) SelectSesiBentrok(ctx context.Context, sesi ujian.SesiCore) ([]ujian.SesiCore, error) {
+	return q.selectSesi(ctx, kolomSesi+` AND s.tanggal = $1::date AND s.jam_mulai < $3::time AND $2::time < s.jam_selesai
+		AND s.id <> $4`+urutanSesi, sesi.Tanggal, sesi.Jam_Mulai, sesi.Jam_Selesai, sesi.ID)
+}
+
+// InsertSesi implements ujian.DataUjianInterface.
+// ID dibuat otomatis jika kosong. Hanya Mata_Pelajaran_ID pada Jadwal dan Ruang_ID pada Ruang yang disimpan.
+func (q *ujianQuery) InsertSesi(ctx context.Context, insert *ujian.SesiCore) error {
+	if insert == nil {
+		return errors.New("insert data is nil")
+	}
+	if insert.ID == "" {
+		insert.ID = uuid.New().String()
+	}
+
+	_, err := q.db.Exec(ctx, `INSERT INTO sesi_ujian (id, ujian_id, tanggal, jam_mulai, jam_selesai)
+		VALUES ($1, $2, $3::date, $4::time, $5::time)`,
+		insert.ID, insert.Ujian_ID, insert.Tanggal, insert.Jam_Mulai, insert.Jam_Selesai)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert sesi ujian error", "error", err)
+		return fmt.Errorf("insert sesi ujian failed: %w", err)
+	}
+
+	mapelIDs := make([]string, len(insert.Jadwal))
+	for i, j := range insert.Jadwal {
+		mapelIDs[i] = j.Mata_Pelajaran_ID
+	}
+	_, err = q.db.Exec(ctx, "INSERT INTO jadwal_ujian (sesi_id, mata_pelajaran_id) SELECT $1, unnest($2::text[])",
+		insert.ID, mapelIDs)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert jadwal ujian error", "error", err)
+		return fmt.Errorf("insert jadwal ujian failed: %w", err)
+	}
+
+	ruangIDs := make([]string, len(insert.Ruang))
+	for i, r := range insert.Ruang {
+		ruangIDs[i] = r.Ruang_ID
+	}
+	_, err = q.db.Exec(ctx, "INSERT INTO ruang_sesi_ujian (sesi_id, ruang_id) SELECT $1, unnest($2::text[])",
+		insert.ID, ruangIDs)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Insert ruang sesi ujian error", "error", err)
+		return fmt.Errorf("insert ruang sesi ujian failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully inserted sesi ujian", "id", insert.ID)
+	return nil
+}
+
+// DeleteSesi implements ujian.DataUjianInterface.
+// Jadwal, ruang, dan denah sesi ikut terhapus lewat ON DELETE CASCADE.
+func (q *ujianQuery) DeleteSesi(ctx context.Context, id string) error {
+	tag, err := q.db.Exec(ctx, "DELETE FROM sesi_ujian WHERE id = $1", id)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("Delete sesi ujian error", "error", err)
+		return fmt.Errorf("delete sesi ujian failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully deleted sesi ujian", "id", id)
+	return nil
+}
+
+// SelectMataPelajaran implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectMataPelajaran(ctx context.Context, ids []string) ([]ujian.JadwalCore, error) {
+	rows, err := q.db.Query(ctx, `SELECT `+kolomJadwal+` FROM mata_pelajaran mp`+dariJadwal+`
+		WHERE mp.id = ANY($1::text[]) AND mp.delete_at IS NULL ORDER BY k.kelas, m.nama, mp.id`, ids)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectMataPelajaran error query", "error", err)
+		return nil, fmt.Errorf("select mata pelajaran failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ujian.JadwalCore{}
+	for rows.Next() {
+		data, err := scanJadwal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("select mata pelajaran failed: %w", err)
+		}
+		result = append(result, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select mata pelajaran failed: %w", err)
+	}
+	return result, nil
+}
+
+// MataPelajaranTerjadwal implements ujian.DataUjianInterface.
+func (q *ujianQuery) MataPelajaranTerjadwal(ctx context.Context, ujianID string, ids []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, `SELECT DISTINCT j.mata_pelajaran_id FROM jadwal_ujian j
+		JOIN sesi_ujian s ON s.id = j.sesi_id
+		WHERE s.ujian_id = $1 AND j.mata_pelajaran_id = ANY($2::text[])`, ujianID, ids)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("MataPelajaranTerjadwal error query", "error", err)
+		return nil, fmt.Errorf("select jadwal ujian failed: %w", err)
+	}
+	result, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("select jadwal ujian failed: %w", err)
+	}
+	return result, nil
+}
+
+// SelectGuru implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectGuru(ctx context.Context, ujianID string) ([]ujian.GuruCore, error) {
+	rows, err := q.db.Query(ctx, `SELECT g.id, g.nama,
+			(SELECT COUNT(*) FROM ruang_sesi_ujian rs JOIN sesi_ujian s ON s.id = rs.sesi_id
+				WHERE rs.pengawas_id = g.id AND s.ujian_id = $1)
+		FROM guru g WHERE g.delete_at IS NULL ORDER BY g.nama, g.id`, ujianID)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectGuru error query", "error", err)
+		return nil, fmt.Errorf("select guru failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ujian.GuruCore{}
+	for rows.Next() {
+		var data ujian.GuruCore
+		if err := rows.Scan(&data.ID, &data.Nama, &data.Jumlah_Tugas); err != nil {
+			return nil, fmt.Errorf("select guru failed: %w", err)
+		}
+		result = append(result, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select guru failed: %w", err)
+	}
+	return result, nil
+}
+
+// SimpanPengawas implements ujian.DataUjianInterface.
+// guruID kosong menghapus pengawas ruang tersebut.
+func (q *ujianQuery) SimpanPengawas(ctx context.Context, sesiID, ruangID, guruID string) error {
+	tag, err := q.db.Exec(ctx,
+		"UPDATE ruang_sesi_ujian SET pengawas_id = NULLIF($3, '') WHERE sesi_id = $1 AND ruang_id = $2",
+		sesiID, ruangID, guruID)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SimpanPengawas error", "error", err)
+		return fmt.Errorf("simpan pengawas failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully saved pengawas ujian", "sesi_id", sesiID, "ruang_id", ruangID, "guru_id", guruID)
+	return nil
+}
+
+// SelectPeserta implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectPeserta(ctx context.Context, sesiID string) ([]ujian.KursiCore, error) {
+	rows, err := q.db.Query(ctx, `SELECT s.id, s.nama, COALESCE(s.nis, ''), s.kelas_id, COALESCE(k.kelas, '')
+		FROM jadwal_ujian j
+		JOIN mata_pelajaran mp ON mp.id = j.mata_pelajaran_id
+		JOIN siswa s ON s.kelas_id = mp.kelas_id AND s.delete_at IS NULL
+		LEFT JOIN kelas k ON k.id = s.kelas_id
+		WHERE j.sesi_id = $1 ORDER BY s.kelas_id, s.nama, s.id`, sesiID)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectPeserta error query", "error", err)
+		return nil, fmt.Errorf("select peserta ujian failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ujian.KursiCore{}
+	for rows.Next() {
+		var data ujian.KursiCore
+		if err := rows.Scan(&data.Siswa_ID, &data.Nama_Siswa, &data.NIS, &data.Kelas_ID, &data.Nama_Kelas); err != nil {
+			return nil, fmt.Errorf("select peserta ujian failed: %w", err)
+		}
+		result = append(result, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select peserta ujian failed: %w", err)
+	}
+	return result, nil
+}
+
+// SimpanDenah implements ujian.DataUjianInterface.
+// Denah lama sesi dihapus lalu diganti dengan kursi yang baru.
+func (q *ujianQuery) SimpanDenah(ctx context.Context, sesiID string, kursi []ujian.KursiCore) error {
+	if _, err := q.db.Exec(ctx, "DELETE FROM denah_ujian WHERE sesi_id = $1", sesiID); err != nil {
+		helper.LoggerFromContext(ctx).Error("SimpanDenah error delete", "error", err)
+		return fmt.Errorf("simpan denah ujian failed: %w", err)
+	}
+
+	siswaIDs := make([]string, len(kursi))
+	ruangIDs := make([]string, len(kursi))
+	nomor := make([]int32, len(kursi))
+	for i, k := range kursi {
+		siswaIDs[i] = k.Siswa_ID
+		ruangIDs[i] = k.Ruang_ID
+		nomor[i] = int32(k.Nomor_Kursi)
+	}
+	_, err := q.db.Exec(ctx, `INSERT INTO denah_ujian (sesi_id, siswa_id, ruang_id, nomor_kursi)
+		SELECT $1, unnest($2::text[]), unnest($3::text[]), unnest($4::int[])`, sesiID, siswaIDs, ruangIDs, nomor)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SimpanDenah error insert", "error", err)
+		return fmt.Errorf("simpan denah ujian failed: %w", err)
+	}
+	helper.LoggerFromContext(ctx).Info("Successfully saved denah ujian", "sesi_id", sesiID, "kursi", len(kursi))
+	return nil
+}
+
+// SelectDenah implements ujian.DataUjianInterface.
+func (q *ujianQuery) SelectDenah(ctx context.Context, sesiID string) ([]ujian.KursiCore, error) {
+	rows, err := q.db.Query(ctx, `SELECT d.ruang_id, r.nama, d.nomor_kursi, s.id, s.nama, COALESCE(s.nis, ''),
+			COALESCE(s.kelas_id, ''), COALESCE(k.kelas, '')
+		FROM denah_ujian d
+		JOIN ruang_ujian r ON r.id = d.ruang_id
+		JOIN siswa s ON s.id = d.siswa_id
+		LEFT JOIN kelas k ON k.id = s.kelas_id
+		WHERE d.sesi_id = $1 ORDER BY r.nama, r.id, d.nomor_kursi`, sesiID)
+	if err != nil {
+		helper.LoggerFromContext(ctx).Error("SelectDenah error query", "error", err)
+		return nil, fmt.Errorf("select denah ujian failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []ujian.KursiCore{}
+	for rows.Next() {
+		var data ujian.KursiCore
+		err := rows.Scan(&data.Ruang_ID, &data.Nama_Ruang, &data.Nomor_Kursi, &data.Siswa_ID, &data.Nama_Siswa,
+			&data.NIS, &data.Kelas_ID, &data.Nama_Kelas)
+		if err != nil {
+			return nil, fmt.Errorf("select denah ujian failed: %w", err)
+		}
+		result = append(result, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select denah ujian failed: %w", err)
+	}
+	return result, nil
+}
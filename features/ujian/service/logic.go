@@ -0,0 +1,739 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/features/ujian"
+	"go_rest_native_sekolah/helper"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errUjianNotFound = errors.New("ujian service: Data tidak ditemukan")
+	errRuangNotFound = errors.New("ujian service: Ruang tidak ditemukan")
+	errSesiNotFound  = errors.New("ujian service: Sesi tidak ditemukan")
+)
+
+// maxNama adalah panjang maksimum nama ujian dan nama ruang, sama dengan kolom nama di database.
+const maxNama = 100
+
+// tahunAjaranRegex memvalidasi format tahun ajaran YYYY/YYYY.
+var tahunAjaranRegex = regexp.MustCompile(`^([0-9]{4})/([0-9]{4})$`)
+
+// ujianService merepresentasikan service untuk penjadwalan ujian.
+type ujianService struct {
+	ujianData ujian.DataUjianInterface                    // ujianData berisi akses ke tabel ujian dan turunannya
+	uow       helper.UnitOfWork[ujian.DataUjianInterface] // uow menyimpan sesi, pengawas, dan denah dalam satu transaksi
+	acak      func(n int, swap func(i, j int))            // acak mengacak urutan siswa, diganti saat pengujian
+}
+
+// NewServiceUjian membuat service penjadwalan ujian.
+// Parameter uow dipakai untuk menyimpan sesi beserta jadwal dan ruangnya, pengawas, dan denah dalam satu transaksi.
+// Jika parameter repo atau uow nil maka akan terjadi panic.
+func NewServiceUjian(repo ujian.DataUjianInterface, uow helper.UnitOfWork[ujian.DataUjianInterface]) ujian.ServiceUjianInterface {
+	if repo == nil || uow == nil {
+		panic("ujian service: Nil repository atau unit of work")
+	}
+	return &ujianService{ujianData: repo, uow: uow, acak: rand.Shuffle}
+}
+
+// GetRuang implements ujian.ServiceUjianInterface.
+func (s *ujianService) GetRuang(ctx context.Context) ([]ujian.RuangCore, error) {
+	result, err := s.ujianData.SelectRuang(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil ruang: %w", err)
+	}
+	return result, nil
+}
+
+// GetRuangById implements ujian.ServiceUjianInterface.
+func (s *ujianService) GetRuangById(ctx context.Context, id string) (*ujian.RuangCore, error) {
+	result, err := s.ujianData.SelectRuangById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errRuangNotFound
+		}
+		return nil, fmt.Errorf("ujian service: gagal mengambil ruang: %w", err)
+	}
+	return result, nil
+}
+
+// InsertRuang implements ujian.ServiceUjianInterface.
+func (s *ujianService) InsertRuang(ctx context.Context, insert *ujian.RuangCore) error {
+	if insert == nil {
+		return errors.New("ujian service: input is nil")
+	}
+	if err := s.validasiRuang(ctx, insert, ""); err != nil {
+		return err
+	}
+
+	insert.ID = ""
+	if err := s.ujianData.InsertRuang(ctx, insert); err != nil {
+		return fmt.Errorf("ujian service: gagal menyimpan ruang: %w", err)
+	}
+	return nil
+}
+
+// UpdateRuang implements ujian.ServiceUjianInterface.
+// Nama kosong dan kapasitas 0 memakai nilai lama.
+func (s *ujianService) UpdateRuang(ctx context.Context, update *ujian.RuangCore, id string) error {
+	if update == nil {
+		return errors.New("ujian service: input is nil")
+	}
+	existing, err := s.GetRuangById(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Tolak update jika data sudah diubah sejak client mengambilnya (If-Match)
+	if update.Version != existing.Version {
+		return helper.ErrVersionConflict
+	}
+
+	if strings.TrimSpace(update.Nama) == "" {
+		update.Nama = existing.Nama
+	}
+	if update.Kapasitas == 0 {
+		update.Kapasitas = existing.Kapasitas
+	}
+	if err := s.validasiRuang(ctx, update, id); err != nil {
+		return err
+	}
+
+	if err := s.ujianData.UpdateRuang(ctx, update, id); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errRuangNotFound
+		}
+		return fmt.Errorf("ujian service: gagal update ruang: %w", err)
+	}
+	return nil
+}
+
+// DeleteRuang implements ujian.ServiceUjianInterface.
+// Sesi yang sudah memakai ruang tetap menyimpan ruang tersebut.
+func (s *ujianService) DeleteRuang(ctx context.Context, id string, version int) error {
+	if err := s.ujianData.DeleteRuang(ctx, id, version); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errRuangNotFound
+		}
+		return fmt.Errorf("ujian service: gagal menghapus ruang: %w", err)
+	}
+	return nil
+}
+
+// validasiRuang merapikan dan memeriksa ruang sebelum disimpan. Parameter id adalah ruang yang sedang
+// diubah sehingga namanya sendiri tidak dianggap duplikat.
+func (s *ujianService) validasiRuang(ctx context.Context, r *ujian.RuangCore, id string) error {
+	r.Nama = strings.TrimSpace(r.Nama)
+	if r.Nama == "" {
+		return errors.New("validation error: nama ruang harus diisi")
+	}
+	if utf8.RuneCountInString(r.Nama) > maxNama {
+		return fmt.Errorf("validation error: nama ruang maksimal %d karakter", maxNama)
+	}
+	if r.Kapasitas <= 0 {
+		return errors.New("validation error: kapasitas harus lebih dari 0")
+	}
+
+	semua, err := s.ujianData.SelectRuang(ctx)
+	if err != nil {
+		return fmt.Errorf("ujian service: gagal mengambil ruang: %w", err)
+	}
+	for _, lain := range semua {
+		if lain.ID != id && strings.EqualFold(lain.Nama, r.Nama) {
+			return fmt.Errorf("validation error: ruang '%s' sudah ada", r.Nama)
+		}
+	}
+	return nil
+}
+
+// GetAll implements ujian.ServiceUjianInterface.
+func (s *ujianService) GetAll(ctx context.Context, tahunAjaran string) ([]ujian.UjianCore, error) {
+	result, err := s.ujianData.SelectAll(ctx, strings.TrimSpace(tahunAjaran))
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// GetById implements ujian.ServiceUjianInterface.
+// Sesi ujian beserta jadwal, ruang, dan pengawasnya ikut diambil.
+func (s *ujianService) GetById(ctx context.Context, id string) (*ujian.UjianCore, error) {
+	result, err := s.getUjian(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if result.Sesi, err = s.ujianData.SelectSesi(ctx, id); err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil sesi: %w", err)
+	}
+	return result, nil
+}
+
+// getUjian mengambil ujian tanpa sesinya.
+func (s *ujianService) getUjian(ctx context.Context, id string) (*ujian.UjianCore, error) {
+	result, err := s.ujianData.SelectById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errUjianNotFound
+		}
+		return nil, fmt.Errorf("ujian service: gagal mengambil data: %w", err)
+	}
+	return result, nil
+}
+
+// Insert implements ujian.ServiceUjianInterface.
+// Tanggal_Selesai yang kosong disamakan dengan Tanggal_Mulai.
+func (s *ujianService) Insert(ctx context.Context, insert *ujian.UjianCore) error {
+	if insert == nil {
+		return errors.New("ujian service: input is nil")
+	}
+	if err := validasiUjian(insert); err != nil {
+		return err
+	}
+
+	insert.ID = ""
+	if err := s.ujianData.Insert(ctx, insert); err != nil {
+		return fmt.Errorf("ujian service: gagal menyimpan data: %w", err)
+	}
+	return nil
+}
+
+// Update implements ujian.ServiceUjianInterface.
+// Field kosong memakai nilai lama. Rentang tanggal baru harus tetap mencakup semua sesi yang sudah dijadwalkan.
+func (s *ujianService) Update(ctx context.Context, update *ujian.UjianCore, id string) error {
+	if update == nil {
+		return errors.New("ujian service: input is nil")
+	}
+	existing, err := s.getUjian(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Tolak update jika data sudah diubah sejak client mengambilnya (If-Match)
+	if update.Version != existing.Version {
+		return helper.ErrVersionConflict
+	}
+
+	if strings.TrimSpace(update.Nama) == "" {
+		update.Nama = existing.Nama
+	}
+	if strings.TrimSpace(update.Jenis) == "" {
+		update.Jenis = existing.Jenis
+	}
+	if strings.TrimSpace(update.Tahun_Ajaran) == "" {
+		update.Tahun_Ajaran = existing.Tahun_Ajaran
+	}
+	if strings.TrimSpace(update.Tanggal_Mulai) == "" {
+		update.Tanggal_Mulai = existing.Tanggal_Mulai
+	}
+	if strings.TrimSpace(update.Tanggal_Selesai) == "" {
+		update.Tanggal_Selesai = existing.Tanggal_Selesai
+	}
+	if err := validasiUjian(update); err != nil {
+		return err
+	}
+
+	sesi, err := s.ujianData.SelectSesi(ctx, id)
+	if err != nil {
+		return fmt.Errorf("ujian service: gagal mengambil sesi: %w", err)
+	}
+	for _, se := range sesi {
+		// Tanggal berformat YYYY-MM-DD sehingga bisa dibandingkan sebagai string
+		if se.Tanggal < update.Tanggal_Mulai || se.Tanggal > update.Tanggal_Selesai {
+			return fmt.Errorf("validation error: sesi tanggal %s berada di luar rentang tanggal ujian yang baru", se.Tanggal)
+		}
+	}
+
+	if err := s.ujianData.Update(ctx, update, id); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errUjianNotFound
+		}
+		return fmt.Errorf("ujian service: gagal update data: %w", err)
+	}
+	return nil
+}
+
+// DeleteById implements ujian.ServiceUjianInterface.
+func (s *ujianService) DeleteById(ctx context.Context, id string, version int) error {
+	if err := s.ujianData.DeleteById(ctx, id, version); err != nil {
+		if errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errUjianNotFound
+		}
+		return fmt.Errorf("ujian service: gagal menghapus data: %w", err)
+	}
+	return nil
+}
+
+// validasiUjian merapikan dan memeriksa ujian sebelum disimpan.
+func validasiUjian(u *ujian.UjianCore) error {
+	u.Nama = strings.TrimSpace(u.Nama)
+	if u.Nama == "" {
+		return errors.New("validation error: nama harus diisi")
+	}
+	if utf8.RuneCountInString(u.Nama) > maxNama {
+		return fmt.Errorf("validation error: nama maksimal %d karakter", maxNama)
+	}
+
+	u.Jenis = strings.ToUpper(strings.TrimSpace(u.Jenis))
+	if !slices.Contains(ujian.JenisValid, u.Jenis) {
+		return fmt.Errorf("validation error: jenis harus salah satu dari %s", strings.Join(ujian.JenisValid, ", "))
+	}
+
+	u.Tahun_Ajaran = strings.TrimSpace(u.Tahun_Ajaran)
+	match := tahunAjaranRegex.FindStringSubmatch(u.Tahun_Ajaran)
+	if match == nil {
+		return errors.New("validation error: tahun_ajaran harus berformat YYYY/YYYY, misalnya 2026/2027")
+	}
+	tahunAwal, _ := strconv.Atoi(match[1])
+	tahunAkhir, _ := strconv.Atoi(match[2])
+	if tahunAkhir != tahunAwal+1 {
+		return errors.New("validation error: tahun_ajaran harus dua tahun berurutan, misalnya 2026/2027")
+	}
+
+	mulai, err := helper.ParseRequiredDate("tanggal_mulai", u.Tanggal_Mulai)
+	if err != nil {
+		return err
+	}
+	selesai := mulai
+	if strings.TrimSpace(u.Tanggal_Selesai) != "" {
+		if selesai, err = helper.ParseRequiredDate("tanggal_selesai", u.Tanggal_Selesai); err != nil {
+			return err
+		}
+	}
+	if selesai.Before(mulai) {
+		return errors.New("validation error: tanggal_selesai tidak boleh sebelum tanggal_mulai")
+	}
+	if mulai.Year() < tahunAwal || selesai.Year() > tahunAkhir {
+		return fmt.Errorf("validation error: tanggal ujian harus berada di tahun %d atau %d", tahunAwal, tahunAkhir)
+	}
+	u.Tanggal_Mulai = mulai.Format(helper.DateLayout)
+	u.Tanggal_Selesai = selesai.Format(helper.DateLayout)
+	return nil
+}
+
+// GetSesi implements ujian.ServiceUjianInterface.
+func (s *ujianService) GetSesi(ctx context.Context, id string) (*ujian.SesiCore, error) {
+	result, err := s.ujianData.SelectSesiById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errSesiNotFound
+		}
+		return nil, fmt.Errorf("ujian service: gagal mengambil sesi: %w", err)
+	}
+	return result, nil
+}
+
+// InsertSesi implements ujian.ServiceUjianInterface.
+// Sesi ditolak jika tanggalnya di luar rentang ujian, jika mata pelajaran sudah punya sesi pada ujian yang sama,
+// jika satu kelas mendapat dua mata pelajaran, jika kelas atau ruang sudah dipakai sesi lain pada jam yang
+// beririsan (termasuk sesi ujian lain), atau jika kapasitas ruang kurang dari jumlah peserta.
+func (s *ujianService) InsertSesi(ctx context.Context, insert *ujian.SesiCore) error {
+	if insert == nil {
+		return errors.New("ujian service: input is nil")
+	}
+	induk, err := s.getUjian(ctx, insert.Ujian_ID)
+	if err != nil {
+		return err
+	}
+
+	tanggal, err := helper.NormalizeDate("tanggal", insert.Tanggal, false)
+	if err != nil {
+		return err
+	}
+	if tanggal == "" {
+		return errors.New("validation error: tanggal harus diisi")
+	}
+	if tanggal < induk.Tanggal_Mulai || tanggal > induk.Tanggal_Selesai {
+		return fmt.Errorf("validation error: tanggal harus di antara %s dan %s", induk.Tanggal_Mulai, induk.Tanggal_Selesai)
+	}
+	insert.Tanggal = tanggal
+
+	mulai, err := helper.ParseRequiredTime("jam_mulai", insert.Jam_Mulai)
+	if err != nil {
+		return err
+	}
+	selesai, err := helper.ParseRequiredTime("jam_selesai", insert.Jam_Selesai)
+	if err != nil {
+		return err
+	}
+	if !selesai.After(mulai) {
+		return errors.New("validation error: jam_selesai harus setelah jam_mulai")
+	}
+	insert.Jam_Mulai = mulai.Format(helper.TimeLayout)
+	insert.Jam_Selesai = selesai.Format(helper.TimeLayout)
+
+	mapelIDs := unik(insert.Jadwal, func(j ujian.JadwalCore) string { return j.Mata_Pelajaran_ID })
+	if len(mapelIDs) == 0 {
+		return errors.New("validation error: mata_pelajaran_id harus diisi minimal satu")
+	}
+	ruangIDs := unik(insert.Ruang, func(r ujian.RuangSesiCore) string { return r.Ruang_ID })
+	if len(ruangIDs) == 0 {
+		return errors.New("validation error: ruang_id harus diisi minimal satu")
+	}
+
+	if insert.Jadwal, err = s.jadwalSesi(ctx, insert.Ujian_ID, mapelIDs); err != nil {
+		return err
+	}
+	if insert.Ruang, err = s.ruangSesi(ctx, ruangIDs); err != nil {
+		return err
+	}
+
+	peserta, kapasitas := 0, 0
+	for _, j := range insert.Jadwal {
+		peserta += j.Jumlah_Siswa
+	}
+	for _, r := range insert.Ruang {
+		kapasitas += r.Kapasitas
+	}
+	if kapasitas < peserta {
+		return fmt.Errorf("validation error: kapasitas ruang (%d kursi) kurang dari jumlah peserta (%d siswa)", kapasitas, peserta)
+	}
+
+	insert.ID = ""
+	bentrok, err := s.ujianData.SelectSesiBentrok(ctx, *insert)
+	if err != nil {
+		return fmt.Errorf("ujian service: gagal mengambil sesi: %w", err)
+	}
+	for _, lain := range bentrok {
+		for _, j := range lain.Jadwal {
+			if slices.ContainsFunc(insert.Jadwal, func(b ujian.JadwalCore) bool { return b.Kelas_ID == j.Kelas_ID }) {
+				return fmt.Errorf("validation error: kelas %s sudah ujian %s pada %s %s-%s",
+					j.Nama_Kelas, j.Nama_Pelajaran, lain.Tanggal, lain.Jam_Mulai, lain.Jam_Selesai)
+			}
+		}
+		for _, r := range lain.Ruang {
+			if slices.Contains(ruangIDs, r.Ruang_ID) {
+				return fmt.Errorf("validation error: ruang %s sudah dipakai pada %s %s-%s",
+					r.Nama_Ruang, lain.Tanggal, lain.Jam_Mulai, lain.Jam_Selesai)
+			}
+		}
+	}
+
+	err = s.uow.Do(ctx, func(repo ujian.DataUjianInterface) error {
+		return repo.InsertSesi(ctx, insert)
+	})
+	if err != nil {
+		return fmt.Errorf("ujian service: gagal menyimpan sesi: %w", err)
+	}
+	return nil
+}
+
+// jadwalSesi mengambil mata pelajaran yang akan diujikan dan memastikan setiap kelas hanya mendapat
+// satu mata pelajaran serta mata pelajaran tersebut belum dijadwalkan pada ujian yang sama.
+func (s *ujianService) jadwalSesi(ctx context.Context, ujianID string, mapelIDs []string) ([]ujian.JadwalCore, error) {
+	jadwal, err := s.ujianData.SelectMataPelajaran(ctx, mapelIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil mata pelajaran: %w", err)
+	}
+	for _, id := range mapelIDs {
+		if !slices.ContainsFunc(jadwal, func(j ujian.JadwalCore) bool { return j.Mata_Pelajaran_ID == id }) {
+			return nil, fmt.Errorf("validation error: mata pelajaran '%s' tidak ditemukan", id)
+		}
+	}
+
+	kelas := map[string]string{}
+	for _, j := range jadwal {
+		if j.Kelas_ID == "" {
+			return nil, fmt.Errorf("validation error: mata pelajaran %s belum punya kelas", j.Nama_Pelajaran)
+		}
+		if lain, ok := kelas[j.Kelas_ID]; ok {
+			return nil, fmt.Errorf("validation error: kelas %s tidak boleh ujian %s dan %s pada sesi yang sama",
+				j.Nama_Kelas, lain, j.Nama_Pelajaran)
+		}
+		kelas[j.Kelas_ID] = j.Nama_Pelajaran
+	}
+
+	terjadwal, err := s.ujianData.MataPelajaranTerjadwal(ctx, ujianID, mapelIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil jadwal: %w", err)
+	}
+	if len(terjadwal) > 0 {
+		for _, j := range jadwal {
+			if slices.Contains(terjadwal, j.Mata_Pelajaran_ID) {
+				return nil, fmt.Errorf("validation error: %s kelas %s sudah dijadwalkan pada ujian ini", j.Nama_Pelajaran, j.Nama_Kelas)
+			}
+		}
+	}
+	return jadwal, nil
+}
+
+// ruangSesi mengambil ruang aktif yang akan dipakai sesi, urut nama seperti yang dikembalikan repository.
+func (s *ujianService) ruangSesi(ctx context.Context, ruangIDs []string) ([]ujian.RuangSesiCore, error) {
+	semua, err := s.ujianData.SelectRuang(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil ruang: %w", err)
+	}
+	result := make([]ujian.RuangSesiCore, 0, len(ruangIDs))
+	for _, r := range semua {
+		if slices.Contains(ruangIDs, r.ID) {
+			result = append(result, ujian.RuangSesiCore{Ruang_ID: r.ID, Nama_Ruang: r.Nama, Kapasitas: r.Kapasitas})
+		}
+	}
+	for _, id := range ruangIDs {
+		if !slices.ContainsFunc(result, func(r ujian.RuangSesiCore) bool { return r.Ruang_ID == id }) {
+			return nil, fmt.Errorf("validation error: ruang '%s' tidak ditemukan", id)
+		}
+	}
+	return result, nil
+}
+
+// DeleteSesi implements ujian.ServiceUjianInterface.
+func (s *ujianService) DeleteSesi(ctx context.Context, id string) error {
+	if _, err := s.GetSesi(ctx, id); err != nil {
+		return err
+	}
+	if err := s.ujianData.DeleteSesi(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errSesiNotFound
+		}
+		return fmt.Errorf("ujian service: gagal menghapus sesi: %w", err)
+	}
+	return nil
+}
+
+// AturPengawas implements ujian.ServiceUjianInterface.
+// Hanya ruang yang belum punya pengawas yang diisi. Guru yang sedang mengawas pada sesi lain di jam yang
+// beririsan tidak dipilih. Guru yang tidak mengajar mata pelajaran yang diujikan didahulukan, lalu guru
+// dengan tugas mengawas paling sedikit pada ujian yang sama agar beban merata.
+func (s *ujianService) AturPengawas(ctx context.Context, sesiID string) (*ujian.SesiCore, error) {
+	sesi, err := s.GetSesi(ctx, sesiID)
+	if err != nil {
+		return nil, err
+	}
+	sibuk, err := s.pengawasSibuk(ctx, *sesi)
+	if err != nil {
+		return nil, err
+	}
+	guru, err := s.ujianData.SelectGuru(ctx, sesi.Ujian_ID)
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil guru: %w", err)
+	}
+
+	pengajar := map[string]bool{}
+	for _, j := range sesi.Jadwal {
+		for _, id := range j.Pengajar {
+			pengajar[id] = true
+		}
+	}
+	kandidat := slices.DeleteFunc(guru, func(g ujian.GuruCore) bool { return sibuk[g.ID] })
+	slices.SortStableFunc(kandidat, func(a, b ujian.GuruCore) int {
+		if pengajar[a.ID] != pengajar[b.ID] {
+			if pengajar[a.ID] {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.Jumlah_Tugas, b.Jumlah_Tugas)
+	})
+
+	var kosong []ujian.RuangSesiCore
+	for _, r := range sesi.Ruang {
+		if r.Pengawas_ID == "" {
+			kosong = append(kosong, r)
+		}
+	}
+	if len(kosong) > len(kandidat) {
+		return nil, fmt.Errorf("validation error: pengawas tidak cukup, %d ruang belum punya pengawas tetapi hanya %d guru yang tersedia",
+			len(kosong), len(kandidat))
+	}
+
+	err = s.uow.Do(ctx, func(repo ujian.DataUjianInterface) error {
+		for i, r := range kosong {
+			if err := repo.SimpanPengawas(ctx, sesi.ID, r.Ruang_ID, kandidat[i].ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal menyimpan pengawas: %w", err)
+	}
+	return s.GetSesi(ctx, sesiID)
+}
+
+// SetPengawas implements ujian.ServiceUjianInterface.
+// Guru ditolak jika sudah mengawas ruang lain pada sesi yang sama atau pada sesi lain di jam yang beririsan.
+func (s *ujianService) SetPengawas(ctx context.Context, sesiID, ruangID, guruID string) error {
+	sesi, err := s.GetSesi(ctx, sesiID)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(sesi.Ruang, func(r ujian.RuangSesiCore) bool { return r.Ruang_ID == ruangID }) {
+		return errRuangNotFound
+	}
+
+	guruID = strings.TrimSpace(guruID)
+	if guruID != "" {
+		guru, err := s.ujianData.SelectGuru(ctx, sesi.Ujian_ID)
+		if err != nil {
+			return fmt.Errorf("ujian service: gagal mengambil guru: %w", err)
+		}
+		if !slices.ContainsFunc(guru, func(g ujian.GuruCore) bool { return g.ID == guruID }) {
+			return fmt.Errorf("validation error: guru '%s' tidak ditemukan", guruID)
+		}
+
+		for _, r := range sesi.Ruang {
+			if r.Ruang_ID != ruangID && r.Pengawas_ID == guruID {
+				return fmt.Errorf("validation error: guru sudah mengawas ruang %s pada sesi ini", r.Nama_Ruang)
+			}
+		}
+		bentrok, err := s.ujianData.SelectSesiBentrok(ctx, *sesi)
+		if err != nil {
+			return fmt.Errorf("ujian service: gagal mengambil sesi: %w", err)
+		}
+		for _, lain := range bentrok {
+			for _, r := range lain.Ruang {
+				if r.Pengawas_ID == guruID {
+					return fmt.Errorf("validation error: guru sudah mengawas ruang %s pada %s %s-%s",
+						r.Nama_Ruang, lain.Tanggal, lain.Jam_Mulai, lain.Jam_Selesai)
+				}
+			}
+		}
+	}
+
+	if err := s.ujianData.SimpanPengawas(ctx, sesiID, ruangID, guruID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errRuangNotFound
+		}
+		return fmt.Errorf("ujian service: gagal menyimpan pengawas: %w", err)
+	}
+	return nil
+}
+
+// pengawasSibuk mengembalikan guru yang sudah mengawas pada sesi tersebut atau pada sesi lain di jam yang beririsan.
+func (s *ujianService) pengawasSibuk(ctx context.Context, sesi ujian.SesiCore) (map[string]bool, error) {
+	bentrok, err := s.ujianData.SelectSesiBentrok(ctx, sesi)
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil sesi: %w", err)
+	}
+	sibuk := map[string]bool{}
+	for _, se := range append(bentrok, sesi) {
+		for _, r := range se.Ruang {
+			if r.Pengawas_ID != "" {
+				sibuk[r.Pengawas_ID] = true
+			}
+		}
+	}
+	return sibuk, nil
+}
+
+// BuatDenah implements ujian.ServiceUjianInterface.
+// Denah lama diganti. Urutan siswa setiap kelas diacak lalu siswa dari kelas yang sama dipisahkan sehingga
+// tidak duduk di nomor kursi yang berurutan, selama kelas terbesar tidak lebih dari separuh peserta.
+func (s *ujianService) BuatDenah(ctx context.Context, sesiID string) ([]ujian.KursiCore, error) {
+	sesi, err := s.GetSesi(ctx, sesiID)
+	if err != nil {
+		return nil, err
+	}
+	peserta, err := s.ujianData.SelectPeserta(ctx, sesiID)
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil peserta: %w", err)
+	}
+	if len(peserta) == 0 {
+		return nil, errors.New("validation error: sesi tidak memiliki peserta")
+	}
+	kapasitas := 0
+	for _, r := range sesi.Ruang {
+		kapasitas += r.Kapasitas
+	}
+	if kapasitas < len(peserta) {
+		return nil, fmt.Errorf("validation error: kapasitas ruang (%d kursi) kurang dari jumlah peserta (%d siswa)", kapasitas, len(peserta))
+	}
+
+	kursi := susunKursi(peserta, sesi.Ruang, s.acak)
+	err = s.uow.Do(ctx, func(repo ujian.DataUjianInterface) error {
+		return repo.SimpanDenah(ctx, sesiID, kursi)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal menyimpan denah: %w", err)
+	}
+	return kursi, nil
+}
+
+// GetDenah implements ujian.ServiceUjianInterface.
+func (s *ujianService) GetDenah(ctx context.Context, sesiID string) ([]ujian.KursiCore, error) {
+	if _, err := s.GetSesi(ctx, sesiID); err != nil {
+		return nil, err
+	}
+	result, err := s.ujianData.SelectDenah(ctx, sesiID)
+	if err != nil {
+		return nil, fmt.Errorf("ujian service: gagal mengambil denah: %w", err)
+	}
+	return result, nil
+}
+
+// susunKursi membagikan peserta ke kursi ruang. Siswa dikelompokkan per kelas dan urutannya diacak dengan acak.
+// Kelompok terbesar lebih dulu mengisi posisi genap (0, 2, 4, ...) lalu sisanya mengisi posisi ganjil,
+// sehingga dua siswa sekelas tidak bersebelahan jika kelas terbesar paling banyak separuh peserta.
+// Ruang kemudian diisi berurutan sampai penuh mulai dari kursi nomor 1.
+func susunKursi(peserta []ujian.KursiCore, ruang []ujian.RuangSesiCore, acak func(n int, swap func(i, j int))) []ujian.KursiCore {
+	grup := map[string][]ujian.KursiCore{}
+	var kelas []string
+	for _, p := range peserta {
+		if _, ok := grup[p.Kelas_ID]; !ok {
+			kelas = append(kelas, p.Kelas_ID)
+		}
+		grup[p.Kelas_ID] = append(grup[p.Kelas_ID], p)
+	}
+	slices.SortFunc(kelas, func(a, b string) int {
+		if c := cmp.Compare(len(grup[b]), len(grup[a])); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+
+	urut := make([]ujian.KursiCore, len(peserta))
+	pos := 0
+	for _, k := range kelas {
+		g := grup[k]
+		acak(len(g), func(i, j int) { g[i], g[j] = g[j], g[i] })
+		for _, p := range g {
+			urut[pos] = p
+			if pos += 2; pos >= len(urut) {
+				pos = 1
+			}
+		}
+	}
+
+	i := 0
+	for _, r := range ruang {
+		for nomor := 1; nomor <= r.Kapasitas && i < len(urut); nomor++ {
+			urut[i].Ruang_ID = r.Ruang_ID
+			urut[i].Nama_Ruang = r.Nama_Ruang
+			urut[i].Nomor_Kursi = nomor
+			i++
+		}
+	}
+	return urut
+}
+
+// unik mengambil nilai kunci yang tidak kosong dari items tanpa duplikat, dengan urutan kemunculan pertama.
+func unik[T any](items []T, kunci func(T) string) []string {
+	var result []string
+	for _, item := range items {
+		k := strings.TrimSpace(kunci(item))
+		if k != "" && !slices.Contains(result, k) {
+			result = append(result, k)
+		}
+	}
+	return result
+}
@@ -0,0 +1,523 @@
+package service
+
+import (
+	"context"
+	"go_rest_native_sekolah/features/ujian"
+	"go_rest_native_sekolah/helper"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock untuk DataUjianInterface
+type mockDataUjian struct {
+	mock.Mock
+}
+
+func (m *mockDataUjian) SelectRuang(ctx context.Context) ([]ujian.RuangCore, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ujian.RuangCore), args.Error(1)
+}
+
+func (m *mockDataUjian) SelectRuangById(ctx context.Context, id string) (*ujian.RuangCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ujian.RuangCore), args.Error(1)
+}
+
+func (m *mockDataUjian) InsertRuang(ctx context.Context, insert *ujian.RuangCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) UpdateRuang(ctx context.Context, update *ujian.RuangCore, id string) error {
+	args := m.Called(update, id)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) DeleteRuang(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) SelectAll(ctx context.Context, tahunAjaran string) ([]ujian.UjianCore, error) {
+	args := m.Called(tahunAjaran)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ujian.UjianCore), args.Error(1)
+}
+
+func (m *mockDataUjian) SelectById(ctx context.Context, id string) (*ujian.UjianCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ujian.UjianCore), args.Error(1)
+}
+
+func (m *mockDataUjian) Insert(ctx context.Context, insert *ujian.UjianCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) Update(ctx context.Context, update *ujian.UjianCore, id string) error {
+	args := m.Called(update, id)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) DeleteById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) SelectSesi(ctx context.Context, ujianID string) ([]ujian.SesiCore, error) {
+	args := m.Called(ujianID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ujian.SesiCore), args.Error(1)
+}
+
+func (m *mockDataUjian) SelectSesiById(ctx context.Context, id string) (*ujian.SesiCore, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ujian.SesiCore), args.Error(1)
+}
+
+func (m *mockDataUjian) SelectSesiBentrok(ctx context.Context, sesi ujian.SesiCore) ([]ujian.SesiCore, error) {
+	args := m.Called(sesi.ID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ujian.SesiCore), args.Error(1)
+}
+
+func (m *mockDataUjian) InsertSesi(ctx context.Context, insert *ujian.SesiCore) error {
+	args := m.Called(insert)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) DeleteSesi(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) SelectMataPelajaran(ctx context.Context, ids []string) ([]ujian.JadwalCore, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ujian.JadwalCore), args.Error(1)
+}
+
+func (m *mockDataUjian) MataPelajaranTerjadwal(ctx context.Context, ujianID string, ids []string) ([]string, error) {
+	args := m.Called(ujianID, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockDataUjian) SelectGuru(ctx context.Context, ujianID string) ([]ujian.GuruCore, error) {
+	args := m.Called(ujianID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ujian.GuruCore), args.Error(1)
+}
+
+func (m *mockDataUjian) SimpanPengawas(ctx context.Context, sesiID, ruangID, guruID string) error {
+	args := m.Called(sesiID, ruangID, guruID)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) SelectPeserta(ctx context.Context, sesiID string) ([]ujian.KursiCore, error) {
+	args := m.Called(sesiID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ujian.KursiCore), args.Error(1)
+}
+
+func (m *mockDataUjian) SimpanDenah(ctx context.Context, sesiID string, kursi []ujian.KursiCore) error {
+	args := m.Called(sesiID, kursi)
+	return args.Error(0)
+}
+
+func (m *mockDataUjian) SelectDenah(ctx context.Context, sesiID string) ([]ujian.KursiCore, error) {
+	args := m.Called(sesiID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]ujian.KursiCore), args.Error(1)
+}
+
+// tanpaAcak mempertahankan urutan siswa agar hasil denah dapat diperiksa.
+func tanpaAcak(n int, swap func(i, j int)) {}
+
+func newTestService(repo *mockDataUjian) *ujianService {
+	return &ujianService{ujianData: repo, uow: helper.JoinUnitOfWork[ujian.DataUjianInterface](repo), acak: tanpaAcak}
+}
+
+// ujianUji adalah UTS pada 5-9 Oktober 2026 yang dipakai selama pengujian.
+var ujianUji = &ujian.UjianCore{ID: "u1", Nama: "UTS Ganjil", Jenis: ujian.JenisUTS, Tahun_Ajaran: "2026/2027",
+	Tanggal_Mulai: "2026-10-05", Tanggal_Selesai: "2026-10-09", Version: 1}
+
+func TestInsertUjian(t *testing.T) {
+	t.Run("success insert - jenis dinormalisasi", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("Insert", mock.AnythingOfType("*ujian.UjianCore")).Return(nil).Once()
+
+		data := &ujian.UjianCore{Nama: " UTS Ganjil ", Jenis: "uts", Tahun_Ajaran: "2026/2027", Tanggal_Mulai: "2026-10-05"}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "UTS Ganjil", data.Nama)
+		assert.Equal(t, ujian.JenisUTS, data.Jenis)
+		assert.Equal(t, "2026-10-05", data.Tanggal_Selesai)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - jenis tidak valid", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		data := &ujian.UjianCore{Nama: "PTS", Jenis: "PTS", Tahun_Ajaran: "2026/2027", Tanggal_Mulai: "2026-10-05"}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.ErrorContains(t, err, "validation error: jenis")
+		mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("error - tanggal di luar tahun ajaran", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		data := &ujian.UjianCore{Nama: "UAS", Jenis: "UAS", Tahun_Ajaran: "2026/2027", Tanggal_Mulai: "2028-06-01"}
+		err := newTestService(mockRepo).Insert(context.Background(), data)
+
+		assert.ErrorContains(t, err, "validation error: tanggal ujian")
+	})
+}
+
+func TestUpdateUjian(t *testing.T) {
+	t.Run("error - sesi berada di luar rentang baru", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+		mockRepo.On("SelectSesi", "u1").Return([]ujian.SesiCore{{ID: "s1", Tanggal: "2026-10-09"}}, nil).Once()
+
+		data := &ujian.UjianCore{Tanggal_Selesai: "2026-10-08", Version: 1}
+		err := newTestService(mockRepo).Update(context.Background(), data, "u1")
+
+		assert.ErrorContains(t, err, "validation error: sesi tanggal 2026-10-09")
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - versi berbeda", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+
+		err := newTestService(mockRepo).Update(context.Background(), &ujian.UjianCore{Version: 3}, "u1")
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+	})
+}
+
+func TestInsertRuang(t *testing.T) {
+	t.Run("error - nama sudah dipakai", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectRuang").Return([]ujian.RuangCore{{ID: "r1", Nama: "Lab Komputer"}}, nil).Once()
+
+		err := newTestService(mockRepo).InsertRuang(context.Background(), &ujian.RuangCore{Nama: "lab komputer", Kapasitas: 30})
+
+		assert.ErrorContains(t, err, "validation error: ruang 'lab komputer' sudah ada")
+		mockRepo.AssertNotCalled(t, "InsertRuang", mock.Anything)
+	})
+
+	t.Run("error - kapasitas kosong", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		err := newTestService(mockRepo).InsertRuang(context.Background(), &ujian.RuangCore{Nama: "R1"})
+
+		assert.ErrorContains(t, err, "validation error: kapasitas")
+	})
+}
+
+// sesiBaru adalah input sesi pada 5 Oktober 2026 pukul 07:30-09:00 untuk dua kelas di satu ruang.
+func sesiBaru() *ujian.SesiCore {
+	return &ujian.SesiCore{
+		Ujian_ID: "u1", Tanggal: "2026-10-05", Jam_Mulai: "07:30", Jam_Selesai: "09:00",
+		Jadwal: []ujian.JadwalCore{{Mata_Pelajaran_ID: "mp1"}, {Mata_Pelajaran_ID: "mp2"}},
+		Ruang:  []ujian.RuangSesiCore{{Ruang_ID: "r1"}},
+	}
+}
+
+// mapelUji adalah matematika kelas 7A dan 7B dengan total 40 siswa.
+var mapelUji = []ujian.JadwalCore{
+	{Mata_Pelajaran_ID: "mp1", Nama_Pelajaran: "Matematika", Kelas_ID: "k1", Nama_Kelas: "7A", Jumlah_Siswa: 20, Pengajar: []string{"g1"}},
+	{Mata_Pelajaran_ID: "mp2", Nama_Pelajaran: "Matematika", Kelas_ID: "k2", Nama_Kelas: "7B", Jumlah_Siswa: 20, Pengajar: []string{"g1"}},
+}
+
+func TestInsertSesi(t *testing.T) {
+	mapelIDs := []string{"mp1", "mp2"}
+
+	t.Run("success insert", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+		mockRepo.On("SelectMataPelajaran", mapelIDs).Return(mapelUji, nil).Once()
+		mockRepo.On("MataPelajaranTerjadwal", "u1", mapelIDs).Return([]string{}, nil).Once()
+		mockRepo.On("SelectRuang").Return([]ujian.RuangCore{{ID: "r1", Nama: "R1", Kapasitas: 40}, {ID: "r2", Nama: "R2", Kapasitas: 40}}, nil).Once()
+		mockRepo.On("SelectSesiBentrok", "").Return([]ujian.SesiCore{}, nil).Once()
+		mockRepo.On("InsertSesi", mock.AnythingOfType("*ujian.SesiCore")).Return(nil).Once()
+
+		data := sesiBaru()
+		err := newTestService(mockRepo).InsertSesi(context.Background(), data)
+
+		assert.NoError(t, err)
+		assert.Len(t, data.Jadwal, 2)
+		assert.Equal(t, []ujian.RuangSesiCore{{Ruang_ID: "r1", Nama_Ruang: "R1", Kapasitas: 40}}, data.Ruang)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - tanggal di luar rentang ujian", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+
+		data := sesiBaru()
+		data.Tanggal = "2026-10-12"
+		err := newTestService(mockRepo).InsertSesi(context.Background(), data)
+
+		assert.ErrorContains(t, err, "validation error: tanggal harus di antara 2026-10-05 dan 2026-10-09")
+	})
+
+	t.Run("error - jam selesai sebelum jam mulai", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+
+		data := sesiBaru()
+		data.Jam_Selesai = "07:00"
+		err := newTestService(mockRepo).InsertSesi(context.Background(), data)
+
+		assert.ErrorContains(t, err, "validation error: jam_selesai harus setelah jam_mulai")
+	})
+
+	t.Run("error - satu kelas dua mata pelajaran", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+		mockRepo.On("SelectMataPelajaran", mapelIDs).Return([]ujian.JadwalCore{
+			mapelUji[0],
+			{Mata_Pelajaran_ID: "mp2", Nama_Pelajaran: "IPA", Kelas_ID: "k1", Nama_Kelas: "7A"},
+		}, nil).Once()
+
+		err := newTestService(mockRepo).InsertSesi(context.Background(), sesiBaru())
+
+		assert.ErrorContains(t, err, "validation error: kelas 7A tidak boleh ujian Matematika dan IPA")
+	})
+
+	t.Run("error - mata pelajaran sudah dijadwalkan", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+		mockRepo.On("SelectMataPelajaran", mapelIDs).Return(mapelUji, nil).Once()
+		mockRepo.On("MataPelajaranTerjadwal", "u1", mapelIDs).Return([]string{"mp2"}, nil).Once()
+
+		err := newTestService(mockRepo).InsertSesi(context.Background(), sesiBaru())
+
+		assert.ErrorContains(t, err, "validation error: Matematika kelas 7B sudah dijadwalkan")
+	})
+
+	t.Run("error - kapasitas ruang kurang", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+		mockRepo.On("SelectMataPelajaran", mapelIDs).Return(mapelUji, nil).Once()
+		mockRepo.On("MataPelajaranTerjadwal", "u1", mapelIDs).Return([]string{}, nil).Once()
+		mockRepo.On("SelectRuang").Return([]ujian.RuangCore{{ID: "r1", Nama: "R1", Kapasitas: 30}}, nil).Once()
+
+		err := newTestService(mockRepo).InsertSesi(context.Background(), sesiBaru())
+
+		assert.ErrorContains(t, err, "validation error: kapasitas ruang (30 kursi) kurang dari jumlah peserta (40 siswa)")
+	})
+
+	t.Run("error - ruang dipakai sesi lain pada jam yang sama", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+		mockRepo.On("SelectMataPelajaran", mapelIDs).Return(mapelUji, nil).Once()
+		mockRepo.On("MataPelajaranTerjadwal", "u1", mapelIDs).Return([]string{}, nil).Once()
+		mockRepo.On("SelectRuang").Return([]ujian.RuangCore{{ID: "r1", Nama: "R1", Kapasitas: 40}}, nil).Once()
+		mockRepo.On("SelectSesiBentrok", "").Return([]ujian.SesiCore{{
+			ID: "s9", Tanggal: "2026-10-05", Jam_Mulai: "08:00", Jam_Selesai: "09:30",
+			Jadwal: []ujian.JadwalCore{{Kelas_ID: "k3", Nama_Kelas: "8A", Nama_Pelajaran: "IPA"}},
+			Ruang:  []ujian.RuangSesiCore{{Ruang_ID: "r1", Nama_Ruang: "R1"}},
+		}}, nil).Once()
+
+		err := newTestService(mockRepo).InsertSesi(context.Background(), sesiBaru())
+
+		assert.ErrorContains(t, err, "validation error: ruang R1 sudah dipakai pada 2026-10-05 08:00-09:30")
+		mockRepo.AssertNotCalled(t, "InsertSesi", mock.Anything)
+	})
+
+	t.Run("error - kelas sudah ujian pada jam yang sama", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(ujianUji, nil).Once()
+		mockRepo.On("SelectMataPelajaran", mapelIDs).Return(mapelUji, nil).Once()
+		mockRepo.On("MataPelajaranTerjadwal", "u1", mapelIDs).Return([]string{}, nil).Once()
+		mockRepo.On("SelectRuang").Return([]ujian.RuangCore{{ID: "r1", Nama: "R1", Kapasitas: 40}}, nil).Once()
+		mockRepo.On("SelectSesiBentrok", "").Return([]ujian.SesiCore{{
+			ID: "s9", Tanggal: "2026-10-05", Jam_Mulai: "07:00", Jam_Selesai: "08:00",
+			Jadwal: []ujian.JadwalCore{{Kelas_ID: "k2", Nama_Kelas: "7B", Nama_Pelajaran: "IPA"}},
+		}}, nil).Once()
+
+		err := newTestService(mockRepo).InsertSesi(context.Background(), sesiBaru())
+
+		assert.ErrorContains(t, err, "validation error: kelas 7B sudah ujian IPA")
+	})
+
+	t.Run("error - ujian tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectById", "u1").Return(nil, pgx.ErrNoRows).Once()
+
+		err := newTestService(mockRepo).InsertSesi(context.Background(), sesiBaru())
+
+		assert.ErrorIs(t, err, errUjianNotFound)
+	})
+}
+
+// sesiUji adalah sesi tersimpan dengan dua ruang tanpa pengawas.
+func sesiUji() *ujian.SesiCore {
+	return &ujian.SesiCore{
+		ID: "s1", Ujian_ID: "u1", Tanggal: "2026-10-05", Jam_Mulai: "07:30", Jam_Selesai: "09:00",
+		Jadwal: mapelUji,
+		Ruang: []ujian.RuangSesiCore{
+			{Ruang_ID: "r1", Nama_Ruang: "R1", Kapasitas: 3},
+			{Ruang_ID: "r2", Nama_Ruang: "R2", Kapasitas: 3},
+		},
+	}
+}
+
+func TestAturPengawas(t *testing.T) {
+	t.Run("success - lewati guru sibuk, dahulukan bukan pengajar dan tugas paling sedikit", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectSesiById", "s1").Return(sesiUji(), nil).Twice()
+		mockRepo.On("SelectSesiBentrok", "s1").Return([]ujian.SesiCore{{
+			ID: "s2", Ruang: []ujian.RuangSesiCore{{Ruang_ID: "r3", Pengawas_ID: "g2"}},
+		}}, nil).Once()
+		mockRepo.On("SelectGuru", "u1").Return([]ujian.GuruCore{
+			{ID: "g1", Nama: "Ani", Jumlah_Tugas: 0},
+			{ID: "g2", Nama: "Budi", Jumlah_Tugas: 0},
+			{ID: "g3", Nama: "Citra", Jumlah_Tugas: 2},
+			{ID: "g4", Nama: "Dedi", Jumlah_Tugas: 1},
+		}, nil).Once()
+		mockRepo.On("SimpanPengawas", "s1", "r1", "g4").Return(nil).Once()
+		mockRepo.On("SimpanPengawas", "s1", "r2", "g3").Return(nil).Once()
+
+		_, err := newTestService(mockRepo).AturPengawas(context.Background(), "s1")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - pengawas tidak cukup", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectSesiById", "s1").Return(sesiUji(), nil).Once()
+		mockRepo.On("SelectSesiBentrok", "s1").Return([]ujian.SesiCore{}, nil).Once()
+		mockRepo.On("SelectGuru", "u1").Return([]ujian.GuruCore{{ID: "g1", Nama: "Ani"}}, nil).Once()
+
+		_, err := newTestService(mockRepo).AturPengawas(context.Background(), "s1")
+
+		assert.ErrorContains(t, err, "validation error: pengawas tidak cukup")
+		mockRepo.AssertNotCalled(t, "SimpanPengawas", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSetPengawas(t *testing.T) {
+	t.Run("error - guru mengawas sesi lain pada jam yang sama", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectSesiById", "s1").Return(sesiUji(), nil).Once()
+		mockRepo.On("SelectGuru", "u1").Return([]ujian.GuruCore{{ID: "g2", Nama: "Budi"}}, nil).Once()
+		mockRepo.On("SelectSesiBentrok", "s1").Return([]ujian.SesiCore{{
+			ID: "s2", Tanggal: "2026-10-05", Jam_Mulai: "08:00", Jam_Selesai: "09:30",
+			Ruang: []ujian.RuangSesiCore{{Ruang_ID: "r3", Nama_Ruang: "R3", Pengawas_ID: "g2"}},
+		}}, nil).Once()
+
+		err := newTestService(mockRepo).SetPengawas(context.Background(), "s1", "r1", "g2")
+
+		assert.ErrorContains(t, err, "validation error: guru sudah mengawas ruang R3")
+		mockRepo.AssertNotCalled(t, "SimpanPengawas", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success - hapus pengawas", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectSesiById", "s1").Return(sesiUji(), nil).Once()
+		mockRepo.On("SimpanPengawas", "s1", "r2", "").Return(nil).Once()
+
+		err := newTestService(mockRepo).SetPengawas(context.Background(), "s1", "r2", " ")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - ruang tidak dipakai sesi", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectSesiById", "s1").Return(sesiUji(), nil).Once()
+
+		err := newTestService(mockRepo).SetPengawas(context.Background(), "s1", "r9", "g1")
+
+		assert.ErrorIs(t, err, errRuangNotFound)
+	})
+}
+
+func TestBuatDenah(t *testing.T) {
+	t.Run("success - siswa sekelas tidak bersebelahan", func(t *testing.T) {
+		peserta := []ujian.KursiCore{
+			{Siswa_ID: "a1", Kelas_ID: "k1"}, {Siswa_ID: "a2", Kelas_ID: "k1"}, {Siswa_ID: "a3", Kelas_ID: "k1"},
+			{Siswa_ID: "b1", Kelas_ID: "k2"}, {Siswa_ID: "b2", Kelas_ID: "k2"},
+			{Siswa_ID: "c1", Kelas_ID: "k3"},
+		}
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectSesiById", "s1").Return(sesiUji(), nil).Once()
+		mockRepo.On("SelectPeserta", "s1").Return(peserta, nil).Once()
+		mockRepo.On("SimpanDenah", "s1", mock.Anything).Return(nil).Once()
+
+		kursi, err := newTestService(mockRepo).BuatDenah(context.Background(), "s1")
+
+		assert.NoError(t, err)
+		urutan := make([]string, len(kursi))
+		for i, k := range kursi {
+			urutan[i] = k.Siswa_ID
+		}
+		assert.Equal(t, []string{"a1", "b1", "a2", "b2", "a3", "c1"}, urutan)
+		for i := 1; i < len(kursi); i++ {
+			assert.NotEqual(t, kursi[i-1].Kelas_ID, kursi[i].Kelas_ID)
+		}
+		assert.Equal(t, "r1", kursi[2].Ruang_ID)
+		assert.Equal(t, 3, kursi[2].Nomor_Kursi)
+		assert.Equal(t, "r2", kursi[3].Ruang_ID)
+		assert.Equal(t, 1, kursi[3].Nomor_Kursi)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("error - kapasitas ruang kurang", func(t *testing.T) {
+		peserta := make([]ujian.KursiCore, 7)
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectSesiById", "s1").Return(sesiUji(), nil).Once()
+		mockRepo.On("SelectPeserta", "s1").Return(peserta, nil).Once()
+
+		_, err := newTestService(mockRepo).BuatDenah(context.Background(), "s1")
+
+		assert.ErrorContains(t, err, "validation error: kapasitas ruang (6 kursi) kurang dari jumlah peserta (7 siswa)")
+		mockRepo.AssertNotCalled(t, "SimpanDenah", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - sesi tidak ditemukan", func(t *testing.T) {
+		mockRepo := new(mockDataUjian)
+		mockRepo.On("SelectSesiById", "s1").Return(nil, pgx.ErrNoRows).Once()
+
+		_, err := newTestService(mockRepo).BuatDenah(context.Background(), "s1")
+
+		assert.ErrorIs(t, err, errSesiNotFound)
+	})
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"go_rest_native_sekolah/features/users"
 	"go_rest_native_sekolah/helper"
-	"log"
 	"net/http"
 	"strings"
 )
@@ -44,7 +43,7 @@ func (uc *UserController) Users(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	// Ambil data user dari database.
-	users, err := uc.userService.SelectAllUser()
+	users, err := uc.userService.SelectAllUser(r.Context())
 	if err != nil {
 		// Jika ada error, maka kita akan mengembalikan error.
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -127,7 +126,7 @@ func (uc *UserController) InsertUser(w http.ResponseWriter, r *http.Request) err
 	usersCore = FormatUserRequestToCore(userReq)
 
 	// Simpan data user ke dalam database menggunakan service user.
-	err := uc.userService.InsertUser(&usersCore)
+	err := uc.userService.InsertUser(r.Context(), &usersCore)
 	if err != nil {
 		// Jika terjadi error, maka kita akan mengembalikan error.
 		http.Error(w, "gagal menyimpan data user", http.StatusInternalServerError)
@@ -161,7 +160,7 @@ func (uc *UserController) GetUserById(w http.ResponseWriter, r *http.Request) er
 		http.Error(w, "parameter 'id' wajib diisi", http.StatusBadRequest)
 		return errors.New("missing 'id' query parameter")
 	}
-	userData, err := uc.userService.SelectUserById(id)
+	userData, err := uc.userService.SelectUserById(r.Context(), id)
 	// Panggil service untuk mengambil data user berdasarkan ID.
 	if err != nil {
 		// Jika terjadi error saat mengambil data user, maka kembalikan error.
@@ -174,6 +173,8 @@ func (uc *UserController) GetUserById(w http.ResponseWriter, r *http.Request) er
 	// Response ini berisi data user yang telah di-format dan di-encode menjadi JSON.
 	// Jika terjadi error saat encoding maka kembalikan error dengan status 500.
 	w.Header().Set("Content-Type", "application/json")
+	// ETag dipakai client sebagai If-Match saat update atau delete.
+	helper.SetETag(w, userData.Version)
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
 		// Jika terjadi error saat encoding response maka kembalikan error dengan pesan "Error encoding response".
@@ -192,27 +193,39 @@ func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request) err
 		return errors.New("missing 'id' query parameter")
 	}
 	// Log permintaan update untuk ID tertentu.
-	log.Printf("Request update user dengan ID: %s", idStr)
+	helper.LoggerFromContext(r.Context()).Debug("Request update user", "id", idStr)
+
+	// Ambil versi data dari header If-Match agar update tidak menimpa perubahan orang lain.
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
 
 	// Deklarasikan objek yang digunakan untuk mengubah data inputan menjadi objek user core.
 	var userReq UserFormatter
 
 	// Dekode request body menjadi objek userReq.
-	err := json.NewDecoder(r.Body).Decode(&userReq)
+	err = json.NewDecoder(r.Body).Decode(&userReq)
 	if err != nil {
 		// Jika terjadi error saat decoding maka kembalikan error dengan status 400.
-		log.Printf("Error decoding request body: %v", err)
+		helper.LoggerFromContext(r.Context()).Error("Error decoding request body", "error", err)
 		http.Error(w, "Gagal memproses data input", http.StatusBadRequest)
 		return err
 	}
 
 	// Format data userReq menjadi objek user core.
 	updateUser := FormatUserRequestToCore(userReq)
+	updateUser.Version = version
 
 	// Panggil service untuk memperbarui data user berdasarkan ID.
-	err = uc.userService.UpdateUser(&updateUser, idStr)
+	err = uc.userService.UpdateUser(r.Context(), &updateUser, idStr)
 	if err != nil {
 		// Jika terjadi error saat memperbarui data user maka kembalikan error sesuai dengan status error.
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		}
 		if strings.Contains(err.Error(), "validation") {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return err
@@ -226,7 +239,7 @@ func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request) err
 	}
 
 	// Ambil data user yang telah diupdate dari database.
-	updatedUser, err := uc.userService.SelectUserById(idStr)
+	updatedUser, err := uc.userService.SelectUserById(r.Context(), idStr)
 	if err != nil {
 		// Jika terjadi error saat mengambil data user maka kembalikan error dengan status 500.
 		http.Error(w, "Gagal mengambil data setelah update", http.StatusInternalServerError)
@@ -241,6 +254,7 @@ func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request) err
 	// Jika terjadi error saat encoding maka kembalikan error dengan status 500.
 	response := helper.APIResponse(http.StatusOK, "Success", formattedUser)
 	w.Header().Set("Content-Type", "application/json")
+	helper.SetETag(w, updatedUser.Version)
 	if err = json.NewEncoder(w).Encode(response); err != nil {
 		// Jika terjadi error saat encoding response maka kembalikan error dengan pesan "Error encoding response".
 		return fmt.Errorf("user controller: error encoding response: %v", err)
@@ -258,10 +272,21 @@ func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) err
 		http.Error(w, "parameter 'id' wajib diisi", http.StatusBadRequest)
 		return errors.New("missing 'id' query parameter")
 	}
+	// Ambil versi data dari header If-Match agar tidak menghapus data yang sudah diubah orang lain.
+	version, err := helper.IfMatchVersion(r)
+	if err != nil {
+		helper.WriteIfMatchError(w, err)
+		return err
+	}
 	// Panggil service untuk menghapus data user berdasarkan ID.
 	// Jika terjadi error saat menghapus data user maka kembalikan error.
-	err := uc.userService.DeleteUserById(id)
+	err = uc.userService.DeleteUserById(r.Context(), id, version)
 	if err != nil {
+		// Jika data sudah diubah request lain maka kembalikan status 412 Precondition Failed.
+		if errors.Is(err, helper.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return err
+		}
 		return fmt.Errorf("user controller: gagal menghapus data user berdasarkan ID: %v", err)
 	}
 	// Buat response API.
@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"go_rest_native_sekolah/features/users"
+	"go_rest_native_sekolah/helper"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,7 +21,11 @@ type mockServiceUser struct {
 	mock.Mock
 }
 
-func (m *mockServiceUser) SelectAllUser() ([]users.UserCore, error) {
+func (m *mockServiceUser) SelectAllUser(ctx context.Context) ([]users.UserCore, error) {
+	// Meniru pgx: query dengan context yang sudah dibatalkan langsung gagal
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -27,7 +33,7 @@ func (m *mockServiceUser) SelectAllUser() ([]users.UserCore, error) {
 	return args.Get(0).([]users.UserCore), args.Error(1)
 }
 
-func (m *mockServiceUser) SelectUserById(id string) (*users.UserCore, error) {
+func (m *mockServiceUser) SelectUserById(ctx context.Context, id string) (*users.UserCore, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -35,18 +41,18 @@ func (m *mockServiceUser) SelectUserById(id string) (*users.UserCore, error) {
 	return args.Get(0).(*users.UserCore), args.Error(1)
 }
 
-func (m *mockServiceUser) InsertUser(input *users.UserCore) error {
+func (m *mockServiceUser) InsertUser(ctx context.Context, input *users.UserCore) error {
 	args := m.Called(input)
 	return args.Error(0)
 }
 
-func (m *mockServiceUser) UpdateUser(input *users.UserCore, id string) error {
+func (m *mockServiceUser) UpdateUser(ctx context.Context, input *users.UserCore, id string) error {
 	args := m.Called(input, id)
 	return args.Error(0)
 }
 
-func (m *mockServiceUser) DeleteUserById(id string) error {
-	args := m.Called(id)
+func (m *mockServiceUser) DeleteUserById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
@@ -90,6 +96,22 @@ func TestUsersController(t *testing.T) {
 
 		assert.Error(t, err)
 	})
+
+	t.Run("failed get all users - request dibatalkan client", func(t *testing.T) {
+		mockService := new(mockServiceUser)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		controller := NewUsesController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/users", nil).WithContext(ctx)
+
+		err := controller.Users(w, r)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), context.Canceled.Error())
+		mockService.AssertNotCalled(t, "SelectAllUser")
+	})
 }
 
 // Test InsertUser Controller
@@ -167,6 +189,7 @@ func TestGetUserByIdController(t *testing.T) {
 			Password:  "hashed_password",
 			Role:      "admin",
 			Update_At: time.Now(),
+			Version:   3,
 		}
 
 		mockService.On("SelectUserById", "user-001").Return(expectedUser, nil).Once()
@@ -179,6 +202,7 @@ func TestGetUserByIdController(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
 	})
 
 	t.Run("failed get user by id - missing id parameter", func(t *testing.T) {
@@ -224,6 +248,7 @@ func TestUpdateUserController(t *testing.T) {
 			Email:    "john.updated@example.com",
 			Password: "new_password",
 			Role:     "user",
+			Version:  1,
 		}
 
 		mockService.On("SelectUserById", "user-001").Return(existingUser, nil).Once()
@@ -241,12 +266,44 @@ func TestUpdateUserController(t *testing.T) {
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodPut, "/users?id=user-001", bytes.NewReader(requestBody))
 		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("If-Match", `"1"`)
 
 		err := controller.UpdateUser(w, r)
 
 		assert.NoError(t, err)
 	})
 
+	t.Run("failed update user - missing If-Match", func(t *testing.T) {
+		mockService := new(mockServiceUser)
+		controller := NewUsesController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/users?id=user-001", bytes.NewReader([]byte(`{"username":"john"}`)))
+		r.Header.Set("Content-Type", "application/json")
+
+		err := controller.UpdateUser(w, r)
+
+		assert.ErrorIs(t, err, helper.ErrPreconditionRequired)
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+		mockService.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed update user - version conflict", func(t *testing.T) {
+		mockService := new(mockServiceUser)
+		mockService.On("UpdateUser", mock.Anything, "user-001").Return(helper.ErrVersionConflict).Once()
+
+		controller := NewUsesController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/users?id=user-001", bytes.NewReader([]byte(`{"username":"john"}`)))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("If-Match", `"2"`)
+
+		err := controller.UpdateUser(w, r)
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
 	t.Run("failed update user - missing id parameter", func(t *testing.T) {
 		controller := NewUsesController(mockService)
 		w := httptest.NewRecorder()
@@ -264,11 +321,12 @@ func TestDeleteUserController(t *testing.T) {
 	mockService := new(mockServiceUser)
 
 	t.Run("success delete user", func(t *testing.T) {
-		mockService.On("DeleteUserById", "user-001").Return(nil).Once()
+		mockService.On("DeleteUserById", "user-001", 1).Return(nil).Once()
 
 		controller := NewUsesController(mockService)
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodDelete, "/users?id=user-001", nil)
+		r.Header.Set("If-Match", `"1"`)
 
 		err := controller.DeleteUser(w, r)
 
@@ -288,14 +346,29 @@ func TestDeleteUserController(t *testing.T) {
 	})
 
 	t.Run("failed delete user - service error", func(t *testing.T) {
-		mockService.On("DeleteUserById", "user-001").Return(errors.New("delete failed")).Once()
+		mockService.On("DeleteUserById", "user-001", 1).Return(errors.New("delete failed")).Once()
 
 		controller := NewUsesController(mockService)
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest(http.MethodDelete, "/users?id=user-001", nil)
+		r.Header.Set("If-Match", `"1"`)
 
 		err := controller.DeleteUser(w, r)
 
 		assert.Error(t, err)
 	})
+
+	t.Run("failed delete user - version conflict", func(t *testing.T) {
+		mockService.On("DeleteUserById", "user-001", 2).Return(helper.ErrVersionConflict).Once()
+
+		controller := NewUsesController(mockService)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/users?id=user-001", nil)
+		r.Header.Set("If-Match", `W/"2"`)
+
+		err := controller.DeleteUser(w, r)
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
 }
@@ -10,6 +10,7 @@ type UserFormatter struct {
 	Email    string `json:"email"`    // Email adalah alamat email user yang digunakan untuk login.
 	Password string `json:"password"` // Password adalah password yang digunakan user untuk login.
 	Role     string `json:"role"`     // Role adalah peran user yang menentukan akses terhadap fitur-fitur di aplikasi.
+	Version  int    `json:"version"`  // Version adalah versi data user, sama dengan ETag.
 
 }
 
@@ -28,6 +29,7 @@ func FormatUserList(cores []users.UserCore) []UserFormatter {
 			Email:    core.Email,    // Email adalah alamat email user yang digunakan untuk login.
 			Password: core.Password, // Password adalah password yang digunakan user untuk login.
 			Role:     core.Role,     // Role adalah peran user yang menentukan akses terhadap fitur-fitur di aplikasi.
+			Version:  core.Version,  // Version adalah versi data user, sama dengan ETag.
 		})
 	}
 	// Mengembalikan slice UserFormatter yang telah di format
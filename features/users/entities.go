@@ -1,6 +1,9 @@
 package users
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type (
 	// UserCore merepresentasikan data user di database.
@@ -12,6 +15,7 @@ type (
 	// 5. Role (string) sebagai peran user
 	// 6. Update_At (time.Time) sebagai waktu update data user
 	// 7. Delete_At (*time.Time) sebagai waktu delete data user
+	// 8. Version (int) sebagai versi data untuk optimistic concurrency
 	UserCore struct {
 		ID        string     `json:"id"`        // ID data user
 		Username  string     `json:"username"`  // Nama pengguna
@@ -20,6 +24,7 @@ type (
 		Role      string     `json:"role"`      // Peran user
 		Update_At time.Time  `json:"update_at"` // Waktu update data user
 		Delete_At *time.Time `json:"delete_at"` // Waktu delete data user
+		Version   int        `json:"version"`   // Versi data user, dikirim sebagai ETag
 	}
 
 	// DataUserInterface merepresentasikan interface untuk data auth.
@@ -28,29 +33,34 @@ type (
 		// SelectAllUser mengembalikan slice users.UserCore yang berisi semua data user
 		// yang tersimpan di database.
 		// Fungsi ini mengembalikan error jika terjadi kesalahan saat query ke database.
-		SelectAllUser() ([]UserCore, error)
+		SelectAllUser(ctx context.Context) ([]UserCore, error)
 
 		// SelectUserById mengembalikan pointer ke struct UserCore yang berisi data user
 		// berdasarkan id yang dikirimkan sebagai parameter.
 		// Fungsi ini mengembalikan error jika terjadi kesalahan saat query ke database.
-		SelectUserById(id string) (*UserCore, error)
+		SelectUserById(ctx context.Context, id string) (*UserCore, error)
+
+		// SelectUserByEmail mengembalikan data user aktif berdasarkan email.
+		// Jika user tidak ditemukan, error yang dikembalikan membungkus pgx.ErrNoRows.
+		SelectUserByEmail(ctx context.Context, email string) (*UserCore, error)
 
 		// InsertUser implements users.DataUserInterface.
 		// Fungsi ini digunakan untuk menginsert data user ke dalam database.
 		// Fungsi ini menerima parameter input yang berisi data user yang ingin diinsert.
 		// Fungsi ini akan mengembalikan error jika terjadi kesalahan saat query ke database.
-		InsertUser(input *UserCore) error
+		InsertUser(ctx context.Context, input *UserCore) error
 
 		// UpdateUser implements users.DataUserInterface.
 		// Fungsi ini digunakan untuk mengupdate data user berdasarkan ID yang diberikan.
 		// Fungsi ini menerima parameter input yang berisi data user yang ingin diupdate.
 		// Fungsi ini akan mengembalikan error jika terjadi kesalahan saat query ke database.
-		UpdateUser(input *UserCore, id string) error
+		UpdateUser(ctx context.Context, input *UserCore, id string) error
 
 		// DeleteUserById mengimplementasikan users.DataUserInterface.
-		// Fungsi ini digunakan untuk menghapus data guru berdasarkan ID yang dikirimkan.
+		// Fungsi ini digunakan untuk menghapus data user berdasarkan ID yang dikirimkan
+		// jika versinya masih sama dengan version.
 		// Fungsi ini akan mengembalikan error jika terjadi kesalahan saat menghapus data.
-		DeleteUserById(id string) error
+		DeleteUserById(ctx context.Context, id string, version int) error
 	}
 
 	// ServiceUserInterface merepresentasikan interface untuk service user.
@@ -59,28 +69,29 @@ type (
 		// SelectAllUser mengembalikan slice users.UserCore yang berisi semua data user
 		// yang tersimpan di database.
 		// Fungsi ini mengembalikan error jika terjadi kesalahan saat query ke database.
-		SelectAllUser() ([]UserCore, error)
+		SelectAllUser(ctx context.Context) ([]UserCore, error)
 
 		// SelectUserById mengembalikan pointer ke struct UserCore yang berisi data user
 		// berdasarkan id yang dikirimkan sebagai parameter.
 		// Fungsi ini mengembalikan error jika terjadi kesalahan saat query ke database.
-		SelectUserById(id string) (*UserCore, error)
+		SelectUserById(ctx context.Context, id string) (*UserCore, error)
 
 		// InsertUser implements users.ServiceUserInterface.
 		// Fungsi ini digunakan untuk menginsert data user ke dalam database.
 		// Fungsi ini menerima parameter input yang berisi data user yang ingin diinsert.
 		// Fungsi ini akan mengembalikan error jika terjadi kesalahan saat query ke database.
-		InsertUser(input *UserCore) error
+		InsertUser(ctx context.Context, input *UserCore) error
 
 		// UpdateUser implements users.ServiceUserInterface.
 		// Fungsi ini digunakan untuk mengupdate data user berdasarkan ID yang diberikan.
 		// Fungsi ini menerima parameter input yang berisi data user yang ingin diupdate.
 		// Fungsi ini akan mengembalikan error jika terjadi kesalahan saat query ke database.
-		UpdateUser(input *UserCore, id string) error
+		UpdateUser(ctx context.Context, input *UserCore, id string) error
 
 		// DeleteUserById mengimplementasikan users.ServiceUserInterface.
-		// Fungsi ini digunakan untuk menghapus data guru berdasarkan ID yang dikirimkan.
+		// Fungsi ini digunakan untuk menghapus data user berdasarkan ID yang dikirimkan
+		// jika versinya masih sama dengan version.
 		// Fungsi ini akan mengembalikan error jika terjadi kesalahan saat menghapus data.
-		DeleteUserById(id string) error
+		DeleteUserById(ctx context.Context, id string, version int) error
 	}
 )
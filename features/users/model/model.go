@@ -24,6 +24,9 @@ type User struct {
 	// Delete_at adalah field yang berisi waktu penghapusan data user
 	// jika field ini kosong maka data user tidak pernah dihapus
 	Delete_At string `json:"delete_at"`
+	// Version adalah field yang berisi versi data user
+	// yang bertambah setiap kali data user diubah atau dihapus
+	Version int `json:"version"`
 }
 
 // TableName mengembalikan nama tabel yang terkait dengan struktur data User.
@@ -45,6 +48,7 @@ func FormatterRequest(req users.UserCore) User {
 		Password:  req.Password,                             // Mengisi field Password dengan Password dari objek UserCore.
 		Role:      req.Role,                                 // Mengisi field Role dengan Role dari objek UserCore.
 		Update_At: time.Now().Format("2006-01-02 15:04:05"), // Mengisi field Update_At dengan waktu saat ini.
+		Version:   req.Version,                              // Mengisi field Version dengan Version dari objek UserCore.
 	}
 
 }
@@ -60,5 +64,6 @@ func FormatterResponse(res User) users.UserCore {
 		Email:    res.Email,    // Mengisi field Email dengan Email dari objek User.
 		Password: res.Password, // Mengisi field Password dengan Password dari objek User.
 		Role:     res.Role,     // Mengisi field Role dengan Role dari objek User.
+		Version:  res.Version,  // Mengisi field Version dengan Version dari objek User.
 	}
 }
@@ -6,22 +6,21 @@ import (
 	"fmt"
 	"go_rest_native_sekolah/features/users"
 	"go_rest_native_sekolah/helper"
-	"log"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// UserQuerry adalah struktur data yang berisi pointer ke objek pgxpool.Pool.
+// UserQuerry adalah struktur data yang berisi koneksi database (pool atau transaksi).
 // Struktur data ini digunakan untuk menghandle query ke database yang berhubungan dengan tabel users.
 type UserQuerry struct {
-	db *pgxpool.Pool // db adalah pointer ke objek pgxpool.Pool yang digunakan untuk menghandle query ke database.
+	db helper.DBTX // db adalah pool atau transaksi yang digunakan untuk menghandle query ke database.
 }
 
 // NewUserData membuat objek UserQuerry yang berisi koneksi database.
 // Fungsi ini digunakan untuk menginisialisasi objek UserQuerry yang berisi koneksi database.
+// Parameter db dapat berupa pool atau transaksi dari helper.UnitOfWork.
 // Jika parameter db nil maka akan terjadi panic.
-func NewUserData(db *pgxpool.Pool) users.DataUserInterface {
+func NewUserData(db helper.DBTX) users.DataUserInterface {
 	// Jika db nil maka akan terjadi panic
 	if db == nil {
 		panic("Nil database")
@@ -33,7 +32,7 @@ func NewUserData(db *pgxpool.Pool) users.DataUserInterface {
 // SelectAllUser mengembalikan slice users.UserCore yang berisi semua data user
 // yang tersimpan di database.
 // Fungsi ini mengembalikan error jika terjadi kesalahan saat query ke database.
-func (u *UserQuerry) SelectAllUser() ([]users.UserCore, error) {
+func (u *UserQuerry) SelectAllUser(ctx context.Context) ([]users.UserCore, error) {
 	if u.db == nil {
 		// Jika koneksi database tidak ada maka kembalikan error
 		return nil, errors.New("Koneksi database tidak ada")
@@ -41,9 +40,9 @@ func (u *UserQuerry) SelectAllUser() ([]users.UserCore, error) {
 
 	// Query untuk mengambil semua data user dari database
 	// Filter data user yang tidak dihapus
-	query := "SELECT id, username, email, password, role FROM users WHERE delete_at IS NULL"
+	query := "SELECT id, username, email, password, role, version FROM users WHERE delete_at IS NULL"
 
-	rows, err := u.db.Query(context.Background(), query)
+	rows, err := u.db.Query(ctx, query)
 	if err != nil {
 		// Jika terjadi error saat query maka kembalikan error
 		return nil, err
@@ -61,7 +60,7 @@ func (u *UserQuerry) SelectAllUser() ([]users.UserCore, error) {
 		// Pindai setiap baris ke dalam variabel user
 		// Fungsi Scan digunakan untuk memindai setiap baris yang diiterasi
 		// dan menyimpannya dalam variabel user
-		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role)
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.Version)
 		if err != nil {
 			// Jika terjadi error saat scan maka kembalikan error
 			return nil, err
@@ -84,7 +83,7 @@ func (u *UserQuerry) SelectAllUser() ([]users.UserCore, error) {
 	}
 
 	// Log suksesnya query dan kembalikan hasil
-	log.Printf("Berhasil mengambil %d users dari database", len(result))
+	helper.LoggerFromContext(ctx).Info("Berhasil mengambil users dari database", "count", len(result))
 
 	return result, nil
 }
@@ -92,7 +91,7 @@ func (u *UserQuerry) SelectAllUser() ([]users.UserCore, error) {
 // InsertUser implements users.DataUserInterface.
 // Fungsi ini digunakan untuk menginsert data user ke dalam database.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan.
-func (u *UserQuerry) InsertUser(input *users.UserCore) error {
+func (u *UserQuerry) InsertUser(ctx context.Context, input *users.UserCore) error {
 	// Cek apakah objek UserQuerry atau koneksi database adalah nil.
 	if u == nil || u.db == nil {
 		return errors.New("Nil UserQuerry or database")
@@ -115,10 +114,11 @@ func (u *UserQuerry) InsertUser(input *users.UserCore) error {
 	userInput.Password = hashedPassword
 
 	// Query untuk memasukkan data user ke dalam tabel users.
-	query := `INSERT INTO users (id, username, email, password, role) VALUES ($1, $2, $3, $4, $5)`
+	// Email selalu disimpan dalam huruf kecil agar cocok dengan pencarian saat login.
+	query := `INSERT INTO users (id, username, email, password, role) VALUES ($1, $2, LOWER(TRIM($3)), $4, $5)`
 
 	// Eksekusi query untuk menyimpan data user ke dalam database.
-	_, err := u.db.Exec(context.Background(), query,
+	_, err := u.db.Exec(ctx, query,
 		userInput.ID,
 		userInput.Username,
 		userInput.Email,
@@ -127,7 +127,7 @@ func (u *UserQuerry) InsertUser(input *users.UserCore) error {
 	)
 	// Jika terjadi error saat eksekusi query, log error dan kembalikan sebagai hasil fungsi.
 	if err != nil {
-		log.Printf("InsertUser error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("InsertUser error exec", "error", err)
 		return fmt.Errorf("insert failed: %w", err)
 	}
 
@@ -140,7 +140,7 @@ func (u *UserQuerry) InsertUser(input *users.UserCore) error {
 // yang dikirimkan sebagai parameter.
 // Fungsi ini akan mengembalikan pointer ke struct UserCore yang berisi data user
 // dan error jika terjadi kesalahan.
-func (u *UserQuerry) SelectUserById(id string) (*users.UserCore, error) {
+func (u *UserQuerry) SelectUserById(ctx context.Context, id string) (*users.UserCore, error) {
 	// Cek apakah objek UserQuerry atau koneksi database adalah nil.
 	// Jika nil maka kembalikan error.
 	if u == nil || u.db == nil {
@@ -154,22 +154,22 @@ func (u *UserQuerry) SelectUserById(id string) (*users.UserCore, error) {
 	}
 
 	// Query untuk mengambil data user berdasarkan id.
-	// Query ini akan mengambil kolom id, username, email, password, dan role
+	// Query ini akan mengambil kolom id, username, email, password, role, dan version
 	// berdasarkan id yang dikirimkan dan delete_at IS NULL
 	// yang artinya data user yang diambil belum dihapus.
-	query := "SELECT id, username, email, password, role FROM users WHERE id = $1 AND delete_at IS NULL"
+	query := "SELECT id, username, email, password, role, version FROM users WHERE id = $1 AND delete_at IS NULL"
 
 	// Jalankan query.
 	// Fungsi QueryRow akan mengembalikan row yang sesuai dengan query
 	// dan error jika terjadi kesalahan.
-	row := u.db.QueryRow(context.Background(), query, id)
+	row := u.db.QueryRow(ctx, query, id)
 
 	// Deklarasikan variabel result yang akan digunakan untuk menyimpan hasil query.
 	var result users.UserCore
 
 	// Scan hasil query ke variabel result.
 	// Fungsi Scan akan mengembalikan error jika terjadi kesalahan.
-	err := row.Scan(&result.ID, &result.Username, &result.Email, &result.Password, &result.Role)
+	err := row.Scan(&result.ID, &result.Username, &result.Email, &result.Password, &result.Role, &result.Version)
 	if err != nil {
 		// Jika terjadi error maka kembalikan error.
 		return nil, fmt.Errorf("gagal mengambil data user: %w", err)
@@ -179,10 +179,29 @@ func (u *UserQuerry) SelectUserById(id string) (*users.UserCore, error) {
 	return &result, nil
 }
 
+// SelectUserByEmail implements users.DataUserInterface.
+// Fungsi ini digunakan untuk mengambil data user aktif berdasarkan email.
+// Jika user tidak ditemukan, error yang dikembalikan membungkus pgx.ErrNoRows.
+func (u *UserQuerry) SelectUserByEmail(ctx context.Context, email string) (*users.UserCore, error) {
+	if u == nil || u.db == nil {
+		return nil, errors.New("Nil UserQuerry or database")
+	}
+
+	query := "SELECT id, username, email, role FROM users WHERE LOWER(email) = LOWER(TRIM($1)) AND delete_at IS NULL"
+
+	var result users.UserCore
+	err := u.db.QueryRow(ctx, query, email).Scan(&result.ID, &result.Username, &result.Email, &result.Role)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil data user: %w", err)
+	}
+
+	return &result, nil
+}
+
 // UpdateUser implements users.DataUserInterface.
 // Fungsi ini digunakan untuk mengupdate data user berdasarkan ID yang diberikan.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat proses update.
-func (u *UserQuerry) UpdateUser(insert *users.UserCore, id string) error {
+func (u *UserQuerry) UpdateUser(ctx context.Context, insert *users.UserCore, id string) error {
 	// Memeriksa apakah objek UserQuerry atau koneksi database adalah nil.
 	if u == nil || u.db == nil {
 		return errors.New("Nil UserQuerry or database")
@@ -199,19 +218,26 @@ func (u *UserQuerry) UpdateUser(insert *users.UserCore, id string) error {
 	hashedPassword := helper.HashPassword(insert.Password)
 
 	// Membuat query SQL untuk mengupdate data user berdasarkan ID.
-	query := "UPDATE users SET username = $2, email = $3, password = $4, role = $5 WHERE id = $1"
+	// Update hanya berhasil jika version masih sama dengan versi yang dibaca client.
+	// Email disimpan dalam huruf kecil seperti pada InsertUser.
+	query := `UPDATE users SET username = $2, email = LOWER(TRIM($3)), password = $4, role = $5, update_at = NOW(), version = version + 1
+		WHERE id = $1 AND delete_at IS NULL AND version = $6`
 	// Menjalankan query update pada database dengan parameter yang diberikan.
-	res, err := u.db.Exec(context.Background(), query, id, insert.Username, insert.Email, hashedPassword, insert.Role)
+	res, err := u.db.Exec(ctx, query, id, insert.Username, insert.Email, hashedPassword, insert.Role, insert.Version)
 	if err != nil {
 		// Log error jika terjadi kesalahan saat eksekusi query.
-		log.Printf("UpdateUser error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("UpdateUser error exec", "error", err)
 		return fmt.Errorf("update failed: %w", err)
 	}
 
 	// Memeriksa apakah ada baris yang terpengaruh oleh update.
 	if res.RowsAffected() == 0 {
+		// Jika user masih ada berarti versinya sudah berubah.
+		if err := helper.CheckVersionConflict(ctx, u.db, "users", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
 		// Log dan kembalikan error jika tidak ada baris yang terpengaruh.
-		log.Printf("UpdateUser: no rows updated for id %s", id)
+		helper.LoggerFromContext(ctx).Warn("UpdateUser: no rows updated", "id", id)
 		return errors.New("update failed: no rows affected")
 	}
 
@@ -223,7 +249,7 @@ func (u *UserQuerry) UpdateUser(insert *users.UserCore, id string) error {
 // DeleteUserById implements users.DataUserInterface.
 // Fungsi ini digunakan untuk menghapus data user berdasarkan ID yang diberikan.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat proses hapus.
-func (u *UserQuerry) DeleteUserById(id string) error {
+func (u *UserQuerry) DeleteUserById(ctx context.Context, id string, version int) error {
 	// Memeriksa apakah objek UserQuerry atau koneksi database adalah nil.
 	// Jika nil maka kembalikan error.
 	if u == nil || u.db == nil {
@@ -237,21 +263,24 @@ func (u *UserQuerry) DeleteUserById(id string) error {
 
 	// Membuat query SQL untuk mengupdate user berdasarkan ID.
 	// Query ini menggunakan soft delete, yaitu mengupdate kolom delete_at menjadi NOW()
-	// jika data user dengan ID yang dikirimkan memang ada dan belum dihapus.
-	query := "UPDATE users SET delete_at = NOW() WHERE id = $1"
+	// jika data user dengan ID yang dikirimkan memang ada, belum dihapus, dan versinya masih sama.
+	query := "UPDATE users SET delete_at = NOW(), version = version + 1 WHERE id = $1 AND delete_at IS NULL AND version = $2"
 
 	// Menjalankan query update pada database dengan parameter yang diberikan.
-	res, err := u.db.Exec(context.Background(), query, id)
+	res, err := u.db.Exec(ctx, query, id, version)
 	if err != nil {
 		// Log error jika terjadi kesalahan saat eksekusi query.
-		log.Printf("DeleteUserById error exec: %v", err)
+		helper.LoggerFromContext(ctx).Error("DeleteUserById error exec", "error", err)
 		return fmt.Errorf("delete failed: %w", err)
 	}
 
 	// Memeriksa apakah ada baris yang terpengaruh oleh update.
 	// Jika tidak ada baris yang terpengaruh maka log dan kembalikan error.
 	if res.RowsAffected() == 0 {
-		log.Printf("DeleteUserById: no rows updated for id %s", id)
+		if err := helper.CheckVersionConflict(ctx, u.db, "users", id); errors.Is(err, helper.ErrVersionConflict) {
+			return err
+		}
+		helper.LoggerFromContext(ctx).Warn("DeleteUserById: no rows updated", "id", id)
 		return errors.New("delete failed: no rows affected")
 	}
 
@@ -1,15 +1,21 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go_rest_native_sekolah/features/users"
+	"go_rest_native_sekolah/helper"
 	"regexp"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// emailRegex adalah format email yang diterima setelah diubah ke huruf kecil.
+var emailRegex = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`)
+
 // userService adalah struct yang berisi property userData.
 // Property userData memiliki tipe interface DataUserInterface
 // yang digunakan untuk mengakses data user dari repository.
@@ -33,14 +39,14 @@ func NewServiceUser(repo users.DataUserInterface, db *pgxpool.Pool) users.Servic
 // SelectAllUser mengembalikan slice users.UserCore yang berisi semua data user
 // yang tersimpan di database.
 // Fungsi ini mengembalikan error jika terjadi kesalahan saat query ke database.
-func (u *userService) SelectAllUser() ([]users.UserCore, error) {
+func (u *userService) SelectAllUser(ctx context.Context) ([]users.UserCore, error) {
 	if u == nil || u.userData == nil {
 		// Jika objek userService atau repository adalah nil
 		// maka kembalikan error.
 		return nil, errors.New("Nil service or repository")
 	}
 
-	users, err := u.userData.SelectAllUser()
+	users, err := u.userData.SelectAllUser(ctx)
 	if err != nil {
 		// Jika terjadi error saat query maka kembalikan error
 		// dengan menggabungkan pesan error yang diterima.
@@ -55,7 +61,7 @@ func (u *userService) SelectAllUser() ([]users.UserCore, error) {
 // Fungsi ini digunakan untuk menginsert data user ke dalam database.
 // Fungsi ini menerima parameter insert yang berisi data user yang ingin diinsert.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat query ke database.
-func (u *userService) InsertUser(insert *users.UserCore) error {
+func (u *userService) InsertUser(ctx context.Context, insert *users.UserCore) error {
 	if u == nil {
 		// Jika objek userService adalah nil maka kembalikan error.
 		return errors.New("user service: Nil service")
@@ -70,27 +76,28 @@ func (u *userService) InsertUser(insert *users.UserCore) error {
 		return errors.New("user service: input is nil")
 	}
 
+	// Email disimpan dalam huruf kecil agar login tidak membedakan huruf besar/kecil.
+	insert.Email = strings.ToLower(strings.TrimSpace(insert.Email))
 	if insert.Username == "" || insert.Email == "" || insert.Password == "" || insert.Role == "" {
 		// Jika salah satu field username, email, password atau role kosong
 		// maka kembalikan error.
 		return errors.New("validation error: username, email, password dan role harus diisi")
 	}
-	emailRegex := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`)
 	if !emailRegex.MatchString(insert.Email) {
 		// Jika format email tidak sesuai maka kembalikan error.
 		return errors.New("validation error: email tidak valid")
 	}
 
 	// Panggil fungsi InsertUser pada repository untuk menginsert data user.
-	return u.userData.InsertUser(insert)
+	return u.userData.InsertUser(ctx, insert)
 }
 
 // SelectUserById mengembalikan pointer ke struct UserCore yang berisi data user
 // berdasarkan id yang dikirimkan sebagai parameter.
 // Fungsi ini mengembalikan error jika terjadi kesalahan saat query ke database.
-func (u *userService) SelectUserById(id string) (*users.UserCore, error) {
+func (u *userService) SelectUserById(ctx context.Context, id string) (*users.UserCore, error) {
 	// Membuat query ke database untuk mengambil data user berdasarkan id.
-	user, err := u.userData.SelectUserById(id)
+	user, err := u.userData.SelectUserById(ctx, id)
 	if err != nil {
 		// Jika terjadi error saat query maka kembalikan error
 		// dengan menggabungkan pesan error yang diterima.
@@ -105,7 +112,7 @@ func (u *userService) SelectUserById(id string) (*users.UserCore, error) {
 // Fungsi ini digunakan untuk mengupdate data user berdasarkan id yang diberikan.
 // Fungsi ini menerima parameter input yang berisi data user yang ingin diupdate.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat query ke database.
-func (u *userService) UpdateUser(input *users.UserCore, id string) error {
+func (u *userService) UpdateUser(ctx context.Context, input *users.UserCore, id string) error {
 	if u == nil {
 		// Jika objek userService adalah nil maka kembalikan error.
 		return errors.New("user service: Nil service")
@@ -115,7 +122,7 @@ func (u *userService) UpdateUser(input *users.UserCore, id string) error {
 		return errors.New("user service: Nil Repository")
 	}
 	// Ambil data lama dari database berdasarkan id
-	exisData, err := u.SelectUserById(id)
+	exisData, err := u.SelectUserById(ctx, id)
 	if err != nil {
 		// Jika terjadi error saat mengambil data maka kembalikan error.
 		// Jika data tidak ditemukan, maka kembalikan error.
@@ -125,6 +132,11 @@ func (u *userService) UpdateUser(input *users.UserCore, id string) error {
 		return fmt.Errorf("Id salah atau gagal mengambil data lama: %w", err)
 	}
 
+	// Tolak update jika data user sudah diubah sejak client mengambilnya (If-Match).
+	if input.Version != exisData.Version {
+		return helper.ErrVersionConflict
+	}
+
 	// Gabungkan data baru dengan data lama
 	// Jika field baru kosong, gunakan field dari data lama
 	if input.Username == "" {
@@ -132,9 +144,13 @@ func (u *userService) UpdateUser(input *users.UserCore, id string) error {
 		input.Username = exisData.Username
 	}
 
+	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
 	if input.Email == "" {
 		// Jika field email kosong maka gunakan field email dari data lama.
 		input.Email = exisData.Email
+	} else if !emailRegex.MatchString(input.Email) {
+		// Jika format email baru tidak sesuai maka kembalikan error.
+		return errors.New("validation error: email tidak valid")
 	}
 
 	if input.Password == "" {
@@ -147,7 +163,7 @@ func (u *userService) UpdateUser(input *users.UserCore, id string) error {
 		input.Role = exisData.Role
 	}
 	// Lakukan update data ke database
-	if err := u.userData.UpdateUser(input, id); err != nil {
+	if err := u.userData.UpdateUser(ctx, input, id); err != nil {
 		// Jika terjadi error saat update maka kembalikan error.
 		return err
 	}
@@ -159,7 +175,7 @@ func (u *userService) UpdateUser(input *users.UserCore, id string) error {
 // DeleteUserById mengimplementasikan users.ServiceUserInterface.
 // Fungsi ini digunakan untuk menghapus data guru berdasarkan ID yang dikirimkan.
 // Fungsi ini akan mengembalikan error jika terjadi kesalahan saat menghapus data.
-func (u *userService) DeleteUserById(id string) error {
+func (u *userService) DeleteUserById(ctx context.Context, id string, version int) error {
 	if u == nil {
 		// Jika service kosong maka kembalikan error.
 		return errors.New("user service: Nil service")
@@ -170,7 +186,7 @@ func (u *userService) DeleteUserById(id string) error {
 	}
 	// Panggil fungsi DeleteUserById pada repository untuk menghapus data guru.
 	// Jika terjadi error maka kembalikan error.
-	if err := u.userData.DeleteUserById(id); err != nil {
+	if err := u.userData.DeleteUserById(ctx, id, version); err != nil {
 		return fmt.Errorf("gagal menghapus data guru: %w", err)
 	}
 	return nil
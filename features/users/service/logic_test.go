@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"go_rest_native_sekolah/features/users"
+	"go_rest_native_sekolah/helper"
 	"testing"
 	"time"
 
@@ -16,7 +18,11 @@ type mockDataUser struct {
 	mock.Mock
 }
 
-func (m *mockDataUser) SelectAllUser() ([]users.UserCore, error) {
+func (m *mockDataUser) SelectAllUser(ctx context.Context) ([]users.UserCore, error) {
+	// Meniru pgx: query dengan context yang sudah dibatalkan langsung gagal
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -24,7 +30,7 @@ func (m *mockDataUser) SelectAllUser() ([]users.UserCore, error) {
 	return args.Get(0).([]users.UserCore), args.Error(1)
 }
 
-func (m *mockDataUser) SelectUserById(id string) (*users.UserCore, error) {
+func (m *mockDataUser) SelectUserById(ctx context.Context, id string) (*users.UserCore, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -32,18 +38,26 @@ func (m *mockDataUser) SelectUserById(id string) (*users.UserCore, error) {
 	return args.Get(0).(*users.UserCore), args.Error(1)
 }
 
-func (m *mockDataUser) InsertUser(input *users.UserCore) error {
+func (m *mockDataUser) SelectUserByEmail(ctx context.Context, email string) (*users.UserCore, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*users.UserCore), args.Error(1)
+}
+
+func (m *mockDataUser) InsertUser(ctx context.Context, input *users.UserCore) error {
 	args := m.Called(input)
 	return args.Error(0)
 }
 
-func (m *mockDataUser) UpdateUser(input *users.UserCore, id string) error {
+func (m *mockDataUser) UpdateUser(ctx context.Context, input *users.UserCore, id string) error {
 	args := m.Called(input, id)
 	return args.Error(0)
 }
 
-func (m *mockDataUser) DeleteUserById(id string) error {
-	args := m.Called(id)
+func (m *mockDataUser) DeleteUserById(ctx context.Context, id string, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
@@ -74,7 +88,7 @@ func TestSelectAllUser(t *testing.T) {
 		mockRepo.On("SelectAllUser").Return(expectedUsers, nil).Once()
 
 		svc := &userService{userData: mockRepo}
-		result, err := svc.SelectAllUser()
+		result, err := svc.SelectAllUser(context.Background())
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedUsers, result)
@@ -86,7 +100,7 @@ func TestSelectAllUser(t *testing.T) {
 		mockRepo.On("SelectAllUser").Return(nil, errors.New("database error")).Once()
 
 		svc := &userService{userData: mockRepo}
-		result, err := svc.SelectAllUser()
+		result, err := svc.SelectAllUser(context.Background())
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -95,11 +109,24 @@ func TestSelectAllUser(t *testing.T) {
 
 	t.Run("failed - nil repository", func(t *testing.T) {
 		svc := &userService{userData: nil}
-		result, err := svc.SelectAllUser()
+		result, err := svc.SelectAllUser(context.Background())
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
 	})
+
+	t.Run("failed - context dibatalkan diteruskan ke repository", func(t *testing.T) {
+		mockRepo := new(mockDataUser)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		svc := &userService{userData: mockRepo}
+		result, err := svc.SelectAllUser(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "SelectAllUser")
+	})
 }
 
 // Test SelectUserById
@@ -119,7 +146,7 @@ func TestSelectUserById(t *testing.T) {
 		mockRepo.On("SelectUserById", "user-001").Return(expectedUser, nil).Once()
 
 		svc := &userService{userData: mockRepo}
-		result, err := svc.SelectUserById("user-001")
+		result, err := svc.SelectUserById(context.Background(), "user-001")
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedUser, result)
@@ -130,7 +157,7 @@ func TestSelectUserById(t *testing.T) {
 		mockRepo.On("SelectUserById", "999").Return(nil, pgx.ErrNoRows).Once()
 
 		svc := &userService{userData: mockRepo}
-		result, err := svc.SelectUserById("999")
+		result, err := svc.SelectUserById(context.Background(), "999")
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -154,9 +181,27 @@ func TestInsertUser(t *testing.T) {
 		mockRepo.On("InsertUser", newUser).Return(nil).Once()
 
 		svc := &userService{userData: mockRepo}
-		err := svc.InsertUser(newUser)
+		err := svc.InsertUser(context.Background(), newUser)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success insert user - email disimpan huruf kecil", func(t *testing.T) {
+		newUser := &users.UserCore{
+			Username: "john_doe",
+			Email:    " John.Doe@Example.COM ",
+			Password: "hashed_password",
+			Role:     "user",
+		}
+
+		mockRepo.On("InsertUser", newUser).Return(nil).Once()
+
+		svc := &userService{userData: mockRepo}
+		err := svc.InsertUser(context.Background(), newUser)
 
 		assert.NoError(t, err)
+		assert.Equal(t, "john.doe@example.com", newUser.Email)
 		mockRepo.AssertExpectations(t)
 	})
 
@@ -169,7 +214,7 @@ func TestInsertUser(t *testing.T) {
 		}
 
 		svc := &userService{userData: nil}
-		err := svc.InsertUser(newUser)
+		err := svc.InsertUser(context.Background(), newUser)
 
 		assert.Error(t, err)
 	})
@@ -185,7 +230,7 @@ func TestInsertUser(t *testing.T) {
 		mockRepo.On("InsertUser", newUser).Return(errors.New("insert failed")).Once()
 
 		svc := &userService{userData: mockRepo}
-		err := svc.InsertUser(newUser)
+		err := svc.InsertUser(context.Background(), newUser)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -217,21 +262,60 @@ func TestUpdateUser(t *testing.T) {
 		mockRepo.On("UpdateUser", updatedUser, "user-001").Return(nil).Once()
 
 		svc := &userService{userData: mockRepo}
-		err := svc.UpdateUser(updatedUser, "user-001")
+		err := svc.UpdateUser(context.Background(), updatedUser, "user-001")
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("success update user - email disimpan huruf kecil", func(t *testing.T) {
+		existingUser := &users.UserCore{ID: "user-001", Username: "john_doe", Email: "john@example.com", Password: "hash", Role: "user"}
+		updatedUser := &users.UserCore{Email: "John.Baru@Example.com"}
+
+		mockRepo.On("SelectUserById", "user-001").Return(existingUser, nil).Once()
+		mockRepo.On("UpdateUser", updatedUser, "user-001").Return(nil).Once()
+
+		svc := &userService{userData: mockRepo}
+		err := svc.UpdateUser(context.Background(), updatedUser, "user-001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "john.baru@example.com", updatedUser.Email)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed update user - email tidak valid", func(t *testing.T) {
+		existingUser := &users.UserCore{ID: "user-001", Email: "john@example.com"}
+		mockRepo.On("SelectUserById", "user-001").Return(existingUser, nil).Once()
+
+		svc := &userService{userData: mockRepo}
+		err := svc.UpdateUser(context.Background(), &users.UserCore{Email: "bukan-email"}, "user-001")
+
+		assert.EqualError(t, err, "validation error: email tidak valid")
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("failed update user - not found", func(t *testing.T) {
 		mockRepo.On("SelectUserById", "999").Return(nil, pgx.ErrNoRows).Once()
 
 		svc := &userService{userData: mockRepo}
-		err := svc.UpdateUser(&users.UserCore{}, "999")
+		err := svc.UpdateUser(context.Background(), &users.UserCore{}, "999")
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("failed update user - version conflict", func(t *testing.T) {
+		mockRepo := new(mockDataUser)
+		existingUser := &users.UserCore{ID: "user-001", Username: "john", Version: 2}
+
+		mockRepo.On("SelectUserById", "user-001").Return(existingUser, nil).Once()
+
+		svc := &userService{userData: mockRepo}
+		err := svc.UpdateUser(context.Background(), &users.UserCore{Username: "johnny", Version: 1}, "user-001")
+
+		assert.ErrorIs(t, err, helper.ErrVersionConflict)
+		mockRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
 }
 
 // Test DeleteUserById
@@ -239,20 +323,20 @@ func TestDeleteUserById(t *testing.T) {
 	mockRepo := new(mockDataUser)
 
 	t.Run("success delete user", func(t *testing.T) {
-		mockRepo.On("DeleteUserById", "user-001").Return(nil).Once()
+		mockRepo.On("DeleteUserById", "user-001", 1).Return(nil).Once()
 
 		svc := &userService{userData: mockRepo}
-		err := svc.DeleteUserById("user-001")
+		err := svc.DeleteUserById(context.Background(), "user-001", 1)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("failed delete user - not found", func(t *testing.T) {
-		mockRepo.On("DeleteUserById", "999").Return(errors.New("data not found")).Once()
+		mockRepo.On("DeleteUserById", "999", 1).Return(errors.New("data not found")).Once()
 
 		svc := &userService{userData: mockRepo}
-		err := svc.DeleteUserById("999")
+		err := svc.DeleteUserById(context.Background(), "999", 1)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -0,0 +1,200 @@
+package helper
+
+import (
+	"context"
+	"go_rest_native_sekolah/config"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// auditFlushTimeout adalah batas waktu satu kali COPY batch ke transaction_logs.
+const auditFlushTimeout = 10 * time.Second
+
+// transactionLogColumns adalah kolom transaction_logs yang diisi oleh AuditWriter.
+var transactionLogColumns = []string{
+	"timestamp", "user_id", "perangkat", "service_name",
+	"request_body", "response_body", "request_param", "result", "header", "request_id",
+}
+
+// AuditCopier adalah bagian dari pgxpool.Pool yang dipakai AuditWriter untuk menulis batch dengan COPY.
+type AuditCopier interface {
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// AuditStats berisi metrik antrean log transaksi.
+type AuditStats struct {
+	QueueDepth    int    `json:"queue_depth"`    // Jumlah log yang sedang menunggu ditulis
+	QueueCapacity int    `json:"queue_capacity"` // Kapasitas maksimum antrean
+	Enqueued      uint64 `json:"enqueued"`       // Total log yang masuk antrean
+	Written       uint64 `json:"written"`        // Total log yang berhasil ditulis ke database
+	Dropped       uint64 `json:"dropped"`        // Total log yang dibuang karena antrean penuh atau writer sudah ditutup
+	Failed        uint64 `json:"failed"`         // Total log yang gagal ditulis ke database
+	Batches       uint64 `json:"batches"`        // Total batch COPY yang dijalankan
+}
+
+// AuditWriter menulis transaction_logs secara asinkron melalui antrean berkapasitas tetap.
+// Log dikumpulkan lalu ditulis per batch dengan COPY ketika batch penuh atau FlushInterval berlalu.
+// Jika antrean penuh, request menunggu paling lama EnqueueTimeout sebelum log dibuang,
+// sehingga database yang lambat tidak membuat memori tumbuh tanpa batas.
+type AuditWriter struct {
+	db     AuditCopier
+	config config.AuditConfig
+	queue  chan TransactionLog
+	done   chan struct{}
+
+	mu     sync.RWMutex // Melindungi closed agar Enqueue tidak mengirim ke channel yang sudah ditutup
+	closed bool
+
+	enqueued atomic.Uint64
+	written  atomic.Uint64
+	dropped  atomic.Uint64
+	failed   atomic.Uint64
+	batches  atomic.Uint64
+}
+
+// NewAuditWriter membuat AuditWriter dan langsung menjalankan goroutine penulisnya.
+// Close harus dipanggil saat shutdown agar sisa antrean ditulis sebelum pool ditutup.
+func NewAuditWriter(db AuditCopier, cfg config.AuditConfig) *AuditWriter {
+	writer := &AuditWriter{
+		db:     db,
+		config: cfg,
+		queue:  make(chan TransactionLog, cfg.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go writer.run()
+	return writer
+}
+
+// Enqueue memasukkan satu log ke antrean. Nilai kembali false berarti log dibuang.
+func (a *AuditWriter) Enqueue(entry TransactionLog) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		a.dropped.Add(1)
+		return false
+	}
+
+	// Coba masuk antrean tanpa menunggu terlebih dahulu
+	select {
+	case a.queue <- entry:
+		a.enqueued.Add(1)
+		return true
+	default:
+	}
+
+	// Antrean penuh: beri backpressure singkat sebelum membuang log
+	if a.config.EnqueueTimeout > 0 {
+		timer := time.NewTimer(a.config.EnqueueTimeout)
+		defer timer.Stop()
+		select {
+		case a.queue <- entry:
+			a.enqueued.Add(1)
+			return true
+		case <-timer.C:
+		}
+	}
+
+	a.dropped.Add(1)
+	slog.Warn("Antrean log transaksi penuh, log dibuang")
+	return false
+}
+
+// Stats mengembalikan metrik antrean saat ini.
+func (a *AuditWriter) Stats() AuditStats {
+	return AuditStats{
+		QueueDepth:    len(a.queue),
+		QueueCapacity: cap(a.queue),
+		Enqueued:      a.enqueued.Load(),
+		Written:       a.written.Load(),
+		Dropped:       a.dropped.Load(),
+		Failed:        a.failed.Load(),
+		Batches:       a.batches.Load(),
+	}
+}
+
+// Close berhenti menerima log baru lalu menunggu sisa antrean ditulis.
+// Jika ctx berakhir lebih dulu, fungsi ini mengembalikan ctx.Err() dan log yang tersisa mungkin hilang.
+func (a *AuditWriter) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run mengumpulkan log dari antrean dan menulisnya per batch sampai antrean ditutup.
+func (a *AuditWriter) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]TransactionLog, 0, a.config.BatchSize)
+	for {
+		select {
+		case entry, ok := <-a.queue:
+			if !ok {
+				a.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= a.config.BatchSize {
+				a.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				a.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush menulis satu batch log ke transaction_logs dengan COPY.
+func (a *AuditWriter) flush(batch []TransactionLog) {
+	if len(batch) == 0 {
+		return
+	}
+
+	rows := make([][]any, 0, len(batch))
+	for _, entry := range batch {
+		rows = append(rows, []any{
+			entry.Timestamp,
+			entry.UserID,
+			entry.Perangkat,
+			entry.ServiceName,
+			entry.RequestBody,
+			entry.ResponseBody,
+			entry.RequestParam,
+			entry.Result,
+			entry.Header,
+			entry.RequestID,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditFlushTimeout)
+	defer cancel()
+
+	a.batches.Add(1)
+	count, err := a.db.CopyFrom(ctx, pgx.Identifier{"transaction_logs"}, transactionLogColumns, pgx.CopyFromRows(rows))
+	if err != nil {
+		a.failed.Add(uint64(len(batch)))
+		slog.Error("Gagal simpan log transaksi", "count", len(batch), "error", err)
+		return
+	}
+	a.written.Add(uint64(count))
+}
@@ -0,0 +1,187 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"go_rest_native_sekolah/config"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// copierPalsu mencatat setiap batch COPY sebagai daftar request_id.
+// Jika tahan tidak nil, CopyFrom menunggu tahan ditutup sebelum menulis.
+type copierPalsu struct {
+	mu      sync.Mutex
+	batch   [][]string
+	gagal   error
+	tahan   chan struct{}
+	mulai   chan struct{} // diisi setiap kali CopyFrom dipanggil
+	ditulis chan struct{} // diisi setiap kali satu batch selesai dicatat
+}
+
+func newCopierPalsu() *copierPalsu {
+	return &copierPalsu{mulai: make(chan struct{}, 16), ditulis: make(chan struct{}, 16)}
+}
+
+func (c *copierPalsu) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	c.mulai <- struct{}{}
+	if c.tahan != nil {
+		<-c.tahan
+	}
+	if c.gagal != nil {
+		c.ditulis <- struct{}{}
+		return 0, c.gagal
+	}
+
+	var ids []string
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return 0, err
+		}
+		ids = append(ids, values[3].(string)) // service_name
+	}
+	c.mu.Lock()
+	c.batch = append(c.batch, ids)
+	c.mu.Unlock()
+	c.ditulis <- struct{}{}
+	return int64(len(ids)), nil
+}
+
+func (c *copierPalsu) semuaBatch() [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]string(nil), c.batch...)
+}
+
+// tunggu menunggu satu sinyal dari ch atau menggagalkan test setelah satu detik.
+func tunggu(t *testing.T, ch chan struct{}, apa string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("timeout menunggu %s", apa)
+	}
+}
+
+func logUji(n int) TransactionLog {
+	return TransactionLog{Timestamp: time.Now(), ServiceName: "/uji/" + strconv.Itoa(n)}
+}
+
+func TestAuditWriterBatchSize(t *testing.T) {
+	copier := newCopierPalsu()
+	writer := NewAuditWriter(copier, config.AuditConfig{QueueSize: 10, BatchSize: 3, FlushInterval: time.Hour})
+
+	for i := 1; i <= 4; i++ {
+		assert.True(t, writer.Enqueue(logUji(i)))
+	}
+	tunggu(t, copier.ditulis, "batch penuh")
+	assert.Equal(t, [][]string{{"/uji/1", "/uji/2", "/uji/3"}}, copier.semuaBatch())
+
+	// Sisa log yang belum mencapai BatchSize baru ditulis saat Close
+	assert.NoError(t, writer.Close(context.Background()))
+	assert.Equal(t, [][]string{{"/uji/1", "/uji/2", "/uji/3"}, {"/uji/4"}}, copier.semuaBatch())
+
+	stats := writer.Stats()
+	assert.Equal(t, uint64(4), stats.Enqueued)
+	assert.Equal(t, uint64(4), stats.Written)
+	assert.Equal(t, uint64(2), stats.Batches)
+	assert.Zero(t, stats.Dropped)
+}
+
+func TestAuditWriterFlushInterval(t *testing.T) {
+	copier := newCopierPalsu()
+	writer := NewAuditWriter(copier, config.AuditConfig{QueueSize: 10, BatchSize: 100, FlushInterval: 20 * time.Millisecond})
+	defer writer.Close(context.Background())
+
+	assert.True(t, writer.Enqueue(logUji(1)))
+	assert.True(t, writer.Enqueue(logUji(2)))
+
+	// Ticker bisa berdetak di antara kedua Enqueue sehingga log boleh terbagi ke dua batch
+	assert.Eventually(t, func() bool { return writer.Stats().Written == 2 }, time.Second, 5*time.Millisecond)
+	var ids []string
+	for _, batch := range copier.semuaBatch() {
+		ids = append(ids, batch...)
+	}
+	assert.Equal(t, []string{"/uji/1", "/uji/2"}, ids)
+}
+
+func TestAuditWriterAntreanPenuh(t *testing.T) {
+	copier := newCopierPalsu()
+	copier.tahan = make(chan struct{})
+	writer := NewAuditWriter(copier, config.AuditConfig{
+		QueueSize: 1, BatchSize: 1, FlushInterval: time.Hour, EnqueueTimeout: 10 * time.Millisecond,
+	})
+
+	// Log pertama diambil writer yang lalu tertahan di COPY, log kedua mengisi antrean
+	assert.True(t, writer.Enqueue(logUji(1)))
+	tunggu(t, copier.mulai, "COPY pertama")
+	assert.True(t, writer.Enqueue(logUji(2)))
+
+	// Antrean penuh: log ketiga dibuang setelah EnqueueTimeout
+	assert.False(t, writer.Enqueue(logUji(3)))
+	stats := writer.Stats()
+	assert.Equal(t, uint64(2), stats.Enqueued)
+	assert.Equal(t, uint64(1), stats.Dropped)
+	assert.Equal(t, 1, stats.QueueDepth)
+	assert.Equal(t, 1, stats.QueueCapacity)
+
+	close(copier.tahan)
+	assert.NoError(t, writer.Close(context.Background()))
+	assert.Equal(t, [][]string{{"/uji/1"}, {"/uji/2"}}, copier.semuaBatch())
+	assert.Equal(t, uint64(2), writer.Stats().Written)
+}
+
+func TestAuditWriterClose(t *testing.T) {
+	t.Run("sisa antrean ditulis sebelum Close selesai", func(t *testing.T) {
+		copier := newCopierPalsu()
+		writer := NewAuditWriter(copier, config.AuditConfig{QueueSize: 10, BatchSize: 100, FlushInterval: time.Hour})
+
+		for i := 1; i <= 5; i++ {
+			assert.True(t, writer.Enqueue(logUji(i)))
+		}
+		assert.NoError(t, writer.Close(context.Background()))
+		assert.Equal(t, [][]string{{"/uji/1", "/uji/2", "/uji/3", "/uji/4", "/uji/5"}}, copier.semuaBatch())
+
+		// Log setelah Close dibuang, dan Close kedua tidak panic
+		assert.False(t, writer.Enqueue(logUji(6)))
+		assert.Equal(t, uint64(1), writer.Stats().Dropped)
+		assert.NoError(t, writer.Close(context.Background()))
+	})
+
+	t.Run("Close berhenti menunggu saat ctx berakhir", func(t *testing.T) {
+		copier := newCopierPalsu()
+		copier.tahan = make(chan struct{})
+		writer := NewAuditWriter(copier, config.AuditConfig{QueueSize: 10, BatchSize: 1, FlushInterval: time.Hour})
+
+		assert.True(t, writer.Enqueue(logUji(1)))
+		tunggu(t, copier.mulai, "COPY")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, writer.Close(ctx), context.DeadlineExceeded)
+
+		close(copier.tahan)
+		assert.NoError(t, writer.Close(context.Background()))
+	})
+
+	t.Run("batch yang gagal dihitung sebagai failed", func(t *testing.T) {
+		copier := newCopierPalsu()
+		copier.gagal = errors.New("koneksi terputus")
+		writer := NewAuditWriter(copier, config.AuditConfig{QueueSize: 10, BatchSize: 100, FlushInterval: time.Hour})
+
+		assert.True(t, writer.Enqueue(logUji(1)))
+		assert.True(t, writer.Enqueue(logUji(2)))
+		assert.NoError(t, writer.Close(context.Background()))
+
+		stats := writer.Stats()
+		assert.Equal(t, uint64(2), stats.Failed)
+		assert.Zero(t, stats.Written)
+		assert.Equal(t, uint64(1), stats.Batches)
+	})
+}
@@ -0,0 +1,296 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BulkMode menentukan bagaimana operasi dalam satu request bulk dijalankan.
+type BulkMode string
+
+const (
+	// BulkModeAtomic menjalankan semua operasi dalam satu transaksi.
+	// Jika satu operasi gagal, semua operasi dibatalkan.
+	BulkModeAtomic BulkMode = "atomic"
+	// BulkModePartial menjalankan setiap operasi dalam transaksinya sendiri.
+	// Operasi yang gagal tidak membatalkan operasi lain.
+	BulkModePartial BulkMode = "partial"
+)
+
+// BulkAction adalah jenis satu operasi dalam request bulk.
+type BulkAction string
+
+const (
+	BulkActionCreate BulkAction = "create"
+	BulkActionUpdate BulkAction = "update"
+	BulkActionDelete BulkAction = "delete"
+)
+
+// MaxBulkOperations adalah jumlah maksimum operasi dalam satu request bulk.
+const MaxBulkOperations = 100
+
+// errBulkAborted menghentikan transaksi BulkModeAtomic setelah satu operasi gagal.
+var errBulkAborted = errors.New("bulk dibatalkan")
+
+// BulkOperation adalah satu operasi create, update, atau delete dalam request bulk.
+// T adalah isi data, misalnya formatter request atau Core dari sebuah fitur.
+type BulkOperation[T any] struct {
+	Action  BulkAction   `json:"action"`
+	ID      string       `json:"id,omitempty"`      // Wajib untuk update dan delete
+	Version int          `json:"version,omitempty"` // Versi data (ETag), wajib untuk update dan delete
+	Policy  DeletePolicy `json:"policy,omitempty"`  // Policy hapus untuk delete kelas dan guru
+	Target  string       `json:"target,omitempty"`  // ID pengganti untuk policy reassign
+	Data    T            `json:"data"`              // Isi data untuk create dan update
+}
+
+// BulkRequest adalah body request endpoint bulk.
+type BulkRequest[T any] struct {
+	Mode       BulkMode           `json:"mode"` // Kosong berarti BulkModeAtomic
+	Operations []BulkOperation[T] `json:"operations"`
+}
+
+// DecodeBulkRequest membaca dan memvalidasi body JSON endpoint bulk.
+func DecodeBulkRequest[T any](r *http.Request) (*BulkRequest[T], error) {
+	var req BulkRequest[T]
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("validation error: gagal membaca JSON: %v", err)
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Validate mengisi mode bawaan dan memastikan setiap operasi lengkap
+// sebelum ada data yang diubah.
+func (req *BulkRequest[T]) Validate() error {
+	req.Mode = BulkMode(strings.ToLower(strings.TrimSpace(string(req.Mode))))
+	switch req.Mode {
+	case "":
+		req.Mode = BulkModeAtomic
+	case BulkModeAtomic, BulkModePartial:
+	default:
+		return fmt.Errorf("validation error: mode bulk tidak dikenal: %q", req.Mode)
+	}
+
+	if len(req.Operations) == 0 {
+		return errors.New("validation error: operations tidak boleh kosong")
+	}
+	if len(req.Operations) > MaxBulkOperations {
+		return fmt.Errorf("validation error: operations maksimal %d per request", MaxBulkOperations)
+	}
+
+	for i := range req.Operations {
+		op := &req.Operations[i]
+		op.Action = BulkAction(strings.ToLower(strings.TrimSpace(string(op.Action))))
+		op.ID = strings.TrimSpace(op.ID)
+		switch op.Action {
+		case BulkActionCreate:
+		case BulkActionUpdate, BulkActionDelete:
+			if op.ID == "" {
+				return fmt.Errorf("validation error: operations[%d]: id wajib diisi untuk %s", i, op.Action)
+			}
+			if op.Version < 1 {
+				return fmt.Errorf("validation error: operations[%d]: version wajib diisi untuk %s", i, op.Action)
+			}
+		default:
+			return fmt.Errorf("validation error: operations[%d]: action tidak dikenal: %q", i, op.Action)
+		}
+		policy, err := ParseDeletePolicy(string(op.Policy), "")
+		if err != nil {
+			return fmt.Errorf("operations[%d]: %w", i, err)
+		}
+		op.Policy = policy
+		op.Target = strings.TrimSpace(op.Target)
+	}
+	return nil
+}
+
+// DeleteOptions mengembalikan policy hapus yang dipilih untuk operasi delete.
+func (op BulkOperation[T]) DeleteOptions() DeleteOptions {
+	return DeleteOptions{Policy: op.Policy, TargetID: op.Target}
+}
+
+// MapBulkOperations mengubah isi data setiap operasi, misalnya dari formatter request ke Core.
+func MapBulkOperations[T, U any](ops []BulkOperation[T], convert func(T) U) []BulkOperation[U] {
+	mapped := make([]BulkOperation[U], len(ops))
+	for i, op := range ops {
+		mapped[i] = BulkOperation[U]{
+			Action:  op.Action,
+			ID:      op.ID,
+			Version: op.Version,
+			Policy:  op.Policy,
+			Target:  op.Target,
+			Data:    convert(op.Data),
+		}
+	}
+	return mapped
+}
+
+// BulkItemResult adalah hasil satu operasi dalam request bulk.
+type BulkItemResult struct {
+	Index  int        `json:"index"` // Posisi operasi di request
+	Action BulkAction `json:"action"`
+	ID     string     `json:"id,omitempty"` // ID data, termasuk ID baru untuk create yang berhasil
+	Status int        `json:"status"`       // Status HTTP operasi ini jika dijalankan sendiri
+	Error  string     `json:"error,omitempty"`
+	Detail any        `json:"detail,omitempty"` // Misalnya daftar data yang masih merujuk saat delete diblokir
+}
+
+// BulkResult adalah hasil seluruh request bulk beserta hasil per operasi.
+type BulkResult struct {
+	Mode     BulkMode         `json:"mode"`
+	Berhasil int              `json:"berhasil"`
+	Gagal    int              `json:"gagal"`
+	Items    []BulkItemResult `json:"items"`
+}
+
+// StatusCode mengembalikan status HTTP untuk response bulk:
+// 200 jika semua operasi berhasil, 207 jika mode partial dan ada yang gagal,
+// atau status operasi yang gagal jika mode atomic dibatalkan.
+func (res *BulkResult) StatusCode() int {
+	if res.Gagal == 0 {
+		return http.StatusOK
+	}
+	if res.Mode == BulkModePartial {
+		return http.StatusMultiStatus
+	}
+	for _, item := range res.Items {
+		if item.Status != http.StatusFailedDependency {
+			return item.Status
+		}
+	}
+	return http.StatusFailedDependency
+}
+
+// Message mengembalikan ringkasan hasil bulk untuk response API.
+func (res *BulkResult) Message() string {
+	switch {
+	case res.Gagal == 0:
+		return fmt.Sprintf("Berhasil menjalankan %d operasi", res.Berhasil)
+	case res.Mode == BulkModeAtomic:
+		return "Semua operasi dibatalkan karena ada operasi yang gagal"
+	default:
+		return fmt.Sprintf("%d operasi berhasil, %d operasi gagal", res.Berhasil, res.Gagal)
+	}
+}
+
+// record mencatat hasil operasi ke-i.
+func (res *BulkResult) record(i int, id string, err error) {
+	item := &res.Items[i]
+	if err == nil {
+		if id != "" {
+			item.ID = id
+		}
+		item.Status = http.StatusOK
+		if item.Action == BulkActionCreate {
+			item.Status = http.StatusCreated
+		}
+		res.Berhasil++
+		return
+	}
+
+	item.Status = bulkErrorStatus(err)
+	item.Error = err.Error()
+	var blocked *DeleteBlockedError
+	if errors.As(err, &blocked) {
+		item.Detail = blocked.Impact
+	}
+	res.Gagal++
+}
+
+// abort menandai semua operasi selain operasi ke-failed sebagai dibatalkan
+// setelah transaksi BulkModeAtomic di-rollback.
+func (res *BulkResult) abort(failed int) {
+	for i := range res.Items {
+		if i == failed {
+			continue
+		}
+		item := &res.Items[i]
+		if item.Action == BulkActionCreate {
+			// ID data baru ikut dibatalkan bersama transaksinya
+			item.ID = ""
+		}
+		item.Status = http.StatusFailedDependency
+		item.Error = fmt.Sprintf("dibatalkan karena operasi index %d gagal", failed)
+	}
+	res.Berhasil = 0
+	res.Gagal = len(res.Items)
+}
+
+// bulkErrorStatus memetakan error dari service ke status HTTP seperti endpoint satuan.
+func bulkErrorStatus(err error) int {
+	var blocked *DeleteBlockedError
+	message := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.As(err, &blocked):
+		return http.StatusConflict
+	case strings.Contains(message, "validation") || strings.Contains(message, "validasi"):
+		return http.StatusBadRequest
+	case errors.Is(err, pgx.ErrNoRows) || strings.Contains(message, "tidak ditemukan") || strings.Contains(message, "no rows affected"):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// BulkApplyFunc menjalankan satu operasi bulk dengan repository transaksi repos
+// dan mengembalikan ID data yang diproses.
+type BulkApplyFunc[T, R any] func(ctx context.Context, repos R, op BulkOperation[T]) (string, error)
+
+// RunBulk menjalankan ops sesuai mode dan mengembalikan hasil per operasi.
+// Pada BulkModeAtomic semua operasi berjalan dalam satu transaksi uow dan operasi pertama yang gagal
+// membatalkan semuanya; pada BulkModePartial setiap operasi berjalan dalam transaksinya sendiri.
+// Error hanya dikembalikan jika transaksi gagal di luar operasi, misalnya saat commit.
+func RunBulk[T, R any](ctx context.Context, uow UnitOfWork[R], mode BulkMode, ops []BulkOperation[T], apply BulkApplyFunc[T, R]) (*BulkResult, error) {
+	if uow == nil || apply == nil {
+		return nil, errors.New("bulk: Nil unit of work")
+	}
+
+	result := &BulkResult{Mode: mode, Items: make([]BulkItemResult, len(ops))}
+	for i, op := range ops {
+		result.Items[i] = BulkItemResult{Index: i, Action: op.Action, ID: op.ID}
+	}
+
+	if mode == BulkModePartial {
+		for i, op := range ops {
+			var id string
+			err := uow.Do(ctx, func(repos R) error {
+				var err error
+				id, err = apply(ctx, repos, op)
+				return err
+			})
+			result.record(i, id, err)
+		}
+		return result, nil
+	}
+
+	failed := -1
+	err := uow.Do(ctx, func(repos R) error {
+		for i, op := range ops {
+			id, err := apply(ctx, repos, op)
+			result.record(i, id, err)
+			if err != nil {
+				failed = i
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+	if failed >= 0 {
+		result.abort(failed)
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
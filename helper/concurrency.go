@@ -0,0 +1,77 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrVersionConflict dikembalikan saat versi data di database sudah berbeda
+	// dengan versi yang dikirim client lewat If-Match (diubah oleh request lain).
+	ErrVersionConflict = errors.New("precondition failed: data sudah diubah oleh request lain, ambil ulang data terbaru")
+	// ErrPreconditionRequired dikembalikan saat request update/delete tidak membawa header If-Match.
+	ErrPreconditionRequired = errors.New("header If-Match wajib diisi dengan ETag data terbaru")
+	// errInvalidIfMatch dikembalikan saat header If-Match bukan ETag versi yang valid.
+	errInvalidIfMatch = errors.New("validation error: header If-Match tidak valid")
+)
+
+// ETag mengubah versi data menjadi nilai header ETag, misalnya "3".
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// SetETag menulis header ETag untuk versi data yang dikirim di response.
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", ETag(version))
+}
+
+// IfMatchVersion membaca versi data dari header If-Match.
+// Format yang diterima adalah ETag dari response sebelumnya ("3" atau W/"3").
+// Mengembalikan ErrPreconditionRequired jika header kosong.
+func IfMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, ErrPreconditionRequired
+	}
+	value = strings.TrimPrefix(value, "W/")
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
+}
+
+// WriteIfMatchError menulis response untuk error dari IfMatchVersion:
+// 428 jika header tidak dikirim dan 400 jika formatnya tidak valid.
+func WriteIfMatchError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrPreconditionRequired) {
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// CheckVersionConflict dipanggil saat UPDATE dengan syarat "version = $n" tidak mengubah baris apa pun.
+// Jika baris aktif dengan id tersebut masih ada berarti versinya sudah berubah (ErrVersionConflict),
+// jika tidak ada dikembalikan pgx.ErrNoRows.
+// Parameter table harus berupa nama tabel tetap, bukan input dari client.
+func CheckVersionConflict(ctx context.Context, db DBTX, table, id string) error {
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND delete_at IS NULL)", table)
+	if err := db.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return fmt.Errorf("gagal memeriksa versi data: %w", err)
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return pgx.ErrNoRows
+}
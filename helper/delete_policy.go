@@ -0,0 +1,131 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DeletePolicy menentukan apa yang terjadi pada data yang masih merujuk
+// ke data yang dihapus, misalnya siswa di kelas yang dihapus.
+type DeletePolicy string
+
+const (
+	// DeletePolicyBlock menolak penghapusan selama masih ada data yang merujuk.
+	DeletePolicyBlock DeletePolicy = "block"
+	// DeletePolicyReassign memindahkan data yang merujuk ke data pengganti (TargetID).
+	DeletePolicyReassign DeletePolicy = "reassign"
+	// DeletePolicyCascade ikut menghapus (soft delete) data yang merujuk.
+	DeletePolicyCascade DeletePolicy = "cascade"
+)
+
+// ParseDeletePolicy mengubah string menjadi DeletePolicy.
+// String kosong menghasilkan fallback.
+func ParseDeletePolicy(value string, fallback DeletePolicy) (DeletePolicy, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch DeletePolicy(value) {
+	case "":
+		return fallback, nil
+	case DeletePolicyBlock, DeletePolicyReassign, DeletePolicyCascade:
+		return DeletePolicy(value), nil
+	default:
+		return "", fmt.Errorf("validation error: policy hapus tidak dikenal: %q", value)
+	}
+}
+
+// DeleteOptions berisi pilihan penghapusan yang dikirim client.
+type DeleteOptions struct {
+	Policy   DeletePolicy // Kosong berarti memakai policy bawaan service
+	TargetID string       // ID pengganti, wajib untuk DeletePolicyReassign
+}
+
+// DeleteOptionsFromRequest membaca policy hapus dari query parameter ?policy= dan ?target=.
+// Policy kosong dibiarkan kosong agar service memakai policy bawaannya.
+func DeleteOptionsFromRequest(r *http.Request) (DeleteOptions, error) {
+	query := r.URL.Query()
+	policy, err := ParseDeletePolicy(query.Get("policy"), "")
+	if err != nil {
+		return DeleteOptions{}, err
+	}
+	return DeleteOptions{Policy: policy, TargetID: strings.TrimSpace(query.Get("target"))}, nil
+}
+
+// Dependent adalah satu data yang merujuk ke data yang akan dihapus.
+type Dependent struct {
+	Tabel string `json:"tabel"` // Nama tabel, misalnya siswa atau mata_pelajaran
+	ID    string `json:"id"`
+	Nama  string `json:"nama"`
+	// Langsung bernilai false jika data hanya terdampak lewat data lain,
+	// misalnya siswa di kelas milik guru yang dihapus. Data seperti ini
+	// hanya ikut terdampak pada DeletePolicyCascade.
+	Langsung bool `json:"langsung"`
+}
+
+// DeleteImpact menjelaskan dampak penghapusan dengan policy tertentu.
+// Dipakai sebagai hasil dry-run maupun isi error saat penghapusan ditolak.
+type DeleteImpact struct {
+	ID         string       `json:"id"`
+	Policy     DeletePolicy `json:"policy"`
+	TargetID   string       `json:"target_id,omitempty"`
+	Aksi       string       `json:"aksi"`          // Apa yang dilakukan pada dependents: diblokir, dipindahkan, atau dihapus
+	DapatHapus bool         `json:"dapat_dihapus"` // false jika policy block dan masih ada dependents
+	Dependents []Dependent  `json:"dependents"`
+}
+
+// NewDeleteImpact menyusun dampak penghapusan dari daftar dependents.
+// Dependents tidak langsung hanya disertakan untuk DeletePolicyCascade.
+func NewDeleteImpact(id string, opts DeleteOptions, dependents []Dependent) *DeleteImpact {
+	impact := &DeleteImpact{
+		ID:         id,
+		Policy:     opts.Policy,
+		TargetID:   opts.TargetID,
+		Dependents: []Dependent{},
+	}
+	for _, d := range dependents {
+		if d.Langsung || opts.Policy == DeletePolicyCascade {
+			impact.Dependents = append(impact.Dependents, d)
+		}
+	}
+
+	switch opts.Policy {
+	case DeletePolicyReassign:
+		impact.Aksi = "dipindahkan"
+	case DeletePolicyCascade:
+		impact.Aksi = "dihapus"
+	default:
+		impact.Aksi = "diblokir"
+	}
+	impact.DapatHapus = opts.Policy != DeletePolicyBlock || len(impact.Dependents) == 0
+	return impact
+}
+
+// DeleteBlockedError dikembalikan saat penghapusan ditolak oleh DeletePolicyBlock.
+type DeleteBlockedError struct {
+	Impact *DeleteImpact
+}
+
+func (e *DeleteBlockedError) Error() string {
+	return fmt.Sprintf("data masih dipakai oleh %d data lain", len(e.Impact.Dependents))
+}
+
+// ValidateDeleteOptions mengisi policy bawaan dan memastikan TargetID valid untuk reassign.
+func ValidateDeleteOptions(id string, opts DeleteOptions, fallback DeletePolicy) (DeleteOptions, error) {
+	if opts.Policy == "" {
+		opts.Policy = fallback
+	}
+	if opts.Policy == "" {
+		opts.Policy = DeletePolicyBlock
+	}
+	if opts.Policy == DeletePolicyReassign {
+		if opts.TargetID == "" {
+			return opts, fmt.Errorf("validation error: target wajib diisi untuk policy %s", DeletePolicyReassign)
+		}
+		if opts.TargetID == id {
+			return opts, errors.New("validation error: target tidak boleh sama dengan data yang dihapus")
+		}
+	} else {
+		opts.TargetID = ""
+	}
+	return opts, nil
+}
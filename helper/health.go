@@ -0,0 +1,76 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// readinessTimeout adalah batas waktu ping database untuk pengecekan readiness.
+const readinessTimeout = 2 * time.Second
+
+// PoolStats merangkum statistik pool koneksi database untuk response readiness.
+type PoolStats struct {
+	MaxConns      int32 `json:"max_conns"`      // Jumlah koneksi maksimum
+	TotalConns    int32 `json:"total_conns"`    // Jumlah koneksi yang sedang dibuka
+	AcquiredConns int32 `json:"acquired_conns"` // Jumlah koneksi yang sedang dipakai
+	IdleConns     int32 `json:"idle_conns"`     // Jumlah koneksi menganggur
+}
+
+// ReadinessStatus adalah isi response endpoint /readyz.
+type ReadinessStatus struct {
+	Status   string    `json:"status"`          // "ok" jika siap menerima request, "unavailable" jika tidak
+	Database string    `json:"database"`        // Hasil ping database
+	Pool     PoolStats `json:"pool"`            // Statistik pool koneksi
+	Error    string    `json:"error,omitempty"` // Pesan error ping jika gagal
+}
+
+// HealthzHandler menangani endpoint liveness /healthz.
+// Endpoint ini hanya memastikan proses masih berjalan dan tidak menyentuh database,
+// agar load balancer tidak me-restart aplikasi hanya karena database sedang bermasalah.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadyzHandler menangani endpoint readiness /readyz.
+// Endpoint ini melakukan ping ke pool database dan mengembalikan 503 jika gagal,
+// sehingga load balancer berhenti mengirim request ke instance yang belum siap.
+func ReadyzHandler(db *pgxpool.Pool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		stat := db.Stat()
+		status := ReadinessStatus{
+			Status:   "ok",
+			Database: "ok",
+			Pool: PoolStats{
+				MaxConns:      stat.MaxConns(),
+				TotalConns:    stat.TotalConns(),
+				AcquiredConns: stat.AcquiredConns(),
+				IdleConns:     stat.IdleConns(),
+			},
+		}
+
+		if err := db.Ping(ctx); err != nil {
+			status.Status = "unavailable"
+			status.Database = "unreachable"
+			status.Error = err.Error()
+			JSONResponse(w, http.StatusServiceUnavailable, status)
+			return
+		}
+
+		JSONResponse(w, http.StatusOK, status)
+	}
+}
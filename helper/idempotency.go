@@ -0,0 +1,276 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/config"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// IdempotencyKeyHeader adalah header yang dikirim client agar request create aman diulang.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader bernilai "true" pada response yang diputar ulang dari penyimpanan.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength adalah panjang maksimum Idempotency-Key (sesuai kolom di database).
+	maxIdempotencyKeyLength = 255
+	// idempotencyStoreTimeout adalah batas waktu menyimpan atau melepas key setelah handler selesai.
+	idempotencyStoreTimeout = 5 * time.Second
+	// idempotencyPurgeInterval adalah jarak minimum antar penghapusan key yang sudah kedaluwarsa.
+	idempotencyPurgeInterval = 10 * time.Minute
+)
+
+// idempotencyRecord adalah satu baris idempotency_keys.
+type idempotencyRecord struct {
+	requestHash string
+	statusCode  *int // nil berarti request pertama masih diproses
+	contentType string
+	body        []byte
+}
+
+// IdempotencyStore menyimpan Idempotency-Key beserta hash request dan response-nya di tabel idempotency_keys.
+// Key hanya berlaku selama TTL; setelah itu key yang sama boleh dipakai untuk request baru.
+type IdempotencyStore struct {
+	db        DBTX
+	ttl       time.Duration
+	maxBody   int64        // Batas ukuran body request yang dibaca middleware dalam byte
+	lastPurge atomic.Int64 // Waktu (unix nano) penghapusan key kedaluwarsa terakhir
+}
+
+// NewIdempotencyStore membuat IdempotencyStore di atas db dengan masa berlaku dan batas body dari cfg.
+// Jika parameter db nil maka akan terjadi panic.
+func NewIdempotencyStore(db DBTX, cfg config.IdempotencyConfig) *IdempotencyStore {
+	if db == nil {
+		panic("idempotency: Nil database")
+	}
+	return &IdempotencyStore{db: db, ttl: cfg.TTL, maxBody: cfg.MaxBodyBytes()}
+}
+
+// claim mencoba mengambil key untuk request ini.
+// Nilai claimed true berarti request boleh diproses; key yang sudah kedaluwarsa diambil alih.
+// Jika key masih berlaku, record berisi data request sebelumnya.
+func (s *IdempotencyStore) claim(ctx context.Context, scope, key, requestHash string) (*idempotencyRecord, bool, error) {
+	s.purgeExpired(ctx)
+
+	query := `INSERT INTO idempotency_keys (scope, idempotency_key, request_hash, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		ON CONFLICT (scope, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status_code = NULL, content_type = NULL,
+			response_body = NULL, created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING true`
+	var claimed bool
+	err := s.db.QueryRow(ctx, query, scope, key, requestHash, s.ttl.Seconds()).Scan(&claimed)
+	if err == nil {
+		return nil, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("gagal menyimpan idempotency key: %w", err)
+	}
+
+	// Key masih berlaku, ambil hasil request sebelumnya
+	var record idempotencyRecord
+	var contentType *string
+	query = `SELECT request_hash, status_code, content_type, response_body FROM idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2`
+	err = s.db.QueryRow(ctx, query, scope, key).Scan(&record.requestHash, &record.statusCode, &contentType, &record.body)
+	if err != nil {
+		return nil, false, fmt.Errorf("gagal membaca idempotency key: %w", err)
+	}
+	if contentType != nil {
+		record.contentType = *contentType
+	}
+	return &record, false, nil
+}
+
+// complete menyimpan response request pertama agar bisa diputar ulang.
+func (s *IdempotencyStore) complete(ctx context.Context, scope, key string, status int, contentType string, body []byte) error {
+	query := `UPDATE idempotency_keys SET status_code = $3, content_type = $4, response_body = $5
+		WHERE scope = $1 AND idempotency_key = $2`
+	if _, err := s.db.Exec(ctx, query, scope, key, status, contentType, body); err != nil {
+		return fmt.Errorf("gagal menyimpan response idempotency: %w", err)
+	}
+	return nil
+}
+
+// release menghapus key agar request dengan key yang sama bisa dicoba lagi.
+func (s *IdempotencyStore) release(ctx context.Context, scope, key string) error {
+	if _, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2", scope, key); err != nil {
+		return fmt.Errorf("gagal melepas idempotency key: %w", err)
+	}
+	return nil
+}
+
+// purgeExpired menghapus key kedaluwarsa paling sering sekali setiap idempotencyPurgeInterval.
+func (s *IdempotencyStore) purgeExpired(ctx context.Context) {
+	now := time.Now().UnixNano()
+	last := s.lastPurge.Load()
+	if now-last < int64(idempotencyPurgeInterval) || !s.lastPurge.CompareAndSwap(last, now) {
+		return
+	}
+	if _, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= NOW()"); err != nil {
+		LoggerFromContext(ctx).Warn("Gagal menghapus idempotency key kedaluwarsa", "error", err)
+	}
+}
+
+// idempotencyRecorder meneruskan response ke client sekaligus menyimpannya untuk diputar ulang.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotencyScope memisahkan key antar client: ID user dari JWT, atau IP untuk endpoint tanpa login.
+func idempotencyScope(r *http.Request) string {
+	if meta, ok := MetaTokenFromContext(r.Context()); ok && meta.ID != "" {
+		return "user:" + meta.ID
+	}
+	return "ip:" + GetClientIP(r)
+}
+
+// idempotencyRequestHash menghitung hash SHA-256 dari method, path, dan body request.
+func idempotencyRequestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// IdempotencyMiddleware membuat endpoint create aman diulang dengan header Idempotency-Key.
+// Request pertama diproses dan response-nya disimpan selama TTL. Request berikutnya dengan key
+// dan body yang sama menerima response yang sama (header Idempotent-Replayed: true) tanpa membuat
+// data baru. Key yang dipakai ulang dengan body berbeda ditolak dengan 422, dan key yang request
+// pertamanya masih diproses ditolak dengan 409. Body yang melebihi batas ditolak dengan 413 sebelum
+// key diambil. Response 5xx dan handler yang panic tidak disimpan
+// agar bisa dicoba lagi. Request tanpa header diteruskan seperti biasa.
+func IdempotencyMiddleware(next http.HandlerFunc, store *IdempotencyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+		if key == "" || store == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			JSONResponse(w, http.StatusBadRequest, APIResponse(http.StatusBadRequest,
+				fmt.Sprintf("validation error: %s maksimal %d karakter", IdempotencyKeyHeader, maxIdempotencyKeyLength), nil))
+			return
+		}
+
+		// Baca body untuk dihitung hash-nya lalu kembalikan agar bisa dibaca controller.
+		// Body dibatasi agar request besar tidak ditampung seluruhnya di memori.
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, store.maxBody))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				JSONResponse(w, http.StatusRequestEntityTooLarge, APIResponse(http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("body request melebihi batas %d byte", maxErr.Limit), nil))
+				return
+			}
+			JSONResponse(w, http.StatusBadRequest, APIResponse(http.StatusBadRequest, "gagal membaca body request", nil))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		logger := LoggerFromContext(ctx)
+		scope := idempotencyScope(r)
+		requestHash := idempotencyRequestHash(r, body)
+
+		record, claimed, err := store.claim(ctx, scope, key, requestHash)
+		if err != nil {
+			logger.Error("Gagal memproses Idempotency-Key", "error", err)
+			JSONResponse(w, http.StatusInternalServerError, APIResponse(http.StatusInternalServerError, "gagal memproses Idempotency-Key", nil))
+			return
+		}
+
+		if !claimed {
+			switch {
+			case record.requestHash != requestHash:
+				JSONResponse(w, http.StatusUnprocessableEntity, APIResponse(http.StatusUnprocessableEntity,
+					"Idempotency-Key sudah dipakai untuk request yang berbeda", nil))
+			case record.statusCode == nil:
+				w.Header().Set("Retry-After", "1")
+				JSONResponse(w, http.StatusConflict, APIResponse(http.StatusConflict,
+					"request dengan Idempotency-Key yang sama sedang diproses", nil))
+			default:
+				logger.Info("Memutar ulang response Idempotency-Key", "path", r.URL.Path)
+				if record.contentType != "" {
+					w.Header().Set("Content-Type", record.contentType)
+				}
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(*record.statusCode)
+				w.Write(record.body)
+			}
+			return
+		}
+
+		// Jika handler panic, key dilepas agar tidak tertahan "sedang diproses" sampai TTL habis,
+		// lalu panic diteruskan ke server seperti biasa
+		selesai := false
+		defer func() {
+			if selesai {
+				return
+			}
+			p := recover()
+			releaseIdempotencyKey(ctx, store, scope, key)
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		recorder := &idempotencyRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		selesai = true
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError {
+			releaseIdempotencyKey(ctx, store, scope, key)
+			return
+		}
+
+		// Simpan hasil walaupun context request sudah selesai atau dibatalkan
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+		defer cancel()
+		if err := store.complete(storeCtx, scope, key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			logger.Error("Gagal menyimpan response Idempotency-Key", "error", err)
+		}
+	}
+}
+
+// releaseIdempotencyKey melepas key setelah handler gagal, walaupun context request sudah dibatalkan.
+func releaseIdempotencyKey(ctx context.Context, store *IdempotencyStore, scope, key string) {
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+	defer cancel()
+	if err := store.release(storeCtx, scope, key); err != nil {
+		LoggerFromContext(ctx).Error("Gagal melepas Idempotency-Key", "error", err)
+	}
+}
@@ -0,0 +1,295 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// barisIdempotency adalah satu baris idempotency_keys di fakeIdempotencyDB.
+type barisIdempotency struct {
+	requestHash string
+	statusCode  *int
+	contentType *string
+	body        []byte
+	expiresAt   time.Time
+}
+
+// fakeIdempotencyDB meniru query IdempotencyStore terhadap tabel idempotency_keys di memori.
+// Waktu database (NOW()) diambil dari field now agar kedaluwarsa bisa diuji.
+type fakeIdempotencyDB struct {
+	mu    sync.Mutex
+	now   time.Time
+	baris map[string]*barisIdempotency
+}
+
+func newFakeIdempotencyDB() *fakeIdempotencyDB {
+	return &fakeIdempotencyDB{now: time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC), baris: map[string]*barisIdempotency{}}
+}
+
+func (f *fakeIdempotencyDB) maju(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeIdempotencyDB) jumlah() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.baris)
+}
+
+func (f *fakeIdempotencyDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case strings.HasPrefix(sql, "UPDATE idempotency_keys SET status_code"):
+		b, ok := f.baris[args[0].(string)+"|"+args[1].(string)]
+		if !ok {
+			return pgconn.NewCommandTag("UPDATE 0"), nil
+		}
+		status, contentType := args[2].(int), args[3].(string)
+		b.statusCode, b.contentType, b.body = &status, &contentType, append([]byte(nil), args[4].([]byte)...)
+		return pgconn.NewCommandTag("UPDATE 1"), nil
+	case strings.HasPrefix(sql, "DELETE FROM idempotency_keys WHERE scope"):
+		delete(f.baris, args[0].(string)+"|"+args[1].(string))
+		return pgconn.NewCommandTag("DELETE 1"), nil
+	case strings.HasPrefix(sql, "DELETE FROM idempotency_keys WHERE expires_at"):
+		for k, b := range f.baris {
+			if !b.expiresAt.After(f.now) {
+				delete(f.baris, k)
+			}
+		}
+		return pgconn.NewCommandTag("DELETE"), nil
+	}
+	return pgconn.CommandTag{}, fmt.Errorf("query tidak dikenal: %s", sql)
+}
+
+func (f *fakeIdempotencyDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, errors.New("tidak dipakai")
+}
+
+func (f *fakeIdempotencyDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := args[0].(string) + "|" + args[1].(string)
+	switch {
+	case strings.HasPrefix(sql, "INSERT INTO idempotency_keys"):
+		if b, ok := f.baris[k]; ok && b.expiresAt.After(f.now) {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		ttl := time.Duration(args[3].(float64) * float64(time.Second))
+		f.baris[k] = &barisIdempotency{requestHash: args[2].(string), expiresAt: f.now.Add(ttl)}
+		return fakeRow{vals: []any{true}}
+	case strings.HasPrefix(sql, "SELECT request_hash"):
+		b, ok := f.baris[k]
+		if !ok {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		return fakeRow{vals: []any{b.requestHash, b.statusCode, b.contentType, b.body}}
+	}
+	return fakeRow{err: fmt.Errorf("query tidak dikenal: %s", sql)}
+}
+
+// fakeRow mengisi tujuan Scan dari vals sesuai urutan kolom.
+type fakeRow struct {
+	vals []any
+	err  error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *bool:
+			*d = r.vals[i].(bool)
+		case *string:
+			*d = r.vals[i].(string)
+		case **int:
+			*d = r.vals[i].(*int)
+		case **string:
+			*d = r.vals[i].(*string)
+		case *[]byte:
+			*d = r.vals[i].([]byte)
+		default:
+			return fmt.Errorf("tipe scan tidak didukung: %T", d)
+		}
+	}
+	return nil
+}
+
+// handlerUji menghitung pemanggilan dan menjawab dengan status dan body dari request.
+type handlerUji struct {
+	mu     sync.Mutex
+	jumlah int
+	status int
+}
+
+func (h *handlerUji) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.jumlah++
+	n, status := h.jumlah, h.status
+	h.mu.Unlock()
+	JSONResponse(w, status, APIResponse(status, "ok", map[string]int{"ke": n}))
+}
+
+func (h *handlerUji) dipanggil() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.jumlah
+}
+
+// kirimIdempotent mengirim POST /kelas/tambah dengan Idempotency-Key dari IP yang sama.
+func kirimIdempotent(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/kelas/tambah", strings.NewReader(body))
+	r.RemoteAddr = "203.0.113.7:40000"
+	r.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	cfg := config.IdempotencyConfig{TTL: 24 * time.Hour, MaxBodyMB: 1}
+
+	t.Run("replay - body sama menerima response yang sama", func(t *testing.T) {
+		db := newFakeIdempotencyDB()
+		h := &handlerUji{status: http.StatusCreated}
+		handler := IdempotencyMiddleware(h.ServeHTTP, NewIdempotencyStore(db, cfg))
+
+		pertama := kirimIdempotent(handler, "k1", `{"kelas":"7A"}`)
+		kedua := kirimIdempotent(handler, "k1", `{"kelas":"7A"}`)
+
+		assert.Equal(t, http.StatusCreated, pertama.Code)
+		assert.Empty(t, pertama.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, http.StatusCreated, kedua.Code)
+		assert.Equal(t, "true", kedua.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, "application/json", kedua.Header().Get("Content-Type"))
+		assert.Equal(t, pertama.Body.String(), kedua.Body.String())
+		assert.Equal(t, 1, h.dipanggil())
+	})
+
+	t.Run("422 - key dipakai ulang dengan body berbeda", func(t *testing.T) {
+		db := newFakeIdempotencyDB()
+		h := &handlerUji{status: http.StatusCreated}
+		handler := IdempotencyMiddleware(h.ServeHTTP, NewIdempotencyStore(db, cfg))
+
+		kirimIdempotent(handler, "k1", `{"kelas":"7A"}`)
+		w := kirimIdempotent(handler, "k1", `{"kelas":"7B"}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 1, h.dipanggil())
+	})
+
+	t.Run("409 - request pertama masih diproses", func(t *testing.T) {
+		db := newFakeIdempotencyDB()
+		mulai, lanjut := make(chan struct{}), make(chan struct{})
+		handler := IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			close(mulai)
+			<-lanjut
+			w.WriteHeader(http.StatusCreated)
+		}, NewIdempotencyStore(db, cfg))
+
+		hasil := make(chan int)
+		go func() { hasil <- kirimIdempotent(handler, "k1", `{}`).Code }()
+		<-mulai
+
+		w := kirimIdempotent(handler, "k1", `{}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+		close(lanjut)
+		assert.Equal(t, http.StatusCreated, <-hasil)
+	})
+
+	t.Run("5xx - key dilepas dan boleh dicoba lagi", func(t *testing.T) {
+		db := newFakeIdempotencyDB()
+		h := &handlerUji{status: http.StatusInternalServerError}
+		handler := IdempotencyMiddleware(h.ServeHTTP, NewIdempotencyStore(db, cfg))
+
+		assert.Equal(t, http.StatusInternalServerError, kirimIdempotent(handler, "k1", `{}`).Code)
+		assert.Equal(t, 0, db.jumlah())
+
+		h.status = http.StatusCreated
+		w := kirimIdempotent(handler, "k1", `{}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 2, h.dipanggil())
+	})
+
+	t.Run("panic - key dilepas lalu panic diteruskan", func(t *testing.T) {
+		db := newFakeIdempotencyDB()
+		gagal := true
+		handler := IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if gagal {
+				panic("handler rusak")
+			}
+			w.WriteHeader(http.StatusCreated)
+		}, NewIdempotencyStore(db, cfg))
+
+		assert.PanicsWithValue(t, "handler rusak", func() { kirimIdempotent(handler, "k1", `{}`) })
+		assert.Equal(t, 0, db.jumlah())
+
+		gagal = false
+		assert.Equal(t, http.StatusCreated, kirimIdempotent(handler, "k1", `{}`).Code)
+	})
+
+	t.Run("ttl - key kedaluwarsa diambil alih request baru", func(t *testing.T) {
+		db := newFakeIdempotencyDB()
+		h := &handlerUji{status: http.StatusCreated}
+		handler := IdempotencyMiddleware(h.ServeHTTP, NewIdempotencyStore(db, cfg))
+
+		kirimIdempotent(handler, "k1", `{"kelas":"7A"}`)
+		db.maju(24*time.Hour - time.Second)
+		assert.Equal(t, http.StatusUnprocessableEntity, kirimIdempotent(handler, "k1", `{"kelas":"7B"}`).Code)
+
+		db.maju(time.Second)
+		w := kirimIdempotent(handler, "k1", `{"kelas":"7B"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+		assert.Contains(t, w.Body.String(), `"ke":2`)
+		assert.Equal(t, 2, h.dipanggil())
+	})
+
+	t.Run("413 - body melebihi batas ditolak sebelum key diambil", func(t *testing.T) {
+		db := newFakeIdempotencyDB()
+		h := &handlerUji{status: http.StatusCreated}
+		handler := IdempotencyMiddleware(h.ServeHTTP, NewIdempotencyStore(db, cfg))
+
+		besar := `{"kelas":"` + strings.Repeat("x", 1<<20) + `"}`
+		w := kirimIdempotent(handler, "k1", besar)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "body request melebihi batas 1048576 byte")
+		assert.Equal(t, 0, h.dipanggil())
+		assert.Equal(t, 0, db.jumlah())
+
+		// Key yang sama tetap bisa dipakai untuk body yang sesuai batas
+		assert.Equal(t, http.StatusCreated, kirimIdempotent(handler, "k1", `{"kelas":"7A"}`).Code)
+	})
+
+	t.Run("tanpa header - diteruskan tanpa menyentuh store", func(t *testing.T) {
+		db := newFakeIdempotencyDB()
+		h := &handlerUji{status: http.StatusCreated}
+		handler := IdempotencyMiddleware(h.ServeHTTP, NewIdempotencyStore(db, cfg))
+
+		kirimIdempotent(handler, "", `{}`)
+		kirimIdempotent(handler, "", `{}`)
+
+		assert.Equal(t, 2, h.dipanggil())
+		assert.Equal(t, 0, db.jumlah())
+	})
+}
@@ -0,0 +1,139 @@
+package helper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"go_rest_native_sekolah/config"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader adalah header yang membawa ID request dari client/proxy dan dikembalikan di response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength adalah panjang maksimum ID request dari client yang masih diterima.
+const maxRequestIDLength = 128
+
+// loggerKey dan requestIDKey adalah key context untuk logger dan ID request.
+type (
+	loggerKey    struct{}
+	requestIDKey struct{}
+)
+
+// NewLogger membuat logger terstruktur yang menulis ke w.
+// level bernilai debug, info, warn, atau error (bawaan info).
+// format bernilai json atau text (bawaan text).
+func NewLogger(w io.Writer, level, format string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: ParseLogLevel(level)}
+	if strings.EqualFold(strings.TrimSpace(format), "json") {
+		return slog.New(slog.NewJSONHandler(w, options))
+	}
+	return slog.New(slog.NewTextHandler(w, options))
+}
+
+// ParseLogLevel mengubah nama level log menjadi slog.Level. Nilai yang tidak dikenal menjadi info.
+func ParseLogLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// InitLogger membuat logger dari konfigurasi LOG_LEVEL dan LOG_FORMAT lalu menjadikannya logger bawaan.
+// Setelah dipanggil, log dari package log standar juga diteruskan ke logger ini.
+func InitLogger(cfg config.LogConfig) *slog.Logger {
+	logger := NewLogger(os.Stdout, cfg.Level, cfg.Format)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// ContextWithLogger menyimpan logger di context.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext mengambil logger milik request dari context.
+// Jika context tidak membawa logger, logger bawaan yang dikembalikan.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// RequestIDFromContext mengambil ID request dari context. String kosong jika tidak ada.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// newRequestID membuat ID request acak 128 bit dalam format hex.
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+// validRequestID memastikan ID request dari client aman untuk dicatat di log.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		isAlphaNumeric := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlphaNumeric && c != '-' && c != '_' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDMiddleware memberi setiap request sebuah ID dan logger yang membawa ID tersebut.
+// ID diambil dari header X-Request-ID jika valid, selain itu dibuat baru, lalu dikembalikan
+// di header response. Logger disimpan di context sehingga controller, service, dan model
+// yang memakai LoggerFromContext mencatat log dengan request_id yang sama.
+// Setelah request selesai, satu baris log akses dicatat.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		logger := slog.Default().With("request_id", requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		ctx = ContextWithLogger(ctx, logger)
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger.Info("request selesai",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", GetClientIP(r),
+		)
+	})
+}
@@ -0,0 +1,223 @@
+package helper
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// metricsBuckets adalah batas atas bucket histogram latensi request dalam detik.
+var metricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestKey mengelompokkan jumlah request per method, route, dan status code.
+type requestKey struct {
+	method string
+	route  string
+	status int
+}
+
+// latencyKey mengelompokkan histogram latensi per method dan route.
+type latencyKey struct {
+	method string
+	route  string
+}
+
+// latencyHistogram menyimpan jumlah observasi kumulatif per bucket beserta total durasi.
+type latencyHistogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// Metrics mengumpulkan metrik request HTTP di memori dan menuliskannya dalam format teks Prometheus.
+// Nilai label ditulis dengan %q yang escape-nya (\\, \", \n) sama dengan format Prometheus.
+// Route yang dipakai sebagai label adalah pola route yang terdaftar di mux, bukan path mentah,
+// agar jumlah label tetap terbatas.
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	latencies map[latencyKey]*latencyHistogram
+}
+
+// NewMetrics membuat penampung metrik request yang masih kosong.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  make(map[requestKey]uint64),
+		latencies: make(map[latencyKey]*latencyHistogram),
+	}
+}
+
+// observe mencatat satu request yang sudah selesai.
+func (m *Metrics) observe(method, route string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{method: method, route: route, status: status}]++
+
+	key := latencyKey{method: method, route: route}
+	histogram, ok := m.latencies[key]
+	if !ok {
+		histogram = &latencyHistogram{buckets: make([]uint64, len(metricsBuckets))}
+		m.latencies[key] = histogram
+	}
+	seconds := duration.Seconds()
+	for i, bound := range metricsBuckets {
+		if seconds <= bound {
+			histogram.buckets[i]++
+		}
+	}
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// statusRecorder menangkap status code yang ditulis handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// MetricsMiddleware mencatat jumlah dan latensi setiap request ke metrics.
+// routeOf menentukan label route untuk request; string kosong dicatat sebagai "unmatched".
+func MetricsMiddleware(next http.Handler, metrics *Metrics, routeOf func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := routeOf(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.observe(r.Method, route, status, time.Since(start))
+	})
+}
+
+// MetricsHandler menampilkan metrik request, pool database, dan antrean log transaksi
+// dalam format teks Prometheus untuk endpoint /metrics.
+func MetricsHandler(metrics *Metrics, db *pgxpool.Pool, auditWriter *AuditWriter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metrics.writeTo(w)
+		writePoolMetrics(w, db)
+		writeAuditMetrics(w, auditWriter)
+	}
+}
+
+// writeTo menulis metrik request HTTP dengan urutan label yang stabil.
+func (m *Metrics) writeTo(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requestKeys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, b := requestKeys[i], requestKeys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	fmt.Fprintln(w, "# HELP http_requests_total Jumlah request HTTP per method, route, dan status code.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	for _, key := range requestKeys {
+		fmt.Fprintf(w, "http_requests_total{method=%q,route=%q,status=\"%d\"} %d\n",
+			key.method, key.route, key.status, m.requests[key])
+	}
+
+	latencyKeys := make([]latencyKey, 0, len(m.latencies))
+	for key := range m.latencies {
+		latencyKeys = append(latencyKeys, key)
+	}
+	sort.Slice(latencyKeys, func(i, j int) bool {
+		a, b := latencyKeys[i], latencyKeys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		return a.method < b.method
+	})
+
+	fmt.Fprintln(w, "# HELP http_request_duration_seconds Latensi request HTTP per method dan route.")
+	fmt.Fprintln(w, "# TYPE http_request_duration_seconds histogram")
+	for _, key := range latencyKeys {
+		histogram := m.latencies[key]
+		labels := fmt.Sprintf("method=%q,route=%q", key.method, key.route)
+		for i, bound := range metricsBuckets {
+			fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), histogram.buckets[i])
+		}
+		fmt.Fprintf(w, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, histogram.count)
+		fmt.Fprintf(w, "http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(histogram.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "http_request_duration_seconds_count{%s} %d\n", labels, histogram.count)
+	}
+}
+
+// writePoolMetrics menulis statistik pool koneksi pgx.
+func writePoolMetrics(w io.Writer, db *pgxpool.Pool) {
+	if db == nil {
+		return
+	}
+	stat := db.Stat()
+	writeMetric(w, "db_pool_max_conns", "gauge", "Jumlah koneksi maksimum pool database.", float64(stat.MaxConns()))
+	writeMetric(w, "db_pool_total_conns", "gauge", "Jumlah koneksi yang sedang dibuka pool database.", float64(stat.TotalConns()))
+	writeMetric(w, "db_pool_acquired_conns", "gauge", "Jumlah koneksi yang sedang dipakai.", float64(stat.AcquiredConns()))
+	writeMetric(w, "db_pool_idle_conns", "gauge", "Jumlah koneksi menganggur.", float64(stat.IdleConns()))
+	writeMetric(w, "db_pool_acquire_total", "counter", "Jumlah total pengambilan koneksi dari pool.", float64(stat.AcquireCount()))
+	writeMetric(w, "db_pool_empty_acquire_total", "counter", "Jumlah pengambilan koneksi yang harus menunggu karena pool kosong.", float64(stat.EmptyAcquireCount()))
+	writeMetric(w, "db_pool_acquire_duration_seconds_total", "counter", "Total waktu menunggu koneksi dari pool.", stat.AcquireDuration().Seconds())
+}
+
+// writeAuditMetrics menulis metrik antrean log transaksi.
+func writeAuditMetrics(w io.Writer, auditWriter *AuditWriter) {
+	if auditWriter == nil {
+		return
+	}
+	stats := auditWriter.Stats()
+	writeMetric(w, "audit_log_queue_depth", "gauge", "Jumlah log transaksi yang menunggu ditulis.", float64(stats.QueueDepth))
+	writeMetric(w, "audit_log_queue_capacity", "gauge", "Kapasitas antrean log transaksi.", float64(stats.QueueCapacity))
+	writeMetric(w, "audit_log_enqueued_total", "counter", "Jumlah log transaksi yang masuk antrean.", float64(stats.Enqueued))
+	writeMetric(w, "audit_log_written_total", "counter", "Jumlah log transaksi yang berhasil ditulis.", float64(stats.Written))
+	writeMetric(w, "audit_log_dropped_total", "counter", "Jumlah log transaksi yang dibuang.", float64(stats.Dropped))
+	writeMetric(w, "audit_log_failed_total", "counter", "Jumlah log transaksi yang gagal ditulis.", float64(stats.Failed))
+	writeMetric(w, "audit_log_batches_total", "counter", "Jumlah batch COPY log transaksi.", float64(stats.Batches))
+}
+
+// writeMetric menulis satu metrik tanpa label beserta HELP dan TYPE.
+func writeMetric(w io.Writer, name, metricType, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, metricType, name, strconv.FormatFloat(value, 'g', -1, 64))
+}
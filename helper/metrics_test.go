@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"context"
+	"go_rest_native_sekolah/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ambilMetrics memanggil MetricsHandler lalu mengembalikan baris metrik tanpa komentar HELP dan TYPE.
+func ambilMetrics(t *testing.T, handler http.HandlerFunc) []string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+
+	var baris []string
+	for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			baris = append(baris, line)
+		}
+	}
+	return baris
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	metrics := NewMetrics()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /kelas/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "hilang" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("POST /kelas/tambah", func(w http.ResponseWriter, r *http.Request) {})
+	handler := MetricsMiddleware(mux, metrics, func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	})
+
+	for _, path := range []string{"/kelas/1", "/kelas/2", "/kelas/hilang"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/kelas/tambah", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/tidak-ada", nil))
+
+	baris := ambilMetrics(t, MetricsHandler(metrics, nil, nil))
+
+	// Label route memakai pola mux, bukan path mentah; handler yang tidak menulis apa pun dicatat 200
+	assert.Contains(t, baris, `http_requests_total{method="GET",route="GET /kelas/{id}",status="200"} 2`)
+	assert.Contains(t, baris, `http_requests_total{method="GET",route="GET /kelas/{id}",status="404"} 1`)
+	assert.Contains(t, baris, `http_requests_total{method="POST",route="POST /kelas/tambah",status="200"} 1`)
+	assert.Contains(t, baris, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, baris, `http_request_duration_seconds_count{method="GET",route="GET /kelas/{id}"} 3`)
+	for _, line := range baris {
+		assert.NotContains(t, line, "/kelas/1")
+		assert.NotContains(t, line, "hilang")
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	t.Run("histogram kumulatif dan label di-escape", func(t *testing.T) {
+		metrics := NewMetrics()
+		metrics.observe(http.MethodGet, "/siswa", http.StatusOK, 20*time.Millisecond)
+		metrics.observe(http.MethodGet, "/siswa", http.StatusOK, 300*time.Millisecond)
+		metrics.observe(http.MethodGet, `/a"b\c`, http.StatusOK, time.Millisecond)
+
+		baris := ambilMetrics(t, MetricsHandler(metrics, nil, nil))
+
+		assert.Equal(t, []string{
+			`http_requests_total{method="GET",route="/a\"b\\c",status="200"} 1`,
+			`http_requests_total{method="GET",route="/siswa",status="200"} 2`,
+		}, baris[:2])
+		assert.Contains(t, baris, `http_request_duration_seconds_bucket{method="GET",route="/siswa",le="0.01"} 0`)
+		assert.Contains(t, baris, `http_request_duration_seconds_bucket{method="GET",route="/siswa",le="0.025"} 1`)
+		assert.Contains(t, baris, `http_request_duration_seconds_bucket{method="GET",route="/siswa",le="0.25"} 1`)
+		assert.Contains(t, baris, `http_request_duration_seconds_bucket{method="GET",route="/siswa",le="0.5"} 2`)
+		assert.Contains(t, baris, `http_request_duration_seconds_bucket{method="GET",route="/siswa",le="+Inf"} 2`)
+		assert.Contains(t, baris, `http_request_duration_seconds_sum{method="GET",route="/siswa"} 0.32`)
+		assert.Contains(t, baris, `http_request_duration_seconds_count{method="GET",route="/siswa"} 2`)
+		assert.Contains(t, baris, `http_request_duration_seconds_bucket{method="GET",route="/a\"b\\c",le="0.005"} 1`)
+	})
+
+	t.Run("metrik pool dan antrean log transaksi", func(t *testing.T) {
+		writer := NewAuditWriter(newCopierPalsu(), config.AuditConfig{QueueSize: 10, BatchSize: 100, FlushInterval: time.Hour})
+		assert.True(t, writer.Enqueue(logUji(1)))
+		assert.NoError(t, writer.Close(context.Background()))
+
+		baris := ambilMetrics(t, MetricsHandler(NewMetrics(), poolTakTerjangkau(t), writer))
+
+		assert.Contains(t, baris, "db_pool_max_conns 3")
+		assert.Contains(t, baris, "db_pool_acquired_conns 0")
+		assert.Contains(t, baris, "audit_log_queue_capacity 10")
+		assert.Contains(t, baris, "audit_log_enqueued_total 1")
+		assert.Contains(t, baris, "audit_log_written_total 1")
+		assert.Contains(t, baris, "audit_log_dropped_total 0")
+		assert.Contains(t, baris, "audit_log_batches_total 1")
+	})
+
+	t.Run("tanpa pool dan writer hanya metrik request yang ditulis", func(t *testing.T) {
+		baris := ambilMetrics(t, MetricsHandler(NewMetrics(), nil, nil))
+		assert.Empty(t, baris)
+	})
+
+	t.Run("405 - selain GET ditolak", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		MetricsHandler(NewMetrics(), nil, nil)(w, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
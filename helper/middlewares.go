@@ -1,21 +1,62 @@
 package helper
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"go_rest_native_sekolah/config"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
+	"net/netip"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/sirupsen/logrus"
 )
 
 type MetaToken struct {
-	ID  string `json:"id"`
-	Exp string `json:"exp"`
+	ID   string `json:"id"`
+	Role string `json:"role"`
+	Typ  string `json:"typ"`
+	Exp  string `json:"exp"`
+}
+
+// TokenTypeChallenge menandai token sementara yang diterbitkan setelah password benar
+// tetapi sebelum kode 2FA diverifikasi. Token ini tidak boleh dipakai untuk mengakses API.
+const TokenTypeChallenge = "2fa_challenge"
+
+// ChallengeTokenTTL adalah masa berlaku token challenge 2FA.
+const ChallengeTokenTTL = 5 * time.Minute
+
+// tokenConfig berisi secret dan masa berlaku token yang dipasang lewat InitToken saat startup.
+var tokenConfig config.AuthConfig
+
+// errJWTSecretNotSet dikembalikan jika token dibuat atau diverifikasi sebelum InitToken dipanggil.
+var errJWTSecretNotSet = errors.New("JWT secret belum dikonfigurasi")
+
+// InitToken memasang konfigurasi token JWT. Dipanggil sekali saat startup setelah config.Load.
+func InitToken(cfg config.AuthConfig) {
+	tokenConfig = cfg
+}
+
+// jwtKey mengembalikan secret penandatangan token untuk jwt.Parse.
+// Secret kosong ditolak agar token tidak pernah diterima dengan kunci kosong.
+func jwtKey(*jwt.Token) (interface{}, error) {
+	if tokenConfig.JWTSecret == "" {
+		return nil, errJWTSecretNotSet
+	}
+	return []byte(tokenConfig.JWTSecret), nil
+}
+
+// metaTokenKey adalah key context untuk menyimpan MetaToken dari request yang sudah terautentikasi.
+type metaTokenKey struct{}
+
+// MetaTokenFromContext mengambil MetaToken yang disimpan AuthMiddleware di context request.
+// Nilai kedua bernilai false jika request belum melewati AuthMiddleware.
+func MetaTokenFromContext(ctx context.Context) (MetaToken, bool) {
+	meta, ok := ctx.Value(metaTokenKey{}).(MetaToken)
+	return meta, ok
 }
 
 type AccessToken struct {
@@ -24,9 +65,15 @@ type AccessToken struct {
 
 // SignToken digunakan untuk membuat token JWT baru berdasarkan data yang diberikan.
 // Fungsi ini mengembalikan token yang ditandatangani, waktu kedaluwarsa, dan error jika ada.
+// Masa berlaku token diambil dari JWT_TIME_DURATION melalui InitToken.
 func SignToken(data map[string]interface{}) (string, time.Time, error) {
-	// Menetapkan waktu kedaluwarsa token secara hardcode
-	expiryTime := time.Now().UTC().Add(time.Hour * 24) // Waktu kedaluwarsa 24 jam di UTC
+	return SignTokenWithExpiry(data, tokenConfig.AccessTokenTTL)
+}
+
+// SignTokenWithExpiry membuat token JWT baru dengan masa berlaku ttl.
+// Fungsi ini mengembalikan token yang ditandatangani, waktu kedaluwarsa, dan error jika ada.
+func SignTokenWithExpiry(data map[string]interface{}, ttl time.Duration) (string, time.Time, error) {
+	expiryTime := time.Now().UTC().Add(ttl) // Waktu kedaluwarsa di UTC
 
 	// Membuat klaim untuk token
 	claims := jwt.MapClaims{}
@@ -40,8 +87,11 @@ func SignToken(data map[string]interface{}) (string, time.Time, error) {
 	// Membuat token baru dengan metode penandatanganan HS256 dan klaim yang telah dibuat
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	// Menandatangani token dengan secret key dari environment variable JWT_SECRET
-	accessToken, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	// Menandatangani token dengan secret key dari konfigurasi JWT_SECRET
+	if tokenConfig.JWTSecret == "" {
+		return "", time.Time{}, errJWTSecretNotSet
+	}
+	accessToken, err := token.SignedString([]byte(tokenConfig.JWTSecret))
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -50,9 +100,27 @@ func SignToken(data map[string]interface{}) (string, time.Time, error) {
 	return accessToken, expiryTime, nil
 }
 
+// SignChallengeToken membuat token challenge 2FA berumur pendek untuk user tertentu.
+func SignChallengeToken(userID string) (string, time.Time, error) {
+	return SignTokenWithExpiry(map[string]interface{}{"id": userID, "typ": TokenTypeChallenge}, ChallengeTokenTTL)
+}
+
+// VerifyChallengeToken memverifikasi token challenge 2FA dan mengembalikan ID user di dalamnya.
+// Token akses biasa ditolak agar tidak bisa dipakai untuk melewati langkah 2FA.
+func VerifyChallengeToken(requestToken string) (string, error) {
+	meta, err := VerifyTokenHeader(requestToken)
+	if err != nil {
+		return "", err
+	}
+	if meta.Typ != TokenTypeChallenge || meta.ID == "" {
+		return "", jwt.ErrTokenInvalidClaims
+	}
+	return meta.ID, nil
+}
+
 // Middleware untuk memverifikasi token JWT
 // Middleware ini akan memverifikasi apakah token yang dikirimkan lewat header Authorization
-// valid dan sesuai dengan secret key yang diatur di environment variable JWT_SECRET
+// valid dan sesuai dengan secret key yang diatur di konfigurasi JWT_SECRET
 // Jika token tidak valid maka akan dikembalikan error 401 Unauthorized
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -70,36 +138,139 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		// Memverifikasi token yang diterima
 		// Jika token tidak valid maka akan dikembalikan error 401 Unauthorized
-		_, err := VerifyTokenHeader(tokenString)
+		meta, err := VerifyTokenHeader(tokenString)
 		if err != nil {
 			JSONResponse(w, http.StatusUnauthorized, "Invalid token: "+err.Error())
 			return
 		}
 
-		// Jika token valid maka akan dilanjutkan ke handler berikutnya
-		next.ServeHTTP(w, r)
+		// Token challenge 2FA hanya boleh dipakai di endpoint verifikasi 2FA
+		if meta.Typ == TokenTypeChallenge {
+			JSONResponse(w, http.StatusUnauthorized, "Invalid token: verifikasi 2FA belum selesai")
+			return
+		}
+
+		// Jika token valid maka isi token disimpan di context dan dilanjutkan ke handler berikutnya
+		ctx := context.WithValue(r.Context(), metaTokenKey{}, meta)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// RoleMiddleware memverifikasi token seperti AuthMiddleware lalu memastikan
+// role pada token termasuk salah satu role yang diizinkan.
+// Jika role tidak diizinkan maka akan dikembalikan error 403 Forbidden.
+func RoleMiddleware(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		meta, _ := MetaTokenFromContext(r.Context())
+		for _, role := range roles {
+			if meta.Role == role {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		JSONResponse(w, http.StatusForbidden, APIResponse(http.StatusForbidden, "Akses ditolak", nil))
+	})
+}
+
+// trustedProxies berisi reverse proxy tepercaya yang dipasang lewat InitTrustedProxies saat startup.
+var trustedProxies []netip.Prefix
+
+// InitTrustedProxies memasang daftar reverse proxy tepercaya dari TRUSTED_PROXIES.
+// Dipanggil sekali saat startup setelah config.Load; daftar kosong berarti header forwarded selalu diabaikan.
+func InitTrustedProxies(cfg config.ServerConfig) error {
+	proxies, err := cfg.Proxies()
+	if err != nil {
+		return err
+	}
+	trustedProxies = proxies
+	return nil
+}
+
+// GetClientIP mengambil alamat IP client dari request.
+// Secara bawaan digunakan RemoteAddr. Header X-Forwarded-For dan X-Real-IP hanya dibaca jika RemoteAddr
+// termasuk proxy tepercaya (TRUSTED_PROXIES), karena client bisa mengisi header tersebut sesuka hati.
+func GetClientIP(r *http.Request) string {
+	return clientIP(r, trustedProxies)
+}
+
+// clientIP menentukan IP client dengan daftar proxy tepercaya proxies.
+// X-Forwarded-For dibaca dari kanan ke kiri dan hop pertama yang bukan proxy tepercaya dianggap client,
+// sehingga entri palsu yang ditambahkan client di sebelah kiri tidak pernah dipakai.
+func clientIP(r *http.Request, proxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !tepercaya(remote, proxies) {
+		return host
+	}
+
+	// Gabungkan semua header X-Forwarded-For karena proxy boleh mengirimnya lebih dari satu kali
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseHop(hops[i])
+		if !ok {
+			// Entri rusak tidak bisa dipercaya, gunakan hop tepercaya terakhir yang sudah diketahui
+			return client.String()
+		}
+		client = hop
+		if !tepercaya(hop, proxies) {
+			return hop.String()
+		}
+	}
+	if len(hops) == 0 {
+		if realIP, ok := parseHop(r.Header.Get("X-Real-IP")); ok {
+			return realIP.String()
+		}
+	}
+	return client.String()
+}
+
+// parseHop membaca satu entri header forwarded yang berupa IP, dengan atau tanpa port.
+func parseHop(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return addr.Unmap(), true
+	}
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
 	}
+	return netip.Addr{}, false
+}
+
+// tepercaya memeriksa apakah addr termasuk salah satu proxy tepercaya.
+func tepercaya(addr netip.Addr, proxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, p := range proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
 }
 
 // Verifikasi token JWT yang diterima dari header Authorization
-// Token yang diterima harus sesuai dengan secret key yang diatur di environment variable JWT_SECRET
+// Token yang diterima harus sesuai dengan secret key yang diatur di konfigurasi JWT_SECRET
 // Jika token tidak valid maka akan dikembalikan error
 func VerifyTokenHeader(requestToken string) (MetaToken, error) {
 	// Buat token JWT baru
-	token, err := jwt.Parse(requestToken, func(token *jwt.Token) (interface{}, error) {
-		// Gunakan secret key yang diatur di environment variable JWT_SECRET
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	// Gunakan secret key yang diatur di konfigurasi JWT_SECRET
+	token, err := jwt.Parse(requestToken, jwtKey)
 
 	// Jika terjadi error maka akan dikembalikan error
 	if err != nil {
-		log.Println(err)
+		slog.Warn("Verifikasi token gagal", "error", err)
 		return MetaToken{}, err
 	}
 
 	// Jika token tidak valid maka akan dikembalikan error
 	if !token.Valid {
-		log.Println("Token tidak valid")
+		slog.Warn("Token tidak valid")
 		return MetaToken{}, jwt.ErrSignatureInvalid
 	}
 
@@ -109,20 +280,14 @@ func VerifyTokenHeader(requestToken string) (MetaToken, error) {
 }
 
 func VerifyToken(accessToken string) (*jwt.Token, error) {
-	config.LoadEnv() // Pastikan environment variable sudah di-load
-	// Ambil secret key dari environment variable
-	jwtSecretKey := os.Getenv("JWT_SECRET")
-
-	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtSecretKey), nil
-	})
+	token, err := jwt.Parse(accessToken, jwtKey)
 
 	if err != nil {
-		logrus.Error(err.Error())
+		slog.Warn("Verifikasi token gagal", "error", err)
 		return nil, err
 	}
 	if !token.Valid {
-		logrus.Error("Token is not valid")
+		slog.Warn("Token tidak valid")
 		return nil, jwt.ErrSignatureInvalid
 	}
 
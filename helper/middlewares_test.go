@@ -0,0 +1,54 @@
+package helper
+
+import (
+	"go_rest_native_sekolah/config"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := config.ServerConfig{TrustedProxies: "10.0.0.0/8, 127.0.0.1"}.Proxies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{"tanpa proxy - header diabaikan", "203.0.113.7:5123", []string{"1.1.1.1"}, "2.2.2.2", "203.0.113.7"},
+		{"tanpa proxy - ipv6", "[2001:db8::1]:443", nil, "", "2001:db8::1"},
+		{"proxy tepercaya - hop terakhir", "10.0.0.2:80", []string{"203.0.113.7"}, "", "203.0.113.7"},
+		{"proxy tepercaya - entri palsu di kiri diabaikan", "10.0.0.2:80", []string{"6.6.6.6, 203.0.113.7"}, "", "203.0.113.7"},
+		{"rantai proxy tepercaya dilewati", "127.0.0.1:80", []string{"198.51.100.4, 10.1.2.3", "10.0.0.9"}, "", "198.51.100.4"},
+		{"hop dengan port", "10.0.0.2:80", []string{"203.0.113.7:4711"}, "", "203.0.113.7"},
+		{"entri rusak - pakai hop tepercaya terakhir", "10.0.0.2:80", []string{"bukan-ip, 10.0.0.5"}, "", "10.0.0.5"},
+		{"semua hop tepercaya", "10.0.0.2:80", []string{"10.0.0.3"}, "", "10.0.0.3"},
+		{"x-real-ip dari proxy tepercaya", "10.0.0.2:80", nil, "203.0.113.9", "203.0.113.9"},
+		{"proxy tepercaya tanpa header", "10.0.0.2:80", nil, "", "10.0.0.2"},
+		{"remote addr ipv4-mapped", "[::ffff:10.0.0.2]:80", []string{"203.0.113.7"}, "", "203.0.113.7"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remote
+			for _, f := range tc.forwarded {
+				r.Header.Add("X-Forwarded-For", f)
+			}
+			if tc.realIP != "" {
+				r.Header.Set("X-Real-IP", tc.realIP)
+			}
+			assert.Equal(t, tc.want, clientIP(r, proxies))
+		})
+	}
+
+	t.Run("config - cidr tidak valid", func(t *testing.T) {
+		_, err := config.ServerConfig{TrustedProxies: "10.0.0.0/33"}.Proxies()
+		assert.ErrorContains(t, err, "TRUSTED_PROXIES")
+	})
+}
@@ -0,0 +1,191 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Ukuran halaman A4 tegak dalam point (1/72 inci) dan tata letak tabel PDFTabel.
+const (
+	pdfLebar       = 595.0
+	pdfTinggi      = 842.0
+	pdfMargin      = 40.0
+	pdfUkuranJudul = 14.0
+	pdfUkuranTeks  = 9.0
+	pdfBaris       = 14.0
+	// pdfLebarHuruf adalah perkiraan lebar rata-rata satu huruf Helvetica relatif terhadap ukuran font.
+	pdfLebarHuruf = 0.55
+)
+
+// PDFTabel adalah dokumen PDF sederhana berisi judul, beberapa baris keterangan, dan satu tabel teks.
+// Keterangan dan tabel dipecah ke beberapa halaman A4 dengan header kolom diulang di setiap halaman tabel.
+type PDFTabel struct {
+	Judul      string     // Judul dicetak tebal di halaman pertama.
+	Keterangan []string   // Keterangan dicetak di bawah judul, satu baris per elemen.
+	Kolom      []string   // Kolom adalah header tabel.
+	Baris      [][]string // Baris adalah isi tabel; setiap baris sepanjang Kolom.
+}
+
+// Bytes membuat dokumen PDF 1.4 dengan font standar Helvetica tanpa dependensi tambahan.
+// Lebar kolom sebanding dengan teks terpanjangnya dan teks yang terlalu panjang dipotong.
+// Karakter di luar Latin-1 diganti tanda tanya karena font standar memakai WinAnsiEncoding.
+func (t PDFTabel) Bytes() []byte {
+	lebar := t.lebarKolom()
+	halaman := t.halaman(lebar)
+
+	var buf bytes.Buffer
+	var offset []int
+	tulisObjek := func(isi string) {
+		offset = append(offset, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offset), isi)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	// Objek 1-4 tetap; setiap halaman memakai dua objek (page dan content stream) mulai dari objek 5
+	kids := make([]string, len(halaman))
+	for i := range halaman {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	tulisObjek("<< /Type /Catalog /Pages 2 0 R >>")
+	tulisObjek(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(halaman)))
+	tulisObjek("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	tulisObjek("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, isi := range halaman {
+		tulisObjek(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfLebar, pdfTinggi, 6+i*2))
+		tulisObjek(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(isi), isi))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offset)+1)
+	for _, o := range offset {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offset)+1, xref)
+	return buf.Bytes()
+}
+
+// lebarKolom membagi lebar area cetak ke setiap kolom sebanding dengan teks terpanjangnya.
+func (t PDFTabel) lebarKolom() []float64 {
+	bobot := make([]int, len(t.Kolom))
+	total := 0
+	for i, k := range t.Kolom {
+		bobot[i] = utf8.RuneCountInString(k)
+		for _, b := range t.Baris {
+			if i < len(b) {
+				bobot[i] = max(bobot[i], utf8.RuneCountInString(b[i]))
+			}
+		}
+		bobot[i] = min(max(bobot[i], 4), 40)
+		total += bobot[i]
+	}
+
+	lebar := make([]float64, len(bobot))
+	for i, b := range bobot {
+		lebar[i] = (pdfLebar - 2*pdfMargin) * float64(b) / float64(total)
+	}
+	return lebar
+}
+
+// halaman menyusun content stream setiap halaman.
+func (t PDFTabel) halaman(lebar []float64) []string {
+	var hasil []string
+	var isi strings.Builder
+	y := pdfTinggi - pdfMargin
+
+	teks := func(font string, ukuran, x, y float64, s string) {
+		fmt.Fprintf(&isi, "BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, ukuran, x, y, pdfString(s))
+	}
+	baris := func(font string, sel []string) {
+		x := pdfMargin
+		for i, l := range lebar {
+			if i < len(sel) {
+				teks(font, pdfUkuranTeks, x, y, potongTeks(sel[i], l, pdfUkuranTeks))
+			}
+			x += l
+		}
+		y -= pdfBaris
+	}
+	header := func() {
+		baris("F2", t.Kolom)
+		fmt.Fprintf(&isi, "%.2f %.2f m %.2f %.2f l S\n", pdfMargin, y+pdfBaris-3, pdfLebar-pdfMargin, y+pdfBaris-3)
+	}
+	// cukup memastikan masih ada ruang untuk n baris di atas margin bawah, jika tidak pindah ke halaman baru
+	cukup := func(n int) bool {
+		if y >= pdfMargin+float64(n)*pdfBaris {
+			return true
+		}
+		hasil = append(hasil, isi.String())
+		isi.Reset()
+		y = pdfTinggi - pdfMargin - pdfUkuranTeks
+		return false
+	}
+
+	lebarCetak := pdfLebar - 2*pdfMargin
+	if t.Judul != "" {
+		y -= pdfUkuranJudul
+		teks("F2", pdfUkuranJudul, pdfMargin, y, potongTeks(t.Judul, lebarCetak, pdfUkuranJudul))
+		y -= pdfBaris
+	}
+	for _, k := range t.Keterangan {
+		cukup(1)
+		teks("F1", pdfUkuranTeks, pdfMargin, y, potongTeks(k, lebarCetak, pdfUkuranTeks))
+		y -= pdfBaris
+	}
+	y -= pdfBaris / 2
+	// Header kolom tidak boleh tertinggal sendirian di ujung halaman tanpa satu baris isi pun
+	cukup(2)
+	header()
+
+	for _, b := range t.Baris {
+		if !cukup(1) {
+			header()
+		}
+		baris("F1", b)
+	}
+	hasil = append(hasil, isi.String())
+
+	// Nomor halaman di kaki setiap halaman
+	for i := range hasil {
+		hasil[i] += fmt.Sprintf("BT /F1 %g Tf %.2f %.2f Td (%s) Tj ET\n", pdfUkuranTeks-1, pdfMargin, pdfMargin/2,
+			pdfString(fmt.Sprintf("Halaman %d dari %d", i+1, len(hasil))))
+	}
+	return hasil
+}
+
+// potongTeks memotong s agar muat pada lebar point dengan font berukuran ukuran.
+func potongTeks(s string, lebar, ukuran float64) string {
+	maks := int(lebar/(ukuran*pdfLebarHuruf)) - 1
+	if maks < 1 || utf8.RuneCountInString(s) <= maks {
+		return s
+	}
+	r := []rune(s)
+	if maks <= 3 {
+		return string(r[:maks])
+	}
+	return string(r[:maks-3]) + "..."
+}
+
+// pdfString mengubah s menjadi isi string literal PDF berenkoding WinAnsi (Latin-1)
+// dengan karakter \, (, dan ) di-escape.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r' || r == '\t':
+			b.WriteByte(' ')
+		case r < 0x20 || (r >= 0x7F && r < 0xA0) || r > 0xFF:
+			b.WriteByte('?')
+		case r < 0x80:
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "\\%03o", r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	reXrefBaris = regexp.MustCompile(`^(\d{10}) 00000 n $`)
+	reStream    = regexp.MustCompile(`<< /Length (\d+) >>\nstream\n`)
+	reTeks      = regexp.MustCompile(`BT /(F\d) ([\d.]+) Tf ([\d.]+) ([\d.-]+) Td \((.*?)\) Tj ET`)
+)
+
+// periksaStrukturPDF memastikan offset xref, startxref, dan /Length setiap stream cocok dengan isi dokumen,
+// lalu mengembalikan content stream setiap halaman.
+func periksaStrukturPDF(t *testing.T, pdf []byte) []string {
+	t.Helper()
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+
+	// startxref menunjuk tepat ke tabel xref
+	idx := bytes.LastIndex(pdf, []byte("startxref\n"))
+	if !assert.Positive(t, idx) {
+		t.FailNow()
+	}
+	xref, err := strconv.Atoi(strings.Fields(string(pdf[idx+len("startxref\n"):]))[0])
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n")), "startxref %d tidak menunjuk ke xref", xref)
+
+	// Setiap entri xref menunjuk ke awal "<n> 0 obj"
+	baris := strings.Split(string(pdf[xref:]), "\n")
+	var jumlah int
+	fmt.Sscanf(baris[1], "0 %d", &jumlah)
+	assert.Equal(t, "0000000000 65535 f ", baris[2])
+	for n := 1; n < jumlah; n++ {
+		m := reXrefBaris.FindStringSubmatch(baris[2+n])
+		if !assert.NotNil(t, m, "entri xref %d: %q", n, baris[2+n]) {
+			continue
+		}
+		offset, _ := strconv.Atoi(m[1])
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", n))), "offset objek %d salah", n)
+	}
+	assert.Contains(t, string(pdf), fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R >>", jumlah))
+
+	// /Length sama dengan panjang isi stream
+	var halaman []string
+	for _, loc := range reStream.FindAllSubmatchIndex(pdf, -1) {
+		panjang, _ := strconv.Atoi(string(pdf[loc[2]:loc[3]]))
+		isi := pdf[loc[1]:]
+		assert.True(t, bytes.HasPrefix(isi[panjang:], []byte("\nendstream")), "/Length %d tidak cocok", panjang)
+		halaman = append(halaman, string(isi[:panjang]))
+	}
+	assert.Contains(t, string(pdf), fmt.Sprintf("/Count %d >>", len(halaman)))
+	assert.Equal(t, len(halaman), bytes.Count(pdf, []byte("/Type /Page /Parent")))
+	return halaman
+}
+
+// periksaTeksDiHalaman memastikan semua teks berada di dalam halaman; hanya nomor halaman yang boleh di bawah margin.
+func periksaTeksDiHalaman(t *testing.T, halaman []string) {
+	t.Helper()
+	for i, isi := range halaman {
+		for _, m := range reTeks.FindAllStringSubmatch(isi, -1) {
+			y, _ := strconv.ParseFloat(m[4], 64)
+			assert.LessOrEqual(t, y, pdfTinggi-pdfMargin, "halaman %d: %q", i+1, m[5])
+			if strings.HasPrefix(m[5], "Halaman ") {
+				continue
+			}
+			assert.GreaterOrEqual(t, y, pdfMargin, "halaman %d: %q keluar dari margin bawah", i+1, m[5])
+		}
+	}
+}
+
+func TestPDFTabel(t *testing.T) {
+	t.Run("satu halaman", func(t *testing.T) {
+		pdf := PDFTabel{
+			Judul:      "Denah (Ruang 1)",
+			Keterangan: []string{"Sesi: Senin"},
+			Kolom:      []string{"No", "Nama"},
+			Baris:      [][]string{{"1", "Budi"}, {"2", "Siti"}},
+		}.Bytes()
+
+		halaman := periksaStrukturPDF(t, pdf)
+		assert.Len(t, halaman, 1)
+		assert.Contains(t, halaman[0], `(Denah \(Ruang 1\))`)
+		assert.Contains(t, halaman[0], "(Halaman 1 dari 1)")
+		periksaTeksDiHalaman(t, halaman)
+	})
+
+	t.Run("baris tabel berlanjut dengan header diulang", func(t *testing.T) {
+		tabel := PDFTabel{Judul: "Daftar", Kolom: []string{"No", "Nama"}}
+		for i := 1; i <= 120; i++ {
+			tabel.Baris = append(tabel.Baris, []string{strconv.Itoa(i), "Siswa " + strconv.Itoa(i)})
+		}
+
+		halaman := periksaStrukturPDF(t, tabel.Bytes())
+		assert.Len(t, halaman, 3)
+		for i, isi := range halaman {
+			assert.Contains(t, isi, "Td (No) Tj", "header halaman %d", i+1)
+			assert.Contains(t, isi, fmt.Sprintf("(Halaman %d dari 3)", i+1))
+		}
+		assert.Contains(t, halaman[2], "(Siswa 120)")
+		periksaTeksDiHalaman(t, halaman)
+	})
+
+	t.Run("keterangan panjang berlanjut ke halaman berikutnya", func(t *testing.T) {
+		tabel := PDFTabel{Judul: "Denah Ujian", Kolom: []string{"Ruang", "Kursi"}, Baris: [][]string{{"R1", "1"}}}
+		for i := 1; i <= 80; i++ {
+			tabel.Keterangan = append(tabel.Keterangan, fmt.Sprintf("Ruang %d: pengawas Guru %d", i, i))
+		}
+
+		halaman := periksaStrukturPDF(t, tabel.Bytes())
+		assert.Len(t, halaman, 2)
+		assert.Contains(t, halaman[1], "(Ruang 80: pengawas Guru 80)")
+		// Header kolom dan isi tabel dicetak setelah keterangan terakhir, di halaman yang sama
+		assert.Less(t, strings.Index(halaman[1], "Ruang 80:"), strings.Index(halaman[1], "Td (Kursi) Tj"))
+		assert.Contains(t, halaman[1], "(R1)")
+		periksaTeksDiHalaman(t, halaman)
+	})
+
+	t.Run("judul dan keterangan panjang dipotong", func(t *testing.T) {
+		panjang := strings.Repeat("Panjang ", 40)
+		halaman := periksaStrukturPDF(t, PDFTabel{
+			Judul: panjang, Keterangan: []string{panjang}, Kolom: []string{"No"},
+		}.Bytes())
+
+		teks := reTeks.FindAllStringSubmatch(halaman[0], -1)
+		judul, keterangan := teks[0][5], teks[1][5]
+		assert.True(t, strings.HasSuffix(judul, "..."))
+		assert.True(t, strings.HasSuffix(keterangan, "..."))
+		assert.Less(t, float64(len(judul))*pdfUkuranJudul*pdfLebarHuruf, pdfLebar-2*pdfMargin)
+		assert.Less(t, float64(len(keterangan))*pdfUkuranTeks*pdfLebarHuruf, pdfLebar-2*pdfMargin)
+	})
+}
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		masuk, keluar string
+	}{
+		{"Ruang (A)", `Ruang \(A\)`},
+		{`C:\ujian`, `C:\\ujian`},
+		{"Sesi 1\tSenin\r\n", "Sesi 1 Senin  "},
+		{"Kelas 7é", `Kelas 7\351`},
+		{"Nilai ±5 ©", `Nilai \2615 \251`},
+		{"Ujian — 你好 \x01", "Ujian ? ?? ?"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.masuk, func(t *testing.T) {
+			assert.Equal(t, tc.keluar, pdfString(tc.masuk))
+		})
+	}
+}
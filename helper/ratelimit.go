@@ -0,0 +1,199 @@
+package helper
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit mengatur jumlah request yang diizinkan dalam satu periode.
+// Token bucket diisi ulang secara merata sebanyak Requests setiap Per,
+// dan kapasitas bucket (burst) sama dengan Requests.
+type RateLimit struct {
+	Requests int           // Jumlah request maksimum dalam satu periode
+	Per      time.Duration // Lama satu periode
+}
+
+// RateLimitRule menerapkan RateLimit khusus untuk path dengan awalan Prefix.
+type RateLimitRule struct {
+	Prefix string    // Awalan path, misalnya "/login"
+	Limit  RateLimit // Batas untuk path tersebut
+}
+
+// ParseRateLimit membaca batas dengan format "<jumlah>/<durasi>", misalnya "120/1m" atau "10/30s".
+func ParseRateLimit(value string) (RateLimit, error) {
+	parts := strings.SplitN(strings.TrimSpace(value), "/", 2)
+	if len(parts) != 2 {
+		return RateLimit{}, fmt.Errorf("format rate limit tidak valid: %q", value)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || requests <= 0 {
+		return RateLimit{}, fmt.Errorf("jumlah request rate limit tidak valid: %q", value)
+	}
+	per, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || per <= 0 {
+		return RateLimit{}, fmt.Errorf("durasi rate limit tidak valid: %q", value)
+	}
+	return RateLimit{Requests: requests, Per: per}, nil
+}
+
+// RateLimitOrDefault membaca RateLimit dari value milik pengaturan key.
+// Jika value kosong atau formatnya salah, fallback yang digunakan.
+func RateLimitOrDefault(key, value string, fallback RateLimit) RateLimit {
+	if value == "" {
+		return fallback
+	}
+	limit, err := ParseRateLimit(value)
+	if err != nil {
+		slog.Warn("Rate limit tidak valid, menggunakan nilai bawaan", "key", key, "error", err, "default_requests", fallback.Requests, "default_per", fallback.Per)
+		return fallback
+	}
+	return limit
+}
+
+// tokenBucket menyimpan sisa token dan waktu pengisian terakhir untuk satu client.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimitResult berisi hasil pengecekan satu request terhadap bucket.
+type rateLimitResult struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	retryAfter time.Duration // Waktu sampai satu token tersedia, hanya diisi jika ditolak
+	reset      time.Duration // Waktu sampai bucket penuh kembali
+}
+
+// RateLimiter membatasi request per client dengan algoritma token bucket.
+// Client diidentifikasi dengan ID user dari JWT jika ada, atau alamat IP jika tidak.
+// Setiap RateLimitRule memiliki bucket terpisah sehingga batas login tidak memakan kuota endpoint lain.
+type RateLimiter struct {
+	mu           sync.Mutex
+	defaultLimit RateLimit
+	rules        []RateLimitRule
+	buckets      map[string]*tokenBucket
+	lastSweep    time.Time
+	now          func() time.Time
+}
+
+// rateLimitSweepInterval adalah jarak minimum antar pembersihan bucket yang sudah penuh kembali.
+const rateLimitSweepInterval = time.Minute
+
+// NewRateLimiter membuat RateLimiter dengan batas bawaan dan aturan per route.
+// Jika beberapa aturan cocok, aturan dengan awalan terpanjang yang digunakan.
+func NewRateLimiter(defaultLimit RateLimit, rules ...RateLimitRule) *RateLimiter {
+	return &RateLimiter{
+		defaultLimit: defaultLimit,
+		rules:        rules,
+		buckets:      make(map[string]*tokenBucket),
+		lastSweep:    time.Now(),
+		now:          time.Now,
+	}
+}
+
+// limitFor mengembalikan awalan dan batas yang berlaku untuk path.
+// Awalan dicocokkan per segmen, sehingga "/siswa" berlaku untuk "/siswa" dan "/siswa/1" tetapi tidak "/siswax".
+func (l *RateLimiter) limitFor(path string) (string, RateLimit) {
+	prefix, limit := "", l.defaultLimit
+	for _, rule := range l.rules {
+		cocok := path == rule.Prefix || strings.HasPrefix(path, strings.TrimSuffix(rule.Prefix, "/")+"/")
+		if cocok && len(rule.Prefix) > len(prefix) {
+			prefix, limit = rule.Prefix, rule.Limit
+		}
+	}
+	return prefix, limit
+}
+
+// allow mengambil satu token dari bucket milik client untuk path tertentu.
+func (l *RateLimiter) allow(client, path string) rateLimitResult {
+	prefix, limit := l.limitFor(path)
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Per.Seconds() // Token per detik
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	key := prefix + "|" + client
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		l.buckets[key] = bucket
+	} else {
+		elapsed := now.Sub(bucket.last).Seconds()
+		bucket.tokens = math.Min(capacity, bucket.tokens+elapsed*rate)
+		bucket.last = now
+	}
+
+	result := rateLimitResult{limit: limit.Requests}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+	result.remaining = int(bucket.tokens)
+	result.reset = time.Duration((capacity - bucket.tokens) / rate * float64(time.Second))
+	return result
+}
+
+// sweep menghapus bucket yang sudah penuh kembali agar map tidak tumbuh tanpa batas.
+// Bucket penuh sama saja dengan bucket baru, jadi menghapusnya tidak mengubah perilaku limiter.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		prefix := strings.SplitN(key, "|", 2)[0]
+		_, limit := l.limitFor(prefix)
+		if now.Sub(bucket.last) >= limit.Per {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitClient menentukan identitas client untuk rate limit.
+// Token JWT yang valid menghasilkan "user:<id>", selain itu "ip:<alamat IP>". Alamat IP diambil dengan
+// GetClientIP sehingga X-Forwarded-For hanya dipakai dari proxy tepercaya dan tidak bisa diputar client.
+func rateLimitClient(r *http.Request) string {
+	if meta, ok := MetaTokenFromContext(r.Context()); ok && meta.ID != "" {
+		return "user:" + meta.ID
+	}
+	if tokenString := GetTokenFromAuthorizationHeader(r.Header.Get("Authorization")); tokenString != "" {
+		if meta, err := VerifyTokenHeader(tokenString); err == nil && meta.ID != "" {
+			return "user:" + meta.ID
+		}
+	}
+	return "ip:" + GetClientIP(r)
+}
+
+// RateLimitMiddleware membatasi jumlah request per client menggunakan limiter.
+// Setiap response diberi header X-RateLimit-Limit, X-RateLimit-Remaining, dan X-RateLimit-Reset.
+// Jika kuota habis maka akan dikembalikan error 429 Too Many Requests dengan header Retry-After.
+func RateLimitMiddleware(next http.Handler, limiter *RateLimiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := limiter.allow(rateLimitClient(r), r.URL.Path)
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.reset.Seconds()))))
+
+		if !result.allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.retryAfter.Seconds()))))
+			JSONResponse(w, http.StatusTooManyRequests, APIResponse(http.StatusTooManyRequests, "Terlalu banyak request, silakan coba lagi nanti", nil))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
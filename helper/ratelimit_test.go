@@ -0,0 +1,229 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// jamPalsu adalah jam yang bisa dimajukan manual untuk pengujian.
+type jamPalsu struct {
+	t time.Time
+}
+
+func (j *jamPalsu) Now() time.Time       { return j.t }
+func (j *jamPalsu) maju(d time.Duration) { j.t = j.t.Add(d) }
+
+func jamUji() *jamPalsu {
+	return &jamPalsu{t: time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC)}
+}
+
+// limiterUji membuat RateLimiter yang memakai jam palsu j.
+func limiterUji(j *jamPalsu, def RateLimit, rules ...RateLimitRule) *RateLimiter {
+	l := NewRateLimiter(def, rules...)
+	l.now = j.Now
+	l.lastSweep = j.Now()
+	return l
+}
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  RateLimit
+		err   bool
+	}{
+		{"120/1m", RateLimit{Requests: 120, Per: time.Minute}, false},
+		{" 10 / 30s ", RateLimit{Requests: 10, Per: 30 * time.Second}, false},
+		{"10", RateLimit{}, true},
+		{"0/1m", RateLimit{}, true},
+		{"10/0s", RateLimit{}, true},
+		{"sepuluh/1m", RateLimit{}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseRateLimit(tc.value)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	// langkah adalah satu request setelah jam dimajukan sejauh maju.
+	type langkah struct {
+		maju       time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}
+	tests := []struct {
+		name    string
+		limit   RateLimit
+		langkah []langkah
+	}{
+		{
+			name:  "burst habis lalu ditolak dengan retry-after",
+			limit: RateLimit{Requests: 2, Per: time.Second},
+			langkah: []langkah{
+				{0, true, 1, 0, 500 * time.Millisecond},
+				{0, true, 0, 0, time.Second},
+				{0, false, 0, 500 * time.Millisecond, time.Second},
+			},
+		},
+		{
+			name:  "token terisi ulang sebanding waktu",
+			limit: RateLimit{Requests: 4, Per: 4 * time.Second},
+			langkah: []langkah{
+				{0, true, 3, 0, time.Second},
+				{0, true, 2, 0, 2 * time.Second},
+				{0, true, 1, 0, 3 * time.Second},
+				{0, true, 0, 0, 4 * time.Second},
+				{250 * time.Millisecond, false, 0, 750 * time.Millisecond, 3750 * time.Millisecond},
+				{750 * time.Millisecond, true, 0, 0, 4 * time.Second},
+			},
+		},
+		{
+			name:  "bucket tidak melebihi kapasitas",
+			limit: RateLimit{Requests: 2, Per: time.Second},
+			langkah: []langkah{
+				{0, true, 1, 0, 500 * time.Millisecond},
+				{time.Hour, true, 1, 0, 500 * time.Millisecond},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			jam := jamUji()
+			l := limiterUji(jam, tc.limit)
+			for i, lk := range tc.langkah {
+				jam.maju(lk.maju)
+				got := l.allow("ip:203.0.113.7", "/kelas")
+				assert.Equal(t, lk.allowed, got.allowed, "langkah %d allowed", i)
+				assert.Equal(t, tc.limit.Requests, got.limit, "langkah %d limit", i)
+				assert.Equal(t, lk.remaining, got.remaining, "langkah %d remaining", i)
+				assert.InDelta(t, lk.retryAfter, got.retryAfter, float64(time.Millisecond), "langkah %d retryAfter", i)
+				assert.InDelta(t, lk.reset, got.reset, float64(time.Millisecond), "langkah %d reset", i)
+			}
+		})
+	}
+
+	t.Run("client berbeda punya bucket sendiri", func(t *testing.T) {
+		l := limiterUji(jamUji(), RateLimit{Requests: 1, Per: time.Minute})
+
+		assert.True(t, l.allow("ip:1", "/kelas").allowed)
+		assert.False(t, l.allow("ip:1", "/kelas").allowed)
+		assert.True(t, l.allow("ip:2", "/kelas").allowed)
+		assert.True(t, l.allow("user:a", "/kelas").allowed)
+	})
+}
+
+func TestRateLimiterRules(t *testing.T) {
+	def := RateLimit{Requests: 100, Per: time.Minute}
+	login := RateLimit{Requests: 3, Per: time.Minute}
+	lockout := RateLimit{Requests: 7, Per: time.Minute}
+	l := limiterUji(jamUji(), def,
+		RateLimitRule{Prefix: "/login", Limit: login},
+		RateLimitRule{Prefix: "/login/lockouts", Limit: lockout},
+		RateLimitRule{Prefix: "/siswa", Limit: RateLimit{Requests: 5, Per: time.Minute}},
+	)
+
+	tests := []struct {
+		path   string
+		prefix string
+		limit  int
+	}{
+		{"/login", "/login", 3},
+		{"/login/2fa", "/login", 3},
+		{"/login/lockouts", "/login/lockouts", 7},
+		{"/siswa/123", "/siswa", 5},
+		{"/siswax", "", 100},
+		{"/kelas", "", 100},
+		{"/", "", 100},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			prefix, limit := l.limitFor(tc.path)
+			assert.Equal(t, tc.prefix, prefix)
+			assert.Equal(t, tc.limit, limit.Requests)
+		})
+	}
+
+	t.Run("bucket login terpisah dari endpoint lain", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.True(t, l.allow("ip:9", "/login").allowed)
+		}
+		assert.False(t, l.allow("ip:9", "/login/2fa").allowed)
+		assert.True(t, l.allow("ip:9", "/kelas").allowed)
+	})
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	jam := jamUji()
+	l := limiterUji(jam, RateLimit{Requests: 10, Per: 10 * time.Minute},
+		RateLimitRule{Prefix: "/login", Limit: RateLimit{Requests: 5, Per: 30 * time.Second}})
+
+	l.allow("ip:a", "/kelas")
+	l.allow("ip:b", "/login")
+	assert.Len(t, l.buckets, 2)
+
+	// Belum satu menit sejak sweep terakhir: tidak ada yang dibersihkan
+	jam.maju(45 * time.Second)
+	l.allow("ip:c", "/kelas")
+	assert.Len(t, l.buckets, 3)
+
+	// Bucket login sudah menganggur lebih lama dari periodenya, bucket default belum
+	jam.maju(20 * time.Second)
+	l.allow("ip:c", "/kelas")
+	assert.Len(t, l.buckets, 2)
+	assert.Contains(t, l.buckets, "|ip:a")
+	assert.NotContains(t, l.buckets, "/login|ip:b")
+
+	// Setelah periode default lewat, semua bucket yang menganggur ikut dibersihkan
+	jam.maju(10 * time.Minute)
+	l.allow("ip:d", "/kelas")
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "|ip:d")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	jam := jamUji()
+	l := limiterUji(jam, RateLimit{Requests: 2, Per: time.Minute})
+	handler := RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), l)
+
+	kirim := func(forwarded string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/kelas", nil)
+		r.RemoteAddr = "203.0.113.7:40000"
+		r.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := kirim("1.1.1.1")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("X-RateLimit-Reset"))
+
+	// X-Forwarded-For yang diputar client tanpa proxy tepercaya tidak menghasilkan bucket baru
+	assert.Equal(t, http.StatusNoContent, kirim("2.2.2.2").Code)
+	w = kirim("3.3.3.3")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "|ip:203.0.113.7")
+
+	jam.maju(30 * time.Second)
+	assert.Equal(t, http.StatusNoContent, kirim("4.4.4.4").Code)
+}
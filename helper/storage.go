@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go_rest_native_sekolah/config"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrObjectNotFound dikembalikan Storage jika objek dengan key tersebut tidak ada.
+var ErrObjectNotFound = errors.New("storage: objek tidak ditemukan")
+
+// Storage menyimpan isi file lampiran berdasarkan key, misalnya "siswa/<id>/<id_lampiran>.jpg".
+// Key dibuat oleh aplikasi dan selalu memakai pemisah "/".
+type Storage interface {
+	// Put menyimpan data dengan key. Objek lama dengan key yang sama ditimpa.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get membuka objek untuk dibaca. Pemanggil wajib menutup reader yang dikembalikan.
+	// Mengembalikan ErrObjectNotFound jika objek tidak ada.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete menghapus objek. Objek yang sudah tidak ada tidak dianggap error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorage membuat Storage sesuai cfg.Driver (local atau s3).
+func NewStorage(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStorage(cfg.LocalDir)
+	case "s3":
+		return NewS3Storage(cfg)
+	}
+	return nil, fmt.Errorf("storage: driver tidak dikenal: %q", cfg.Driver)
+}
+
+// LocalStorage menyimpan objek sebagai file di bawah satu folder di filesystem lokal.
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage membuat LocalStorage di folder dir dan membuat folder tersebut jika belum ada.
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("storage: gagal membuat folder %s: %w", dir, err)
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+// path mengubah key menjadi path file dan menolak key yang keluar dari folder penyimpanan.
+func (l *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("storage: key tidak valid: %q", key)
+	}
+	return filepath.Join(l.dir, clean), nil
+}
+
+// Put implements Storage.
+// File ditulis ke file sementara lalu di-rename agar pembaca tidak pernah melihat file setengah jadi.
+func (l *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("storage: gagal membuat folder: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("storage: gagal membuat file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, bytes.NewReader(data)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("storage: gagal menulis file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("storage: gagal menulis file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("storage: gagal menyimpan file: %w", err)
+	}
+	return nil
+}
+
+// Get implements Storage.
+func (l *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storage: gagal membuka file: %w", err)
+	}
+	return file, nil
+}
+
+// Delete implements Storage.
+func (l *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("storage: gagal menghapus file: %w", err)
+	}
+	return nil
+}
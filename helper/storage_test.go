@@ -0,0 +1,264 @@
+package helper
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"go_rest_native_sekolah/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waktuS3 adalah waktu tetap yang dipakai untuk tanda tangan request S3 selama pengujian.
+var waktuS3 = time.Date(2026, 8, 3, 7, 0, 0, 0, time.UTC)
+
+// s3Palsu meniru bucket S3 dengan path-style URL dan menolak request yang tanda tangannya salah.
+type s3Palsu struct {
+	t         *testing.T
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	gagal     int // Jika tidak nol, semua request dijawab dengan status ini
+
+	mu     sync.Mutex
+	objek  map[string][]byte
+	metode []string
+}
+
+func (s *s3Palsu) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metode = append(s.metode, r.Method)
+
+	if err := s.verifikasi(r, body); err != nil {
+		s.t.Errorf("tanda tangan %s %s tidak valid: %v", r.Method, r.URL.Path, err)
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+	if s.gagal != 0 {
+		http.Error(w, "<Error><Code>InternalError</Code></Error>", s.gagal)
+		return
+	}
+
+	prefix := "/" + s.bucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.Error(w, "<Error><Code>NoSuchBucket</Code></Error>", http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	switch r.Method {
+	case http.MethodPut:
+		s.objek[key] = body
+	case http.MethodGet:
+		data, ok := s.objek[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(s.objek, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// verifikasi menghitung ulang tanda tangan AWS Signature Version 4 dari request yang diterima server.
+func (s *s3Palsu) verifikasi(r *http.Request, body []byte) error {
+	payloadHash := sha256Hex(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != payloadHash {
+		return errors.New("x-amz-content-sha256 tidak sesuai isi body")
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if amzDate != waktuS3.Format("20060102T150405Z") {
+		return errors.New("x-amz-date tidak sesuai")
+	}
+
+	auth := r.Header.Get("Authorization")
+	scope := waktuS3.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+	prefix := "AWS4-HMAC-SHA256 Credential=" + s.accessKey + "/" + scope + ", SignedHeaders="
+	if !strings.HasPrefix(auth, prefix) {
+		return errors.New("credential tidak sesuai: " + auth)
+	}
+	signedHeaders, signature, ok := strings.Cut(strings.TrimPrefix(auth, prefix), ", Signature=")
+	if !ok {
+		return errors.New("signature tidak ada")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, h := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(h)
+		if h == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + value + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method, r.URL.EscapedPath(), r.URL.RawQuery, canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, bagian := range []string{waktuS3.Format("20060102"), s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, bagian)
+	}
+	if want := hex.EncodeToString(hmacSHA256(key, stringToSign)); signature != want {
+		return errors.New("signature berbeda")
+	}
+	return nil
+}
+
+// newS3Uji menjalankan s3Palsu dan membuat S3Storage yang mengarah ke sana.
+func newS3Uji(t *testing.T) (*S3Storage, *s3Palsu) {
+	t.Helper()
+	palsu := &s3Palsu{t: t, bucket: "sekolah", region: "ap-southeast-3", accessKey: "AKIAUJI", secretKey: "rahasia", objek: map[string][]byte{}}
+	server := httptest.NewServer(palsu)
+	t.Cleanup(server.Close)
+
+	storage, err := NewS3Storage(config.StorageConfig{
+		S3Endpoint: server.URL + "/", S3Region: palsu.region, S3Bucket: palsu.bucket,
+		S3AccessKey: palsu.accessKey, S3SecretKey: palsu.secretKey,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	storage.now = func() time.Time { return waktuS3 }
+	return storage, palsu
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("put, get, lalu delete dengan request bertanda tangan", func(t *testing.T) {
+		storage, palsu := newS3Uji(t)
+
+		assert.NoError(t, storage.Put(ctx, "siswa/s-1/l-1.jpg", []byte("isi gambar"), "image/jpeg"))
+		assert.Equal(t, []byte("isi gambar"), palsu.objek["siswa/s-1/l-1.jpg"])
+
+		reader, err := storage.Get(ctx, "siswa/s-1/l-1.jpg")
+		if assert.NoError(t, err) {
+			data, _ := io.ReadAll(reader)
+			reader.Close()
+			assert.Equal(t, "isi gambar", string(data))
+		}
+
+		assert.NoError(t, storage.Delete(ctx, "siswa/s-1/l-1.jpg"))
+		assert.Empty(t, palsu.objek)
+		assert.Equal(t, []string{http.MethodPut, http.MethodGet, http.MethodDelete}, palsu.metode)
+	})
+
+	t.Run("get objek yang tidak ada mengembalikan ErrObjectNotFound", func(t *testing.T) {
+		storage, _ := newS3Uji(t)
+
+		reader, err := storage.Get(ctx, "siswa/s-1/tidak-ada.jpg")
+
+		assert.ErrorIs(t, err, ErrObjectNotFound)
+		assert.Nil(t, reader)
+	})
+
+	t.Run("delete objek yang tidak ada bukan error", func(t *testing.T) {
+		storage, palsu := newS3Uji(t)
+
+		assert.NoError(t, storage.Delete(ctx, "siswa/s-1/tidak-ada.jpg"))
+		assert.Equal(t, []string{http.MethodDelete}, palsu.metode)
+	})
+
+	t.Run("status gagal dari S3 dikembalikan sebagai error", func(t *testing.T) {
+		storage, palsu := newS3Uji(t)
+		palsu.gagal = http.StatusInternalServerError
+
+		err := storage.Put(ctx, "siswa/s-1/l-1.jpg", []byte("isi"), "image/jpeg")
+		assert.ErrorContains(t, err, "S3 menjawab 500")
+		assert.ErrorContains(t, err, "InternalError")
+
+		_, err = storage.Get(ctx, "siswa/s-1/l-1.jpg")
+		assert.ErrorContains(t, err, "S3 menjawab 500")
+		assert.NotErrorIs(t, err, ErrObjectNotFound)
+
+		assert.ErrorContains(t, storage.Delete(ctx, "siswa/s-1/l-1.jpg"), "S3 menjawab 500")
+	})
+
+	t.Run("endpoint tidak valid ditolak", func(t *testing.T) {
+		_, err := NewS3Storage(config.StorageConfig{S3Endpoint: "minio:9000"})
+		assert.ErrorContains(t, err, "S3_ENDPOINT tidak valid")
+	})
+}
+
+func TestS3StorageSign(t *testing.T) {
+	// Tanda tangan pembanding dihitung terpisah mengikuti spesifikasi AWS Signature Version 4
+	storage := &S3Storage{region: "us-east-1", accessKey: "AKIAUJI", secretKey: "rahasia", now: func() time.Time { return waktuS3 }}
+	req := httptest.NewRequest(http.MethodPut, "http://minio.local:9000/sekolah/siswa/s-1/l-1.jpg", strings.NewReader("halo"))
+	req.Header.Set("Content-Type", "image/jpeg")
+
+	storage.sign(req, []byte("halo"))
+
+	assert.Equal(t, "20260803T070000Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, sha256Hex([]byte("halo")), req.Header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIAUJI/20260803/us-east-1/s3/aws4_request, "+
+		"SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, "+
+		"Signature=e96bbb463a315956ed242b04806513ff0a0b24363761c03a952fbd5be1e13c05", req.Header.Get("Authorization"))
+}
+
+func TestLocalStoragePath(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewLocalStorage(dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	for _, key := range []string{"", "..", "../rahasia.txt", "siswa/../../rahasia.txt", "/etc/passwd"} {
+		t.Run("key "+key+" ditolak", func(t *testing.T) {
+			_, err := storage.path(key)
+			assert.ErrorContains(t, err, "key tidak valid")
+
+			assert.Error(t, storage.Put(context.Background(), key, []byte("isi"), "text/plain"))
+			_, err = storage.Get(context.Background(), key)
+			assert.ErrorContains(t, err, "key tidak valid")
+			assert.ErrorContains(t, storage.Delete(context.Background(), key), "key tidak valid")
+		})
+	}
+
+	t.Run("key di dalam folder diterima", func(t *testing.T) {
+		path, err := storage.path("siswa/s-1/../s-2/l-1.jpg")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "siswa", "s-2", "l-1.jpg"), path)
+	})
+
+	// Tidak ada file yang tertulis di luar folder penyimpanan
+	_, err = os.Stat(filepath.Join(filepath.Dir(dir), "rahasia.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	storage, err := NewLocalStorage(filepath.Join(t.TempDir(), "uploads"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, storage.Put(ctx, "siswa/s-1/l-1.pdf", []byte("%PDF"), "application/pdf"))
+
+	reader, err := storage.Get(ctx, "siswa/s-1/l-1.pdf")
+	if assert.NoError(t, err) {
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		assert.Equal(t, "%PDF", string(data))
+	}
+
+	assert.NoError(t, storage.Delete(ctx, "siswa/s-1/l-1.pdf"))
+	assert.NoError(t, storage.Delete(ctx, "siswa/s-1/l-1.pdf"))
+	_, err = storage.Get(ctx, "siswa/s-1/l-1.pdf")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
@@ -0,0 +1,82 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Daftarkan decoder PNG dan GIF untuk image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// thumbnailQuality adalah kualitas JPEG untuk thumbnail.
+const thumbnailQuality = 80
+
+// ErrImageTooLarge dikembalikan MakeThumbnail jika jumlah piksel gambar melebihi batas.
+var ErrImageTooLarge = errors.New("dimensi gambar terlalu besar")
+
+// MakeThumbnail memperkecil gambar JPEG, PNG, atau GIF sehingga sisi terpanjangnya paling besar maxSize piksel
+// dan mengembalikannya sebagai JPEG. Gambar yang sudah lebih kecil tidak diperbesar.
+// Setiap piksel thumbnail adalah rata-rata piksel gambar asli yang tercakup (box filter),
+// dan bagian transparan diberi latar putih karena JPEG tidak mendukung transparansi.
+//
+// Dimensi gambar dibaca dari header lebih dulu. Gambar dengan lebar x tinggi di atas maxPixels ditolak
+// sebelum di-decode, karena file kecil yang terkompresi bisa berisi gambar raksasa yang menghabiskan memori.
+// maxPixels 0 atau negatif berarti tanpa batas.
+func MakeThumbnail(data []byte, maxSize, maxPixels int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("gambar tidak dapat dibaca: %w", err)
+	}
+	if maxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > int64(maxPixels) {
+		return nil, fmt.Errorf("%w: %dx%d piksel, maksimal %d piksel", ErrImageTooLarge, cfg.Width, cfg.Height, maxPixels)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("gambar tidak dapat dibaca: %w", err)
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil, fmt.Errorf("gambar kosong")
+	}
+	tw, th := w, h
+	if w > maxSize || h > maxSize {
+		if w >= h {
+			tw, th = maxSize, max(1, h*maxSize/w)
+		} else {
+			tw, th = max(1, w*maxSize/h), maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := bounds.Min.Y+y*h/th, bounds.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := bounds.Min.X+x*w/tw, bounds.Min.X+max((x+1)*w/tw, x*w/tw+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			// Warna dari RGBA() sudah premultiplied, jadi bagian transparan tinggal ditambah latar putih
+			bg := 0xffff - a/n
+			dst.Set(x, y, color.RGBA64{uint16(r/n + bg), uint16(g/n + bg), uint16(b/n + bg), 0xffff})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("gagal membuat thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
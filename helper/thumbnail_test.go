@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pngPolos membuat gambar PNG satu warna berukuran w x h.
+func pngPolos(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: 10, G: 120, B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pngRaksasa mengubah header IHDR sebuah PNG kecil sehingga mengaku berukuran w x h.
+// Data piksel tidak ikut diubah, sehingga file tetap kecil seperti bom dekompresi.
+func pngRaksasa(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	data := pngPolos(t, 1, 1)
+	// Signature 8 byte, lalu chunk IHDR: panjang (4), tipe (4), data (13), CRC (4)
+	ihdr := data[8+4 : 8+4+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:8], w)
+	binary.BigEndian.PutUint32(ihdr[8:12], h)
+	binary.BigEndian.PutUint32(data[8+4+4+13:], crc32.ChecksumIEEE(ihdr))
+	return data
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	t.Run("success - sisi terpanjang diperkecil", func(t *testing.T) {
+		thumb, err := MakeThumbnail(pngPolos(t, 400, 100), 64, 1<<20)
+
+		assert.NoError(t, err)
+		img, err := jpeg.Decode(bytes.NewReader(thumb))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Pt(64, 16), img.Bounds().Size())
+	})
+
+	t.Run("success - gambar kecil tidak diperbesar", func(t *testing.T) {
+		thumb, err := MakeThumbnail(pngPolos(t, 30, 20), 64, 0)
+
+		assert.NoError(t, err)
+		img, err := jpeg.Decode(bytes.NewReader(thumb))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Pt(30, 20), img.Bounds().Size())
+	})
+
+	t.Run("error - dimensi raksasa ditolak sebelum decode", func(t *testing.T) {
+		data := pngRaksasa(t, 20000, 20000)
+		cfg, err := png.DecodeConfig(bytes.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, 20000, cfg.Width)
+		assert.Less(t, len(data), 1024)
+
+		thumb, err := MakeThumbnail(data, 64, 25_000_000)
+
+		assert.ErrorIs(t, err, ErrImageTooLarge)
+		assert.ErrorContains(t, err, "20000x20000 piksel")
+		assert.Nil(t, thumb)
+	})
+
+	t.Run("error - batas piksel tepat terlampaui", func(t *testing.T) {
+		_, err := MakeThumbnail(pngPolos(t, 10, 10), 64, 99)
+		assert.ErrorIs(t, err, ErrImageTooLarge)
+
+		_, err = MakeThumbnail(pngPolos(t, 10, 10), 64, 100)
+		assert.NoError(t, err)
+	})
+
+	t.Run("error - bukan gambar", func(t *testing.T) {
+		_, err := MakeThumbnail([]byte("bukan gambar"), 64, 0)
+		assert.ErrorContains(t, err, "gambar tidak dapat dibaca")
+	})
+}
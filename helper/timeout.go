@@ -0,0 +1,22 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// QueryTimeoutMiddleware memberi batas waktu pada context setiap request.
+// Controller meneruskan r.Context() sampai ke query pgx, sehingga query dibatalkan
+// ketika batas waktu ini habis atau ketika client memutus koneksi.
+// Nilai timeout nol atau negatif berarti tanpa batas tambahan.
+func QueryTimeoutMiddleware(next http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,115 @@
+package helper
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+// databaseMacet membuka listener yang menerima koneksi tetapi tidak pernah menjawab,
+// sehingga setiap query pgx menggantung sampai context-nya berakhir.
+func databaseMacet(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	selesai := make(chan struct{})
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+			close(selesai)
+		}()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		<-selesai
+	})
+
+	// connect_timeout sengaja panjang agar yang menghentikan query hanya deadline dari context
+	pool, err := pgxpool.New(context.Background(), "postgres://uji:uji@"+listener.Addr().String()+"/uji?connect_timeout=30&sslmode=disable")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestQueryTimeoutMiddleware(t *testing.T) {
+	t.Run("query yang melebihi batas waktu dibatalkan", func(t *testing.T) {
+		pool := databaseMacet(t)
+		var queryErr error
+		var elapsed time.Duration
+		handler := QueryTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			var n int
+			queryErr = pool.QueryRow(r.Context(), "SELECT 1").Scan(&n)
+			elapsed = time.Since(start)
+			if queryErr != nil {
+				w.WriteHeader(http.StatusGatewayTimeout)
+			}
+		}), 50*time.Millisecond)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/siswa", nil))
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.ErrorIs(t, queryErr, context.DeadlineExceeded)
+		assert.Less(t, elapsed, 5*time.Second)
+	})
+
+	t.Run("deadline diteruskan ke context handler", func(t *testing.T) {
+		var deadline time.Time
+		var ok bool
+		handler := QueryTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok = r.Context().Deadline()
+		}), time.Minute)
+
+		start := time.Now()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/siswa", nil))
+
+		assert.True(t, ok)
+		assert.WithinDuration(t, start.Add(time.Minute), deadline, time.Second)
+	})
+
+	t.Run("client memutus koneksi membatalkan query", func(t *testing.T) {
+		pool := databaseMacet(t)
+		var queryErr error
+		handler := QueryTimeoutMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var n int
+			queryErr = pool.QueryRow(r.Context(), "SELECT 1").Scan(&n)
+		}), time.Minute)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/siswa", nil).WithContext(ctx))
+
+		assert.ErrorIs(t, queryErr, context.Canceled)
+	})
+
+	t.Run("timeout nol tidak menambah deadline", func(t *testing.T) {
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := r.Context().Deadline()
+			assert.False(t, ok)
+		})
+		handler := QueryTimeoutMiddleware(next, 0)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/siswa", nil))
+	})
+}
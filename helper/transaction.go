@@ -0,0 +1,94 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBTX adalah method query yang dimiliki *pgxpool.Pool maupun pgx.Tx.
+// Repository menerima DBTX sehingga bisa dipakai langsung dengan pool
+// atau di dalam transaksi milik UnitOfWork.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// UnitOfWork menjalankan beberapa repository dalam satu transaksi database.
+// R adalah kumpulan repository yang dibuat ulang di atas transaksi tersebut,
+// misalnya sebuah struct berisi repository guru dan users.
+type UnitOfWork[R any] interface {
+	// Do menjalankan fn di dalam satu transaksi. Transaksi di-commit jika fn
+	// mengembalikan nil, dan di-rollback jika fn mengembalikan error atau panic.
+	Do(ctx context.Context, fn func(repos R) error) error
+}
+
+// pgxUnitOfWork adalah UnitOfWork di atas pgx.Tx.
+type pgxUnitOfWork[R any] struct {
+	db    *pgxpool.Pool
+	repos func(tx DBTX) R
+}
+
+// NewUnitOfWork membuat UnitOfWork yang memulai transaksi dari db.
+// Parameter repos membuat repository yang memakai transaksi tersebut.
+// Jika parameter db atau repos nil maka akan terjadi panic.
+func NewUnitOfWork[R any](db *pgxpool.Pool, repos func(tx DBTX) R) UnitOfWork[R] {
+	if db == nil || repos == nil {
+		panic("unit of work: Nil database or repository factory")
+	}
+	return &pgxUnitOfWork[R]{db: db, repos: repos}
+}
+
+// Do implements UnitOfWork.
+func (u *pgxUnitOfWork[R]) Do(ctx context.Context, fn func(repos R) error) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaksi: %w", err)
+	}
+
+	// Rollback tetap dijalankan walaupun context request sudah dibatalkan,
+	// dan panic dari fn tidak meninggalkan transaksi terbuka.
+	rollback := func() {
+		if err := tx.Rollback(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			LoggerFromContext(ctx).Error("Gagal rollback transaksi", "error", err)
+		}
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(u.repos(tx)); err != nil {
+		rollback()
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("gagal commit transaksi: %w", err)
+	}
+	return nil
+}
+
+// joinedUnitOfWork adalah UnitOfWork yang memakai transaksi yang sedang berjalan.
+type joinedUnitOfWork[R any] struct {
+	repos R
+}
+
+// JoinUnitOfWork membuat UnitOfWork yang menjalankan fn langsung dengan repos
+// tanpa memulai transaksi baru. Dipakai saat method service yang memakai UnitOfWork
+// dipanggil dari dalam transaksi lain, misalnya pada operasi bulk.
+func JoinUnitOfWork[R any](repos R) UnitOfWork[R] {
+	return joinedUnitOfWork[R]{repos: repos}
+}
+
+// Do implements UnitOfWork.
+func (u joinedUnitOfWork[R]) Do(ctx context.Context, fn func(repos R) error) error {
+	return fn(u.repos)
+}
@@ -5,13 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"io"
-	"log"
 	"net/http"
 	"os"
 	"strings"
 	"time"
-
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // TransactionLog merepresentasikan data log transaksi yang disimpan di database.
@@ -26,6 +23,7 @@ type TransactionLog struct {
 	RequestParam string    `json:"request_param"`
 	Result       string    `json:"result"`
 	Header       string    `json:"header"`
+	RequestID    string    `json:"request_id"`
 }
 
 // responseCategory adalah ResponseWriter custom untuk menangkap response body & status
@@ -46,7 +44,10 @@ func (rw *responseCategory) WriteHeader(status int) {
 }
 
 func (rw *responseCategory) Write(b []byte) (int, error) {
-	*rw.body = append(*rw.body, b...)
+	// Isi file (gambar, PDF) tidak disimpan ke log, hanya response teks dan JSON
+	if isTextContent(rw.Header().Get("Content-Type")) {
+		*rw.body = append(*rw.body, b...)
+	}
 	// default status jika belum pernah ditulis
 	if !rw.wrote {
 		rw.WriteHeader(http.StatusOK)
@@ -54,6 +55,11 @@ func (rw *responseCategory) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
+// isTextContent bernilai true jika content type kosong, JSON, atau teks sehingga body layak disimpan ke log.
+func isTextContent(contentType string) bool {
+	return contentType == "" || strings.HasPrefix(contentType, "text/") || strings.Contains(contentType, "json")
+}
+
 // GetServiceNameFromEndpoint digunakan untuk memberi nama service berdasarkan endpoint
 func GetServiceNameFromEndpoint(endpoint string) string {
 	endpoint = strings.Split(endpoint, "?")[0]
@@ -67,19 +73,28 @@ func GetServiceNameFromEndpoint(endpoint string) string {
 	return ""
 }
 
+// auditUserKey adalah key context untuk penampung ID user yang dicatat di transaction_logs.
+type auditUserKey struct{}
+
+// SetAuditUserID mencatat ID user pada log transaksi request ini.
+// Dipakai endpoint tanpa token seperti login setelah user berhasil dikenali,
+// sehingga LoggingMiddleware tidak perlu mencari user ke database.
+func SetAuditUserID(ctx context.Context, userID string) {
+	if holder, ok := ctx.Value(auditUserKey{}).(*string); ok {
+		*holder = userID
+	}
+}
+
 // LoggingMiddleware mengumpulkan data transaksi setiap request
-func LoggingMiddleware(next http.Handler, db *pgxpool.Pool) http.Handler {
+// Log tidak ditulis langsung, melainkan dimasukkan ke antrean writer yang menulis per batch.
+// ID user diambil dari token JWT, atau dari SetAuditUserID untuk request tanpa token.
+func LoggingMiddleware(next http.Handler, writer *AuditWriter) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var requestBody []byte
 		var responseBody []byte
 
-		if db == nil {
-			http.Error(w, "DB pool belum diinisialisasi", http.StatusInternalServerError)
-			return
-		}
-
-		// baca request body
-		if r.Body != nil {
+		// baca request body; upload multipart tidak disimpan karena berisi file
+		if r.Body != nil && !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
 			bodyBytes, err := io.ReadAll(r.Body)
 			if err == nil {
 				requestBody = bodyBytes
@@ -87,18 +102,16 @@ func LoggingMiddleware(next http.Handler, db *pgxpool.Pool) http.Handler {
 			}
 		}
 
-		// Ambil userID dari token / body
+		// Ambil userID dari token
 		authHeader := r.Header.Get("Authorization")
 		accessToken := GetTokenFromAuthorizationHeader(authHeader)
 
 		var userID string
 		if accessToken != "" {
-			token, err := VerifyToken(accessToken)
-			if err != nil {
+			if _, err := VerifyToken(accessToken); err != nil {
 				http.Error(w, "Token tidak valid", http.StatusUnauthorized)
 				return
 			}
-			log.Println("Token:", token)
 
 			metaToken, err := VerifyTokenHeader(accessToken)
 			if err != nil {
@@ -106,17 +119,8 @@ func LoggingMiddleware(next http.Handler, db *pgxpool.Pool) http.Handler {
 				return
 			}
 			userID = metaToken.ID
-		} else {
-			var bodyMap map[string]interface{}
-			if err := json.Unmarshal(requestBody, &bodyMap); err == nil {
-				if email, ok := bodyMap["email"].(string); ok {
-					uid, err := GetUserIDByEmail(db, email)
-					if err == nil {
-						userID = uid
-					}
-				}
-			}
 		}
+		r = r.WithContext(context.WithValue(r.Context(), auditUserKey{}, &userID))
 
 		// ResponseWriter custom
 		responseWriter := &responseCategory{
@@ -128,84 +132,128 @@ func LoggingMiddleware(next http.Handler, db *pgxpool.Pool) http.Handler {
 		// Jalankan handler berikutnya
 		next.ServeHTTP(responseWriter, r)
 
-		// Simpan log di background
-		go func() {
-			perangkat, _ := os.Hostname()
+		// Susun log lalu serahkan ke antrean AuditWriter agar request tidak menunggu database
+		writer.Enqueue(buildTransactionLog(r, userID, requestBody, responseBody, responseWriter.statusCode))
+	})
+}
 
-			paramJSON, _ := json.Marshal(r.URL.Query())
-			headerJSON, _ := json.Marshal(r.Header)
+// buildTransactionLog menyusun satu baris transaction_logs dari request dan response.
+// Semua kolom JSONB dijamin berisi JSON valid dan field sensitif sudah disamarkan.
+func buildTransactionLog(r *http.Request, userID string, requestBody, responseBody []byte, statusCode int) TransactionLog {
+	perangkat, _ := os.Hostname()
 
-			// masking request body sensitif
-			requestStr := "{}"
-			if len(requestBody) > 0 {
-				requestStr = maskSensitiveData(string(requestBody))
-			}
+	paramJSON, _ := json.Marshal(r.URL.Query())
+	headerJSON, _ := json.Marshal(maskSensitiveHeaders(r.Header))
 
-			// response body → pastikan JSON valid
-			responseStr := "{}"
-			if json.Valid(responseBody) {
-				responseStr = string(responseBody)
-			} else if len(responseBody) > 0 {
-				tmp, _ := json.Marshal(string(responseBody))
-				responseStr = string(tmp)
-			}
+	resultStatus := "Success"
+	if statusCode >= 400 {
+		resultStatus = "Failed"
+	}
 
-			resultStatus := "Success"
-			if responseWriter.statusCode >= 400 {
-				resultStatus = "Failed"
-			}
+	return TransactionLog{
+		Timestamp:    time.Now(),
+		UserID:       userID,
+		Perangkat:    perangkat,
+		ServiceName:  GetServiceNameFromEndpoint(r.RequestURI),
+		RequestBody:  toJSONColumn(requestBody, requestSensitiveFields(r)),
+		ResponseBody: toJSONColumn(responseBody, nil),
+		RequestParam: string(paramJSON),
+		Result:       resultStatus,
+		Header:       string(headerJSON),
+		RequestID:    RequestIDFromContext(r.Context()),
+	}
+}
 
-			_, err := db.Exec(
-				context.Background(),
-				`INSERT INTO transaction_logs 
-					(timestamp, user_id, perangkat, service_name, request_body, response_body, request_param, result, header)
-				VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, $7::jsonb, $8, $9::jsonb)`,
-				time.Now(),
-				userID,
-				perangkat,
-				GetServiceNameFromEndpoint(r.RequestURI),
-				requestStr,
-				responseStr,
-				string(paramJSON),
-				resultStatus,
-				string(headerJSON),
-			)
-			if err != nil {
-				log.Println("[ERROR] Gagal simpan log:", err)
-			}
-		}()
-	})
+// requestSensitiveFields mengembalikan field tambahan yang disamarkan pada body request r.
+func requestSensitiveFields(r *http.Request) map[string]bool {
+	if twoFactorCodePaths[r.URL.Path] {
+		return twoFactorCodeField
+	}
+	return nil
 }
 
-// GetUserIDByEmail ambil ID user berdasarkan email
-func GetUserIDByEmail(db *pgxpool.Pool, email string) (string, error) {
-	var userID string
-	query := "SELECT id FROM users WHERE email = $1"
-	err := db.QueryRow(context.Background(), query, email).Scan(&userID)
-	if err != nil {
-		return "", err
+// toJSONColumn mengubah body menjadi JSON valid untuk kolom JSONB dengan field sensitif disamarkan.
+// Selain sensitiveFields, field pada extra juga disamarkan.
+// Body kosong menjadi "{}" dan body yang bukan JSON disimpan sebagai string JSON.
+func toJSONColumn(body []byte, extra map[string]bool) string {
+	if len(body) == 0 {
+		return "{}"
 	}
-	return userID, nil
+	if !json.Valid(body) {
+		tmp, _ := json.Marshal(string(body))
+		return string(tmp)
+	}
+	return maskSensitiveData(string(body), extra)
+}
+
+// sensitiveFields adalah field JSON yang nilainya tidak boleh tersimpan di log,
+// baik di request maupun response (termasuk yang bersarang di dalam "data").
+var sensitiveFields = map[string]bool{
+	"password":        true,
+	"access_token":    true,
+	"refresh_token":   true,
+	"token":           true,
+	"challenge_token": true,
+	"secret":          true,
+	"otpauth_uri":     true,
+	"recovery_codes":  true,
+}
+
+// twoFactorCodePaths adalah endpoint 2FA yang body request-nya berisi kode TOTP atau kode pemulihan
+// di field "code". Field itu hanya disamarkan di sini agar field "code" pada response API (status HTTP) tetap tercatat.
+var twoFactorCodePaths = map[string]bool{
+	"/login/2fa":   true,
+	"/2fa/confirm": true,
+	"/2fa/disable": true,
 }
 
+// twoFactorCodeField adalah field kode 2FA yang disamarkan pada request twoFactorCodePaths.
+var twoFactorCodeField = map[string]bool{"code": true}
+
+// maskedValue adalah pengganti nilai field sensitif di log.
+const maskedValue = "***MASKED***"
+
 // maskSensitiveData → sembunyikan field sensitif
-func maskSensitiveData(data string) string {
-	var bodyMap map[string]interface{}
-	if err := json.Unmarshal([]byte(data), &bodyMap); err != nil {
+// Nilai disamarkan dengan string tetap, bukan di-hash, agar tidak membebani setiap request.
+func maskSensitiveData(data string, extra map[string]bool) string {
+	var body interface{}
+	if err := json.Unmarshal([]byte(data), &body); err != nil {
 		return data
 	}
 
-	sensitiveFields := []string{"password", "access_token", "refresh_token"}
-	for _, field := range sensitiveFields {
-		if val, ok := bodyMap[field].(string); ok {
-			if field == "password" {
-				bodyMap[field] = HashPassword(val)
+	maskedData, _ := json.Marshal(maskValue(body, extra))
+	return string(maskedData)
+}
+
+// maskValue menyamarkan field sensitif dan field extra secara rekursif pada object dan array JSON.
+func maskValue(value interface{}, extra map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if name := strings.ToLower(key); sensitiveFields[name] || extra[name] {
+				v[key] = maskedValue
 			} else {
-				bodyMap[field] = "***MASKED***"
+				v[key] = maskValue(field, extra)
 			}
 		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = maskValue(item, extra)
+		}
+		return v
+	default:
+		return v
 	}
+}
 
-	hashedData, _ := json.Marshal(bodyMap)
-	return string(hashedData)
+// maskSensitiveHeaders menyalin header request dengan nilai kredensial disamarkan.
+func maskSensitiveHeaders(header http.Header) http.Header {
+	masked := header.Clone()
+	for _, key := range []string{"Authorization", "Cookie"} {
+		if masked.Get(key) != "" {
+			masked.Set(key, maskedValue)
+		}
+	}
+	return masked
 }
@@ -0,0 +1,128 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"go_rest_native_sekolah/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// copierLog mencatat setiap baris transaction_logs yang ditulis AuditWriter.
+type copierLog struct {
+	mu    sync.Mutex
+	baris []map[string]interface{}
+}
+
+func (c *copierLog) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	var n int64
+	for rowSrc.Next() {
+		values, err := rowSrc.Values()
+		if err != nil {
+			return n, err
+		}
+		row := make(map[string]interface{}, len(columnNames))
+		for i, kolom := range columnNames {
+			row[kolom] = values[i]
+		}
+		c.mu.Lock()
+		c.baris = append(c.baris, row)
+		c.mu.Unlock()
+		n++
+	}
+	return n, nil
+}
+
+// jalankanLogging mengirim satu request melalui LoggingMiddleware lalu mengembalikan baris log yang ditulis.
+func jalankanLogging(t *testing.T, next http.Handler, req *http.Request) map[string]interface{} {
+	t.Helper()
+	copier := &copierLog{}
+	writer := NewAuditWriter(copier, config.AuditConfig{QueueSize: 10, BatchSize: 1, FlushInterval: time.Hour})
+
+	LoggingMiddleware(next, writer).ServeHTTP(httptest.NewRecorder(), req)
+	assert.NoError(t, writer.Close(context.Background()))
+
+	if !assert.Len(t, copier.baris, 1) {
+		t.FailNow()
+	}
+	return copier.baris[0]
+}
+
+func TestLoggingMiddlewareUserID(t *testing.T) {
+	t.Run("user diambil dari token tanpa query database", func(t *testing.T) {
+		InitToken(config.AuthConfig{JWTSecret: "rahasia-uji", AccessTokenTTL: time.Minute})
+		t.Cleanup(func() { InitToken(config.AuthConfig{}) })
+
+		token, _, err := SignToken(map[string]interface{}{"id": "user-001", "role": "admin"})
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/siswa", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		row := jalankanLogging(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), req)
+
+		assert.Equal(t, "user-001", row["user_id"])
+	})
+
+	t.Run("request tanpa token memakai SetAuditUserID dari handler", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			SetAuditUserID(r.Context(), "user-002")
+		})
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"a@b.com","password":"rahasia"}`))
+		row := jalankanLogging(t, handler, req)
+
+		assert.Equal(t, "user-002", row["user_id"])
+	})
+
+	t.Run("login gagal tidak mencatat user", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"a@b.com","password":"salah"}`))
+		row := jalankanLogging(t, handler, req)
+
+		assert.Equal(t, "", row["user_id"])
+		assert.Equal(t, "Failed", row["result"])
+	})
+
+	t.Run("SetAuditUserID di luar LoggingMiddleware diabaikan", func(t *testing.T) {
+		assert.NotPanics(t, func() { SetAuditUserID(context.Background(), "user-003") })
+	})
+}
+
+func TestBuildTransactionLogMasking(t *testing.T) {
+	responseBody := []byte(`{"code":200,"message":"ok","data":{"token":"abc"}}`)
+
+	t.Run("code pada response API tetap tercatat", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		entry := buildTransactionLog(req, "", []byte(`{"email":"a@b.com","password":"rahasia","code":"x"}`), responseBody, http.StatusOK)
+
+		var request, response map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(entry.RequestBody), &request))
+		assert.NoError(t, json.Unmarshal([]byte(entry.ResponseBody), &response))
+		assert.Equal(t, maskedValue, request["password"])
+		assert.Equal(t, "x", request["code"])
+		assert.Equal(t, float64(200), response["code"])
+		assert.Equal(t, maskedValue, response["data"].(map[string]interface{})["token"])
+	})
+
+	for _, path := range []string{"/login/2fa", "/2fa/confirm", "/2fa/disable"} {
+		t.Run("kode TOTP pada request "+path+" disamarkan", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			entry := buildTransactionLog(req, "", []byte(`{"challenge_token":"t","code":"123456"}`), responseBody, http.StatusOK)
+
+			var request, response map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(entry.RequestBody), &request))
+			assert.NoError(t, json.Unmarshal([]byte(entry.ResponseBody), &response))
+			assert.Equal(t, maskedValue, request["code"])
+			assert.Equal(t, maskedValue, request["challenge_token"])
+			assert.Equal(t, float64(200), response["code"])
+		})
+	}
+}
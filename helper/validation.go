@@ -0,0 +1,73 @@
+package helper
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DateLayout adalah format tanggal (tanpa jam) yang diterima dan dikembalikan API, misalnya tanggal lahir.
+const DateLayout = "2006-01-02"
+
+// TimeLayout adalah format jam (tanpa tanggal) yang diterima dan dikembalikan API, misalnya jam mulai sesi.
+const TimeLayout = "15:04"
+
+// phoneRegex memvalidasi nomor telepon setelah spasi dan tanda hubung dibuang.
+var phoneRegex = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+
+// NormalizePhone membuang spasi dan tanda hubung dari nomor telepon lalu memvalidasi formatnya
+// (8-15 digit, boleh diawali +). Nilai kosong dikembalikan apa adanya.
+// Parameter field dipakai sebagai nama field pada pesan validation error.
+func NormalizePhone(field, value string) (string, error) {
+	value = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(value))
+	if value != "" && !phoneRegex.MatchString(value) {
+		return "", fmt.Errorf("validation error: %s harus 8-15 digit angka", field)
+	}
+	return value, nil
+}
+
+// NormalizeDate merapikan tanggal berformat DateLayout dan mengembalikan validation error
+// jika formatnya salah. Jika notFuture true, tanggal setelah hari ini juga ditolak.
+// Nilai kosong dikembalikan apa adanya.
+func NormalizeDate(field, value string, notFuture bool) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return "", fmt.Errorf("validation error: %s harus berformat YYYY-MM-DD", field)
+	}
+	if notFuture && date.After(time.Now()) {
+		return "", fmt.Errorf("validation error: %s tidak boleh di masa depan", field)
+	}
+	return value, nil
+}
+
+// ParseRequiredDate membaca tanggal berformat DateLayout yang wajib diisi.
+// Parameter field dipakai sebagai nama field pada pesan validation error.
+func ParseRequiredDate(field, value string) (time.Time, error) {
+	value, err := NormalizeDate(field, value, false)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if value == "" {
+		return time.Time{}, fmt.Errorf("validation error: %s harus diisi", field)
+	}
+	return time.Parse(DateLayout, value)
+}
+
+// ParseRequiredTime membaca jam berformat TimeLayout (HH:MM) yang wajib diisi.
+// Parameter field dipakai sebagai nama field pada pesan validation error.
+func ParseRequiredTime(field, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("validation error: %s harus diisi", field)
+	}
+	t, err := time.Parse(TimeLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("validation error: %s harus berformat HH:MM", field)
+	}
+	return t, nil
+}
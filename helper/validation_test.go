@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRequiredDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		err   string
+	}{
+		{" 2026-10-19 ", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), ""},
+		{"", time.Time{}, "validation error: tanggal harus diisi"},
+		{"19-10-2026", time.Time{}, "validation error: tanggal harus berformat YYYY-MM-DD"},
+		{"2026-02-30", time.Time{}, "validation error: tanggal harus berformat YYYY-MM-DD"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseRequiredDate("tanggal", tc.value)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseRequiredTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		err   string
+	}{
+		{"07:30", "07:30", ""},
+		{" 7:05 ", "07:05", ""},
+		{"", "", "validation error: jam_mulai harus diisi"},
+		{"24:00", "", "validation error: jam_mulai harus berformat HH:MM"},
+		{"07.30", "", "validation error: jam_mulai harus berformat HH:MM"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := ParseRequiredTime("jam_mulai", tc.value)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got.Format(TimeLayout))
+		})
+	}
+}
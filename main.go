@@ -1,44 +1,112 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"go_rest_native_sekolah/config"
+	"go_rest_native_sekolah/helper"
 	"go_rest_native_sekolah/router"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	log.Println("[INFO] 🚀 Aplikasi dimulai...")
+	slog.Info("Aplikasi dimulai")
 
-	// Load environment variables
-	config.LoadEnv()
+	// Muat dan validasi seluruh konfigurasi satu kali saat startup
+	cfg, err := config.Load()
 
-	// Ambil PORT dari environment
-	port := os.Getenv("PORT")
-	if port == "" {
-		// PORT tidak ditemukan di environment variable
-		log.Fatal("[FATAL] ❌ PORT tidak ditemukan di environment variable")
+	// Logger terstruktur sesuai LOG_LEVEL dan LOG_FORMAT
+	helper.InitLogger(cfg.Log)
+	if err != nil {
+		// Konfigurasi wajib kosong atau tidak valid, hentikan aplikasi sebelum menerima request
+		slog.Error("Konfigurasi tidak valid", "error", err)
+		os.Exit(1)
 	}
 
+	// Secret dan masa berlaku token JWT
+	helper.InitToken(cfg.Auth)
+
+	// Reverse proxy tepercaya yang boleh mengisi X-Forwarded-For
+	if err := helper.InitTrustedProxies(cfg.Server); err != nil {
+		slog.Error("Konfigurasi proxy tidak valid", "error", err)
+		os.Exit(1)
+	}
+	serverConfig := cfg.Server
+
 	// Inisialisasi koneksi database
-	db, err := config.InitPostgreSQLPool()
+	db, err := config.InitPostgreSQLPool(cfg.Database)
 	if err != nil {
-		log.Fatalf("[FATAL] ❌ Gagal terhubung ke database: %v", err)
+		slog.Error("Gagal terhubung ke database", "error", err)
+		os.Exit(1)
 	}
 	defer func() {
 		db.Close()
-		log.Println("[INFO] ✅ Koneksi database berhasil ditutup")
+		slog.Info("Koneksi database berhasil ditutup")
 	}()
 
+	// Writer log transaksi yang menulis ke transaction_logs per batch
+	auditWriter := helper.NewAuditWriter(db, cfg.Audit)
+
+	// Penyimpanan file lampiran (folder lokal atau bucket S3)
+	storage, err := helper.NewStorage(cfg.Storage)
+	if err != nil {
+		slog.Error("Gagal menyiapkan penyimpanan file", "error", err)
+		os.Exit(1)
+	}
+
 	// Router
-	header := router.InitRouter(db)
+	header := router.InitRouter(db, auditWriter, storage, cfg)
 
-	// Log port
-	log.Printf("[INFO] 🌐 Server berjalan di http://localhost:%s", port)
+	// Server dengan timeout agar client lambat tidak menahan koneksi selamanya
+	server := &http.Server{
+		Addr:         ":" + serverConfig.Port,
+		Handler:      header,
+		ReadTimeout:  serverConfig.ReadTimeout,
+		WriteTimeout: serverConfig.WriteTimeout,
+		IdleTimeout:  serverConfig.IdleTimeout,
+	}
+
+	// Context yang dibatalkan ketika menerima SIGINT atau SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Jalankan server di goroutine terpisah agar main bisa menunggu sinyal shutdown
+	serverErr := make(chan error, 1)
+	go func() {
+		// Log port
+		slog.Info("Server berjalan", "addr", "http://localhost:"+serverConfig.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		// Jangan pakai log.Fatal agar defer penutupan database tetap dijalankan
+		slog.Error("Gagal menjalankan server", "error", err)
+		auditWriter.Close(context.Background())
+		return
+	case <-ctx.Done():
+		slog.Info("Sinyal shutdown diterima, menunggu request yang sedang berjalan")
+	}
+
+	// Batas waktu total untuk menyelesaikan request dan penyimpanan log
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+
+	// Berhenti menerima koneksi baru dan tunggu request yang sedang berjalan
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Server tidak berhenti dengan bersih", "error", err)
+	}
 
-	// Jalankan server
-	if err := http.ListenAndServe(":"+port, header); err != nil {
-		log.Fatalf("[FATAL] ❌ Gagal menjalankan server: %v", err)
+	// Tulis sisa antrean transaction_logs sebelum pool database ditutup
+	if err := auditWriter.Close(shutdownCtx); err != nil {
+		slog.Warn("Sebagian log transaksi belum tersimpan", "error", err)
 	}
+	stats := auditWriter.Stats()
+	slog.Info("Ringkasan log transaksi", "written", stats.Written, "dropped", stats.Dropped, "failed", stats.Failed)
 }
@@ -1,53 +1,159 @@
 package router
 
 import (
+	"go_rest_native_sekolah/config"
+	"go_rest_native_sekolah/features/auth"
 	authcontroller "go_rest_native_sekolah/features/auth/controllers"
 	authmodels "go_rest_native_sekolah/features/auth/model"
 	serviceauth "go_rest_native_sekolah/features/auth/service"
+	"go_rest_native_sekolah/features/guru"
 	gurucontroller "go_rest_native_sekolah/features/guru/controllers"
 	gurumodels "go_rest_native_sekolah/features/guru/model"
 	"go_rest_native_sekolah/features/guru/service"
+	izinguru "go_rest_native_sekolah/features/izin_guru"
+	izincontroller "go_rest_native_sekolah/features/izin_guru/controllers"
+	izinmodels "go_rest_native_sekolah/features/izin_guru/model"
+	serviceizin "go_rest_native_sekolah/features/izin_guru/service"
+	jadwalmengajar "go_rest_native_sekolah/features/jadwal_mengajar"
+	jadwalcontroller "go_rest_native_sekolah/features/jadwal_mengajar/controllers"
+	jadwalmodels "go_rest_native_sekolah/features/jadwal_mengajar/model"
+	servicejadwal "go_rest_native_sekolah/features/jadwal_mengajar/service"
+	"go_rest_native_sekolah/features/kalender"
+	kalendercontroller "go_rest_native_sekolah/features/kalender/controllers"
+	kalendermodels "go_rest_native_sekolah/features/kalender/model"
+	servicekalender "go_rest_native_sekolah/features/kalender/service"
+	"go_rest_native_sekolah/features/kelas"
 	kelascontroller "go_rest_native_sekolah/features/kelas/controllers"
 	kelasmodels "go_rest_native_sekolah/features/kelas/model"
 	servicekelas "go_rest_native_sekolah/features/kelas/service"
+	lampirancontroller "go_rest_native_sekolah/features/lampiran/controllers"
+	lampiranmodels "go_rest_native_sekolah/features/lampiran/model"
+	servicelampiran "go_rest_native_sekolah/features/lampiran/service"
+	matapelajaran "go_rest_native_sekolah/features/mata_pelajaran"
 	mapelcontroller "go_rest_native_sekolah/features/mata_pelajaran/controllers"
 	mapelsmodels "go_rest_native_sekolah/features/mata_pelajaran/model"
 	servicemapel "go_rest_native_sekolah/features/mata_pelajaran/service"
+	"go_rest_native_sekolah/features/pengumuman"
+	pengumumancontroller "go_rest_native_sekolah/features/pengumuman/controllers"
+	pengumumanmodels "go_rest_native_sekolah/features/pengumuman/model"
+	servicepengumuman "go_rest_native_sekolah/features/pengumuman/service"
+	"go_rest_native_sekolah/features/siswa"
 	siswacontroller "go_rest_native_sekolah/features/siswa/controllers"
 	siswamodels "go_rest_native_sekolah/features/siswa/model"
 	servicesiswa "go_rest_native_sekolah/features/siswa/service"
+	"go_rest_native_sekolah/features/tugas"
+	tugascontroller "go_rest_native_sekolah/features/tugas/controllers"
+	tugasmodels "go_rest_native_sekolah/features/tugas/model"
+	servicetugas "go_rest_native_sekolah/features/tugas/service"
+	"go_rest_native_sekolah/features/ujian"
+	ujiancontroller "go_rest_native_sekolah/features/ujian/controllers"
+	ujianmodels "go_rest_native_sekolah/features/ujian/model"
+	serviceujian "go_rest_native_sekolah/features/ujian/service"
 	userscontroller "go_rest_native_sekolah/features/users/controllers"
 	usersmodels "go_rest_native_sekolah/features/users/model"
 	serviceuser "go_rest_native_sekolah/features/users/service"
 
 	"go_rest_native_sekolah/helper"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // InitRouter digunakan untuk menginisialisasi router.
 // Fungsi ini akan menginisialisasi router untuk fitur auth, guru, users, dan kelas.
-func InitRouter(db *pgxpool.Pool) http.Handler {
+// Log transaksi setiap request dikirim ke auditWriter untuk ditulis per batch.
+// Isi file lampiran disimpan di storage.
+// Query database milik satu request dibatalkan setelah cfg.Server.QueryTimeout berlalu.
+func InitRouter(db *pgxpool.Pool, auditWriter *helper.AuditWriter, storage helper.Storage, cfg config.Config) http.Handler {
 	mux := http.NewServeMux()
 
+	// Penyimpanan Idempotency-Key untuk endpoint POST create agar retry tidak membuat data ganda
+	idempotency := helper.NewIdempotencyStore(db, cfg.Idempotency)
+
 	// Pasang semua route
 	// Endpoint /login digunakan untuk mengotentikasi user
 	loginRouter(mux, db)
 	// Endpoint /guru digunakan untuk mengelola data guru
-	guruRouter(mux, db)
+	guruRouter(mux, db, helper.DeletePolicy(cfg.DeletePolicy.Guru), guru.BatasBebanMengajar{
+		MinJam: cfg.BebanMengajar.MinJam,
+		MaxJam: cfg.BebanMengajar.MaxJam,
+	}, idempotency)
 	// Endpoint /users digunakan untuk mengelola data user
-	usersRouter(mux, db)
+	usersRouter(mux, db, idempotency)
 	// Endpoint /kelas digunakan untuk mengelola data kelas
-	kelasRouter(mux, db)
+	kelasRouter(mux, db, helper.DeletePolicy(cfg.DeletePolicy.Kelas), idempotency)
 	// Endpoint /siswa digunakan untuk mengelola data siswa
-	siswaRouter(mux, db)
+	siswaRouter(mux, db, idempotency)
 	// Endpoint /mapel digunakan untuk mengelola data mata pelajaran
-	mataPelajaranRouter(mux, db)
+	mataPelajaranRouter(mux, db, idempotency)
+	// Endpoint /lampiran digunakan untuk mengunggah dan mengunduh foto serta dokumen
+	lampiranRouter(mux, db, storage, cfg.Storage)
+	// Endpoint /pengumuman dan /me/pengumuman digunakan untuk mengelola dan membaca pengumuman sekolah
+	pengumumanRouter(mux, db, idempotency)
+	// Endpoint /tugas dan /me/tugas digunakan untuk tugas, pengumpulan siswa, dan penilaiannya
+	tugasRouter(mux, db, storage, cfg.Storage, idempotency)
+	// Endpoint /kalender digunakan untuk kalender akademik, feed iCalendar, dan hari efektif sekolah
+	kalenderRouter(mux, db, cfg.Kalender, idempotency)
+	// Endpoint /ujian digunakan untuk jadwal UTS/UAS, ruang, pengawas, dan denah tempat duduk
+	ujianRouter(mux, db, idempotency)
+	// Endpoint /jadwal-mengajar digunakan untuk jadwal mengajar mingguan setiap kelas
+	jadwalMengajarRouter(mux, db, cfg.Kalender, idempotency)
+	// Endpoint /izin-guru digunakan untuk izin guru, persetujuan admin, dan pencatatan guru pengganti
+	izinGuruRouter(mux, db, cfg.Kalender, idempotency)
+
+	// Batasi lama query database setiap request
+	// Context request diteruskan sampai ke pgx sehingga query berhenti saat timeout atau client disconnect
+	handler := helper.QueryTimeoutMiddleware(mux, cfg.Server.QueryTimeout)
 
-	// Bungkus mux dengan middleware logging
+	// Bungkus dengan middleware logging
 	// Middleware logging digunakan untuk mencatat setiap request yang diterima oleh server
-	return helper.LoggingMiddleware(mux, db)
+	handler = helper.LoggingMiddleware(handler, auditWriter)
+
+	// Bungkus dengan rate limiter
+	// Request yang ditolak tidak diteruskan ke logging sehingga tidak membebani database
+	handler = helper.RateLimitMiddleware(handler, newRateLimiter(cfg.RateLimit))
+
+	// Endpoint operasional dipasang di luar rate limiter dan logging transaksi
+	// agar pengecekan load balancer dan scrape Prometheus tidak ditolak atau memenuhi transaction_logs
+	metrics := helper.NewMetrics()
+	root := http.NewServeMux()
+	root.HandleFunc("/healthz", helper.HealthzHandler)
+	root.HandleFunc("/readyz", helper.ReadyzHandler(db))
+	root.HandleFunc("/metrics", helper.MetricsHandler(metrics, db, auditWriter))
+	root.Handle("/", handler)
+
+	// Bungkus dengan middleware metrics agar semua response, termasuk 429, ikut tercatat
+	handler = helper.MetricsMiddleware(root, metrics, func(r *http.Request) string {
+		// Gunakan pola route yang terdaftar, bukan path mentah, sebagai label
+		if _, pattern := mux.Handler(r); pattern != "" {
+			return pattern
+		}
+		if _, pattern := root.Handler(r); pattern != "/" {
+			return pattern
+		}
+		return ""
+	})
+
+	// Bungkus paling luar dengan request ID agar semua log, termasuk transaction_logs, membawa ID yang sama
+	return helper.RequestIDMiddleware(handler)
+}
+
+// newRateLimiter membuat rate limiter dengan batas bawaan dan batas khusus per route.
+// Batas dapat diubah lewat konfigurasi dengan format "<jumlah>/<durasi>", misalnya "120/1m".
+func newRateLimiter(cfg config.RateLimitConfig) *helper.RateLimiter {
+	// Batas bawaan untuk semua endpoint
+	defaultLimit := helper.RateLimitOrDefault("RATE_LIMIT_DEFAULT", cfg.Default, helper.RateLimit{Requests: 120, Per: time.Minute})
+	// Endpoint login lebih ketat untuk menahan percobaan kredensial massal
+	loginLimit := helper.RateLimitOrDefault("RATE_LIMIT_LOGIN", cfg.Login, helper.RateLimit{Requests: 10, Per: time.Minute})
+	// Endpoint siswa mengembalikan data paling besar sehingga dibatasi tersendiri
+	siswaLimit := helper.RateLimitOrDefault("RATE_LIMIT_SISWA", cfg.Siswa, helper.RateLimit{Requests: 60, Per: time.Minute})
+
+	return helper.NewRateLimiter(defaultLimit,
+		helper.RateLimitRule{Prefix: "/login", Limit: loginLimit},
+		helper.RateLimitRule{Prefix: "/siswa", Limit: siswaLimit},
+	)
 }
 
 // loginRouter digunakan untuk menginisialisasi router untuk fitur auth.
@@ -57,7 +163,7 @@ func loginRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 	// Inisialisasi repository
 	authRepo := authmodels.NewAuthData(db)
 	// Inisialisasi service
-	authService := serviceauth.NewServiceAuth(authRepo)
+	authService := serviceauth.NewServiceAuth(authRepo, auth.DefaultLockoutPolicy())
 	// Inisialisasi controller
 	authController := authcontroller.NewAutController(authService)
 	// Membuat handler untuk endpoint /login
@@ -74,14 +180,90 @@ func loginRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 	})
+
+	// Endpoint POST untuk langkah kedua login bagi user yang mengaktifkan 2FA
+	mux.HandleFunc("/login/2fa", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			err := authController.VerifyTwoFactor(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	})
+
+	// Endpoint POST untuk memulai pendaftaran 2FA (admin dan guru)
+	mux.HandleFunc("/2fa/enroll", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			err := authController.EnrollTOTP(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}, auth.TwoFactorRoles...))
+
+	// Endpoint POST untuk mengonfirmasi dan mengaktifkan 2FA (admin dan guru)
+	mux.HandleFunc("/2fa/confirm", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			err := authController.ConfirmTOTP(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}, auth.TwoFactorRoles...))
+
+	// Endpoint POST untuk menonaktifkan 2FA (admin dan guru)
+	mux.HandleFunc("/2fa/disable", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			err := authController.DisableTOTP(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}, auth.TwoFactorRoles...))
+
+	// Endpoint GET untuk melihat daftar email/IP yang gagal login atau sedang dikunci (khusus admin)
+	mux.HandleFunc("/login/lockouts", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			err := authController.Lockouts(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}, "admin"))
+
+	// Endpoint DELETE untuk melepas penguncian login satu email/IP (khusus admin)
+	mux.HandleFunc("/login/lockouts/clear", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			err := authController.ClearLockout(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}, "admin"))
 }
 
-func guruRouter(mux *http.ServeMux, db *pgxpool.Pool) {
+func guruRouter(mux *http.ServeMux, db *pgxpool.Pool, deletePolicy helper.DeletePolicy, batas guru.BatasBebanMengajar, idempotency *helper.IdempotencyStore) {
 	// Inisialisasi repository
 	guruRepo := gurumodels.NewDataGuru(db)
 
 	// Inisialisasi service
-	guruService := service.NewServiceGuru(guruRepo, db)
+	// Pembuatan guru sekaligus akun users dijalankan dalam satu transaksi
+	guruUow := helper.NewUnitOfWork(db, func(tx helper.DBTX) guru.Repositories {
+		return guru.Repositories{Guru: gurumodels.NewDataGuru(tx), Users: usersmodels.NewUserData(tx)}
+	})
+	guruService := service.NewServiceGuru(guruRepo, guruUow, deletePolicy, batas)
 
 	// Inisialisasi controller
 	guruController := gurucontroller.NewGuruController(guruService)
@@ -99,7 +281,7 @@ func guruRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 	}))
 
 	// Endpoint POST untuk menambah data guru
-	mux.HandleFunc("/guru/tambah", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/guru/tambah", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			err := guruController.InsertGuru(w, r)
 			if err != nil {
@@ -108,7 +290,7 @@ func guruRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 		} else {
 			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
-	}))
+	}, idempotency)))
 
 	mux.HandleFunc("/guru/update", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost || r.Method == http.MethodPut {
@@ -147,9 +329,57 @@ func guruRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 	}))
+
+	// Endpoint GET dry-run untuk melihat data yang terdampak jika guru dihapus
+	mux.HandleFunc("/guru/deleted/preview", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			err := guruController.PreviewDeleteGuru(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}))
+
+	// Endpoint GET untuk rekap beban mengajar seluruh guru
+	mux.HandleFunc("/guru/beban-mengajar", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			err := guruController.RekapBebanMengajar(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}))
+
+	// Endpoint GET untuk beban mengajar satu guru
+	mux.HandleFunc("/guru/{id}/beban-mengajar", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			err := guruController.GetBebanMengajarGuru(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}))
+
+	// Endpoint POST untuk menjalankan banyak operasi create, update, dan delete guru sekaligus
+	mux.HandleFunc("/guru/bulk", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			err := guruController.BulkGuru(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}, idempotency)))
 }
 
-func usersRouter(mux *http.ServeMux, db *pgxpool.Pool) {
+func usersRouter(mux *http.ServeMux, db *pgxpool.Pool, idempotency *helper.IdempotencyStore) {
 	usersRepo := usersmodels.NewUserData(db)
 	usersService := serviceuser.NewServiceUser(usersRepo, db)
 	usersController := userscontroller.NewUsesController(usersService)
@@ -165,7 +395,7 @@ func usersRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 		}
 	}))
 
-	mux.HandleFunc("/users/tambah", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/users/tambah", helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			err := usersController.InsertUser(w, r)
 			if err != nil {
@@ -174,7 +404,7 @@ func usersRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 		} else {
 			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
-	})
+	}, idempotency))
 
 	mux.HandleFunc("/users/userbyid", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet || r.Method == http.MethodPut {
@@ -216,12 +446,15 @@ func usersRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 	}))
 }
 
-func kelasRouter(mux *http.ServeMux, db *pgxpool.Pool) {
+func kelasRouter(mux *http.ServeMux, db *pgxpool.Pool, deletePolicy helper.DeletePolicy, idempotency *helper.IdempotencyStore) {
 	kelasRepo := kelasmodels.NewDataKelas(db)
-	kelasService := servicekelas.NewServiceKelas(kelasRepo)
+	kelasUow := helper.NewUnitOfWork(db, func(tx helper.DBTX) kelas.DataKelasInterface {
+		return kelasmodels.NewDataKelas(tx)
+	})
+	kelasService := servicekelas.NewServiceKelas(kelasRepo, kelasUow, deletePolicy)
 	kelasController := kelascontroller.NewKelasController(kelasService)
 
-	mux.HandleFunc("/kelas/tambah", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/kelas/tambah", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			err := kelasController.Insert(w, r)
 			if err != nil {
@@ -231,7 +464,7 @@ func kelasRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 
-	}))
+	}, idempotency)))
 
 	mux.HandleFunc("/kelas", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
@@ -282,15 +515,54 @@ func kelasRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 	}))
+
+	// Endpoint GET untuk mengambil detail kelas beserta wali kelas, siswa, dan mata pelajaran
+	mux.HandleFunc("/kelas/{id}/detail", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			err := kelasController.GetKelasDetail(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}))
+
+	// Endpoint GET dry-run untuk melihat data yang terdampak jika kelas dihapus
+	mux.HandleFunc("/kelas/deleted/preview", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			err := kelasController.PreviewDeleteKelas(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}))
+
+	// Endpoint POST untuk menjalankan banyak operasi create, update, dan delete kelas sekaligus
+	mux.HandleFunc("/kelas/bulk", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			err := kelasController.BulkKelas(w, r)
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		} else {
+			helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}, idempotency)))
 }
 
-func siswaRouter(mux *http.ServeMux, db *pgxpool.Pool) {
+func siswaRouter(mux *http.ServeMux, db *pgxpool.Pool, idempotency *helper.IdempotencyStore) {
 	{
 		siswaRepo := siswamodels.NewSiswaData(db)
-		siswaService := servicesiswa.NewServiceSiswa(siswaRepo)
+		siswaUow := helper.NewUnitOfWork(db, func(tx helper.DBTX) siswa.DataSiswaInterface {
+			return siswamodels.NewSiswaData(tx)
+		})
+		siswaService := servicesiswa.NewServiceSiswa(siswaRepo, siswaUow)
 		siswaController := siswacontroller.NewSiswaController(siswaService)
 
-		mux.HandleFunc("/siswa/tambah", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		mux.HandleFunc("/siswa/tambah", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodPost {
 				err := siswaController.InsertSiswa(w, r)
 				if err != nil {
@@ -299,7 +571,7 @@ func siswaRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 			} else {
 				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 			}
-		}))
+		}, idempotency)))
 
 		mux.HandleFunc("/siswa", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodGet {
@@ -351,16 +623,31 @@ func siswaRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 			}
 		}))
 
+		// Endpoint POST untuk menjalankan banyak operasi create, update, dan delete siswa sekaligus
+		mux.HandleFunc("/siswa/bulk", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := siswaController.BulkSiswa(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency)))
+
 	}
 }
 
-func mataPelajaranRouter(mux *http.ServeMux, db *pgxpool.Pool) {
+func mataPelajaranRouter(mux *http.ServeMux, db *pgxpool.Pool, idempotency *helper.IdempotencyStore) {
 	{
 		mataPelajaranRepo := mapelsmodels.NewDataMataPelajaran(db)
-		mataPelajaranService := servicemapel.NewMataPelajaranService(mataPelajaranRepo)
+		mataPelajaranUow := helper.NewUnitOfWork(db, func(tx helper.DBTX) matapelajaran.DataMataPelajaranInterface {
+			return mapelsmodels.NewDataMataPelajaran(tx)
+		})
+		mataPelajaranService := servicemapel.NewMataPelajaranService(mataPelajaranRepo, mataPelajaranUow)
 		mataPelajaranController := mapelcontroller.NewMataPelajaranController(mataPelajaranService)
 
-		mux.HandleFunc("/mapel/tambah", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		mux.HandleFunc("/mapel/tambah", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodPost {
 				err := mataPelajaranController.InsertMapel(w, r)
 				if err != nil {
@@ -369,7 +656,7 @@ func mataPelajaranRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 			} else {
 				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 			}
-		}))
+		}, idempotency)))
 
 		mux.HandleFunc("/mapel", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method == http.MethodGet {
@@ -420,5 +707,753 @@ func mataPelajaranRouter(mux *http.ServeMux, db *pgxpool.Pool) {
 				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 			}
 		}))
+
+		// Endpoint POST untuk menjalankan banyak operasi create, update, dan delete mata pelajaran sekaligus
+		mux.HandleFunc("/mapel/bulk", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := mataPelajaranController.BulkMapel(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency)))
+
+		// Endpoint katalog mapel (kode, nama, deskripsi, kelompok) yang dipakai oleh penugasan mapel per kelas
+		mux.HandleFunc("/mapel/katalog", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := mataPelajaranController.Katalog(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		mux.HandleFunc("/mapel/katalog/tambah", helper.AuthMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := mataPelajaranController.InsertKatalog(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency)))
+
+		mux.HandleFunc("/mapel/katalog/{id}", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = mataPelajaranController.GetKatalogById(w, r)
+			case http.MethodPut:
+				err = mataPelajaranController.UpdateKatalog(w, r)
+			case http.MethodDelete:
+				err = mataPelajaranController.DeleteKatalog(w, r)
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}))
+	}
+}
+
+func lampiranRouter(mux *http.ServeMux, db *pgxpool.Pool, storage helper.Storage, cfg config.StorageConfig) {
+	{
+		lampiranRepo := lampiranmodels.NewDataLampiran(db)
+		lampiranService := servicelampiran.NewServiceLampiran(lampiranRepo, storage, cfg.MaxUploadBytes(), cfg.ThumbnailSize, cfg.MaxImagePixel)
+		lampiranController := lampirancontroller.NewLampiranController(lampiranService, cfg.MaxUploadBytes())
+
+		// Endpoint POST multipart untuk mengunggah file yang terhubung ke siswa, guru, atau user.
+		// Admin dan guru boleh mengunggah untuk siapa pun, user lain hanya untuk dirinya sendiri
+		mux.HandleFunc("/lampiran/upload", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := lampiranController.Upload(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		// Daftar, metadata, dan unduhan lampiran untuk admin, guru, atau pemiliknya;
+		// file soal tugas juga boleh diunduh siswa di kelas tugas tersebut
+		mux.HandleFunc("/lampiran", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := lampiranController.Lampiran(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		mux.HandleFunc("/lampiran/{id}/unduh", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := lampiranController.Unduh(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		// Hapus lampiran hanya untuk admin dan guru
+		mux.HandleFunc("/lampiran/{id}", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = lampiranController.GetLampiranById(w, r)
+			case http.MethodDelete:
+				if meta, _ := helper.MetaTokenFromContext(r.Context()); meta.Role != "admin" && meta.Role != "guru" {
+					helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, "Akses ditolak", nil))
+					return
+				}
+				err = lampiranController.DeleteLampiran(w, r)
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}))
+	}
+}
+
+func pengumumanRouter(mux *http.ServeMux, db *pgxpool.Pool, idempotency *helper.IdempotencyStore) {
+	{
+		pengumumanRepo := pengumumanmodels.NewDataPengumuman(db)
+		pengumumanUow := helper.NewUnitOfWork(db, func(tx helper.DBTX) pengumuman.DataPengumumanInterface {
+			return pengumumanmodels.NewDataPengumuman(tx)
+		})
+		pengumumanService := servicepengumuman.NewServicePengumuman(pengumumanRepo, pengumumanUow)
+		pengumumanController := pengumumancontroller.NewPengumumanController(pengumumanService)
+
+		// Pengelolaan pengumuman hanya untuk admin dan guru
+		mux.HandleFunc("/pengumuman", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := pengumumanController.Pengumuman(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, pengumuman.PembuatRoles...))
+
+		mux.HandleFunc("/pengumuman/tambah", helper.RoleMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := pengumumanController.InsertPengumuman(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency), pengumuman.PembuatRoles...))
+
+		// Guru hanya boleh mengubah dan menghapus pengumuman yang ia buat
+		mux.HandleFunc("/pengumuman/{id}", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = pengumumanController.GetPengumumanById(w, r)
+			case http.MethodPut:
+				err = pengumumanController.UpdatePengumuman(w, r)
+			case http.MethodDelete:
+				err = pengumumanController.DeletePengumuman(w, r)
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}, pengumuman.PembuatRoles...))
+
+		mux.HandleFunc("/pengumuman/{id}/pembaca", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := pengumumanController.Pembaca(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, pengumuman.PembuatRoles...))
+
+		// Feed pengumuman untuk semua user yang login, disaring berdasarkan role dan kelasnya
+		mux.HandleFunc("/me/pengumuman", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := pengumumanController.Feed(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		mux.HandleFunc("/me/pengumuman/{id}/baca", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := pengumumanController.TandaiDibaca(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+	}
+}
+
+func tugasRouter(mux *http.ServeMux, db *pgxpool.Pool, storage helper.Storage, cfg config.StorageConfig, idempotency *helper.IdempotencyStore) {
+	{
+		// File soal dan jawaban tugas disimpan sebagai lampiran
+		lampiranService := servicelampiran.NewServiceLampiran(lampiranmodels.NewDataLampiran(db), storage, cfg.MaxUploadBytes(), cfg.ThumbnailSize, cfg.MaxImagePixel)
+		tugasRepo := tugasmodels.NewDataTugas(db)
+		tugasService := servicetugas.NewServiceTugas(tugasRepo, lampiranService)
+		tugasController := tugascontroller.NewTugasController(tugasService, cfg.MaxUploadBytes())
+
+		// Pengelolaan dan penilaian tugas hanya untuk admin dan guru
+		mux.HandleFunc("/tugas", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := tugasController.Tugas(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, tugas.PengelolaRoles...))
+
+		mux.HandleFunc("/tugas/tambah", helper.RoleMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := tugasController.InsertTugas(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency), tugas.PengelolaRoles...))
+
+		mux.HandleFunc("/tugas/rekap-nilai", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := tugasController.RekapNilai(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, tugas.PengelolaRoles...))
+
+		// Guru hanya boleh mengubah dan menghapus tugas mata pelajaran yang ia ajar
+		mux.HandleFunc("/tugas/{id}", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = tugasController.GetTugasById(w, r)
+			case http.MethodPut:
+				err = tugasController.UpdateTugas(w, r)
+			case http.MethodDelete:
+				err = tugasController.DeleteTugas(w, r)
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}, tugas.PengelolaRoles...))
+
+		mux.HandleFunc("/tugas/{id}/pengumpulan", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := tugasController.Pengumpulan(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, tugas.PengelolaRoles...))
+
+		mux.HandleFunc("/tugas/pengumpulan/{id}/nilai", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				err := tugasController.BeriNilai(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, tugas.PengelolaRoles...))
+
+		// Pengumpulan tugas untuk siswa yang login; service menolak akun yang tidak terhubung ke siswa
+		mux.HandleFunc("/tugas/{id}/kumpul", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := tugasController.Kumpulkan(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		mux.HandleFunc("/me/tugas", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := tugasController.TugasSaya(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+	}
+}
+
+func kalenderRouter(mux *http.ServeMux, db *pgxpool.Pool, cfg config.KalenderConfig, idempotency *helper.IdempotencyStore) {
+	{
+		// HARI_SEKOLAH sudah divalidasi saat config.Load
+		hariSekolah, _ := cfg.Hari()
+		kalenderRepo := kalendermodels.NewDataKalender(db)
+		kalenderService := servicekalender.NewServiceKalender(kalenderRepo, hariSekolah)
+		kalenderController := kalendercontroller.NewKalenderController(kalenderService)
+
+		mux.HandleFunc("/kalender", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := kalenderController.Kalender(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		// Pengelolaan agenda kalender akademik hanya untuk admin
+		mux.HandleFunc("/kalender/tambah", helper.RoleMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := kalenderController.InsertKalender(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency), kalender.PengelolaRoles...))
+
+		mux.HandleFunc("/kalender/{id}", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = kalenderController.GetKalenderById(w, r)
+			case http.MethodPut, http.MethodDelete:
+				if meta, _ := helper.MetaTokenFromContext(r.Context()); !slices.Contains(kalender.PengelolaRoles, meta.Role) {
+					helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, "Akses ditolak", nil))
+					return
+				}
+				if r.Method == http.MethodPut {
+					err = kalenderController.UpdateKalender(w, r)
+				} else {
+					err = kalenderController.DeleteKalender(w, r)
+				}
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}))
+
+		// Perhitungan hari efektif untuk presensi, penjadwalan, dan laporan
+		mux.HandleFunc("/kalender/hari-efektif", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := kalenderController.HariEfektif(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		// Feed iCalendar (.ics) per kelas dan per guru untuk aplikasi kalender
+		mux.HandleFunc("/kalender/kelas/{id}/ical", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := kalenderController.ICalKelas(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		mux.HandleFunc("/kalender/guru/{id}/ical", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := kalenderController.ICalGuru(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+	}
+}
+
+// ujianRouter memasang endpoint penjadwalan ujian. Admin mengatur ruang, sesi, pengawas, dan denah;
+// guru dapat melihat jadwal, pengawas, dan denah tempat duduk.
+func ujianRouter(mux *http.ServeMux, db *pgxpool.Pool, idempotency *helper.IdempotencyStore) {
+	{
+		ujianRepo := ujianmodels.NewDataUjian(db)
+		ujianUow := helper.NewUnitOfWork(db, func(tx helper.DBTX) ujian.DataUjianInterface {
+			return ujianmodels.NewDataUjian(tx)
+		})
+		ujianService := serviceujian.NewServiceUjian(ujianRepo, ujianUow)
+		ujianController := ujiancontroller.NewUjianController(ujianService)
+
+		// Ruang ujian beserta kapasitasnya
+		mux.HandleFunc("/ujian/ruang", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := ujianController.Ruang(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, ujian.PembacaRoles...))
+
+		mux.HandleFunc("/ujian/ruang/tambah", helper.RoleMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := ujianController.InsertRuang(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency), ujian.PengelolaRoles...))
+
+		mux.HandleFunc("/ujian/ruang/{id}", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = ujianController.GetRuangById(w, r)
+			case http.MethodPut, http.MethodDelete:
+				if meta, _ := helper.MetaTokenFromContext(r.Context()); !slices.Contains(ujian.PengelolaRoles, meta.Role) {
+					helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, "Akses ditolak", nil))
+					return
+				}
+				if r.Method == http.MethodPut {
+					err = ujianController.UpdateRuang(w, r)
+				} else {
+					err = ujianController.DeleteRuang(w, r)
+				}
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}, ujian.PembacaRoles...))
+
+		// Periode ujian (UTS/UAS) beserta sesinya
+		mux.HandleFunc("/ujian", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := ujianController.Ujian(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, ujian.PembacaRoles...))
+
+		mux.HandleFunc("/ujian/tambah", helper.RoleMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := ujianController.InsertUjian(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency), ujian.PengelolaRoles...))
+
+		mux.HandleFunc("/ujian/{id}", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = ujianController.GetUjianById(w, r)
+			case http.MethodPut, http.MethodDelete:
+				if meta, _ := helper.MetaTokenFromContext(r.Context()); !slices.Contains(ujian.PengelolaRoles, meta.Role) {
+					helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, "Akses ditolak", nil))
+					return
+				}
+				if r.Method == http.MethodPut {
+					err = ujianController.UpdateUjian(w, r)
+				} else {
+					err = ujianController.DeleteUjian(w, r)
+				}
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}, ujian.PembacaRoles...))
+
+		mux.HandleFunc("/ujian/{id}/sesi/tambah", helper.RoleMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := ujianController.InsertSesi(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency), ujian.PengelolaRoles...))
+
+		mux.HandleFunc("/ujian/sesi/{id}", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = ujianController.GetSesi(w, r)
+			case http.MethodDelete:
+				if meta, _ := helper.MetaTokenFromContext(r.Context()); !slices.Contains(ujian.PengelolaRoles, meta.Role) {
+					helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, "Akses ditolak", nil))
+					return
+				}
+				err = ujianController.DeleteSesi(w, r)
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}, ujian.PembacaRoles...))
+
+		// Pengawas dipilih otomatis tanpa bentrok jam, atau diatur manual per ruang
+		mux.HandleFunc("/ujian/sesi/{id}/pengawas", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := ujianController.AturPengawas(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, ujian.PengelolaRoles...))
+
+		mux.HandleFunc("/ujian/sesi/{id}/ruang/{ruang_id}/pengawas", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				err := ujianController.SetPengawas(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, ujian.PengelolaRoles...))
+
+		// Denah tempat duduk acak; GET mendukung ?format=json|csv|pdf
+		mux.HandleFunc("/ujian/sesi/{id}/denah", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = ujianController.Denah(w, r)
+			case http.MethodPost:
+				if meta, _ := helper.MetaTokenFromContext(r.Context()); !slices.Contains(ujian.PengelolaRoles, meta.Role) {
+					helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, "Akses ditolak", nil))
+					return
+				}
+				err = ujianController.BuatDenah(w, r)
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}, ujian.PembacaRoles...))
+	}
+}
+
+func jadwalMengajarRouter(mux *http.ServeMux, db *pgxpool.Pool, cfg config.KalenderConfig, idempotency *helper.IdempotencyStore) {
+	{
+		// HARI_SEKOLAH sudah divalidasi saat config.Load
+		hariSekolah, _ := cfg.Hari()
+		jadwalRepo := jadwalmodels.NewDataJadwal(db)
+		jadwalService := servicejadwal.NewServiceJadwal(jadwalRepo, hariSekolah)
+		jadwalController := jadwalcontroller.NewJadwalController(jadwalService)
+
+		mux.HandleFunc("/jadwal-mengajar", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := jadwalController.Jadwal(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}))
+
+		// Pengelolaan slot jadwal mengajar hanya untuk admin
+		mux.HandleFunc("/jadwal-mengajar/tambah", helper.RoleMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := jadwalController.InsertJadwal(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency), jadwalmengajar.PengelolaRoles...))
+
+		mux.HandleFunc("/jadwal-mengajar/{id}", helper.AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = jadwalController.GetJadwalById(w, r)
+			case http.MethodDelete:
+				if meta, _ := helper.MetaTokenFromContext(r.Context()); !slices.Contains(jadwalmengajar.PengelolaRoles, meta.Role) {
+					helper.JSONResponse(w, http.StatusForbidden, helper.APIResponse(http.StatusForbidden, "Akses ditolak", nil))
+					return
+				}
+				err = jadwalController.DeleteJadwal(w, r)
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}))
+	}
+}
+
+func izinGuruRouter(mux *http.ServeMux, db *pgxpool.Pool, cfg config.KalenderConfig, idempotency *helper.IdempotencyStore) {
+	{
+		// Pertemuan yang ditinggalkan dihitung dari hari efektif kalender akademik
+		hariSekolah, _ := cfg.Hari()
+		kalenderService := servicekalender.NewServiceKalender(kalendermodels.NewDataKalender(db), hariSekolah)
+		izinRepo := izinmodels.NewDataIzin(db)
+		izinService := serviceizin.NewServiceIzin(izinRepo, kalenderService)
+		izinController := izincontroller.NewIzinController(izinService)
+
+		mux.HandleFunc("/izin-guru", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := izinController.Izin(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, izinguru.PengajuRoles...))
+
+		mux.HandleFunc("/izin-guru/tambah", helper.RoleMiddleware(helper.IdempotencyMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				err := izinController.InsertIzin(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, idempotency), izinguru.PengajuRoles...))
+
+		// Guru hanya boleh melihat dan membatalkan izinnya sendiri, diperiksa di service
+		mux.HandleFunc("/izin-guru/{id}", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			switch r.Method {
+			case http.MethodGet:
+				err = izinController.GetIzinById(w, r)
+			case http.MethodDelete:
+				err = izinController.DeleteIzin(w, r)
+			default:
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+				return
+			}
+			if err != nil {
+				helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+			}
+		}, izinguru.PengajuRoles...))
+
+		// Persetujuan izin dan pencarian guru pengganti hanya untuk admin
+		mux.HandleFunc("/izin-guru/{id}/keputusan", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				err := izinController.Putuskan(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, izinguru.PengelolaRoles...))
+
+		mux.HandleFunc("/izin-guru/{id}/sesi", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := izinController.Sesi(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, izinguru.PengelolaRoles...))
+
+		mux.HandleFunc("/izin-guru/{id}/sesi/kandidat", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := izinController.Kandidat(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, izinguru.PengelolaRoles...))
+
+		mux.HandleFunc("/izin-guru/{id}/pengganti", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				err := izinController.SetPengganti(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, izinguru.PengelolaRoles...))
+
+		// Rekap pertemuan yang digantikan, misalnya untuk honor guru pengganti
+		mux.HandleFunc("/izin-guru/pengganti", helper.RoleMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				err := izinController.Pengganti(w, r)
+				if err != nil {
+					helper.JSONResponse(w, http.StatusInternalServerError, err.Error())
+				}
+			} else {
+				helper.JSONResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+			}
+		}, izinguru.PengajuRoles...))
 	}
 }